@@ -21,6 +21,7 @@ let package = Package(
   products: [
     .library(name: "GRPC", targets: ["GRPC"]),
     .library(name: "CGRPCZlib", targets: ["CGRPCZlib"]),
+    .library(name: "GRPCTestingSupport", targets: ["GRPCTestingSupport"]),
     .executable(name: "protoc-gen-grpc-swift", targets: ["protoc-gen-grpc-swift"]),
   ],
   dependencies: [
@@ -40,7 +41,7 @@ let package = Package(
     .package(
       name: "SwiftProtobuf",
       url: "https://github.com/apple/swift-protobuf.git",
-      from: "1.9.0"
+      from: "1.14.0"
     ),
 
     // Logging API.
@@ -74,6 +75,7 @@ let package = Package(
         .target(name: "EchoModel"),
         .target(name: "EchoImplementation"),
         .target(name: "GRPCSampleData"),
+        .target(name: "GRPCTestingSupport"),
         .target(name: "GRPCInteroperabilityTestsImplementation"),
         .target(name: "HelloWorldModel"),
       ]
@@ -153,6 +155,17 @@ let package = Package(
       ]
     ),
 
+    // Utilities for testing services.
+    .target(
+      name: "GRPCTestingSupport",
+      dependencies: [
+        .target(name: "GRPC"),
+        .product(name: "NIO", package: "swift-nio"),
+        .product(name: "NIOConcurrencyHelpers", package: "swift-nio"),
+        .product(name: "SwiftProtobuf", package: "SwiftProtobuf"),
+      ]
+    ),
+
     // Echo example CLI.
     .target(
       name: "Echo",
@@ -236,6 +236,8 @@ public protocol Grpc_Testing_BenchmarkServiceProvider: CallHandlerProvider {
 extension Grpc_Testing_BenchmarkServiceProvider {
   public var serviceName: Substring { return "grpc.testing.BenchmarkService" }
 
+  public var methodNames: [Substring] { return ["UnaryCall", "StreamingCall", "StreamingFromClient", "StreamingFromServer", "StreamingBothWays"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -292,6 +294,16 @@ extension Grpc_Testing_BenchmarkServiceProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'grpc.testing.BenchmarkService' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'grpc.testing.BenchmarkService' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Grpc_Testing_BenchmarkServiceServerInterceptorFactoryProtocol {
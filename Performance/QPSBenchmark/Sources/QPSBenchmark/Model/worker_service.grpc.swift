@@ -212,6 +212,8 @@ public protocol Grpc_Testing_WorkerServiceProvider: CallHandlerProvider {
 extension Grpc_Testing_WorkerServiceProvider {
   public var serviceName: Substring { return "grpc.testing.WorkerService" }
 
+  public var methodNames: [Substring] { return ["RunServer", "RunClient", "CoreCount", "QuitWorker"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -259,6 +261,16 @@ extension Grpc_Testing_WorkerServiceProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'grpc.testing.WorkerService' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'grpc.testing.WorkerService' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Grpc_Testing_WorkerServiceServerInterceptorFactoryProtocol {
@@ -37,7 +37,7 @@ class LoggingEchoClientInterceptor: ClientInterceptor<Echo_EchoRequest, Echo_Ech
     // The (user-provided) request headers, we send these at the start of each RPC. They will be
     // augmented with transport specific headers once the request part reaches the transport.
     case let .metadata(headers):
-      print("> Starting '\(context.path)' RPC, headers:", prettify(headers))
+      print("> Starting '\(context.path)' RPC, headers:", prettify(headers.redacting()))
 
     // The request message and metadata (ignored here). For unary and server-streaming RPCs we
     // expect exactly one message, for client-streaming and bidirectional streaming RPCs any number
@@ -70,7 +70,7 @@ class LoggingEchoClientInterceptor: ClientInterceptor<Echo_EchoRequest, Echo_Ech
     // of a response stream, however, it is also valid to see no 'metadata' parts on the response
     // stream if the server rejects the RPC (in which case we expect the 'end' part).
     case let .metadata(headers):
-      print("< Received headers:", prettify(headers))
+      print("< Received headers:", prettify(headers.redacting()))
 
     // A response message received from the server. For unary and client-streaming RPCs we expect
     // one message. For server-streaming and bidirectional-streaming we expect any number of
@@ -81,7 +81,10 @@ class LoggingEchoClientInterceptor: ClientInterceptor<Echo_EchoRequest, Echo_Ech
     // The end of the response stream (and by extension, request stream). We expect one 'end' part,
     // after which no more response parts may be received and no more request parts will be sent.
     case let .end(status, trailers):
-      print("< Response stream closed with status: '\(status)' and trailers:", prettify(trailers))
+      print(
+        "< Response stream closed with status: '\(status)' and trailers:",
+        prettify(trailers.redacting())
+      )
     }
 
     // Forward the response part to the next interceptor.
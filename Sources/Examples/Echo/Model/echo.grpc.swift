@@ -310,6 +310,8 @@ public protocol Echo_EchoProvider: CallHandlerProvider {
 extension Echo_EchoProvider {
   public var serviceName: Substring { return "echo.Echo" }
 
+  public var methodNames: [Substring] { return ["Get", "Expand", "Collect", "Update"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -357,6 +359,16 @@ extension Echo_EchoProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'echo.Echo' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'echo.Echo' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Echo_EchoServerInterceptorFactoryProtocol {
@@ -377,3 +389,41 @@ public protocol Echo_EchoServerInterceptorFactoryProtocol {
   ///   Defaults to calling `self.makeInterceptors()`.
   func makeUpdateInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>]
 }
+
+/// A fake provider for 'echo.Echo' for testing clients.
+///
+/// Each method responds according to the stub of its handler and records the requests it
+/// receives. Methods may also be overridden to provide custom behaviour.
+open class Echo_EchoFakeProvider: Echo_EchoProvider {
+  open var interceptors: Echo_EchoServerInterceptorFactoryProtocol? { return nil }
+
+  /// Stubs and received requests for 'Get'.
+  public let getHandler = FakeMethodHandler<Echo_EchoRequest, Echo_EchoResponse>()
+
+  /// Stubs and received requests for 'Expand'.
+  public let expandHandler = FakeMethodHandler<Echo_EchoRequest, Echo_EchoResponse>()
+
+  /// Stubs and received requests for 'Collect'.
+  public let collectHandler = FakeMethodHandler<Echo_EchoRequest, Echo_EchoResponse>()
+
+  /// Stubs and received requests for 'Update'.
+  public let updateHandler = FakeMethodHandler<Echo_EchoRequest, Echo_EchoResponse>()
+
+  public init() {}
+
+  open func get(request: Echo_EchoRequest, context: StatusOnlyCallContext) -> EventLoopFuture<Echo_EchoResponse> {
+    return self.getHandler.handleUnary(request: request, context: context)
+  }
+
+  open func expand(request: Echo_EchoRequest, context: StreamingResponseCallContext<Echo_EchoResponse>) -> EventLoopFuture<GRPCStatus> {
+    return self.expandHandler.handleServerStreaming(request: request, context: context)
+  }
+
+  open func collect(context: UnaryResponseCallContext<Echo_EchoResponse>) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return self.collectHandler.handleClientStreaming(context: context)
+  }
+
+  open func update(context: StreamingResponseCallContext<Echo_EchoResponse>) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return self.updateHandler.handleBidirectionalStreaming(context: context)
+  }
+}
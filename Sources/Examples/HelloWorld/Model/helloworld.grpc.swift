@@ -103,6 +103,8 @@ public protocol Helloworld_GreeterProvider: CallHandlerProvider {
 extension Helloworld_GreeterProvider {
   public var serviceName: Substring { return "helloworld.Greeter" }
 
+  public var methodNames: [Substring] { return ["SayHello"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -123,6 +125,16 @@ extension Helloworld_GreeterProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'helloworld.Greeter' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'helloworld.Greeter' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Helloworld_GreeterServerInterceptorFactoryProtocol {
@@ -228,6 +228,8 @@ public protocol Routeguide_RouteGuideProvider: CallHandlerProvider {
 extension Routeguide_RouteGuideProvider {
   public var serviceName: Substring { return "routeguide.RouteGuide" }
 
+  public var methodNames: [Substring] { return ["GetFeature", "ListFeatures", "RecordRoute", "RouteChat"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -275,6 +277,16 @@ extension Routeguide_RouteGuideProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'routeguide.RouteGuide' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'routeguide.RouteGuide' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Routeguide_RouteGuideServerInterceptorFactoryProtocol {
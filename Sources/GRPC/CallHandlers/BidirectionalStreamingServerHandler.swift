@@ -56,6 +56,10 @@ public final class BidirectionalStreamingServerHandler<
   @usableFromInline
   internal var state: State = .idle
 
+  /// Whether response headers have been sent, either by the user or on their behalf.
+  @usableFromInline
+  internal var responseHeadersSent = false
+
   @usableFromInline
   internal enum State {
     // No headers have been received.
@@ -91,8 +95,11 @@ public final class BidirectionalStreamingServerHandler<
       path: context.path,
       callType: .bidirectionalStreaming,
       remoteAddress: context.remoteAddress,
+      streamID: context.streamID,
+      connection: context.connection,
+      transferTotals: context.transferTotals,
       userInfoRef: userInfoRef,
-      interceptors: interceptors,
+      interceptors: context.resolveInterceptors(interceptors),
       onRequestPart: self.receiveInterceptedPart(_:),
       onResponsePart: self.sendInterceptedPart(_:promise:)
     )
@@ -108,7 +115,10 @@ public final class BidirectionalStreamingServerHandler<
   @inlinable
   public func receiveMessage(_ bytes: ByteBuffer) {
     do {
-      let message = try self.deserializer.deserialize(byteBuffer: bytes)
+      let message = try self.deserializer.deserialize(
+        byteBuffer: bytes,
+        unknownFields: self.context.unknownFieldHandling
+      )
       self.interceptors.receive(.message(message))
     } catch {
       self.handleError(error)
@@ -122,6 +132,7 @@ public final class BidirectionalStreamingServerHandler<
 
   @inlinable
   public func receiveError(_ error: Error) {
+    self.interceptors?._isTerminatedByServer = true
     self.handleError(error)
     self.finish()
   }
@@ -135,6 +146,7 @@ public final class BidirectionalStreamingServerHandler<
 
     case let .creatingObserver(context),
          let .observing(_, context):
+      self.interceptors._isTerminatedByServer = true
       context.statusPromise.fail(GRPCStatus(code: .unavailable, message: nil))
 
     case .completed:
@@ -168,20 +180,29 @@ public final class BidirectionalStreamingServerHandler<
         userInfoRef: self.userInfoRef,
         compressionIsEnabled: self.context.encoding.isEnabled,
         closeFuture: self.context.closeFuture,
-        sendResponse: self.interceptResponse(_:metadata:promise:)
+        streamID: self.context.streamID,
+        connection: self.context.connection,
+        deadline: self.context.deadline,
+        sendHeaders: self.interceptResponseHeaders(_:promise:),
+        sendResponse: self.interceptResponse(_:metadata:promise:),
+        flush: self.flushResponses
       )
 
       // Move to the next state.
       self.state = .creatingObserver(context)
 
-      // Send response headers back via the interceptors.
-      self.interceptors.send(.metadata([:]), promise: nil)
-
       // Register callbacks on the status future.
       context.statusPromise.futureResult.whenComplete(self.userFunctionStatusResolved(_:))
 
-      // Make an observer block and register a completion block.
-      self.observerFactory(context).whenComplete(self.userFunctionResolvedWithResult(_:))
+      // Make an observer block.
+      let observer = self.observerFactory(context)
+
+      // The user had the opportunity to send headers while making the observer; if they didn't
+      // then send empty headers on their behalf.
+      self.sendResponseHeadersIfNecessary()
+
+      // Register a completion block on the observer.
+      observer.whenComplete(self.userFunctionResolvedWithResult(_:))
 
     case .creatingObserver, .observing:
       self.handleError(GRPCError.ProtocolViolation("Multiple header blocks received on RPC"))
@@ -200,7 +221,16 @@ public final class BidirectionalStreamingServerHandler<
     case .idle:
       self.handleError(GRPCError.ProtocolViolation("Message received before headers"))
     case .creatingObserver:
-      self.requestBuffer.append(.message(request))
+      if let limit = self.context.maximumBufferedRequests, self.requestBuffer.count >= limit {
+        self.handleError(
+          GRPCStatus(
+            code: .resourceExhausted,
+            message: "Too many requests buffered before the handler was ready (limit \(limit))"
+          )
+        )
+      } else {
+        self.requestBuffer.append(.message(request))
+      }
     case let .observing(observer, _):
       observer(.message(request))
     case .completed:
@@ -257,6 +287,56 @@ public final class BidirectionalStreamingServerHandler<
     }
   }
 
+  @inlinable
+  internal func interceptResponseHeaders(
+    _ headers: HPACKHeaders,
+    promise: EventLoopPromise<Void>?
+  ) {
+    switch self.state {
+    case .idle:
+      // The observer block can't send headers if it doesn't exist.
+      preconditionFailure()
+
+    case .creatingObserver, .observing:
+      if self.responseHeadersSent {
+        promise?.fail(GRPCError.InvalidState("Response headers have already been sent"))
+      } else {
+        self.responseHeadersSent = true
+        self.interceptors.send(.metadata(headers), promise: promise)
+      }
+
+    case .completed:
+      promise?.fail(GRPCError.AlreadyComplete())
+    }
+  }
+
+  @inlinable
+  internal func sendResponseHeadersIfNecessary() {
+    switch self.state {
+    case .creatingObserver, .observing:
+      if !self.responseHeadersSent {
+        self.responseHeadersSent = true
+        self.interceptors.send(.metadata([:]), promise: nil)
+      }
+
+    case .idle, .completed:
+      // Nothing to send headers for, or the RPC has already ended.
+      ()
+    }
+  }
+
+  @inlinable
+  internal func flushResponses() {
+    switch self.state {
+    case .idle, .completed:
+      // Nothing can have been sent, or the end of the response stream has been flushed already.
+      ()
+
+    case .creatingObserver, .observing:
+      self.context.responseWriter.flush()
+    }
+  }
+
   @inlinable
   internal func interceptResponse(
     _ response: Response,
@@ -271,6 +351,7 @@ public final class BidirectionalStreamingServerHandler<
     case .creatingObserver, .observing:
       // The user has access to the response context before returning a future observer,
       // so 'creatingObserver' is valid here (if a little strange).
+      self.sendResponseHeadersIfNecessary()
       self.interceptors.send(.message(response, metadata), promise: promise)
 
     case .completed:
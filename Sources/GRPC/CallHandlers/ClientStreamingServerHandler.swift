@@ -56,6 +56,10 @@ public final class ClientStreamingServerHandler<
   @usableFromInline
   internal var state: State = .idle
 
+  /// Validates that exactly one response is sent.
+  @usableFromInline
+  internal var responseValidator = SingleResponseValidator(callType: .clientStreaming)
+
   @usableFromInline
   internal enum State {
     // Nothing has happened yet.
@@ -92,8 +96,11 @@ public final class ClientStreamingServerHandler<
       path: context.path,
       callType: .clientStreaming,
       remoteAddress: context.remoteAddress,
+      streamID: context.streamID,
+      connection: context.connection,
+      transferTotals: context.transferTotals,
       userInfoRef: userInfoRef,
-      interceptors: interceptors,
+      interceptors: context.resolveInterceptors(interceptors),
       onRequestPart: self.receiveInterceptedPart(_:),
       onResponsePart: self.sendInterceptedPart(_:promise:)
     )
@@ -109,7 +116,10 @@ public final class ClientStreamingServerHandler<
   @inlinable
   public func receiveMessage(_ bytes: ByteBuffer) {
     do {
-      let message = try self.deserializer.deserialize(byteBuffer: bytes)
+      let message = try self.deserializer.deserialize(
+        byteBuffer: bytes,
+        unknownFields: self.context.unknownFieldHandling
+      )
       self.interceptors.receive(.message(message))
     } catch {
       self.handleError(error)
@@ -123,6 +133,7 @@ public final class ClientStreamingServerHandler<
 
   @inlinable
   public func receiveError(_ error: Error) {
+    self.interceptors?._isTerminatedByServer = true
     self.handleError(error)
     self.finish()
   }
@@ -136,6 +147,7 @@ public final class ClientStreamingServerHandler<
 
     case let .creatingObserver(context),
          let .observing(_, context):
+      self.interceptors._isTerminatedByServer = true
       context.responsePromise.fail(GRPCStatus(code: .unavailable, message: nil))
 
     case .completed:
@@ -167,7 +179,10 @@ public final class ClientStreamingServerHandler<
         headers: headers,
         logger: self.context.logger,
         userInfoRef: self.userInfoRef,
-        closeFuture: self.context.closeFuture
+        closeFuture: self.context.closeFuture,
+        streamID: self.context.streamID,
+        connection: self.context.connection,
+        deadline: self.context.deadline
       )
 
       // Move to the next state.
@@ -199,7 +214,16 @@ public final class ClientStreamingServerHandler<
     case .idle:
       self.handleError(GRPCError.ProtocolViolation("Message received before headers"))
     case .creatingObserver:
-      self.requestBuffer.append(.message(request))
+      if let limit = self.context.maximumBufferedRequests, self.requestBuffer.count >= limit {
+        self.handleError(
+          GRPCStatus(
+            code: .resourceExhausted,
+            message: "Too many requests buffered before the handler was ready (limit \(limit))"
+          )
+        )
+      } else {
+        self.requestBuffer.append(.message(request))
+      }
     case let .observing(observer, _):
       observer(.message(request))
     case .completed:
@@ -343,6 +367,12 @@ public final class ClientStreamingServerHandler<
       self.context.responseWriter.sendMetadata(headers, flush: true, promise: promise)
 
     case let .message(message, metadata):
+      if let violation = self.responseValidator.validateMessage() {
+        // The status is replaced when the RPC ends; drop the message.
+        promise?.fail(violation)
+        return
+      }
+
       do {
         let bytes = try self.serializer.serialize(message, allocator: ByteBufferAllocator())
         self.context.responseWriter.sendMessage(bytes, metadata: metadata, promise: promise)
@@ -358,6 +388,7 @@ public final class ClientStreamingServerHandler<
       }
 
     case let .end(status, trailers):
+      let status = self.responseValidator.validateEnd(status)
       self.context.responseWriter.sendEnd(status: status, trailers: trailers, promise: promise)
     }
   }
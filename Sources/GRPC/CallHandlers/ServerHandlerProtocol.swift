@@ -67,4 +67,7 @@ internal protocol GRPCServerResponseWriter {
   ///   - trailers: Any user-provided trailers to send back to the client with the status.
   ///   - promise: A promise to complete once the status and trailers have been handled.
   func sendEnd(status: GRPCStatus, trailers: HPACKHeaders, promise: EventLoopPromise<Void>?)
+
+  /// Flushes any metadata or messages which were sent without being flushed.
+  func flush()
 }
@@ -52,6 +52,10 @@ public final class ServerStreamingServerHandler<
   @usableFromInline
   internal var state: State = .idle
 
+  /// Whether response headers have been sent, either by the user or on their behalf.
+  @usableFromInline
+  internal var responseHeadersSent = false
+
   @usableFromInline
   internal enum State {
     // Initial state. Nothing has happened yet.
@@ -88,8 +92,11 @@ public final class ServerStreamingServerHandler<
       path: context.path,
       callType: .serverStreaming,
       remoteAddress: context.remoteAddress,
+      streamID: context.streamID,
+      connection: context.connection,
+      transferTotals: context.transferTotals,
       userInfoRef: userInfoRef,
-      interceptors: interceptors,
+      interceptors: context.resolveInterceptors(interceptors),
       onRequestPart: self.receiveInterceptedPart(_:),
       onResponsePart: self.sendInterceptedPart(_:promise:)
     )
@@ -105,7 +112,10 @@ public final class ServerStreamingServerHandler<
   @inlinable
   public func receiveMessage(_ bytes: ByteBuffer) {
     do {
-      let message = try self.deserializer.deserialize(byteBuffer: bytes)
+      let message = try self.deserializer.deserialize(
+        byteBuffer: bytes,
+        unknownFields: self.context.unknownFieldHandling
+      )
       self.interceptors.receive(.message(message))
     } catch {
       self.handleError(error)
@@ -119,6 +129,7 @@ public final class ServerStreamingServerHandler<
 
   @inlinable
   public func receiveError(_ error: Error) {
+    self.interceptors?._isTerminatedByServer = true
     self.handleError(error)
     self.finish()
   }
@@ -132,6 +143,7 @@ public final class ServerStreamingServerHandler<
 
     case let .createdContext(context),
          let .invokedFunction(context):
+      self.interceptors._isTerminatedByServer = true
       context.statusPromise.fail(GRPCStatus(code: .unavailable, message: nil))
 
     case .completed:
@@ -165,7 +177,12 @@ public final class ServerStreamingServerHandler<
         userInfoRef: self.userInfoRef,
         compressionIsEnabled: self.context.encoding.isEnabled,
         closeFuture: self.context.closeFuture,
-        sendResponse: self.interceptResponse(_:metadata:promise:)
+        streamID: self.context.streamID,
+        connection: self.context.connection,
+        deadline: self.context.deadline,
+        sendHeaders: self.interceptResponseHeaders(_:promise:),
+        sendResponse: self.interceptResponse(_:metadata:promise:),
+        flush: self.flushResponses
       )
 
       // Move to the next state.
@@ -174,9 +191,6 @@ public final class ServerStreamingServerHandler<
       // Register a callback on the status future.
       context.statusPromise.futureResult.whenComplete(self.userFunctionCompletedWithResult(_:))
 
-      // Send response headers back via the interceptors.
-      self.interceptors.send(.metadata([:]), promise: nil)
-
     case .createdContext, .invokedFunction:
       self.handleError(GRPCError.InvalidState("Protocol violation: already received headers"))
 
@@ -196,8 +210,12 @@ public final class ServerStreamingServerHandler<
 
     case let .createdContext(context):
       self.state = .invokedFunction(context)
+      let status = self.userFunction(request, context)
+      // The user had the opportunity to send headers while the function was running; if they
+      // didn't then send empty headers on their behalf.
+      self.sendResponseHeadersIfNecessary()
       // Complete the status promise with the function outcome.
-      context.statusPromise.completeWith(self.userFunction(request, context))
+      context.statusPromise.completeWith(status)
 
     case .invokedFunction:
       let error = GRPCError.ProtocolViolation("Multiple messages received on server streaming RPC")
@@ -226,6 +244,56 @@ public final class ServerStreamingServerHandler<
 
   // MARK: - User Function To Interceptors
 
+  @inlinable
+  internal func interceptResponseHeaders(
+    _ headers: HPACKHeaders,
+    promise: EventLoopPromise<Void>?
+  ) {
+    switch self.state {
+    case .idle:
+      // The user function can't send headers if it doesn't exist.
+      preconditionFailure()
+
+    case .createdContext, .invokedFunction:
+      if self.responseHeadersSent {
+        promise?.fail(GRPCError.InvalidState("Response headers have already been sent"))
+      } else {
+        self.responseHeadersSent = true
+        self.interceptors.send(.metadata(headers), promise: promise)
+      }
+
+    case .completed:
+      promise?.fail(GRPCError.AlreadyComplete())
+    }
+  }
+
+  @inlinable
+  internal func sendResponseHeadersIfNecessary() {
+    switch self.state {
+    case .createdContext, .invokedFunction:
+      if !self.responseHeadersSent {
+        self.responseHeadersSent = true
+        self.interceptors.send(.metadata([:]), promise: nil)
+      }
+
+    case .idle, .completed:
+      // Nothing to send headers for, or the RPC has already ended.
+      ()
+    }
+  }
+
+  @inlinable
+  internal func flushResponses() {
+    switch self.state {
+    case .idle, .completed:
+      // Nothing can have been sent, or the end of the response stream has been flushed already.
+      ()
+
+    case .createdContext, .invokedFunction:
+      self.context.responseWriter.flush()
+    }
+  }
+
   @inlinable
   internal func interceptResponse(
     _ response: Response,
@@ -240,6 +308,7 @@ public final class ServerStreamingServerHandler<
     case .createdContext, .invokedFunction:
       // The user has access to the response context before returning a future observer,
       // so 'createdContext' is valid here (if a little strange).
+      self.sendResponseHeadersIfNecessary()
       self.interceptors.send(.message(response, metadata), promise: promise)
 
     case .completed:
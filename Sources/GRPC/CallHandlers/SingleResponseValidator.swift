@@ -0,0 +1,72 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/// Validates that RPCs with a single response (unary and client streaming) send exactly one
+/// response message before ending successfully.
+///
+/// Violations are programming errors on the server (in the handler or an interceptor) and are
+/// surfaced to the client with an `.internalError` status.
+@usableFromInline
+internal struct SingleResponseValidator {
+  /// The type of RPC being validated, used in error messages.
+  @usableFromInline
+  internal let callType: GRPCCallType
+
+  /// The number of response messages sent so far.
+  @usableFromInline
+  internal private(set) var responsesSent = 0
+
+  @inlinable
+  internal init(callType: GRPCCallType) {
+    self.callType = callType
+  }
+
+  /// Validates that a response message may be sent. Returns the status to fail the RPC with if
+  /// one has already been sent.
+  @inlinable
+  internal mutating func validateMessage() -> GRPCStatus? {
+    self.responsesSent += 1
+    if self.responsesSent > 1 {
+      return self.violation
+    } else {
+      return nil
+    }
+  }
+
+  /// Validates the status the RPC is ending with, returning the status which should be sent
+  /// instead if the RPC did not send exactly one response.
+  @inlinable
+  internal func validateEnd(_ status: GRPCStatus) -> GRPCStatus {
+    switch (status.code, self.responsesSent) {
+    case (.ok, 1):
+      return status
+    case (.ok, _):
+      return self.violation
+    default:
+      // The RPC failed: there's no requirement to send a response.
+      return status
+    }
+  }
+
+  @inlinable
+  internal var violation: GRPCStatus {
+    return GRPCStatus(
+      code: .internalError,
+      message: "Server sent \(self.responsesSent) responses on \(self.callType) RPC, " +
+        "exactly one is required"
+    )
+  }
+}
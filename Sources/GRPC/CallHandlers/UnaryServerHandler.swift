@@ -51,6 +51,10 @@ public final class UnaryServerHandler<
   @usableFromInline
   internal var state: State = .idle
 
+  /// Validates that exactly one response is sent.
+  @usableFromInline
+  internal var responseValidator = SingleResponseValidator(callType: .unary)
+
   @usableFromInline
   internal enum State {
     // Initial state. Nothing has happened yet.
@@ -86,8 +90,11 @@ public final class UnaryServerHandler<
       path: context.path,
       callType: .unary,
       remoteAddress: context.remoteAddress,
+      streamID: context.streamID,
+      connection: context.connection,
+      transferTotals: context.transferTotals,
       userInfoRef: userInfoRef,
-      interceptors: interceptors,
+      interceptors: context.resolveInterceptors(interceptors),
       onRequestPart: self.receiveInterceptedPart(_:),
       onResponsePart: self.sendInterceptedPart(_:promise:)
     )
@@ -103,7 +110,10 @@ public final class UnaryServerHandler<
   @inlinable
   public func receiveMessage(_ bytes: ByteBuffer) {
     do {
-      let message = try self.deserializer.deserialize(byteBuffer: bytes)
+      let message = try self.deserializer.deserialize(
+        byteBuffer: bytes,
+        unknownFields: self.context.unknownFieldHandling
+      )
       self.interceptors.receive(.message(message))
     } catch {
       self.handleError(error)
@@ -117,6 +127,7 @@ public final class UnaryServerHandler<
 
   @inlinable
   public func receiveError(_ error: Error) {
+    self.interceptors?._isTerminatedByServer = true
     self.handleError(error)
     self.finish()
   }
@@ -130,6 +141,7 @@ public final class UnaryServerHandler<
 
     case let .createdContext(context),
          let .invokedFunction(context):
+      self.interceptors._isTerminatedByServer = true
       context.responsePromise.fail(GRPCStatus(code: .unavailable, message: nil))
 
     case .completed:
@@ -161,7 +173,10 @@ public final class UnaryServerHandler<
         headers: headers,
         logger: self.context.logger,
         userInfoRef: self.userInfoRef,
-        closeFuture: self.context.closeFuture
+        closeFuture: self.context.closeFuture,
+        streamID: self.context.streamID,
+        connection: self.context.connection,
+        deadline: self.context.deadline
       )
 
       // Move to the next state.
@@ -267,6 +282,12 @@ public final class UnaryServerHandler<
       self.context.responseWriter.sendMetadata(headers, flush: false, promise: promise)
 
     case let .message(message, metadata):
+      if let violation = self.responseValidator.validateMessage() {
+        // The status is replaced when the RPC ends; drop the message.
+        promise?.fail(violation)
+        return
+      }
+
       do {
         let bytes = try self.serializer.serialize(message, allocator: self.context.allocator)
         self.context.responseWriter.sendMessage(bytes, metadata: metadata, promise: promise)
@@ -282,6 +303,7 @@ public final class UnaryServerHandler<
       }
 
     case let .end(status, trailers):
+      let status = self.responseValidator.validateEnd(status)
       self.context.responseWriter.sendEnd(status: status, trailers: trailers, promise: promise)
     }
   }
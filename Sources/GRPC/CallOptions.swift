@@ -23,6 +23,11 @@ import NIOHTTP2
 /// Options to use for GRPC calls.
 public struct CallOptions {
   /// Additional metadata to send to the service.
+  ///
+  /// Lookups on `HPACKHeaders` are case-insensitive, so `customMetadata["Authorization"]` and
+  /// `customMetadata["authorization"]` return the same values. Names are lowercased before being
+  /// sent, as required by HTTP/2. Entries added under names differing only in case are sent as
+  /// separate values for the same (lowercased) name, in the order they were added.
   public var customMetadata: HPACKHeaders
 
   /// The time limit for the RPC.
@@ -30,6 +35,41 @@ public struct CallOptions {
   /// - Note: timeouts are treated as deadlines as soon as an RPC has been invoked.
   public var timeLimit: TimeLimit
 
+  /// The maximum amount of time to wait between response parts for server streaming and
+  /// bidirectional streaming RPCs. If no response part is received within this time then the RPC
+  /// is failed with status code `.deadlineExceeded`. The timeout is reset each time a response part
+  /// is received. If the value is `nil` (the default) then no idle timeout is applied.
+  ///
+  /// The idle timeout is independent of `timeLimit`: it may be used to detect a stalled stream
+  /// without limiting the total duration of the RPC.
+  public var responseIdleTimeout: TimeAmount?
+
+  /// The maximum amount of time to wait for the response headers once the request headers have
+  /// been written, that is, the time to first byte. If the server hasn't started responding within
+  /// this time then the RPC is failed with status code `.deadlineExceeded`. If the value is `nil`
+  /// (the default) then no response headers timeout is applied.
+  ///
+  /// The response headers timeout is independent of `timeLimit`: it may be used to detect an
+  /// unresponsive server quickly while allowing a generous deadline for the RPC as a whole. It is
+  /// distinguished from other timeouts by the `termination` of the RPC.
+  ///
+  /// - Note: Some servers, including gRPC Swift servers for unary and client streaming RPCs, only
+  ///   send response headers with the first response message. For these RPCs the timeout also
+  ///   limits how long the server may take to produce a response.
+  public var responseHeadersTimeout: TimeAmount?
+
+  /// The maximum amount of time to wait for each request message to be written for client
+  /// streaming and bidirectional streaming RPCs. If a request message can't be written within this
+  /// time, for example because the server has stopped reading and flow control is preventing
+  /// further writes, then the RPC is failed with status code `.deadlineExceeded`. The timeout is
+  /// reset each time a request message is written. If the value is `nil` (the default) then no
+  /// write timeout is applied.
+  ///
+  /// The write timeout is independent of `responseIdleTimeout` and `timeLimit`; together with
+  /// `responseIdleTimeout` it may be used to detect a peer which has stopped reading or stopped
+  /// responding. The two are distinguished by the `termination` of the RPC.
+  public var writeTimeout: TimeAmount?
+
   /// The compression used for requests, and the compression algorithms to advertise as acceptable
   /// for the remote peer to use for encoding responses.
   ///
@@ -62,6 +102,20 @@ public struct CallOptions {
   /// messages associated with the call.
   public var requestIDHeader: String?
 
+  /// The value to use for the ":authority" pseudo-header, overriding the one derived from the
+  /// connection target (or the TLS hostname override). Must be a well-formed authority of the
+  /// form "host" or "host:port" where the host may be a registered name, an IPv4 address or an
+  /// IPv6 address enclosed in square brackets. User information is not permitted. If the value
+  /// is `nil` (the default) then the authority of the connection is used. RPCs made with a
+  /// malformed authority fail with status code 'invalidArgument' without being sent.
+  ///
+  /// Set this on a client's default call options to override the authority for all of its RPCs.
+  ///
+  /// - Note: This only affects the ":authority" pseudo-header. The server name used for TLS
+  ///   (SNI) is a property of the connection and must be set with the TLS hostname override
+  ///   when configuring the connection.
+  public var authority: String?
+
   /// A preference for the `EventLoop` that the call is executed on.
   ///
   /// The `EventLoop` resulting from the preference will be used to create any `EventLoopFuture`s
@@ -72,6 +126,39 @@ public struct CallOptions {
   /// Note that the underlying connection is not guaranteed to run on the same event loop.
   public var eventLoopPreference: EventLoopPreference
 
+  /// The clock used to resolve `timeLimit` into a deadline and to schedule the task enforcing it.
+  /// Defaults to the system clock.
+  ///
+  /// A different clock is only useful for testing. The clock doesn't affect keepalive or idle
+  /// timeouts, these use the `connectionClock` of the connection. See `GRPCClock` for details.
+  public var clock: GRPCClock = .system
+
+  /// A hint about the importance of the RPC relative to other RPCs on the same connection.
+  /// Defaults to `.normal`.
+  ///
+  /// When the connection has a concurrent stream limit (see `ClientConcurrentStreamLimit`), RPCs
+  /// waiting for a stream to become available are started in priority order: higher priority RPCs
+  /// open their stream before lower priority RPCs which have been waiting longer. RPCs of the same
+  /// priority are started in the order they were made.
+  ///
+  /// - Note: The priority is best-effort and only applies on the client. It is not sent to the
+  ///   server as an HTTP/2 stream priority; most servers and intermediaries ignore it and its use
+  ///   is deprecated by RFC 9113. It has no effect if the connection has no concurrent stream
+  ///   limit, and a steady stream of higher priority RPCs may delay lower priority RPCs until
+  ///   their deadline.
+  public var priority: Priority = .normal
+
+  /// Headers which are sent exactly as given, bypassing the handling applied to `customMetadata`.
+  ///
+  /// - Warning: This is a last-resort workaround for interoperating with peers which require
+  ///   headers gRPC would not otherwise send. Names are not lowercased, values are not checked
+  ///   for characters which are not permitted in metadata and the headers are not visible to
+  ///   client interceptors. The headers are added after all other request headers; names which
+  ///   collide with headers set by gRPC (such as "content-type" or "grpc-timeout") result in
+  ///   duplicate headers. Sending malformed headers may cause the server or any intermediary to
+  ///   reject the RPC or the connection. Prefer `customMetadata` in all other cases.
+  public var unsafeRawHeaders = HPACKHeaders()
+
   /// A logger used for the call. Defaults to a no-op logger.
   ///
   /// If a `requestIDProvider` exists then a request ID will automatically attached to the logger's
@@ -81,18 +168,26 @@ public struct CallOptions {
   public init(
     customMetadata: HPACKHeaders = HPACKHeaders(),
     timeLimit: TimeLimit = .none,
+    responseIdleTimeout: TimeAmount? = nil,
+    responseHeadersTimeout: TimeAmount? = nil,
+    writeTimeout: TimeAmount? = nil,
     messageEncoding: ClientMessageEncoding = .disabled,
     requestIDProvider: RequestIDProvider = .autogenerated,
     requestIDHeader: String? = nil,
+    authority: String? = nil,
     cacheable: Bool = false,
     logger: Logger = Logger(label: "io.grpc", factory: { _ in SwiftLogNoOpLogHandler() })
   ) {
     self.init(
       customMetadata: customMetadata,
       timeLimit: timeLimit,
+      responseIdleTimeout: responseIdleTimeout,
+      responseHeadersTimeout: responseHeadersTimeout,
+      writeTimeout: writeTimeout,
       messageEncoding: messageEncoding,
       requestIDProvider: requestIDProvider,
       requestIDHeader: requestIDHeader,
+      authority: authority,
       eventLoopPreference: .indifferent,
       cacheable: cacheable,
       logger: logger
@@ -102,9 +197,13 @@ public struct CallOptions {
   public init(
     customMetadata: HPACKHeaders = HPACKHeaders(),
     timeLimit: TimeLimit = .none,
+    responseIdleTimeout: TimeAmount? = nil,
+    responseHeadersTimeout: TimeAmount? = nil,
+    writeTimeout: TimeAmount? = nil,
     messageEncoding: ClientMessageEncoding = .disabled,
     requestIDProvider: RequestIDProvider = .autogenerated,
     requestIDHeader: String? = nil,
+    authority: String? = nil,
     eventLoopPreference: EventLoopPreference,
     cacheable: Bool = false,
     logger: Logger = Logger(label: "io.grpc", factory: { _ in SwiftLogNoOpLogHandler() })
@@ -113,13 +212,116 @@ public struct CallOptions {
     self.messageEncoding = messageEncoding
     self.requestIDProvider = requestIDProvider
     self.requestIDHeader = requestIDHeader
+    self.authority = authority
     self.cacheable = cacheable
     self.timeLimit = timeLimit
+    self.responseIdleTimeout = responseIdleTimeout
+    self.responseHeadersTimeout = responseHeadersTimeout
+    self.writeTimeout = writeTimeout
     self.logger = logger
     self.eventLoopPreference = eventLoopPreference
   }
 }
 
+extension CallOptions {
+  /// Returns whether the given value is a well-formed authority ("host" or "host:port") suitable
+  /// for use as the ":authority" pseudo-header.
+  ///
+  /// See: https://datatracker.ietf.org/doc/html/rfc3986#section-3.2
+  internal static func isValidAuthority(_ authority: String) -> Bool {
+    let utf8 = Array(authority.utf8)
+    guard !utf8.isEmpty else {
+      return false
+    }
+
+    let hostEnd: Int
+    if utf8[0] == UInt8(ascii: "[") {
+      // IP literal: "[" IPv6 address "]".
+      guard let close = utf8.firstIndex(of: UInt8(ascii: "]")), close > 1 else {
+        return false
+      }
+      let isIPv6Character: (UInt8) -> Bool = { byte in
+        switch byte {
+        case UInt8(ascii: "0") ... UInt8(ascii: "9"),
+             UInt8(ascii: "a") ... UInt8(ascii: "f"),
+             UInt8(ascii: "A") ... UInt8(ascii: "F"),
+             UInt8(ascii: ":"), UInt8(ascii: "."):
+          return true
+        default:
+          return false
+        }
+      }
+      guard utf8[1 ..< close].allSatisfy(isIPv6Character) else {
+        return false
+      }
+      hostEnd = close + 1
+    } else {
+      // Registered name or IPv4 address.
+      hostEnd = utf8.firstIndex(of: UInt8(ascii: ":")) ?? utf8.endIndex
+      let isHostCharacter: (UInt8) -> Bool = { byte in
+        switch byte {
+        case UInt8(ascii: "a") ... UInt8(ascii: "z"),
+             UInt8(ascii: "A") ... UInt8(ascii: "Z"),
+             UInt8(ascii: "0") ... UInt8(ascii: "9"),
+             UInt8(ascii: "-"), UInt8(ascii: "."), UInt8(ascii: "_"), UInt8(ascii: "~"),
+             UInt8(ascii: "!"), UInt8(ascii: "$"), UInt8(ascii: "&"), UInt8(ascii: "'"),
+             UInt8(ascii: "("), UInt8(ascii: ")"), UInt8(ascii: "*"), UInt8(ascii: "+"),
+             UInt8(ascii: ","), UInt8(ascii: ";"), UInt8(ascii: "="), UInt8(ascii: "%"):
+          return true
+        default:
+          return false
+        }
+      }
+      guard hostEnd > 0, utf8[0 ..< hostEnd].allSatisfy(isHostCharacter) else {
+        return false
+      }
+    }
+
+    // Optional port.
+    if hostEnd == utf8.endIndex {
+      return true
+    }
+
+    guard utf8[hostEnd] == UInt8(ascii: ":") else {
+      return false
+    }
+
+    let port = utf8[(hostEnd + 1)...]
+    guard (1 ... 5).contains(port.count),
+      port.allSatisfy({ (UInt8(ascii: "0") ... UInt8(ascii: "9")).contains($0) }),
+      let value = Int(String(decoding: port, as: UTF8.self)) else {
+      return false
+    }
+
+    return value <= 65535
+  }
+}
+
+extension CallOptions {
+  /// Returns a copy of these options whose deadline is no later than `deadline`.
+  ///
+  /// The time limit of the returned options is the earlier of `deadline` and the deadline of
+  /// `timeLimit`, both measured by `clock`. This allows one deadline to be shared by every RPC made
+  /// within a scope, such as handling a request, while still respecting shorter time limits set
+  /// for individual RPCs:
+  ///
+  /// ```
+  /// let deadline = NIODeadline.now() + .seconds(5)
+  /// let user = client.getUser(request, callOptions: options.limitingDeadline(to: deadline))
+  /// ```
+  ///
+  /// - Parameter deadline: The latest deadline the returned options may have.
+  /// - Returns: Call options with a time limit no later than `deadline`.
+  public func limitingDeadline(to deadline: NIODeadline) -> CallOptions {
+    var options = self
+    let current = self.timeLimit.makeDeadline(using: self.clock)
+    if deadline < current {
+      options.timeLimit = .deadline(deadline)
+    }
+    return options
+  }
+}
+
 extension CallOptions {
   public struct RequestIDProvider {
     private enum RequestIDSource {
@@ -165,6 +367,29 @@ extension CallOptions {
   }
 }
 
+extension CallOptions {
+  /// The relative importance of an RPC.
+  public struct Priority: Hashable, Comparable {
+    internal var rawValue: Int
+    private init(_ rawValue: Int) {
+      self.rawValue = rawValue
+    }
+
+    /// For background work, such as bulk synchronization, which may be delayed by other RPCs.
+    public static let low = Priority(0)
+
+    /// The default priority.
+    public static let normal = Priority(1)
+
+    /// For latency sensitive work, such as RPCs a user interface is waiting on.
+    public static let high = Priority(2)
+
+    public static func < (lhs: Priority, rhs: Priority) -> Bool {
+      return lhs.rawValue < rhs.rawValue
+    }
+  }
+}
+
 extension CallOptions {
   public struct EventLoopPreference {
     /// No preference. The framework will assign an `EventLoop`.
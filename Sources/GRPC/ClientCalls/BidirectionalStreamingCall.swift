@@ -55,6 +55,11 @@ public struct BidirectionalStreamingCall<
   // MARK: - Response Parts
 
   /// The initial metadata returned from the server.
+  ///
+  /// The request headers, including the `customMetadata` of the call's `options`, are sent and
+  /// flushed as soon as the call is made, before any messages. A server may respond with its
+  /// initial metadata before receiving any messages, so this may be waited on before sending the
+  /// first message, for example to complete a handshake.
   public var initialMetadata: EventLoopFuture<HPACKHeaders> {
     return self.responseParts.initialMetadata
   }
@@ -69,6 +74,36 @@ public struct BidirectionalStreamingCall<
     return self.responseParts.status
   }
 
+  /// How the RPC ended: whether it completed with a status from the server, was cancelled, exceeded
+  /// its deadline or failed because of a transport problem. Completed at the same time as `status`.
+  public var termination: EventLoopFuture<RPCTermination> {
+    return self.responseParts.termination
+  }
+
+  /// Succeeds once every response has been received and the server ended the RPC with an 'ok'
+  /// status, or fails with the `GRPCStatus` of the RPC otherwise. This separates the normal end
+  /// of the response stream from errors:
+  ///
+  /// ```
+  /// call.completion.whenComplete { result in
+  ///   switch result {
+  ///   case .success:
+  ///     ()  // All responses were received.
+  ///   case let .failure(status):
+  ///     self.handleError(status)
+  ///   }
+  /// }
+  /// ```
+  ///
+  /// The trailing metadata sent by the server is available from `trailingMetadata`.
+  public var completion: EventLoopFuture<Void> {
+    return self.status.flatMapThrowing { status in
+      guard status.isOk else {
+        throw status
+      }
+    }
+  }
+
   internal init(
     call: Call<RequestPayload, ResponsePayload>,
     callback: @escaping (ResponsePayload) -> Void
@@ -82,6 +82,10 @@ public protocol StreamingRequestClientCall: ClientCall {
 
   /// Sends a message to the service.
   ///
+  /// This may be called from any thread. Each message is written atomically on the `eventLoop`,
+  /// however messages sent concurrently from multiple threads are not guaranteed to be written in
+  /// the order they were sent. Use a `SerialRPCWriter` if ordering is required.
+  ///
   /// - Important: Callers must terminate the stream of messages by calling `sendEnd()` or `sendEnd(promise:)`.
   ///
   /// - Parameters:
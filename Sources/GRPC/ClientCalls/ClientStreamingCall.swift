@@ -53,6 +53,9 @@ public struct ClientStreamingCall<RequestPayload, ResponsePayload>: StreamingReq
   // MARK: - Response Parts
 
   /// The initial metadata returned from the server.
+  ///
+  /// The request headers, including the `customMetadata` of the call's `options`, are sent and
+  /// flushed as soon as the call is made, before any messages.
   public var initialMetadata: EventLoopFuture<HPACKHeaders> {
     return self.responseParts.initialMetadata
   }
@@ -72,6 +75,12 @@ public struct ClientStreamingCall<RequestPayload, ResponsePayload>: StreamingReq
     return self.responseParts.status
   }
 
+  /// How the RPC ended: whether it completed with a status from the server, was cancelled, exceeded
+  /// its deadline or failed because of a transport problem. Completed at the same time as `status`.
+  public var termination: EventLoopFuture<RPCTermination> {
+    return self.responseParts.termination
+  }
+
   internal init(call: Call<RequestPayload, ResponsePayload>) {
     self.call = call
     self.responseParts = UnaryResponseParts(on: call.eventLoop)
@@ -0,0 +1,159 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOHTTP2
+
+/// How an RPC ended, as observed by the client.
+///
+/// The `status` of an RPC doesn't distinguish between a status sent by the server and one
+/// produced by the client, for example a 'cancelled' status may have been sent by the server or
+/// may be the result of the caller cancelling the RPC. The termination of an RPC makes that
+/// distinction which is useful when deciding whether an RPC should be retried or a stream
+/// restarted:
+///
+/// ```
+/// call.termination.whenSuccess { termination in
+///   switch termination {
+///   case .completed(status: let status) where status.isOk:
+///     ()  // All done.
+///   case .transportFailure, .cancelled(reason: .responseIdleTimeout),
+///        .cancelled(reason: .writeTimeout):
+///     self.restartStream()
+///   case .completed, .cancelled, .deadlineExceeded, .failed:
+///     self.reportFailure(termination)
+///   }
+/// }
+/// ```
+public enum RPCTermination {
+  /// The RPC ended with a status received from the server. The status may or may not be 'ok'.
+  case completed(status: GRPCStatus)
+
+  /// The RPC was cancelled before it completed.
+  case cancelled(reason: CancellationReason)
+
+  /// The time limit of the RPC (see `CallOptions.timeLimit`) passed before it completed.
+  case deadlineExceeded
+
+  /// The RPC failed because of a problem with the underlying transport, for example the
+  /// connection couldn't be established or was closed before the RPC completed.
+  case transportFailure(cause: Error)
+
+  /// The RPC failed on the client for another reason, for example an interceptor failed the RPC or
+  /// a response couldn't be deserialized.
+  case failed(cause: Error)
+}
+
+extension RPCTermination {
+  /// The reason an RPC was cancelled.
+  public struct CancellationReason: Hashable, CustomStringConvertible {
+    private enum Wrapped: Hashable {
+      case cancelledByClient
+      case responseIdleTimeout
+      case responseHeadersTimeout
+      case writeTimeout
+      case streamReset
+    }
+
+    private var wrapped: Wrapped
+
+    private init(_ wrapped: Wrapped) {
+      self.wrapped = wrapped
+    }
+
+    /// The RPC was cancelled on the client, by calling `cancel(promise:)` on the call or by an
+    /// interceptor.
+    public static let cancelledByClient = CancellationReason(.cancelledByClient)
+
+    /// No response part was received within the response idle timeout of the RPC (see
+    /// `CallOptions.responseIdleTimeout`), this usually means the stream has stalled.
+    public static let responseIdleTimeout = CancellationReason(.responseIdleTimeout)
+
+    /// The response headers weren't received within the response headers timeout of the RPC (see
+    /// `CallOptions.responseHeadersTimeout`), this usually means the server is unresponsive.
+    public static let responseHeadersTimeout = CancellationReason(.responseHeadersTimeout)
+
+    /// A request message wasn't written within the write timeout of the RPC (see
+    /// `CallOptions.writeTimeout`), this usually means the server has stopped reading.
+    public static let writeTimeout = CancellationReason(.writeTimeout)
+
+    /// The HTTP/2 stream was reset with the 'CANCEL' error code by the server or an intermediary,
+    /// such as a proxy or load balancer.
+    public static let streamReset = CancellationReason(.streamReset)
+
+    public var description: String {
+      switch self.wrapped {
+      case .cancelledByClient:
+        return "cancelled by client"
+      case .responseIdleTimeout:
+        return "response idle timeout"
+      case .responseHeadersTimeout:
+        return "response headers timeout"
+      case .writeTimeout:
+        return "write timeout"
+      case .streamReset:
+        return "stream reset"
+      }
+    }
+  }
+}
+
+extension RPCTermination {
+  /// Classifies an error which failed an RPC on the client.
+  internal init(error: Error) {
+    switch error {
+    case let withContext as GRPCError.WithContext:
+      self.init(error: withContext.error)
+
+    case is GRPCError.RPCCancelledByClient:
+      self = .cancelled(reason: .cancelledByClient)
+
+    case is GRPCError.RPCIdleTimedOut:
+      self = .cancelled(reason: .responseIdleTimeout)
+
+    case is GRPCError.RPCResponseHeadersTimedOut:
+      self = .cancelled(reason: .responseHeadersTimeout)
+
+    case is GRPCError.RPCWriteTimedOut:
+      self = .cancelled(reason: .writeTimeout)
+
+    case is GRPCError.RPCTimedOut:
+      self = .deadlineExceeded
+
+    case let closed as NIOHTTP2Errors.StreamClosed:
+      if closed.errorCode == .cancel {
+        self = .cancelled(reason: .streamReset)
+      } else {
+        self = .transportFailure(cause: closed)
+      }
+
+    case let failure as ConnectionFailure:
+      self = .transportFailure(cause: failure.reason)
+
+    case is NIOHTTP2Errors.IOOnClosedConnection,
+         is ChannelError:
+      self = .transportFailure(cause: error)
+
+    case let transformable as GRPCStatusTransformable
+      where transformable.makeGRPCStatus().code == .unavailable:
+      // Errors raised by the client with an 'unavailable' status come from the transport (e.g.
+      // "Transport became inactive").
+      self = .transportFailure(cause: error)
+
+    default:
+      self = .failed(cause: error)
+    }
+  }
+}
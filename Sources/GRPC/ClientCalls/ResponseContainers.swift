@@ -28,6 +28,7 @@ internal class UnaryResponseParts<Response> {
   private var initialMetadataPromise: LazyEventLoopPromise<HPACKHeaders>
   private var trailingMetadataPromise: LazyEventLoopPromise<HPACKHeaders>
   private var statusPromise: LazyEventLoopPromise<GRPCStatus>
+  private var terminationPromise: LazyEventLoopPromise<RPCTermination>
 
   internal var response: EventLoopFuture<Response> {
     return self.responsePromise.futureResult
@@ -51,12 +52,19 @@ internal class UnaryResponseParts<Response> {
     }
   }
 
+  internal var termination: EventLoopFuture<RPCTermination> {
+    return self.eventLoop.executeOrFlatSubmit {
+      return self.terminationPromise.getFutureResult()
+    }
+  }
+
   internal init(on eventLoop: EventLoop) {
     self.eventLoop = eventLoop
     self.responsePromise = eventLoop.makePromise()
     self.initialMetadataPromise = eventLoop.makeLazyPromise()
     self.trailingMetadataPromise = eventLoop.makeLazyPromise()
     self.statusPromise = eventLoop.makeLazyPromise()
+    self.terminationPromise = eventLoop.makeLazyPromise()
   }
 
   /// Handle the response part, completing any promises as necessary.
@@ -79,6 +87,7 @@ internal class UnaryResponseParts<Response> {
 
       self.trailingMetadataPromise.succeed(trailers)
       self.statusPromise.succeed(status)
+      self.terminationPromise.succeed(.completed(status: status))
     }
   }
 
@@ -89,6 +98,7 @@ internal class UnaryResponseParts<Response> {
     self.responsePromise.fail(withoutContext)
     self.trailingMetadataPromise.fail(withoutContext)
     self.statusPromise.succeed(status)
+    self.terminationPromise.succeed(RPCTermination(error: withoutContext))
   }
 }
 
@@ -104,6 +114,7 @@ internal class StreamingResponseParts<Response> {
   private var initialMetadataPromise: LazyEventLoopPromise<HPACKHeaders>
   private var trailingMetadataPromise: LazyEventLoopPromise<HPACKHeaders>
   private var statusPromise: LazyEventLoopPromise<GRPCStatus>
+  private var terminationPromise: LazyEventLoopPromise<RPCTermination>
 
   internal var initialMetadata: EventLoopFuture<HPACKHeaders> {
     return self.eventLoop.executeOrFlatSubmit {
@@ -123,12 +134,19 @@ internal class StreamingResponseParts<Response> {
     }
   }
 
+  internal var termination: EventLoopFuture<RPCTermination> {
+    return self.eventLoop.executeOrFlatSubmit {
+      return self.terminationPromise.getFutureResult()
+    }
+  }
+
   internal init(on eventLoop: EventLoop, _ responseCallback: @escaping (Response) -> Void) {
     self.eventLoop = eventLoop
     self.responseCallback = responseCallback
     self.initialMetadataPromise = eventLoop.makeLazyPromise()
     self.trailingMetadataPromise = eventLoop.makeLazyPromise()
     self.statusPromise = eventLoop.makeLazyPromise()
+    self.terminationPromise = eventLoop.makeLazyPromise()
   }
 
   internal func handle(_ part: GRPCClientResponsePart<Response>) {
@@ -145,6 +163,7 @@ internal class StreamingResponseParts<Response> {
       self.initialMetadataPromise.fail(status)
       self.trailingMetadataPromise.succeed(trailers)
       self.statusPromise.succeed(status)
+      self.terminationPromise.succeed(.completed(status: status))
     }
   }
 
@@ -154,6 +173,7 @@ internal class StreamingResponseParts<Response> {
     self.initialMetadataPromise.fail(withoutContext)
     self.trailingMetadataPromise.fail(withoutContext)
     self.statusPromise.succeed(status)
+    self.terminationPromise.succeed(RPCTermination(error: withoutContext))
   }
 }
 
@@ -186,7 +206,7 @@ extension Error {
     } else if let transformable = self as? GRPCStatusTransformable {
       return transformable.makeGRPCStatus()
     } else {
-      return GRPCStatus(code: .unknown, message: String(describing: self))
+      return GRPCStatus(code: .unknown, message: String(describing: self), cause: self)
     }
   }
 }
@@ -71,6 +71,12 @@ public struct UnaryCall<RequestPayload, ResponsePayload>: UnaryResponseClientCal
     return self.responseParts.status
   }
 
+  /// How the RPC ended: whether it completed with a status from the server, was cancelled, exceeded
+  /// its deadline or failed because of a transport problem. Completed at the same time as `status`.
+  public var termination: EventLoopFuture<RPCTermination> {
+    return self.responseParts.termination
+  }
+
   internal init(call: Call<RequestPayload, ResponsePayload>) {
     self.call = call
     self.responseParts = UnaryResponseParts(on: call.eventLoop)
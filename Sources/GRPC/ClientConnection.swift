@@ -95,6 +95,9 @@ public class ClientConnection {
   /// A monitor for the connectivity state.
   public let connectivity: ConnectivityStateMonitor
 
+  /// Limits the number of concurrent streams, if `configuration.concurrentStreamLimit` is set.
+  private let streamGate: StreamGate?
+
   /// The `EventLoop` this connection is using.
   public var eventLoop: EventLoop {
     return self.connectionManager.eventLoop
@@ -106,7 +109,19 @@ public class ClientConnection {
   ///
   /// - Important: Users should prefer using `ClientConnection.secure(group:)` to build a connection
   ///   with TLS, or `ClientConnection.insecure(group:)` to build a connection without TLS.
+  ///
+  /// If the configuration is invalid then no connection is attempted: the connection is shutdown
+  /// and RPCs fail with a `GRPCError.InvalidState` describing the problem.
   public init(configuration: Configuration) {
+    var configuration = configuration
+    if let error = configuration.validationError {
+      configuration.backgroundActivityLogger.error("invalid connection configuration", metadata: [
+        MetadataKey.error: "\(error)",
+      ])
+      // The error is surfaced by failing the first connection attempt; there's no point retrying.
+      configuration.connectionBackoff = nil
+    }
+
     self.configuration = configuration
     self.scheme = configuration.tlsConfiguration == nil ? "http" : "https"
     self.authority = configuration.tlsConfiguration?.hostnameOverride ?? configuration.target.host
@@ -117,11 +132,102 @@ public class ClientConnection {
     )
 
     self.connectivity = monitor
+    self.streamGate = configuration.concurrentStreamLimit.map { StreamGate(limit: $0) }
     self.connectionManager = ConnectionManager(
       configuration: configuration,
       connectivityDelegate: monitor,
       logger: configuration.backgroundActivityLogger
     )
+
+    switch configuration.connectionStartBehavior.wrapped {
+    case .eager:
+      // Failures are surfaced via `waitForReady()` and the connectivity state.
+      _ = self.connectionManager.getHTTP2Multiplexer()
+    case .lazy:
+      ()
+    }
+  }
+
+  /// Returns a future which succeeds once the connection is ready, starting a connection attempt if
+  /// one isn't already in progress. This may be used with `ConnectionStartBehavior.eager` to
+  /// check that the server is reachable before starting any RPCs.
+  ///
+  /// The future fails in the same way an RPC would when waiting for the connection: with the
+  /// `.fastFailure` `CallStartBehavior` it fails if the connection attempt fails, with
+  /// `.waitsForConnectivity` it fails only if the connection is shutdown or reconnect attempts
+  /// are exhausted.
+  public func waitForReady() -> EventLoopFuture<Void> {
+    return self.connectionManager.getHTTP2Multiplexer().map { _ in () }
+  }
+
+  /// Establishes the connection ahead of the first RPC to hide the latency of connecting (and
+  /// completing a TLS handshake, if applicable) from it.
+  ///
+  /// If a `primingPath` is given then a unary RPC with an empty request message is made to that
+  /// path once the connection is ready. This primes the HPACK header tables of the connection so
+  /// that the headers of subsequent RPCs are smaller. Any method which accepts an empty request
+  /// may be used, such as "/grpc.health.v1.Health/Check". The priming RPC is only considered
+  /// successful if it ends with status code 'ok' or 'unimplemented', i.e. the server handled it.
+  ///
+  /// Warming up never fails: if it doesn't complete within the `timeLimit` the connection continues
+  /// to be established (and retried) as usual in the background.
+  ///
+  /// - Parameters:
+  ///   - timeLimit: The time limit for warming up. Defaults to a timeout of 10 seconds.
+  ///   - primingPath: The path of a method to call once the connection is ready, in the format
+  ///       "/Service/Method". Defaults to `nil`, no RPC is made.
+  /// - Returns: A future which succeeds with `true` if the connection was ready (and the priming
+  ///     RPC, if any, succeeded) within the time limit, or `false` otherwise.
+  public func warmUp(
+    timeLimit: TimeLimit = .timeout(.seconds(10)),
+    primingPath: String? = nil
+  ) -> EventLoopFuture<Bool> {
+    let eventLoop = self.eventLoop
+    let deadline = timeLimit.makeDeadline()
+    let promise = eventLoop.makePromise(of: Bool.self)
+
+    // Both the timeout and the warm up complete on 'eventLoop': whichever is first wins.
+    var isComplete = false
+    func complete(_ warm: Bool) {
+      if !isComplete {
+        isComplete = true
+        promise.succeed(warm)
+      }
+    }
+
+    let timeout: Scheduled<Void>?
+    if deadline == .distantFuture {
+      timeout = nil
+    } else {
+      timeout = eventLoop.scheduleTask(deadline: deadline) {
+        complete(false)
+      }
+    }
+
+    self.waitForReady().flatMap { () -> EventLoopFuture<Bool> in
+      guard let path = primingPath else {
+        return eventLoop.makeSucceededFuture(true)
+      }
+
+      return self.makePrimingCall(
+        path: path,
+        timeLimit: .deadline(deadline),
+        logger: self.configuration.backgroundActivityLogger
+      )
+    }.hop(to: eventLoop).whenComplete { result in
+      timeout?.cancel()
+      switch result {
+      case let .success(primed):
+        complete(primed)
+      case let .failure(error):
+        self.configuration.backgroundActivityLogger.debug("connection warm up failed", metadata: [
+          MetadataKey.error: "\(error)",
+        ])
+        complete(false)
+      }
+    }
+
+    return promise.futureResult
   }
 
   /// Closes the connection to the server.
@@ -129,6 +235,40 @@ public class ClientConnection {
     return self.connectionManager.shutdown()
   }
 
+  /// Drains the current connection, for example ahead of a deploy or certificate rotation on the
+  /// server, without closing the `ClientConnection`.
+  ///
+  /// RPCs already in progress on the current connection are allowed to complete while new RPCs
+  /// are made on a new connection, which is established when the next RPC is started. A GOAWAY
+  /// frame is sent on the drained connection and it is closed once its RPCs have completed or,
+  /// if `grace` is not `nil`, once it has elapsed, in which case RPCs still running on the drained
+  /// connection fail with status code 'unavailable'. RPCs are never moved between connections.
+  ///
+  /// This has no effect if there is no ready connection.
+  ///
+  /// - Parameter grace: The time to allow RPCs in progress to complete in, if any. Defaults to
+  ///     `nil`.
+  /// - Returns: A future which is completed when the drained connection has been closed.
+  public func drainConnection(grace: TimeAmount? = nil) -> EventLoopFuture<Void> {
+    return self.connectionManager.drain(grace: grace)
+  }
+
+  /// Measures the round-trip time to the server by sending an HTTP/2 PING frame on the current
+  /// connection and waiting for it to be acknowledged. This may be used as an active probe when
+  /// diagnosing latency.
+  ///
+  /// The returned future fails if the connection is not currently ready; this function does not
+  /// cause a connection to be established.
+  ///
+  /// Servers may close connections which receive too many pings while no RPCs are in progress. To
+  /// avoid this, the PING frames sent by this function are limited in the same way as keepalive
+  /// pings by the `maximumPingsWithoutData` and `minimumSentPingIntervalWithoutData` of the
+  /// connection's keepalive configuration. If sending a ping would exceed those limits then the
+  /// returned future fails with status code 'resourceExhausted'.
+  public func measureRoundTripTime() -> EventLoopFuture<TimeAmount> {
+    return self.connectionManager.measureRoundTripTime()
+  }
+
   /// Populates the logger in `options` and appends a request ID header to the metadata, if
   /// configured.
   /// - Parameter options: The options containing the logger to populate.
@@ -171,7 +311,17 @@ extension ClientConnection: GRPCChannel {
         authority: self.authority,
         scheme: self.scheme,
         maximumReceiveMessageLength: self.configuration.maximumReceiveMessageLength,
-        errorDelegate: self.configuration.errorDelegate
+        messageObserver: self.configuration.debugMessageObserver,
+        compressionStatisticsObserver: self.configuration.compressionStatisticsObserver,
+        errorDelegate: self.configuration.errorDelegate,
+        streamGate: self.streamGate,
+        wireProtocol: self.configuration.wireProtocol,
+        peerMaxHeaderListSize: { [connectionManager = self.connectionManager] in
+          connectionManager.peerMaxHeaderListSize
+        },
+        stopWaitingForMultiplexer: { [connectionManager = self.connectionManager] error in
+          connectionManager.stopWaitingForConnection(multiplexer, error: error)
+        }
       )
     )
   }
@@ -198,7 +348,17 @@ extension ClientConnection: GRPCChannel {
         authority: self.authority,
         scheme: self.scheme,
         maximumReceiveMessageLength: self.configuration.maximumReceiveMessageLength,
-        errorDelegate: self.configuration.errorDelegate
+        messageObserver: self.configuration.debugMessageObserver,
+        compressionStatisticsObserver: self.configuration.compressionStatisticsObserver,
+        errorDelegate: self.configuration.errorDelegate,
+        streamGate: self.streamGate,
+        wireProtocol: self.configuration.wireProtocol,
+        peerMaxHeaderListSize: { [connectionManager = self.connectionManager] in
+          connectionManager.peerMaxHeaderListSize
+        },
+        stopWaitingForMultiplexer: { [connectionManager = self.connectionManager] error in
+          connectionManager.stopWaitingForConnection(multiplexer, error: error)
+        }
       )
     )
   }
@@ -280,6 +440,32 @@ public struct CallStartBehavior: Hashable {
   public static let fastFailure = CallStartBehavior(.fastFailure)
 }
 
+/// When a `ClientConnection` should first attempt to connect to its target.
+public struct ConnectionStartBehavior: Hashable {
+  internal enum Behavior: Hashable {
+    case lazy
+    case eager
+  }
+
+  internal var wrapped: Behavior
+  private init(_ wrapped: Behavior) {
+    self.wrapped = wrapped
+  }
+
+  /// The connection is established when the first RPC is started, or when
+  /// `ClientConnection.waitForReady()` is called.
+  ///
+  /// This is the default behaviour.
+  public static let lazy = ConnectionStartBehavior(.lazy)
+
+  /// A connection attempt is started as soon as the `ClientConnection` is created. This surfaces
+  /// a misconfigured or unreachable target early: readiness may be awaited with
+  /// `ClientConnection.waitForReady()` or observed via the connectivity state.
+  ///
+  /// Note that the connection may still become idle, after which it is re-established lazily.
+  public static let eager = ConnectionStartBehavior(.eager)
+}
+
 extension ClientConnection {
   /// Configuration for a `ClientConnection`. Users should prefer using one of the
   /// `ClientConnection` builders: `ClientConnection.secure(_:)` or `ClientConnection.insecure(_:)`.
@@ -323,9 +509,26 @@ extension ClientConnection {
     /// be `nil`.
     public var connectionBackoff: ConnectionBackoff? = ConnectionBackoff()
 
+    /// The maximum amount of time to wait for a connection to be established. This bounds the TCP
+    /// connect, the TLS handshake (if TLS is enabled) and receiving the server's initial HTTP/2
+    /// SETTINGS frame. If the connection is not established in time then the attempt fails with
+    /// status code `.unavailable` and, if `connectionBackoff` is set, another attempt is scheduled.
+    ///
+    /// RPCs using the `.waitsForConnectivity` call start behavior continue to wait for subsequent
+    /// connection attempts (subject to their own deadline); `.fastFailure` RPCs fail.
+    ///
+    /// Defaults to `nil`, i.e. only the TCP connect is bounded (see
+    /// `ConnectionBackoff.minimumConnectionTimeout`).
+    public var connectTimeout: TimeAmount?
+
     /// The connection keepalive configuration.
     public var connectionKeepalive = ClientConnectionKeepalive()
 
+    /// A closure which is called with the round-trip time of each keepalive ping acknowledged by
+    /// the server. The closure is called on the `EventLoop` of the connection and must not block.
+    /// Defaults to `nil`.
+    public var keepaliveRoundTripTimeObserver: ((TimeAmount) -> Void)?
+
     /// The amount of time to wait before closing the connection. The idle timeout will start only
     /// if there are no RPCs in progress and will be cancelled as soon as any RPCs start.
     ///
@@ -334,15 +537,62 @@ extension ClientConnection {
     /// Defaults to 30 minutes.
     public var connectionIdleTimeout: TimeAmount = .minutes(30)
 
+    /// The clock used to schedule the connection level timers: the idle timeout, keepalive pings
+    /// and their timeouts. It's also used to measure the round-trip time of pings.
+    ///
+    /// A different clock is only useful for testing. Defaults to `nil`, i.e. the timers are
+    /// scheduled on the `EventLoop` of the connection. See `GRPCClock` for details.
+    public var connectionClock: GRPCClock?
+
     /// The behavior used to determine when an RPC should start. That is, whether it should wait for
     /// an active connection or fail quickly if no connection is currently available.
     ///
     /// Defaults to `waitsForConnectivity`.
     public var callStartBehavior: CallStartBehavior = .waitsForConnectivity
 
+    /// The maximum number of RPCs which may wait for the connection to become ready when using
+    /// the `waitsForConnectivity` call start behavior. RPCs started once the limit is reached fail
+    /// immediately with status code 'unavailable' rather than waiting. RPCs which are cancelled or
+    /// time out while waiting no longer count towards the limit.
+    ///
+    /// Defaults to `nil`, meaning the number of waiting RPCs is not limited.
+    public var maxWaitersForConnection: Int?
+
+    /// A closure which is called with the number of RPCs waiting for the connection to become
+    /// ready each time it changes, for example to export it as a metric. The closure is called on
+    /// the `EventLoop` of the connection and must not block. Defaults to `nil`.
+    public var waitersForConnectionObserver: ((Int) -> Void)?
+
+    /// Whether the connection should be established when the first RPC is started or as soon as
+    /// the `ClientConnection` is created.
+    ///
+    /// Defaults to `lazy`.
+    public var connectionStartBehavior: ConnectionStartBehavior = .lazy
+
     /// The HTTP/2 flow control target window size. Defaults to 65535.
     public var httpTargetWindowSize = 65535
 
+    /// The maximum size, in bytes, of the HPACK dynamic table used to decode headers received
+    /// from the remote peer. This is advertised to the peer as SETTINGS_HEADER_TABLE_SIZE and
+    /// bounds the table the peer's encoder may use. Larger tables use more memory per connection
+    /// but can compress repeated headers, such as access tokens, more effectively. Must be in the
+    /// range `0 ... 2^32-1`, otherwise connection attempts fail.
+    ///
+    /// Defaults to 4096, the HTTP/2 default.
+    public var httpHeaderTableSize: Int = 4096
+
+    /// Options applied to the socket of each connection. Defaults to disabling Nagle's algorithm
+    /// (`TCP_NODELAY`) and enabling `SO_REUSEADDR`, see `GRPCSocketOptions`.
+    public var socketOptions = GRPCSocketOptions()
+
+    /// A limit on the number of concurrent RPCs on the connection. RPCs started while the limit is
+    /// reached are queued until an RPC finishes. Defaults to `nil`, RPCs are not limited by the
+    /// client. RPCs on the connection fail if the limit is invalid.
+    public var concurrentStreamLimit: ClientConcurrentStreamLimit?
+
+    /// The protocol used to make RPCs. Defaults to `.grpc`.
+    public var wireProtocol: ClientWireProtocol = .grpc
+
     /// The HTTP protocol used for this connection.
     public var httpProtocol: HTTP2FramePayloadToHTTP1ClientCodec.HTTPProtocol {
       return self.tlsConfiguration == nil ? .http : .https
@@ -370,6 +620,27 @@ extension ClientConnection {
     /// - Warning: The initializer closure may be invoked *multiple times*.
     public var debugChannelInitializer: ((Channel) -> EventLoopFuture<Void>)?
 
+    /// A logger which, if set, logs every HTTP/2 frame sent or received on the connection at the
+    /// 'debug' level. This is intended for debugging and is very verbose. Header values which
+    /// typically carry credentials are redacted and the contents of DATA frames are not logged.
+    /// Defaults to `nil`, i.e. frames aren't logged.
+    public var debugHTTP2FrameLogger: Logger?
+
+    /// A closure which is called with every serialized message sent or received by RPCs on this
+    /// connection. This is intended for debugging and tooling, such as recording traffic.
+    ///
+    /// The closure is called on the `EventLoop` of the connection and must not block. Messages are
+    /// observed without being copied. Defaults to `nil`, in which case observation has no cost.
+    public var debugMessageObserver: ((ObservedMessage) -> Void)?
+
+    /// A closure which is called with the compression statistics of each RPC on this connection
+    /// once the RPC's stream has closed. This may be used to judge whether compressing messages
+    /// is worth the CPU time it costs.
+    ///
+    /// The closure is called on the `EventLoop` of the connection and must not block. Defaults to
+    /// `nil`, in which case no statistics are collected.
+    public var compressionStatisticsObserver: ((CompressionStatistics) -> Void)?
+
     /// Create a `Configuration` with some pre-defined defaults. Prefer using
     /// `ClientConnection.secure(group:)` to build a connection secured with TLS or
     /// `ClientConnection.insecure(group:)` to build a plaintext connection.
@@ -447,6 +718,59 @@ extension ClientConnection {
   }
 }
 
+extension ClientConnection.Configuration {
+  /// An error describing why a connection can't be established with this configuration, or `nil`
+  /// if the configuration is valid.
+  internal var validationError: GRPCError.InvalidState? {
+    if let backoff = self.connectionBackoff {
+      guard (0.0 ... 1.0).contains(backoff.jitter) else {
+        return GRPCError.InvalidState(
+          "Connection backoff jitter must be in the range 0...1 (but was \(backoff.jitter))"
+        )
+      }
+
+      guard backoff.multiplier > 0 else {
+        return GRPCError.InvalidState(
+          "Connection backoff multiplier must be positive (but was \(backoff.multiplier))"
+        )
+      }
+    }
+
+    let windowSize = self.httpTargetWindowSize
+    guard (1 ... Int(Int32.max)).contains(windowSize) else {
+      return GRPCError.InvalidState(
+        "The HTTP/2 target window size must be in the range 1...\(Int32.max) "
+          + "(but was \(windowSize))"
+      )
+    }
+
+    guard isValidHTTPHeaderTableSize(self.httpHeaderTableSize) else {
+      return GRPCError.InvalidState(
+        "The HTTP/2 header table size must be in the range 0...\(UInt32.max) "
+          + "(but was \(self.httpHeaderTableSize))"
+      )
+    }
+
+    if let connectTimeout = self.connectTimeout, connectTimeout.nanoseconds <= 0 {
+      return GRPCError.InvalidState(
+        "The connect timeout must be positive (but was \(connectTimeout.nanoseconds)ns)"
+      )
+    }
+
+    if let maxWaiters = self.maxWaitersForConnection, maxWaiters < 0 {
+      return GRPCError.InvalidState(
+        "The maximum number of waiters for connection must not be negative (but was \(maxWaiters))"
+      )
+    }
+
+    if let error = self.concurrentStreamLimit?.validationError {
+      return error
+    }
+
+    return nil
+  }
+}
+
 // MARK: - Configuration helpers/extensions
 
 extension ClientBootstrapProtocol {
@@ -499,13 +823,20 @@ extension ChannelPipeline.SynchronousOperations {
     connectionManager: ConnectionManager,
     connectionKeepalive: ClientConnectionKeepalive,
     connectionIdleTimeout: TimeAmount,
+    connectionClock: GRPCClock? = nil,
     httpTargetWindowSize: Int,
+    httpHeaderTableSize: Int = defaultHTTPHeaderTableSize,
     errorDelegate: ClientErrorDelegate?,
+    frameLogger: Logger? = nil,
     logger: Logger
   ) throws {
     // We could use 'configureHTTP2Pipeline' here, but we need to add a few handlers between the
     // two HTTP/2 handlers so we'll do it manually instead.
-    try self.addHandler(NIOHTTP2Handler(mode: .client))
+    try self.addHandler(NIOHTTP2Handler(mode: .client, headerTableSize: httpHeaderTableSize))
+
+    if let frameLogger = frameLogger {
+      try self.addHandler(HTTP2FrameLoggingHandler(logger: frameLogger))
+    }
 
     let h2Multiplexer = HTTP2StreamMultiplexer(
       mode: .client,
@@ -522,6 +853,7 @@ extension ChannelPipeline.SynchronousOperations {
       multiplexer: h2Multiplexer,
       idleTimeout: connectionIdleTimeout,
       keepalive: connectionKeepalive,
+      clock: connectionClock,
       logger: logger
     ))
 
@@ -90,7 +90,9 @@ extension ClientMessageEncoding {
     /// The compression algorithm used for outbound messages.
     public var outbound: CompressionAlgorithm?
 
-    /// The set of compression algorithms advertised to the remote peer that they may use.
+    /// The compression algorithms advertised to the remote peer that they may use, in order of
+    /// preference. Servers compress responses using the first algorithm in this list which they
+    /// support.
     public var inbound: [CompressionAlgorithm]
 
     /// The decompression limit acceptable for responses. RPCs which receive a message whose
@@ -109,6 +111,20 @@ extension ClientMessageEncoding {
       )
     }
 
+    /// Compress requests using the given algorithm but only advertise 'identity' as acceptable for
+    /// responses. This may be used to prevent a server from compressing responses, even if it
+    /// supports compression.
+    public static func requestsOnly(
+      using algorithm: CompressionAlgorithm,
+      decompressionLimit: DecompressionLimit
+    ) -> Configuration {
+      return Configuration(
+        forRequests: algorithm,
+        acceptableForResponses: [.identity],
+        decompressionLimit: decompressionLimit
+      )
+    }
+
     internal var acceptEncodingHeader: String {
       return self.inbound.map { $0.name }.joined(separator: ",")
     }
@@ -146,17 +162,90 @@ extension ServerMessageEncoding {
     /// decompressed size exceeds the limit will be cancelled.
     public var decompressionLimit: DecompressionLimit
 
+    /// Whether responses may be compressed. If `false` then responses are never compressed,
+    /// regardless of the algorithms the client advertises as acceptable. Compressed requests are
+    /// still accepted.
+    public var compressResponses: Bool
+
+    /// The minimum size, in bytes, of a serialized response message for it to be compressed. If
+    /// set, a response message is only compressed if the client accepts one of the enabled
+    /// algorithms and its serialized size is at least this many bytes. Messages are also sent
+    /// uncompressed if compressing them would not make them smaller.
+    ///
+    /// If `nil` then every response message is compressed, if the client accepts one of the
+    /// enabled algorithms. Messages are never compressed if the RPC has disabled compression.
+    public var responseCompressionThreshold: Int?
+
     /// Create a configuration for server message encoding.
     ///
     /// - Parameters:
     ///   - enabledAlgorithms: The list of algorithms which are enabled.
     ///   - decompressionLimit: Decompression limit acceptable for requests.
+    ///   - compressResponses: Whether responses may be compressed. Defaults to `true`.
+    ///   - responseCompressionThreshold: The minimum serialized size of a response message for it
+    ///     to be compressed. Defaults to `nil`, i.e. all response messages may be compressed.
     public init(
       enabledAlgorithms: [CompressionAlgorithm] = CompressionAlgorithm.all,
-      decompressionLimit: DecompressionLimit
+      decompressionLimit: DecompressionLimit,
+      compressResponses: Bool = true,
+      responseCompressionThreshold: Int? = nil
     ) {
       self.enabledAlgorithms = enabledAlgorithms
       self.decompressionLimit = decompressionLimit
+      self.compressResponses = compressResponses
+      self.responseCompressionThreshold = responseCompressionThreshold
+    }
+  }
+}
+
+extension ServerMessageEncoding.Configuration {
+  /// Selects the algorithm used to compress responses from the values of the
+  /// 'grpc-accept-encoding' header sent by the client.
+  ///
+  /// The client lists algorithms in order of preference. Each may also carry a weight from 0 to 1
+  /// (such as "gzip;q=0.5", the weight defaults to 1). Algorithms are ranked by weight and then by
+  /// their position in the list, with algorithms weighted 0 never being used. The highest ranked
+  /// algorithm which is enabled is selected.
+  ///
+  /// - Parameter acceptEncoding: The comma separated values of the 'grpc-accept-encoding' header.
+  /// - Returns: The algorithm to compress responses with, or `nil` if there isn't one.
+  internal func responseAlgorithm(acceptEncoding: [String]) -> CompressionAlgorithm? {
+    var candidates: [(algorithm: CompressionAlgorithm, weight: Double, index: Int)] = []
+
+    for (index, value) in acceptEncoding.enumerated() {
+      let parts = value.split(separator: ";")
+      guard let name = parts.first?.trimmingWhitespace(),
+        let algorithm = CompressionAlgorithm(rawValue: name.lowercased()),
+        self.enabledAlgorithms.contains(algorithm) else {
+        continue
+      }
+
+      var weight = 1.0
+      for parameter in parts.dropFirst().map({ $0.trimmingWhitespace() }) {
+        if parameter.hasPrefix("q="), let value = Double(parameter.dropFirst(2)) {
+          weight = value
+        }
+      }
+
+      if weight > 0 {
+        candidates.append((algorithm, weight, index))
+      }
+    }
+
+    // Highest weight first, then earliest in the list.
+    return candidates.min { lhs, rhs in
+      lhs.weight == rhs.weight ? lhs.index < rhs.index : lhs.weight > rhs.weight
+    }?.algorithm
+  }
+}
+
+extension Substring {
+  fileprivate func trimmingWhitespace() -> Substring {
+    let isWhitespace: (Character) -> Bool = { $0 == " " || $0 == "\t" }
+    guard let start = self.firstIndex(where: { !isWhitespace($0) }),
+      let end = self.lastIndex(where: { !isWhitespace($0) }) else {
+      return ""
     }
+    return self[start ... end]
   }
 }
@@ -0,0 +1,111 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// Statistics about the messages sent and received by a single RPC, used to judge how effective
+/// message compression is.
+///
+/// Statistics are passed to the `compressionStatisticsObserver` of a `ClientConnection` or
+/// `Server` configuration once the RPC's HTTP/2 stream has closed.
+public struct CompressionStatistics {
+  /// Totals for the messages sent or received by an RPC.
+  public struct MessageTotals: Hashable {
+    /// The number of messages.
+    public var messages: Int
+
+    /// The number of bytes in the serialized messages before compression (or after
+    /// decompression).
+    public var uncompressedBytes: Int
+
+    /// The number of message bytes sent or received on the wire, i.e. after compression (or
+    /// before decompression). This excludes the 5-byte gRPC length-prefix of each message. It is
+    /// equal to `uncompressedBytes` if the messages weren't compressed.
+    public var wireBytes: Int
+
+    /// The time spent framing and compressing sent messages, or parsing and decompressing
+    /// received messages.
+    public var duration: TimeAmount
+
+    /// The ratio of uncompressed bytes to bytes on the wire, or `nil` if no bytes were sent on
+    /// the wire. Higher values indicate more effective compression; a value of 1 indicates no
+    /// compression.
+    public var compressionRatio: Double? {
+      guard self.wireBytes > 0 else {
+        return nil
+      }
+      return Double(self.uncompressedBytes) / Double(self.wireBytes)
+    }
+
+    public init(
+      messages: Int = 0,
+      uncompressedBytes: Int = 0,
+      wireBytes: Int = 0,
+      duration: TimeAmount = .nanoseconds(0)
+    ) {
+      self.messages = messages
+      self.uncompressedBytes = uncompressedBytes
+      self.wireBytes = wireBytes
+      self.duration = duration
+    }
+  }
+
+  /// The path of the RPC, e.g. "/echo.Echo/Get".
+  public var path: String
+
+  /// Totals for the messages sent by this peer: requests for a client, responses for a server.
+  public var sent: MessageTotals
+
+  /// Totals for the messages received by this peer: responses for a client, requests for a
+  /// server.
+  public var received: MessageTotals
+
+  public init(path: String, sent: MessageTotals, received: MessageTotals) {
+    self.path = path
+    self.sent = sent
+    self.received = received
+  }
+}
+
+extension CompressionStatistics.MessageTotals {
+  /// The size of the gRPC length-prefix which precedes each message on the wire.
+  private static let prefixLength = 5
+
+  /// Records a framed message of `framedBytes` produced from `uncompressedBytes`.
+  internal mutating func recordSent(
+    uncompressedBytes: Int,
+    framedBytes: Int,
+    duration: TimeAmount
+  ) {
+    self.messages += 1
+    self.uncompressedBytes += uncompressedBytes
+    self.wireBytes += framedBytes - Self.prefixLength
+    self.duration = self.duration + duration
+  }
+
+  /// Records `framedBytes` received from the wire. The bytes may contain any number of (partial)
+  /// messages.
+  internal mutating func recordReceived(framedBytes: Int) {
+    self.wireBytes += framedBytes
+  }
+
+  /// Records messages parsed from previously recorded wire bytes.
+  internal mutating func recordReceived(messages: [ByteBuffer], duration: TimeAmount) {
+    self.messages += messages.count
+    self.wireBytes -= messages.count * Self.prefixLength
+    self.uncompressedBytes += messages.reduce(0) { $0 + $1.readableBytes }
+    self.duration = self.duration + duration
+  }
+}
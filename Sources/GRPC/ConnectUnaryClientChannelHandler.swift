@@ -0,0 +1,357 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOHPACK
+import NIOHTTP2
+
+/// The protocol a client uses to make RPCs.
+public struct ClientWireProtocol: Hashable {
+  internal enum Wrapped: Hashable {
+    case grpc
+    case connect
+  }
+
+  internal var wrapped: Wrapped
+  private init(_ wrapped: Wrapped) {
+    self.wrapped = wrapped
+  }
+
+  /// The gRPC protocol over HTTP/2.
+  public static let grpc = ClientWireProtocol(.grpc)
+
+  /// The unary subset of the Connect protocol over HTTP/2 with binary protobuf messages.
+  ///
+  /// Each request is sent as the body of a POST request with content-type 'application/proto',
+  /// errors are read from the JSON body of non-200 responses and trailing metadata is read from
+  /// headers prefixed with 'trailer-'. Streaming RPCs fail with status code 'unimplemented' and
+  /// messages are never compressed.
+  ///
+  /// See: https://connectrpc.com/docs/protocol
+  public static let connect = ClientWireProtocol(.connect)
+}
+
+/// A channel handler for clients which translates HTTP/2 frames into messages using the unary
+/// subset of the Connect protocol. It is a drop in replacement for `GRPCClientChannelHandler` for
+/// unary RPCs.
+internal final class ConnectUnaryClientChannelHandler {
+  private enum State {
+    /// The request head hasn't been written yet.
+    case idle
+
+    /// The request head has been written and we're waiting for the response head.
+    case requestSent(requestMessageSent: Bool)
+
+    /// The response head has been received and we're collecting the response body.
+    case receivingResponse(status: String?, headers: HPACKHeaders, body: ByteBuffer)
+
+    /// The response has been received, or the RPC failed.
+    case closed
+  }
+
+  private let callType: GRPCCallType
+  private let maximumReceiveMessageLength: Int
+  private let logger: GRPCLogger
+  private var state: State = .idle
+
+  /// The time limit of the RPC and the clock it's measured by, used to determine the
+  /// 'connect-timeout-ms' sent to the server.
+  private let timeLimit: TimeLimit
+  private let clock: GRPCClock
+
+  internal init(
+    callType: GRPCCallType,
+    timeLimit: TimeLimit = .none,
+    clock: GRPCClock = .system,
+    maximumReceiveMessageLength: Int,
+    logger: GRPCLogger
+  ) {
+    self.callType = callType
+    self.timeLimit = timeLimit
+    self.clock = clock
+    self.maximumReceiveMessageLength = maximumReceiveMessageLength
+    self.logger = logger
+  }
+
+  /// The prefix of headers which carry trailing metadata in unary Connect responses.
+  private static let trailerPrefix = "trailer-"
+
+  /// Connect error codes and their gRPC equivalents.
+  private static let errorCodes: [String: GRPCStatus.Code] = [
+    "canceled": .cancelled,
+    "unknown": .unknown,
+    "invalid_argument": .invalidArgument,
+    "deadline_exceeded": .deadlineExceeded,
+    "not_found": .notFound,
+    "already_exists": .alreadyExists,
+    "permission_denied": .permissionDenied,
+    "resource_exhausted": .resourceExhausted,
+    "failed_precondition": .failedPrecondition,
+    "aborted": .aborted,
+    "out_of_range": .outOfRange,
+    "unimplemented": .unimplemented,
+    "internal": .internalError,
+    "unavailable": .unavailable,
+    "data_loss": .dataLoss,
+    "unauthenticated": .unauthenticated,
+  ]
+}
+
+// MARK: - Inbound
+
+extension ConnectUnaryClientChannelHandler: ChannelInboundHandler {
+  internal typealias InboundIn = HTTP2Frame.FramePayload
+  internal typealias InboundOut = _RawGRPCClientResponsePart
+
+  internal func channelRead(context: ChannelHandlerContext, data: NIOAny) {
+    switch self.unwrapInboundIn(data) {
+    case let .headers(content):
+      self.readHeaders(content: content, context: context)
+
+    case let .data(content):
+      guard case let .byteBuffer(buffer) = content.data else {
+        preconditionFailure("Received DATA frame with non-ByteBuffer IOData")
+      }
+      self.readBody(buffer, endStream: content.endStream, context: context)
+
+    default:
+      ()
+    }
+  }
+
+  private func readHeaders(
+    content: HTTP2Frame.FramePayload.Headers,
+    context: ChannelHandlerContext
+  ) {
+    self.logger.trace("received HTTP2 frame", metadata: [
+      MetadataKey.h2Payload: "HEADERS",
+      MetadataKey.h2Headers: "\(content.headers.redacting())",
+      MetadataKey.h2EndStream: "\(content.endStream)",
+    ])
+
+    switch self.state {
+    case .requestSent:
+      let status = content.headers.first(name: ":status")
+      self.state = .receivingResponse(status: status, headers: content.headers, body: ByteBuffer())
+      if content.endStream {
+        self.readBody(ByteBuffer(), endStream: true, context: context)
+      }
+
+    case .receivingResponse(let status, var headers, let body):
+      // Unary Connect responses don't have trailers but tolerate them as trailing metadata.
+      headers.add(contentsOf: content.headers.map { name, value, indexable in
+        (ConnectUnaryClientChannelHandler.trailerPrefix + name, value, indexable)
+      })
+      self.state = .receivingResponse(status: status, headers: headers, body: body)
+      if content.endStream {
+        self.readBody(ByteBuffer(), endStream: true, context: context)
+      }
+
+    case .idle, .closed:
+      ()
+    }
+  }
+
+  private func readBody(
+    _ buffer: ByteBuffer,
+    endStream: Bool,
+    context: ChannelHandlerContext
+  ) {
+    guard case .receivingResponse(let status, let headers, var body) = self.state else {
+      return
+    }
+
+    var buffer = buffer
+    body.writeBuffer(&buffer)
+
+    if body.readableBytes > self.maximumReceiveMessageLength {
+      self.state = .closed
+      let error = GRPCError.PayloadLengthLimitExceeded(
+        actualLength: body.readableBytes,
+        limit: self.maximumReceiveMessageLength
+      )
+      context.fireErrorCaught(error.captureContext())
+      return
+    }
+
+    if endStream {
+      self.state = .closed
+      self.readResponse(status: status, headers: headers, body: body, context: context)
+    } else {
+      self.state = .receivingResponse(status: status, headers: headers, body: body)
+    }
+  }
+
+  /// Reads a complete response and forwards it to the next handler.
+  private func readResponse(
+    status: String?,
+    headers: HPACKHeaders,
+    body: ByteBuffer,
+    context: ChannelHandlerContext
+  ) {
+    // Split off the trailing metadata, dropping the prefix.
+    var initialMetadata = HPACKHeaders()
+    var trailingMetadata = HPACKHeaders()
+    let prefix = ConnectUnaryClientChannelHandler.trailerPrefix
+    for (name, value, indexable) in headers {
+      if name.hasPrefix(prefix) {
+        let trailerName = String(name.dropFirst(prefix.count))
+        trailingMetadata.add(name: trailerName, value: value, indexing: indexable)
+      } else {
+        initialMetadata.add(name: name, value: value, indexing: indexable)
+      }
+    }
+
+    guard status == "200" else {
+      // The response headers are still the initial metadata. However, errors carry all metadata
+      // so the trailing metadata includes the initial metadata too.
+      var metadata = initialMetadata
+      metadata.add(contentsOf: trailingMetadata)
+      let grpcStatus = self.parseError(body, httpStatus: status)
+      context.fireChannelRead(self.wrapInboundOut(.initialMetadata(initialMetadata)))
+      context.fireChannelRead(self.wrapInboundOut(.trailingMetadata(metadata)))
+      context.fireChannelRead(self.wrapInboundOut(.status(grpcStatus)))
+      return
+    }
+
+    let contentType = headers.first(name: "content-type")
+    guard contentType?.hasPrefix("application/proto") == true else {
+      let snippet = body.getString(at: body.readerIndex, length: min(body.readableBytes, 256))
+      let error = GRPCError.InvalidContentType(contentType, bodySnippet: snippet)
+      context.fireErrorCaught(error.captureContext())
+      return
+    }
+
+    if let encoding = headers.first(name: "content-encoding"), encoding != "identity" {
+      context.fireErrorCaught(GRPCError.CompressionUnsupported().captureContext())
+      return
+    }
+
+    context.fireChannelRead(self.wrapInboundOut(.initialMetadata(initialMetadata)))
+    context.fireChannelRead(self.wrapInboundOut(.message(.init(body, compressed: false))))
+    context.fireChannelRead(self.wrapInboundOut(.trailingMetadata(trailingMetadata)))
+    context.fireChannelRead(self.wrapInboundOut(.status(.ok)))
+  }
+
+  /// Parses the JSON body of an error response into a status. If the body can't be parsed then
+  /// the status code is derived from the HTTP status.
+  private func parseError(_ body: ByteBuffer, httpStatus: String?) -> GRPCStatus {
+    let bytes = body.readableBytesView
+    guard let json = try? JSONSerialization.jsonObject(with: Data(bytes)) as? [String: Any],
+      let codeName = json["code"] as? String,
+      let code = ConnectUnaryClientChannelHandler.errorCodes[codeName] else {
+      return GRPCError.InvalidHTTPStatus(httpStatus).makeGRPCStatus()
+    }
+
+    return GRPCStatus(code: code, message: json["message"] as? String)
+  }
+}
+
+// MARK: - Outbound
+
+extension ConnectUnaryClientChannelHandler: ChannelOutboundHandler {
+  internal typealias OutboundIn = _RawGRPCClientRequestPart
+  internal typealias OutboundOut = HTTP2Frame.FramePayload
+
+  internal func write(
+    context: ChannelHandlerContext,
+    data: NIOAny,
+    promise: EventLoopPromise<Void>?
+  ) {
+    switch (self.unwrapOutboundIn(data), self.state) {
+    case let (.head(head), .idle):
+      guard self.callType == .unary else {
+        self.state = .closed
+        let status = GRPCStatus(
+          code: .unimplemented,
+          message: "Only unary RPCs are supported by the Connect protocol"
+        )
+        promise?.fail(status)
+        context.fireErrorCaught(status)
+        return
+      }
+
+      self.state = .requestSent(requestMessageSent: false)
+      let headers = self.makeRequestHeaders(head)
+      self.logger.trace("writing HTTP2 frame", metadata: [
+        MetadataKey.h2Payload: "HEADERS",
+        MetadataKey.h2Headers: "\(headers.redacting())",
+        MetadataKey.h2EndStream: "false",
+      ])
+      context.write(self.wrapOutboundOut(.headers(.init(headers: headers))), promise: promise)
+
+    case let (.message(request), .requestSent(requestMessageSent: false)):
+      // The message is the entire body: it isn't length-prefixed or compressed.
+      self.state = .requestSent(requestMessageSent: true)
+      self.logger.trace("writing HTTP2 frame", metadata: [
+        MetadataKey.h2Payload: "DATA",
+        MetadataKey.h2DataBytes: "\(request.message.readableBytes)",
+        MetadataKey.h2EndStream: "false",
+      ])
+      let payload = HTTP2Frame.FramePayload.data(.init(data: .byteBuffer(request.message)))
+      context.write(self.wrapOutboundOut(payload), promise: promise)
+
+    case (.message, .requestSent(requestMessageSent: true)):
+      promise?.fail(GRPCError.StreamCardinalityViolation.request)
+
+    case (.end, .requestSent),
+         (.end, .receivingResponse):
+      let empty = context.channel.allocator.buffer(capacity: 0)
+      let payload = HTTP2Frame.FramePayload.data(.init(data: .byteBuffer(empty), endStream: true))
+      self.logger.trace("writing HTTP2 frame", metadata: [
+        MetadataKey.h2Payload: "DATA",
+        MetadataKey.h2DataBytes: "0",
+        MetadataKey.h2EndStream: "true",
+      ])
+      context.write(self.wrapOutboundOut(payload), promise: promise)
+
+    case (.head, _), (.message, _), (.end, _):
+      promise?.fail(GRPCError.InvalidState("unable to write request part"))
+    }
+  }
+
+  private func makeRequestHeaders(_ head: _GRPCRequestHead) -> HPACKHeaders {
+    var headers = HPACKHeaders()
+    headers.reserveCapacity(8 + head.customMetadata.count + head.unsafeRawHeaders.count)
+
+    headers.add(name: ":method", value: "POST")
+    headers.add(name: ":path", value: head.path)
+    headers.add(name: ":authority", value: head.host)
+    headers.add(name: ":scheme", value: head.scheme)
+    headers.add(name: "content-type", value: "application/proto")
+    headers.add(name: "connect-protocol-version", value: "1")
+
+    // The deadline is measured by the clock of the RPC: 'head.deadline' is only meaningful to
+    // the system clock.
+    let deadline = self.timeLimit.makeDeadline(using: self.clock)
+    if deadline != .distantFuture {
+      // Round up so that an RPC with time remaining isn't sent with a zero timeout.
+      let remaining = max((deadline - self.clock.now()).nanoseconds, 0)
+      headers.add(name: "connect-timeout-ms", value: String((remaining + 999_999) / 1_000_000))
+    }
+
+    headers.add(contentsOf: head.customMetadata.lazy.map { name, value, indexing in
+      (name.lowercased(), value, indexing)
+    })
+
+    if !head.customMetadata.contains(name: "user-agent") {
+      headers.add(name: "user-agent", value: GRPCClientStateMachine.userAgent)
+    }
+
+    headers.add(contentsOf: head.unsafeRawHeaders)
+    return headers
+  }
+}
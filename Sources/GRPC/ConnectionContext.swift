@@ -0,0 +1,70 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// State shared by every RPC on a connection accepted by a server.
+///
+/// Unlike the `UserInfo` of a call context, which is per-RPC, the `userInfo` of a
+/// `ConnectionContext` is shared by all RPCs on the same connection. This may be used to store
+/// connection-scoped state, such as the result of an authentication handshake performed by an
+/// earlier RPC:
+///
+/// ```
+/// enum SessionKey: UserInfo.Key {
+///   typealias Value = Session
+/// }
+///
+/// // In the handshake RPC:
+/// context.connection?.userInfo[SessionKey.self] = session
+///
+/// // In subsequent RPCs on the same connection:
+/// let session = context.connection?.userInfo[SessionKey.self]
+/// ```
+///
+/// The `userInfo` is emptied when the connection closes.
+public final class ConnectionContext {
+  /// The `EventLoop` of the connection. All RPCs on the connection run on this event loop.
+  public let eventLoop: EventLoop
+
+  /// The address of the remote peer, if known.
+  public let remoteAddress: SocketAddress?
+
+  /// A `UserInfo` dictionary shared by all RPCs on the connection.
+  ///
+  /// - Important: This *must* be accessed from the connection's `eventLoop`.
+  public var userInfo: UserInfo {
+    get {
+      self.eventLoop.assertInEventLoop()
+      return self._userInfo
+    }
+    set {
+      self.eventLoop.assertInEventLoop()
+      self._userInfo = newValue
+    }
+  }
+
+  private var _userInfo = UserInfo()
+
+  /// Creates a context for the connection over the given channel. The `userInfo` is emptied when
+  /// the channel closes.
+  internal init(channel: Channel) {
+    self.eventLoop = channel.eventLoop
+    self.remoteAddress = channel.remoteAddress
+    channel.closeFuture.whenComplete { _ in
+      self._userInfo = UserInfo()
+    }
+  }
+}
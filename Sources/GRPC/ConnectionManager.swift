@@ -193,8 +193,10 @@ internal final class ConnectionManager {
     didSet {
       switch self.state {
       case .idle:
+        self.cancelConnectTimeout()
         self.updateExternalState(to: .idle)
         self.updateConnectionID()
+        self.peerMaxHeaderListSize = nil
 
       case .connecting:
         self.updateExternalState(to: .connecting)
@@ -204,13 +206,17 @@ internal final class ConnectionManager {
         ()
 
       case .ready:
+        self.cancelConnectTimeout()
         self.updateExternalState(to: .ready)
 
       case .transientFailure:
+        self.cancelConnectTimeout()
         self.updateExternalState(to: .transientFailure)
         self.updateConnectionID()
+        self.peerMaxHeaderListSize = nil
 
       case .shutdown:
+        self.cancelConnectTimeout()
         self.updateExternalState(to: .shutdown)
       }
     }
@@ -248,6 +254,10 @@ internal final class ConnectionManager {
   /// A delegate for HTTP/2 connection changes. Executed on the `EventLoop`.
   private var http2Delegate: ConnectionManagerHTTP2Delegate?
 
+  /// Called with the round-trip time of each acknowledged keepalive ping. Executed on the
+  /// `EventLoop`.
+  private let keepaliveRoundTripTimeObserver: ((TimeAmount) -> Void)?
+
   /// An `EventLoopFuture<Channel>` provider.
   private let channelProvider: ConnectionManagerChannelProvider
 
@@ -259,6 +269,35 @@ internal final class ConnectionManager {
   /// attempts should be made at all.
   private let connectionBackoff: ConnectionBackoff?
 
+  /// The maximum amount of time to wait for a connection to be established, from starting to
+  /// connect to receiving the server's initial HTTP/2 SETTINGS frame, or `nil` if there is no limit.
+  private let connectTimeout: TimeAmount?
+
+  /// A task which closes the connection if it isn't established before `connectTimeout`.
+  private var scheduledConnectTimeout: Scheduled<Void>?
+
+  /// The maximum number of RPCs which may wait for the connection to become ready, or `nil` if
+  /// the number is not limited.
+  private let maxWaitersForConnection: Int?
+
+  /// Called with the number of RPCs waiting for the connection to become ready each time it
+  /// changes. Executed on the `EventLoop`.
+  private let waitersForConnectionObserver: ((Int) -> Void)?
+
+  /// The value of SETTINGS_MAX_HEADER_LIST_SIZE advertised by the peer on the current connection,
+  /// or `nil` if it hasn't advertised one (in which case the size is unlimited). Must only be
+  /// accessed on the `EventLoop`.
+  internal private(set) var peerMaxHeaderListSize: Int?
+
+  /// RPCs waiting for the connection to become ready, keyed by the identity of the future
+  /// returned to each of them.
+  private var waitersForConnection: [ObjectIdentifier: EventLoopPromise<HTTP2StreamMultiplexer>] =
+    [:] {
+    didSet {
+      self.waitersForConnectionObserver?(self.waitersForConnection.count)
+    }
+  }
+
   /// A logger.
   internal var logger: Logger
 
@@ -300,6 +339,10 @@ internal final class ConnectionManager {
       connectionBackoff: configuration.connectionBackoff,
       connectivityDelegate: connectivityDelegate,
       http2Delegate: nil,
+      keepaliveRoundTripTimeObserver: configuration.keepaliveRoundTripTimeObserver,
+      connectTimeout: configuration.connectTimeout,
+      maxWaitersForConnection: configuration.maxWaitersForConnection,
+      waitersForConnectionObserver: configuration.waitersForConnectionObserver,
       logger: logger
     )
   }
@@ -311,6 +354,10 @@ internal final class ConnectionManager {
     connectionBackoff: ConnectionBackoff?,
     connectivityDelegate: ConnectionManagerConnectivityDelegate?,
     http2Delegate: ConnectionManagerHTTP2Delegate?,
+    keepaliveRoundTripTimeObserver: ((TimeAmount) -> Void)? = nil,
+    connectTimeout: TimeAmount? = nil,
+    maxWaitersForConnection: Int? = nil,
+    waitersForConnectionObserver: ((Int) -> Void)? = nil,
     logger: Logger
   ) {
     // Setup the logger.
@@ -327,6 +374,10 @@ internal final class ConnectionManager {
     self.connectionBackoff = connectionBackoff
     self.connectivityDelegate = connectivityDelegate
     self.http2Delegate = http2Delegate
+    self.keepaliveRoundTripTimeObserver = keepaliveRoundTripTimeObserver
+    self.connectTimeout = connectTimeout
+    self.maxWaitersForConnection = maxWaitersForConnection
+    self.waitersForConnectionObserver = waitersForConnectionObserver
 
     self.connectionID = connectionID
     self.channelNumber = channelNumber
@@ -367,19 +418,19 @@ internal final class ConnectionManager {
       guard case let .connecting(connecting) = self.state else {
         self.invalidState()
       }
-      multiplexer = connecting.readyChannelMuxPromise.futureResult
+      multiplexer = self.waitForConnection(connecting.readyChannelMuxPromise.futureResult)
 
     case let .connecting(state):
-      multiplexer = state.readyChannelMuxPromise.futureResult
+      multiplexer = self.waitForConnection(state.readyChannelMuxPromise.futureResult)
 
     case let .active(state):
-      multiplexer = state.readyChannelMuxPromise.futureResult
+      multiplexer = self.waitForConnection(state.readyChannelMuxPromise.futureResult)
 
     case let .ready(state):
       multiplexer = self.eventLoop.makeSucceededFuture(state.multiplexer)
 
     case let .transientFailure(state):
-      multiplexer = state.readyChannelMuxPromise.futureResult
+      multiplexer = self.waitForConnection(state.readyChannelMuxPromise.futureResult)
 
     case let .shutdown(state):
       multiplexer = self.eventLoop.makeFailedFuture(state.reason)
@@ -392,6 +443,68 @@ internal final class ConnectionManager {
     return multiplexer
   }
 
+  /// Counts the caller as waiting for the connection to become ready until `multiplexer`
+  /// completes or the caller stops waiting with `stopWaitingForConnection(_:error:)`. Returns a
+  /// failed future instead if `maxWaitersForConnection` callers are already waiting.
+  private func waitForConnection(
+    _ multiplexer: EventLoopFuture<HTTP2StreamMultiplexer>
+  ) -> EventLoopFuture<HTTP2StreamMultiplexer> {
+    self.eventLoop.assertInEventLoop()
+
+    if let limit = self.maxWaitersForConnection, self.waitersForConnection.count >= limit {
+      self.logger.debug("too many RPCs waiting for connection", metadata: [
+        "waiters_for_connection": "\(self.waitersForConnection.count)",
+        "max_waiters_for_connection": "\(limit)",
+      ])
+      let status = GRPCStatus(code: .unavailable, message: "too many queued requests")
+      return self.eventLoop.makeFailedFuture(status)
+    }
+
+    // Each waiter has its own promise so that it can stop waiting without affecting others.
+    let promise = self.eventLoop.makePromise(of: HTTP2StreamMultiplexer.self)
+    let id = ObjectIdentifier(promise.futureResult)
+    self.waitersForConnection[id] = promise
+    multiplexer.whenComplete { result in
+      self.removeWaiterForConnection(withID: id)?.completeWith(result)
+    }
+    return promise.futureResult
+  }
+
+  /// Removes the waiter with the given ID, returning its promise, or `nil` if it isn't waiting.
+  private func removeWaiterForConnection(
+    withID id: ObjectIdentifier
+  ) -> EventLoopPromise<HTTP2StreamMultiplexer>? {
+    // Check first: removing a missing key would still notify the observer.
+    guard self.waitersForConnection[id] != nil else {
+      return nil
+    }
+    return self.waitersForConnection.removeValue(forKey: id)
+  }
+
+  /// Stops counting the caller which was given `multiplexer` by `getHTTP2Multiplexer()` as waiting
+  /// for the connection, for example because its RPC was cancelled or timed out. The future is
+  /// failed with `error` if it hasn't already completed.
+  internal func stopWaitingForConnection(
+    _ multiplexer: EventLoopFuture<HTTP2StreamMultiplexer>,
+    error: Error
+  ) {
+    if self.eventLoop.inEventLoop {
+      self._stopWaitingForConnection(multiplexer, error: error)
+    } else {
+      self.eventLoop.execute {
+        self._stopWaitingForConnection(multiplexer, error: error)
+      }
+    }
+  }
+
+  private func _stopWaitingForConnection(
+    _ multiplexer: EventLoopFuture<HTTP2StreamMultiplexer>,
+    error: Error
+  ) {
+    self.eventLoop.assertInEventLoop()
+    self.removeWaiterForConnection(withID: ObjectIdentifier(multiplexer))?.fail(error)
+  }
+
   /// Returns a future for the current HTTP/2 stream multiplexer, or future HTTP/2 stream multiplexer from the current connection
   /// attempt, or if the state is 'idle' returns the future for the next connection attempt.
   ///
@@ -444,6 +557,82 @@ internal final class ConnectionManager {
     }
   }
 
+  /// Gracefully close the current connection, if it is ready, without shutting down. This is a
+  /// request from the application.
+  ///
+  /// The manager stops vending the multiplexer of the current connection and goes idle: the next
+  /// request for a multiplexer establishes a new connection. A GOAWAY frame is sent on the current
+  /// connection and RPCs already in progress on it are allowed to complete, after which it is
+  /// closed. If `grace` is not `nil` then the connection is closed once it has elapsed, failing any
+  /// RPCs which are still running.
+  ///
+  /// - Parameter grace: The time to wait for RPCs in progress to complete, if any.
+  /// - Returns: A future which is completed when the drained connection has been closed.
+  internal func drain(grace: TimeAmount?) -> EventLoopFuture<Void> {
+    if self.eventLoop.inEventLoop {
+      return self._drain(grace: grace)
+    } else {
+      return self.eventLoop.flatSubmit {
+        return self._drain(grace: grace)
+      }
+    }
+  }
+
+  /// Measures the round-trip time of the current connection by sending an HTTP/2 PING frame. The
+  /// returned future fails if there is no ready connection.
+  internal func measureRoundTripTime() -> EventLoopFuture<TimeAmount> {
+    if self.eventLoop.inEventLoop {
+      return self._measureRoundTripTime()
+    } else {
+      return self.eventLoop.flatSubmit {
+        return self._measureRoundTripTime()
+      }
+    }
+  }
+
+  private func _measureRoundTripTime() -> EventLoopFuture<TimeAmount> {
+    switch self.state {
+    case let .ready(state):
+      return state.channel.pipeline.handler(type: GRPCIdleHandler.self).flatMap { handler in
+        let promise = state.channel.eventLoop.makePromise(of: TimeAmount.self)
+        handler.measureRoundTripTime(promise: promise)
+        return promise.futureResult
+      }
+
+    case .idle, .connecting, .active, .transientFailure, .shutdown:
+      return self.eventLoop.makeFailedFuture(
+        GRPCStatus(code: .unavailable, message: "No ready connection to measure")
+      )
+    }
+  }
+
+  private func _drain(grace: TimeAmount?) -> EventLoopFuture<Void> {
+    guard case let .ready(state) = self.state else {
+      // There's no connection in use to drain.
+      return self.eventLoop.makeSucceededFuture(())
+    }
+
+    self.logger.debug("draining connection", metadata: [
+      "connectivity_state": "\(self.state.label)",
+    ])
+
+    let channel = state.channel
+    // Stop hearing about the drained connection before going idle: it's no longer ours to manage.
+    channel.pipeline.fireUserInboundEventTriggered(ConnectionDrainEvent())
+    self.state = .idle
+
+    if let grace = grace {
+      let closeAfterGrace = self.eventLoop.scheduleTask(in: grace) {
+        channel.close(mode: .all, promise: nil)
+      }
+      channel.closeFuture.whenComplete { _ in
+        closeAfterGrace.cancel()
+      }
+    }
+
+    return channel.closeFuture
+  }
+
   private func _shutdown() -> EventLoopFuture<Void> {
     self.logger.debug("shutting down connection", metadata: [
       "connectivity_state": "\(self.state.label)",
@@ -595,7 +784,8 @@ internal final class ConnectionManager {
     switch self.state {
     // The channel is `active` but not `ready`. Should we try again?
     case let .active(active):
-      let error = GRPCStatus(
+      // Prefer the error which caused the connection to fail (e.g. the connect timeout), if any.
+      let error = active.error ?? GRPCStatus(
         code: .unavailable,
         message: "The connection was dropped and connection re-establishment is disabled"
       )
@@ -745,6 +935,11 @@ internal final class ConnectionManager {
     self.http2Delegate?.streamClosed(self)
   }
 
+  internal func maxHeaderListSizeChanged(_ maxHeaderListSize: Int) {
+    self.eventLoop.assertInEventLoop()
+    self.peerMaxHeaderListSize = maxHeaderListSize
+  }
+
   internal func maxConcurrentStreamsChanged(_ maxConcurrentStreams: Int) {
     self.eventLoop.assertInEventLoop()
     self.http2Delegate?.receivedSettingsMaxConcurrentStreams(
@@ -752,6 +947,15 @@ internal final class ConnectionManager {
     )
   }
 
+  /// A keepalive ping was acknowledged by the remote peer.
+  internal func keepaliveRoundTripTimeMeasured(_ roundTripTime: TimeAmount) {
+    self.eventLoop.assertInEventLoop()
+    self.logger.trace("keepalive ping acknowledged", metadata: [
+      "round_trip_time_ns": "\(roundTripTime.nanoseconds)",
+    ])
+    self.keepaliveRoundTripTimeObserver?(roundTripTime)
+  }
+
   /// The connection has started quiescing: notify the connectivity monitor of this.
   internal func beginQuiescing() {
     self.eventLoop.assertInEventLoop()
@@ -862,11 +1066,19 @@ extension ConnectionManager {
     // state change to `.connecting`.
     self.eventLoop.assertInEventLoop()
 
+    // The TCP connect is bounded by the smaller of the backoff's connection timeout and the
+    // overall connect timeout.
+    let socketConnectTimeouts: [TimeAmount?] = [
+      timeoutAndBackoff.map { .seconds(timeInterval: $0.timeout) },
+      self.connectTimeout,
+    ]
+    let socketConnectTimeout = socketConnectTimeouts.compactMap { $0 }.min()
+
     let candidate: EventLoopFuture<Channel> = self.eventLoop.flatSubmit {
       let channel: EventLoopFuture<Channel> = self.channelProvider.makeChannel(
         managedBy: self,
         onEventLoop: self.eventLoop,
-        connectTimeout: timeoutAndBackoff.map { .seconds(timeInterval: $0.timeout) },
+        connectTimeout: socketConnectTimeout,
         logger: self.logger
       )
 
@@ -888,6 +1100,48 @@ extension ConnectionManager {
     )
 
     self.state = .connecting(connecting)
+
+    if let connectTimeout = self.connectTimeout {
+      self.scheduledConnectTimeout = self.eventLoop.scheduleTask(in: connectTimeout) {
+        self.connectTimedOut(after: connectTimeout)
+      }
+    }
+  }
+
+  private func cancelConnectTimeout() {
+    self.scheduledConnectTimeout?.cancel()
+    self.scheduledConnectTimeout = nil
+  }
+
+  /// The connection wasn't established within the connect timeout.
+  private func connectTimedOut(after timeout: TimeAmount) {
+    self.eventLoop.assertInEventLoop()
+    self.scheduledConnectTimeout = nil
+
+    switch self.state {
+    // The TCP connection has been established but the TLS handshake or the initial HTTP/2 SETTINGS
+    // exchange hasn't completed. Close the channel: `channelInactive()` will reconnect or shutdown
+    // as appropriate.
+    case var .active(state):
+      let error = GRPCStatus(
+        code: .unavailable,
+        message: "Connection not established after \(timeout.nanoseconds / 1_000_000)ms: "
+          + "the TLS handshake or HTTP/2 SETTINGS exchange did not complete"
+      )
+      self.logger.debug("connect timeout elapsed", metadata: [
+        MetadataKey.error: "\(error)",
+      ])
+      state.error = error
+      self.state = .active(state)
+      state.candidate.close(mode: .all, promise: nil)
+
+    // The TCP connect is bounded by the same timeout so the candidate channel will fail.
+    case .connecting:
+      ()
+
+    case .idle, .ready, .transientFailure, .shutdown:
+      ()
+    }
   }
 }
 
@@ -44,36 +44,51 @@ internal struct DefaultChannelProvider: ConnectionManagerChannelProvider {
   internal var connectionTarget: ConnectionTarget
   internal var connectionKeepalive: ClientConnectionKeepalive
   internal var connectionIdleTimeout: TimeAmount
+  internal var connectionClock: GRPCClock?
 
   internal var tlsMode: TLSMode
   internal var tlsConfiguration: GRPCTLSConfiguration?
 
   internal var httpTargetWindowSize: Int
+  internal var httpHeaderTableSize: Int
+  internal var socketOptions: GRPCSocketOptions
+
+  /// An error to fail every connection attempt with, if the configuration is invalid.
+  internal var validationError: Optional<Error> = nil
 
   internal var errorDelegate: Optional<ClientErrorDelegate>
   internal var debugChannelInitializer: Optional<(Channel) -> EventLoopFuture<Void>>
+  internal var debugHTTP2FrameLogger: Optional<Logger>
 
   internal init(
     connectionTarget: ConnectionTarget,
     connectionKeepalive: ClientConnectionKeepalive,
     connectionIdleTimeout: TimeAmount,
+    connectionClock: GRPCClock? = nil,
     tlsMode: TLSMode,
     tlsConfiguration: GRPCTLSConfiguration?,
     httpTargetWindowSize: Int,
+    httpHeaderTableSize: Int = defaultHTTPHeaderTableSize,
+    socketOptions: GRPCSocketOptions = GRPCSocketOptions(),
     errorDelegate: ClientErrorDelegate?,
-    debugChannelInitializer: ((Channel) -> EventLoopFuture<Void>)?
+    debugChannelInitializer: ((Channel) -> EventLoopFuture<Void>)?,
+    debugHTTP2FrameLogger: Logger? = nil
   ) {
     self.connectionTarget = connectionTarget
     self.connectionKeepalive = connectionKeepalive
     self.connectionIdleTimeout = connectionIdleTimeout
+    self.connectionClock = connectionClock
 
     self.tlsMode = tlsMode
     self.tlsConfiguration = tlsConfiguration
 
     self.httpTargetWindowSize = httpTargetWindowSize
+    self.httpHeaderTableSize = httpHeaderTableSize
+    self.socketOptions = socketOptions
 
     self.errorDelegate = errorDelegate
     self.debugChannelInitializer = debugChannelInitializer
+    self.debugHTTP2FrameLogger = debugHTTP2FrameLogger
   }
 
   internal init(configuration: ClientConnection.Configuration) {
@@ -98,12 +113,19 @@ internal struct DefaultChannelProvider: ConnectionManagerChannelProvider {
       connectionTarget: configuration.target,
       connectionKeepalive: configuration.connectionKeepalive,
       connectionIdleTimeout: configuration.connectionIdleTimeout,
+      connectionClock: configuration.connectionClock,
       tlsMode: tlsMode,
       tlsConfiguration: configuration.tlsConfiguration,
       httpTargetWindowSize: configuration.httpTargetWindowSize,
+      httpHeaderTableSize: configuration.httpHeaderTableSize,
+      socketOptions: configuration.socketOptions,
       errorDelegate: configuration.errorDelegate,
-      debugChannelInitializer: configuration.debugChannelInitializer
+      debugChannelInitializer: configuration.debugChannelInitializer,
+      debugHTTP2FrameLogger: configuration.debugHTTP2FrameLogger
     )
+
+    // Like TLS errors, we're limited by our API in when we can surface configuration errors.
+    self.validationError = configuration.validationError
   }
 
   private var serverHostname: String? {
@@ -125,6 +147,10 @@ internal struct DefaultChannelProvider: ConnectionManagerChannelProvider {
     connectTimeout: TimeAmount?,
     logger: Logger
   ) -> EventLoopFuture<Channel> {
+    if let error = self.validationError {
+      return eventLoop.makeFailedFuture(error)
+    }
+
     let hostname = self.serverHostname
     let needsZeroLengthWriteWorkaround = self.requiresZeroLengthWorkaround(eventLoop: eventLoop)
 
@@ -135,8 +161,7 @@ internal struct DefaultChannelProvider: ConnectionManagerChannelProvider {
     )
 
     bootstrap = bootstrap
-      .channelOption(ChannelOptions.socket(SocketOptionLevel(SOL_SOCKET), SO_REUSEADDR), value: 1)
-      .channelOption(ChannelOptions.socket(IPPROTO_TCP, TCP_NODELAY), value: 1)
+      .socketOptions(self.socketOptions)
       .channelInitializer { channel in
         let sync = channel.pipeline.syncOperations
 
@@ -168,8 +193,11 @@ internal struct DefaultChannelProvider: ConnectionManagerChannelProvider {
             connectionManager: connectionManager,
             connectionKeepalive: self.connectionKeepalive,
             connectionIdleTimeout: self.connectionIdleTimeout,
+            connectionClock: self.connectionClock,
             httpTargetWindowSize: self.httpTargetWindowSize,
+            httpHeaderTableSize: self.httpHeaderTableSize,
             errorDelegate: self.errorDelegate,
+            frameLogger: self.debugHTTP2FrameLogger,
             logger: logger
           )
         } catch {
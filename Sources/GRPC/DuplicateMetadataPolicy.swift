@@ -0,0 +1,94 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIOHPACK
+
+/// How a server handles request metadata which contains the same key more than once.
+///
+/// Keys are compared case-insensitively. Pseudo-headers (such as ":path") are never altered.
+///
+/// The policy determines what is seen when reading the request metadata (`context.headers`):
+/// - `first(name:)` returns the first value for a key,
+/// - `headers[name]` returns every value for a key as a separate entry, in the order received,
+/// - `headers[canonicalForm: name]` additionally splits each value on commas, so values which
+///   were sent as a single comma-separated entry are returned in the same way as values sent as
+///   repeated entries.
+public struct DuplicateMetadataPolicy: Hashable {
+  internal enum Policy: Hashable {
+    case keepAll
+    case joinWithCommas
+    case keepFirst
+  }
+
+  internal var wrapped: Policy
+  private init(_ wrapped: Policy) {
+    self.wrapped = wrapped
+  }
+
+  /// Every value is kept as a separate entry, as received. This matches HTTP semantics, where
+  /// repeating a field is equivalent to sending its values as a comma-separated list.
+  ///
+  /// This is the default policy.
+  public static let keepAll = DuplicateMetadataPolicy(.keepAll)
+
+  /// The values of a repeated key are joined into a single entry, separated by ", ", in the
+  /// position of the first occurrence of the key.
+  public static let joinWithCommas = DuplicateMetadataPolicy(.joinWithCommas)
+
+  /// Only the first value of a repeated key is kept, later values are dropped.
+  public static let keepFirst = DuplicateMetadataPolicy(.keepFirst)
+}
+
+extension DuplicateMetadataPolicy {
+  /// Applies the policy to the given headers.
+  internal func apply(to headers: inout HPACKHeaders) {
+    if self.wrapped == .keepAll {
+      return
+    }
+
+    // Maps a lowercased key to the index of its first entry in 'entries'.
+    var firstIndices: [String: Int] = [:]
+    var entries: [(name: String, value: String, indexing: HPACKIndexing)] = []
+    var hasDuplicates = false
+
+    for (name, value, indexing) in headers {
+      if name.hasPrefix(":") {
+        entries.append((name, value, indexing))
+        continue
+      }
+
+      let key = name.lowercased()
+      if let index = firstIndices[key] {
+        hasDuplicates = true
+        if self.wrapped == .joinWithCommas {
+          entries[index].value += ", " + value
+        }
+      } else {
+        firstIndices[key] = entries.count
+        entries.append((name, value, indexing))
+      }
+    }
+
+    guard hasDuplicates else {
+      return
+    }
+
+    var deduplicated = HPACKHeaders()
+    for entry in entries {
+      deduplicated.add(name: entry.name, value: entry.value, indexing: entry.indexing)
+    }
+    headers = deduplicated
+  }
+}
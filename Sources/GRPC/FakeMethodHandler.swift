@@ -0,0 +1,227 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+
+/// Handles the RPCs of a single method of a generated fake provider.
+///
+/// Each method of a fake provider responds according to its `stub` and records the requests it
+/// receives. RPCs to a method without a stub fail with status code `.unimplemented`.
+///
+/// Users will typically not create a `FakeMethodHandler` directly, instead they should use the
+/// handlers of a fake provider generated with the `FakeProvider` option, for example:
+///
+/// ```
+/// let provider = Echo_EchoFakeProvider()
+/// provider.getHandler.stub = .respond(with: .with { $0.text = "foo" })
+/// // Serve the provider and run the client under test.
+/// XCTAssertEqual(provider.getHandler.receivedRequests.count, 1)
+/// ```
+///
+/// All methods may be called from any thread.
+public final class FakeMethodHandler<Request, Response> {
+  /// Canned behaviour for the RPCs of a method.
+  public struct Stub {
+    /// The responses to send. Unary and client streaming RPCs only send the first response.
+    public var responses: [Response]
+
+    /// The status to end the RPC with.
+    public var status: GRPCStatus
+
+    /// How long to wait before responding. For client and bidirectional streaming RPCs the delay
+    /// starts once the request stream has ended.
+    public var delay: TimeAmount
+
+    public init(
+      responses: [Response] = [],
+      status: GRPCStatus = .ok,
+      delay: TimeAmount = .nanoseconds(0)
+    ) {
+      self.responses = responses
+      self.status = status
+      self.delay = delay
+    }
+
+    /// Respond with a single response and an 'ok' status.
+    public static func respond(
+      with response: Response,
+      delay: TimeAmount = .nanoseconds(0)
+    ) -> Stub {
+      return Stub(responses: [response], delay: delay)
+    }
+
+    /// Respond with the given responses and status.
+    public static func respond(
+      with responses: [Response],
+      status: GRPCStatus = .ok,
+      delay: TimeAmount = .nanoseconds(0)
+    ) -> Stub {
+      return Stub(responses: responses, status: status, delay: delay)
+    }
+
+    /// Fail the RPC with the given status without sending any responses.
+    public static func fail(with status: GRPCStatus, delay: TimeAmount = .nanoseconds(0)) -> Stub {
+      return Stub(status: status, delay: delay)
+    }
+  }
+
+  private let lock = Lock()
+  private var _stub: Stub?
+  private var _receivedRequests: [Request] = []
+  private var _callCount = 0
+
+  public init(stub: Stub? = nil) {
+    self._stub = stub
+  }
+
+  /// The behaviour of RPCs started after the stub is set, or `nil` if RPCs should fail with status
+  /// code `.unimplemented`.
+  public var stub: Stub? {
+    get {
+      return self.lock.withLock { self._stub }
+    }
+    set {
+      self.lock.withLockVoid { self._stub = newValue }
+    }
+  }
+
+  /// The requests received by all RPCs, in the order they were received.
+  public var receivedRequests: [Request] {
+    return self.lock.withLock { self._receivedRequests }
+  }
+
+  /// The number of RPCs which have been started.
+  public var callCount: Int {
+    return self.lock.withLock { self._callCount }
+  }
+
+  /// Removes all received requests and resets the call count. The stub is not modified.
+  public func reset() {
+    self.lock.withLockVoid {
+      self._receivedRequests.removeAll()
+      self._callCount = 0
+    }
+  }
+
+  /// Records the start of an RPC and returns its stub.
+  private func start(request: Request? = nil) -> Stub? {
+    return self.lock.withLock {
+      self._callCount += 1
+      if let request = request {
+        self._receivedRequests.append(request)
+      }
+      return self._stub
+    }
+  }
+
+  private func record(_ request: Request) {
+    self.lock.withLockVoid {
+      self._receivedRequests.append(request)
+    }
+  }
+
+  /// Handles a unary RPC.
+  public func handleUnary(
+    request: Request,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Response> {
+    let stub = self.start(request: request)
+    return self.respond(with: stub, context: context)
+  }
+
+  /// Handles a server streaming RPC.
+  public func handleServerStreaming(
+    request: Request,
+    context: StreamingResponseCallContext<Response>
+  ) -> EventLoopFuture<GRPCStatus> {
+    let stub = self.start(request: request)
+    return self.respond(with: stub, context: context)
+  }
+
+  /// Handles a client streaming RPC.
+  public func handleClientStreaming(
+    context: UnaryResponseCallContext<Response>
+  ) -> EventLoopFuture<(StreamEvent<Request>) -> Void> {
+    let stub = self.start()
+    return context.eventLoop.makeSucceededFuture({ event in
+      switch event {
+      case let .message(request):
+        self.record(request)
+      case .end:
+        context.responsePromise.completeWith(self.respond(with: stub, context: context))
+      }
+    })
+  }
+
+  /// Handles a bidirectional streaming RPC.
+  public func handleBidirectionalStreaming(
+    context: StreamingResponseCallContext<Response>
+  ) -> EventLoopFuture<(StreamEvent<Request>) -> Void> {
+    let stub = self.start()
+    return context.eventLoop.makeSucceededFuture({ event in
+      switch event {
+      case let .message(request):
+        self.record(request)
+      case .end:
+        context.statusPromise.completeWith(self.respond(with: stub, context: context))
+      }
+    })
+  }
+
+  private func respond(
+    with stub: Stub?,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Response> {
+    guard let stub = stub else {
+      return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+    }
+
+    return self.delay(stub, on: context.eventLoop).flatMapThrowing {
+      guard stub.status.isOk else {
+        throw stub.status
+      }
+      guard let response = stub.responses.first else {
+        throw GRPCStatus(code: .internalError, message: "The stub has no response")
+      }
+      return response
+    }
+  }
+
+  private func respond(
+    with stub: Stub?,
+    context: StreamingResponseCallContext<Response>
+  ) -> EventLoopFuture<GRPCStatus> {
+    guard let stub = stub else {
+      return context.eventLoop.makeSucceededFuture(
+        GRPCStatus(code: .unimplemented, message: nil)
+      )
+    }
+
+    return self.delay(stub, on: context.eventLoop).flatMap {
+      context.sendResponses(stub.responses)
+    }.map {
+      stub.status
+    }
+  }
+
+  private func delay(_ stub: Stub, on eventLoop: EventLoop) -> EventLoopFuture<Void> {
+    if stub.delay > .nanoseconds(0) {
+      return eventLoop.scheduleTask(in: stub.delay) {}.futureResult
+    } else {
+      return eventLoop.makeSucceededVoidFuture()
+    }
+  }
+}
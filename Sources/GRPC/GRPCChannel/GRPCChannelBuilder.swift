@@ -120,6 +120,7 @@ extension ClientConnection {
 
     private var connectionBackoff = ConnectionBackoff()
     private var connectionBackoffIsEnabled = true
+    private var connectionBackoffIsCustomized = false
 
     fileprivate init(group: EventLoopGroup) {
       // This is okay: the configuration is only consumed on a call to `connect` which sets the host
@@ -128,6 +129,8 @@ extension ClientConnection {
     }
 
     public func connect(host: String, port: Int) -> ClientConnection {
+      self.validate()
+
       // Finish setting up the configuration.
       self.configuration.target = .hostAndPort(host, port)
       self.configuration.connectionBackoff =
@@ -138,6 +141,21 @@ extension ClientConnection {
   }
 }
 
+extension ClientConnection.Builder {
+  /// Checks that the options set on the builder are consistent with one another.
+  ///
+  /// Options which are valid but have no effect because of other options are logged as a
+  /// warning. Invalid options are surfaced by the connection: it is shutdown without connecting
+  /// and RPCs fail with a `GRPCError.InvalidState` (see `ClientConnection.init(configuration:)`).
+  private func validate() {
+    if !self.connectionBackoffIsEnabled, self.connectionBackoffIsCustomized {
+      self.configuration.backgroundActivityLogger.warning(
+        "ignoring connection backoff options: connection re-establishment is disabled"
+      )
+    }
+  }
+}
+
 extension ClientConnection.Builder {
   public class Secure: ClientConnection.Builder {
     internal var tls: GRPCTLSConfiguration
@@ -159,6 +177,7 @@ extension ClientConnection.Builder {
   /// 1 second if not set.
   @discardableResult
   public func withConnectionBackoff(initial amount: TimeAmount) -> Self {
+    self.connectionBackoffIsCustomized = true
     self.connectionBackoff.initialBackoff = .seconds(from: amount)
     return self
   }
@@ -168,6 +187,7 @@ extension ClientConnection.Builder {
   /// backoff *before* jitter is applied. Defaults to 120 seconds if not set.
   @discardableResult
   public func withConnectionBackoff(maximum amount: TimeAmount) -> Self {
+    self.connectionBackoffIsCustomized = true
     self.connectionBackoff.maximumBackoff = .seconds(from: amount)
     return self
   }
@@ -176,9 +196,10 @@ extension ClientConnection.Builder {
   /// establish a connection. The jittered backoff will be no more than `jitter ⨯ unjitteredBackoff`
   /// from `unjitteredBackoff`. Defaults to 0.2 if not set.
   ///
-  /// - Precondition: `0 <= jitter <= 1`
+  /// The jitter must be in the range `0 ... 1`, RPCs on the connection fail otherwise.
   @discardableResult
   public func withConnectionBackoff(jitter: Double) -> Self {
+    self.connectionBackoffIsCustomized = true
     self.connectionBackoff.jitter = jitter
     return self
   }
@@ -187,6 +208,7 @@ extension ClientConnection.Builder {
   /// Defaults to 1.6 if not set.
   @discardableResult
   public func withConnectionBackoff(multiplier: Double) -> Self {
+    self.connectionBackoffIsCustomized = true
     self.connectionBackoff.multiplier = multiplier
     return self
   }
@@ -196,14 +218,25 @@ extension ClientConnection.Builder {
   /// timeout. Defaults to 20 seconds if not set.
   @discardableResult
   public func withConnectionTimeout(minimum amount: TimeAmount) -> Self {
+    self.connectionBackoffIsCustomized = true
     self.connectionBackoff.minimumConnectionTimeout = .seconds(from: amount)
     return self
   }
 
+  /// The maximum amount of time to wait for each connection attempt to be established, including
+  /// the TCP connect, TLS handshake and initial HTTP/2 SETTINGS exchange. Attempts which take
+  /// longer fail with status code `.unavailable`. Not bounded (beyond the TCP connect) if not set.
+  @discardableResult
+  public func withConnectTimeout(_ timeout: TimeAmount) -> Self {
+    self.configuration.connectTimeout = timeout
+    return self
+  }
+
   /// Sets the initial and maximum backoff to given amount. Disables jitter and sets the backoff
   /// multiplier to 1.0.
   @discardableResult
   public func withConnectionBackoff(fixed amount: TimeAmount) -> Self {
+    self.connectionBackoffIsCustomized = true
     let seconds = Double.seconds(from: amount)
     self.connectionBackoff.initialBackoff = seconds
     self.connectionBackoff.maximumBackoff = seconds
@@ -216,6 +249,7 @@ extension ClientConnection.Builder {
   /// to `.unlimited` if not set.
   @discardableResult
   public func withConnectionBackoff(retries: ConnectionBackoff.Retries) -> Self {
+    self.connectionBackoffIsCustomized = true
     self.connectionBackoff.retries = retries
     return self
   }
@@ -237,6 +271,16 @@ extension ClientConnection.Builder {
     return self
   }
 
+  /// Sets a closure to call with the round-trip time of each keepalive ping acknowledged by the
+  /// server. The closure is called on the `EventLoop` of the connection and must not block.
+  @discardableResult
+  public func withKeepaliveRoundTripTimeObserver(
+    _ observer: @escaping (TimeAmount) -> Void
+  ) -> Self {
+    self.configuration.keepaliveRoundTripTimeObserver = observer
+    return self
+  }
+
   /// The amount of time to wait before closing the connection. The idle timeout will start only
   /// if there are no RPCs in progress and will be cancelled as soon as any RPCs start. If a
   /// connection becomes idle, starting a new RPC will automatically create a new connection.
@@ -255,6 +299,32 @@ extension ClientConnection.Builder {
     self.configuration.callStartBehavior = behavior
     return self
   }
+
+  /// Sets the maximum number of RPCs which may wait for the connection to become ready. RPCs
+  /// started once the limit is reached fail immediately with status code 'unavailable'. The
+  /// number of waiting RPCs is not limited by default.
+  @discardableResult
+  public func withMaxWaitersForConnection(_ limit: Int) -> Self {
+    self.configuration.maxWaitersForConnection = limit
+    return self
+  }
+
+  /// Sets a closure to call with the number of RPCs waiting for the connection to become ready
+  /// each time it changes. The closure is called on the `EventLoop` of the connection and must
+  /// not block.
+  @discardableResult
+  public func withWaitersForConnectionObserver(_ observer: @escaping (Int) -> Void) -> Self {
+    self.configuration.waitersForConnectionObserver = observer
+    return self
+  }
+
+  /// Whether the connection should be established when the first RPC is started (`.lazy`) or as
+  /// soon as the connection is created (`.eager`). Connections are `.lazy` by default.
+  @discardableResult
+  public func withConnectionStartBehavior(_ behavior: ConnectionStartBehavior) -> Self {
+    self.configuration.connectionStartBehavior = behavior
+    return self
+  }
 }
 
 extension ClientConnection.Builder {
@@ -390,6 +460,46 @@ extension ClientConnection.Builder {
   }
 }
 
+extension ClientConnection.Builder {
+  /// Sets the maximum size, in bytes, of the HPACK dynamic table used to decode headers from the
+  /// remote peer. Must be in the range `0 ... 2^32-1`. Defaults to 4096 if not explicitly set.
+  @discardableResult
+  public func withHTTPHeaderTableSize(_ httpHeaderTableSize: Int) -> Self {
+    self.configuration.httpHeaderTableSize = httpHeaderTableSize
+    return self
+  }
+}
+
+extension ClientConnection.Builder {
+  /// Sets the options applied to the socket of each connection. Defaults to disabling Nagle's
+  /// algorithm (`TCP_NODELAY`) and enabling `SO_REUSEADDR` if not explicitly set.
+  @discardableResult
+  public func withSocketOptions(_ socketOptions: GRPCSocketOptions) -> Self {
+    self.configuration.socketOptions = socketOptions
+    return self
+  }
+}
+
+extension ClientConnection.Builder {
+  /// Limits the number of concurrent RPCs on the connection. RPCs started while the limit is
+  /// reached are queued until an RPC finishes or their deadline passes. RPCs are not limited by
+  /// the client if not explicitly set.
+  @discardableResult
+  public func withConcurrentStreamLimit(_ limit: ClientConcurrentStreamLimit?) -> Self {
+    self.configuration.concurrentStreamLimit = limit
+    return self
+  }
+}
+
+extension ClientConnection.Builder {
+  /// Sets the protocol used to make RPCs. Defaults to `.grpc`.
+  @discardableResult
+  public func withWireProtocol(_ wireProtocol: ClientWireProtocol) -> Self {
+    self.configuration.wireProtocol = wireProtocol
+    return self
+  }
+}
+
 extension ClientConnection.Builder {
   /// Sets the maximum message size the client is permitted to receive in bytes.
   ///
@@ -426,6 +536,36 @@ extension ClientConnection.Builder {
     self.configuration.debugChannelInitializer = debugChannelInitializer
     return self
   }
+
+  /// A logger which logs every HTTP/2 frame sent or received on the connection at the 'debug'
+  /// level. This is intended for debugging and is very verbose.
+  @discardableResult
+  public func withDebugHTTP2FrameLogger(_ logger: Logger) -> Self {
+    self.configuration.debugHTTP2FrameLogger = logger
+    return self
+  }
+
+  /// A closure which is called with every serialized message sent or received by RPCs on the
+  /// connection. This is intended for debugging and tooling, such as recording traffic.
+  ///
+  /// The closure is called on the `EventLoop` of the connection and must not block.
+  @discardableResult
+  public func withDebugMessageObserver(
+    _ observer: @escaping (ObservedMessage) -> Void
+  ) -> Self {
+    self.configuration.debugMessageObserver = observer
+    return self
+  }
+
+  /// A closure which is called with the compression statistics of each RPC on the connection once
+  /// the RPC's stream has closed. The closure must not block.
+  @discardableResult
+  public func withCompressionStatisticsObserver(
+    _ observer: @escaping (CompressionStatistics) -> Void
+  ) -> Self {
+    self.configuration.compressionStatisticsObserver = observer
+    return self
+  }
 }
 
 private extension Double {
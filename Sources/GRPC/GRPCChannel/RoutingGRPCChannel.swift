@@ -0,0 +1,424 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+import SwiftProtobuf
+
+/// A `GRPCChannel` which routes each RPC to one of a number of underlying channels.
+///
+/// Channels are registered with a name. For each RPC a selector is called with the path of the RPC
+/// and its `CallOptions` (including any custom metadata) and returns the name of the channel to
+/// make the RPC on. This allows a single client to be used with multiple backends, for example to
+/// shard RPCs across backends based on a tenant ID in the request metadata.
+///
+/// Each underlying channel manages its own connections; RPCs routed to the same channel share
+/// that channel's connections.
+///
+/// Channels may be marked as unhealthy by the application, for example in response to an
+/// out-of-band health signal, with `markUnhealthy(_:for:grace:)`. New RPCs are not routed to
+/// unhealthy channels and the connection of an unhealthy `ClientConnection` is drained: RPCs
+/// already in progress on it are left to complete, after which the connection is closed. Once the
+/// cooldown passes the channel becomes eligible for new RPCs again and the next RPC routed to it
+/// establishes a new connection.
+///
+/// ```
+/// let channel = try RoutingGRPCChannel(
+///   channels: ["eu": euConnection, "us": usConnection],
+///   defaultChannel: "us"
+/// ) { path, callOptions in
+///   callOptions.customMetadata.first(name: "x-region")
+/// }
+///
+/// let echo = Echo_EchoClient(channel: channel)
+/// ```
+public final class RoutingGRPCChannel: GRPCChannel {
+  /// The channels RPCs may be routed to, keyed by name.
+  public let channels: [String: GRPCChannel]
+
+  /// The name of the channel to use when the selector returns `nil` or the name of a channel which
+  /// doesn't exist.
+  public let defaultChannel: String
+
+  /// Selects the name of the channel to use for an RPC.
+  private let selector: (String, CallOptions) -> String?
+
+  /// Protects `unhealthyUntil`.
+  private let lock = Lock()
+
+  /// The time until which each channel marked as unhealthy should not be used for new RPCs.
+  private var unhealthyUntil: [String: NIODeadline] = [:]
+
+  /// The clock used to determine when the cooldown of an unhealthy channel has passed.
+  private let clock: GRPCClock
+
+  /// Creates a channel which routes RPCs to one of the given channels.
+  ///
+  /// - Parameters:
+  ///   - channels: The channels RPCs may be routed to, keyed by name.
+  ///   - defaultChannel: The name of the channel to use when `selector` returns `nil` or the name
+  ///       of a channel which doesn't exist.
+  ///   - clock: The clock used to determine when the cooldown of an unhealthy channel has passed.
+  ///       Defaults to the system clock.
+  ///   - selector: A closure called with the path and call options of each RPC which returns the
+  ///       name of the channel to use for that RPC. The closure may be called from any thread.
+  /// - Throws: `GRPCError.InvalidState` if `channels` doesn't contain a channel named
+  ///   `defaultChannel`.
+  public init(
+    channels: [String: GRPCChannel],
+    defaultChannel: String,
+    clock: GRPCClock = .system,
+    selector: @escaping (_ path: String, _ callOptions: CallOptions) -> String?
+  ) throws {
+    guard channels[defaultChannel] != nil else {
+      throw GRPCError.InvalidState(
+        "No channel named '\(defaultChannel)' exists for the default channel"
+      )
+    }
+    self.channels = channels
+    self.defaultChannel = defaultChannel
+    self.clock = clock
+    self.selector = selector
+  }
+
+  /// Marks the named channel as unhealthy: new RPCs won't be routed to it until `cooldown` has
+  /// passed or `markHealthy(_:)` is called.
+  ///
+  /// If the channel is a `ClientConnection` then its current connection is also drained with
+  /// `drainConnection(grace:)`: RPCs already in progress are allowed to complete and the
+  /// connection is closed once they have or, if `grace` is not `nil`, once it has elapsed. The
+  /// `ClientConnection` isn't closed: the next RPC made on it, i.e. the first RPC routed to it
+  /// after the cooldown, establishes a new connection. Other types of channel only stop receiving
+  /// new RPCs.
+  ///
+  /// RPCs in progress are never moved to another channel: gRPC Swift can't tell whether an RPC is
+  /// idempotent. RPCs which are safe to retry may be made with a `RetryPolicy` instead.
+  ///
+  /// RPCs which would have been routed to an unhealthy channel are routed to the default channel
+  /// or, if that's unhealthy too, to the first healthy channel ordered by name. If every channel
+  /// is unhealthy then RPCs are routed as if all channels were healthy.
+  ///
+  /// Names of channels which don't exist are ignored.
+  ///
+  /// - Parameters:
+  ///   - name: The name of the channel.
+  ///   - cooldown: The amount of time to wait before routing new RPCs to the channel again.
+  ///   - grace: The time to allow RPCs in progress on the channel to complete in, if any. Defaults
+  ///       to `nil`.
+  public func markUnhealthy(_ name: String, for cooldown: TimeAmount, grace: TimeAmount? = nil) {
+    guard self.channels[name] != nil else {
+      return
+    }
+
+    let deadline = self.clock.now() + cooldown
+    self.lock.withLockVoid {
+      self.unhealthyUntil[name] = deadline
+    }
+
+    if let connection = self.channels[name] as? ClientConnection {
+      // Failures are surfaced via the connectivity state of the connection.
+      _ = connection.drainConnection(grace: grace)
+    }
+  }
+
+  /// Marks the named channel as healthy, allowing new RPCs to be routed to it immediately. Names
+  /// of channels which don't exist are ignored.
+  ///
+  /// - Parameter name: The name of the channel.
+  public func markHealthy(_ name: String) {
+    self.lock.withLockVoid {
+      self.unhealthyUntil.removeValue(forKey: name)
+    }
+  }
+
+  /// Returns whether new RPCs may be routed to the named channel, i.e. it hasn't been marked as
+  /// unhealthy or its cooldown has passed.
+  ///
+  /// - Parameter name: The name of the channel.
+  public func isHealthy(_ name: String) -> Bool {
+    let now = self.clock.now()
+    return self.lock.withLock {
+      self.isHealthy(name, now: now)
+    }
+  }
+
+  /// Returns whether the named channel is healthy at `now`, forgetting expired cooldowns.
+  ///
+  /// - Important: Must be called while holding `lock`.
+  private func isHealthy(_ name: String, now: NIODeadline) -> Bool {
+    guard let until = self.unhealthyUntil[name] else {
+      return true
+    }
+
+    if until <= now {
+      self.unhealthyUntil.removeValue(forKey: name)
+      return true
+    } else {
+      return false
+    }
+  }
+
+  /// Warms up each of the underlying channels which can be warmed up, see
+  /// `GRPCClient.warmUp(timeLimit:primingPath:)`. Channels are warmed up regardless of their
+  /// health.
+  ///
+  /// - Parameters:
+  ///   - timeLimit: The time limit for warming up each channel. Defaults to a timeout of 10
+  ///       seconds.
+  ///   - primingPath: The path of a method which accepts an empty request to call on each channel
+  ///       once connected, in the format "/Service/Method". Defaults to `nil`, no RPC is made.
+  /// - Returns: A future which succeeds with `true` if every channel was warmed up within the time
+  ///     limit, or `false` otherwise. Returns `nil` if none of the channels can be warmed up.
+  public func warmUp(
+    timeLimit: TimeLimit = .timeout(.seconds(10)),
+    primingPath: String? = nil
+  ) -> EventLoopFuture<Bool>? {
+    let warmUps = self.channels.keys.sorted().compactMap { name in
+      warmUpChannel(
+        self.channels[name]!,
+        timeLimit: timeLimit,
+        primingPath: primingPath,
+        logger: CallOptions().logger
+      )
+    }
+
+    guard let first = warmUps.first else {
+      return nil
+    }
+
+    return EventLoopFuture.whenAllSucceed(warmUps, on: first.eventLoop).map { warm in
+      warm.allSatisfy { $0 }
+    }
+  }
+
+  /// Returns the channel to make the RPC with the given path and options on.
+  private func channel(forPath path: String, callOptions: CallOptions) -> GRPCChannel {
+    return self.channels[self.channelName(forPath: path, callOptions: callOptions)]!
+  }
+
+  /// Returns the name of the channel to make the RPC with the given path and options on.
+  ///
+  /// - Parameter excluding: The names of channels which should be treated as unhealthy.
+  fileprivate func channelName(
+    forPath path: String,
+    callOptions: CallOptions,
+    excluding excluded: Set<String> = []
+  ) -> String {
+    let selected: String
+    if let name = self.selector(path, callOptions), self.channels[name] != nil {
+      selected = name
+    } else {
+      selected = self.defaultChannel
+    }
+
+    let now = self.clock.now()
+    return self.lock.withLock {
+      let isHealthy = { (name: String) -> Bool in
+        !excluded.contains(name) && self.isHealthy(name, now: now)
+      }
+
+      // Nothing is unhealthy: this is the common case.
+      if (self.unhealthyUntil.isEmpty && excluded.isEmpty) || isHealthy(selected) {
+        return selected
+      } else if isHealthy(self.defaultChannel) {
+        return self.defaultChannel
+      } else {
+        return self.channels.keys.sorted().first(where: isHealthy) ?? selected
+      }
+    }
+  }
+
+  /// Returns whether the connection of the named channel is known to be down, that is, it has been
+  /// shut down or is failing to connect. Only the state of `ClientConnection`s is known.
+  fileprivate func isConnectionDown(_ name: String) -> Bool {
+    switch (self.channels[name] as? ClientConnection)?.connectivity.state {
+    case .some(.shutdown), .some(.transientFailure):
+      return true
+    case .some(.idle), .some(.connecting), .some(.ready), .none:
+      return false
+    }
+  }
+
+  /// An event loop belonging to one of the underlying channels, if one is known. The default
+  /// channel is preferred.
+  fileprivate func eventLoop() -> EventLoop? {
+    let names = [self.defaultChannel] + self.channels.keys.sorted()
+    for candidate in names {
+      switch self.channels[candidate] {
+      case let connection as ClientConnection:
+        return connection.eventLoop
+      case let router as RoutingGRPCChannel:
+        if let eventLoop = router.eventLoop() {
+          return eventLoop
+        }
+      default:
+        ()
+      }
+    }
+    return nil
+  }
+
+  public func makeCall<Request: Message, Response: Message>(
+    path: String,
+    type: GRPCCallType,
+    callOptions: CallOptions,
+    interceptors: [ClientInterceptor<Request, Response>]
+  ) -> Call<Request, Response> {
+    return self.channel(forPath: path, callOptions: callOptions).makeCall(
+      path: path,
+      type: type,
+      callOptions: callOptions,
+      interceptors: interceptors
+    )
+  }
+
+  public func makeCall<Request: GRPCPayload, Response: GRPCPayload>(
+    path: String,
+    type: GRPCCallType,
+    callOptions: CallOptions,
+    interceptors: [ClientInterceptor<Request, Response>]
+  ) -> Call<Request, Response> {
+    return self.channel(forPath: path, callOptions: callOptions).makeCall(
+      path: path,
+      type: type,
+      callOptions: callOptions,
+      interceptors: interceptors
+    )
+  }
+
+  /// Closes all of the underlying channels.
+  public func close() -> EventLoopFuture<Void> {
+    let futures = self.channels.values.map { $0.close() }
+    // There's always at least one channel: the default.
+    return EventLoopFuture.andAllSucceed(futures, on: futures[0].eventLoop)
+  }
+}
+
+extension RoutingGRPCChannel {
+  /// Calls `body` with a channel whose RPCs are all made on the same underlying channel, for
+  /// example to keep the RPCs of a user session on one backend.
+  ///
+  /// The underlying channel is chosen as usual for the first RPC made on the pinned channel and is
+  /// used for every subsequent RPC, regardless of the selector. RPCs only fail over to another
+  /// channel if the pinned channel is marked as unhealthy or, for a `ClientConnection`, if its
+  /// connection has been shut down or is failing to connect (its connectivity state is `.shutdown`
+  /// or `.transientFailure`). Subsequent RPCs are then pinned to the newly chosen channel. A
+  /// `ClientConnection` has a single connection, so RPCs pinned to one also share a connection.
+  ///
+  /// ```
+  /// try channel.withPinnedChannel { pinned in
+  ///   let echo = Echo_EchoClient(channel: pinned)
+  ///   ...
+  /// }
+  /// ```
+  ///
+  /// The pinned channel may be used after `body` returns, for example by RPCs which are still in
+  /// progress; closing it does not close any underlying channels.
+  ///
+  /// - Parameters:
+  ///   - eventLoop: The event loop to complete the future returned by closing the pinned channel
+  ///       on. Defaults to `nil`, the event loop of one of the underlying `ClientConnection`s is
+  ///       used.
+  ///   - body: A closure which is passed the pinned channel.
+  /// - Throws: `GRPCError.InvalidState` if `eventLoop` is `nil` and no event loop is known because
+  ///   none of the underlying channels are a `ClientConnection`, or any error thrown by `body`.
+  /// - Returns: The value returned by `body`.
+  public func withPinnedChannel<Result>(
+    eventLoop: EventLoop? = nil,
+    _ body: (GRPCChannel) throws -> Result
+  ) throws -> Result {
+    guard let eventLoop = eventLoop ?? self.eventLoop() else {
+      throw GRPCError.InvalidState(
+        "An event loop must be provided if none of the channels are a 'ClientConnection'"
+      )
+    }
+    return try body(PinnedGRPCChannel(router: self, eventLoop: eventLoop))
+  }
+}
+
+/// A channel which pins all of its RPCs to the channel chosen by a `RoutingGRPCChannel` for its
+/// first RPC.
+private final class PinnedGRPCChannel: GRPCChannel {
+  private let router: RoutingGRPCChannel
+
+  /// The event loop to complete the future returned by `close()` on.
+  private let eventLoop: EventLoop
+
+  /// Protects `pinned`.
+  private let lock = Lock()
+
+  /// The name of the channel RPCs are pinned to, if one has been chosen.
+  private var pinned: String?
+
+  init(router: RoutingGRPCChannel, eventLoop: EventLoop) {
+    self.router = router
+    self.eventLoop = eventLoop
+  }
+
+  private func channel(forPath path: String, callOptions: CallOptions) -> GRPCChannel {
+    let name: String = self.lock.withLock {
+      if let pinned = self.pinned {
+        if self.router.isConnectionDown(pinned) {
+          // Don't pick the same channel again, even if the selector chooses it.
+          self.pinned = self.router.channelName(
+            forPath: path,
+            callOptions: callOptions,
+            excluding: [pinned]
+          )
+        } else if !self.router.isHealthy(pinned) {
+          self.pinned = self.router.channelName(forPath: path, callOptions: callOptions)
+        }
+      } else {
+        self.pinned = self.router.channelName(forPath: path, callOptions: callOptions)
+      }
+      return self.pinned!
+    }
+
+    return self.router.channels[name]!
+  }
+
+  func makeCall<Request: Message, Response: Message>(
+    path: String,
+    type: GRPCCallType,
+    callOptions: CallOptions,
+    interceptors: [ClientInterceptor<Request, Response>]
+  ) -> Call<Request, Response> {
+    return self.channel(forPath: path, callOptions: callOptions).makeCall(
+      path: path,
+      type: type,
+      callOptions: callOptions,
+      interceptors: interceptors
+    )
+  }
+
+  func makeCall<Request: GRPCPayload, Response: GRPCPayload>(
+    path: String,
+    type: GRPCCallType,
+    callOptions: CallOptions,
+    interceptors: [ClientInterceptor<Request, Response>]
+  ) -> Call<Request, Response> {
+    return self.channel(forPath: path, callOptions: callOptions).makeCall(
+      path: path,
+      type: type,
+      callOptions: callOptions,
+      interceptors: interceptors
+    )
+  }
+
+  func close() -> EventLoopFuture<Void> {
+    // We don't own any channels so there's nothing to close.
+    return self.eventLoop.makeSucceededFuture(())
+  }
+}
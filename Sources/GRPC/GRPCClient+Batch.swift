@@ -0,0 +1,220 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// How a batch of unary calls behaves when one of the calls fails.
+public struct BatchFailureMode: Hashable {
+  internal enum Mode: Hashable {
+    case failFast
+    case collectAll
+  }
+
+  internal var mode: Mode
+
+  private init(_ mode: Mode) {
+    self.mode = mode
+  }
+
+  /// The batch fails with the error of the first call to fail. Calls in progress are cancelled and
+  /// calls which haven't started are never made.
+  public static let failFast = BatchFailureMode(.failFast)
+
+  /// Every call is made regardless of failures and the result of each call is collected.
+  public static let collectAll = BatchFailureMode(.collectAll)
+}
+
+extension GRPCClient {
+  /// Makes a unary call for each of the given requests with at most `maxConcurrent` calls in
+  /// progress at a time.
+  ///
+  /// Calls are made by `makeCall` which is passed a request and the `CallOptions` to use, for
+  /// example:
+  ///
+  /// ```
+  /// let responses = client.batch(requests, maxConcurrent: 4, on: eventLoop) { request, options in
+  ///   client.get(request, callOptions: options)
+  /// }
+  /// ```
+  ///
+  /// The call options are `defaultCallOptions` with an `eventLoopPreference` of `eventLoop`. All
+  /// calls share the client's `channel`, bounding the number of concurrent calls avoids making
+  /// more concurrent streams than the connection allows, leaving the remaining calls waiting in
+  /// the transport.
+  ///
+  /// - Parameters:
+  ///   - requests: The requests to make calls with.
+  ///   - maxConcurrent: The maximum number of calls which may be in progress at a time.
+  ///   - failureMode: How the batch behaves when a call fails. Defaults to `.failFast`.
+  ///   - eventLoop: The `EventLoop` to run the calls on.
+  ///   - makeCall: A closure which makes a unary call with the given request and options.
+  /// - Returns: A future result of each call, in the same order as `requests`. If `failureMode`
+  ///   is `.failFast` then the future fails with the error of the first call to fail.
+  /// - Precondition: `maxConcurrent` must be greater than zero.
+  public func batch<Request, Response>(
+    _ requests: [Request],
+    maxConcurrent: Int,
+    failureMode: BatchFailureMode = .failFast,
+    on eventLoop: EventLoop,
+    _ makeCall: @escaping (Request, CallOptions) -> UnaryCall<Request, Response>
+  ) -> EventLoopFuture<[Result<Response, Error>]> {
+    precondition(maxConcurrent > 0, "maxConcurrent must be greater than zero")
+
+    var options = self.defaultCallOptions
+    options.eventLoopPreference = .exact(eventLoop)
+
+    let batch = UnaryCallBatch(
+      requests: requests,
+      maxConcurrent: maxConcurrent,
+      failureMode: failureMode,
+      options: options,
+      eventLoop: eventLoop,
+      makeCall: makeCall
+    )
+
+    return eventLoop.flatSubmit {
+      batch.start()
+    }
+  }
+}
+
+/// Runs a batch of unary calls. All state is accessed on `eventLoop`.
+private final class UnaryCallBatch<Request, Response> {
+  private let requests: [Request]
+  private let maxConcurrent: Int
+  private let failureMode: BatchFailureMode
+  private let options: CallOptions
+  private let eventLoop: EventLoop
+  private let makeCall: (Request, CallOptions) -> UnaryCall<Request, Response>
+  private let promise: EventLoopPromise<[Result<Response, Error>]>
+
+  /// The result of each call, indexed by the position of its request.
+  private var results: [Result<Response, Error>?]
+
+  /// The index of the next request to make a call for.
+  private var nextIndex = 0
+
+  /// The number of calls which have completed.
+  private var completed = 0
+
+  /// Calls in progress, keyed by the index of their request.
+  private var inFlight: [Int: UnaryCall<Request, Response>] = [:]
+
+  /// Whether the batch has finished, either because all calls completed or one failed fast.
+  private var isFinished = false
+
+  /// Whether calls are being made by `makeCalls()`. Calls which complete synchronously leave
+  /// making the next call to the loop in `makeCalls()` rather than recursing into it.
+  private var isMakingCalls = false
+
+  init(
+    requests: [Request],
+    maxConcurrent: Int,
+    failureMode: BatchFailureMode,
+    options: CallOptions,
+    eventLoop: EventLoop,
+    makeCall: @escaping (Request, CallOptions) -> UnaryCall<Request, Response>
+  ) {
+    self.requests = requests
+    self.maxConcurrent = maxConcurrent
+    self.failureMode = failureMode
+    self.options = options
+    self.eventLoop = eventLoop
+    self.makeCall = makeCall
+    self.promise = eventLoop.makePromise()
+    self.results = Array(repeating: nil, count: requests.count)
+  }
+
+  func start() -> EventLoopFuture<[Result<Response, Error>]> {
+    self.eventLoop.assertInEventLoop()
+
+    if self.requests.isEmpty {
+      self.finish()
+    } else {
+      self.makeCalls()
+    }
+
+    return self.promise.futureResult
+  }
+
+  /// Makes calls until `maxConcurrent` calls are in progress or there are no more requests.
+  private func makeCalls() {
+    guard !self.isMakingCalls else {
+      return
+    }
+
+    self.isMakingCalls = true
+    defer {
+      self.isMakingCalls = false
+    }
+
+    // Calls may complete synchronously, in 'failFast' mode a failure finishes the batch.
+    while !self.isFinished,
+      self.inFlight.count < self.maxConcurrent,
+      self.nextIndex < self.requests.endIndex {
+      self.makeNextCall()
+    }
+  }
+
+  private func makeNextCall() {
+    let index = self.nextIndex
+    self.nextIndex += 1
+
+    let call = self.makeCall(self.requests[index], self.options)
+    self.inFlight[index] = call
+
+    call.response.hop(to: self.eventLoop).whenComplete { result in
+      self.callCompleted(index: index, result: result)
+    }
+  }
+
+  private func callCompleted(index: Int, result: Result<Response, Error>) {
+    self.eventLoop.assertInEventLoop()
+
+    self.inFlight.removeValue(forKey: index)
+    guard !self.isFinished else {
+      return
+    }
+
+    self.results[index] = result
+    self.completed += 1
+
+    switch (result, self.failureMode.mode) {
+    case let (.failure(error), .failFast):
+      self.isFinished = true
+      // Cancel anything still in progress; calls which haven't started won't be.
+      let inFlight = self.inFlight
+      self.inFlight.removeAll()
+      for call in inFlight.values {
+        call.cancel(promise: nil)
+      }
+      self.promise.fail(error)
+
+    case (.success, _),
+         (.failure, .collectAll):
+      if self.completed == self.requests.count {
+        self.finish()
+      } else {
+        self.makeCalls()
+      }
+    }
+  }
+
+  private func finish() {
+    self.isFinished = true
+    // '!' is okay: every call has completed.
+    self.promise.succeed(self.results.map { $0! })
+  }
+}
@@ -13,6 +13,7 @@
  * See the License for the specific language governing permissions and
  * limitations under the License.
  */
+import Logging
 import NIO
 import NIOHTTP2
 import SwiftProtobuf
@@ -164,6 +165,328 @@ extension GRPCClient {
   }
 }
 
+// MARK: Mapped server streaming calls
+
+extension GRPCClient {
+  /// Makes a server streaming call, passing each response to `handler` once it has been
+  /// transformed by `transform`.
+  ///
+  /// Only the response messages are transformed: the `initialMetadata`, `trailingMetadata` and
+  /// `status` of the returned call are those sent by the server. Its `completion` succeeds once
+  /// every response has been handled and the server ended the RPC with an 'ok' status, and fails
+  /// with the `GRPCStatus` of the RPC otherwise:
+  ///
+  /// ```
+  /// let call = client.makeServerStreamingCall(
+  ///   path: "/echo.Echo/Expand",
+  ///   request: request,
+  ///   responseType: Echo_EchoResponse.self,
+  ///   mapMessages: { $0.text }
+  /// ) { text in
+  ///   model.append(text)
+  /// }
+  ///
+  /// call.completion.whenFailure { error in
+  ///   model.fail(error)
+  /// }
+  /// ```
+  ///
+  /// - Parameters:
+  ///   - path: Path of the RPC, e.g. "/echo.Echo/Expand".
+  ///   - request: The request to send.
+  ///   - callOptions: Options for the call, `defaultCallOptions` if `nil`.
+  ///   - interceptors: Interceptors for the call.
+  ///   - responseType: The type of the responses sent by the server.
+  ///   - transform: Transforms each response before it's passed to `handler`.
+  ///   - handler: Called with each transformed response.
+  public func makeServerStreamingCall<
+    Request: SwiftProtobuf.Message,
+    Response: SwiftProtobuf.Message,
+    Output
+  >(
+    path: String,
+    request: Request,
+    callOptions: CallOptions? = nil,
+    interceptors: [ClientInterceptor<Request, Response>] = [],
+    responseType: Response.Type = Response.self,
+    mapMessages transform: @escaping (Response) -> Output,
+    handler: @escaping (Output) -> Void
+  ) -> ServerStreamingCall<Request, Response> {
+    return self.makeServerStreamingCall(
+      path: path,
+      request: request,
+      callOptions: callOptions,
+      interceptors: interceptors,
+      responseType: responseType
+    ) { response in
+      handler(transform(response))
+    }
+  }
+
+  /// Makes a server streaming call, passing each response to `handler` once it has been
+  /// transformed by `transform`. See the overload for `SwiftProtobuf.Message`s for details.
+  public func makeServerStreamingCall<Request: GRPCPayload, Response: GRPCPayload, Output>(
+    path: String,
+    request: Request,
+    callOptions: CallOptions? = nil,
+    interceptors: [ClientInterceptor<Request, Response>] = [],
+    responseType: Response.Type = Response.self,
+    mapMessages transform: @escaping (Response) -> Output,
+    handler: @escaping (Output) -> Void
+  ) -> ServerStreamingCall<Request, Response> {
+    return self.makeServerStreamingCall(
+      path: path,
+      request: request,
+      callOptions: callOptions,
+      interceptors: interceptors,
+      responseType: responseType
+    ) { response in
+      handler(transform(response))
+    }
+  }
+}
+
+// MARK: Best-effort calls
+
+extension GRPCClient {
+  /// Sends a unary request without waiting for, or providing, its response.
+  ///
+  /// This returns as soon as the call has been started; the call continues in the background
+  /// and its response is discarded. Delivery is **not** guaranteed: the request may never reach
+  /// the server, for example if the channel is closed or no connection can be established, and
+  /// the call is not retried. Failures are never thrown, instead they are logged at debug level
+  /// using the logger from the call options and passed to `onFailure`, if provided.
+  ///
+  /// This is suitable for non-critical requests, such as analytics pings, where the caller
+  /// should not be delayed by the RPC.
+  ///
+  /// - Parameters:
+  ///   - path: Path of the RPC, e.g. "/echo.Echo/Get".
+  ///   - request: The request to send.
+  ///   - callOptions: Options for the call, `defaultCallOptions` if `nil`.
+  ///   - interceptors: Interceptors for the call.
+  ///   - responseType: The type of the (discarded) response.
+  ///   - onFailure: Called with the status of the call if it doesn't succeed.
+  public func sendBestEffort<Request: SwiftProtobuf.Message, Response: SwiftProtobuf.Message>(
+    path: String,
+    request: Request,
+    callOptions: CallOptions? = nil,
+    interceptors: [ClientInterceptor<Request, Response>] = [],
+    responseType: Response.Type = Response.self,
+    onFailure: ((GRPCStatus) -> Void)? = nil
+  ) {
+    let call: UnaryCall<Request, Response> = self.makeUnaryCall(
+      path: path,
+      request: request,
+      callOptions: callOptions,
+      interceptors: interceptors
+    )
+    call.observeBestEffortStatus(path: path, onFailure: onFailure)
+  }
+
+  /// Sends a unary request without waiting for, or providing, its response. See
+  /// `sendBestEffort(path:request:callOptions:interceptors:responseType:onFailure:)`.
+  public func sendBestEffort<Request: GRPCPayload, Response: GRPCPayload>(
+    path: String,
+    request: Request,
+    callOptions: CallOptions? = nil,
+    interceptors: [ClientInterceptor<Request, Response>] = [],
+    responseType: Response.Type = Response.self,
+    onFailure: ((GRPCStatus) -> Void)? = nil
+  ) {
+    let call: UnaryCall<Request, Response> = self.makeUnaryCall(
+      path: path,
+      request: request,
+      callOptions: callOptions,
+      interceptors: interceptors
+    )
+    call.observeBestEffortStatus(path: path, onFailure: onFailure)
+  }
+}
+
+extension UnaryCall {
+  fileprivate func observeBestEffortStatus(
+    path: String,
+    onFailure: ((GRPCStatus) -> Void)?
+  ) {
+    let logger = self.options.logger
+    self.status.whenSuccess { status in
+      guard !status.isOk else {
+        return
+      }
+
+      logger.debug("best-effort rpc failed", metadata: [
+        "path": "\(path)",
+        "status": "\(status)",
+      ])
+      onFailure?(status)
+    }
+  }
+}
+
+// MARK: Warm up
+
+extension GRPCClient {
+  /// Establishes the connections of the client's channel ahead of the first RPC to hide the
+  /// latency of connecting (and completing a TLS handshake, if applicable) from it.
+  ///
+  /// A `ClientConnection` is warmed up with `ClientConnection.warmUp(timeLimit:primingPath:)`
+  /// and a `RoutingGRPCChannel` with `RoutingGRPCChannel.warmUp(timeLimit:primingPath:)`. Other
+  /// channels can only be warmed up by the priming RPC.
+  ///
+  /// Warming up never fails: if it doesn't complete within the `timeLimit` the connections
+  /// continue to be established (and retried) as usual in the background.
+  ///
+  /// - Parameters:
+  ///   - timeLimit: The time limit for warming up. Defaults to a timeout of 10 seconds.
+  ///   - primingPath: The path of a method which accepts an empty request to call once connected,
+  ///       in the format "/Service/Method". Defaults to `nil`, no RPC is made.
+  /// - Returns: A future which succeeds with `true` if the channel was warmed up within the time
+  ///     limit, or `false` otherwise. Returns `nil` if the channel can't be warmed up, i.e. it
+  ///     isn't one of the channels above and no `primingPath` was given.
+  public func warmUp(
+    timeLimit: TimeLimit = .timeout(.seconds(10)),
+    primingPath: String? = nil
+  ) -> EventLoopFuture<Bool>? {
+    return warmUpChannel(
+      self.channel,
+      timeLimit: timeLimit,
+      primingPath: primingPath,
+      logger: self.defaultCallOptions.logger
+    )
+  }
+}
+
+/// Warms up `channel`, returning `nil` if it can't be warmed up.
+internal func warmUpChannel(
+  _ channel: GRPCChannel,
+  timeLimit: TimeLimit,
+  primingPath: String?,
+  logger: Logger
+) -> EventLoopFuture<Bool>? {
+  switch channel {
+  case let connection as ClientConnection:
+    return connection.warmUp(timeLimit: timeLimit, primingPath: primingPath)
+
+  case let routing as RoutingGRPCChannel:
+    return routing.warmUp(timeLimit: timeLimit, primingPath: primingPath)
+
+  default:
+    return primingPath.map { path in
+      channel.makePrimingCall(path: path, timeLimit: timeLimit, logger: logger)
+    }
+  }
+}
+
+extension GRPCChannel {
+  /// Makes a unary RPC with an empty request to `path`. The returned future succeeds with `true`
+  /// if the server handled the RPC, that is, it ended with status code 'ok' or 'unimplemented'.
+  internal func makePrimingCall(
+    path: String,
+    timeLimit: TimeLimit,
+    logger: Logger
+  ) -> EventLoopFuture<Bool> {
+    var options = CallOptions(timeLimit: timeLimit)
+    options.logger = logger
+    let call: UnaryCall<GRPCRawPayload, GRPCRawPayload> = self.makeUnaryCall(
+      path: path,
+      request: GRPCRawPayload(ByteBuffer()),
+      callOptions: options
+    )
+
+    return call.status.map { status in
+      switch status.code {
+      case .ok, .unimplemented:
+        return true
+      default:
+        logger.debug("priming rpc failed", metadata: [MetadataKey.error: "\(status)"])
+        return false
+      }
+    }
+  }
+}
+
+// MARK: Lifecycle
+
+/// A client which can be made from a channel and a factory of interceptors.
+///
+/// Generated clients have a matching initializer so only need to declare their conformance in
+/// order to be used with `withGRPCClient(transport:interceptors:_:)`:
+///
+/// ```
+/// extension Echo_EchoClient: InterceptableGRPCClient {}
+/// ```
+public protocol InterceptableGRPCClient: GRPCClient {
+  /// The type of the factory providing interceptors for each RPC.
+  associatedtype InterceptorFactory
+
+  /// Creates a client making RPCs on `channel`.
+  init(channel: GRPCChannel, defaultCallOptions: CallOptions, interceptors: InterceptorFactory?)
+}
+
+/// Makes a client on a new `ClientConnection`, runs `body` with it and then gracefully shuts the
+/// connection down once the future returned by `body` has completed, whether it succeeded or
+/// failed.
+///
+/// This is the recommended way to use a client whose lifetime is bounded by some unit of work.
+/// When shutting down, RPCs still in progress on the connection, such as those started by `body`
+/// without waiting for them, are allowed to complete before it is closed. The connection is
+/// always closed exactly once, even if `body` fails, and must not be used once the returned
+/// future has completed.
+///
+/// Example:
+///
+/// ```
+/// let transport = ClientConnection.Configuration.default(
+///   target: .hostAndPort("localhost", 1234),
+///   eventLoopGroup: group
+/// )
+///
+/// let response = withGRPCClient(transport: transport, interceptors: nil) {
+///   (client: Echo_EchoClient) in
+///   client.get(.with { $0.text = "foo" }).response
+/// }
+/// ```
+///
+/// - Parameters:
+///   - configuration: The configuration of the connection to make RPCs on.
+///   - interceptors: A factory providing interceptors for each RPC made by the client.
+///   - body: A closure returning a future which completes when the client is no longer required.
+/// - Returns: A future which is completed with the result of the future returned by `body`
+///     after the connection has been shut down.
+public func withGRPCClient<Client: InterceptableGRPCClient, Result>(
+  transport configuration: ClientConnection.Configuration,
+  interceptors: Client.InterceptorFactory?,
+  _ body: (Client) -> EventLoopFuture<Result>
+) -> EventLoopFuture<Result> {
+  let connection = ClientConnection(configuration: configuration)
+  let client = Client(
+    channel: connection,
+    defaultCallOptions: CallOptions(),
+    interceptors: interceptors
+  )
+  let result = body(client)
+
+  return result.flatMapError { error in
+    // Shut down but surface the original error rather than any error from shutting down.
+    connection.shutDownGracefully().recover { _ in () }.flatMapThrowing { _ -> Result in
+      throw error
+    }
+  }.flatMap { value in
+    connection.shutDownGracefully().map { value }
+  }
+}
+
+extension ClientConnection {
+  /// Drains the current connection, allowing RPCs in progress to complete, and then closes the
+  /// `ClientConnection`.
+  fileprivate func shutDownGracefully() -> EventLoopFuture<Void> {
+    return self.drainConnection().flatMap {
+      self.close()
+    }
+  }
+}
+
 /// A client which has no generated stubs and may be used to create gRPC calls manually.
 /// See `GRPCClient` for details.
 ///
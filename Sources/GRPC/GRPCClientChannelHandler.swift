@@ -103,6 +103,9 @@ public struct _GRPCRequestHead {
   // Don't put this in storage: it would CoW for every mutation.
   internal var customMetadata: HPACKHeaders
 
+  /// Headers sent as-is after all other headers, see `CallOptions.unsafeRawHeaders`.
+  internal var unsafeRawHeaders = HPACKHeaders()
+
   internal var method: String {
     get {
       return self._storage.method
@@ -218,10 +221,11 @@ extension _GRPCRequestHead {
       scheme: scheme,
       path: path,
       host: host,
-      deadline: options.timeLimit.makeDeadline(),
+      deadline: options.systemDeadline,
       customMetadata: metadata,
       encoding: options.messageEncoding
     )
+    self.unsafeRawHeaders = options.unsafeRawHeaders
   }
 }
 
@@ -288,19 +292,58 @@ internal final class GRPCClientChannelHandler {
   private var stateMachine: GRPCClientStateMachine
   private let maximumReceiveMessageLength: Int
 
+  /// The 'content-type' and start of the body of a response which isn't a gRPC response. Rather
+  /// than failing as soon as the headers are received we wait until the end of the current read
+  /// so that some of the body can be included in the error: non-gRPC responses are typically
+  /// error pages from misconfigured proxies and the body is useful when diagnosing them.
+  private var invalidResponse: (contentType: String?, body: ByteBuffer)?
+
+  /// The maximum number of bytes of a non-gRPC response body to include in an error.
+  private static let maximumBodySnippetLength = 256
+
+  /// Called with each serialized message sent or received on this stream, if set.
+  private let messageObserver: ((ObservedMessage) -> Void)?
+
+  /// The path of the RPC, used when observing messages. Set when the request head is written.
+  private var path: String = ""
+
+  /// Called with the compression statistics for the RPC when the stream closes, if set.
+  private let compressionStatisticsObserver: ((CompressionStatistics) -> Void)?
+
+  /// Compression statistics for the RPC; only collected if there is an observer.
+  private var compressionStatistics: CompressionStatistics?
+
+  /// The maximum size of the request headers permitted by the server, if it advertised one.
+  private let maximumHeaderListSize: Int?
+
   /// Creates a new gRPC channel handler for clients to translate HTTP/2 frames to gRPC messages.
   ///
   /// - Parameters:
   ///   - callType: Type of RPC call being made.
   ///   - maximumReceiveMessageLength: Maximum allowed length in bytes of a received message.
+  ///   - messageObserver: Called with each serialized message sent or received, if not `nil`.
+  ///   - compressionStatisticsObserver: Called with the compression statistics for the RPC when
+  ///       the stream closes, if not `nil`.
+  ///   - maximumHeaderListSize: The maximum size of the request headers permitted by the server
+  ///       (see `HPACKHeaders.headerListSize`), if not `nil`. RPCs with larger request headers
+  ///       fail without being sent.
   ///   - logger: Logger.
   internal init(
     callType: GRPCCallType,
     maximumReceiveMessageLength: Int,
+    messageObserver: ((ObservedMessage) -> Void)? = nil,
+    compressionStatisticsObserver: ((CompressionStatistics) -> Void)? = nil,
+    maximumHeaderListSize: Int? = nil,
     logger: GRPCLogger
   ) {
     self.logger = logger
     self.maximumReceiveMessageLength = maximumReceiveMessageLength
+    self.maximumHeaderListSize = maximumHeaderListSize
+    self.messageObserver = messageObserver
+    self.compressionStatisticsObserver = compressionStatisticsObserver
+    if compressionStatisticsObserver != nil {
+      self.compressionStatistics = CompressionStatistics(path: "", sent: .init(), received: .init())
+    }
     switch callType {
     case .unary:
       self.stateMachine = .init(requestArity: .one, responseArity: .one)
@@ -354,7 +397,7 @@ extension GRPCClientChannelHandler: ChannelInboundHandler {
   ) {
     self.logger.trace("received HTTP2 frame", metadata: [
       MetadataKey.h2Payload: "HEADERS",
-      MetadataKey.h2Headers: "\(content.headers)",
+      MetadataKey.h2Headers: "\(content.headers.redacting())",
       MetadataKey.h2EndStream: "\(content.endStream)",
     ])
 
@@ -391,22 +434,29 @@ extension GRPCClientChannelHandler: ChannelInboundHandler {
     } else {
       // "Normal" response headers, but are they valid?
       let result = self.stateMachine.receiveResponseHeaders(content.headers)
-        .mapError { error -> GRPCError.WithContext in
-          // The headers aren't valid so let's figure out a reasonable error to forward:
-          switch error {
-          case let .invalidContentType(contentType):
-            return GRPCError.InvalidContentType(contentType).captureContext()
-          case let .invalidHTTPStatus(status):
-            return GRPCError.InvalidHTTPStatus(status).captureContext()
-          case .unsupportedMessageEncoding:
-            return GRPCError.CompressionUnsupported().captureContext()
-          case .invalidState:
-            return GRPCError.InvalidState("parsing headers").captureContext()
-          }
+
+      // Not a gRPC response: hold off on the error until we've seen some of the body.
+      if case let .failure(.invalidContentType(contentType)) = result {
+        self.invalidResponse = (contentType, ByteBuffer())
+        return
+      }
+
+      let mappedResult = result.mapError { error -> GRPCError.WithContext in
+        // The headers aren't valid so let's figure out a reasonable error to forward:
+        switch error {
+        case let .invalidContentType(contentType):
+          return GRPCError.InvalidContentType(contentType).captureContext()
+        case let .invalidHTTPStatus(status):
+          return GRPCError.InvalidHTTPStatus(status).captureContext()
+        case .unsupportedMessageEncoding:
+          return GRPCError.CompressionUnsupported().captureContext()
+        case .invalidState:
+          return GRPCError.InvalidState("parsing headers").captureContext()
         }
+      }
 
       // Okay, what should we tell the next handler?
-      switch result {
+      switch mappedResult {
       case .success:
         context.fireChannelRead(self.wrapInboundOut(.initialMetadata(content.headers)))
       case let .failure(error):
@@ -432,6 +482,21 @@ extension GRPCClientChannelHandler: ChannelInboundHandler {
       MetadataKey.h2EndStream: "\(content.endStream)",
     ])
 
+    // The response isn't a gRPC response; collect the start of the body for the error.
+    if var invalidResponse = self.invalidResponse {
+      let maximumLength = GRPCClientChannelHandler.maximumBodySnippetLength
+      let length = min(buffer.readableBytes, maximumLength - invalidResponse.body.readableBytes)
+      if var slice = buffer.readSlice(length: length) {
+        invalidResponse.body.writeBuffer(&slice)
+      }
+      self.invalidResponse = invalidResponse
+
+      if content.endStream || invalidResponse.body.readableBytes >= maximumLength {
+        self.fireInvalidResponseError(context: context)
+      }
+      return
+    }
+
     self.consumeBytes(from: &buffer, context: context)
 
     // End stream is set; we don't usually expect this but can handle it in some situations.
@@ -441,6 +506,39 @@ extension GRPCClientChannelHandler: ChannelInboundHandler {
     }
   }
 
+  internal func channelReadComplete(context: ChannelHandlerContext) {
+    // Don't wait any longer for the body of a non-gRPC response.
+    self.fireInvalidResponseError(context: context)
+    context.fireChannelReadComplete()
+  }
+
+  internal func channelInactive(context: ChannelHandlerContext) {
+    self.fireInvalidResponseError(context: context)
+
+    if let statistics = self.compressionStatistics {
+      self.compressionStatistics = nil
+      self.compressionStatisticsObserver?(statistics)
+    }
+
+    context.fireChannelInactive()
+  }
+
+  /// Fires an `InvalidContentType` error, including the start of the response body, if the
+  /// response was not a gRPC response.
+  private func fireInvalidResponseError(context: ChannelHandlerContext) {
+    guard let invalidResponse = self.invalidResponse else {
+      return
+    }
+
+    self.invalidResponse = nil
+    let body = invalidResponse.body
+    let snippet = body.readableBytes > 0
+      ? String(decoding: body.readableBytesView, as: UTF8.self)
+      : nil
+    let error = GRPCError.InvalidContentType(invalidResponse.contentType, bodySnippet: snippet)
+    context.fireErrorCaught(error.captureContext())
+  }
+
   private func consumeBytes(from buffer: inout ByteBuffer, context: ChannelHandlerContext) {
     // Do we have bytes to read? If there are no bytes to read then we can't do anything. This may
     // happen if the end-of-stream flag is not set on the trailing headers frame (i.e. the one
@@ -450,6 +548,14 @@ extension GRPCClientChannelHandler: ChannelInboundHandler {
       return
     }
 
+    let start: NIODeadline?
+    if self.compressionStatistics != nil {
+      self.compressionStatistics?.received.recordReceived(framedBytes: buffer.readableBytes)
+      start = .now()
+    } else {
+      start = nil
+    }
+
     // Feed the buffer into the state machine.
     let result = self.stateMachine.receiveResponseBuffer(
       &buffer,
@@ -463,6 +569,9 @@ extension GRPCClientChannelHandler: ChannelInboundHandler {
       case let .decompressionLimitExceeded(compressedSize):
         return GRPCError.DecompressionLimitExceeded(compressedSize: compressedSize)
           .captureContext()
+      case let .payloadLengthLimitExceeded(actualLength, limit):
+        return GRPCError.PayloadLengthLimitExceeded(actualLength: actualLength, limit: limit)
+          .captureContext()
       case .invalidState:
         return GRPCError.InvalidState("parsing data as a response message").captureContext()
       }
@@ -471,9 +580,17 @@ extension GRPCClientChannelHandler: ChannelInboundHandler {
     // Did we get any messages?
     switch result {
     case let .success(messages):
+      if let start = start {
+        self.compressionStatistics?.received.recordReceived(
+          messages: messages,
+          duration: .now() - start
+        )
+      }
+
       // Awesome: we got some messages. The state machine guarantees we only get at most a single
       // message for unary and client-streaming RPCs.
       for message in messages {
+        self.messageObserver?(.init(path: self.path, direction: .inbound, bytes: message))
         // Note: `compressed: false` is currently just a placeholder. This is fine since the message
         // context is not currently exposed to the user. If we implement interceptors for the client
         // and decide to surface this information then we'll need to extract that information from
@@ -499,14 +616,25 @@ extension GRPCClientChannelHandler: ChannelOutboundHandler {
   ) {
     switch self.unwrapOutboundIn(data) {
     case let .head(requestHead):
+      self.path = requestHead.path
+      self.compressionStatistics?.path = requestHead.path
       // Feed the request into the state machine:
       switch self.stateMachine.sendRequestHeaders(requestHead: requestHead) {
       case let .success(headers):
+        // The server would reset the stream if the headers exceed its limit: fail the RPC with a
+        // more useful error instead.
+        if let limit = self.maximumHeaderListSize, headers.headerListSize > limit {
+          let status = self.makeHeadersTooLargeStatus(headers, limit: limit, head: requestHead)
+          promise?.fail(status)
+          context.fireErrorCaught(status)
+          return
+        }
+
         // We're clear to write some headers. Create an appropriate frame and write it.
         let framePayload = HTTP2Frame.FramePayload.headers(.init(headers: headers))
         self.logger.trace("writing HTTP2 frame", metadata: [
           MetadataKey.h2Payload: "HEADERS",
-          MetadataKey.h2Headers: "\(headers)",
+          MetadataKey.h2Headers: "\(headers.redacting())",
           MetadataKey.h2EndStream: "false",
         ])
         context.write(self.wrapOutboundOut(framePayload), promise: promise)
@@ -521,7 +649,9 @@ extension GRPCClientChannelHandler: ChannelOutboundHandler {
       }
 
     case let .message(request):
+      self.messageObserver?(.init(path: self.path, direction: .outbound, bytes: request.message))
       // Feed the request message into the state machine:
+      let start: NIODeadline? = self.compressionStatistics == nil ? nil : .now()
       let result = self.stateMachine.sendRequest(
         request.message,
         compressed: request.compressed,
@@ -529,6 +659,14 @@ extension GRPCClientChannelHandler: ChannelOutboundHandler {
       )
       switch result {
       case let .success(buffer):
+        if let start = start {
+          self.compressionStatistics?.sent.recordSent(
+            uncompressedBytes: request.message.readableBytes,
+            framedBytes: buffer.readableBytes,
+            duration: .now() - start
+          )
+        }
+
         // We're clear to send a message; wrap it up in an HTTP/2 frame.
         let framePayload = HTTP2Frame.FramePayload.data(.init(data: .byteBuffer(buffer)))
         self.logger.trace("writing HTTP2 frame", metadata: [
@@ -591,3 +729,42 @@ extension GRPCClientChannelHandler: ChannelOutboundHandler {
     }
   }
 }
+
+extension GRPCClientChannelHandler {
+  /// Returns a status for request headers which exceed the size permitted by the server. The
+  /// message lists the largest metadata keys, which would need to be removed or shortened for the
+  /// headers to fit.
+  private func makeHeadersTooLargeStatus(
+    _ headers: HPACKHeaders,
+    limit: Int,
+    head: _GRPCRequestHead
+  ) -> GRPCStatus {
+    let size = headers.headerListSize
+
+    var metadata: [(name: String, size: Int)] = []
+    for (name, value, _) in head.customMetadata {
+      metadata.append((name.lowercased(), HPACKHeaders.headerSize(name: name, value: value)))
+    }
+    for (name, value, _) in head.unsafeRawHeaders {
+      metadata.append((name, HPACKHeaders.headerSize(name: name, value: value)))
+    }
+
+    // Blame the largest metadata first, until enough has been blamed to account for the excess.
+    var excess = size - limit
+    var offendingKeys: [String] = []
+    for entry in metadata.sorted(by: { $0.size > $1.size }) where excess > 0 {
+      excess -= entry.size
+      if !offendingKeys.contains(entry.name) {
+        offendingKeys.append(entry.name)
+      }
+    }
+
+    var message = "metadata too large: the request headers are \(size) bytes but the server " +
+      "permits at most \(limit) bytes"
+    if !offendingKeys.isEmpty {
+      message += " (largest metadata keys: \(offendingKeys.joined(separator: ", ")))"
+    }
+
+    return GRPCStatus(code: .invalidArgument, message: message)
+  }
+}
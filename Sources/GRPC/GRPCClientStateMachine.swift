@@ -168,7 +168,7 @@ struct GRPCClientStateMachine {
   }
 
   /// The default user-agent string.
-  private static let userAgent = "grpc-swift-nio/\(Version.versionString)"
+  internal static let userAgent = "grpc-swift-nio/\(Version.versionString)"
 
   /// Creates a state machine representing a gRPC client's request and response stream state.
   ///
@@ -304,6 +304,7 @@ struct GRPCClientStateMachine {
   /// - `.leftOverBytes` if bytes remain in the buffer after reading one message when at most one
   ///   message is expected.
   /// - `.deserializationFailed` if the message could not be deserialized.
+  /// - `.payloadLengthLimitExceeded` if the length of a message exceeds `maxMessageLength`.
   ///
   /// It is not possible to receive response headers from the following states:
   /// - `.clientIdleServerIdle`
@@ -398,6 +399,7 @@ extension GRPCClientStateMachine.State {
         path: requestHead.path,
         timeout: GRPCTimeout(deadline: requestHead.deadline),
         customMetadata: requestHead.customMetadata,
+        unsafeRawHeaders: requestHead.unsafeRawHeaders,
         compression: requestHead.encoding
       )
       result = .success(headers)
@@ -586,9 +588,13 @@ extension GRPCClientStateMachine.State {
          .clientClosedServerIdle,
          .clientClosedServerActive:
       self = .clientClosedServerClosed
+      // The server should end the RPC with trailers (or a trailers-only response); seeing end
+      // stream on a DATA frame usually means that trailers were stripped along the way.
       status = .init(
         code: .internalError,
-        message: "Protocol violation: received DATA frame with end stream set"
+        message: "Protocol violation: received DATA frame with end stream set; the response " +
+          "ended without trailers so its status is unknown. This may be caused by an " +
+          "intermediary (such as a proxy) which does not forward HTTP/2 trailers"
       )
 
     case .clientClosedServerClosed:
@@ -618,13 +624,14 @@ extension GRPCClientStateMachine.State {
     path: String,
     timeout: GRPCTimeout,
     customMetadata: HPACKHeaders,
+    unsafeRawHeaders: HPACKHeaders,
     compression: ClientMessageEncoding
   ) -> HPACKHeaders {
     var headers = HPACKHeaders()
     // The 10 is:
     // - 6 which are required and added just below, and
     // - 4 which are possibly added, depending on conditions.
-    headers.reserveCapacity(10 + customMetadata.count)
+    headers.reserveCapacity(10 + customMetadata.count + unsafeRawHeaders.count)
 
     // Add the required headers.
     headers.add(name: ":method", value: method)
@@ -667,6 +674,9 @@ extension GRPCClientStateMachine.State {
       headers.add(name: "user-agent", value: GRPCClientStateMachine.userAgent)
     }
 
+    // Raw headers are added last and passed through untouched.
+    headers.add(contentsOf: unsafeRawHeaders)
+
     return headers
   }
 
@@ -731,16 +741,50 @@ extension GRPCClientStateMachine.State {
   ///
   /// - Parameter trailers: Trailers to parse.
   private func parseTrailers(_ trailers: HPACKHeaders) -> GRPCStatus {
-    // Extract the "Status" and "Status-Message"
-    let code = self.readStatusCode(from: trailers) ?? .unknown
-    let message = self.readStatusMessage(from: trailers)
-    return .init(code: code, message: message)
+    if let status = self.readStatus(from: trailers) {
+      return status
+    }
+
+    return .init(
+      code: .unknown,
+      message: self.readStatusMessage(from: trailers) ??
+        "Response trailers did not include a '\(GRPCHeaderName.statusCode)' header. " +
+        "This may be caused by an intermediary (such as a proxy) which does not forward " +
+        "HTTP/2 trailers"
+    )
   }
 
-  private func readStatusCode(from trailers: HPACKHeaders) -> GRPCStatus.Code? {
-    return trailers.first(name: GRPCHeaderName.statusCode)
-      .flatMap(Int.init)
-      .flatMap(GRPCStatus.Code.init)
+  /// Reads the "grpc-status" and "grpc-message" from the trailers. Returns `nil` if there is no
+  /// "grpc-status" header; a value which can't be parsed results in an 'internal' status.
+  private func readStatus(from trailers: HPACKHeaders) -> GRPCStatus? {
+    guard let statusHeader = trailers.first(name: GRPCHeaderName.statusCode) else {
+      return nil
+    }
+
+    guard let rawCode = Self.parseStatusCode(statusHeader) else {
+      return .init(
+        code: .internalError,
+        message: "Invalid '\(GRPCHeaderName.statusCode)' header value '\(statusHeader)'"
+      )
+    }
+
+    // Codes we don't know about are treated as 'unknown'.
+    return .init(
+      code: GRPCStatus.Code(rawValue: rawCode) ?? .unknown,
+      message: self.readStatusMessage(from: trailers)
+    )
+  }
+
+  /// Parses the value of a "grpc-status" header into an integer. Some servers send values with
+  /// surrounding whitespace or leading zeros, these are tolerated. Returns `nil` if the value is
+  /// not made up of decimal digits.
+  internal static func parseStatusCode(_ value: String) -> Int? {
+    let digits = value.trimmingCharacters(in: .whitespaces)
+    let isNumeric = digits.utf8.allSatisfy { $0 >= UInt8(ascii: "0") && $0 <= UInt8(ascii: "9") }
+    guard !digits.isEmpty, isNumeric else {
+      return nil
+    }
+    return Int(digits)
   }
 
   private func readStatusMessage(from trailers: HPACKHeaders) -> String? {
@@ -768,9 +812,8 @@ extension GRPCClientStateMachine.State {
     }
 
     guard status == .ok else {
-      if let code = self.readStatusCode(from: trailers) {
-        let message = self.readStatusMessage(from: trailers)
-        return .failure(.invalidHTTPStatusWithGRPCStatus(.init(code: code, message: message)))
+      if let grpcStatus = self.readStatus(from: trailers) {
+        return .failure(.invalidHTTPStatusWithGRPCStatus(grpcStatus))
       } else {
         return .failure(.invalidHTTPStatus(statusHeader))
       }
@@ -0,0 +1,116 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// A source of the current time for an RPC or connection, and of the timers which enforce its time
+/// limits.
+///
+/// The clock is used to resolve the `timeLimit` of an RPC into a deadline and to schedule the
+/// task which fails the RPC once that deadline has passed. This applies to the RPC itself and to
+/// any time it spends waiting for a stream on a connection with a stream limit.
+///
+/// A clock created with `init(now:)` schedules the deadline on the `EventLoop` of the RPC, as an
+/// amount of time from its own `now()`. Time doesn't pass on an `EmbeddedEventLoop` until it is
+/// advanced, so a time limit can be tested deterministically, without waiting:
+///
+/// ```
+/// var options = CallOptions(timeLimit: .timeout(.milliseconds(100)))
+/// options.clock = GRPCClock { .uptimeNanoseconds(0) }
+/// let call = client.get(request, callOptions: options)
+///
+/// embeddedEventLoop.advanceTime(by: .milliseconds(100))
+/// // 'call.status' is now completed with '.deadlineExceeded'.
+/// ```
+///
+/// A clock created with `init(now:scheduleTask:)` schedules deadlines itself; this allows the time
+/// limits of RPCs on any `EventLoop` to be driven by a virtual clock.
+///
+/// The clock in `CallOptions` is only used for the time limits of RPCs. Connection level timers,
+/// such as keepalive pings and their timeouts and the idle timeout, use the `connectionClock` of
+/// `ClientConnection.Configuration` instead. If it's `nil` they're scheduled directly on the
+/// `EventLoop` of the connection and are only affected by its time.
+public struct GRPCClock {
+  @usableFromInline
+  internal let _now: () -> NIODeadline
+
+  @usableFromInline
+  internal let _scheduleTask: (NIODeadline, EventLoop, @escaping () -> Void) -> Scheduled<Void>
+
+  /// Creates a clock which reads the current time from the given closure. Deadlines are scheduled
+  /// on the `EventLoop` of the RPC, relative to the time returned by `now`.
+  ///
+  /// - Parameter now: Returns the current time. It may be called from any thread.
+  public init(now: @escaping () -> NIODeadline) {
+    self.init(now: now) { deadline, eventLoop, task in
+      return eventLoop.scheduleTask(in: deadline - now(), task)
+    }
+  }
+
+  /// Creates a clock which reads the current time from `now` and schedules deadlines with
+  /// `scheduleTask`.
+  ///
+  /// - Parameters:
+  ///   - now: Returns the current time. It may be called from any thread.
+  ///   - scheduleTask: Schedules the given task to run on the `EventLoop` once the deadline has
+  ///       passed, as measured by `now`. It may be called from any thread. The returned
+  ///       `Scheduled` must stop the task from running when it is cancelled.
+  public init(
+    now: @escaping () -> NIODeadline,
+    scheduleTask: @escaping (
+      _ deadline: NIODeadline,
+      _ eventLoop: EventLoop,
+      _ task: @escaping () -> Void
+    ) -> Scheduled<Void>
+  ) {
+    self._now = now
+    self._scheduleTask = scheduleTask
+  }
+
+  /// The system clock, i.e. `NIODeadline.now()`. Deadlines are scheduled on the `EventLoop` of
+  /// the RPC.
+  public static let system = GRPCClock(now: NIODeadline.now) { deadline, eventLoop, task in
+    return eventLoop.scheduleTask(deadline: deadline, task)
+  }
+
+  /// Returns the current time.
+  @inlinable
+  public func now() -> NIODeadline {
+    return self._now()
+  }
+
+  /// Schedules `task` to run on `eventLoop` once `deadline` has passed.
+  @inlinable
+  internal func scheduleTask(
+    deadline: NIODeadline,
+    on eventLoop: EventLoop,
+    _ task: @escaping () -> Void
+  ) -> Scheduled<Void> {
+    return self._scheduleTask(deadline, eventLoop, task)
+  }
+}
+
+extension CallOptions {
+  /// The deadline of the call measured by the system clock, i.e. a deadline which is the same
+  /// amount of time away as the deadline measured by `clock`. The 'grpc-timeout' sent to the
+  /// server is derived from this deadline.
+  internal var systemDeadline: NIODeadline {
+    let deadline = self.timeLimit.makeDeadline(using: self.clock)
+    if deadline == .distantFuture {
+      return deadline
+    }
+    return NIODeadline.now() + (deadline - self.clock.now())
+  }
+}
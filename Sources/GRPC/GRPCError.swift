@@ -70,6 +70,82 @@ public enum GRPCError {
     }
   }
 
+  /// The RPC did not receive a response part within the response idle timeout.
+  public struct RPCIdleTimedOut: GRPCErrorProtocol {
+    /// The idle timeout which was exceeded by the RPC.
+    public var idleTimeout: TimeAmount
+
+    public init(_ idleTimeout: TimeAmount) {
+      self.idleTimeout = idleTimeout
+    }
+
+    public var description: String {
+      return "RPC timed out waiting for a response"
+    }
+
+    public func makeGRPCStatus() -> GRPCStatus {
+      return GRPCStatus(code: .deadlineExceeded, message: self.description)
+    }
+  }
+
+  /// The RPC did not receive response headers within the response headers timeout.
+  public struct RPCResponseHeadersTimedOut: GRPCErrorProtocol {
+    /// The response headers timeout which was exceeded by the RPC.
+    public var responseHeadersTimeout: TimeAmount
+
+    public init(_ responseHeadersTimeout: TimeAmount) {
+      self.responseHeadersTimeout = responseHeadersTimeout
+    }
+
+    public var description: String {
+      return "RPC timed out waiting for response headers"
+    }
+
+    public func makeGRPCStatus() -> GRPCStatus {
+      return GRPCStatus(code: .deadlineExceeded, message: self.description)
+    }
+  }
+
+  /// The RPC did not write a request message within the write timeout.
+  public struct RPCWriteTimedOut: GRPCErrorProtocol {
+    /// The write timeout which was exceeded by the RPC.
+    public var writeTimeout: TimeAmount
+
+    public init(_ writeTimeout: TimeAmount) {
+      self.writeTimeout = writeTimeout
+    }
+
+    public var description: String {
+      return "RPC timed out waiting for a request message to be written"
+    }
+
+    public func makeGRPCStatus() -> GRPCStatus {
+      return GRPCStatus(code: .deadlineExceeded, message: self.description)
+    }
+  }
+
+  /// The length of a received message exceeded the maximum allowed message length.
+  public struct PayloadLengthLimitExceeded: GRPCErrorProtocol {
+    /// The length of the message, as advertised by its length-prefix.
+    public var actualLength: Int
+
+    /// The maximum allowed message length.
+    public var limit: Int
+
+    public init(actualLength: Int, limit: Int) {
+      self.actualLength = actualLength
+      self.limit = limit
+    }
+
+    public var description: String {
+      return "Message length \(self.actualLength) exceeded the limit of \(self.limit) bytes"
+    }
+
+    public func makeGRPCStatus() -> GRPCStatus {
+      return GRPCStatus(code: .resourceExhausted, message: self.description)
+    }
+  }
+
   /// A message was not able to be serialized.
   public struct SerializationFailure: GRPCErrorProtocol {
     public let description = "Message serialization failed"
@@ -133,7 +209,7 @@ public enum GRPCError {
     }
   }
 
-  /// It was not possible to decode a base64 message (gRPC-Web only).
+  /// It was not possible to decode a base64 message (gRPC-Web) or binary header value.
   public struct Base64DecodeError: GRPCErrorProtocol {
     public let description = "Base64 message decoding failed"
 
@@ -155,6 +231,29 @@ public enum GRPCError {
     }
   }
 
+  /// A message had its compressed flag set but the peer didn't declare a message encoding which
+  /// compresses messages, i.e. 'grpc-encoding' was absent or 'identity'.
+  public struct CompressedFlagMismatch: GRPCErrorProtocol {
+    /// The declared message encoding, if there was one.
+    public let encoding: String?
+
+    public init(encoding: String?) {
+      self.encoding = encoding
+    }
+
+    public var description: String {
+      if let encoding = self.encoding {
+        return "Message has the compressed flag set but its declared encoding is '\(encoding)'"
+      } else {
+        return "Message has the compressed flag set but no message encoding was declared"
+      }
+    }
+
+    public func makeGRPCStatus() -> GRPCStatus {
+      return GRPCStatus(code: .internalError, message: self.description)
+    }
+  }
+
   /// Too many, or too few, messages were sent over the given stream.
   public struct StreamCardinalityViolation: GRPCErrorProtocol {
     /// The stream on which there was a cardinality violation.
@@ -180,16 +279,32 @@ public enum GRPCError {
     /// The value of the 'content-type' header, if it was present.
     public var contentType: String?
 
+    /// The start of the response body, if any was received. Non-gRPC responses are often HTML
+    /// error pages from proxies which can help to diagnose misconfigurations.
+    public var bodySnippet: String?
+
     public init(_ contentType: String?) {
+      self.init(contentType, bodySnippet: nil)
+    }
+
+    public init(_ contentType: String?, bodySnippet: String?) {
       self.contentType = contentType
+      self.bodySnippet = bodySnippet
     }
 
     public var description: String {
+      var description: String
       if let contentType = self.contentType {
-        return "Invalid 'content-type' header: '\(contentType)'"
+        description = "Invalid 'content-type' header: '\(contentType)'"
       } else {
-        return "Missing 'content-type' header"
+        description = "Missing 'content-type' header"
       }
+
+      if let bodySnippet = self.bodySnippet, !bodySnippet.isEmpty {
+        description += ", response body starts with: '\(bodySnippet)'"
+      }
+
+      return description
     }
 
     public func makeGRPCStatus() -> GRPCStatus {
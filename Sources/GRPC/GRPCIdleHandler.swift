@@ -31,24 +31,60 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
   private var scheduledClose: Scheduled<Void>?
 
   /// The scheduled task which will ping.
-  private var scheduledPing: RepeatedTask?
+  private var scheduledPing: Scheduled<Void>?
+
+  /// The maximum amount of time a connection may exist before it is gracefully shutdown. Only
+  /// used by the server.
+  private let maximumConnectionAge: TimeAmount
+
+  /// The amount of time to wait for RPCs to complete after the maximum connection age has been
+  /// reached before closing the connection. Only used by the server.
+  private let maximumConnectionAgeGrace: TimeAmount
+
+  /// The scheduled task which will gracefully shutdown the connection when it reaches its
+  /// maximum age.
+  private var scheduledMaximumAge: Scheduled<Void>?
+
+  /// The scheduled task which will close the connection when the grace period after reaching the
+  /// maximum connection age has passed.
+  private var scheduledMaximumAgeGrace: Scheduled<Void>?
+
+  /// The clock used to schedule the idle timeout, keepalive pings and their timeouts and the
+  /// maximum connection age, and to measure round-trip times. If `nil` the timers are scheduled
+  /// on the `EventLoop` of the connection.
+  private let clock: GRPCClock?
 
   /// The mode we're operating in.
-  private let mode: Mode
+  private var mode: Mode
 
   private var context: ChannelHandlerContext?
 
+  /// Round-trip time measurements which are waiting for a PING ack, keyed by the ping code.
+  private var roundTripTimeProbes: [UInt64: RoundTripTimeProbe] = [:]
+
+  private struct RoundTripTimeProbe {
+    var sentAt: NIODeadline
+    var promise: EventLoopPromise<TimeAmount>
+  }
+
+  /// The code to use for the next round-trip time measurement. Starts well above the codes used
+  /// for keepalive pings.
+  private var nextProbeCode: UInt64 = 1 << 32
+
   /// The mode of operation: the client tracks additional connection state in the connection
   /// manager.
   internal enum Mode {
     case client(ConnectionManager, HTTP2StreamMultiplexer)
+    /// A client connection which was drained: the connection manager no longer manages it, so it
+    /// isn't told about changes to the connection.
+    case drainedClient
     case server
 
     var connectionManager: ConnectionManager? {
       switch self {
       case let .client(manager, _):
         return manager
-      case .server:
+      case .drainedClient, .server:
         return nil
       }
     }
@@ -62,10 +98,14 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
     multiplexer: HTTP2StreamMultiplexer,
     idleTimeout: TimeAmount,
     keepalive configuration: ClientConnectionKeepalive,
+    clock: GRPCClock? = nil,
     logger: Logger
   ) {
     self.mode = .client(connectionManager, multiplexer)
+    self.clock = clock
     self.idleTimeout = idleTimeout
+    self.maximumConnectionAge = .nanoseconds(.max)
+    self.maximumConnectionAgeGrace = .nanoseconds(.max)
     self.stateMachine = .init(role: .client, logger: logger)
     self.pingHandler = PingHandler(
       pingCode: 5,
@@ -73,18 +113,25 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
       timeout: configuration.timeout,
       permitWithoutCalls: configuration.permitWithoutCalls,
       maximumPingsWithoutData: configuration.maximumPingsWithoutData,
-      minimumSentPingIntervalWithoutData: configuration.minimumSentPingIntervalWithoutData
+      minimumSentPingIntervalWithoutData: configuration.minimumSentPingIntervalWithoutData,
+      clock: clock
     )
   }
 
   init(
     idleTimeout: TimeAmount,
     keepalive configuration: ServerConnectionKeepalive,
+    maximumConnectionAge: TimeAmount = .nanoseconds(.max),
+    maximumConnectionAgeGrace: TimeAmount = .nanoseconds(.max),
+    clock: GRPCClock? = nil,
     logger: Logger
   ) {
     self.mode = .server
+    self.clock = clock
     self.stateMachine = .init(role: .server, logger: logger)
     self.idleTimeout = idleTimeout
+    self.maximumConnectionAge = maximumConnectionAge
+    self.maximumConnectionAgeGrace = maximumConnectionAgeGrace
     self.pingHandler = PingHandler(
       pingCode: 10,
       interval: configuration.interval,
@@ -93,7 +140,8 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
       maximumPingsWithoutData: configuration.maximumPingsWithoutData,
       minimumSentPingIntervalWithoutData: configuration.minimumSentPingIntervalWithoutData,
       minimumReceivedPingIntervalWithoutData: configuration.minimumReceivedPingIntervalWithoutData,
-      maximumPingStrikes: configuration.maximumPingStrikes
+      maximumPingStrikes: configuration.maximumPingStrikes,
+      clock: clock
     )
   }
 
@@ -131,6 +179,12 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
       manager.maxConcurrentStreamsChanged(maxConcurrentStreams)
     }
 
+    // Max header list size changed.
+    if let manager = self.mode.connectionManager,
+      let maxHeaderListSize = operations.maxHeaderListSizeChange {
+      manager.maxHeaderListSizeChanged(maxHeaderListSize)
+    }
+
     // Handle idle timeout creation/cancellation.
     if let idleTask = operations.idleTask {
       switch idleTask {
@@ -139,7 +193,7 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
 
       case .schedule:
         if self.idleTimeout != .nanoseconds(.max), let context = self.context {
-          let task = context.eventLoop.scheduleTask(in: self.idleTimeout) {
+          let task = self.scheduleTask(in: self.idleTimeout, on: context.eventLoop) {
             self.idleTimeoutFired()
           }
           self.perform(operations: self.stateMachine.scheduledIdleTimeoutTask(task))
@@ -181,14 +235,13 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
   }
 
   private func schedulePing(in delay: TimeAmount, timeout: TimeAmount) {
-    guard delay != .nanoseconds(.max) else {
+    guard delay != .nanoseconds(.max), let eventLoop = self.context?.eventLoop else {
       return
     }
 
-    self.scheduledPing = self.context?.eventLoop.scheduleRepeatedTask(
-      initialDelay: delay,
-      delay: delay
-    ) { _ in
+    // The clock can't schedule repeated tasks: each ping schedules the next one.
+    self.scheduledPing = self.scheduleTask(in: delay, on: eventLoop) {
+      self.schedulePing(in: delay, timeout: timeout)
       self.handlePingAction(self.pingHandler.pingFired())
       // `timeout` is less than `interval`, guaranteeing that the close task
       // will be fired before a new ping is triggered.
@@ -198,21 +251,136 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
   }
 
   private func scheduleClose(in timeout: TimeAmount) {
-    self.scheduledClose = self.context?.eventLoop.scheduleTask(in: timeout) {
+    guard let eventLoop = self.context?.eventLoop else {
+      return
+    }
+
+    self.scheduledClose = self.scheduleTask(in: timeout, on: eventLoop) {
       self.perform(operations: self.stateMachine.shutdownNow())
     }
   }
 
+  /// Schedules `task` to run on `eventLoop` once `amount` of time has passed, as measured by
+  /// `clock` if there is one.
+  private func scheduleTask(
+    in amount: TimeAmount,
+    on eventLoop: EventLoop,
+    _ task: @escaping () -> Void
+  ) -> Scheduled<Void> {
+    if let clock = self.clock {
+      return clock.scheduleTask(deadline: clock.now() + amount, on: eventLoop, task)
+    } else {
+      return eventLoop.scheduleTask(in: amount, task)
+    }
+  }
+
+  private func now() -> NIODeadline {
+    return self.clock?.now() ?? .now()
+  }
+
+  /// Sends a PING frame and completes the promise with the time taken for the peer to acknowledge
+  /// it.
+  ///
+  /// - Important: This *must* to be called from the `EventLoop` of the channel.
+  internal func measureRoundTripTime(promise: EventLoopPromise<TimeAmount>) {
+    guard let context = self.context else {
+      promise.fail(GRPCStatus(code: .unavailable, message: "Connection is not active"))
+      return
+    }
+
+    context.eventLoop.assertInEventLoop()
+
+    let code = self.nextProbeCode
+
+    // Probes go through the ping handler so that they count towards, and are limited by, the
+    // pings sent without data: the server may otherwise close the connection for sending too many
+    // pings.
+    guard let payload = self.pingHandler.roundTripTimeProbe(code: code) else {
+      promise.fail(GRPCStatus(
+        code: .resourceExhausted,
+        message: "Too many pings sent without data, try again later"
+      ))
+      return
+    }
+
+    self.nextProbeCode &+= 1
+    self.roundTripTimeProbes[code] = RoundTripTimeProbe(sentAt: self.now(), promise: promise)
+
+    let frame = HTTP2Frame(streamID: .rootStream, payload: payload)
+    context.writeAndFlush(self.wrapOutboundOut(frame), promise: nil)
+  }
+
+  /// Fails any round-trip time measurements which are waiting for a PING ack.
+  private func failRoundTripTimeProbes() {
+    let probes = self.roundTripTimeProbes
+    self.roundTripTimeProbes.removeAll()
+
+    for probe in probes.values {
+      probe.promise.fail(GRPCStatus(code: .unavailable, message: "Connection closed"))
+    }
+  }
+
   private func idleTimeoutFired() {
     self.perform(operations: self.stateMachine.idleTimeoutTaskFired())
   }
 
+  /// Schedules a graceful shutdown of the connection once it reaches its maximum age, if there
+  /// is one.
+  private func scheduleMaximumConnectionAge(on eventLoop: EventLoop) {
+    guard self.maximumConnectionAge != .nanoseconds(.max) else {
+      return
+    }
+
+    // Apply up to 10% jitter (in either direction) so that connections accepted at around the
+    // same time aren't all shutdown at the same time.
+    let nanoseconds = Double(self.maximumConnectionAge.nanoseconds) * .random(in: 0.9 ... 1.1)
+    let age: TimeAmount = nanoseconds >= Double(Int64.max)
+      ? .nanoseconds(.max)
+      : .nanoseconds(Int64(nanoseconds))
+
+    self.scheduledMaximumAge = self.scheduleTask(in: age, on: eventLoop) {
+      self.maximumConnectionAgeReached()
+    }
+  }
+
+  private func maximumConnectionAgeReached() {
+    self.stateMachine.logger.debug("maximum connection age reached, shutting down gracefully")
+    self.perform(operations: self.stateMachine.initiateGracefulShutdown())
+
+    guard self.maximumConnectionAgeGrace != .nanoseconds(.max), let context = self.context else {
+      return
+    }
+
+    self.scheduledMaximumAgeGrace = self.scheduleTask(
+      in: self.maximumConnectionAgeGrace,
+      on: context.eventLoop
+    ) {
+      self.stateMachine.logger.debug("maximum connection age grace period passed, closing")
+      self.perform(operations: self.stateMachine.shutdownNow())
+    }
+  }
+
+  private func cancelMaximumConnectionAgeTasks() {
+    self.scheduledMaximumAge?.cancel()
+    self.scheduledMaximumAgeGrace?.cancel()
+    self.scheduledMaximumAge = nil
+    self.scheduledMaximumAgeGrace = nil
+  }
+
   func handlerAdded(context: ChannelHandlerContext) {
     self.context = context
+
+    // The server's handlers are added once the connection is active, so the age of the connection
+    // is measured from here.
+    if case .server = self.mode {
+      self.scheduleMaximumConnectionAge(on: context.eventLoop)
+    }
   }
 
   func handlerRemoved(context: ChannelHandlerContext) {
     self.context = nil
+    self.cancelMaximumConnectionAgeTasks()
+    self.failRoundTripTimeProbes()
   }
 
   func userInboundEventTriggered(context: ChannelHandlerContext, event: Any) {
@@ -228,6 +396,14 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
     } else if event is ChannelShouldQuiesceEvent {
       self.perform(operations: self.stateMachine.initiateGracefulShutdown())
       // Swallow this event.
+    } else if event is ConnectionDrainEvent {
+      // The connection manager has stopped managing this connection: stop telling it about the
+      // connection and close it once the open streams have closed.
+      if case .client = self.mode {
+        self.mode = .drainedClient
+      }
+      self.perform(operations: self.stateMachine.initiateGracefulShutdown())
+      // Swallow this event.
     } else {
       context.fireUserInboundEventTriggered(event)
     }
@@ -249,7 +425,7 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
     switch self.mode {
     case let .client(connectionManager, multiplexer):
       connectionManager.channelActive(channel: context.channel, multiplexer: multiplexer)
-    case .server:
+    case .drainedClient, .server:
       ()
     }
     context.fireChannelActive()
@@ -261,6 +437,8 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
     self.scheduledClose?.cancel()
     self.scheduledPing = nil
     self.scheduledClose = nil
+    self.cancelMaximumConnectionAgeTasks()
+    self.failRoundTripTimeProbes()
     context.fireChannelInactive()
   }
 
@@ -273,7 +451,16 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
     case let .settings(.settings(settings)):
       self.perform(operations: self.stateMachine.receiveSettings(settings))
     case let .ping(data, ack):
-      self.handlePingAction(self.pingHandler.read(pingData: data, ack: ack))
+      if ack, let probe = self.roundTripTimeProbes.removeValue(forKey: data.integer) {
+        probe.promise.succeed(self.now() - probe.sentAt)
+      } else {
+        let action = self.pingHandler.read(pingData: data, ack: ack)
+        if ack, case .cancelScheduledTimeout = action,
+          let roundTripTime = self.pingHandler.lastRoundTripTime {
+          self.mode.connectionManager?.keepaliveRoundTripTimeMeasured(roundTripTime)
+        }
+        self.handlePingAction(action)
+      }
     default:
       // We're not interested in other events.
       ()
@@ -283,6 +470,11 @@ internal final class GRPCIdleHandler: ChannelInboundHandler {
   }
 }
 
+/// An event fired by the `ConnectionManager` when it drains a connection: the `GRPCIdleHandler`
+/// stops notifying the connection manager of changes to the connection and shuts it down
+/// gracefully.
+internal struct ConnectionDrainEvent {}
+
 extension HTTP2SettingsParameter {
   internal var loggingMetadataKey: String {
     switch self {
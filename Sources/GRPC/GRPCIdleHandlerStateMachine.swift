@@ -180,6 +180,9 @@ struct GRPCIdleHandlerStateMachine {
     /// The value of HTTP/2 SETTINGS_MAX_CONCURRENT_STREAMS changed.
     private(set) var maxConcurrentStreamsChange: Int?
 
+    /// The value of HTTP/2 SETTINGS_MAX_HEADER_LIST_SIZE changed.
+    private(set) var maxHeaderListSizeChange: Int?
+
     /// An idle task, either scheduling or cancelling an idle timeout.
     private(set) var idleTask: IdleTask?
 
@@ -215,6 +218,10 @@ struct GRPCIdleHandlerStateMachine {
       self.maxConcurrentStreamsChange = newValue
     }
 
+    fileprivate mutating func maxHeaderListSizeChanged(_ newValue: Int) {
+      self.maxHeaderListSizeChange = newValue
+    }
+
     private init() {
       self.connectionManagerEvent = nil
       self.idleTask = nil
@@ -520,6 +527,11 @@ struct GRPCIdleHandlerStateMachine {
         state.maxConcurrentStreams = 100
       }
 
+      // Update max header list size. There's no limit by default so only changes are emitted.
+      if let maxSize = settings.last(where: { $0.parameter == .maxHeaderListSize })?.value {
+        operations.maxHeaderListSizeChanged(maxSize)
+      }
+
       self.state = .operating(state)
 
     case var .waitingToIdle(state):
@@ -528,6 +540,9 @@ struct GRPCIdleHandlerStateMachine {
         operations.maxConcurrentStreamsChanged(maxStreams)
         state.maxConcurrentStreams = maxStreams
       }
+      if let maxSize = settings.last(where: { $0.parameter == .maxHeaderListSize })?.value {
+        operations.maxHeaderListSizeChanged(maxSize)
+      }
       self.state = .waitingToIdle(state)
 
     case .quiescing, .closing, .closed:
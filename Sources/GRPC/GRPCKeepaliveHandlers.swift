@@ -58,6 +58,12 @@ struct PingHandler {
   /// When the last ping was sent
   private var lastSentPingDate: NIODeadline?
 
+  /// When the last keepalive ping (i.e. not a ping ack) was sent
+  private var lastSentKeepalivePingDate: NIODeadline?
+
+  /// The round-trip time of the most recently acknowledged keepalive ping
+  private(set) var lastRoundTripTime: TimeAmount?
+
   /// The number of pings sent on the transport without any data
   private var sentPingsWithoutData = 0
 
@@ -67,6 +73,9 @@ struct PingHandler {
   /// The scheduled task which will close the connection.
   private var scheduledClose: Scheduled<Void>?
 
+  /// The clock used to measure the time between pings, if not the system clock.
+  private let clock: GRPCClock?
+
   /// Number of active streams
   private var activeStreams = 0 {
     didSet {
@@ -100,7 +109,8 @@ struct PingHandler {
     maximumPingsWithoutData: UInt,
     minimumSentPingIntervalWithoutData: TimeAmount,
     minimumReceivedPingIntervalWithoutData: TimeAmount? = nil,
-    maximumPingStrikes: UInt? = nil
+    maximumPingStrikes: UInt? = nil,
+    clock: GRPCClock? = nil
   ) {
     self.pingCode = pingCode
     self.interval = interval
@@ -110,6 +120,7 @@ struct PingHandler {
     self.minimumSentPingIntervalWithoutData = minimumSentPingIntervalWithoutData
     self.minimumReceivedPingIntervalWithoutData = minimumReceivedPingIntervalWithoutData
     self.maximumPingStrikes = maximumPingStrikes
+    self.clock = clock
   }
 
   mutating func streamCreated() -> Action {
@@ -136,8 +147,14 @@ struct PingHandler {
     }
   }
 
-  private func handlePong(_ pingData: HTTP2PingData) -> Action {
+  private mutating func handlePong(_ pingData: HTTP2PingData) -> Action {
     if pingData.integer == self.pingCode {
+      if let sentAt = self.lastSentKeepalivePingDate {
+        self.lastRoundTripTime = self.now() - sentAt
+        self.lastSentKeepalivePingDate = nil
+      } else {
+        self.lastRoundTripTime = nil
+      }
       return .cancelScheduledTimeout
     } else {
       return .none
@@ -180,12 +197,30 @@ struct PingHandler {
     }
   }
 
+  /// Returns a PING frame to measure the round-trip time with, or `nil` if the ping must not be
+  /// sent yet.
+  ///
+  /// Round-trip time probes are sent regardless of `permitWithoutCalls`. Otherwise they are
+  /// accounted for and limited in the same way as keepalive pings: when there are no active
+  /// streams a peer may treat frequent pings as abusive and close the connection with a GOAWAY
+  /// ('too_many_pings').
+  mutating func roundTripTimeProbe(code: UInt64) -> HTTP2Frame.FramePayload? {
+    if self.activeStreams == 0, self.exceedsPingLimitsWithoutData {
+      return nil
+    } else {
+      return self.generatePingFrame(code: code, ack: false)
+    }
+  }
+
   private mutating func generatePingFrame(code: UInt64, ack: Bool) -> HTTP2Frame.FramePayload {
     if self.activeStreams == 0 {
       self.sentPingsWithoutData += 1
     }
 
     self.lastSentPingDate = self.now()
+    if !ack, code == self.pingCode {
+      self.lastSentKeepalivePingDate = self.lastSentPingDate
+    }
     return HTTP2Frame.FramePayload.ping(HTTP2PingData(withInteger: code), ack: ack)
   }
 
@@ -220,24 +255,30 @@ struct PingHandler {
 
     // There is no active call on the transport but pings should be sent
     if self.activeStreams == 0, self.permitWithoutCalls {
-      // The number of pings already sent on the transport without any data has already exceeded the limit
-      if self.sentPingsWithoutData > self.maximumPingsWithoutData {
-        return true
-      }
+      return self.exceedsPingLimitsWithoutData
+    }
 
-      // The time elapsed since the previous ping is less than the minimum required
-      if let lastSentPingDate = self.lastSentPingDate,
-        self.now() - lastSentPingDate < self.minimumSentPingIntervalWithoutData {
-        return true
-      }
+    return false
+  }
 
-      return false
+  /// Returns true if sending a ping while there are no active streams would exceed the limits on
+  /// pings sent without data.
+  private var exceedsPingLimitsWithoutData: Bool {
+    // The number of pings already sent on the transport without any data has already exceeded the limit
+    if self.sentPingsWithoutData > self.maximumPingsWithoutData {
+      return true
+    }
+
+    // The time elapsed since the previous ping is less than the minimum required
+    if let lastSentPingDate = self.lastSentPingDate,
+      self.now() - lastSentPingDate < self.minimumSentPingIntervalWithoutData {
+      return true
     }
 
     return false
   }
 
   private func now() -> NIODeadline {
-    return self._testingOnlyNow ?? .now()
+    return self._testingOnlyNow ?? self.clock?.now() ?? .now()
   }
 }
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// A `GRPCPayload` holding the serialized bytes of a message. This allows RPCs to be made (and
+/// served) using the bytes of each message rather than a typed model, which is useful for proxies
+/// and other intermediaries which forward messages without knowing their schema.
+///
+/// The bytes are passed through without modification: they must not include the gRPC
+/// length-prefix, which is added and removed by the transport along with any message
+/// compression.
+public struct GRPCRawPayload: GRPCPayload, Hashable {
+  /// The serialized bytes of the message.
+  public var buffer: ByteBuffer
+
+  /// The serialized bytes of the message.
+  public var bytes: [UInt8] {
+    // '!' is okay; we can always read 'readableBytes'.
+    return self.buffer.getBytes(at: self.buffer.readerIndex, length: self.buffer.readableBytes)!
+  }
+
+  /// Creates a payload holding the readable bytes of `buffer`.
+  public init(_ buffer: ByteBuffer) {
+    self.buffer = buffer
+  }
+
+  /// Creates a payload holding `bytes`.
+  public init<Bytes: Sequence>(bytes: Bytes) where Bytes.Element == UInt8 {
+    self.buffer = ByteBuffer(bytes: bytes)
+  }
+
+  @inlinable
+  public init(serializedByteBuffer: inout ByteBuffer) throws {
+    self.buffer = serializedByteBuffer
+  }
+
+  @inlinable
+  public func serialize(into buffer: inout ByteBuffer) throws {
+    var copy = self.buffer
+    buffer.writeBuffer(&copy)
+  }
+}
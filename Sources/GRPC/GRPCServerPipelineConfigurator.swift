@@ -72,18 +72,21 @@ final class GRPCServerPipelineConfigurator: ChannelInboundHandler, RemovableChan
     return .init(
       idleTimeout: self.configuration.connectionIdleTimeout,
       keepalive: self.configuration.connectionKeepalive,
+      maximumConnectionAge: self.configuration.maximumConnectionAge,
+      maximumConnectionAgeGrace: self.configuration.maximumConnectionAgeGrace,
       logger: self.configuration.logger
     )
   }
 
   /// Makes an HTTP/2 handler.
   private func makeHTTP2Handler() -> NIOHTTP2Handler {
-    return .init(mode: .server)
+    return .init(mode: .server, headerTableSize: self.configuration.httpHeaderTableSize)
   }
 
   /// Makes an HTTP/2 multiplexer suitable handling gRPC requests.
   private func makeHTTP2Multiplexer(for channel: Channel) -> HTTP2StreamMultiplexer {
     var logger = self.configuration.logger
+    let connection = ConnectionContext(channel: channel)
 
     return .init(
       mode: .server,
@@ -92,15 +95,20 @@ final class GRPCServerPipelineConfigurator: ChannelInboundHandler, RemovableChan
     ) { stream in
       // TODO: use sync options when NIO HTTP/2 support for them is released
       // https://github.com/apple/swift-nio-http2/pull/283
-      stream.getOption(HTTP2StreamChannelOptions.streamID).map { streamID -> Logger in
-        logger[metadataKey: MetadataKey.h2StreamID] = "\(streamID)"
-        return logger
+      stream.getOption(HTTP2StreamChannelOptions.streamID).map { streamID -> HTTP2StreamID? in
+        streamID
       }.recover { _ in
-        logger[metadataKey: MetadataKey.h2StreamID] = "<unknown>"
-        return logger
-      }.flatMap { logger in
+        nil
+      }.flatMap { streamID in
+        logger[metadataKey: MetadataKey.h2StreamID] = streamID.map { "\($0)" } ?? "<unknown>"
         // TODO: provide user configuration for header normalization.
-        let handler = self.makeHTTP2ToRawGRPCHandler(normalizeHeaders: true, logger: logger)
+        let handler = self.makeHTTP2ToRawGRPCHandler(
+          normalizeHeaders: true,
+          streamID: streamID,
+          connection: connection,
+          streamInactivityTimeout: self.configuration.streamInactivityTimeout,
+          logger: logger
+        )
         return stream.pipeline.addHandler(handler)
       }
     }
@@ -109,14 +117,35 @@ final class GRPCServerPipelineConfigurator: ChannelInboundHandler, RemovableChan
   /// Makes an HTTP/2 to raw gRPC server handler.
   private func makeHTTP2ToRawGRPCHandler(
     normalizeHeaders: Bool,
+    streamID: HTTP2StreamID? = nil,
+    connection: ConnectionContext? = nil,
+    streamInactivityTimeout: TimeAmount = .nanoseconds(.max),
     logger: Logger
   ) -> HTTP2ToRawGRPCServerCodec {
     return HTTP2ToRawGRPCServerCodec(
       servicesByName: self.configuration.serviceProvidersByName,
+      pathAliases: self.configuration.pathAliases,
+      duplicateMetadataPolicy: self.configuration.duplicateMetadataPolicy,
+      unknownFieldHandling: ServerUnknownFieldHandling(
+        default: self.configuration.unknownFieldHandling,
+        byService: self.configuration.unknownFieldHandlingByService
+      ),
       encoding: self.configuration.messageEncoding,
       errorDelegate: self.configuration.errorDelegate,
       normalizeHeaders: normalizeHeaders,
       maximumReceiveMessageLength: self.configuration.maximumReceiveMessageLength,
+      transferLimits: self.configuration.transferLimits,
+      transferLimitsByMethod: self.configuration.transferLimitsByMethod,
+      maximumBufferedRequests: self.configuration.maximumBufferedRequests,
+      interceptorRegistry: self.configuration.interceptorRegistry,
+      streamID: streamID,
+      connection: connection,
+      messageObserver: self.configuration.debugMessageObserver,
+      compressionStatisticsObserver: self.configuration.compressionStatisticsObserver,
+      includeKnownMethodsInUnimplementedStatus: self.configuration
+        .includeKnownMethodsInUnimplementedStatus,
+      rpcLoggerMetadata: self.configuration.rpcLoggerMetadata,
+      streamInactivityTimeout: streamInactivityTimeout,
       logger: logger
     )
   }
@@ -145,6 +174,9 @@ final class GRPCServerPipelineConfigurator: ChannelInboundHandler, RemovableChan
       // we'll be on the right event loop and sync operations are fine.
       let sync = context.pipeline.syncOperations
       try sync.addHandler(self.makeHTTP2Handler())
+      if let frameLogger = self.configuration.debugHTTP2FrameLogger {
+        try sync.addHandler(HTTP2FrameLoggingHandler(logger: frameLogger))
+      }
       try sync.addHandler(self.makeIdleHandler())
       try sync.addHandler(self.makeHTTP2Multiplexer(for: context.channel))
       result = .success(())
@@ -171,7 +203,11 @@ final class GRPCServerPipelineConfigurator: ChannelInboundHandler, RemovableChan
       try sync.addHandler(GRPCWebToHTTP2ServerCodec(scheme: scheme))
       // There's no need to normalize headers for HTTP/1.
       try sync.addHandler(
-        self.makeHTTP2ToRawGRPCHandler(normalizeHeaders: false, logger: self.configuration.logger)
+        self.makeHTTP2ToRawGRPCHandler(
+          normalizeHeaders: false,
+          connection: ConnectionContext(channel: context.channel),
+          logger: self.configuration.logger
+        )
       )
       result = .success(())
     } catch {
@@ -29,6 +29,10 @@ public protocol CallHandlerProvider: AnyObject {
   /// - Example: "io.grpc.Echo.EchoService"
   var serviceName: Substring { get }
 
+  /// The names of the methods this object provides, e.g. "Get". Only used to describe the known
+  /// methods when an RPC is rejected as unimplemented; may be empty if not known.
+  var methodNames: [Substring] { get }
+
   /// Returns a call handler for the method with the given name, if this service provider implements
   /// the given method. Returns `nil` if the method is not handled by this provider.
   /// - Parameters:
@@ -37,6 +41,12 @@ public protocol CallHandlerProvider: AnyObject {
   func handle(method name: Substring, context: CallHandlerContext) -> GRPCServerHandlerProtocol?
 }
 
+extension CallHandlerProvider {
+  public var methodNames: [Substring] {
+    return []
+  }
+}
+
 // This is public because it will be passed into generated code, all members are `internal` because
 // the context will get passed from generated code back into gRPC library code and all members should
 // be considered an implementation detail to the user.
@@ -54,11 +64,29 @@ public struct CallHandlerContext {
   @usableFromInline
   internal var remoteAddress: SocketAddress?
   @usableFromInline
+  internal var streamID: HTTP2StreamID?
+  @usableFromInline
+  internal var connection: ConnectionContext?
+  @usableFromInline
   internal var responseWriter: GRPCServerResponseWriter
   @usableFromInline
   internal var allocator: ByteBufferAllocator
   @usableFromInline
   internal var closeFuture: EventLoopFuture<Void>
+  @usableFromInline
+  internal var unknownFieldHandling: UnknownFieldHandling = .preserve
+  @usableFromInline
+  internal var transferTotals: MessageTransferTotals?
+  /// The deadline of the RPC, as enforced by the server.
+  @usableFromInline
+  internal var deadline: NIODeadline = .distantFuture
+  /// The maximum number of request messages the handler may buffer before its stream observer has
+  /// been created, or `nil` if there is no limit.
+  @usableFromInline
+  internal var maximumBufferedRequests: Int? = nil
+  /// Interceptors which run ahead of those provided by the service provider.
+  @usableFromInline
+  internal var interceptorRegistry = ServerInterceptorRegistry()
 }
 
 /// A call URI split into components.
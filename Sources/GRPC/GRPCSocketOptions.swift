@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// Options applied to the sockets of connections made by a client or accepted by a server.
+///
+/// The defaults are suitable for most applications: Nagle's algorithm is disabled
+/// (`TCP_NODELAY`) so that small messages, such as unary requests and responses, are sent
+/// immediately rather than being delayed to be coalesced with later writes, and `SO_REUSEADDR` is
+/// enabled. The kernel's default buffer sizes are used.
+///
+/// - Note: Buffer sizes are only supported by the SwiftNIO POSIX transport; connections using
+///   Network.framework will fail if they are set.
+public struct GRPCSocketOptions: Hashable {
+  /// Whether Nagle's algorithm should be disabled, i.e. whether `TCP_NODELAY` is set. Defaults to
+  /// `true`.
+  public var noDelay: Bool
+
+  /// Whether `SO_REUSEADDR` is set. For servers this also applies to the listening socket, which
+  /// avoids "address already in use" errors when restarting. Defaults to `true`.
+  public var reuseAddress: Bool
+
+  /// The size of the socket send buffer in bytes (`SO_SNDBUF`), or `nil` to use the system
+  /// default. Defaults to `nil`.
+  public var sendBufferSize: Int?
+
+  /// The size of the socket receive buffer in bytes (`SO_RCVBUF`), or `nil` to use the system
+  /// default. Defaults to `nil`.
+  public var receiveBufferSize: Int?
+
+  public init(
+    noDelay: Bool = true,
+    reuseAddress: Bool = true,
+    sendBufferSize: Int? = nil,
+    receiveBufferSize: Int? = nil
+  ) {
+    self.noDelay = noDelay
+    self.reuseAddress = reuseAddress
+    self.sendBufferSize = sendBufferSize
+    self.receiveBufferSize = receiveBufferSize
+  }
+
+  /// The options as `ChannelOptions`, in the order in which they should be applied.
+  internal var channelOptions: [(ChannelOptions.Types.SocketOption, SocketOptionValue)] {
+    let socketLevel = SocketOptionLevel(SOL_SOCKET)
+    var options: [(ChannelOptions.Types.SocketOption, SocketOptionValue)] = [
+      (ChannelOptions.socket(socketLevel, SO_REUSEADDR), self.reuseAddress ? 1 : 0),
+      (ChannelOptions.socket(IPPROTO_TCP, TCP_NODELAY), self.noDelay ? 1 : 0),
+    ]
+
+    if let size = self.sendBufferSize {
+      options.append((ChannelOptions.socket(socketLevel, SO_SNDBUF), SocketOptionValue(size)))
+    }
+
+    if let size = self.receiveBufferSize {
+      options.append((ChannelOptions.socket(socketLevel, SO_RCVBUF), SocketOptionValue(size)))
+    }
+
+    return options
+  }
+}
+
+extension ClientBootstrapProtocol {
+  /// Applies the given socket options to the channel.
+  internal func socketOptions(_ options: GRPCSocketOptions) -> Self {
+    var bootstrap = self
+    for (option, value) in options.channelOptions {
+      bootstrap = bootstrap.channelOption(option, value: value)
+    }
+    return bootstrap
+  }
+}
+
+extension ServerBootstrapProtocol {
+  /// Applies the given socket options to accepted channels, and `SO_REUSEADDR` to the server
+  /// channel.
+  internal func socketOptions(_ options: GRPCSocketOptions) -> Self {
+    var bootstrap = self.serverChannelOption(
+      ChannelOptions.socket(SocketOptionLevel(SOL_SOCKET), SO_REUSEADDR),
+      value: options.reuseAddress ? 1 : 0
+    )
+    for (option, value) in options.channelOptions {
+      bootstrap = bootstrap.childChannelOption(option, value: value)
+    }
+    return bootstrap
+  }
+}
@@ -20,8 +20,45 @@ import NIOHTTP2
 
 /// Encapsulates the result of a gRPC call.
 public struct GRPCStatus: Error {
+  /// Storage for the message and cause. Statuses are frequently passed around as existential
+  /// `Error`s so they are kept small enough to fit in an existential container: only the code is
+  /// stored inline. Most statuses (e.g. '.ok') have neither a message nor a cause.
+  private final class Storage {
+    var message: String?
+    var cause: Error?
+
+    init(message: String?, cause: Error?) {
+      self.message = message
+      self.cause = cause
+    }
+  }
+
+  private var storage: Storage?
+
   /// The status message of the RPC.
-  public var message: String?
+  public var message: String? {
+    get {
+      return self.storage?.message
+    }
+    set {
+      self.setStorage(message: newValue, cause: self.cause)
+    }
+  }
+
+  /// The error which caused the RPC to fail, if known. For example, the `NIOSSLError` which
+  /// caused a TLS handshake to fail and the RPC to fail with status code `.unavailable`.
+  ///
+  /// The cause is included in the `description` of the status but is never sent to the remote
+  /// peer and doesn't affect equality. It is intended for diagnostics, e.g. in interceptors or
+  /// when logging.
+  public var cause: Error? {
+    get {
+      return self.storage?.cause
+    }
+    set {
+      self.setStorage(message: self.message, cause: newValue)
+    }
+  }
 
   /// The status code of the RPC.
   public var code: Code
@@ -32,8 +69,26 @@ public struct GRPCStatus: Error {
   }
 
   public init(code: Code, message: String?) {
+    self.init(code: code, message: message, cause: nil)
+  }
+
+  public init(code: Code, message: String?, cause: Error?) {
     self.code = code
-    self.message = message
+    if message != nil || cause != nil {
+      self.storage = Storage(message: message, cause: cause)
+    }
+  }
+
+  private mutating func setStorage(message: String?, cause: Error?) {
+    if message == nil, cause == nil {
+      self.storage = nil
+    } else if isKnownUniquelyReferenced(&self.storage) {
+      // `isKnownUniquelyReferenced` returns false for nil, so the storage must exist.
+      self.storage!.message = message
+      self.storage!.cause = cause
+    } else {
+      self.storage = Storage(message: message, cause: cause)
+    }
   }
 
   // Frequently used "default" statuses.
@@ -58,11 +113,14 @@ extension GRPCStatus: Equatable {
 
 extension GRPCStatus: CustomStringConvertible {
   public var description: String {
-    if let message = message {
-      return "\(self.code): \(message)"
-    } else {
-      return "\(self.code)"
+    var description = "\(self.code)"
+    if let message = self.message {
+      description += ": \(message)"
+    }
+    if let cause = self.cause {
+      description += ", cause: \(cause)"
     }
+    return description
   }
 }
 
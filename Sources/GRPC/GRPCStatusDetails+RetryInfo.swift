@@ -0,0 +1,96 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOHPACK
+import SwiftProtobuf
+
+extension GRPCStatusDetails {
+  /// The type URL of a `google.rpc.RetryInfo` detail.
+  public static let retryInfoTypeURL = "type.googleapis.com/google.rpc.RetryInfo"
+
+  /// Creates status details carrying a `google.rpc.RetryInfo`, which tells the client how long
+  /// to wait before retrying the RPC. gRPC Swift never retries RPCs automatically: the delay is
+  /// honored by RPCs made with `RetryPolicy.retrying(_:)`, other clients may read it with
+  /// `retryDelay(in:)`.
+  ///
+  /// ```
+  /// return context.eventLoop.makeFailedFuture(
+  ///   GRPCStatusDetails(code: .unavailable, message: "Reindexing", retryDelay: .seconds(5))
+  /// )
+  /// ```
+  ///
+  /// - Parameters:
+  ///   - code: The status code.
+  ///   - message: A developer facing error message.
+  ///   - retryDelay: How long the client should wait before retrying.
+  ///   - details: Any other messages carrying details about the error.
+  public init(
+    code: GRPCStatus.Code,
+    message: String = "",
+    retryDelay: TimeAmount,
+    details: [Google_Protobuf_Any] = []
+  ) {
+    self.init(code: code, message: message, details: details)
+    self.retryDelay = retryDelay
+  }
+
+  /// The retry delay of the first `google.rpc.RetryInfo` in `details`, if there is one. Setting
+  /// a value replaces any existing `RetryInfo`; setting `nil` removes it.
+  public var retryDelay: TimeAmount? {
+    get {
+      for detail in self.details where detail.typeURL == Self.retryInfoTypeURL {
+        if let delay = try? Self.decodeRetryDelay(from: detail.value) {
+          return delay
+        }
+      }
+      return nil
+    }
+    set {
+      self.details.removeAll { $0.typeURL == Self.retryInfoTypeURL }
+      if let delay = newValue, let value = Self.encodeRetryDelay(delay) {
+        var retryInfo = Google_Protobuf_Any()
+        retryInfo.typeURL = Self.retryInfoTypeURL
+        retryInfo.value = value
+        self.details.append(retryInfo)
+      }
+    }
+  }
+
+  /// The retry delay requested by the server in the trailing metadata of an RPC, if there is one.
+  /// Malformed status details are ignored and delays too large for a `TimeAmount` are clamped.
+  ///
+  /// `RetryPolicy` waits for this delay rather than its own backoff before retrying an RPC which
+  /// it decides to retry.
+  ///
+  /// - Parameter trailers: The trailing metadata of an RPC.
+  public static func retryDelay(in trailers: HPACKHeaders) -> TimeAmount? {
+    return (try? GRPCStatusDetails(trailers: trailers))?.retryDelay
+  }
+
+  private static func encodeRetryDelay(_ delay: TimeAmount) -> Data? {
+    let retryInfo = Google_Rpc_RetryInfo.with {
+      $0.retryDelay = Google_Protobuf_Duration(delay)
+    }
+    return try? retryInfo.serializedData()
+  }
+
+  private static func decodeRetryDelay(from data: Data) throws -> TimeAmount? {
+    let retryInfo = try Google_Rpc_RetryInfo(serializedData: data)
+    // The delay is set by the server: clamp it rather than trapping if it's too large.
+    return retryInfo.hasRetryDelay ? TimeAmount(saturating: retryInfo.retryDelay) : nil
+  }
+}
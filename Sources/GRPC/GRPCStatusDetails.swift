@@ -0,0 +1,138 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOFoundationCompat
+import NIOHPACK
+import SwiftProtobuf
+
+/// A rich error model for the status of an RPC, i.e. a `google.rpc.Status`.
+///
+/// Details are sent in the "grpc-status-details-bin" trailer alongside the usual status code and
+/// message. A service provider may fail an RPC with a `GRPCStatusDetails` as its error: any
+/// responses already sent are delivered to the client before the status, and the details are
+/// added to the trailers:
+///
+/// ```
+/// func search(
+///   request: SearchRequest,
+///   context: StreamingResponseCallContext<SearchResult>
+/// ) -> EventLoopFuture<GRPCStatus> {
+///   // ... send some results, then:
+///   let details = GRPCStatusDetails(
+///     code: .unavailable,
+///     message: "Results are incomplete",
+///     details: [try Google_Protobuf_Any(message: backendFailure)]
+///   )
+///   return context.eventLoop.makeFailedFuture(details)
+/// }
+/// ```
+///
+/// Clients can read the details from the trailing metadata of the RPC with
+/// `init(trailers:)`.
+///
+/// Unknown fields are ignored when decoding the status.
+public struct GRPCStatusDetails: Hashable, Error {
+  /// The status code; this should match the code in the "grpc-status" trailer.
+  public var code: GRPCStatus.Code
+
+  /// A developer facing error message.
+  public var message: String
+
+  /// Messages carrying details about the error.
+  public var details: [Google_Protobuf_Any]
+
+  public init(
+    code: GRPCStatus.Code,
+    message: String = "",
+    details: [Google_Protobuf_Any] = []
+  ) {
+    self.code = code
+    self.message = message
+    self.details = details
+  }
+}
+
+extension GRPCStatusDetails: GRPCStatusTransformable {
+  public func makeGRPCStatus() -> GRPCStatus {
+    return GRPCStatus(code: self.code, message: self.message.isEmpty ? nil : self.message)
+  }
+}
+
+extension GRPCStatusDetails: GRPCPayload {
+  public init(serializedByteBuffer: inout ByteBuffer) throws {
+    // '!' is okay; we can always read 'readableBytes'.
+    let data = serializedByteBuffer.readData(length: serializedByteBuffer.readableBytes)!
+    let status = try Google_Rpc_Status(serializedData: data)
+
+    self.init(
+      code: GRPCStatus.Code(rawValue: Int(status.code)) ?? .unknown,
+      message: status.message,
+      details: status.details
+    )
+  }
+
+  public func serialize(into buffer: inout ByteBuffer) throws {
+    let status = Google_Rpc_Status.with {
+      $0.code = Int32(self.code.rawValue)
+      $0.message = self.message
+      $0.details = self.details
+    }
+    buffer.writeBytes(try status.serializedData())
+  }
+}
+
+extension GRPCStatusDetails {
+  /// The name of the trailer in which status details are sent.
+  public static let trailerName = "grpc-status-details-bin"
+
+  /// Decodes status details from the value of the "grpc-status-details-bin" trailer. Binary
+  /// metadata is base64 encoded; padding may be omitted.
+  ///
+  /// - Parameter trailerValue: The base64 encoded trailer value.
+  /// - Throws: If the value is not valid base64 or does not contain a valid status.
+  public init(trailerValue: String) throws {
+    guard let bytes = trailerValue.base64DecodedBytes() else {
+      throw GRPCError.DeserializationFailure()
+    }
+
+    var buffer = ByteBufferAllocator().buffer(capacity: bytes.count)
+    buffer.writeBytes(bytes)
+    try self.init(serializedByteBuffer: &buffer)
+  }
+
+  /// Decodes status details from the trailing metadata of an RPC.
+  ///
+  /// - Parameter trailers: The trailing metadata of an RPC.
+  /// - Returns: The status details, or `nil` if the trailers don't contain any.
+  /// - Throws: If the "grpc-status-details-bin" trailer is present but not valid.
+  public init?(trailers: HPACKHeaders) throws {
+    guard let value = trailers.first(name: Self.trailerName) else {
+      return nil
+    }
+    try self.init(trailerValue: value)
+  }
+
+  /// The base64 encoded value of the "grpc-status-details-bin" trailer for these details.
+  ///
+  /// - Throws: If any of the `details` could not be serialized.
+  public func makeTrailerValue() throws -> String {
+    var buffer = ByteBufferAllocator().buffer(capacity: 64)
+    try self.serialize(into: &buffer)
+    // '!' is okay; we can always read 'readableBytes'.
+    return buffer.readData(length: buffer.readableBytes)!.base64EncodedString()
+  }
+}
@@ -147,7 +147,10 @@ extension GRPCStatusMessageMarshaller {
       currentIndex = utf8.index(after: currentIndex)
     }
 
-    return String(decoding: chars, as: Unicode.UTF8.self)
+    // The decoded bytes may not be valid UTF-8, in which case decoding substitutes replacement
+    // characters and the bytes of the string differ. Return the encoded message instead.
+    let decoded = String(decoding: chars, as: Unicode.UTF8.self)
+    return decoded.utf8.elementsEqual(chars) ? decoded : message
   }
 
   /// Returns the expected length of the decoded `UTF8View`.
@@ -181,9 +184,9 @@ extension GRPCStatusMessageMarshaller {
     switch byte {
     case UInt8(ascii: "0") ... UInt8(ascii: "9"):
       return byte &- UInt8(ascii: "0")
-    case UInt8(ascii: "A") ... UInt8(ascii: "Z"):
+    case UInt8(ascii: "A") ... UInt8(ascii: "F"):
       return byte &- (UInt8(ascii: "A") &- 10)
-    case UInt8(ascii: "a") ... UInt8(ascii: "z"):
+    case UInt8(ascii: "a") ... UInt8(ascii: "f"):
       return byte &- (UInt8(ascii: "a") &- 10)
     default:
       return nil
@@ -195,8 +198,8 @@ extension String.UTF8View {
   /// Return the next two valid indices after the given index. The indices are considered valid if
   /// they less than `endIndex`.
   fileprivate func nextTwoIndices(after index: Index) -> (Index, Index)? {
-    let secondIndex = self.index(index, offsetBy: 2)
-    guard secondIndex < self.endIndex else {
+    guard let secondIndex = self.index(index, offsetBy: 2, limitedBy: self.endIndex),
+      secondIndex < self.endIndex else {
       return nil
     }
 
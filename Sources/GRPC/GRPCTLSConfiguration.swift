@@ -194,6 +194,17 @@ extension GRPCTLSConfiguration {
     var hostnameOverride: String?
     // The client doesn't support this yet (https://github.com/grpc/grpc-swift/issues/1042).
     var requireALPN: Bool
+    // Server only: identities to present instead of the default, keyed by lowercased SNI name.
+    var identitiesByServerName: [String: ServerIdentity] = [:]
+    // Server only: whether connections with an SNI name not in `identitiesByServerName` (or
+    // without one) are rejected rather than being presented the default identity.
+    var rejectUnknownServerNames = false
+  }
+
+  /// A certificate chain and private key presented by a server.
+  internal struct ServerIdentity {
+    var certificateChain: [NIOSSLCertificateSource]
+    var privateKey: NIOSSLPrivateKeySource
   }
 
   /// TLS Configuration with suitable defaults for clients, using `NIOSSL`.
@@ -340,6 +351,32 @@ extension GRPCTLSConfiguration {
     }
   }
 
+  /// Makes the `NIOSSLContext`s for a server: one for the default identity and one for each
+  /// identity selected by server name. Returns `nil` if the backend isn't NIOSSL.
+  internal func makeNIOSSLServerContexts() throws -> NIOSSLServerContexts? {
+    switch self.backend {
+    case let .nio(configuration):
+      let defaultContext = try NIOSSLContext(configuration: configuration.configuration)
+      let contextsByServerName = try configuration.identitiesByServerName.mapValues {
+        identity -> NIOSSLContext in
+        var tlsConfiguration = configuration.configuration
+        tlsConfiguration.certificateChain = identity.certificateChain
+        tlsConfiguration.privateKey = identity.privateKey
+        return try NIOSSLContext(configuration: tlsConfiguration)
+      }
+
+      return NIOSSLServerContexts(
+        defaultContext: defaultContext,
+        contextsByServerName: contextsByServerName,
+        rejectUnknownServerNames: configuration.rejectUnknownServerNames
+      )
+    #if canImport(Network)
+    case .network:
+      return nil
+    #endif
+    }
+  }
+
   internal var nioSSLCustomVerificationCallback: NIOSSLCustomVerificationCallback? {
     switch self.backend {
     case let .nio(configuration):
@@ -385,6 +422,21 @@ extension GRPCTLSConfiguration {
     }
   }
 
+  internal mutating func updateNIOServerIdentity(
+    _ identity: ServerIdentity,
+    forServerName serverName: String
+  ) {
+    self.modifyingNIOConfiguration {
+      $0.identitiesByServerName[serverName.lowercased()] = identity
+    }
+  }
+
+  internal mutating func updateNIORejectUnknownServerNames(to reject: Bool) {
+    self.modifyingNIOConfiguration {
+      $0.rejectUnknownServerNames = reject
+    }
+  }
+
   private mutating func modifyingNIOConfiguration(_ modify: (inout NIOConfiguration) -> Void) {
     switch self.backend {
     case var .nio(configuration):
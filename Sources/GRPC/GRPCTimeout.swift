@@ -15,6 +15,7 @@
  */
 import Dispatch
 import NIO
+import NIOHPACK
 
 /// A timeout for a gRPC call.
 ///
@@ -51,6 +52,40 @@ public struct GRPCTimeout: CustomStringConvertible, Equatable {
     }
   }
 
+  /// Parses a timeout from its wire encoding, e.g. "100m". Returns `nil` if the encoding is not
+  /// valid.
+  internal init?(decoding wireEncoding: String) {
+    // At least one digit and a unit, no more than 8 digits.
+    guard (2 ... 9).contains(wireEncoding.utf8.count),
+      let unit = wireEncoding.last.flatMap({ GRPCTimeoutUnit(rawValue: String($0)) }) else {
+      return nil
+    }
+
+    let digits = wireEncoding.dropLast()
+    guard digits.utf8.allSatisfy({ $0 >= UInt8(ascii: "0") && $0 <= UInt8(ascii: "9") }),
+      let amount = Int64(digits) else {
+      return nil
+    }
+
+    self.init(amount: amount, unit: unit)
+  }
+
+  /// Returns the deadline for an RPC with the given request headers: the time at which the
+  /// timeout in the 'grpc-timeout' header expires, if present and valid, or `.distantFuture`
+  /// otherwise.
+  @usableFromInline
+  internal static func deadline(
+    fromRequestHeaders headers: HPACKHeaders,
+    now: NIODeadline = .now()
+  ) -> NIODeadline {
+    guard let timeout = headers.first(name: GRPCHeaderName.timeout)
+      .flatMap(GRPCTimeout.init(decoding:)) else {
+      return .distantFuture
+    }
+
+    return now + .nanoseconds(timeout.nanoseconds)
+  }
+
   private init(nanoseconds: Int64, wireEncoding: String) {
     self.nanoseconds = nanoseconds
     self.wireEncoding = wireEncoding
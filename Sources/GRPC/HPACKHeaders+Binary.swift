@@ -0,0 +1,66 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIOHPACK
+
+extension HPACKHeaders {
+  /// Adds a binary metadata value. Binary values are base64 encoded on the wire; gRPC does not
+  /// otherwise modify them.
+  ///
+  /// - Parameters:
+  ///   - name: The name of the metadata, e.g. "grpc-tags-bin". It must end with "-bin".
+  ///   - value: The bytes of the value.
+  /// - Throws: `GRPCError.InvalidState` if `name` doesn't end with "-bin", in which case nothing
+  ///     is added.
+  public mutating func addBinary<Bytes: Sequence>(
+    name: String,
+    value: Bytes
+  ) throws where Bytes.Element == UInt8 {
+    guard name.hasSuffix("-bin") else {
+      throw GRPCError.InvalidState(
+        "The names of binary metadata must end with '-bin' (but was '\(name)')"
+      )
+    }
+    self.add(name: name, value: Data(value).base64EncodedString())
+  }
+
+  /// Returns the decoded values of all binary metadata with the given name, in order.
+  ///
+  /// Both padded and unpadded base64 values are accepted, as are multiple comma separated values
+  /// in a single entry (as may be produced by intermediaries joining repeated headers).
+  ///
+  /// - Parameter name: The name of the metadata, e.g. "grpc-tags-bin".
+  /// - Throws: `GRPCError.Base64DecodeError` if a value isn't valid base64.
+  public func binaryValues(forName name: String) throws -> [[UInt8]] {
+    return try self[name].flatMap { value in
+      try value.split(separator: ",", omittingEmptySubsequences: false).map { encoded in
+        let trimmed = encoded.trimmingCharacters(in: .whitespaces)
+        guard let bytes = trimmed.base64DecodedBytes() else {
+          throw GRPCError.Base64DecodeError()
+        }
+        return bytes
+      }
+    }
+  }
+
+  /// Returns the decoded value of the first binary metadata with the given name, if any.
+  ///
+  /// - Parameter name: The name of the metadata, e.g. "grpc-tags-bin".
+  /// - Throws: `GRPCError.Base64DecodeError` if the value isn't valid base64.
+  public func firstBinaryValue(forName name: String) throws -> [UInt8]? {
+    return try self.binaryValues(forName: name).first
+  }
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIOHPACK
+
+extension HPACKHeaders {
+  /// Creates metadata from HTTP/1-style header name and value pairs, for example when bridging
+  /// requests from an HTTP/1 gateway.
+  ///
+  /// Names are lowercased as required by HTTP/2 and repeated names are kept as separate entries,
+  /// in order. Values of binary headers (those whose names end in "-bin") must be base64 encoded;
+  /// they are decoded and re-encoded with padding, since gRPC accepts both padded and unpadded
+  /// values but not all peers do. Pseudo-headers (those whose names start with ":") are dropped.
+  ///
+  /// - Parameter httpHeaders: The header name and value pairs.
+  /// - Throws: `GRPCError.Base64DecodeError` if the value of a binary header isn't valid base64.
+  public init(httpHeaders: [(String, String)]) throws {
+    self.init()
+    self.reserveCapacity(httpHeaders.count)
+
+    for (name, value) in httpHeaders {
+      let name = name.lowercased()
+      if name.hasPrefix(":") {
+        continue
+      }
+
+      if name.hasSuffix("-bin") {
+        guard let bytes = value.base64DecodedBytes() else {
+          throw GRPCError.Base64DecodeError()
+        }
+        self.add(name: name, value: Data(bytes).base64EncodedString())
+      } else {
+        self.add(name: name, value: value)
+      }
+    }
+  }
+
+  /// The headers as HTTP/1-style name and value pairs, excluding any pseudo-headers. Repeated
+  /// names are returned as separate pairs and values of binary headers remain base64 encoded.
+  public var httpHeaders: [(String, String)] {
+    return self.compactMap { name, value, _ in
+      name.hasPrefix(":") ? nil : (name, value)
+    }
+  }
+}
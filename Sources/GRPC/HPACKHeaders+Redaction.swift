@@ -0,0 +1,80 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIOHPACK
+
+extension HPACKHeaders {
+  /// The names of headers whose values are redacted by `redacting(names:replacement:)` by default.
+  /// These headers typically carry credentials.
+  public static let defaultSensitiveNames: Set<String> = [
+    "authorization",
+    "proxy-authorization",
+    "cookie",
+    "set-cookie",
+    "x-api-key",
+  ]
+
+  /// Returns a copy of the headers with the values of any headers with the given names replaced.
+  /// This is useful for logging headers which may contain sensitive values.
+  ///
+  /// - Parameters:
+  ///   - names: The names of the headers to redact, matched case-insensitively. Defaults to
+  ///       `HPACKHeaders.defaultSensitiveNames`.
+  ///   - replacement: The value to replace redacted values with.
+  public func redacting(
+    names: Set<String> = HPACKHeaders.defaultSensitiveNames,
+    replacement: String = "<redacted>"
+  ) -> HPACKHeaders {
+    let names = Set(names.map { $0.lowercased() })
+    return self.replacingValues(replacement) { name in
+      names.contains(name.lowercased())
+    }
+  }
+
+  /// Returns a copy of the headers with the values of all headers *except* those with the given
+  /// names replaced. This is useful for logging headers when only a known set of headers is safe
+  /// to log.
+  ///
+  /// - Parameters:
+  ///   - names: The names of the headers whose values should be kept, matched case-insensitively.
+  ///   - replacement: The value to replace redacted values with.
+  public func allowingOnly(
+    names: Set<String>,
+    replacement: String = "<redacted>"
+  ) -> HPACKHeaders {
+    let names = Set(names.map { $0.lowercased() })
+    return self.replacingValues(replacement) { name in
+      !names.contains(name.lowercased())
+    }
+  }
+
+  private func replacingValues(
+    _ replacement: String,
+    where shouldReplace: (String) -> Bool
+  ) -> HPACKHeaders {
+    var headers = HPACKHeaders()
+    headers.reserveCapacity(self.count)
+
+    for (name, value, indexing) in self {
+      if shouldReplace(name) {
+        headers.add(name: name, value: replacement, indexing: indexing)
+      } else {
+        headers.add(name: name, value: value, indexing: indexing)
+      }
+    }
+
+    return headers
+  }
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIOHPACK
+
+extension HPACKHeaders {
+  /// The size of the headers as measured against the HTTP/2 SETTINGS_MAX_HEADER_LIST_SIZE
+  /// advertised by a peer: the length in bytes of each name and value plus an overhead of 32 bytes
+  /// per header.
+  ///
+  /// This is the size of the headers before HPACK compression, which is how peers enforce their
+  /// limit; the number of bytes sent on the wire is typically smaller.
+  ///
+  /// See: https://httpwg.org/specs/rfc7540.html#SETTINGS_MAX_HEADER_LIST_SIZE
+  public var headerListSize: Int {
+    return self.reduce(0) { size, header in
+      size + HPACKHeaders.headerSize(name: header.name, value: header.value)
+    }
+  }
+
+  /// The size of a single header as counted by `headerListSize`.
+  internal static func headerSize(name: String, value: String) -> Int {
+    return name.utf8.count + value.utf8.count + 32
+  }
+}
@@ -0,0 +1,124 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Logging
+import NIO
+import NIOHTTP2
+
+/// Logs every HTTP/2 frame read from or written to a connection. Must be added to the pipeline
+/// directly after the `NIOHTTP2Handler`.
+///
+/// Frames are logged at the 'debug' level with the stream ID and frame type as metadata. Header
+/// values which typically carry credentials are redacted and the contents of DATA frames are
+/// not logged, only their size.
+internal final class HTTP2FrameLoggingHandler: ChannelDuplexHandler {
+  typealias InboundIn = HTTP2Frame
+  typealias InboundOut = HTTP2Frame
+  typealias OutboundIn = HTTP2Frame
+  typealias OutboundOut = HTTP2Frame
+
+  private let logger: Logger
+
+  internal init(logger: Logger) {
+    self.logger = logger
+  }
+
+  internal func channelRead(context: ChannelHandlerContext, data: NIOAny) {
+    self.log(self.unwrapInboundIn(data), direction: "inbound")
+    context.fireChannelRead(data)
+  }
+
+  internal func write(
+    context: ChannelHandlerContext,
+    data: NIOAny,
+    promise: EventLoopPromise<Void>?
+  ) {
+    self.log(self.unwrapOutboundIn(data), direction: "outbound")
+    context.write(data, promise: promise)
+  }
+
+  private func log(_ frame: HTTP2Frame, direction: Logger.MetadataValue) {
+    var metadata: Logger.Metadata = [
+      MetadataKey.h2Direction: direction,
+      MetadataKey.h2StreamID: "\(frame.streamID)",
+    ]
+
+    let type: Logger.MetadataValue
+
+    switch frame.payload {
+    case let .headers(headers):
+      type = "HEADERS"
+      metadata[MetadataKey.h2EndStream] = "\(headers.endStream)"
+      metadata[MetadataKey.h2Headers] = "\(headers.headers.redacting())"
+
+    case let .data(data):
+      type = "DATA"
+      metadata[MetadataKey.h2EndStream] = "\(data.endStream)"
+      metadata[MetadataKey.h2DataBytes] = "\(data.data.readableBytes)"
+      if let paddingBytes = data.paddingBytes {
+        metadata[MetadataKey.h2PaddingBytes] = "\(paddingBytes)"
+      }
+
+    case let .settings(settings):
+      type = "SETTINGS"
+      switch settings {
+      case .ack:
+        metadata[MetadataKey.h2Ack] = "true"
+      case let .settings(parameters):
+        metadata[MetadataKey.h2Ack] = "false"
+        let values = parameters.map {
+          Logger.MetadataValue.string("\($0.parameter)=\($0.value)")
+        }
+        metadata[MetadataKey.h2Settings] = .array(values)
+      }
+
+    case let .ping(data, ack):
+      type = "PING"
+      metadata[MetadataKey.h2Ack] = "\(ack)"
+      metadata[MetadataKey.h2PingData] = "\(data)"
+
+    case let .goAway(lastStreamID, errorCode, opaqueData):
+      type = "GOAWAY"
+      metadata[MetadataKey.h2LastStreamID] = "\(lastStreamID)"
+      metadata[MetadataKey.h2ErrorCode] = "\(errorCode)"
+      if let opaqueData = opaqueData, opaqueData.readableBytes > 0 {
+        metadata[MetadataKey.h2DebugData] = "\(String(buffer: opaqueData))"
+      }
+
+    case let .rstStream(errorCode):
+      type = "RST_STREAM"
+      metadata[MetadataKey.h2ErrorCode] = "\(errorCode)"
+
+    case let .windowUpdate(windowSizeIncrement):
+      type = "WINDOW_UPDATE"
+      metadata[MetadataKey.h2WindowSizeIncrement] = "\(windowSizeIncrement)"
+
+    case .priority:
+      type = "PRIORITY"
+
+    case .pushPromise:
+      type = "PUSH_PROMISE"
+
+    case .alternativeService:
+      type = "ALTSVC"
+
+    case .origin:
+      type = "ORIGIN"
+    }
+
+    metadata[MetadataKey.h2Payload] = type
+    self.logger.debug("HTTP/2 frame", metadata: metadata)
+  }
+}
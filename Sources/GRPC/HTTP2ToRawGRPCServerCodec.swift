@@ -28,10 +28,61 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
   private var context: ChannelHandlerContext!
 
   private let servicesByName: [Substring: CallHandlerProvider]
+
+  /// Alternative paths for methods, keyed by the alias.
+  private let pathAliases: [String: String]
+  private let duplicateMetadataPolicy: DuplicateMetadataPolicy
+  private let unknownFieldHandling: ServerUnknownFieldHandling
   private let encoding: ServerMessageEncoding
   private let normalizeHeaders: Bool
+
+  /// Whether to include the closest known methods in the status of unimplemented RPCs.
+  private let includeKnownMethodsInUnimplementedStatus: Bool
+
+  /// Metadata to add to the logger once the request headers have been received.
+  private let rpcLoggerMetadata: ServerRPCLoggerMetadata
+
   private let maxReceiveMessageLength: Int
 
+  /// Limits on the total message bytes transferred by RPCs, and per-method overrides keyed by
+  /// path.
+  private let transferLimits: MessageTransferLimits
+  private let transferLimitsByMethod: [String: MessageTransferLimits]
+
+  /// The maximum number of request messages an RPC's handler may buffer before its stream
+  /// observer has been created, or `nil` if there is no limit.
+  private let maximumBufferedRequests: Int?
+
+  /// Interceptors which run ahead of those provided by the service provider of each RPC.
+  private let interceptorRegistry: ServerInterceptorRegistry
+
+  /// Totals of the messages transferred by the RPC. Set when the request headers are read.
+  private var transferTotals: MessageTransferTotals?
+
+  /// The transfer limits of the RPC. Set when the request headers are read.
+  private var rpcTransferLimits: MessageTransferLimits = .unlimited
+
+  /// The status to end the RPC with if it has exceeded one of its transfer limits.
+  private var transferLimitStatus: GRPCStatus?
+
+  /// The ID of the HTTP/2 stream this handler is serving, if known.
+  private let streamID: HTTP2StreamID?
+
+  /// The context of the connection this handler's stream is on, if known.
+  private let connection: ConnectionContext?
+
+  /// Called with each serialized message sent or received on this stream, if set.
+  private let messageObserver: ((ObservedMessage) -> Void)?
+
+  /// The path of the RPC, used when observing messages. Set when the request headers are read.
+  private var path: String = ""
+
+  /// Called with the compression statistics for the RPC when the stream closes, if set.
+  private let compressionStatisticsObserver: ((CompressionStatistics) -> Void)?
+
+  /// Compression statistics for the RPC; only collected if there is an observer.
+  private var compressionStatistics: CompressionStatistics?
+
   /// The configuration state of the handler.
   private var configurationState: Configuration = .notConfigured
 
@@ -43,6 +94,16 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
   /// then it is held until the read completes in order to elide unnecessary flushes.
   private var flushPending = false
 
+  /// A task which fails the RPC once the deadline sent by the client has passed.
+  private var scheduledDeadline: Scheduled<Void>?
+
+  /// The maximum amount of time the stream may go without reading or writing a frame before
+  /// it is reset.
+  private let streamInactivityTimeout: TimeAmount
+
+  /// A task which resets the stream once it has been inactive for `streamInactivityTimeout`.
+  private var scheduledInactivityTimeout: Scheduled<Void>?
+
   private enum Configuration {
     case notConfigured
     case configured(GRPCServerHandlerProtocol)
@@ -69,28 +130,62 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
 
   init(
     servicesByName: [Substring: CallHandlerProvider],
+    pathAliases: [String: String] = [:],
+    duplicateMetadataPolicy: DuplicateMetadataPolicy = .keepAll,
+    unknownFieldHandling: ServerUnknownFieldHandling = ServerUnknownFieldHandling(),
     encoding: ServerMessageEncoding,
     errorDelegate: ServerErrorDelegate?,
     normalizeHeaders: Bool,
     maximumReceiveMessageLength: Int,
+    transferLimits: MessageTransferLimits = .unlimited,
+    transferLimitsByMethod: [String: MessageTransferLimits] = [:],
+    maximumBufferedRequests: Int? = nil,
+    interceptorRegistry: ServerInterceptorRegistry = ServerInterceptorRegistry(),
+    streamID: HTTP2StreamID? = nil,
+    connection: ConnectionContext? = nil,
+    messageObserver: ((ObservedMessage) -> Void)? = nil,
+    compressionStatisticsObserver: ((CompressionStatistics) -> Void)? = nil,
+    includeKnownMethodsInUnimplementedStatus: Bool = false,
+    rpcLoggerMetadata: ServerRPCLoggerMetadata = .none,
+    streamInactivityTimeout: TimeAmount = .nanoseconds(.max),
     logger: Logger
   ) {
     self.logger = logger
     self.errorDelegate = errorDelegate
     self.servicesByName = servicesByName
+    self.pathAliases = pathAliases
+    self.duplicateMetadataPolicy = duplicateMetadataPolicy
+    self.unknownFieldHandling = unknownFieldHandling
     self.encoding = encoding
     self.normalizeHeaders = normalizeHeaders
+    self.includeKnownMethodsInUnimplementedStatus = includeKnownMethodsInUnimplementedStatus
+    self.rpcLoggerMetadata = rpcLoggerMetadata
     self.maxReceiveMessageLength = maximumReceiveMessageLength
+    self.transferLimits = transferLimits
+    self.transferLimitsByMethod = transferLimitsByMethod
+    self.maximumBufferedRequests = maximumBufferedRequests
+    self.interceptorRegistry = interceptorRegistry
+    self.streamID = streamID
+    self.connection = connection
+    self.messageObserver = messageObserver
+    self.compressionStatisticsObserver = compressionStatisticsObserver
+    self.streamInactivityTimeout = streamInactivityTimeout
+    if compressionStatisticsObserver != nil {
+      self.compressionStatistics = CompressionStatistics(path: "", sent: .init(), received: .init())
+    }
     self.state = HTTP2ToRawGRPCStateMachine()
   }
 
   internal func handlerAdded(context: ChannelHandlerContext) {
     self.context = context
+    self.scheduleInactivityTimeout(on: context.eventLoop)
   }
 
   internal func handlerRemoved(context: ChannelHandlerContext) {
     self.context = nil
     self.configurationState = .notConfigured
+    self.cancelDeadline()
+    self.cancelInactivityTimeout()
   }
 
   internal func errorCaught(context: ChannelHandlerContext, error: Error) {
@@ -103,6 +198,14 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
   }
 
   internal func channelInactive(context: ChannelHandlerContext) {
+    self.cancelDeadline()
+    self.cancelInactivityTimeout()
+
+    if let statistics = self.compressionStatistics {
+      self.compressionStatistics = nil
+      self.compressionStatisticsObserver?(statistics)
+    }
+
     if let handler = self.configurationState.tearDown() {
       handler.finish()
     } else {
@@ -112,28 +215,63 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
 
   internal func channelRead(context: ChannelHandlerContext, data: NIOAny) {
     self.isReading = true
+    self.recordActivity()
     let payload = self.unwrapInboundIn(data)
 
     switch payload {
-    case let .headers(payload):
+    case var .headers(payload):
+      self.resolvePathAlias(in: &payload.headers)
+      self.duplicateMetadataPolicy.apply(to: &payload.headers)
+
+      if self.messageObserver != nil || self.compressionStatistics != nil {
+        self.path = payload.headers.first(name: ":path") ?? ""
+        self.compressionStatistics?.path = self.path
+      }
+
+      self.rpcLoggerMetadata.apply(
+        to: &self.logger,
+        requestHeaders: payload.headers,
+        remoteAddress: context.channel.remoteAddress
+      )
+
+      let transferTotals = MessageTransferTotals()
+      self.transferTotals = transferTotals
+      if let path = payload.headers.first(name: ":path") {
+        self.rpcTransferLimits = self.transferLimitsByMethod[path] ?? self.transferLimits
+      } else {
+        self.rpcTransferLimits = self.transferLimits
+      }
+
+      // Computed once so that the deadline we enforce is the one the call context exposes.
+      let deadline = GRPCTimeout.deadline(fromRequestHeaders: payload.headers)
+
       let receiveHeaders = self.state.receive(
         headers: payload.headers,
         eventLoop: context.eventLoop,
         errorDelegate: self.errorDelegate,
         remoteAddress: context.channel.remoteAddress,
+        streamID: self.streamID,
+        connection: self.connection,
         logger: self.logger,
         allocator: context.channel.allocator,
         responseWriter: self,
         closeFuture: context.channel.closeFuture,
         services: self.servicesByName,
+        unknownFieldHandling: self.unknownFieldHandling,
+        transferTotals: transferTotals,
+        deadline: deadline,
+        maximumBufferedRequests: self.maximumBufferedRequests,
+        interceptorRegistry: self.interceptorRegistry,
         encoding: self.encoding,
-        normalizeHeaders: self.normalizeHeaders
+        normalizeHeaders: self.normalizeHeaders,
+        includeKnownMethodsInUnimplementedStatus: self.includeKnownMethodsInUnimplementedStatus
       )
 
       switch receiveHeaders {
       case let .configure(handler):
         assert(!self.configurationState.isConfigured)
         self.configurationState = .configured(handler)
+        self.scheduleDeadline(deadline, on: context.eventLoop)
         self.configured()
 
       case let .rejectRPC(trailers):
@@ -146,6 +284,7 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
     case let .data(payload):
       switch payload.data {
       case var .byteBuffer(buffer):
+        self.compressionStatistics?.received.recordReceived(framedBytes: buffer.readableBytes)
         let action = self.state.receive(buffer: &buffer, endStream: payload.endStream)
         switch action {
         case .tryReading:
@@ -188,6 +327,100 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
     context.fireChannelReadComplete()
   }
 
+  /// Replaces the ':path' of the request with the path it is an alias for, if it is an alias.
+  private func resolvePathAlias(in headers: inout HPACKHeaders) {
+    guard !self.pathAliases.isEmpty,
+      let alias = headers.first(name: ":path"),
+      let path = self.pathAliases[alias] else {
+      return
+    }
+
+    self.logger.debug("routing rpc on aliased path", metadata: [
+      "alias": "\(alias)",
+      "path": "\(path)",
+    ])
+    headers.replaceOrAdd(name: ":path", value: path)
+  }
+
+  /// Schedules a task to fail the RPC when the given deadline passes.
+  private func scheduleDeadline(_ deadline: NIODeadline, on eventLoop: EventLoop) {
+    guard deadline != .distantFuture else {
+      return
+    }
+
+    self.scheduledDeadline = eventLoop.scheduleTask(deadline: deadline) {
+      self.scheduledDeadline = nil
+
+      switch self.configurationState {
+      case .notConfigured:
+        ()
+      case let .configured(handler):
+        self.logger.debug("rpc deadline exceeded, failing rpc")
+        handler.receiveError(GRPCError.RPCTimedOut(.deadline(deadline)))
+      }
+    }
+  }
+
+  /// Cancels the deadline task, if one exists.
+  private func cancelDeadline() {
+    self.scheduledDeadline?.cancel()
+    self.scheduledDeadline = nil
+  }
+
+  /// Records that a frame was read or written on the stream, restarting the inactivity timeout
+  /// if there is one.
+  private func recordActivity() {
+    if let scheduled = self.scheduledInactivityTimeout {
+      scheduled.cancel()
+      self.scheduleInactivityTimeout(on: self.context.eventLoop)
+    }
+  }
+
+  /// Schedules a task to reset the stream once it has been inactive for the configured amount
+  /// of time.
+  private func scheduleInactivityTimeout(on eventLoop: EventLoop) {
+    guard self.streamInactivityTimeout != .nanoseconds(.max) else {
+      return
+    }
+
+    self.scheduledInactivityTimeout = eventLoop.scheduleTask(in: self.streamInactivityTimeout) {
+      self.scheduledInactivityTimeout = nil
+      self.streamInactivityTimeoutExpired()
+    }
+  }
+
+  /// Cancels the inactivity timeout task, if one exists.
+  private func cancelInactivityTimeout() {
+    self.scheduledInactivityTimeout?.cancel()
+    self.scheduledInactivityTimeout = nil
+  }
+
+  /// Fails the RPC, if there is one, and resets the stream.
+  private func streamInactivityTimeoutExpired() {
+    guard let context = self.context else {
+      return
+    }
+
+    self.logger.debug("stream inactivity timeout expired, resetting stream", metadata: [
+      "timeout": "\(self.streamInactivityTimeout)",
+    ])
+
+    switch self.configurationState {
+    case .notConfigured:
+      ()
+    case let .configured(handler):
+      handler.receiveError(
+        GRPCStatus(
+          code: .deadlineExceeded,
+          message: "Stream was inactive for longer than the server's stream inactivity timeout"
+        )
+      )
+    }
+
+    // Closing the stream channel resets the stream if it hasn't already been closed.
+    context.close(mode: .all, promise: nil)
+  }
+
   /// Called when the pipeline has finished configuring.
   private func configured() {
     switch self.state.pipelineConfigured() {
@@ -214,32 +447,32 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
   private func tryReadingMessage() {
     // This while loop exists to break the recursion in `.forwardMessageThenReadNextMessage`.
     // Almost all cases return directly out of the loop.
-    while true {
+    while self.transferLimitStatus == nil {
+      let start: NIODeadline? = self.compressionStatistics == nil ? nil : .now()
       let action = self.state.readNextRequest(
         maxLength: self.maxReceiveMessageLength
       )
+
       switch action {
       case .none:
         return
 
       case let .forwardMessage(buffer):
-        switch self.configurationState {
-        case .notConfigured:
-          preconditionFailure()
-        case let .configured(handler):
-          handler.receiveMessage(buffer)
+        guard self.recordReceivedMessage(buffer, readStartedAt: start) else {
+          return
         }
 
+        self.forwardMessage(buffer)
+
         return
 
       case let .forwardMessageThenReadNextMessage(buffer):
-        switch self.configurationState {
-        case .notConfigured:
-          preconditionFailure()
-        case let .configured(handler):
-          handler.receiveMessage(buffer)
+        guard self.recordReceivedMessage(buffer, readStartedAt: start) else {
+          return
         }
 
+        self.forwardMessage(buffer)
+
         continue
 
       case .forwardEnd:
@@ -265,11 +498,106 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
     }
   }
 
+  /// Passes a request message to the message observer, if there is one, and then to the handler.
+  private func forwardMessage(_ buffer: ByteBuffer) {
+    switch self.configurationState {
+    case .notConfigured:
+      preconditionFailure()
+    case let .configured(handler):
+      self.messageObserver?(.init(path: self.path, direction: .inbound, bytes: buffer))
+      handler.receiveMessage(buffer)
+    }
+  }
+
+  /// Records a request message read by the state machine in the compression statistics and the
+  /// transfer totals. Returns `false` if the RPC was failed and the message must not be forwarded.
+  private func recordReceivedMessage(
+    _ buffer: ByteBuffer,
+    readStartedAt start: NIODeadline?
+  ) -> Bool {
+    if let start = start {
+      self.compressionStatistics?.received.recordReceived(
+        messages: [buffer],
+        duration: .now() - start
+      )
+    }
+
+    return self.recordRequestMessage(buffer)
+  }
+
+  /// Adds a request message to the transfer totals. Returns `false` and fails the RPC if the
+  /// total request size limit has been exceeded.
+  private func recordRequestMessage(_ buffer: ByteBuffer) -> Bool {
+    guard let totals = self.transferTotals else {
+      return true
+    }
+
+    totals.requestMessages += 1
+    totals.requestBytes += buffer.readableBytes
+
+    if let limit = self.rpcTransferLimits.maximumTotalRequestBytes, totals.requestBytes > limit {
+      let status = GRPCStatus(
+        code: .resourceExhausted,
+        message: "Total request size exceeds limit (\(limit) bytes)"
+      )
+      self.transferLimitStatus = status
+      self.failRPCExceedingTransferLimit(status)
+      return false
+    }
+
+    return true
+  }
+
+  /// Adds a response message to the transfer totals. Returns `false` and fails the RPC if the
+  /// total response size limit would be exceeded by sending the message.
+  private func recordResponseMessage(_ buffer: ByteBuffer) -> Bool {
+    guard let totals = self.transferTotals else {
+      return true
+    }
+
+    guard self.transferLimitStatus == nil else {
+      return false
+    }
+
+    if let limit = self.rpcTransferLimits.maximumTotalResponseBytes,
+      totals.responseBytes + buffer.readableBytes > limit {
+      let status = GRPCStatus(
+        code: .resourceExhausted,
+        message: "Total response size exceeds limit (\(limit) bytes)"
+      )
+      // The handler is sending this message; fail the RPC once it has finished doing so. If it
+      // ends the RPC first then its status is replaced in 'sendEnd'.
+      self.transferLimitStatus = status
+      self.context.eventLoop.execute {
+        self.failRPCExceedingTransferLimit(status)
+      }
+      return false
+    }
+
+    totals.responseMessages += 1
+    totals.responseBytes += buffer.readableBytes
+    return true
+  }
+
+  private func failRPCExceedingTransferLimit(_ status: GRPCStatus) {
+    self.logger.debug("rpc exceeded transfer limit, failing rpc", metadata: [
+      "status": "\(status)",
+    ])
+
+    switch self.configurationState {
+    case .notConfigured:
+      ()
+    case let .configured(handler):
+      handler.receiveError(status)
+    }
+  }
+
   internal func sendMetadata(
     _ headers: HPACKHeaders,
     flush: Bool,
     promise: EventLoopPromise<Void>?
   ) {
+    self.recordActivity()
     switch self.state.send(headers: headers) {
     case let .success(headers):
       let payload = HTTP2Frame.FramePayload.headers(.init(headers: headers))
@@ -288,6 +616,15 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
     metadata: MessageMetadata,
     promise: EventLoopPromise<Void>?
   ) {
+    self.recordActivity()
+
+    if !self.recordResponseMessage(buffer) {
+      promise?.fail(self.transferLimitStatus!)
+      return
+    }
+
+    self.messageObserver?(.init(path: self.path, direction: .outbound, bytes: buffer))
+    let start: NIODeadline? = self.compressionStatistics == nil ? nil : .now()
     let writeBuffer = self.state.send(
       buffer: buffer,
       allocator: self.context.channel.allocator,
@@ -295,8 +632,16 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
     )
 
     switch writeBuffer {
-    case let .success(buffer):
-      let payload = HTTP2Frame.FramePayload.data(.init(data: .byteBuffer(buffer)))
+    case let .success(framed):
+      if let start = start {
+        self.compressionStatistics?.sent.recordSent(
+          uncompressedBytes: buffer.readableBytes,
+          framedBytes: framed.readableBytes,
+          duration: .now() - start
+        )
+      }
+
+      let payload = HTTP2Frame.FramePayload.data(.init(data: .byteBuffer(framed)))
       self.context.write(self.wrapOutboundOut(payload), promise: promise)
       if metadata.flush {
         self.markFlushPoint()
@@ -312,11 +657,15 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
     trailers: HPACKHeaders,
     promise: EventLoopPromise<Void>?
   ) {
+    // An RPC which exceeded a transfer limit always ends with that status.
+    let status = self.transferLimitStatus ?? status
     switch self.state.send(status: status, trailers: trailers) {
     case let .sendTrailers(trailers):
+      self.cancelDeadline()
       self.sendTrailers(trailers, promise: promise)
 
     case let .sendTrailersAndFinish(trailers):
+      self.cancelDeadline()
       self.sendTrailers(trailers, promise: promise)
 
       // 'finish' the handler.
@@ -328,6 +677,10 @@ internal final class HTTP2ToRawGRPCServerCodec: ChannelInboundHandler, GRPCServe
     }
   }
 
+  internal func flush() {
+    self.markFlushPoint()
+  }
+
   private func sendTrailers(_ trailers: HPACKHeaders, promise: EventLoopPromise<Void>?) {
     // Always end stream for status and trailers.
     let payload = HTTP2Frame.FramePayload.headers(.init(headers: trailers, endStream: true))
@@ -271,13 +271,21 @@ extension HTTP2ToRawGRPCStateMachine.State {
     eventLoop: EventLoop,
     errorDelegate: ServerErrorDelegate?,
     remoteAddress: SocketAddress?,
+    streamID: HTTP2StreamID?,
+    connection: ConnectionContext?,
     logger: Logger,
     allocator: ByteBufferAllocator,
     responseWriter: GRPCServerResponseWriter,
     closeFuture: EventLoopFuture<Void>,
     services: [Substring: CallHandlerProvider],
+    unknownFieldHandling: ServerUnknownFieldHandling,
+    transferTotals: MessageTransferTotals?,
+    deadline: NIODeadline,
+    maximumBufferedRequests: Int?,
+    interceptorRegistry: ServerInterceptorRegistry,
     encoding: ServerMessageEncoding,
-    normalizeHeaders: Bool
+    normalizeHeaders: Bool,
+    includeKnownMethodsInUnimplementedStatus: Bool
   ) -> HTTP2ToRawGRPCStateMachine.StateAndReceiveHeadersAction {
     // Extract and validate the content type. If it's nil we need to close.
     guard let contentType = self.extractContentType(from: headers) else {
@@ -307,12 +315,20 @@ extension HTTP2ToRawGRPCStateMachine.State {
 
     // Parse the path, and create a call handler.
     guard let path = headers.first(name: ":path") else {
-      return self.methodNotImplemented("", contentType: contentType)
+      return self.methodNotImplemented(
+        "",
+        contentType: contentType,
+        knownServices: includeKnownMethodsInUnimplementedStatus ? services : nil
+      )
     }
 
     guard let callPath = CallPath(requestURI: path),
       let service = services[Substring(callPath.service)] else {
-      return self.methodNotImplemented(path, contentType: contentType)
+      return self.methodNotImplemented(
+        path,
+        contentType: contentType,
+        knownServices: includeKnownMethodsInUnimplementedStatus ? services : nil
+      )
     }
 
     // Create a call handler context, i.e. a bunch of 'stuff' we need to create the handler with,
@@ -324,9 +340,16 @@ extension HTTP2ToRawGRPCStateMachine.State {
       eventLoop: eventLoop,
       path: path,
       remoteAddress: remoteAddress,
+      streamID: streamID,
+      connection: connection,
       responseWriter: responseWriter,
       allocator: allocator,
-      closeFuture: closeFuture
+      closeFuture: closeFuture,
+      unknownFieldHandling: unknownFieldHandling.handling(forService: Substring(callPath.service)),
+      transferTotals: transferTotals,
+      deadline: deadline,
+      maximumBufferedRequests: maximumBufferedRequests,
+      interceptorRegistry: interceptorRegistry
     )
 
     // We have a matching service, hopefully we have a provider for the method too.
@@ -348,7 +371,11 @@ extension HTTP2ToRawGRPCStateMachine.State {
         action: .configure(handler)
       )
     } else {
-      return self.methodNotImplemented(path, contentType: contentType)
+      return self.methodNotImplemented(
+        path,
+        contentType: contentType,
+        knownServices: includeKnownMethodsInUnimplementedStatus ? services : nil
+      )
     }
   }
 
@@ -368,12 +395,24 @@ extension HTTP2ToRawGRPCStateMachine.State {
   }
 
   /// The RPC method is not implemented. Close with an appropriate status.
+  ///
+  /// If `knownServices` is not `nil` then the status message includes the known methods which are
+  /// closest to the requested path to aid debugging.
   private func methodNotImplemented(
     _ path: String,
-    contentType: ContentType
+    contentType: ContentType,
+    knownServices: [Substring: CallHandlerProvider]?
   ) -> HTTP2ToRawGRPCStateMachine.StateAndReceiveHeadersAction {
+    var message = "'\(path)' is not implemented"
+    if let services = knownServices {
+      let closest = HTTP2ToRawGRPCStateMachine.closestKnownMethods(to: path, in: services)
+      if !closest.isEmpty {
+        message += "; closest known methods: " + closest.joined(separator: ", ")
+      }
+    }
+
     let trailers = HTTP2ToRawGRPCStateMachine.makeResponseTrailersOnly(
-      for: GRPCStatus(code: .unimplemented, message: "'\(path)' is not implemented"),
+      for: GRPCStatus(code: .unimplemented, message: message),
       contentType: contentType,
       acceptableRequestEncoding: nil,
       userProvidedHeaders: nil,
@@ -522,19 +561,23 @@ extension HTTP2ToRawGRPCStateMachine.State {
     let responseEncoding: String?
 
     switch encoding {
+    case let .enabled(configuration) where !configuration.compressResponses:
+      // Compression is enabled for requests only.
+      writer = LengthPrefixedMessageWriter(compression: .none)
+      responseEncoding = nil
+
     case let .enabled(configuration):
       // Extract the encodings acceptable to the client for response messages.
       let acceptableResponseEncoding = headers[canonicalForm: GRPCHeaderName.acceptEncoding]
 
-      // Select the first algorithm that we support and have enabled. If we don't find one then we
-      // won't compress response messages.
-      let algorithm = acceptableResponseEncoding.lazy.compactMap { value in
-        CompressionAlgorithm(rawValue: value)
-      }.first {
-        configuration.enabledAlgorithms.contains($0)
-      }
+      // Select the algorithm the client most prefers which we support and have enabled. If we
+      // don't find one then we won't compress response messages.
+      let algorithm = configuration.responseAlgorithm(acceptEncoding: acceptableResponseEncoding)
 
-      writer = LengthPrefixedMessageWriter(compression: algorithm)
+      writer = LengthPrefixedMessageWriter(
+        compression: algorithm,
+        compressionThreshold: configuration.responseCompressionThreshold
+      )
       responseEncoding = algorithm?.name
 
     case .disabled:
@@ -859,13 +902,21 @@ extension HTTP2ToRawGRPCStateMachine {
     eventLoop: EventLoop,
     errorDelegate: ServerErrorDelegate?,
     remoteAddress: SocketAddress?,
+    streamID: HTTP2StreamID? = nil,
+    connection: ConnectionContext? = nil,
     logger: Logger,
     allocator: ByteBufferAllocator,
     responseWriter: GRPCServerResponseWriter,
     closeFuture: EventLoopFuture<Void>,
     services: [Substring: CallHandlerProvider],
+    unknownFieldHandling: ServerUnknownFieldHandling = ServerUnknownFieldHandling(),
+    transferTotals: MessageTransferTotals? = nil,
+    deadline: NIODeadline = .distantFuture,
+    maximumBufferedRequests: Int? = nil,
+    interceptorRegistry: ServerInterceptorRegistry = ServerInterceptorRegistry(),
     encoding: ServerMessageEncoding,
-    normalizeHeaders: Bool
+    normalizeHeaders: Bool,
+    includeKnownMethodsInUnimplementedStatus: Bool = false
   ) -> ReceiveHeadersAction {
     return self.withStateAvoidingCoWs { state in
       state.receive(
@@ -873,13 +924,21 @@ extension HTTP2ToRawGRPCStateMachine {
         eventLoop: eventLoop,
         errorDelegate: errorDelegate,
         remoteAddress: remoteAddress,
+        streamID: streamID,
+        connection: connection,
         logger: logger,
         allocator: allocator,
         responseWriter: responseWriter,
         closeFuture: closeFuture,
         services: services,
+        unknownFieldHandling: unknownFieldHandling,
+        transferTotals: transferTotals,
+        deadline: deadline,
+        maximumBufferedRequests: maximumBufferedRequests,
+        interceptorRegistry: interceptorRegistry,
         encoding: encoding,
-        normalizeHeaders: normalizeHeaders
+        normalizeHeaders: normalizeHeaders,
+        includeKnownMethodsInUnimplementedStatus: includeKnownMethodsInUnimplementedStatus
       )
     }
   }
@@ -968,13 +1027,21 @@ extension HTTP2ToRawGRPCStateMachine.State {
     eventLoop: EventLoop,
     errorDelegate: ServerErrorDelegate?,
     remoteAddress: SocketAddress?,
+    streamID: HTTP2StreamID?,
+    connection: ConnectionContext?,
     logger: Logger,
     allocator: ByteBufferAllocator,
     responseWriter: GRPCServerResponseWriter,
     closeFuture: EventLoopFuture<Void>,
     services: [Substring: CallHandlerProvider],
+    unknownFieldHandling: ServerUnknownFieldHandling,
+    transferTotals: MessageTransferTotals?,
+    deadline: NIODeadline,
+    maximumBufferedRequests: Int?,
+    interceptorRegistry: ServerInterceptorRegistry,
     encoding: ServerMessageEncoding,
-    normalizeHeaders: Bool
+    normalizeHeaders: Bool,
+    includeKnownMethodsInUnimplementedStatus: Bool
   ) -> HTTP2ToRawGRPCStateMachine.ReceiveHeadersAction {
     switch self {
     // These are the only states in which we can receive headers. Everything else is invalid.
@@ -985,13 +1052,21 @@ extension HTTP2ToRawGRPCStateMachine.State {
         eventLoop: eventLoop,
         errorDelegate: errorDelegate,
         remoteAddress: remoteAddress,
+        streamID: streamID,
+        connection: connection,
         logger: logger,
         allocator: allocator,
         responseWriter: responseWriter,
         closeFuture: closeFuture,
         services: services,
+        unknownFieldHandling: unknownFieldHandling,
+        transferTotals: transferTotals,
+        deadline: deadline,
+        maximumBufferedRequests: maximumBufferedRequests,
+        interceptorRegistry: interceptorRegistry,
         encoding: encoding,
-        normalizeHeaders: normalizeHeaders
+        normalizeHeaders: normalizeHeaders,
+        includeKnownMethodsInUnimplementedStatus: includeKnownMethodsInUnimplementedStatus
       )
       self = stateAndAction.state
       return stateAndAction.action
@@ -1290,6 +1365,49 @@ extension HTTP2ToRawGRPCStateMachine {
   private static let gRPCStatusOkTrailers: HPACKHeaders = [
     GRPCHeaderName.statusCode: String(describing: GRPCStatus.Code.ok.rawValue),
   ]
+
+  /// Returns up to `limit` paths of known methods, ordered by their edit distance from `path`.
+  /// Services which don't provide their method names are included as "/<service>/*".
+  static func closestKnownMethods(
+    to path: String,
+    in services: [Substring: CallHandlerProvider],
+    limit: Int = 5
+  ) -> [String] {
+    let known = services.flatMap { serviceName, provider -> [String] in
+      if provider.methodNames.isEmpty {
+        return ["/\(serviceName)/*"]
+      } else {
+        return provider.methodNames.map { "/\(serviceName)/\($0)" }
+      }
+    }
+
+    let requested = Array(path.utf8)
+    let ranked = known.map { (path: $0, distance: editDistance(requested, Array($0.utf8))) }
+    return ranked.sorted {
+      ($0.distance, $0.path) < ($1.distance, $1.path)
+    }.prefix(limit).map { $0.path }
+  }
+
+  /// The Levenshtein distance between two byte sequences.
+  private static func editDistance(_ lhs: [UInt8], _ rhs: [UInt8]) -> Int {
+    if lhs.isEmpty || rhs.isEmpty {
+      return max(lhs.count, rhs.count)
+    }
+
+    var previous = Array(0 ... rhs.count)
+    var current = [Int](repeating: 0, count: rhs.count + 1)
+
+    for i in 1 ... lhs.count {
+      current[0] = i
+      for j in 1 ... rhs.count {
+        let substitution = previous[j - 1] + (lhs[i - 1] == rhs[j - 1] ? 0 : 1)
+        current[j] = min(previous[j] + 1, current[j - 1] + 1, substitution)
+      }
+      swap(&previous, &current)
+    }
+
+    return previous[rhs.count]
+  }
 }
 
 private extension HPACKHeaders {
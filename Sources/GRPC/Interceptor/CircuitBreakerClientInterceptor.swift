@@ -0,0 +1,334 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+
+/// Tracks the outcome of recent RPCs for each method and decides whether new RPCs should be
+/// attempted or failed immediately. Used by `CircuitBreakerClientInterceptor`.
+///
+/// Each method (i.e. RPC path) has its own breaker which starts `closed`: RPCs are allowed and
+/// their outcomes recorded. If, within the configured window, at least `minimumRequests` RPCs
+/// have completed and the proportion which failed reaches `failureRateThreshold` then the
+/// breaker trips `open` and RPCs fail immediately with status code 'unavailable'. Once
+/// `openDuration` has passed the breaker becomes `halfOpen` and allows a single RPC through as a
+/// probe: if it succeeds the breaker closes, otherwise it opens again.
+///
+/// Breakers are per-method rather than per-target: a `CircuitBreaker` should be shared by the
+/// interceptors of RPCs made to a single target (typically one per channel).
+///
+/// The circuit breaker is thread safe.
+public final class CircuitBreaker {
+  public struct Configuration {
+    /// The proportion of failed RPCs, in the range `0...1`, at which the breaker trips open.
+    /// Defaults to 0.5.
+    ///
+    /// Values outside of the valid range of each option are clamped to the nearest valid value
+    /// when the `CircuitBreaker` is created.
+    public var failureRateThreshold: Double
+
+    /// The window over which the outcome of RPCs is considered, which must be greater than zero.
+    /// Defaults to 10 seconds.
+    public var window: TimeAmount
+
+    /// The minimum number of RPCs which must have completed within the window before the
+    /// breaker may trip, at least one. Defaults to 10.
+    public var minimumRequests: Int
+
+    /// How long the breaker stays open before allowing a probe RPC, which must not be negative.
+    /// Defaults to 30 seconds.
+    public var openDuration: TimeAmount
+
+    /// Status codes which are counted as failures. Defaults to 'unavailable', 'deadline exceeded'
+    /// and 'internal error'; other codes usually indicate a problem with the request rather than
+    /// the backend.
+    public var failureCodes: Set<GRPCStatus.Code>
+
+    public init(
+      failureRateThreshold: Double = 0.5,
+      window: TimeAmount = .seconds(10),
+      minimumRequests: Int = 10,
+      openDuration: TimeAmount = .seconds(30),
+      failureCodes: Set<GRPCStatus.Code> = [.unavailable, .deadlineExceeded, .internalError]
+    ) {
+      self.failureRateThreshold = failureRateThreshold
+      self.window = window
+      self.minimumRequests = minimumRequests
+      self.openDuration = openDuration
+      self.failureCodes = failureCodes
+    }
+  }
+
+  /// The configuration with each option clamped to its valid range.
+  internal static func clamping(_ configuration: Configuration) -> Configuration {
+    var clamped = configuration
+    clamped.failureRateThreshold = min(max(configuration.failureRateThreshold, 0), 1)
+    clamped.window = max(configuration.window, .nanoseconds(1))
+    clamped.minimumRequests = max(configuration.minimumRequests, 1)
+    clamped.openDuration = max(configuration.openDuration, .nanoseconds(0))
+    return clamped
+  }
+
+  /// The state of a breaker.
+  public enum State: Hashable {
+    /// RPCs are allowed.
+    case closed
+    /// RPCs fail immediately.
+    case open
+    /// A single probe RPC is allowed; its outcome decides whether the breaker closes or opens.
+    case halfOpen
+  }
+
+  private struct Breaker {
+    var state: State = .closed
+    /// The time and outcome (`true` if it failed) of RPCs completed while closed.
+    var outcomes: CircularBuffer<(time: NIODeadline, failed: Bool)> = CircularBuffer()
+    /// When the breaker last opened.
+    var openedAt: NIODeadline = .distantPast
+    /// Whether the probe RPC is in-flight while half open.
+    var probeInFlight = false
+  }
+
+  /// The configuration of the circuit breaker, with each option clamped to its valid range.
+  public let configuration: Configuration
+
+  /// Returns the current time.
+  private let now: () -> NIODeadline
+
+  /// Breakers for each path. Protected by `lock`.
+  private var breakers: [String: Breaker] = [:]
+  private let lock = Lock()
+
+  public convenience init(configuration: Configuration = Configuration()) {
+    self.init(configuration: configuration, now: NIODeadline.now)
+  }
+
+  internal init(configuration: Configuration, now: @escaping () -> NIODeadline) {
+    self.configuration = CircuitBreaker.clamping(configuration)
+    self.now = now
+  }
+
+  /// Returns the state of the breaker for the given path.
+  public func state(forPath path: String) -> State {
+    return self.lock.withLock {
+      guard var breaker = self.breakers[path] else {
+        return .closed
+      }
+      self.updateOpenBreaker(&breaker, now: self.now())
+      self.breakers[path] = breaker
+      return breaker.state
+    }
+  }
+
+  /// Returns whether an RPC to the given path may be attempted. If `true` then the outcome of
+  /// the RPC must later be reported with `record(path:failed:)` or `release(path:)`.
+  internal func acquire(path: String) -> Bool {
+    return self.lock.withLock {
+      var breaker = self.breakers[path] ?? Breaker()
+      defer {
+        self.breakers[path] = breaker
+      }
+
+      self.updateOpenBreaker(&breaker, now: self.now())
+
+      switch breaker.state {
+      case .closed:
+        return true
+      case .open:
+        return false
+      case .halfOpen:
+        if breaker.probeInFlight {
+          return false
+        } else {
+          breaker.probeInFlight = true
+          return true
+        }
+      }
+    }
+  }
+
+  /// Records the outcome of an RPC which was allowed by `acquire(path:)`.
+  internal func record(path: String, failed: Bool) {
+    self.lock.withLockVoid {
+      var breaker = self.breakers[path] ?? Breaker()
+      defer {
+        self.breakers[path] = breaker
+      }
+
+      let now = self.now()
+
+      switch breaker.state {
+      case .closed:
+        breaker.outcomes.append((now, failed))
+        self.dropExpiredOutcomes(&breaker, now: now)
+
+        let failures = breaker.outcomes.lazy.filter { $0.failed }.count
+        let total = breaker.outcomes.count
+        let failureRate = Double(failures) / Double(total)
+
+        if total >= self.configuration.minimumRequests,
+          failureRate >= self.configuration.failureRateThreshold {
+          breaker.state = .open
+          breaker.openedAt = now
+          breaker.outcomes.removeAll()
+        }
+
+      case .halfOpen:
+        breaker.probeInFlight = false
+        if failed {
+          breaker.state = .open
+          breaker.openedAt = now
+        } else {
+          breaker.state = .closed
+        }
+
+      case .open:
+        // RPCs started before the breaker opened may complete while it's open; they don't
+        // affect the state.
+        ()
+      }
+    }
+  }
+
+  /// Releases an RPC allowed by `acquire(path:)` without recording an outcome, for example if
+  /// it was cancelled by the caller.
+  internal func release(path: String) {
+    self.lock.withLockVoid {
+      if var breaker = self.breakers[path], breaker.state == .halfOpen {
+        breaker.probeInFlight = false
+        self.breakers[path] = breaker
+      }
+    }
+  }
+
+  /// Moves an open breaker to half open if it has been open for long enough. Must be called
+  /// with the lock held.
+  private func updateOpenBreaker(_ breaker: inout Breaker, now: NIODeadline) {
+    if breaker.state == .open, now >= breaker.openedAt + self.configuration.openDuration {
+      breaker.state = .halfOpen
+      breaker.probeInFlight = false
+    }
+  }
+
+  /// Drops outcomes which are older than the window. Must be called with the lock held.
+  private func dropExpiredOutcomes(_ breaker: inout Breaker, now: NIODeadline) {
+    while let first = breaker.outcomes.first, now - first.time > self.configuration.window {
+      breaker.outcomes.removeFirst()
+    }
+  }
+}
+
+/// A client interceptor which fails RPCs immediately with status code 'unavailable' while the
+/// `CircuitBreaker` for the method is open, protecting an unhealthy backend from further load
+/// and callers from waiting on RPCs which are likely to fail.
+///
+/// The outcome of each RPC which is attempted is recorded with the breaker. RPCs cancelled by
+/// the caller are not recorded.
+///
+/// A new interceptor must be created for each RPC; the `breaker` should be shared.
+public final class CircuitBreakerClientInterceptor<Request, Response>:
+  ClientInterceptor<Request, Response> {
+  /// The circuit breaker shared between RPCs.
+  public let breaker: CircuitBreaker
+
+  private var state: State = .idle
+
+  private enum State {
+    /// No request parts have been sent.
+    case idle
+    /// The breaker allowed the RPC to the given path.
+    case attempting(path: String)
+    /// The breaker rejected the RPC.
+    case rejected
+    /// The outcome of the RPC has been handled.
+    case finished
+  }
+
+  public init(breaker: CircuitBreaker) {
+    self.breaker = breaker
+  }
+
+  override public func send(
+    _ part: GRPCClientRequestPart<Request>,
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    switch self.state {
+    case .idle:
+      if self.breaker.acquire(path: context.path) {
+        self.state = .attempting(path: context.path)
+        context.send(part, promise: promise)
+      } else {
+        self.state = .rejected
+        context.logger.debug("circuit breaker is open, failing RPC")
+        let status = GRPCStatus(
+          code: .unavailable,
+          message: "Circuit breaker is open for '\(context.path)'"
+        )
+        promise?.fail(status)
+        context.errorCaught(status)
+      }
+
+    case .attempting, .finished:
+      context.send(part, promise: promise)
+
+    case .rejected:
+      promise?.fail(GRPCError.AlreadyComplete())
+    }
+  }
+
+  override public func receive(
+    _ part: GRPCClientResponsePart<Response>,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    if case let .end(status, _) = part {
+      self.finish(failed: self.breaker.configuration.failureCodes.contains(status.code))
+    }
+    context.receive(part)
+  }
+
+  override public func errorCaught(
+    _ error: Error,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    let code: GRPCStatus.Code
+    if let transformable = error as? GRPCStatusTransformable {
+      code = transformable.makeGRPCStatus().code
+    } else {
+      code = .unavailable
+    }
+    self.finish(failed: self.breaker.configuration.failureCodes.contains(code))
+    context.errorCaught(error)
+  }
+
+  override public func cancel(
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    if case let .attempting(path) = self.state {
+      self.state = .finished
+      self.breaker.release(path: path)
+    }
+    context.cancel(promise: promise)
+  }
+
+  private func finish(failed: Bool) {
+    // Only RPCs which were attempted are recorded, and only once.
+    if case let .attempting(path) = self.state {
+      self.state = .finished
+      self.breaker.record(path: path, failed: failed)
+    }
+  }
+}
@@ -54,6 +54,22 @@ public struct ClientInterceptorContext<Request, Response> {
     return self._pipeline.details.options
   }
 
+  /// The deadline of the RPC, or `.distantFuture` if the RPC has no deadline.
+  ///
+  /// The deadline is resolved from `options.timeLimit` when the RPC starts: a timeout is converted
+  /// to a deadline relative to that point in time, as measured by `options.clock`. Time limits
+  /// derived from an inbound RPC, for example with
+  /// `ServerCallContext.propagatingCallOptions(_:metadataKeys:)`, are already the earliest of the
+  /// inherited and explicit deadlines.
+  public var deadline: NIODeadline {
+    return self._pipeline.deadline
+  }
+
+  /// Whether the RPC has a deadline.
+  public var hasDeadline: Bool {
+    return self.deadline != .distantFuture
+  }
+
   /// Construct a `ClientInterceptorContext` for the interceptor at the given index within in
   /// interceptor pipeline.
   @inlinable
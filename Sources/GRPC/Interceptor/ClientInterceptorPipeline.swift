@@ -71,10 +71,37 @@ internal final class ClientInterceptorPipeline<Request, Response> {
   @usableFromInline
   internal let details: CallDetails
 
+  /// The deadline of the RPC, resolved from the time limit in the call options when the pipeline
+  /// was created. This is `.distantFuture` if the RPC has no deadline.
+  @usableFromInline
+  internal let deadline: NIODeadline
+
   /// A task for closing the RPC in case of a timeout.
   @usableFromInline
   internal var _scheduledClose: Scheduled<Void>?
 
+  /// A task for closing the RPC if no response parts are received within the idle timeout.
+  @usableFromInline
+  internal var _scheduledIdleClose: Scheduled<Void>?
+
+  /// A task for closing the RPC if the response headers aren't received within the response
+  /// headers timeout.
+  @usableFromInline
+  internal var _scheduledHeadersClose: Scheduled<Void>?
+
+  /// A task for closing the RPC if a request message isn't written within the write timeout.
+  @usableFromInline
+  internal var _scheduledWriteClose: Scheduled<Void>?
+
+  /// Whether a response part has been received from the transport.
+  @usableFromInline
+  internal var _receivedResponsePart = false
+
+  /// The number of request messages waiting to be written, only tracked if a write timeout
+  /// applies.
+  @usableFromInline
+  internal var _pendingWrites = 0
+
   @usableFromInline
   internal let _errorDelegate: ClientErrorDelegate?
 
@@ -144,6 +171,7 @@ internal final class ClientInterceptorPipeline<Request, Response> {
   ) {
     self.eventLoop = eventLoop
     self.details = details
+    self.deadline = details.options.timeLimit.makeDeadline(using: details.options.clock)
     self.logger = logger
 
     self._errorDelegate = errorDelegate
@@ -167,6 +195,7 @@ internal final class ClientInterceptorPipeline<Request, Response> {
     }
 
     self._setupDeadline()
+    self._setupIdleTimeout()
   }
 
   /// Emit a response part message into the interceptor pipeline.
@@ -213,6 +242,8 @@ internal final class ClientInterceptorPipeline<Request, Response> {
   ) {
     switch index {
     case self._headIndex:
+      self._cancelResponseHeadersTimeout()
+      self._resetIdleTimeout()
       self._invokeReceive(part, onContextAtUncheckedIndex: self._nextInboundIndex(after: index))
 
     case self._tailIndex:
@@ -351,7 +382,13 @@ internal final class ClientInterceptorPipeline<Request, Response> {
   ) {
     switch index {
     case self._headIndex:
-      self._onRequestPart(part, promise)
+      if part.isMessage, self._writeTimeout != nil {
+        self._sendWithWriteTimeout(part, promise: promise)
+      } else if part.isMetadata, self.details.options.responseHeadersTimeout != nil {
+        self._sendWithResponseHeadersTimeout(part, promise: promise)
+      } else {
+        self._onRequestPart(part, promise)
+      }
 
     case self._tailIndex:
       self._invokeSend(
@@ -435,6 +472,12 @@ extension ClientInterceptorPipeline {
     // Cancel the timeout.
     self._scheduledClose?.cancel()
     self._scheduledClose = nil
+    self._scheduledIdleClose?.cancel()
+    self._scheduledIdleClose = nil
+    self._scheduledHeadersClose?.cancel()
+    self._scheduledHeadersClose = nil
+    self._scheduledWriteClose?.cancel()
+    self._scheduledWriteClose = nil
 
     // Cancel the transport.
     self._onCancel(nil)
@@ -447,17 +490,25 @@ extension ClientInterceptorPipeline {
       self.eventLoop.assertInEventLoop()
 
       let timeLimit = self.details.options.timeLimit
-      let deadline = timeLimit.makeDeadline()
 
       // There's no point scheduling this.
-      if deadline == .distantFuture {
+      if self.deadline == .distantFuture {
         return
       }
 
-      self._scheduledClose = self.eventLoop.scheduleTask(deadline: deadline) {
+      let clock = self.details.options.clock
+      self._scheduledClose = clock.scheduleTask(deadline: self.deadline, on: self.eventLoop) {
         // When the error hits the tail we'll call 'close()', this will cancel the transport if
         // necessary.
-        self.errorCaught(GRPCError.RPCTimedOut(timeLimit))
+        let error = GRPCError.RPCTimedOut(timeLimit)
+        self.errorCaught(error)
+
+        // An interceptor may not have forwarded the error (or may be holding on to it). The
+        // deadline must be honoured regardless of the interceptors and the state of the transport
+        // so complete the RPC and cancel the transport directly.
+        if self._isOpen {
+          self._errorCaught(error)
+        }
       }
     }
 
@@ -471,6 +522,172 @@ extension ClientInterceptorPipeline {
   }
 }
 
+// MARK: - Idle Timeout
+
+extension ClientInterceptorPipeline {
+  /// The response idle timeout for the RPC, if one applies.
+  @inlinable
+  internal var _responseIdleTimeout: TimeAmount? {
+    switch self.details.type {
+    case .serverStreaming, .bidirectionalStreaming:
+      return self.details.options.responseIdleTimeout
+    case .unary, .clientStreaming:
+      // The server is not expected to respond until the client has finished sending, so an idle
+      // timeout would be measured against the client rather than the server.
+      return nil
+    }
+  }
+
+  /// Sets up the response idle timeout for the pipeline, if one applies.
+  @inlinable
+  internal func _setupIdleTimeout() {
+    guard self._responseIdleTimeout != nil else {
+      return
+    }
+
+    if self.eventLoop.inEventLoop {
+      self._resetIdleTimeout()
+    } else {
+      self.eventLoop.execute {
+        self._resetIdleTimeout()
+      }
+    }
+  }
+
+  /// Cancels the idle timeout task, if one exists, and schedules a new one.
+  /// - Important: This *must* to be called from the `eventLoop`.
+  @inlinable
+  internal func _resetIdleTimeout() {
+    self.eventLoop.assertInEventLoop()
+
+    guard self._isOpen, let idleTimeout = self._responseIdleTimeout else {
+      return
+    }
+
+    self._scheduledIdleClose?.cancel()
+    self._scheduledIdleClose = self.eventLoop.scheduleTask(in: idleTimeout) {
+      // When the error hits the tail we'll call 'close()', this will cancel the transport if
+      // necessary.
+      self.errorCaught(GRPCError.RPCIdleTimedOut(idleTimeout))
+    }
+  }
+}
+
+// MARK: - Response Headers Timeout
+
+extension ClientInterceptorPipeline {
+  /// Sends the request headers to the transport, failing the RPC if the response headers aren't
+  /// received within the response headers timeout of them being written.
+  /// - Important: This *must* to be called from the `eventLoop`.
+  @inlinable
+  internal func _sendWithResponseHeadersTimeout(
+    _ part: GRPCClientRequestPart<Request>,
+    promise: EventLoopPromise<Void>?
+  ) {
+    let written = self.eventLoop.makePromise(of: Void.self)
+    written.futureResult.whenComplete { result in
+      if case .success = result {
+        self._startResponseHeadersTimeout()
+      }
+      promise?.completeWith(result)
+    }
+
+    self._onRequestPart(part, written)
+  }
+
+  /// Schedules the response headers timeout task.
+  /// - Important: This *must* to be called from the `eventLoop`.
+  @inlinable
+  internal func _startResponseHeadersTimeout() {
+    self.eventLoop.assertInEventLoop()
+
+    // Nothing to do if the RPC has completed or a response part has already been received.
+    guard self._isOpen, !self._receivedResponsePart,
+      let headersTimeout = self.details.options.responseHeadersTimeout else {
+      return
+    }
+
+    self._scheduledHeadersClose = self.eventLoop.scheduleTask(in: headersTimeout) {
+      // When the error hits the tail we'll call 'close()', this will cancel the transport if
+      // necessary.
+      self.errorCaught(GRPCError.RPCResponseHeadersTimedOut(headersTimeout))
+    }
+  }
+
+  /// Cancels the response headers timeout task, if one exists. Called as each response part is
+  /// received.
+  /// - Important: This *must* to be called from the `eventLoop`.
+  @inlinable
+  internal func _cancelResponseHeadersTimeout() {
+    self._receivedResponsePart = true
+    self._scheduledHeadersClose?.cancel()
+    self._scheduledHeadersClose = nil
+  }
+}
+
+// MARK: - Write Timeout
+
+extension ClientInterceptorPipeline {
+  /// The write timeout for the RPC, if one applies.
+  @inlinable
+  internal var _writeTimeout: TimeAmount? {
+    switch self.details.type {
+    case .clientStreaming, .bidirectionalStreaming:
+      return self.details.options.writeTimeout
+    case .unary, .serverStreaming:
+      return nil
+    }
+  }
+
+  /// Sends a request message to the transport, failing the RPC if it isn't written within the write
+  /// timeout.
+  /// - Important: This *must* to be called from the `eventLoop`.
+  @inlinable
+  internal func _sendWithWriteTimeout(
+    _ part: GRPCClientRequestPart<Request>,
+    promise: EventLoopPromise<Void>?
+  ) {
+    // The timeout runs while any messages are waiting to be written: it's only started for the
+    // first pending write and is reset as each write completes.
+    self._pendingWrites += 1
+    if self._pendingWrites == 1 {
+      self._resetWriteTimeout()
+    }
+
+    let written = self.eventLoop.makePromise(of: Void.self)
+    written.futureResult.whenComplete { result in
+      self._pendingWrites -= 1
+      if self._pendingWrites == 0 {
+        self._scheduledWriteClose?.cancel()
+        self._scheduledWriteClose = nil
+      } else if case .success = result {
+        self._resetWriteTimeout()
+      }
+      promise?.completeWith(result)
+    }
+
+    self._onRequestPart(part, written)
+  }
+
+  /// Cancels the write timeout task, if one exists, and schedules a new one.
+  /// - Important: This *must* to be called from the `eventLoop`.
+  @inlinable
+  internal func _resetWriteTimeout() {
+    self.eventLoop.assertInEventLoop()
+
+    guard self._isOpen, let writeTimeout = self._writeTimeout else {
+      return
+    }
+
+    self._scheduledWriteClose?.cancel()
+    self._scheduledWriteClose = self.eventLoop.scheduleTask(in: writeTimeout) {
+      // When the error hits the tail we'll call 'close()', this will cancel the transport if
+      // necessary.
+      self.errorCaught(GRPCError.RPCWriteTimedOut(writeTimeout))
+    }
+  }
+}
+
 extension ClientInterceptorContext {
   @inlinable
   internal func invokeReceive(_ part: GRPCClientResponsePart<Response>) {
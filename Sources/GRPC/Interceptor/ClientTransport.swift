@@ -396,6 +396,8 @@ extension ClientTransport {
     case .propagateError:
       self.forwardErrorToInterceptors(error)
       self.failBufferedWrites(with: error)
+      // There's no 'Channel' to close so we won't see it become inactive; fail the promise now.
+      self.channelPromise?.fail(error)
 
     case .propagateErrorAndClose:
       self.forwardErrorToInterceptors(error)
@@ -917,6 +919,19 @@ extension ClientTransport {
   ) {
     switch part {
     case let .metadata(headers):
+      // The authority is validated here rather than when it's set so that a malformed value
+      // fails the RPC rather than the process.
+      if let authority = self.callDetails.options.authority,
+        !CallOptions.isValidAuthority(authority) {
+        let status = GRPCStatus(
+          code: .invalidArgument,
+          message: "'\(authority)' is not a valid authority"
+        )
+        promise?.fail(status)
+        self.handleError(status)
+        return
+      }
+
       let head = self.makeRequestHead(with: headers)
       channel.write(self.wrapOutboundOut(.head(head)), promise: promise)
 
@@ -975,15 +990,17 @@ extension ClientTransport {
 
   /// Make a `_GRPCRequestHead` with the provided metadata.
   private func makeRequestHead(with metadata: HPACKHeaders) -> _GRPCRequestHead {
-    return _GRPCRequestHead(
+    var head = _GRPCRequestHead(
       method: self.callDetails.options.cacheable ? "GET" : "POST",
       scheme: self.callDetails.scheme,
       path: self.callDetails.path,
       host: self.callDetails.authority,
-      deadline: self.callDetails.options.timeLimit.makeDeadline(),
+      deadline: self.callDetails.options.systemDeadline,
       customMetadata: metadata,
       encoding: self.callDetails.options.messageEncoding
     )
+    head.unsafeRawHeaders = self.callDetails.options.unsafeRawHeaders
+    return head
   }
 }
 
@@ -1016,6 +1033,19 @@ internal struct ConnectionFailure: Error, GRPCStatusTransformable, CustomStringC
   }
 
   func makeGRPCStatus() -> GRPCStatus {
-    return GRPCStatus(code: .unavailable, message: String(describing: self.reason))
+    // Classify certificate verification failures so they can be told apart from other failures.
+    if let verificationFailure = TLSVerificationFailure(self.reason) {
+      return GRPCStatus(
+        code: .unavailable,
+        message: verificationFailure.description,
+        cause: verificationFailure
+      )
+    }
+
+    return GRPCStatus(
+      code: .unavailable,
+      message: String(describing: self.reason),
+      cause: self.reason
+    )
   }
 }
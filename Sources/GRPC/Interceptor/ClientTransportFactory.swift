@@ -41,14 +41,29 @@ internal struct ClientTransportFactory<Request, Response> {
   ///   - multiplexer: The multiplexer used to create an HTTP/2 stream for the RPC.
   ///   - host: The value of the ":authority" pseudo header.
   ///   - scheme: The value of the ":scheme" pseudo header.
+  ///   - messageObserver: Called with each serialized message sent or received, if not `nil`.
+  ///   - compressionStatisticsObserver: Called with the compression statistics of each RPC, if
+  ///       not `nil`.
   ///   - errorDelegate: A client error delegate.
+  ///   - streamGate: Limits the number of concurrent streams, if not `nil`.
+  ///   - wireProtocol: The protocol used to make the RPC.
+  ///   - peerMaxHeaderListSize: Returns the maximum header list size advertised by the peer, if
+  ///       any. Called on the `EventLoop` of the multiplexer.
+  ///   - stopWaitingForMultiplexer: Called with an error if the RPC is cancelled or closed before
+  ///       it has a stream, if not `nil`. The RPC no longer needs the multiplexer.
   /// - Returns: A factory for making and configuring HTTP/2 based transport.
   internal static func http2<Request: SwiftProtobuf.Message, Response: SwiftProtobuf.Message>(
     multiplexer: EventLoopFuture<HTTP2StreamMultiplexer>,
     authority: String,
     scheme: String,
     maximumReceiveMessageLength: Int,
-    errorDelegate: ClientErrorDelegate?
+    messageObserver: ((ObservedMessage) -> Void)? = nil,
+    compressionStatisticsObserver: ((CompressionStatistics) -> Void)? = nil,
+    errorDelegate: ClientErrorDelegate?,
+    streamGate: StreamGate? = nil,
+    wireProtocol: ClientWireProtocol = .grpc,
+    peerMaxHeaderListSize: @escaping () -> Int? = { nil },
+    stopWaitingForMultiplexer: ((Error) -> Void)? = nil
   ) -> ClientTransportFactory<Request, Response> {
     let http2 = HTTP2ClientTransportFactory<Request, Response>(
       multiplexer: multiplexer,
@@ -57,7 +72,13 @@ internal struct ClientTransportFactory<Request, Response> {
       serializer: ProtobufSerializer(),
       deserializer: ProtobufDeserializer(),
       maximumReceiveMessageLength: maximumReceiveMessageLength,
-      errorDelegate: errorDelegate
+      messageObserver: messageObserver,
+      compressionStatisticsObserver: compressionStatisticsObserver,
+      errorDelegate: errorDelegate,
+      streamGate: streamGate,
+      wireProtocol: wireProtocol,
+      peerMaxHeaderListSize: peerMaxHeaderListSize,
+      stopWaitingForMultiplexer: stopWaitingForMultiplexer
     )
     return .init(http2)
   }
@@ -67,14 +88,29 @@ internal struct ClientTransportFactory<Request, Response> {
   ///   - multiplexer: The multiplexer used to create an HTTP/2 stream for the RPC.
   ///   - host: The value of the ":authority" pseudo header.
   ///   - scheme: The value of the ":scheme" pseudo header.
+  ///   - messageObserver: Called with each serialized message sent or received, if not `nil`.
+  ///   - compressionStatisticsObserver: Called with the compression statistics of each RPC, if
+  ///       not `nil`.
   ///   - errorDelegate: A client error delegate.
+  ///   - streamGate: Limits the number of concurrent streams, if not `nil`.
+  ///   - wireProtocol: The protocol used to make the RPC.
+  ///   - peerMaxHeaderListSize: Returns the maximum header list size advertised by the peer, if
+  ///       any. Called on the `EventLoop` of the multiplexer.
+  ///   - stopWaitingForMultiplexer: Called with an error if the RPC is cancelled or closed before
+  ///       it has a stream, if not `nil`. The RPC no longer needs the multiplexer.
   /// - Returns: A factory for making and configuring HTTP/2 based transport.
   internal static func http2<Request: GRPCPayload, Response: GRPCPayload>(
     multiplexer: EventLoopFuture<HTTP2StreamMultiplexer>,
     authority: String,
     scheme: String,
     maximumReceiveMessageLength: Int,
-    errorDelegate: ClientErrorDelegate?
+    messageObserver: ((ObservedMessage) -> Void)? = nil,
+    compressionStatisticsObserver: ((CompressionStatistics) -> Void)? = nil,
+    errorDelegate: ClientErrorDelegate?,
+    streamGate: StreamGate? = nil,
+    wireProtocol: ClientWireProtocol = .grpc,
+    peerMaxHeaderListSize: @escaping () -> Int? = { nil },
+    stopWaitingForMultiplexer: ((Error) -> Void)? = nil
   ) -> ClientTransportFactory<Request, Response> {
     let http2 = HTTP2ClientTransportFactory<Request, Response>(
       multiplexer: multiplexer,
@@ -83,7 +119,13 @@ internal struct ClientTransportFactory<Request, Response> {
       serializer: AnySerializer(wrapping: GRPCPayloadSerializer()),
       deserializer: AnyDeserializer(wrapping: GRPCPayloadDeserializer()),
       maximumReceiveMessageLength: maximumReceiveMessageLength,
-      errorDelegate: errorDelegate
+      messageObserver: messageObserver,
+      compressionStatisticsObserver: compressionStatisticsObserver,
+      errorDelegate: errorDelegate,
+      streamGate: streamGate,
+      wireProtocol: wireProtocol,
+      peerMaxHeaderListSize: peerMaxHeaderListSize,
+      stopWaitingForMultiplexer: stopWaitingForMultiplexer
     )
     return .init(http2)
   }
@@ -189,6 +231,24 @@ private struct HTTP2ClientTransportFactory<Request, Response> {
   /// Maximum allowed length of a received message.
   private let maximumReceiveMessageLength: Int
 
+  /// Called with each serialized message sent or received, if set.
+  private let messageObserver: ((ObservedMessage) -> Void)?
+
+  /// Called with the compression statistics of each RPC, if set.
+  private let compressionStatisticsObserver: ((CompressionStatistics) -> Void)?
+
+  /// Limits the number of concurrent streams, if set.
+  private let streamGate: StreamGate?
+
+  /// The protocol used to make RPCs.
+  private let wireProtocol: ClientWireProtocol
+
+  /// Returns the maximum header list size advertised by the peer, if any.
+  private let peerMaxHeaderListSize: () -> Int?
+
+  /// Called if the RPC is abandoned before it has a stream, if set.
+  private let stopWaitingForMultiplexer: ((Error) -> Void)?
+
   fileprivate init<Serializer: MessageSerializer, Deserializer: MessageDeserializer>(
     multiplexer: EventLoopFuture<HTTP2StreamMultiplexer>,
     scheme: String,
@@ -196,7 +256,13 @@ private struct HTTP2ClientTransportFactory<Request, Response> {
     serializer: Serializer,
     deserializer: Deserializer,
     maximumReceiveMessageLength: Int,
-    errorDelegate: ClientErrorDelegate?
+    messageObserver: ((ObservedMessage) -> Void)?,
+    compressionStatisticsObserver: ((CompressionStatistics) -> Void)?,
+    errorDelegate: ClientErrorDelegate?,
+    streamGate: StreamGate?,
+    wireProtocol: ClientWireProtocol,
+    peerMaxHeaderListSize: @escaping () -> Int?,
+    stopWaitingForMultiplexer: ((Error) -> Void)?
   ) where Serializer.Input == Request, Deserializer.Output == Response {
     self.multiplexer = multiplexer
     self.scheme = scheme
@@ -204,7 +270,13 @@ private struct HTTP2ClientTransportFactory<Request, Response> {
     self.serializer = AnySerializer(wrapping: serializer)
     self.deserializer = AnyDeserializer(wrapping: deserializer)
     self.maximumReceiveMessageLength = maximumReceiveMessageLength
+    self.messageObserver = messageObserver
+    self.compressionStatisticsObserver = compressionStatisticsObserver
     self.errorDelegate = errorDelegate
+    self.streamGate = streamGate
+    self.wireProtocol = wireProtocol
+    self.peerMaxHeaderListSize = peerMaxHeaderListSize
+    self.stopWaitingForMultiplexer = stopWaitingForMultiplexer
   }
 
   fileprivate func makeTransport(
@@ -230,35 +302,102 @@ private struct HTTP2ClientTransportFactory<Request, Response> {
 
   fileprivate func configure<Request, Response>(_ transport: ClientTransport<Request, Response>) {
     transport.configure { _ in
-      self.multiplexer.flatMap { multiplexer in
-        let streamPromise = self.multiplexer.eventLoop.makePromise(of: Channel.self)
-
-        multiplexer.createStreamChannel(promise: streamPromise) { streamChannel in
-          // This initializer will always occur on the appropriate event loop, sync operations are
-          // fine here.
-          let syncOperations = streamChannel.pipeline.syncOperations
-
-          do {
-            let clientHandler = GRPCClientChannelHandler(
-              callType: transport.callDetails.type,
-              maximumReceiveMessageLength: self.maximumReceiveMessageLength,
-              logger: transport.logger
-            )
-            try syncOperations.addHandler(clientHandler)
-            try syncOperations.addHandler(transport)
-          } catch {
-            return streamChannel.eventLoop.makeFailedFuture(error)
-          }
+      // The channel future fails if the RPC is cancelled or closed before it has a stream, in
+      // which case it shouldn't hold on to its place waiting for the connection or in the stream
+      // gate's queue. We're on the call event loop here, as 'getChannel()' requires.
+      let abandoned: EventLoopFuture<Void>?
+      if self.streamGate != nil || self.stopWaitingForMultiplexer != nil {
+        abandoned = transport.getChannel().map { _ in () }
+      } else {
+        abandoned = nil
+      }
 
-          return streamChannel.eventLoop.makeSucceededVoidFuture()
+      if let stopWaiting = self.stopWaitingForMultiplexer {
+        abandoned?.whenFailure(stopWaiting)
+      }
+
+      return self.multiplexer.flatMap { multiplexer in
+        guard let gate = self.streamGate else {
+          return self.createStream(on: multiplexer, for: transport)
         }
 
+        let options = transport.callDetails.options
+        let permit = gate.acquire(
+          on: self.multiplexer.eventLoop,
+          timeLimit: options.timeLimit,
+          deadline: options.timeLimit.makeDeadline(using: options.clock),
+          clock: options.clock,
+          priority: options.priority,
+          abandoned: abandoned
+        )
+
+        return permit.flatMap {
+          let stream = self.createStream(on: multiplexer, for: transport)
+          // Hold the permit until the stream closes. If the stream couldn't be created then there's
+          // no stream to wait for.
+          stream.whenComplete { result in
+            switch result {
+            case let .success(streamChannel):
+              streamChannel.closeFuture.whenComplete { _ in
+                gate.release()
+              }
+            case .failure:
+              gate.release()
+            }
+          }
+          return stream
+        }
+      }.map { _ in
         // We don't need the stream, but we do need to know it was correctly configured.
-        return streamPromise.futureResult.map { _ in }
       }
     }
   }
 
+  private func createStream<Request, Response>(
+    on multiplexer: HTTP2StreamMultiplexer,
+    for transport: ClientTransport<Request, Response>
+  ) -> EventLoopFuture<Channel> {
+    let streamPromise = self.multiplexer.eventLoop.makePromise(of: Channel.self)
+
+    multiplexer.createStreamChannel(promise: streamPromise) { streamChannel in
+      // This initializer will always occur on the appropriate event loop, sync operations are
+      // fine here.
+      let syncOperations = streamChannel.pipeline.syncOperations
+
+      do {
+        switch self.wireProtocol.wrapped {
+        case .grpc:
+          let clientHandler = GRPCClientChannelHandler(
+            callType: transport.callDetails.type,
+            maximumReceiveMessageLength: self.maximumReceiveMessageLength,
+            messageObserver: self.messageObserver,
+            compressionStatisticsObserver: self.compressionStatisticsObserver,
+            maximumHeaderListSize: self.peerMaxHeaderListSize(),
+            logger: transport.logger
+          )
+          try syncOperations.addHandler(clientHandler)
+
+        case .connect:
+          let clientHandler = ConnectUnaryClientChannelHandler(
+            callType: transport.callDetails.type,
+            timeLimit: transport.callDetails.options.timeLimit,
+            clock: transport.callDetails.options.clock,
+            maximumReceiveMessageLength: self.maximumReceiveMessageLength,
+            logger: transport.logger
+          )
+          try syncOperations.addHandler(clientHandler)
+        }
+        try syncOperations.addHandler(transport)
+      } catch {
+        return streamChannel.eventLoop.makeFailedFuture(error)
+      }
+
+      return streamChannel.eventLoop.makeSucceededVoidFuture()
+    }
+
+    return streamPromise.futureResult
+  }
+
   private func makeCallDetails(
     type: GRPCCallType,
     path: String,
@@ -267,7 +406,7 @@ private struct HTTP2ClientTransportFactory<Request, Response> {
     return .init(
       type: type,
       path: path,
-      authority: self.authority,
+      authority: options.authority ?? self.authority,
       scheme: self.scheme,
       options: options
     )
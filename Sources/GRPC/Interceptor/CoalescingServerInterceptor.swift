@@ -0,0 +1,316 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOConcurrencyHelpers
+import NIOHPACK
+import SwiftProtobuf
+
+/// Tracks unary RPCs which are in-flight so that identical concurrent requests may be coalesced
+/// by a `CoalescingServerInterceptor`.
+///
+/// A single coalescer should be shared by all `CoalescingServerInterceptor`s whose RPCs may be
+/// coalesced with one another. Requests are only coalesced if they have the same path and the
+/// same key (typically the serialized request message).
+///
+/// The coalescer is thread safe.
+public final class UnaryRequestCoalescer {
+  internal struct Key: Hashable {
+    var path: String
+    var request: Data
+  }
+
+  /// The outcome of an in-flight RPC, as seen by a waiter.
+  internal enum Outcome {
+    /// The leader completed with the given result, which should be shared with the waiter.
+    case completed(Any)
+    /// The leader was terminated without a result to share: the waiter is now the leader and is
+    /// responsible for running the handler.
+    case promoted
+  }
+
+  /// Waiters for each in-flight RPC. The presence of a key indicates that a handler is already
+  /// running for that request. Protected by `lock`.
+  private var inFlight: [Key: [(Outcome) -> Void]] = [:]
+  private let lock = Lock()
+
+  public init() {}
+
+  /// The number of distinct in-flight RPCs.
+  internal var inFlightCount: Int {
+    return self.lock.withLock { self.inFlight.count }
+  }
+
+  /// Join the RPC with the given key. Returns `true` if the caller is the first to join and is
+  /// therefore responsible for running the handler and calling `complete(_:with:)` or
+  /// `abandon(_:)`. Otherwise `onComplete` is registered and will be called with the outcome
+  /// once it is known.
+  internal func join(_ key: Key, onComplete: @escaping (Outcome) -> Void) -> Bool {
+    return self.lock.withLock {
+      if self.inFlight[key] == nil {
+        self.inFlight[key] = []
+        return true
+      } else {
+        self.inFlight[key]!.append(onComplete)
+        return false
+      }
+    }
+  }
+
+  /// Completes the RPC with the given key, notifying any waiters. Subsequent requests with the
+  /// same key will not be coalesced with this RPC.
+  internal func complete(_ key: Key, with result: Any) {
+    let waiters = self.lock.withLock {
+      self.inFlight.removeValue(forKey: key) ?? []
+    }
+
+    for waiter in waiters {
+      waiter(.completed(result))
+    }
+  }
+
+  /// Gives up leadership of the RPC with the given key without a result, e.g. because the leader
+  /// was cancelled. The longest waiting waiter, if there is one, is promoted to be the leader.
+  internal func abandon(_ key: Key) {
+    let next: ((Outcome) -> Void)? = self.lock.withLock {
+      guard var waiters = self.inFlight[key], !waiters.isEmpty else {
+        self.inFlight.removeValue(forKey: key)
+        return nil
+      }
+
+      let next = waiters.removeFirst()
+      self.inFlight[key] = waiters
+      return next
+    }
+
+    next?(.promoted)
+  }
+}
+
+/// A server interceptor which coalesces identical concurrent unary RPCs so that the service
+/// provider is only invoked once and all callers share the response.
+///
+/// The first RPC for a given path and request (the 'leader') is passed through to the service
+/// provider as normal. Identical RPCs received while the leader is in-flight are not passed to
+/// the service provider; instead they receive a copy of the leader's response metadata, message,
+/// status and trailers. If the service provider fails then every caller receives the same
+/// status.
+///
+/// Each caller's deadline is enforced independently: a caller whose deadline expires (or whose
+/// RPC is cancelled) before the leader completes is terminated without affecting the other
+/// callers. This includes the leader: only statuses produced by the service provider are shared.
+/// If the leader is terminated by the server instead, e.g. because it was cancelled or its
+/// deadline passed, then the longest waiting caller becomes the leader and its request is passed
+/// to the service provider.
+///
+/// This is only suitable for idempotent RPCs whose response does not depend on request metadata.
+/// Streaming RPCs are passed through without coalescing.
+///
+/// A new interceptor must be created for each RPC; the `coalescer` should be shared.
+public final class CoalescingServerInterceptor<Request, Response>:
+  ServerInterceptor<Request, Response> {
+  private let coalescer: UnaryRequestCoalescer
+  private let makeKey: (Request) -> Data?
+  private var state: State = .idle
+
+  private enum State {
+    /// No request parts have been received.
+    case idle
+    /// Request metadata was received and is being held until the request message arrives.
+    case bufferingMetadata(HPACKHeaders)
+    /// The RPC is not being coalesced: all parts are forwarded.
+    case passthrough
+    /// This RPC is the leader for the key and is recording its response.
+    case leading(UnaryRequestCoalescer.Key, CoalescedResponse)
+    /// This RPC is waiting for the result of the leader.
+    case following(Follower)
+    /// A response has been sent for this RPC.
+    case finished
+  }
+
+  /// The request parts of an RPC waiting for the leader, held in case it is promoted.
+  private struct Follower {
+    var key: UnaryRequestCoalescer.Key
+    var headers: HPACKHeaders
+    var request: Request
+    var receivedEnd = false
+  }
+
+  /// The response parts sent by the leader.
+  private struct CoalescedResponse {
+    var headers: HPACKHeaders?
+    var message: (Response, MessageMetadata)?
+    var status: GRPCStatus = .processingError
+    var trailers: HPACKHeaders = [:]
+  }
+
+  /// Create a coalescing interceptor.
+  ///
+  /// - Parameters:
+  ///   - coalescer: The coalescer, shared between RPCs, used to track in-flight requests.
+  ///   - key: A closure returning the bytes which identify the request, typically the serialized
+  ///       request. Requests are not coalesced if this returns `nil`.
+  public init(coalescer: UnaryRequestCoalescer, key: @escaping (Request) -> Data?) {
+    self.coalescer = coalescer
+    self.makeKey = key
+  }
+
+  override public func receive(
+    _ part: GRPCServerRequestPart<Request>,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    switch (self.state, part) {
+    case let (.idle, .metadata(headers)) where context.type == .unary:
+      self.state = .bufferingMetadata(headers)
+
+    case let (.bufferingMetadata(headers), .message(request)):
+      guard let requestKey = self.makeKey(request) else {
+        self.state = .passthrough
+        context.receive(.metadata(headers))
+        context.receive(part)
+        return
+      }
+
+      let key = UnaryRequestCoalescer.Key(path: context.path, request: requestKey)
+      let isLeader = self.coalescer.join(key) { outcome in
+        context.eventLoop.execute {
+          switch outcome {
+          case let .completed(result):
+            self.replay(result, context: context)
+          case .promoted:
+            self.promote(key, context: context)
+          }
+        }
+      }
+
+      if isLeader {
+        self.state = .leading(key, CoalescedResponse())
+        context.receive(.metadata(headers))
+        context.receive(part)
+      } else {
+        context.logger.debug("coalescing RPC with identical in-flight request")
+        self.state = .following(Follower(key: key, headers: headers, request: request))
+      }
+
+    case (.following(var follower), .end):
+      // Held in case this RPC is promoted to be the leader.
+      follower.receivedEnd = true
+      self.state = .following(follower)
+
+    case (.following, _), (.finished, _):
+      // The service provider isn't involved in this RPC: drop the request parts.
+      ()
+
+    case (.idle, _):
+      self.state = .passthrough
+      context.receive(part)
+
+    case (.bufferingMetadata, _), (.passthrough, _), (.leading, _):
+      context.receive(part)
+    }
+  }
+
+  override public func send(
+    _ part: GRPCServerResponsePart<Response>,
+    promise: EventLoopPromise<Void>?,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    switch self.state {
+    case .leading(let key, var response):
+      switch part {
+      case let .metadata(headers):
+        response.headers = headers
+        self.state = .leading(key, response)
+      case let .message(message, metadata):
+        response.message = (message, metadata)
+        self.state = .leading(key, response)
+      case let .end(status, trailers):
+        self.state = .finished
+        if context.isTerminatedByServer {
+          // The status wasn't produced by the service provider (e.g. the leader was cancelled or
+          // its deadline passed) so isn't shared: another caller must lead instead.
+          self.coalescer.abandon(key)
+        } else {
+          response.status = status
+          response.trailers = trailers
+          self.coalescer.complete(key, with: response)
+        }
+      }
+
+    case .following:
+      // The RPC was terminated before the leader completed, e.g. because its deadline expired.
+      if part.isEnd {
+        self.state = .finished
+      }
+
+    case .idle, .bufferingMetadata, .passthrough, .finished:
+      ()
+    }
+
+    context.send(part, promise: promise)
+  }
+
+  /// Passes this RPC's request to the service provider after the leader was terminated.
+  private func promote(
+    _ key: UnaryRequestCoalescer.Key,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    guard case let .following(follower) = self.state else {
+      // This RPC has already finished so can't lead: pass leadership on.
+      self.coalescer.abandon(key)
+      return
+    }
+
+    context.logger.debug("leader of coalesced RPCs was terminated, running handler")
+    self.state = .leading(key, CoalescedResponse())
+    context.receive(.metadata(follower.headers))
+    context.receive(.message(follower.request))
+    if follower.receivedEnd {
+      context.receive(.end)
+    }
+  }
+
+  /// Sends the leader's response on this RPC.
+  private func replay(_ result: Any, context: ServerInterceptorContext<Request, Response>) {
+    guard case .following = self.state else {
+      return
+    }
+
+    self.state = .finished
+
+    guard let response = result as? CoalescedResponse else {
+      context.send(.end(.processingError, [:]), promise: nil)
+      return
+    }
+
+    if let headers = response.headers {
+      context.send(.metadata(headers), promise: nil)
+    }
+    if case let (message, metadata)? = response.message {
+      context.send(.message(message, metadata), promise: nil)
+    }
+    context.send(.end(response.status, response.trailers), promise: nil)
+  }
+}
+
+extension CoalescingServerInterceptor where Request: SwiftProtobuf.Message {
+  /// Create a coalescing interceptor which identifies requests by their serialized bytes.
+  ///
+  /// - Parameter coalescer: The coalescer, shared between RPCs, used to track in-flight requests.
+  public convenience init(coalescer: UnaryRequestCoalescer) {
+    self.init(coalescer: coalescer, key: { try? $0.serializedData() })
+  }
+}
@@ -0,0 +1,83 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Dispatch
+import NIO
+
+extension ClientInterceptorContext {
+  /// Runs `body` on the given queue and returns a future on the `eventLoop` which is completed
+  /// with its result.
+  ///
+  /// Interceptors are called on the `EventLoop` of the RPC, which is shared with other RPCs.
+  /// Blocking or CPU intensive work should be offloaded so that it doesn't stall them. Callbacks
+  /// on the returned future run on the `eventLoop`, so parts may be forwarded from them:
+  ///
+  /// ```
+  /// override func send(
+  ///   _ part: GRPCClientRequestPart<Request>,
+  ///   promise: EventLoopPromise<Void>?,
+  ///   context: ClientInterceptorContext<Request, Response>
+  /// ) {
+  ///   context.offload(to: self.signingQueue) {
+  ///     try self.sign(part)
+  ///   }.whenComplete { result in
+  ///     switch result {
+  ///     case let .success(signed):
+  ///       context.send(signed, promise: promise)
+  ///     case let .failure(error):
+  ///       context.errorCaught(error)
+  ///     }
+  ///   }
+  /// }
+  /// ```
+  ///
+  /// Parts must still be forwarded in the order they were received. Offloading every part to the
+  /// same serial queue preserves their order.
+  ///
+  /// - Parameters:
+  ///   - queue: The queue to run `body` on.
+  ///   - body: The work to perform.
+  /// - Returns: A future completed on `eventLoop` with the value returned or error thrown by
+  ///   `body`.
+  public func offload<Value>(
+    to queue: DispatchQueue,
+    _ body: @escaping () throws -> Value
+  ) -> EventLoopFuture<Value> {
+    return self.eventLoop.offload(to: queue, body)
+  }
+}
+
+extension ServerInterceptorContext {
+  /// Runs `body` on the given queue and returns a future on the `eventLoop` which is completed
+  /// with its result.
+  ///
+  /// Interceptors are called on the `EventLoop` of the RPC, which is shared with other RPCs and
+  /// connections. Blocking or CPU intensive work should be offloaded so that it doesn't stall
+  /// them. Callbacks on the returned future run on the `eventLoop`, so parts may be forwarded
+  /// from them. Parts must still be forwarded in the order they were received. Offloading every
+  /// part to the same serial queue preserves their order.
+  ///
+  /// - Parameters:
+  ///   - queue: The queue to run `body` on.
+  ///   - body: The work to perform.
+  /// - Returns: A future completed on `eventLoop` with the value returned or error thrown by
+  ///   `body`.
+  public func offload<Value>(
+    to queue: DispatchQueue,
+    _ body: @escaping () throws -> Value
+  ) -> EventLoopFuture<Value> {
+    return self.eventLoop.offload(to: queue, body)
+  }
+}
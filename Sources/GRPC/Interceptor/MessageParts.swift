@@ -76,6 +76,28 @@ public struct MessageMetadata: Equatable {
   }
 }
 
+extension GRPCClientRequestPart {
+  @inlinable
+  internal var isMetadata: Bool {
+    switch self {
+    case .metadata:
+      return true
+    case .message, .end:
+      return false
+    }
+  }
+
+  @inlinable
+  internal var isMessage: Bool {
+    switch self {
+    case .message:
+      return true
+    case .metadata, .end:
+      return false
+    }
+  }
+}
+
 extension GRPCClientResponsePart {
   @inlinable
   internal var isEnd: Bool {
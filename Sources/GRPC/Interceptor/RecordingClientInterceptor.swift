@@ -0,0 +1,135 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOFoundationCompat
+
+/// A client interceptor which records the messages, response metadata and status of an RPC with
+/// a `RPCRecorder`. Interceptors should be created with `RPCRecorder.makeInterceptor()`.
+///
+/// Request and response parts are passed through unchanged. The recording is made when the RPC
+/// ends; RPCs cancelled by the caller are not recorded.
+internal final class RecordingClientInterceptor<Request, Response>:
+  ClientInterceptor<Request, Response> {
+  private let recorder: RPCRecorder
+  private let requestSerializer: AnySerializer<Request>
+  private let responseSerializer: AnySerializer<Response>
+  private let allocator = ByteBufferAllocator()
+
+  /// The recording in progress, `nil` until the first request part is sent and after the RPC has
+  /// ended.
+  private var recording: RPCRecording?
+
+  internal init<RequestSerializer: MessageSerializer, ResponseSerializer: MessageSerializer>(
+    recorder: RPCRecorder,
+    requestSerializer: RequestSerializer,
+    responseSerializer: ResponseSerializer
+  ) where RequestSerializer.Input == Request, ResponseSerializer.Input == Response {
+    self.recorder = recorder
+    self.requestSerializer = AnySerializer(wrapping: requestSerializer)
+    self.responseSerializer = AnySerializer(wrapping: responseSerializer)
+  }
+
+  override func send(
+    _ part: GRPCClientRequestPart<Request>,
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case .metadata:
+      self.recording = RPCRecording(path: context.path)
+
+    case let .message(request, _):
+      if let data = self.serialize(request, with: self.requestSerializer, context: context) {
+        self.recording?.requests.append(data)
+      }
+
+    case .end:
+      ()
+    }
+
+    context.send(part, promise: promise)
+  }
+
+  override func receive(
+    _ part: GRPCClientResponsePart<Response>,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case let .metadata(metadata):
+      self.recording?.initialMetadata = RPCRecording.Header.headers(from: metadata)
+
+    case let .message(response):
+      if let data = self.serialize(response, with: self.responseSerializer, context: context) {
+        self.recording?.responses.append(data)
+      }
+
+    case let .end(status, trailers):
+      self.recording?.trailingMetadata = RPCRecording.Header.headers(from: trailers)
+      self.finish(status: status)
+    }
+
+    context.receive(part)
+  }
+
+  override func errorCaught(
+    _ error: Error,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    let status: GRPCStatus
+    if let transformable = error as? GRPCStatusTransformable {
+      status = transformable.makeGRPCStatus()
+    } else {
+      status = .processingError
+    }
+    self.finish(status: status)
+    context.errorCaught(error)
+  }
+
+  override func cancel(
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    self.recording = nil
+    context.cancel(promise: promise)
+  }
+
+  private func serialize<Message>(
+    _ message: Message,
+    with serializer: AnySerializer<Message>,
+    context: ClientInterceptorContext<Request, Response>
+  ) -> Data? {
+    do {
+      var buffer = try serializer.serialize(message, allocator: self.allocator)
+      return buffer.readData(length: buffer.readableBytes)
+    } catch {
+      context.logger.error("unable to serialize message for recording", metadata: [
+        MetadataKey.error: "\(error)",
+      ])
+      return nil
+    }
+  }
+
+  private func finish(status: GRPCStatus) {
+    guard var recording = self.recording else {
+      return
+    }
+
+    self.recording = nil
+    recording.status = RPCRecording.Status(status)
+    self.recorder.append(recording)
+  }
+}
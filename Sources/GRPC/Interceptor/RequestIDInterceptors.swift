@@ -0,0 +1,167 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import struct Foundation.UUID
+import NIO
+import NIOHPACK
+
+/// A client interceptor which ensures every RPC carries a request ID in its request metadata.
+///
+/// If the request metadata already contains a value for the header (for example, one set via
+/// `CallOptions.customMetadata` or `CallOptions.requestIDHeader`) then it is left untouched,
+/// otherwise a new ID is generated and added.
+///
+/// The interceptor holds no per-RPC state and may be shared between RPCs.
+public final class RequestIDClientInterceptor<Request, Response>:
+  ClientInterceptor<Request, Response> {
+  /// The name of the metadata key used to propagate the request ID.
+  public let headerName: String
+
+  /// Generates a request ID.
+  private let generateID: () -> String
+
+  /// Creates a new request ID interceptor.
+  ///
+  /// - Parameters:
+  ///   - headerName: The name of the metadata key to store the request ID in. Defaults to
+  ///       "x-request-id".
+  ///   - generateID: Generates a request ID. Defaults to generating a UUID.
+  public init(
+    headerName: String = "x-request-id",
+    generateID: @escaping () -> String = { UUID().uuidString }
+  ) {
+    self.headerName = headerName.lowercased()
+    self.generateID = generateID
+  }
+
+  override public func send(
+    _ part: GRPCClientRequestPart<Request>,
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case var .metadata(headers):
+      if !headers.contains(name: self.headerName) {
+        let requestID = self.generateID()
+        headers.add(name: self.headerName, value: requestID)
+        context.logger.trace("added request ID to request metadata", metadata: [
+          MetadataKey.requestID: "\(requestID)",
+        ])
+      }
+      context.send(.metadata(headers), promise: promise)
+
+    case .message, .end:
+      context.send(part, promise: promise)
+    }
+  }
+}
+
+/// A server interceptor which reads the request ID sent by the client, generating one if the
+/// client didn't send one, and echoes it back to the client in the response trailers.
+///
+/// The request ID is stored in the RPC's `UserInfo` and is available to subsequent interceptors
+/// and to the service provider via `context.userInfo.requestID`. Since the logger of an RPC is
+/// fixed when the RPC is created, service providers wishing to include the request ID in their
+/// logs should attach it to a copy of the logger:
+///
+/// ```
+/// var logger = context.logger
+/// logger[metadataKey: "request_id"] = context.userInfo.requestID.map { "\($0)" }
+/// ```
+///
+/// The interceptor holds no per-RPC state and may be shared between RPCs.
+public final class RequestIDServerInterceptor<Request, Response>:
+  ServerInterceptor<Request, Response> {
+  /// The name of the metadata key used to propagate the request ID.
+  public let headerName: String
+
+  /// Generates a request ID.
+  private let generateID: () -> String
+
+  /// Creates a new request ID interceptor.
+  ///
+  /// - Parameters:
+  ///   - headerName: The name of the metadata key to read the request ID from and to echo it back
+  ///       in. Defaults to "x-request-id".
+  ///   - generateID: Generates a request ID if the client didn't send one. Defaults to
+  ///       generating a UUID.
+  public init(
+    headerName: String = "x-request-id",
+    generateID: @escaping () -> String = { UUID().uuidString }
+  ) {
+    self.headerName = headerName.lowercased()
+    self.generateID = generateID
+  }
+
+  override public func receive(
+    _ part: GRPCServerRequestPart<Request>,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case var .metadata(headers):
+      let requestID: String
+      if let existing = headers.first(name: self.headerName) {
+        requestID = existing
+      } else {
+        // Add the generated ID to the headers so that the service provider sees the same ID.
+        requestID = self.generateID()
+        headers.add(name: self.headerName, value: requestID)
+      }
+
+      context.userInfo.requestID = requestID
+      context.logger.debug("received request", metadata: [
+        MetadataKey.requestID: "\(requestID)",
+      ])
+      context.receive(.metadata(headers))
+
+    case .message, .end:
+      context.receive(part)
+    }
+  }
+
+  override public func send(
+    _ part: GRPCServerResponsePart<Response>,
+    promise: EventLoopPromise<Void>?,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case .end(let status, var trailers):
+      if let requestID = context.userInfo.requestID, !trailers.contains(name: self.headerName) {
+        trailers.add(name: self.headerName, value: requestID)
+      }
+      context.send(.end(status, trailers), promise: promise)
+
+    case .metadata, .message:
+      context.send(part, promise: promise)
+    }
+  }
+}
+
+/// The `UserInfo` key for the request ID of an RPC.
+public enum RequestIDKey: UserInfo.Key {
+  public typealias Value = String
+}
+
+extension UserInfo {
+  /// The request ID of the RPC, if one was set by the `RequestIDServerInterceptor`.
+  public var requestID: RequestIDKey.Value? {
+    get {
+      return self[RequestIDKey.self]
+    }
+    set {
+      self[RequestIDKey.self] = newValue
+    }
+  }
+}
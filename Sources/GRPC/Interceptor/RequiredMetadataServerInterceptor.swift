@@ -0,0 +1,147 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOHPACK
+
+/// A requirement on the metadata sent by a client at the start of an RPC.
+public struct ServerMetadataRequirement {
+  /// The name of the metadata key which must be present. Names are matched case-insensitively.
+  public var name: String
+
+  /// The paths of the RPCs this requirement applies to, in the format "/Service/Method". The
+  /// requirement applies to all RPCs if this is `nil`.
+  public var paths: Set<String>?
+
+  /// The status code used to reject the RPC if the requirement is not met.
+  public var statusCode: GRPCStatus.Code
+
+  /// Validates the value associated with `name`.
+  internal var validate: (String) -> Bool
+
+  /// Creates a new requirement.
+  ///
+  /// - Parameters:
+  ///   - name: The name of the required metadata key.
+  ///   - paths: The paths of the RPCs this requirement applies to, or `nil` if it applies to all
+  ///       RPCs. Defaults to `nil`.
+  ///   - statusCode: The status code to reject the RPC with. Defaults to `.invalidArgument`.
+  ///   - validate: A closure to validate the first value associated with `name`. Defaults to
+  ///       accepting any value.
+  public init(
+    name: String,
+    paths: Set<String>? = nil,
+    statusCode: GRPCStatus.Code = .invalidArgument,
+    validate: @escaping (String) -> Bool = { _ in true }
+  ) {
+    self.name = name
+    self.paths = paths
+    self.statusCode = statusCode
+    self.validate = validate
+  }
+
+  /// Requires an "authorization" header using the given scheme, e.g. "Bearer <token>". RPCs which
+  /// do not meet the requirement, including those whose token is empty or only whitespace, are
+  /// rejected with `.unauthenticated`.
+  ///
+  /// - Parameters:
+  ///   - scheme: The authorization scheme, matched case-insensitively. Defaults to "Bearer".
+  ///   - paths: The paths of the RPCs this requirement applies to, or `nil` if it applies to all
+  ///       RPCs. Defaults to `nil`.
+  public static func authorization(
+    scheme: String = "Bearer",
+    paths: Set<String>? = nil
+  ) -> ServerMetadataRequirement {
+    let prefix = scheme.lowercased() + " "
+    return ServerMetadataRequirement(
+      name: "authorization",
+      paths: paths,
+      statusCode: .unauthenticated,
+      validate: { value in
+        guard value.lowercased().hasPrefix(prefix) else {
+          return false
+        }
+        // The token, once trimmed of whitespace, must not be empty.
+        return value.dropFirst(prefix.count).contains { !$0.isWhitespace }
+      }
+    )
+  }
+
+  /// Returns whether the requirement applies to the RPC with the given path.
+  internal func applies(to path: String) -> Bool {
+    return self.paths.map { $0.contains(path) } ?? true
+  }
+
+  /// Checks the requirement against the given headers, returning a status describing why the
+  /// requirement was not met or `nil` if it was met.
+  internal func check(_ headers: HPACKHeaders) -> GRPCStatus? {
+    guard let value = headers.first(name: self.name) else {
+      return GRPCStatus(
+        code: self.statusCode,
+        message: "Missing required metadata '\(self.name)'"
+      )
+    }
+
+    guard self.validate(value) else {
+      return GRPCStatus(
+        code: self.statusCode,
+        message: "Invalid value for metadata '\(self.name)'"
+      )
+    }
+
+    return nil
+  }
+}
+
+/// A server interceptor which rejects RPCs whose request metadata does not satisfy a set of
+/// requirements.
+///
+/// Requirements are checked in order when the request metadata is received. If any requirement
+/// is not met then the RPC is terminated with the status of the first failing requirement and the
+/// request is never passed to the service provider.
+///
+/// The interceptor holds no per-RPC state and may be shared between RPCs.
+public final class RequiredMetadataServerInterceptor<Request, Response>:
+  ServerInterceptor<Request, Response> {
+  /// The requirements to check, in order.
+  public let requirements: [ServerMetadataRequirement]
+
+  public init(requirements: [ServerMetadataRequirement]) {
+    self.requirements = requirements
+  }
+
+  override public func receive(
+    _ part: GRPCServerRequestPart<Request>,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case let .metadata(headers):
+      for requirement in self.requirements where requirement.applies(to: context.path) {
+        if let status = requirement.check(headers) {
+          context.logger.debug("rejecting RPC with unsatisfied metadata requirement", metadata: [
+            "metadata_name": "\(requirement.name)",
+          ])
+          // Sending 'end' closes the interceptor pipeline: any further request parts are dropped.
+          context.send(.end(status, [:]), promise: nil)
+          return
+        }
+      }
+      context.receive(part)
+
+    case .message, .end:
+      context.receive(part)
+    }
+  }
+}
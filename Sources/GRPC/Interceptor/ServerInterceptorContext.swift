@@ -15,6 +15,7 @@
  */
 import Logging
 import NIO
+import NIOHTTP2
 
 public struct ServerInterceptorContext<Request, Response> {
   /// The interceptor this context is for.
@@ -54,6 +55,30 @@ public struct ServerInterceptorContext<Request, Response> {
     return self._pipeline.remoteAddress
   }
 
+  /// The ID of the HTTP/2 stream the RPC is running on, if known.
+  public var streamID: HTTP2StreamID? {
+    return self._pipeline.streamID
+  }
+
+  /// State shared by all RPCs on the connection the RPC is running on, if known.
+  public var connection: ConnectionContext? {
+    return self._pipeline.connection
+  }
+
+  /// Running totals of the messages transferred by the RPC, if known.
+  ///
+  /// - Important: `transferTotals` *must* be accessed from the context's `eventLoop`.
+  public var transferTotals: MessageTransferTotals? {
+    return self._pipeline.transferTotals
+  }
+
+  /// Whether the RPC was terminated by the server rather than completed by the service provider,
+  /// e.g. because the client cancelled it or its deadline passed. Any status sent once this is
+  /// `true` was not produced by the service provider.
+  internal var isTerminatedByServer: Bool {
+    return self._pipeline._isTerminatedByServer
+  }
+
   /// A 'UserInfo' dictionary.
   ///
   /// - Important: While `UserInfo` has value-semantics, this property retrieves from, and sets a
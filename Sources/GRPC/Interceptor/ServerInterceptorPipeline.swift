@@ -15,6 +15,7 @@
  */
 import Logging
 import NIO
+import NIOHTTP2
 
 @usableFromInline
 internal final class ServerInterceptorPipeline<Request, Response> {
@@ -34,6 +35,18 @@ internal final class ServerInterceptorPipeline<Request, Response> {
   @usableFromInline
   internal let remoteAddress: SocketAddress?
 
+  /// The ID of the HTTP/2 stream the RPC is running on, if known.
+  @usableFromInline
+  internal let streamID: HTTP2StreamID?
+
+  /// The context of the connection the RPC is running on, if known.
+  @usableFromInline
+  internal let connection: ConnectionContext?
+
+  /// Running totals of the messages transferred by the RPC, if known.
+  @usableFromInline
+  internal let transferTotals: MessageTransferTotals?
+
   /// A logger.
   @usableFromInline
   internal let logger: Logger
@@ -67,6 +80,11 @@ internal final class ServerInterceptorPipeline<Request, Response> {
   @usableFromInline
   internal var _isOpen = true
 
+  /// Whether the RPC was terminated by the server rather than completed by the service provider,
+  /// e.g. because the client cancelled it or its deadline passed.
+  @usableFromInline
+  internal var _isTerminatedByServer = false
+
   /// The index of the next context on the inbound side of the context at the given index.
   @inlinable
   internal func _nextInboundIndex(after index: Int) -> Int {
@@ -98,6 +116,9 @@ internal final class ServerInterceptorPipeline<Request, Response> {
     path: String,
     callType: GRPCCallType,
     remoteAddress: SocketAddress?,
+    streamID: HTTP2StreamID? = nil,
+    connection: ConnectionContext? = nil,
+    transferTotals: MessageTransferTotals? = nil,
     userInfoRef: Ref<UserInfo>,
     interceptors: [ServerInterceptor<Request, Response>],
     onRequestPart: @escaping (GRPCServerRequestPart<Request>) -> Void,
@@ -108,6 +129,9 @@ internal final class ServerInterceptorPipeline<Request, Response> {
     self.path = path
     self.type = callType
     self.remoteAddress = remoteAddress
+    self.streamID = streamID
+    self.connection = connection
+    self.transferTotals = transferTotals
     self.userInfoRef = userInfoRef
 
     self._onResponsePart = onResponsePart
@@ -0,0 +1,196 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/// Makes server interceptors for methods of any request and response type.
+///
+/// Implementations typically create a new instance of a generic interceptor for each RPC:
+///
+/// ```
+/// struct LoggingInterceptorMaker: ServerInterceptorMaker {
+///   func makeInterceptor<Request, Response>(
+///     for method: ServerMethodDescriptor
+///   ) -> ServerInterceptor<Request, Response> {
+///     return LoggingInterceptor<Request, Response>()
+///   }
+/// }
+/// ```
+public protocol ServerInterceptorMaker {
+  /// Returns an interceptor for an RPC to the given method.
+  func makeInterceptor<Request, Response>(
+    for method: ServerMethodDescriptor
+  ) -> ServerInterceptor<Request, Response>
+}
+
+/// The methods a server interceptor registered with a `ServerInterceptorRegistry` applies to.
+public struct ServerInterceptorScope {
+  internal enum Wrapped {
+    case allMethods
+    case services(Set<String>)
+    case methods(Set<ServerMethodDescriptor>)
+  }
+
+  internal var wrapped: Wrapped
+  private init(_ wrapped: Wrapped) {
+    self.wrapped = wrapped
+  }
+
+  /// Every method of every service.
+  public static let allMethods = ServerInterceptorScope(.allMethods)
+
+  /// Every method of the services with the given names, including their package, e.g. "echo.Echo".
+  public static func services(_ names: Set<String>) -> ServerInterceptorScope {
+    return ServerInterceptorScope(.services(names))
+  }
+
+  /// Only the given methods.
+  public static func methods(_ methods: Set<ServerMethodDescriptor>) -> ServerInterceptorScope {
+    return ServerInterceptorScope(.methods(methods))
+  }
+
+  /// Returns whether the scope includes the given method.
+  internal func contains(_ method: ServerMethodDescriptor) -> Bool {
+    switch self.wrapped {
+    case .allMethods:
+      return true
+    case let .services(names):
+      return names.contains(method.serviceName)
+    case let .methods(methods):
+      return methods.contains(method)
+    }
+  }
+}
+
+/// A registry of server interceptors which resolves the interceptors for each method from their
+/// scope and priority, rather than relying on the order of an array returned by each method of an
+/// interceptor factory.
+///
+/// The interceptors for a method are those whose scope contains it, ordered by descending priority;
+/// interceptors with the same priority are ordered by when they were registered. The first
+/// interceptor is the first to receive request parts and the last to send response parts. The
+/// order is deterministic so the resolved chain, as returned by `resolvedChain(for:)`, may be
+/// asserted on in tests.
+///
+/// The registry is installed on a `Server` with `interceptorRegistry` on its configuration (or
+/// `withInterceptorRegistry(_:)` on its builder). The interceptors it resolves for the method of
+/// each RPC run ahead of those made by the interceptor factory of the service provider, so the
+/// same ordering and scoping apply across services:
+///
+/// ```
+/// var registry = ServerInterceptorRegistry()
+/// let get = ServerMethodDescriptor(serviceName: "echo.Echo", name: "Get")
+/// try registry.register("auth", priority: 300, scope: .methods([get]), maker: AuthMaker())
+/// try registry.register("rate-limit", priority: 200, scope: .services(["echo.Echo"]),
+///                       maker: RateLimitMaker())
+/// try registry.register("logging", priority: 100, maker: LoggingMaker())
+///
+/// // "auth", "rate-limit", "logging"
+/// registry.resolvedChain(for: get)
+///
+/// let server = Server.insecure(group: group)
+///   .withServiceProviders([EchoProvider()])
+///   .withInterceptorRegistry(registry)
+///   .bind(host: "localhost", port: 0)
+/// ```
+public struct ServerInterceptorRegistry {
+  private struct Registration {
+    var name: String
+    var priority: Int
+    var scope: ServerInterceptorScope
+    var maker: ServerInterceptorMaker
+  }
+
+  /// Registered interceptors, in the order they were registered.
+  private var registrations: [Registration] = []
+
+  public init() {}
+
+  /// Registers an interceptor.
+  ///
+  /// - Parameters:
+  ///   - name: The name of the interceptor, used by `resolvedChain(for:)`. Names must be unique.
+  ///   - priority: The priority of the interceptor. Interceptors with a higher priority are earlier
+  ///       in the chain.
+  ///   - scope: The methods the interceptor applies to. Defaults to `.allMethods`.
+  ///   - maker: Makes the interceptor for each RPC.
+  /// - Throws: `GRPCError.InvalidState` if an interceptor named `name` has already been
+  ///   registered, in which case the registry is unchanged.
+  public mutating func register(
+    _ name: String,
+    priority: Int,
+    scope: ServerInterceptorScope = .allMethods,
+    maker: ServerInterceptorMaker
+  ) throws {
+    guard !self.registrations.contains(where: { $0.name == name }) else {
+      throw GRPCError.InvalidState("An interceptor named '\(name)' has already been registered")
+    }
+    self.registrations.append(
+      Registration(name: name, priority: priority, scope: scope, maker: maker)
+    )
+  }
+
+  /// Whether no interceptors have been registered.
+  internal var isEmpty: Bool {
+    return self.registrations.isEmpty
+  }
+
+  /// Returns the names of the interceptors which apply to the given method, in order.
+  public func resolvedChain(for method: ServerMethodDescriptor) -> [String] {
+    return self.resolve(for: method).map { $0.name }
+  }
+
+  /// Returns new interceptors for an RPC to the given method, in order.
+  public func makeInterceptors<Request, Response>(
+    for method: ServerMethodDescriptor
+  ) -> [ServerInterceptor<Request, Response>] {
+    return self.resolve(for: method).map {
+      $0.maker.makeInterceptor(for: method)
+    }
+  }
+
+  private func resolve(for method: ServerMethodDescriptor) -> [Registration] {
+    // 'sorted(by:)' isn't stable, so break ties using the registration order explicitly.
+    return self.registrations.enumerated().filter {
+      $0.element.scope.contains(method)
+    }.sorted { lhs, rhs in
+      if lhs.element.priority == rhs.element.priority {
+        return lhs.offset < rhs.offset
+      } else {
+        return lhs.element.priority > rhs.element.priority
+      }
+    }.map {
+      $0.element
+    }
+  }
+}
+
+extension CallHandlerContext {
+  /// Returns the interceptors resolved by the server's `ServerInterceptorRegistry` for the method
+  /// being called followed by `interceptors`, those made by the service provider.
+  @usableFromInline
+  internal func resolveInterceptors<Request, Response>(
+    _ interceptors: [ServerInterceptor<Request, Response>]
+  ) -> [ServerInterceptor<Request, Response>] {
+    guard !self.interceptorRegistry.isEmpty, let callPath = CallPath(requestURI: self.path) else {
+      return interceptors
+    }
+
+    let method = ServerMethodDescriptor(
+      serviceName: String(Substring(callPath.service)),
+      name: String(Substring(callPath.method))
+    )
+    return self.interceptorRegistry.makeInterceptors(for: method) + interceptors
+  }
+}
@@ -57,6 +57,12 @@ open class ServerInterceptor<Request, Response> {
   }
 
   /// Called when the interceptor has received a response part to handle.
+  ///
+  /// The 'end' part carries the trailers set by the service provider, or those derived from the
+  /// error if the RPC failed. Interceptors may add to or modify these trailers before forwarding
+  /// the part; whatever is forwarded by the first interceptor in the pipeline is sent to the
+  /// client.
+  ///
   /// - Parameters:
   ///   - part: The request part which should be sent to the client.
   ///   - promise: A promise which should be completed when the response part has been written.
@@ -0,0 +1,78 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOHPACK
+
+/// A server interceptor which adds the time taken to process an RPC to its trailers, in
+/// milliseconds.
+///
+/// Processing time is measured from when the request metadata reaches the interceptor until the
+/// 'end' response part does, and so includes the time spent in the service provider and in any
+/// interceptors after this one in the pipeline. The trailer is added whether the RPC succeeded or
+/// failed, including when the handler threw an error or failed its response promise.
+///
+/// Trailers set by the handler (or by later interceptors) are kept. If they already contain a
+/// value for `trailerName` then it is replaced: the value measured by this interceptor wins.
+///
+/// RPCs rejected by an interceptor *before* this one in the pipeline never reach it, so it should
+/// usually be the first interceptor returned by the interceptor factory.
+///
+/// The interceptor holds per-RPC state: a new instance must be created for each RPC.
+public final class ServerProcessingTimeInterceptor<Request, Response>:
+  ServerInterceptor<Request, Response> {
+  /// The name of the trailer the processing time is added to.
+  public let trailerName: String
+
+  /// When the request metadata was received, or `nil` if it hasn't been received yet.
+  private var start: NIODeadline?
+
+  /// Creates a new interceptor.
+  ///
+  /// - Parameter trailerName: The name of the trailer to add the processing time to. Defaults to
+  ///     "x-server-processing-ms".
+  public init(trailerName: String = "x-server-processing-ms") {
+    self.trailerName = trailerName
+  }
+
+  override public func receive(
+    _ part: GRPCServerRequestPart<Request>,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    if case .metadata = part {
+      self.start = .now()
+    }
+    context.receive(part)
+  }
+
+  override public func send(
+    _ part: GRPCServerResponsePart<Response>,
+    promise: EventLoopPromise<Void>?,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case var .end(status, trailers):
+      if let start = self.start {
+        let elapsed = NIODeadline.now() - start
+        let millis = elapsed.nanoseconds / 1_000_000
+        trailers.replaceOrAdd(name: self.trailerName, value: String(millis))
+      }
+      context.send(.end(status, trailers), promise: promise)
+
+    case .metadata, .message:
+      context.send(part, promise: promise)
+    }
+  }
+}
@@ -0,0 +1,324 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+import NIOHPACK
+
+/// Holds an access token, such as an OAuth token, shared by RPCs and refreshes it when it is
+/// rejected. Used by `TokenRefreshClientInterceptor`.
+///
+/// Refreshes are coalesced: while a refresh is in progress, RPCs which need a new token wait for
+/// that refresh rather than starting another one. A refresh is only started if the token being
+/// replaced is still the current token, so many RPCs failing with the same stale token result in a
+/// single refresh.
+///
+/// The token provider is thread safe.
+public final class AccessTokenProvider {
+  /// Fetches a new token.
+  private let fetchToken: (EventLoop) -> EventLoopFuture<String>
+
+  /// The current token. Protected by `lock`.
+  private var _token: String?
+
+  /// The refresh in progress, if there is one. Protected by `lock`.
+  private var refreshInProgress: EventLoopFuture<String>?
+
+  private let lock = Lock()
+
+  /// The current token, if one has been fetched.
+  public var token: String? {
+    return self.lock.withLock {
+      self._token
+    }
+  }
+
+  /// Creates a token provider.
+  ///
+  /// - Parameters:
+  ///   - initialToken: The token to use until it's rejected. If `nil` then a token is fetched when
+  ///       the first RPC starts. Defaults to `nil`.
+  ///   - fetchToken: Fetches a new token. Called with an `EventLoop` which the returned future
+  ///       may be created on.
+  public init(
+    initialToken: String? = nil,
+    fetchToken: @escaping (EventLoop) -> EventLoopFuture<String>
+  ) {
+    self._token = initialToken
+    self.fetchToken = fetchToken
+  }
+
+  /// Refreshes the token if `staleToken` is the current token, or waits for a refresh already in
+  /// progress.
+  ///
+  /// - Parameters:
+  ///   - staleToken: The token which was rejected, or `nil` if there was no token.
+  ///   - eventLoop: The `EventLoop` to complete the returned future on.
+  /// - Returns: A future new token.
+  internal func refresh(
+    replacing staleToken: String?,
+    on eventLoop: EventLoop
+  ) -> EventLoopFuture<String> {
+    enum Action {
+      case useCurrent(String)
+      case wait(EventLoopFuture<String>)
+      case fetch(EventLoopPromise<String>)
+    }
+
+    let action: Action = self.lock.withLock {
+      if let refresh = self.refreshInProgress {
+        return .wait(refresh)
+      } else if let token = self._token, token != staleToken {
+        // The token has already been refreshed.
+        return .useCurrent(token)
+      } else {
+        let promise = eventLoop.makePromise(of: String.self)
+        self.refreshInProgress = promise.futureResult
+        return .fetch(promise)
+      }
+    }
+
+    switch action {
+    case let .useCurrent(token):
+      return eventLoop.makeSucceededFuture(token)
+
+    case let .wait(refresh):
+      return refresh.hop(to: eventLoop)
+
+    case let .fetch(promise):
+      // Completion callbacks may run immediately, so fetch without holding the lock.
+      promise.futureResult.whenComplete { result in
+        self.lock.withLockVoid {
+          self.refreshInProgress = nil
+          if case let .success(token) = result {
+            self._token = token
+          }
+        }
+      }
+      promise.completeWith(self.fetchToken(eventLoop))
+      return promise.futureResult
+    }
+  }
+
+  /// Returns a future which completes when the refresh in progress, if there is one, completes.
+  /// The future succeeds with `false` if the refresh failed.
+  private func refreshCompleted(on eventLoop: EventLoop) -> EventLoopFuture<Bool> {
+    guard let refresh = self.lock.withLock({ self.refreshInProgress }) else {
+      return eventLoop.makeSucceededFuture(true)
+    }
+    return refresh.hop(to: eventLoop).map { _ in true }.recover { _ in false }
+  }
+}
+
+extension AccessTokenProvider {
+  /// Makes a unary RPC with `makeCall` and, if it fails with status code 'unauthenticated', makes
+  /// it once more after the token has been refreshed. Other failures are not retried.
+  ///
+  /// The RPCs made by `makeCall` must use a `TokenRefreshClientInterceptor` with this provider.
+  /// As with any retry the RPC must be idempotent: the server may have processed the first
+  /// attempt.
+  ///
+  /// ```
+  /// let response = tokenProvider.retryingOnceIfUnauthenticated {
+  ///   client.get(request)
+  /// }
+  /// ```
+  ///
+  /// - Parameter makeCall: Makes the RPC, called at most twice.
+  /// - Returns: The response of the first attempt, or of the retry if the first attempt was
+  ///     rejected as 'unauthenticated'.
+  public func retryingOnceIfUnauthenticated<Request, Response>(
+    _ makeCall: @escaping () -> UnaryCall<Request, Response>
+  ) -> EventLoopFuture<Response> {
+    let call = makeCall()
+    return call.response.flatMapError { error in
+      call.status.flatMap { status in
+        guard status.code == .unauthenticated else {
+          return call.eventLoop.makeFailedFuture(error)
+        }
+
+        // The interceptor started the refresh before the RPC failed: retry with the new token once
+        // the refresh completes. If it failed there's no new token to retry with.
+        return self.refreshCompleted(on: call.eventLoop).flatMap { refreshed in
+          refreshed ? makeCall().response : call.eventLoop.makeFailedFuture(error)
+        }
+      }
+    }
+  }
+}
+
+/// A client interceptor which adds an access token from an `AccessTokenProvider` to the request
+/// metadata of each RPC and refreshes the token when an RPC fails with status code
+/// 'unauthenticated'.
+///
+/// RPCs started before a token is available wait for one to be fetched. The interceptor can't
+/// retry an RPC which fails with 'unauthenticated': the failure is passed through and the
+/// refreshed token is used by subsequent RPCs. Unary RPCs may be retried once with the refreshed
+/// token by making them with `AccessTokenProvider.retryingOnceIfUnauthenticated(_:)`. All other
+/// failures are passed through unchanged.
+///
+/// A new interceptor must be created for each RPC; the `tokenProvider` should be shared.
+public final class TokenRefreshClientInterceptor<Request, Response>:
+  ClientInterceptor<Request, Response> {
+  /// The token provider shared between RPCs.
+  public let tokenProvider: AccessTokenProvider
+
+  /// The name of the metadata key to store the token in.
+  public let headerName: String
+
+  /// Makes the header value from a token.
+  private let makeHeaderValue: (String) -> String
+
+  private var state: State = .idle
+
+  /// A request part sent while waiting for a token.
+  private typealias BufferedPart = (GRPCClientRequestPart<Request>, EventLoopPromise<Void>?)
+
+  private enum State {
+    /// No request parts have been sent.
+    case idle
+    /// Waiting for a token; request parts are buffered until it is available.
+    case waitingForToken(CircularBuffer<BufferedPart>)
+    /// The request metadata has been sent with the given token.
+    case active(token: String)
+    /// The RPC failed or was cancelled before the request metadata was sent.
+    case failed
+  }
+
+  /// Creates a new token refresh interceptor.
+  ///
+  /// - Parameters:
+  ///   - tokenProvider: The token provider, which should be shared between RPCs.
+  ///   - headerName: The name of the metadata key to store the token in. Defaults to
+  ///       "authorization".
+  ///   - makeHeaderValue: Makes the header value from a token. Defaults to using the
+  ///       "Bearer" scheme.
+  public init(
+    tokenProvider: AccessTokenProvider,
+    headerName: String = "authorization",
+    makeHeaderValue: @escaping (String) -> String = { "Bearer \($0)" }
+  ) {
+    self.tokenProvider = tokenProvider
+    self.headerName = headerName.lowercased()
+    self.makeHeaderValue = makeHeaderValue
+  }
+
+  override public func send(
+    _ part: GRPCClientRequestPart<Request>,
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    switch self.state {
+    case .idle:
+      if let token = self.tokenProvider.token {
+        self.sendWithToken(part, token: token, promise: promise, context: context)
+      } else {
+        var buffer = CircularBuffer<BufferedPart>()
+        buffer.append((part, promise))
+        self.state = .waitingForToken(buffer)
+
+        context.logger.debug("no access token available, fetching one")
+        self.tokenProvider.refresh(replacing: nil, on: context.eventLoop).whenComplete {
+          self.tokenFetched($0, context: context)
+        }
+      }
+
+    case var .waitingForToken(buffer):
+      buffer.append((part, promise))
+      self.state = .waitingForToken(buffer)
+
+    case .active:
+      context.send(part, promise: promise)
+
+    case .failed:
+      promise?.fail(GRPCError.AlreadyComplete())
+    }
+  }
+
+  override public func receive(
+    _ part: GRPCClientResponsePart<Response>,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    if case let .end(status, _) = part, status.code == .unauthenticated,
+      case let .active(token) = self.state {
+      context.logger.debug("access token was rejected, refreshing it")
+      // Later RPCs pick up the new token; this RPC fails with the status from the server.
+      self.tokenProvider.refresh(replacing: token, on: context.eventLoop).whenFailure { error in
+        context.logger.debug("failed to refresh access token", metadata: [
+          MetadataKey.error: "\(error)",
+        ])
+      }
+    }
+    context.receive(part)
+  }
+
+  override public func cancel(
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    if case let .waitingForToken(buffer) = self.state {
+      self.state = .failed
+      for (_, promise) in buffer {
+        promise?.fail(GRPCError.RPCCancelledByClient())
+      }
+    }
+    context.cancel(promise: promise)
+  }
+
+  private func sendWithToken(
+    _ part: GRPCClientRequestPart<Request>,
+    token: String,
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    self.state = .active(token: token)
+
+    switch part {
+    case var .metadata(headers):
+      headers.replaceOrAdd(name: self.headerName, value: self.makeHeaderValue(token))
+      context.send(.metadata(headers), promise: promise)
+
+    case .message, .end:
+      // The metadata is always the first request part.
+      context.send(part, promise: promise)
+    }
+  }
+
+  private func tokenFetched(
+    _ result: Result<String, Error>,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    // The RPC may have been cancelled while waiting.
+    guard case let .waitingForToken(buffer) = self.state else {
+      return
+    }
+
+    switch result {
+    case let .success(token):
+      for (part, promise) in buffer {
+        self.sendWithToken(part, token: token, promise: promise, context: context)
+      }
+
+    case let .failure(error):
+      self.state = .failed
+      for (_, promise) in buffer {
+        promise?.fail(error)
+      }
+      context.errorCaught(
+        GRPCStatus(code: .unauthenticated, message: "Failed to fetch access token: \(error)")
+      )
+    }
+  }
+}
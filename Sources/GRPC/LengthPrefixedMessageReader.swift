@@ -126,7 +126,8 @@ internal struct LengthPrefixedMessageReader {
   ///
   /// - Returns: A buffer containing a message if one has been read, or `nil` if not enough
   ///   bytes have been consumed to return a message.
-  /// - Throws: Throws an error if the compression algorithm is not supported.
+  /// - Throws: Throws an error if the compression algorithm is not supported or if the length of
+  ///   the message exceeds `maxLength`.
   internal mutating func nextMessage(maxLength: Int) throws -> ByteBuffer? {
     switch try self.processNextState(maxLength: maxLength) {
     case .needMoreData:
@@ -173,9 +174,12 @@ internal struct LengthPrefixedMessageReader {
       }
 
       let isCompressionEnabled = compressionFlag != 0
-      // Compression is enabled, but not expected.
-      if isCompressionEnabled, self.compression == nil {
-        throw GRPCError.CompressionUnsupported().captureContext()
+      // Compression is enabled, but not expected: either no message encoding was negotiated or
+      // the negotiated encoding is 'identity' and there is no decompressor. The opposite case, an
+      // uncompressed message with a non-identity encoding, is valid: the encoding only applies to
+      // messages with the flag set.
+      if isCompressionEnabled, self.decompressor == nil {
+        throw GRPCError.CompressedFlagMismatch(encoding: self.compression?.name).captureContext()
       }
       self.state = .expectingMessageLength(compressed: isCompressionEnabled)
 
@@ -184,8 +188,11 @@ internal struct LengthPrefixedMessageReader {
         return .needMoreData
       }
 
+      // Validate the length before reading (and allocating space for) the message: the length is
+      // controlled by the remote peer.
       if messageLength > maxLength {
-        throw GRPCError.DeserializationFailure().captureContext()
+        throw GRPCError.PayloadLengthLimitExceeded(actualLength: messageLength, limit: maxLength)
+          .captureContext()
       }
 
       self.state = .expectingMessage(messageLength, compressed: compressed)
@@ -201,9 +208,9 @@ internal struct LengthPrefixedMessageReader {
       // into the decompressor. This should eliminate one buffer allocation (i.e. the buffer into
       // which we currently accumulate the slices before decompressing it into a new buffer).
 
-      // If compression is set but the algorithm is 'identity' then we will not get a decompressor
-      // here.
-      if compressed, let decompressor = self.decompressor {
+      // The compression flag is only accepted if we have a decompressor. An empty payload has
+      // nothing to decompress (and isn't valid compressed data) so it's passed through as-is.
+      if compressed, length > 0, let decompressor = self.decompressor {
         var decompressed = ByteBufferAllocator().buffer(capacity: 0)
         try decompressor.inflate(&message, into: &decompressed)
         // Compression contexts should be reset between messages.
@@ -23,13 +23,18 @@ internal struct LengthPrefixedMessageWriter {
   let compression: CompressionAlgorithm?
   private let compressor: Zlib.Deflate?
 
+  /// If set, only messages of at least this many bytes are compressed and compressed messages
+  /// which are no smaller than the uncompressed message are written uncompressed instead.
+  let compressionThreshold: Int?
+
   /// Whether the compression message flag should be set.
   private var shouldSetCompressionFlag: Bool {
     return self.compression != nil
   }
 
-  init(compression: CompressionAlgorithm? = nil) {
+  init(compression: CompressionAlgorithm? = nil, compressionThreshold: Int? = nil) {
     self.compression = compression
+    self.compressionThreshold = compressionThreshold
 
     switch self.compression?.algorithm {
     case .none, .some(.identity):
@@ -80,13 +85,26 @@ internal struct LengthPrefixedMessageWriter {
   ///   - buffer: The bytes to compress and length-prefix.
   ///   - allocator: A `ByteBufferAllocator`.
   ///   - compressed: Whether the bytes should be compressed. This is ignored if not compression
-  ///     mechanism was configured on this writer.
+  ///     mechanism was configured on this writer, or if the bytes don't meet the
+  ///     `compressionThreshold`.
   /// - Returns: A buffer containing the length prefixed bytes.
   func write(buffer: ByteBuffer, allocator: ByteBufferAllocator,
              compressed: Bool = true) throws -> ByteBuffer {
     if compressed, let compressor = self.compressor {
-      return try self.compress(buffer: buffer, using: compressor, allocator: allocator)
-    } else if buffer.readerIndex >= 5 {
+      guard let threshold = self.compressionThreshold else {
+        return try self.compress(buffer: buffer, using: compressor, allocator: allocator)
+      }
+
+      if buffer.readableBytes >= threshold {
+        let message = try self.compress(buffer: buffer, using: compressor, allocator: allocator)
+        // Only use the compressed message if it's actually smaller.
+        if message.readableBytes < buffer.readableBytes + Self.metadataLength {
+          return message
+        }
+      }
+    }
+
+    if buffer.readerIndex >= 5 {
       // We're not compressing and we have enough bytes before the reader index that we can write
       // over with the compression byte and length.
       var buffer = buffer
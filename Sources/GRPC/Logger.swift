@@ -20,6 +20,11 @@ import NIO
 enum MetadataKey {
   static let requestID = "grpc_request_id"
   static let connectionID = "grpc_connection_id"
+  static let method = "grpc_method"
+  static let peer = "grpc_peer"
+  static let timeout = "grpc_timeout"
+  static let traceID = "trace_id"
+  static let spanID = "span_id"
 
   static let eventLoop = "event_loop"
 
@@ -29,6 +34,15 @@ enum MetadataKey {
   static let h2Payload = "h2_payload"
   static let h2Headers = "h2_headers"
   static let h2DataBytes = "h2_data_bytes"
+  static let h2Direction = "h2_direction"
+  static let h2PaddingBytes = "h2_padding_bytes"
+  static let h2Ack = "h2_ack"
+  static let h2Settings = "h2_settings"
+  static let h2PingData = "h2_ping_data"
+  static let h2LastStreamID = "h2_last_stream_id"
+  static let h2ErrorCode = "h2_error_code"
+  static let h2DebugData = "h2_debug_data"
+  static let h2WindowSizeIncrement = "h2_window_size_increment"
 
   static let error = "error"
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/// Limits on the total number of message bytes a single RPC may transfer, summed across all of
+/// its messages. Sizes are of serialized messages before compression (or after decompression)
+/// and exclude the gRPC length-prefix.
+///
+/// These are distinct from the maximum receive message length, which limits the size of each
+/// individual message. An RPC exceeding either limit fails with status code
+/// `.resourceExhausted`.
+public struct MessageTransferLimits: Hashable {
+  /// The maximum total size of the request messages of an RPC, or `nil` for no limit.
+  public var maximumTotalRequestBytes: Int?
+
+  /// The maximum total size of the response messages of an RPC, or `nil` for no limit.
+  public var maximumTotalResponseBytes: Int?
+
+  public init(maximumTotalRequestBytes: Int? = nil, maximumTotalResponseBytes: Int? = nil) {
+    self.maximumTotalRequestBytes = maximumTotalRequestBytes
+    self.maximumTotalResponseBytes = maximumTotalResponseBytes
+  }
+
+  /// No limits.
+  public static let unlimited = MessageTransferLimits()
+}
+
+/// Running totals of the messages transferred by a single RPC on a server. Sizes are of
+/// serialized messages before compression (or after decompression).
+///
+/// Totals are updated as messages are received from and sent to the network, so the request
+/// totals include a message before it is passed to interceptors and the response totals only
+/// include a message once it has passed through all interceptors.
+///
+/// - Important: Totals *must* be accessed from the `EventLoop` of the RPC.
+public final class MessageTransferTotals {
+  /// The number of request messages received.
+  public internal(set) var requestMessages: Int = 0
+
+  /// The total size of the request messages received.
+  public internal(set) var requestBytes: Int = 0
+
+  /// The number of response messages sent.
+  public internal(set) var responseMessages: Int = 0
+
+  /// The total size of the response messages sent.
+  public internal(set) var responseBytes: Int = 0
+
+  internal init() {}
+}
@@ -0,0 +1,37 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIOHTTP2
+
+/// The HPACK dynamic table size assumed by HTTP/2 peers before any settings are exchanged.
+internal let defaultHTTPHeaderTableSize = 4096
+
+/// Returns whether `size` may be sent as the value of SETTINGS_HEADER_TABLE_SIZE, i.e. whether it
+/// is an unsigned 32-bit integer.
+internal func isValidHTTPHeaderTableSize(_ size: Int) -> Bool {
+  return size >= 0 && Int64(size) <= Int64(UInt32.max)
+}
+
+extension NIOHTTP2Handler {
+  /// Creates a handler which advertises `headerTableSize` as the maximum size of the HPACK
+  /// dynamic table it uses to decode headers from the remote peer.
+  convenience init(mode: ParserMode, headerTableSize: Int) {
+    var settings = nioDefaultSettings
+    if headerTableSize != defaultHTTPHeaderTableSize {
+      settings.append(HTTP2Setting(parameter: .headerTableSize, value: headerTableSize))
+    }
+    self.init(mode: mode, initialSettings: settings)
+  }
+}
@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Logging
+import NIO
+import NIOSSL
+import NIOTLS
+
+/// The `NIOSSLContext`s used by a server, and the rules for choosing between them based on the
+/// server name sent by the client via the SNI extension.
+internal struct NIOSSLServerContexts {
+  /// The context to use if server names aren't used to select a context, or if the client's
+  /// server name has no context of its own and unknown server names aren't rejected.
+  var defaultContext: NIOSSLContext
+
+  /// Contexts keyed by their lowercased server name.
+  var contextsByServerName: [String: NIOSSLContext]
+
+  /// Whether to reject connections with an unknown server name or no server name.
+  var rejectUnknownServerNames: Bool
+
+  /// Whether the server name sent by the client must be inspected to select a context.
+  var selectsByServerName: Bool {
+    return !self.contextsByServerName.isEmpty
+  }
+
+  /// Returns the context to use for a connection, or `nil` if the connection should be rejected.
+  func context(for result: SNIResult) -> NIOSSLContext? {
+    let selected: NIOSSLContext?
+
+    switch result {
+    case let .hostname(hostname):
+      selected = self.contextsByServerName[hostname.lowercased()]
+    case .fallback:
+      selected = nil
+    }
+
+    if let selected = selected {
+      return selected
+    } else if self.rejectUnknownServerNames {
+      return nil
+    } else {
+      return self.defaultContext
+    }
+  }
+
+  /// Adds handlers to the `channel` to perform a TLS handshake using the appropriate context.
+  ///
+  /// If a context is selected by server name then the `ClientHello` is inspected before the
+  /// `NIOSSLServerHandler` is added: it is added immediately before `nextHandler`.
+  func configureTLS(
+    on channel: Channel,
+    before nextHandler: ChannelHandler,
+    logger: Logger
+  ) throws {
+    let sync = channel.pipeline.syncOperations
+
+    guard self.selectsByServerName else {
+      try sync.addHandler(NIOSSLServerHandler(context: self.defaultContext))
+      return
+    }
+
+    let sniHandler = ByteToMessageHandler(SNIHandler { result in
+      guard let context = self.context(for: result) else {
+        logger.debug("rejecting connection with unknown TLS server name", metadata: [
+          "tls_server_name": "\(result)",
+        ])
+        channel.close(mode: .all, promise: nil)
+        return channel.eventLoop.makeFailedFuture(UnknownServerName())
+      }
+
+      return channel.pipeline.addHandler(
+        NIOSSLServerHandler(context: context),
+        position: .before(nextHandler)
+      )
+    })
+
+    try sync.addHandler(sniHandler)
+  }
+}
+
+/// The server name sent by a client didn't match any configured identity.
+internal struct UnknownServerName: Error {}
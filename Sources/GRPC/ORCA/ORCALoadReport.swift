@@ -0,0 +1,163 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOFoundationCompat
+import SwiftProtobuf
+
+/// A backend metrics report in the Open Request Cost Aggregation (ORCA) format, i.e. an
+/// `xds.data.orca.v3.OrcaLoadReport`.
+///
+/// Reports may be received in-band, in the "endpoint-load-metrics-bin" trailer of an RPC (see
+/// `ORCALoadReportInterceptor`), or out-of-band, by streaming reports from the
+/// "xds.service.orca.v3.OpenRcaService" (see `GRPCChannel.makeORCALoadReportStream`).
+///
+/// Unknown fields are ignored when decoding a report.
+public struct ORCALoadReport: Hashable {
+  /// CPU utilization expressed as a fraction of available CPU resources.
+  public var cpuUtilization: Double
+
+  /// Memory utilization expressed as a fraction of available memory resources.
+  public var memoryUtilization: Double
+
+  /// Application specific utilization expressed as a fraction of available resources.
+  public var applicationUtilization: Double
+
+  /// Total requests per second being served by the backend.
+  public var requestsPerSecond: Double
+
+  /// Total errors per second being served by the backend.
+  public var errorsPerSecond: Double
+
+  /// Application specific request costs, keyed by cost name.
+  public var requestCost: [String: Double]
+
+  /// Resource utilization values, keyed by resource name.
+  public var utilization: [String: Double]
+
+  /// Application specific opaque metrics, keyed by metric name.
+  public var namedMetrics: [String: Double]
+
+  public init(
+    cpuUtilization: Double = 0,
+    memoryUtilization: Double = 0,
+    applicationUtilization: Double = 0,
+    requestsPerSecond: Double = 0,
+    errorsPerSecond: Double = 0,
+    requestCost: [String: Double] = [:],
+    utilization: [String: Double] = [:],
+    namedMetrics: [String: Double] = [:]
+  ) {
+    self.cpuUtilization = cpuUtilization
+    self.memoryUtilization = memoryUtilization
+    self.applicationUtilization = applicationUtilization
+    self.requestsPerSecond = requestsPerSecond
+    self.errorsPerSecond = errorsPerSecond
+    self.requestCost = requestCost
+    self.utilization = utilization
+    self.namedMetrics = namedMetrics
+  }
+}
+
+extension ORCALoadReport: GRPCPayload {
+  public init(serializedByteBuffer: inout ByteBuffer) throws {
+    // '!' is okay; we can always read 'readableBytes'.
+    let data = serializedByteBuffer.readData(length: serializedByteBuffer.readableBytes)!
+    let report = try Xds_Data_Orca_V3_OrcaLoadReport(serializedData: data)
+
+    self.init(
+      cpuUtilization: report.cpuUtilization,
+      memoryUtilization: report.memUtilization,
+      applicationUtilization: report.applicationUtilization,
+      // 'rps' is deprecated in favour of 'rps_fractional' but may still be sent.
+      requestsPerSecond: report.rpsFractional != 0 ? report.rpsFractional : Double(report.rps),
+      errorsPerSecond: report.eps,
+      requestCost: report.requestCost,
+      utilization: report.utilization,
+      namedMetrics: report.namedMetrics
+    )
+  }
+
+  public func serialize(into buffer: inout ByteBuffer) throws {
+    let report = Xds_Data_Orca_V3_OrcaLoadReport.with {
+      $0.cpuUtilization = self.cpuUtilization
+      $0.memUtilization = self.memoryUtilization
+      $0.applicationUtilization = self.applicationUtilization
+      $0.rpsFractional = self.requestsPerSecond
+      $0.eps = self.errorsPerSecond
+      $0.requestCost = self.requestCost
+      $0.utilization = self.utilization
+      $0.namedMetrics = self.namedMetrics
+    }
+    buffer.writeBytes(try report.serializedData())
+  }
+}
+
+extension ORCALoadReport {
+  /// The name of the trailer in which load reports are sent in-band.
+  public static let trailerName = "endpoint-load-metrics-bin"
+
+  /// Decodes a load report from the value of the "endpoint-load-metrics-bin" trailer. Binary
+  /// metadata is base64 encoded; padding may be omitted.
+  ///
+  /// - Parameter trailerValue: The base64 encoded trailer value.
+  /// - Throws: If the value is not valid base64 or does not contain a valid load report.
+  public init(trailerValue: String) throws {
+    guard let bytes = trailerValue.base64DecodedBytes() else {
+      throw GRPCError.DeserializationFailure()
+    }
+
+    var buffer = ByteBufferAllocator().buffer(capacity: bytes.count)
+    buffer.writeBytes(bytes)
+    try self.init(serializedByteBuffer: &buffer)
+  }
+}
+
+/// A request for a stream of out-of-band load reports, i.e. an
+/// `xds.service.orca.v3.OrcaLoadReportRequest`.
+public struct ORCALoadReportRequest: Hashable {
+  /// The interval at which reports should be sent by the server.
+  public var reportInterval: TimeAmount
+
+  /// The request cost names to include in each report. All costs are sent if empty.
+  public var requestCostNames: [String]
+
+  public init(reportInterval: TimeAmount, requestCostNames: [String] = []) {
+    self.reportInterval = reportInterval
+    self.requestCostNames = requestCostNames
+  }
+}
+
+extension ORCALoadReportRequest: GRPCPayload {
+  public init(serializedByteBuffer: inout ByteBuffer) throws {
+    // '!' is okay; we can always read 'readableBytes'.
+    let data = serializedByteBuffer.readData(length: serializedByteBuffer.readableBytes)!
+    let request = try Xds_Service_Orca_V3_OrcaLoadReportRequest(serializedData: data)
+
+    self.init(
+      reportInterval: TimeAmount(saturating: request.reportInterval),
+      requestCostNames: request.requestCostNames
+    )
+  }
+
+  public func serialize(into buffer: inout ByteBuffer) throws {
+    let request = Xds_Service_Orca_V3_OrcaLoadReportRequest.with {
+      $0.reportInterval = Google_Protobuf_Duration(self.reportInterval)
+      $0.requestCostNames = self.requestCostNames
+    }
+    buffer.writeBytes(try request.serializedData())
+  }
+}
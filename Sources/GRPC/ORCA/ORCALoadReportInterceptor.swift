@@ -0,0 +1,82 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// A client interceptor which decodes ORCA load reports sent in-band by the server in the
+/// "endpoint-load-metrics-bin" trailer.
+///
+/// Each decoded report is passed to the `onLoadReport` callback along with the path of the RPC
+/// the report was received on. Reports which can't be decoded are logged and dropped; the RPC is
+/// not affected.
+///
+/// The callback is invoked on the `EventLoop` of the RPC and may be used to feed load information
+/// into custom load balancing logic, such as weighted round-robin.
+public final class ORCALoadReportInterceptor<Request, Response>:
+  ClientInterceptor<Request, Response> {
+  private let onLoadReport: (ORCALoadReport, String) -> Void
+
+  /// Creates a new interceptor.
+  ///
+  /// - Parameter onLoadReport: A callback invoked with each decoded load report and the path of
+  ///     the RPC it was received on.
+  public init(onLoadReport: @escaping (ORCALoadReport, String) -> Void) {
+    self.onLoadReport = onLoadReport
+  }
+
+  override public func receive(
+    _ part: GRPCClientResponsePart<Response>,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    if case let .end(_, trailers) = part,
+      let value = trailers.first(name: ORCALoadReport.trailerName) {
+      do {
+        let report = try ORCALoadReport(trailerValue: value)
+        self.onLoadReport(report, context.path)
+      } catch {
+        context.logger.debug("unable to decode ORCA load report", metadata: [
+          MetadataKey.error: "\(error)",
+        ])
+      }
+    }
+
+    context.receive(part)
+  }
+}
+
+extension GRPCChannel {
+  /// Starts an out-of-band stream of ORCA load reports from the
+  /// "xds.service.orca.v3.OpenRcaService" on the remote peer.
+  ///
+  /// The server sends a report at the requested interval until the RPC is cancelled.
+  ///
+  /// - Parameters:
+  ///   - request: The report request, including the reporting interval.
+  ///   - callOptions: Options for the RPC.
+  ///   - handler: A handler invoked with each load report received.
+  /// - Returns: A server streaming call.
+  public func makeORCALoadReportStream(
+    request: ORCALoadReportRequest,
+    callOptions: CallOptions = CallOptions(),
+    handler: @escaping (ORCALoadReport) -> Void
+  ) -> ServerStreamingCall<ORCALoadReportRequest, ORCALoadReport> {
+    return self.makeServerStreamingCall(
+      path: "/xds.service.orca.v3.OpenRcaService/StreamCoreMetrics",
+      request: request,
+      callOptions: callOptions,
+      handler: handler
+    )
+  }
+}
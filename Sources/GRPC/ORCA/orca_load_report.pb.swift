@@ -0,0 +1,159 @@
+// DO NOT EDIT.
+// swift-format-ignore-file
+//
+// Generated by the Swift generator plugin for the protocol buffer compiler.
+// Source: orca_load_report.proto
+//
+// For information on using the generated types, please see the documentation:
+//   https://github.com/apple/swift-protobuf/
+
+// Copyright 2021, gRPC Authors All rights reserved.
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//     http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The 'OrcaLoadReport' message from 'xds/data/orca/v3/orca_load_report.proto' in
+// https://github.com/cncf/xds, without the validation options.
+
+import Foundation
+import SwiftProtobuf
+
+// If the compiler emits an error on this type, it is because this file
+// was generated by a version of the `protoc` Swift plug-in that is
+// incompatible with the version of SwiftProtobuf to which you are linking.
+// Please ensure that you are building against the same version of the API
+// that was used to generate this file.
+fileprivate struct _GeneratedWithProtocGenSwiftVersion: SwiftProtobuf.ProtobufAPIVersionCheck {
+  struct _2: SwiftProtobuf.ProtobufAPIVersion_2 {}
+  typealias Version = _2
+}
+
+struct Xds_Data_Orca_V3_OrcaLoadReport {
+  // SwiftProtobuf.Message conformance is added in an extension below. See the
+  // `Message` and `Message+*Additions` files in the SwiftProtobuf library for
+  // methods supported on all messages.
+
+  /// CPU utilization expressed as a fraction of available CPU resources.
+  var cpuUtilization: Double = 0
+
+  /// Memory utilization expressed as a fraction of available memory resources.
+  var memUtilization: Double = 0
+
+  /// Total RPS being served by an endpoint. Deprecated in favor of 'rps_fractional'.
+  var rps: UInt64 = 0
+
+  /// Application specific requests costs.
+  var requestCost: Dictionary<String,Double> = [:]
+
+  /// Resource utilization values.
+  var utilization: Dictionary<String,Double> = [:]
+
+  /// Total RPS being served by an endpoint.
+  var rpsFractional: Double = 0
+
+  /// Total EPS (errors/second) being served by an endpoint.
+  var eps: Double = 0
+
+  /// Application specific opaque metrics.
+  var namedMetrics: Dictionary<String,Double> = [:]
+
+  /// Application specific utilization expressed as a fraction of available resources.
+  var applicationUtilization: Double = 0
+
+  var unknownFields = SwiftProtobuf.UnknownStorage()
+
+  init() {}
+}
+
+// MARK: - Code below here is support for the SwiftProtobuf runtime.
+
+fileprivate let _protobuf_package = "xds.data.orca.v3"
+
+extension Xds_Data_Orca_V3_OrcaLoadReport: SwiftProtobuf.Message, SwiftProtobuf._MessageImplementationBase, SwiftProtobuf._ProtoNameProviding {
+  static let protoMessageName: String = _protobuf_package + ".OrcaLoadReport"
+  static let _protobuf_nameMap: SwiftProtobuf._NameMap = [
+    1: .standard(proto: "cpu_utilization"),
+    2: .standard(proto: "mem_utilization"),
+    3: .same(proto: "rps"),
+    4: .standard(proto: "request_cost"),
+    5: .same(proto: "utilization"),
+    6: .standard(proto: "rps_fractional"),
+    7: .same(proto: "eps"),
+    8: .standard(proto: "named_metrics"),
+    9: .standard(proto: "application_utilization"),
+  ]
+
+  mutating func decodeMessage<D: SwiftProtobuf.Decoder>(decoder: inout D) throws {
+    while let fieldNumber = try decoder.nextFieldNumber() {
+      // The use of inline closures is to circumvent an issue where the compiler
+      // allocates stack space for every case branch when no optimizations are
+      // enabled. https://github.com/apple/swift-protobuf/issues/1034
+      switch fieldNumber {
+      case 1: try { try decoder.decodeSingularDoubleField(value: &self.cpuUtilization) }()
+      case 2: try { try decoder.decodeSingularDoubleField(value: &self.memUtilization) }()
+      case 3: try { try decoder.decodeSingularUInt64Field(value: &self.rps) }()
+      case 4: try { try decoder.decodeMapField(fieldType: SwiftProtobuf._ProtobufMap<SwiftProtobuf.ProtobufString,SwiftProtobuf.ProtobufDouble>.self, value: &self.requestCost) }()
+      case 5: try { try decoder.decodeMapField(fieldType: SwiftProtobuf._ProtobufMap<SwiftProtobuf.ProtobufString,SwiftProtobuf.ProtobufDouble>.self, value: &self.utilization) }()
+      case 6: try { try decoder.decodeSingularDoubleField(value: &self.rpsFractional) }()
+      case 7: try { try decoder.decodeSingularDoubleField(value: &self.eps) }()
+      case 8: try { try decoder.decodeMapField(fieldType: SwiftProtobuf._ProtobufMap<SwiftProtobuf.ProtobufString,SwiftProtobuf.ProtobufDouble>.self, value: &self.namedMetrics) }()
+      case 9: try { try decoder.decodeSingularDoubleField(value: &self.applicationUtilization) }()
+      default: break
+      }
+    }
+  }
+
+  func traverse<V: SwiftProtobuf.Visitor>(visitor: inout V) throws {
+    if self.cpuUtilization != 0 {
+      try visitor.visitSingularDoubleField(value: self.cpuUtilization, fieldNumber: 1)
+    }
+    if self.memUtilization != 0 {
+      try visitor.visitSingularDoubleField(value: self.memUtilization, fieldNumber: 2)
+    }
+    if self.rps != 0 {
+      try visitor.visitSingularUInt64Field(value: self.rps, fieldNumber: 3)
+    }
+    if !self.requestCost.isEmpty {
+      try visitor.visitMapField(fieldType: SwiftProtobuf._ProtobufMap<SwiftProtobuf.ProtobufString,SwiftProtobuf.ProtobufDouble>.self, value: self.requestCost, fieldNumber: 4)
+    }
+    if !self.utilization.isEmpty {
+      try visitor.visitMapField(fieldType: SwiftProtobuf._ProtobufMap<SwiftProtobuf.ProtobufString,SwiftProtobuf.ProtobufDouble>.self, value: self.utilization, fieldNumber: 5)
+    }
+    if self.rpsFractional != 0 {
+      try visitor.visitSingularDoubleField(value: self.rpsFractional, fieldNumber: 6)
+    }
+    if self.eps != 0 {
+      try visitor.visitSingularDoubleField(value: self.eps, fieldNumber: 7)
+    }
+    if !self.namedMetrics.isEmpty {
+      try visitor.visitMapField(fieldType: SwiftProtobuf._ProtobufMap<SwiftProtobuf.ProtobufString,SwiftProtobuf.ProtobufDouble>.self, value: self.namedMetrics, fieldNumber: 8)
+    }
+    if self.applicationUtilization != 0 {
+      try visitor.visitSingularDoubleField(value: self.applicationUtilization, fieldNumber: 9)
+    }
+    try unknownFields.traverse(visitor: &visitor)
+  }
+
+  static func ==(lhs: Xds_Data_Orca_V3_OrcaLoadReport, rhs: Xds_Data_Orca_V3_OrcaLoadReport) -> Bool {
+    if lhs.cpuUtilization != rhs.cpuUtilization {return false}
+    if lhs.memUtilization != rhs.memUtilization {return false}
+    if lhs.rps != rhs.rps {return false}
+    if lhs.requestCost != rhs.requestCost {return false}
+    if lhs.utilization != rhs.utilization {return false}
+    if lhs.rpsFractional != rhs.rpsFractional {return false}
+    if lhs.eps != rhs.eps {return false}
+    if lhs.namedMetrics != rhs.namedMetrics {return false}
+    if lhs.applicationUtilization != rhs.applicationUtilization {return false}
+    if lhs.unknownFields != rhs.unknownFields {return false}
+    return true
+  }
+}
@@ -0,0 +1,52 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+/// A serialized message observed at the boundary between gRPC and the HTTP/2 transport.
+///
+/// Observed messages are passed to the `debugMessageObserver` of a `ClientConnection` or `Server`
+/// configuration. They are intended for debugging and tooling such as recording and replaying
+/// traffic: no deserialization is required to produce them.
+public struct ObservedMessage {
+  /// The direction a message was travelling in, relative to the observer.
+  public enum Direction: Hashable {
+    /// The message was sent: a request sent by a client or a response sent by a server.
+    case outbound
+    /// The message was received: a response received by a client or a request received by a
+    /// server.
+    case inbound
+  }
+
+  /// The path of the RPC the message belongs to, e.g. "/echo.Echo/Get".
+  public var path: String
+
+  /// The direction of the message.
+  public var direction: Direction
+
+  /// The serialized message. This does not include the gRPC length-prefix and is never
+  /// compressed: inbound messages have already been decompressed and outbound messages have not
+  /// yet been compressed.
+  ///
+  /// The buffer shares its storage with the buffer used by gRPC so observing a message does not
+  /// copy it. Writing to the buffer will trigger a copy-on-write.
+  public var bytes: ByteBuffer
+
+  public init(path: String, direction: Direction, bytes: ByteBuffer) {
+    self.path = path
+    self.direction = direction
+    self.bytes = bytes
+  }
+}
@@ -0,0 +1,181 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIOConcurrencyHelpers
+import NIOHPACK
+import SwiftProtobuf
+
+/// A recording of a single RPC: the method, the serialized request and response messages, the
+/// response metadata and the status.
+///
+/// Recordings are made by a `RPCRecorder` and may be replayed by a `RPCReplayChannel`.
+public struct RPCRecording: Codable, Hashable {
+  /// A metadata header.
+  public struct Header: Codable, Hashable {
+    public var name: String
+    public var value: String
+
+    public init(name: String, value: String) {
+      self.name = name
+      self.value = value
+    }
+  }
+
+  /// The status the RPC ended with.
+  public struct Status: Codable, Hashable {
+    /// The raw value of the status code.
+    public var code: Int
+    public var message: String?
+
+    public init(_ status: GRPCStatus) {
+      self.code = status.code.rawValue
+      self.message = status.message
+    }
+
+    /// The recorded status as a `GRPCStatus`. Unrecognised codes are mapped to 'unknown'.
+    public var grpcStatus: GRPCStatus {
+      let code = GRPCStatus.Code(rawValue: self.code) ?? .unknown
+      return GRPCStatus(code: code, message: self.message)
+    }
+  }
+
+  /// The path of the RPC, e.g. "/echo.Echo/Get".
+  public var path: String
+
+  /// The serialized request messages, in the order they were sent.
+  public var requests: [Data]
+
+  /// The initial metadata sent by the server.
+  public var initialMetadata: [Header]
+
+  /// The serialized response messages, in the order they were received.
+  public var responses: [Data]
+
+  /// The trailing metadata sent by the server.
+  public var trailingMetadata: [Header]
+
+  /// The status of the RPC.
+  public var status: Status
+
+  public init(
+    path: String,
+    requests: [Data] = [],
+    initialMetadata: [Header] = [],
+    responses: [Data] = [],
+    trailingMetadata: [Header] = [],
+    status: Status = Status(.ok)
+  ) {
+    self.path = path
+    self.requests = requests
+    self.initialMetadata = initialMetadata
+    self.responses = responses
+    self.trailingMetadata = trailingMetadata
+    self.status = status
+  }
+}
+
+extension RPCRecording.Header {
+  internal static func headers(from metadata: HPACKHeaders) -> [RPCRecording.Header] {
+    return metadata.map { name, value, _ in
+      RPCRecording.Header(name: name, value: value)
+    }
+  }
+
+  internal static func metadata(from headers: [RPCRecording.Header]) -> HPACKHeaders {
+    return HPACKHeaders(headers.map { ($0.name, $0.value) })
+  }
+}
+
+extension RPCRecording {
+  /// Encodes the recordings as JSON. Keys are sorted (where supported) and the output is pretty
+  /// printed so that recordings are suitable for checking in as golden files.
+  public static func encode(_ recordings: [RPCRecording]) throws -> Data {
+    let encoder = JSONEncoder()
+    if #available(macOS 10.13, iOS 11.0, tvOS 11.0, watchOS 4.0, *) {
+      encoder.outputFormatting = [.prettyPrinted, .sortedKeys]
+    } else {
+      encoder.outputFormatting = [.prettyPrinted]
+    }
+    return try encoder.encode(recordings)
+  }
+
+  /// Decodes recordings previously encoded with `encode(_:)`.
+  public static func decode(_ data: Data) throws -> [RPCRecording] {
+    return try JSONDecoder().decode([RPCRecording].self, from: data)
+  }
+}
+
+/// Records RPCs made by clients for later replay with a `RPCReplayChannel`.
+///
+/// Each RPC to be recorded must use an interceptor made by `makeInterceptor()`. Once an RPC has
+/// completed its recording is appended to `recordings`. Recordings are held in memory until
+/// `save(to:)` is called, which should be done once the RPCs of interest have completed.
+/// Recordings are stored in the order in which RPCs complete: RPCs made concurrently should be
+/// avoided if the file is to be deterministic.
+///
+/// The recorder is thread safe.
+public final class RPCRecorder {
+  private var _recordings: [RPCRecording] = []
+  private let lock = Lock()
+
+  /// Creates a recorder.
+  public init() {}
+
+  /// The recordings made so far.
+  public var recordings: [RPCRecording] {
+    return self.lock.withLock {
+      self._recordings
+    }
+  }
+
+  /// Returns an interceptor which records an RPC using protobuf messages.
+  public func makeInterceptor<Request: SwiftProtobuf.Message, Response: SwiftProtobuf.Message>(
+  ) -> ClientInterceptor<Request, Response> {
+    return RecordingClientInterceptor(
+      recorder: self,
+      requestSerializer: ProtobufSerializer(),
+      responseSerializer: ProtobufSerializer()
+    )
+  }
+
+  /// Returns an interceptor which records an RPC using `GRPCPayload` messages.
+  public func makeInterceptor<Request: GRPCPayload, Response: GRPCPayload>(
+  ) -> ClientInterceptor<Request, Response> {
+    return RecordingClientInterceptor(
+      recorder: self,
+      requestSerializer: GRPCPayloadSerializer(),
+      responseSerializer: GRPCPayloadSerializer()
+    )
+  }
+
+  /// Writes the recordings made so far to a file, replacing its contents. The file may be replayed
+  /// with `RPCReplayChannel(contentsOfFile:)`.
+  ///
+  /// This does blocking I/O so shouldn't be called from an `EventLoop`.
+  ///
+  /// - Parameter path: The path of the file to write the recordings to.
+  public func save(to path: String) throws {
+    let data = try RPCRecording.encode(self.recordings)
+    try data.write(to: URL(fileURLWithPath: path), options: .atomic)
+  }
+
+  /// Appends a recording.
+  internal func append(_ recording: RPCRecording) {
+    self.lock.withLockVoid {
+      self._recordings.append(recording)
+    }
+  }
+}
@@ -0,0 +1,191 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOFoundationCompat
+import SwiftProtobuf
+
+/// A channel which replays RPCs recorded by a `RPCRecorder` rather than communicating with a
+/// server. This allows for golden tests of client behaviour without a live server.
+///
+/// Each RPC is matched against the recordings by its path and the serialized request messages.
+/// Because the request messages are part of the match, responses are only replayed once the
+/// client has ended the request stream: bidirectional streaming RPCs which wait for a response
+/// before sending further requests can not be replayed. If more than one recording matches then
+/// the first is used. RPCs which don't match a recording fail with status code 'unavailable'.
+///
+/// Like `FakeChannel`, calls avoid most of the gRPC stack and don't do real networking.
+public final class RPCReplayChannel: GRPCChannel {
+  /// The recordings to replay.
+  public let recordings: [RPCRecording]
+
+  /// Creates a channel which replays the given recordings.
+  public init(recordings: [RPCRecording]) {
+    self.recordings = recordings
+  }
+
+  /// Creates a channel which replays the recordings written to the given file by a `RPCRecorder`.
+  public convenience init(contentsOfFile path: String) throws {
+    let data = try Data(contentsOf: URL(fileURLWithPath: path))
+    try self.init(recordings: RPCRecording.decode(data))
+  }
+
+  public func makeCall<Request: SwiftProtobuf.Message, Response: SwiftProtobuf.Message>(
+    path: String,
+    type: GRPCCallType,
+    callOptions: CallOptions,
+    interceptors: [ClientInterceptor<Request, Response>]
+  ) -> Call<Request, Response> {
+    let stream = self.makeReplayStream(
+      path: path,
+      requestSerializer: ProtobufSerializer<Request>(),
+      responseDeserializer: ProtobufDeserializer<Response>()
+    )
+    return Call(
+      path: path,
+      type: type,
+      eventLoop: stream.channel.eventLoop,
+      options: callOptions,
+      interceptors: interceptors,
+      transportFactory: .fake(stream)
+    )
+  }
+
+  public func makeCall<Request: GRPCPayload, Response: GRPCPayload>(
+    path: String,
+    type: GRPCCallType,
+    callOptions: CallOptions,
+    interceptors: [ClientInterceptor<Request, Response>]
+  ) -> Call<Request, Response> {
+    let stream = self.makeReplayStream(
+      path: path,
+      requestSerializer: GRPCPayloadSerializer<Request>(),
+      responseDeserializer: GRPCPayloadDeserializer<Response>()
+    )
+    return Call(
+      path: path,
+      type: type,
+      eventLoop: stream.channel.eventLoop,
+      options: callOptions,
+      interceptors: interceptors,
+      transportFactory: .fake(stream)
+    )
+  }
+
+  private func makeReplayStream<
+    RequestSerializer: MessageSerializer,
+    ResponseDeserializer: MessageDeserializer
+  >(
+    path: String,
+    requestSerializer: RequestSerializer,
+    responseDeserializer: ResponseDeserializer
+  ) -> FakeStreamingResponse<RequestSerializer.Input, ResponseDeserializer.Output> {
+    let replay = Replay(
+      path: path,
+      recordings: self.recordings,
+      requestSerializer: requestSerializer,
+      responseDeserializer: responseDeserializer
+    )
+    let stream = FakeStreamingResponse<RequestSerializer.Input, ResponseDeserializer.Output> {
+      replay.handle($0)
+    }
+    replay.stream = stream
+    return stream
+  }
+
+  public func close() -> EventLoopFuture<Void> {
+    // We don't have anything to close.
+    return EmbeddedEventLoop().makeSucceededFuture(())
+  }
+}
+
+/// Collects the request messages of a single RPC and, once the request stream has ended, responds
+/// with the matching recording.
+private final class Replay<Request, Response> {
+  private let path: String
+  private let recordings: [RPCRecording]
+  private let requestSerializer: AnySerializer<Request>
+  private let responseDeserializer: AnyDeserializer<Response>
+  private let allocator = ByteBufferAllocator()
+
+  /// The serialized requests received so far.
+  private var requests: [Data] = []
+
+  /// The stream to respond on. The stream owns the request handler which owns this object so the
+  /// reference must be weak.
+  weak var stream: FakeStreamingResponse<Request, Response>?
+
+  init<RequestSerializer: MessageSerializer, ResponseDeserializer: MessageDeserializer>(
+    path: String,
+    recordings: [RPCRecording],
+    requestSerializer: RequestSerializer,
+    responseDeserializer: ResponseDeserializer
+  ) where RequestSerializer.Input == Request, ResponseDeserializer.Output == Response {
+    self.path = path
+    self.recordings = recordings
+    self.requestSerializer = AnySerializer(wrapping: requestSerializer)
+    self.responseDeserializer = AnyDeserializer(wrapping: responseDeserializer)
+  }
+
+  func handle(_ part: FakeRequestPart<Request>) {
+    guard let stream = self.stream else {
+      return
+    }
+
+    do {
+      switch part {
+      case .metadata:
+        ()
+
+      case let .message(request):
+        var buffer = try self.requestSerializer.serialize(request, allocator: self.allocator)
+        // '!' is okay; we can always read 'readableBytes'.
+        self.requests.append(buffer.readData(length: buffer.readableBytes)!)
+
+      case .end:
+        try self.respond(on: stream)
+      }
+    } catch {
+      try? stream.sendError(error)
+    }
+  }
+
+  private func respond(on stream: FakeStreamingResponse<Request, Response>) throws {
+    let recording = self.recordings.first {
+      $0.path == self.path && $0.requests == self.requests
+    }
+
+    guard let match = recording else {
+      let status = GRPCStatus(
+        code: .unavailable,
+        message: "No recording matches the RPC to '\(self.path)'"
+      )
+      try stream.sendEnd(status: status)
+      return
+    }
+
+    try stream.sendInitialMetadata(RPCRecording.Header.metadata(from: match.initialMetadata))
+    for response in match.responses {
+      var buffer = self.allocator.buffer(capacity: response.count)
+      buffer.writeBytes(response)
+      try stream.sendMessage(self.responseDeserializer.deserialize(byteBuffer: buffer))
+    }
+    try stream.sendEnd(
+      status: match.status.grpcStatus,
+      trailingMetadata: RPCRecording.Header.metadata(from: match.trailingMetadata)
+    )
+  }
+}
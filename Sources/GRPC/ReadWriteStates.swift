@@ -161,6 +161,12 @@ enum ReadState {
         if let grpcError = error as? GRPCError.WithContext,
           let limitExceeded = grpcError.error as? GRPCError.DecompressionLimitExceeded {
           return .failure(.decompressionLimitExceeded(limitExceeded.compressedSize))
+        } else if let grpcError = error as? GRPCError.WithContext,
+          let limitExceeded = grpcError.error as? GRPCError.PayloadLengthLimitExceeded {
+          return .failure(.payloadLengthLimitExceeded(
+            actualLength: limitExceeded.actualLength,
+            limit: limitExceeded.limit
+          ))
         } else {
           return .failure(.deserializationFailed)
         }
@@ -209,6 +215,9 @@ enum MessageReadError: Error, Equatable {
   /// The limit for decompression was exceeded.
   case decompressionLimitExceeded(Int)
 
+  /// The length of a message exceeded the maximum allowed message length.
+  case payloadLengthLimitExceeded(actualLength: Int, limit: Int)
+
   /// An invalid state was encountered. This is a serious implementation error.
   case invalidState
 }
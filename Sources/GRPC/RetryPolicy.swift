@@ -0,0 +1,270 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import NIO
+import NIOConcurrencyHelpers
+import NIOHPACK
+
+/// A policy for retrying failed unary RPCs.
+///
+/// gRPC Swift can't know whether an RPC is idempotent so it never retries RPCs automatically.
+/// Instead, unary RPCs which are safe to retry may be made with `retrying(_:)`, which makes the
+/// RPC again if it fails with a retryable status:
+///
+/// ```
+/// let policy = RetryPolicy(maximumAttempts: 3, retryableStatusCodes: [.unavailable])
+/// let response = policy.retrying {
+///   client.get(request)
+/// }
+/// ```
+///
+/// By default a failed attempt is retried if its status code is one of `retryableStatusCodes`,
+/// after an exponential backoff with jitter: the delay before the `n`th retry is chosen at random
+/// between zero and `initialBackoff * backoffMultiplier^(n-1)`, capped at `maximumBackoff`. If the
+/// server sent a `google.rpc.RetryInfo` in the status details of the failed attempt (see
+/// `GRPCStatusDetails.retryDelay(in:)`) then its delay is used instead of the backoff.
+///
+/// A `decider` may be provided to decide instead, for example based on the trailing metadata of
+/// the failed attempt. Regardless of the decision, no more than `maximumAttempts` attempts are
+/// made and, if the policy has a `throttle`, retries stop while the throttle doesn't permit them.
+public struct RetryPolicy {
+  /// The maximum number of attempts to make, including the first. Values less than one are
+  /// treated as one. Defaults to 3.
+  public var maximumAttempts: Int
+
+  /// The upper bound of the delay before the first retry. Defaults to 100 milliseconds.
+  public var initialBackoff: TimeAmount
+
+  /// The upper bound of the delay before any retry. Defaults to 10 seconds.
+  public var maximumBackoff: TimeAmount
+
+  /// The factor the upper bound of the delay grows by after each retry. Defaults to 2.
+  public var backoffMultiplier: Double
+
+  /// Status codes which are retried if there's no `decider`. Defaults to 'unavailable'.
+  public var retryableStatusCodes: Set<GRPCStatus.Code>
+
+  /// Limits retries when many RPCs are failing. Defaults to `nil`, retries are not throttled.
+  public var throttle: RetryThrottle?
+
+  /// Decides whether to retry a failed attempt instead of `retryableStatusCodes`. Defaults to
+  /// `nil`.
+  public var decider: RetryDecider?
+
+  public init(
+    maximumAttempts: Int = 3,
+    initialBackoff: TimeAmount = .milliseconds(100),
+    maximumBackoff: TimeAmount = .seconds(10),
+    backoffMultiplier: Double = 2,
+    retryableStatusCodes: Set<GRPCStatus.Code> = [.unavailable],
+    throttle: RetryThrottle? = nil,
+    decider: RetryDecider? = nil
+  ) {
+    self.maximumAttempts = maximumAttempts
+    self.initialBackoff = initialBackoff
+    self.maximumBackoff = maximumBackoff
+    self.backoffMultiplier = backoffMultiplier
+    self.retryableStatusCodes = retryableStatusCodes
+    self.throttle = throttle
+    self.decider = decider
+  }
+}
+
+/// Decides whether a failed attempt of an RPC should be retried. It may be called from any
+/// thread.
+///
+/// - Parameters:
+///   - status: The status of the failed attempt.
+///   - trailers: The trailing metadata of the failed attempt, empty if none was received.
+///   - attempt: The number of the failed attempt, starting at 1.
+public typealias RetryDecider = (
+  _ status: GRPCStatus,
+  _ trailers: HPACKHeaders,
+  _ attempt: Int
+) -> RetryDecision
+
+/// Whether, and when, a failed attempt of an RPC should be retried.
+public struct RetryDecision: Hashable {
+  internal enum Decision: Hashable {
+    case doNotRetry
+    case retry
+    case retryAfter(TimeAmount)
+  }
+
+  internal var wrapped: Decision
+  private init(_ wrapped: Decision) {
+    self.wrapped = wrapped
+  }
+
+  /// The attempt is not retried: the RPC fails with its error.
+  public static let doNotRetry = RetryDecision(.doNotRetry)
+
+  /// The attempt is retried after the delay requested by the server in a `google.rpc.RetryInfo`,
+  /// if there is one, or the backoff computed by the policy otherwise.
+  public static let retry = RetryDecision(.retry)
+
+  /// The attempt is retried after the given delay rather than the backoff computed by the policy.
+  public static func retry(after delay: TimeAmount) -> RetryDecision {
+    return RetryDecision(.retryAfter(delay))
+  }
+}
+
+/// Limits retries while many RPCs are failing, so that retries don't add to the load of a server
+/// which is already struggling. A throttle should be shared by the retry policies of all RPCs made
+/// to the same server.
+///
+/// The throttle starts with `maximumTokens` tokens. Each failed attempt which would be retried
+/// removes one token and each successful RPC adds `tokenRatio` tokens, up to `maximumTokens`.
+/// Retries are only permitted while there are more than half of `maximumTokens` tokens.
+///
+/// The throttle is thread safe.
+public final class RetryThrottle {
+  /// The maximum number of tokens.
+  public let maximumTokens: Int
+
+  /// The number of tokens added for each successful RPC.
+  public let tokenRatio: Double
+
+  /// The number of tokens available. Protected by `lock`.
+  private var tokens: Double
+  private let lock = Lock()
+
+  /// Creates a throttle.
+  ///
+  /// - Parameters:
+  ///   - maximumTokens: The maximum number of tokens, at least one. Defaults to 10.
+  ///   - tokenRatio: The number of tokens added for each successful RPC, which must not be
+  ///       negative. Defaults to 0.1.
+  public init(maximumTokens: Int = 10, tokenRatio: Double = 0.1) {
+    self.maximumTokens = max(maximumTokens, 1)
+    self.tokenRatio = max(tokenRatio, 0)
+    self.tokens = Double(self.maximumTokens)
+  }
+
+  /// Whether retries are currently permitted.
+  public var isRetryPermitted: Bool {
+    return self.lock.withLock {
+      self.tokens > Double(self.maximumTokens) / 2
+    }
+  }
+
+  /// Records a failed attempt which would be retried and returns whether the retry is permitted.
+  internal func recordFailure() -> Bool {
+    return self.lock.withLock {
+      self.tokens = max(self.tokens - 1, 0)
+      return self.tokens > Double(self.maximumTokens) / 2
+    }
+  }
+
+  /// Records a successful RPC.
+  internal func recordSuccess() {
+    self.lock.withLockVoid {
+      self.tokens = min(self.tokens + self.tokenRatio, Double(self.maximumTokens))
+    }
+  }
+}
+
+extension RetryPolicy {
+  /// Makes a unary RPC with `makeCall` and retries it according to the policy.
+  ///
+  /// The RPC must be idempotent: the server may have processed an attempt which failed.
+  ///
+  /// - Parameter makeCall: Makes an attempt of the RPC, called at most `maximumAttempts` times.
+  /// - Returns: The response of the first successful attempt, or the error of the last attempt.
+  public func retrying<Request, Response>(
+    _ makeCall: @escaping () -> UnaryCall<Request, Response>
+  ) -> EventLoopFuture<Response> {
+    return self.makeAttempt(1, makeCall)
+  }
+
+  private func makeAttempt<Request, Response>(
+    _ attempt: Int,
+    _ makeCall: @escaping () -> UnaryCall<Request, Response>
+  ) -> EventLoopFuture<Response> {
+    let call = makeCall()
+    return call.response.map { response in
+      self.throttle?.recordSuccess()
+      return response
+    }.flatMapError { error in
+      // The trailing metadata fails if the RPC failed without receiving any (e.g. because of a
+      // transport error) but the status never fails.
+      let trailers = call.trailingMetadata.recover { _ in [:] }
+      return call.status.and(trailers).flatMap { status, trailers in
+        guard let delay = self.delayBeforeRetrying(
+          afterAttempt: attempt,
+          status: status,
+          trailers: trailers
+        ) else {
+          return call.eventLoop.makeFailedFuture(error)
+        }
+
+        return call.eventLoop.scheduleTask(in: delay) {
+          self.makeAttempt(attempt + 1, makeCall)
+        }.futureResult.flatMap { $0 }
+      }
+    }
+  }
+
+  /// Returns the delay before retrying the given failed attempt, or `nil` if it shouldn't be
+  /// retried.
+  internal func delayBeforeRetrying(
+    afterAttempt attempt: Int,
+    status: GRPCStatus,
+    trailers: HPACKHeaders
+  ) -> TimeAmount? {
+    let decision: RetryDecision
+    if let decider = self.decider {
+      decision = decider(status, trailers, attempt)
+    } else if self.retryableStatusCodes.contains(status.code) {
+      decision = .retry
+    } else {
+      decision = .doNotRetry
+    }
+
+    let delay: TimeAmount
+    switch decision.wrapped {
+    case .doNotRetry:
+      return nil
+    case .retry:
+      delay = GRPCStatusDetails.retryDelay(in: trailers) ?? self.backoff(afterAttempt: attempt)
+    case let .retryAfter(requested):
+      delay = max(requested, .nanoseconds(0))
+    }
+
+    // Failures count against the throttle even if this was the last attempt.
+    if let throttle = self.throttle, !throttle.recordFailure() {
+      return nil
+    }
+
+    return attempt < self.maximumAttempts ? delay : nil
+  }
+
+  /// Returns a random backoff before retrying the given failed attempt.
+  internal func backoff(afterAttempt attempt: Int) -> TimeAmount {
+    let upperBound = min(
+      Double(self.initialBackoff.nanoseconds) * pow(self.backoffMultiplier, Double(attempt - 1)),
+      Double(self.maximumBackoff.nanoseconds)
+    )
+
+    guard upperBound > 0 else {
+      return .nanoseconds(0)
+    }
+
+    let backoff = Double.random(in: 0 ... upperBound)
+    // 'Int64.max' isn't exactly representable as a 'Double', the conversion must be guarded.
+    return backoff < Double(Int64.max) ? .nanoseconds(Int64(backoff)) : self.maximumBackoff
+  }
+}
@@ -0,0 +1,164 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+
+/// A writer which may be shared between multiple concurrent producers of messages for a single
+/// RPC.
+///
+/// Messages written to an RPC (via `sendMessage` on a client call or `sendResponse` on a server
+/// call context) are always framed atomically: each message is written in its entirety on the
+/// RPC's `EventLoop`. However, the *order* in which messages written from different threads are
+/// sent is only well defined if each producer is on the `EventLoop` or each producer is off the
+/// `EventLoop`: writes made on the `EventLoop` are performed immediately while writes made from
+/// other threads are enqueued.
+///
+/// `SerialRPCWriter` removes this subtlety by enqueuing every write and performing them on the
+/// `EventLoop` in exactly the order in which `write` was called, regardless of the calling thread.
+/// This is useful when fanning messages in from multiple sources into a single response or request
+/// stream.
+///
+/// - Important: Writes made directly to the underlying call or context are not ordered with
+///   respect to writes made via this writer. Once a writer has been created all messages should be
+///   written through it.
+public final class SerialRPCWriter<Message> {
+  /// The `EventLoop` of the underlying RPC. Writes are performed on this event loop.
+  public let eventLoop: EventLoop
+
+  /// Performs a write on the underlying RPC.
+  private let _write: (Message, Compression, EventLoopPromise<Void>?) -> Void
+
+  /// Writes which have not yet been performed. Protected by `lock`.
+  private var pending: CircularBuffer<PendingWrite>
+
+  /// Whether a task to drain `pending` has been submitted to the event loop. Protected by `lock`.
+  private var isDraining: Bool
+
+  private let lock = Lock()
+
+  private struct PendingWrite {
+    var message: Message
+    var compression: Compression
+    var promise: EventLoopPromise<Void>?
+  }
+
+  private init(
+    eventLoop: EventLoop,
+    write: @escaping (Message, Compression, EventLoopPromise<Void>?) -> Void
+  ) {
+    self.eventLoop = eventLoop
+    self._write = write
+    self.pending = CircularBuffer()
+    self.isDraining = false
+  }
+
+  /// Create a writer which sends responses on the given server call context.
+  ///
+  /// - Parameter context: The context of a server streaming or bidirectional streaming RPC.
+  public convenience init(wrapping context: StreamingResponseCallContext<Message>) {
+    self.init(eventLoop: context.eventLoop) { message, compression, promise in
+      context.sendResponse(message, compression: compression, promise: promise)
+    }
+  }
+
+  /// Create a writer which sends requests on the given client call.
+  ///
+  /// - Parameter call: A client streaming or bidirectional streaming call.
+  public convenience init<Call: StreamingRequestClientCall>(
+    wrapping call: Call
+  ) where Call.RequestPayload == Message {
+    self.init(eventLoop: call.eventLoop) { message, compression, promise in
+      call.sendMessage(message, compression: compression, promise: promise)
+    }
+  }
+
+  /// Write a message. Messages are written in the order in which this function is called.
+  ///
+  /// This function may be called from any thread.
+  ///
+  /// - Parameters:
+  ///   - message: The message to write.
+  ///   - compression: Whether compression should be used for this message. Defaults to deferring
+  ///     to the value set on the underlying call.
+  ///   - promise: A promise to complete once the message has been written.
+  public func write(
+    _ message: Message,
+    compression: Compression = .deferToCallDefault,
+    promise: EventLoopPromise<Void>?
+  ) {
+    let write = PendingWrite(message: message, compression: compression, promise: promise)
+
+    let shouldDrain: Bool = self.lock.withLock {
+      self.pending.append(write)
+      if self.isDraining {
+        return false
+      } else {
+        self.isDraining = true
+        return true
+      }
+    }
+
+    if shouldDrain {
+      self.eventLoop.execute {
+        self.drain()
+      }
+    }
+  }
+
+  /// Write a message. Messages are written in the order in which this function is called.
+  ///
+  /// This function may be called from any thread.
+  ///
+  /// - Parameters:
+  ///   - message: The message to write.
+  ///   - compression: Whether compression should be used for this message. Defaults to deferring
+  ///     to the value set on the underlying call.
+  /// - Returns: A future which will be completed once the message has been written.
+  public func write(
+    _ message: Message,
+    compression: Compression = .deferToCallDefault
+  ) -> EventLoopFuture<Void> {
+    let promise = self.eventLoop.makePromise(of: Void.self)
+    self.write(message, compression: compression, promise: promise)
+    return promise.futureResult
+  }
+
+  /// Performs all pending writes, in order.
+  private func drain() {
+    self.eventLoop.assertInEventLoop()
+
+    while true {
+      let writes: CircularBuffer<PendingWrite>? = self.lock.withLock {
+        if self.pending.isEmpty {
+          self.isDraining = false
+          return nil
+        } else {
+          let writes = self.pending
+          self.pending.removeAll(keepingCapacity: true)
+          return writes
+        }
+      }
+
+      guard let toWrite = writes else {
+        return
+      }
+
+      for write in toWrite {
+        self._write(write.message, write.compression, write.promise)
+      }
+    }
+  }
+}
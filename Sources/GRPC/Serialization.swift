@@ -37,6 +37,25 @@ public protocol MessageDeserializer {
   /// - Parameter byteBuffer: The `ByteBuffer` to deserialize.
   @inlinable
   func deserialize(byteBuffer: ByteBuffer) throws -> Output
+
+  /// Deserializes `byteBuffer` to produce a single `Output`, handling any unknown fields as
+  /// specified. Deserializers which don't have a notion of unknown fields ignore `unknownFields`.
+  ///
+  /// - Parameters:
+  ///   - byteBuffer: The `ByteBuffer` to deserialize.
+  ///   - unknownFields: How unknown fields should be handled.
+  @inlinable
+  func deserialize(byteBuffer: ByteBuffer, unknownFields: UnknownFieldHandling) throws -> Output
+}
+
+extension MessageDeserializer {
+  @inlinable
+  public func deserialize(
+    byteBuffer: ByteBuffer,
+    unknownFields: UnknownFieldHandling
+  ) throws -> Output {
+    return try self.deserialize(byteBuffer: byteBuffer)
+  }
 }
 
 // MARK: Protobuf
@@ -75,6 +94,39 @@ public struct ProtobufDeserializer<Message: SwiftProtobuf.Message>: MessageDeser
     let data = buffer.readData(length: buffer.readableBytes)!
     return try Message(serializedData: data)
   }
+
+  @inlinable
+  public func deserialize(
+    byteBuffer: ByteBuffer,
+    unknownFields: UnknownFieldHandling
+  ) throws -> Message {
+    var buffer = byteBuffer
+    // '!' is okay; we can always read 'readableBytes'.
+    let data = buffer.readData(length: buffer.readableBytes)!
+
+    switch unknownFields.wrapped {
+    case .preserve:
+      return try Message(serializedData: data)
+
+    case .drop:
+      var options = BinaryDecodingOptions()
+      options.discardUnknownFields = true
+      return try Message(serializedData: data, options: options)
+
+    case .reject:
+      // Unknown fields may be nested, so compare against the message with them discarded.
+      var options = BinaryDecodingOptions()
+      options.discardUnknownFields = true
+      let message = try Message(serializedData: data, options: options)
+      guard message.isEqualTo(message: try Message(serializedData: data)) else {
+        throw GRPCStatus(
+          code: .invalidArgument,
+          message: "Request message of type '\(Message.protoMessageName)' has unknown fields"
+        )
+      }
+      return message
+    }
+  }
 }
 
 // MARK: GRPCPayload
@@ -0,0 +1,177 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Dispatch
+import Foundation
+import NIO
+
+extension Server {
+  /// Installs handlers for the given signals which initiate a graceful shutdown of the server.
+  ///
+  /// When one of the signals is received the server stops accepting new connections and RPCs
+  /// while allowing existing RPCs to run to completion (see `initiateGracefulShutdown()`). If
+  /// the server hasn't shut down within `gracePeriod`, or a second signal is received, then the
+  /// server is closed immediately. This is intended for deployments which signal a process before
+  /// terminating it, such as containers managed by Kubernetes: `gracePeriod` should be less than
+  /// the termination grace period of the pod.
+  ///
+  /// The disposition of each signal is replaced while the server is running: the process will not
+  /// terminate when receiving them. Progress is logged using the server's logger.
+  ///
+  /// Handlers may only be installed once per server, subsequent calls have no effect. Handlers
+  /// are removed when the server closes, at which point the disposition each signal had before
+  /// the handlers were installed is restored. The handlers are ready when this function returns:
+  /// signals received afterwards are never missed.
+  ///
+  /// - Parameters:
+  ///   - signals: The signals to handle. Defaults to `SIGTERM` and `SIGINT`.
+  ///   - gracePeriod: The amount of time to allow for RPCs to complete before closing the
+  ///       server. Defaults to 30 seconds.
+  public func initiateGracefulShutdownOnSignals(
+    _ signals: [Int32] = [SIGTERM, SIGINT],
+    gracePeriod: TimeAmount = .seconds(30)
+  ) {
+    let registered: DispatchGroup? = self.signalLock.withLock {
+      if self.signalHandlers != nil {
+        return nil
+      }
+
+      let queue = DispatchQueue(label: "io.grpc.server.signals")
+      let shutdown = SignalTriggeredShutdown(server: self, gracePeriod: gracePeriod)
+      let registered = DispatchGroup()
+
+      self.signalHandlers = signals.map { signalNumber in
+        // Keep the current action so that it can be restored when the server closes.
+        var previousAction = sigaction()
+        sigaction(signalNumber, nil, &previousAction)
+
+        // Ignore the signal so that the default action (usually terminating the process) isn't
+        // taken; the dispatch source is still notified.
+        signal(signalNumber, SIG_IGN)
+        let source = DispatchSource.makeSignalSource(signal: signalNumber, queue: queue)
+        source.setEventHandler {
+          shutdown.signalReceived(signalNumber)
+        }
+        registered.enter()
+        source.setRegistrationHandler {
+          registered.leave()
+        }
+        source.resume()
+        return InstalledSignalHandler(
+          signal: signalNumber,
+          source: source,
+          previousAction: previousAction
+        )
+      }
+
+      return registered
+    }
+
+    guard let sourcesRegistered = registered else {
+      self.logger.debug("signal handlers for graceful shutdown have already been installed")
+      return
+    }
+
+    // Sources are registered asynchronously once resumed, a signal received before then would be
+    // missed.
+    sourcesRegistered.wait()
+
+    self.logger.debug("installed signal handlers for graceful shutdown", metadata: [
+      "signals": "\(signals)",
+    ])
+
+    self.onClose.whenComplete { _ in
+      self.removeSignalHandlers()
+    }
+  }
+
+  private func removeSignalHandlers() {
+    let handlers: [InstalledSignalHandler] = self.signalLock.withLock {
+      let handlers = self.signalHandlers ?? []
+      self.signalHandlers = []
+      return handlers
+    }
+
+    for handler in handlers {
+      handler.source.cancel()
+      var previousAction = handler.previousAction
+      sigaction(handler.signal, &previousAction, nil)
+    }
+  }
+}
+
+/// A signal handler installed by `initiateGracefulShutdownOnSignals(_:gracePeriod:)`.
+internal struct InstalledSignalHandler {
+  /// The signal number.
+  var signal: Int32
+
+  /// The source notified when the signal is received.
+  var source: DispatchSourceSignal
+
+  /// The action for the signal before the handler was installed.
+  var previousAction: sigaction
+}
+
+/// Drives a graceful shutdown triggered by signals. All methods are called on a serial queue.
+private final class SignalTriggeredShutdown {
+  private let server: Server
+  private let gracePeriod: TimeAmount
+  private var forceClose: Scheduled<Void>?
+  private var isShuttingDown = false
+
+  init(server: Server, gracePeriod: TimeAmount) {
+    self.server = server
+    self.gracePeriod = gracePeriod
+  }
+
+  func signalReceived(_ signalNumber: Int32) {
+    let logger = self.server.logger
+
+    if self.isShuttingDown {
+      logger.notice("received signal during graceful shutdown, closing server", metadata: [
+        "signal": "\(signalNumber)",
+      ])
+      self.forceClose?.cancel()
+      self.server.close(promise: nil)
+      return
+    }
+
+    self.isShuttingDown = true
+    logger.notice("received signal, initiating graceful shutdown", metadata: [
+      "signal": "\(signalNumber)",
+      "grace_period_ms": "\(self.gracePeriod.nanoseconds / 1_000_000)",
+    ])
+
+    let eventLoop = self.server.channel.eventLoop
+    let server = self.server
+    let gracePeriod = self.gracePeriod
+
+    self.forceClose = eventLoop.scheduleTask(in: gracePeriod) {
+      logger.warning("graceful shutdown did not complete within grace period, closing server")
+      server.close(promise: nil)
+    }
+
+    let forceClose = self.forceClose
+    server.initiateGracefulShutdown().whenComplete { result in
+      forceClose?.cancel()
+      switch result {
+      case .success:
+        logger.notice("graceful shutdown complete")
+      case let .failure(error):
+        logger.error("graceful shutdown failed", metadata: [MetadataKey.error: "\(error)"])
+      }
+    }
+  }
+}
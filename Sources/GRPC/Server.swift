@@ -16,6 +16,7 @@
 import Foundation
 import Logging
 import NIO
+import NIOConcurrencyHelpers
 import NIOExtras
 import NIOHTTP1
 import NIOHTTP2
@@ -91,28 +92,52 @@ public final class Server {
       _ = bootstrap.serverChannelOption(ChannelOptions.backlog, value: 256)
     }
 
+    return bootstrap
+      // By default `SO_REUSEADDR` is enabled to avoid "address already in use" errors and
+      // `TCP_NODELAY` is enabled for accepted channels.
+      .socketOptions(configuration.socketOptions)
+      // Set the handlers that are applied to the accepted Channels
+      .childChannelInitializer(
+        self.makeChildChannelInitializer(configuration: configuration, bootstrap: bootstrap)
+      )
+  }
+
+  /// Makes an initializer which configures the pipeline of an accepted connection. If TLS is
+  /// provided by Network.framework then its options are applied to the `bootstrap`; connections
+  /// accepted elsewhere (i.e. when `bootstrap` is `nil`) fail to initialize in that case.
+  private static func makeChildChannelInitializer(
+    configuration: Configuration,
+    bootstrap: ServerBootstrapProtocol?
+  ) -> (Channel) -> EventLoopFuture<Void> {
     // Making a `NIOSSLContext` is expensive, we should only do it once per TLS configuration so
     // we'll do it now, before accepting connections. Unfortunately our API isn't throwing so we'll
     // only surface any error when initializing a child channel.
     //
     // 'nil' means we're not using TLS, or we're using the Network.framework TLS backend. If we're
     // using the Network.framework TLS backend we'll apply the settings just below.
-    let sslContext: Result<NIOSSLContext, Error>?
+    var sslContexts: Result<NIOSSLServerContexts, Error>?
 
     if let tlsConfiguration = configuration.tlsConfiguration {
       do {
-        sslContext = try configuration.tlsConfiguration?.makeNIOSSLContext().map { .success($0) }
+        sslContexts = try tlsConfiguration.makeNIOSSLServerContexts().map { .success($0) }
       } catch {
-        sslContext = .failure(error)
+        sslContexts = .failure(error)
       }
 
       // No SSL context means we must be using the Network.framework TLS stack (as
       // `tlsConfiguration` was not `nil`).
-      if sslContext == nil {
+      if sslContexts == nil {
         #if canImport(Network)
         if #available(OSX 10.14, iOS 12.0, tvOS 12.0, watchOS 6.0, *),
           let transportServicesBootstrap = bootstrap as? NIOTSListenerBootstrap {
           _ = transportServicesBootstrap.tlsOptions(from: tlsConfiguration)
+        } else {
+          // Network.framework TLS can only be applied by its bootstrap.
+          sslContexts = .failure(
+            GRPCError.InvalidState(
+              "Network.framework TLS is only supported for connections accepted by the server"
+            )
+          )
         }
         #else
         // We must be using Network.framework (because we aren't using NIOSSL) but we don't have
@@ -122,92 +147,236 @@ public final class Server {
       }
     } else {
       // No TLS configuration, no SSL context.
-      sslContext = nil
+      sslContexts = nil
     }
 
-    return bootstrap
-      // Enable `SO_REUSEADDR` to avoid "address already in use" error.
-      .serverChannelOption(
-        ChannelOptions.socket(SocketOptionLevel(SOL_SOCKET), SO_REUSEADDR),
-        value: 1
-      )
-      // Set the handlers that are applied to the accepted Channels
-      .childChannelInitializer { channel in
-        var configuration = configuration
-        configuration.logger[metadataKey: MetadataKey.connectionID] = "\(UUID().uuidString)"
-        configuration.logger.addIPAddressMetadata(
-          local: channel.localAddress,
-          remote: channel.remoteAddress
-        )
+    // Connection events for every connection are delivered on the same queue.
+    let connectionEventDelegateQueue = configuration.connectionEventDelegate.map { _ in
+      configuration.connectionEventDelegateQueue ?? DispatchQueue(label: "io.grpc.server-events")
+    }
 
-        do {
-          let sync = channel.pipeline.syncOperations
-          if let sslContext = try sslContext?.get() {
-            try sync.addHandler(NIOSSLServerHandler(context: sslContext))
-          }
+    return { channel in
+      var configuration = configuration
+      configuration.logger[metadataKey: MetadataKey.connectionID] = "\(UUID().uuidString)"
+      configuration.logger.addIPAddressMetadata(
+        local: channel.localAddress,
+        remote: channel.remoteAddress
+      )
 
-          // Configures the pipeline based on whether the connection uses TLS or not.
-          try sync.addHandler(GRPCServerPipelineConfigurator(configuration: configuration))
+      do {
+        let sync = channel.pipeline.syncOperations
+        let configurator = GRPCServerPipelineConfigurator(configuration: configuration)
+        let tls = try sslContexts?.get()
+
+        // Observes the connection, must be after the TLS handler to see handshake events.
+        let eventHandler: ServerConnectionEventHandler?
+        if let delegate = configuration.connectionEventDelegate,
+          let queue = connectionEventDelegateQueue {
+          eventHandler = ServerConnectionEventHandler(
+            delegate: delegate,
+            queue: queue,
+            expectsHandshake: tls != nil
+          )
+        } else {
+          eventHandler = nil
+        }
 
-          // Work around the zero length write issue, if needed.
-          let requiresZeroLengthWorkaround = PlatformSupport.requiresZeroLengthWriteWorkaround(
-            group: configuration.eventLoopGroup,
-            hasTLS: configuration.tlsConfiguration != nil
+        if let tls = tls {
+          try tls.configureTLS(
+            on: channel,
+            before: eventHandler ?? configurator,
+            logger: configuration.logger
           )
-          if requiresZeroLengthWorkaround,
-            #available(OSX 10.14, iOS 12.0, tvOS 12.0, watchOS 6.0, *) {
-            try sync.addHandler(NIOFilterEmptyWritesHandler())
-          }
-        } catch {
-          return channel.eventLoop.makeFailedFuture(error)
         }
 
-        // Run the debug initializer, if there is one.
-        if let debugAcceptedChannelInitializer = configuration.debugChannelInitializer {
-          return debugAcceptedChannelInitializer(channel)
-        } else {
-          return channel.eventLoop.makeSucceededVoidFuture()
+        if let eventHandler = eventHandler {
+          try sync.addHandler(eventHandler)
+        }
+
+        // Configures the pipeline based on whether the connection uses TLS or not.
+        try sync.addHandler(configurator)
+
+        // Work around the zero length write issue, if needed.
+        let requiresZeroLengthWorkaround = PlatformSupport.requiresZeroLengthWriteWorkaround(
+          group: configuration.eventLoopGroup,
+          hasTLS: configuration.tlsConfiguration != nil
+        )
+        if requiresZeroLengthWorkaround,
+          #available(OSX 10.14, iOS 12.0, tvOS 12.0, watchOS 6.0, *) {
+          try sync.addHandler(NIOFilterEmptyWritesHandler())
         }
+      } catch {
+        return channel.eventLoop.makeFailedFuture(error)
       }
 
-      // Enable TCP_NODELAY and SO_REUSEADDR for the accepted Channels
-      .childChannelOption(ChannelOptions.socket(IPPROTO_TCP, TCP_NODELAY), value: 1)
-      .childChannelOption(
-        ChannelOptions.socket(SocketOptionLevel(SOL_SOCKET), SO_REUSEADDR),
-        value: 1
-      )
+      // Run the debug initializer, if there is one.
+      if let debugAcceptedChannelInitializer = configuration.debugChannelInitializer {
+        return debugAcceptedChannelInitializer(channel)
+      } else {
+        return channel.eventLoop.makeSucceededVoidFuture()
+      }
+    }
   }
 
   /// Starts a server with the given configuration. See `Server.Configuration` for the options
   /// available to configure the server.
+  ///
+  /// The returned future fails with a `GRPCError.InvalidState` if the configuration is invalid,
+  /// e.g. if multiple service providers have the same service name or the HTTP/2 target window
+  /// size is out of range.
   public static func start(configuration: Configuration) -> EventLoopFuture<Server> {
+    return self.start(configuration: configuration) { bootstrap in
+      bootstrap.bind(to: configuration.target)
+    }
+  }
+
+  /// Starts a server which accepts connections on an existing socket rather than binding to
+  /// `configuration.target`, which is ignored. This is useful for socket activation, where the
+  /// listening socket is inherited from the process which started the server.
+  ///
+  /// The socket must be a bound stream socket; it may already be listening. Ownership of the
+  /// socket is transferred to the server: it is closed when the server is closed, or if the server
+  /// fails to start, and must not be used or closed by the caller afterwards.
+  ///
+  /// - Important: Only supported by `ServerBootstrap`, i.e. not when using Network.framework.
+  public static func start(
+    configuration: Configuration,
+    boundSocket socket: NIOBSDSocket.Handle
+  ) -> EventLoopFuture<Server> {
+    if let error = configuration.validationError {
+      closeSocket(socket)
+      return configuration.eventLoopGroup.next().makeFailedFuture(error)
+    }
+
+    return self.start(configuration: configuration) { bootstrap in
+      guard let bootstrap = bootstrap as? ServerBootstrap else {
+        closeSocket(socket)
+        return configuration.eventLoopGroup.next().makeFailedFuture(
+          GRPCError.InvalidState("Existing sockets may only be used with a ServerBootstrap")
+        )
+      }
+      return bootstrap.withBoundSocket(socket)
+    }
+  }
+
+  private static func start(
+    configuration: Configuration,
+    bind: (ServerBootstrapProtocol) -> EventLoopFuture<Channel>
+  ) -> EventLoopFuture<Server> {
+    if let error = configuration.validationError {
+      return configuration.eventLoopGroup.next().makeFailedFuture(error)
+    }
+
     let quiescingHelper = ServerQuiescingHelper(group: configuration.eventLoopGroup)
 
-    return self.makeBootstrap(configuration: configuration)
+    let bootstrap = self.makeBootstrap(configuration: configuration)
       .serverChannelInitializer { channel in
         channel.pipeline.addHandler(quiescingHelper.makeServerChannelHandler(channel: channel))
       }
-      .bind(to: configuration.target)
-      .map { channel in
-        Server(
-          channel: channel,
-          quiescingHelper: quiescingHelper,
-          errorDelegate: configuration.errorDelegate
-        )
-      }
+
+    return bind(bootstrap).map { channel in
+      Server(
+        channel: channel,
+        quiescingHelper: quiescingHelper,
+        errorDelegate: configuration.errorDelegate,
+        logger: configuration.logger,
+        services: configuration.services
+      )
+    }
+  }
+
+  /// Serves RPCs on a single connection which was accepted, or otherwise created, outside of the
+  /// server, for example one end of a `socketpair` in a test harness. `configuration.target` is
+  /// ignored.
+  ///
+  /// The `channel` must be active and its pipeline must not have been configured for HTTP/2 or
+  /// TLS; the handlers needed to serve gRPC (including TLS, if configured) are added to it. The
+  /// returned future completes once the pipeline has been configured. The caller retains
+  /// ownership of the channel: RPCs are served until the channel is closed, either by the caller
+  /// or by the remote peer. As there is no `Server`, the connection doesn't take part in graceful
+  /// shutdown.
+  ///
+  /// - Important: TLS provided by Network.framework is not supported; configuring the pipeline
+  ///   fails if it is used.
+  public static func serve(
+    connection channel: Channel,
+    configuration: Configuration
+  ) -> EventLoopFuture<Void> {
+    if let error = configuration.validationError {
+      return channel.eventLoop.makeFailedFuture(error)
+    }
+
+    let initializer = self.makeChildChannelInitializer(configuration: configuration, bootstrap: nil)
+    return channel.eventLoop.flatSubmit {
+      initializer(channel)
+    }
+  }
+
+  /// Serves RPCs on a single connected stream socket, for example one accepted by another process
+  /// or one end of a `socketpair`. `configuration.target` is ignored.
+  ///
+  /// Ownership of the socket is transferred to the returned `Channel`: the socket is closed when
+  /// the channel is closed, or if the channel can't be created, and must not be used or closed by
+  /// the caller afterwards. RPCs are served until the channel is closed. As there is no `Server`,
+  /// the connection doesn't take part in graceful shutdown. `configuration.socketOptions` are
+  /// applied to the socket.
+  ///
+  /// - Important: Only supported by `MultiThreadedEventLoopGroup`s.
+  public static func serve(
+    connectedSocket socket: NIOBSDSocket.Handle,
+    configuration: Configuration
+  ) -> EventLoopFuture<Channel> {
+    if let error = configuration.validationError {
+      closeSocket(socket)
+      return configuration.eventLoopGroup.next().makeFailedFuture(error)
+    }
+
+    guard let bootstrap = ClientBootstrap(validatingGroup: configuration.eventLoopGroup) else {
+      closeSocket(socket)
+      return configuration.eventLoopGroup.next().makeFailedFuture(
+        GRPCError.InvalidState("Connected sockets require a MultiThreadedEventLoopGroup")
+      )
+    }
+
+    return bootstrap
+      .socketOptions(configuration.socketOptions)
+      .channelInitializer(
+        self.makeChildChannelInitializer(configuration: configuration, bootstrap: nil)
+      )
+      .withConnectedSocket(socket)
   }
 
   public let channel: Channel
   private let quiescingHelper: ServerQuiescingHelper
   private var errorDelegate: ServerErrorDelegate?
+  internal let logger: Logger
+
+  /// The services provided by the server, ordered by service name. Services can't be added or
+  /// removed once the server has started.
+  public let services: [ServerServiceDescriptor]
+
+  /// The methods provided by the server, ordered by service name. Services whose providers don't
+  /// list their methods aren't included, see `ServerServiceDescriptor.methods`.
+  public var registeredMethods: [ServerMethodDescriptor] {
+    return self.services.flatMap { $0.methods }
+  }
+
+  /// Handlers for signals which trigger a graceful shutdown, if any have been installed.
+  /// Protected by `signalLock`.
+  internal var signalHandlers: [InstalledSignalHandler]?
+  internal let signalLock = Lock()
 
   private init(
     channel: Channel,
     quiescingHelper: ServerQuiescingHelper,
-    errorDelegate: ServerErrorDelegate?
+    errorDelegate: ServerErrorDelegate?,
+    logger: Logger,
+    services: [ServerServiceDescriptor]
   ) {
     self.channel = channel
     self.quiescingHelper = quiescingHelper
+    self.logger = logger
+    self.services = services
 
     // Maintain a strong reference to ensure it lives as long as the server.
     self.errorDelegate = errorDelegate
@@ -224,6 +393,14 @@ public final class Server {
     }
   }
 
+  /// The address the server is listening on.
+  ///
+  /// This includes the port chosen by the operating system if the server was bound to port 0,
+  /// making it useful for connecting clients in tests. Will be `nil` once the server has closed.
+  public var localAddress: SocketAddress? {
+    return self.channel.localAddress
+  }
+
   /// Fired when the server shuts down.
   public var onClose: EventLoopFuture<Void> {
     return self.channel.closeFuture
@@ -265,16 +442,15 @@ extension Server {
     public var eventLoopGroup: EventLoopGroup
 
     /// Providers the server should use to handle gRPC requests.
+    ///
+    /// Each provider must have a distinct `serviceName`: the server fails to start otherwise.
     public var serviceProviders: [CallHandlerProvider] {
       get {
         return Array(self.serviceProvidersByName.values)
       }
       set {
-        self
-          .serviceProvidersByName = Dictionary(
-            uniqueKeysWithValues: newValue
-              .map { ($0.serviceName, $0) }
-          )
+        (self.serviceProvidersByName, self.duplicateServiceNames) =
+          Server.Configuration.indexByServiceName(newValue)
       }
     }
 
@@ -282,6 +458,13 @@ extension Server {
     /// maintain a strong reference to this `Server`**. Doing so will cause a retain cycle.
     public var errorDelegate: ServerErrorDelegate?
 
+    /// A delegate which is called when connections are accepted or closed. Defaults to `nil`.
+    public var connectionEventDelegate: ServerConnectionEventDelegate?
+
+    /// The `DispatchQueue` on which to call the connection event delegate. If a delegate is
+    /// provided but the queue is `nil` then one will be created by gRPC. Defaults to `nil`.
+    public var connectionEventDelegateQueue: DispatchQueue?
+
     /// TLS configuration for this connection. `nil` if TLS is not desired.
     @available(*, deprecated, renamed: "tlsConfiguration")
     public var tls: TLS? {
@@ -302,6 +485,80 @@ extension Server {
     /// if there are no RPCs in progress and will be cancelled as soon as any RPCs start.
     public var connectionIdleTimeout: TimeAmount = .nanoseconds(.max)
 
+    /// The maximum amount of time a connection may exist before the server gracefully shuts it
+    /// down by sending a GOAWAY frame. Clients will make new RPCs on a new connection which, when
+    /// running multiple servers behind a load balancer, allows load to be rebalanced. Up to 10%
+    /// jitter is applied to the age of each connection.
+    ///
+    /// Defaults to `.nanoseconds(.max)`, i.e. connections may exist indefinitely.
+    public var maximumConnectionAge: TimeAmount = .nanoseconds(.max)
+
+    /// The amount of time to wait for RPCs to complete once a connection has reached its
+    /// `maximumConnectionAge`. Once the grace period has passed the connection is closed, failing
+    /// any RPCs still in progress.
+    ///
+    /// Defaults to `.nanoseconds(.max)`, i.e. RPCs are given as long as they need to complete.
+    public var maximumConnectionAgeGrace: TimeAmount = .nanoseconds(.max)
+
+    /// The maximum amount of time an RPC's stream may go without the server reading or writing
+    /// a frame on it. Once passed the RPC is failed with status code 'deadlineExceeded' and the
+    /// stream is reset. This is independent of any deadline set by the client and guards against
+    /// clients which open streams and then neither send nor close them. Only applies to HTTP/2
+    /// connections.
+    ///
+    /// Defaults to `.nanoseconds(.max)`, i.e. inactive streams are never reset.
+    public var streamInactivityTimeout: TimeAmount = .nanoseconds(.max)
+
+    /// How request metadata containing the same key more than once is presented to RPCs. See
+    /// `DuplicateMetadataPolicy` for how the values of a key may be read.
+    ///
+    /// Defaults to `.keepAll`, i.e. each value is kept as a separate entry.
+    public var duplicateMetadataPolicy: DuplicateMetadataPolicy = .keepAll
+
+    /// How fields which are unknown to the schema of a Protobuf request message are handled for
+    /// services without an entry in `unknownFieldHandlingByService`.
+    ///
+    /// Defaults to `.preserve`.
+    public var unknownFieldHandling: UnknownFieldHandling = .preserve
+
+    /// How fields which are unknown to the schema of a Protobuf request message are handled for
+    /// specific services, keyed by fully qualified service name (e.g. "echo.Echo").
+    ///
+    /// Defaults to no service specific handling.
+    public var unknownFieldHandlingByService: [String: UnknownFieldHandling] = [:]
+
+    /// Limits on the total size of the request and response messages of each RPC, for methods
+    /// without an entry in `transferLimitsByMethod`. RPCs exceeding a limit fail with status code
+    /// `.resourceExhausted`. Running totals are available to interceptors via `transferTotals` on
+    /// their context; final totals are reported to the `compressionStatisticsObserver`.
+    ///
+    /// Defaults to `.unlimited`.
+    public var transferLimits: MessageTransferLimits = .unlimited
+
+    /// Limits on the total size of the request and response messages of RPCs to specific methods,
+    /// keyed by path (e.g. "/echo.Echo/Update").
+    ///
+    /// Defaults to no method specific limits.
+    public var transferLimitsByMethod: [String: MessageTransferLimits] = [:]
+
+    /// The maximum number of request messages a client streaming or bidirectional streaming RPC
+    /// may buffer while its service provider is creating the stream observer, i.e. before the
+    /// future returned by the provider has completed. RPCs which exceed the limit fail with status
+    /// code `.resourceExhausted`. Once the observer exists each request is passed to it as soon as
+    /// it has been read so nothing is buffered. Must be greater than zero if set, otherwise the
+    /// server fails to start.
+    ///
+    /// Defaults to `nil`, i.e. the number of buffered requests isn't limited.
+    public var maximumBufferedRequests: Int?
+
+    /// Interceptors applied to RPCs on every service provider, according to their scope and
+    /// priority. For each RPC the interceptors resolved for its method by the registry run ahead
+    /// of those made by the interceptor factory of the service provider, i.e. they are the first
+    /// to receive request parts and the last to send response parts.
+    ///
+    /// Defaults to an empty registry.
+    public var interceptorRegistry = ServerInterceptorRegistry()
+
     /// The compression configuration for requests and responses.
     ///
     /// If compression is enabled for the server it may be disabled for responses on any RPC by
@@ -324,6 +581,19 @@ extension Server {
     /// The HTTP/2 flow control target window size. Defaults to 65535.
     public var httpTargetWindowSize: Int = 65535
 
+    /// The maximum size, in bytes, of the HPACK dynamic table used to decode headers received
+    /// from the remote peer. This is advertised to the peer as SETTINGS_HEADER_TABLE_SIZE and
+    /// bounds the table the peer's encoder may use. Larger tables use more memory per connection
+    /// but can compress repeated headers, such as access tokens, more effectively. Must be in the
+    /// range `0 ... 2^32-1`, otherwise the server fails to start.
+    ///
+    /// Defaults to 4096, the HTTP/2 default.
+    public var httpHeaderTableSize: Int = 4096
+
+    /// Options applied to the socket of each accepted connection. Defaults to disabling Nagle's
+    /// algorithm (`TCP_NODELAY`) and enabling `SO_REUSEADDR`, see `GRPCSocketOptions`.
+    public var socketOptions = GRPCSocketOptions()
+
     /// The root server logger. Accepted connections will branch from this logger and RPCs on
     /// each connection will use a logger branched from the connections logger. This logger is made
     /// available to service providers via `context`. Defaults to a no-op logger.
@@ -337,12 +607,54 @@ extension Server {
     ///   be invoked at most once per accepted connection.
     public var debugChannelInitializer: ((Channel) -> EventLoopFuture<Void>)?
 
+    /// A logger which, if set, logs every HTTP/2 frame sent or received on each accepted connection
+    /// at the 'debug' level. This is intended for debugging and is very verbose. Header values
+    /// which typically carry credentials are redacted and the contents of DATA frames are not
+    /// logged. Defaults to `nil`, i.e. frames aren't logged.
+    public var debugHTTP2FrameLogger: Logger?
+
+    /// A closure which is called with every serialized message sent or received by RPCs on the
+    /// server. This is intended for debugging and tooling, such as recording traffic.
+    ///
+    /// The closure is called on the `EventLoop` of the RPC and must not block. Messages are
+    /// observed without being copied. Defaults to `nil`, in which case observation has no cost.
+    public var debugMessageObserver: ((ObservedMessage) -> Void)?
+
+    /// A closure which is called with the compression statistics of each RPC on the server once
+    /// the RPC's stream has closed. This may be used to judge whether compressing messages is
+    /// worth the CPU time it costs.
+    ///
+    /// The closure is called on the `EventLoop` of the RPC and must not block. Defaults to `nil`,
+    /// in which case no statistics are collected.
+    public var compressionStatisticsObserver: ((CompressionStatistics) -> Void)?
+
+    /// Whether the status message of RPCs rejected because their method is not implemented should
+    /// include the known methods closest to the requested one. This is intended to help during
+    /// development and should not be enabled in production as it reveals the methods offered by
+    /// the server. Defaults to `false`.
+    ///
+    /// Such RPCs are always rejected with a "trailers-only" response with status code
+    /// 'unimplemented' (12) and no response body.
+    public var includeKnownMethodsInUnimplementedStatus: Bool = false
+
+    /// Metadata added to the `logger` of each RPC, such as its method and the remote peer.
+    ///
+    /// Defaults to `.none`: no metadata is added. Use `ServerRPCLoggerMetadata()` to add the
+    /// method, peer, 'grpc-timeout' and "x-request-id" header of each RPC.
+    public var rpcLoggerMetadata: ServerRPCLoggerMetadata = .none
+
     /// A calculated private cache of the service providers by name.
     ///
     /// This is how gRPC consumes the service providers internally. Caching this as stored data avoids
     /// the need to recalculate this dictionary each time we receive an rpc.
     internal var serviceProvidersByName: [Substring: CallHandlerProvider]
 
+    /// The names of services with more than one provider in `serviceProviders`.
+    internal var duplicateServiceNames: [Substring]
+
+    /// Paths registered with `addAlias(from:to:)`, keyed by the alias.
+    internal var pathAliases: [String: String] = [:]
+
     /// Create a `Configuration` with some pre-defined defaults.
     ///
     /// - Parameters:
@@ -376,11 +688,8 @@ extension Server {
     ) {
       self.target = target
       self.eventLoopGroup = eventLoopGroup
-      self
-        .serviceProvidersByName = Dictionary(
-          uniqueKeysWithValues: serviceProviders
-            .map { ($0.serviceName, $0) }
-        )
+      (self.serviceProvidersByName, self.duplicateServiceNames) =
+        Server.Configuration.indexByServiceName(serviceProviders)
       self.errorDelegate = errorDelegate
       self.tlsConfiguration = tls.map { GRPCTLSConfiguration(transforming: $0) }
       self.connectionKeepalive = connectionKeepalive
@@ -398,9 +707,8 @@ extension Server {
     ) {
       self.eventLoopGroup = eventLoopGroup
       self.target = target
-      self.serviceProvidersByName = Dictionary(uniqueKeysWithValues: serviceProviders.map {
-        ($0.serviceName, $0)
-      })
+      (self.serviceProvidersByName, self.duplicateServiceNames) =
+        Server.Configuration.indexByServiceName(serviceProviders)
     }
 
     /// Make a new configuration using default values.
@@ -425,6 +733,72 @@ extension Server {
   }
 }
 
+extension Server.Configuration {
+  /// Returns the given providers keyed by their service name, and the names of any services
+  /// provided more than once. Only the last provider for each service is kept.
+  fileprivate static func indexByServiceName(
+    _ providers: [CallHandlerProvider]
+  ) -> ([Substring: CallHandlerProvider], [Substring]) {
+    var providersByName: [Substring: CallHandlerProvider] = [:]
+    providersByName.reserveCapacity(providers.count)
+    var duplicateNames: [Substring] = []
+
+    for provider in providers {
+      let existing = providersByName.updateValue(provider, forKey: provider.serviceName)
+      if existing != nil, !duplicateNames.contains(provider.serviceName) {
+        duplicateNames.append(provider.serviceName)
+      }
+    }
+
+    return (providersByName, duplicateNames)
+  }
+
+  /// Adds a provider to `serviceProviders`. If its service is already provided then the new
+  /// provider replaces it and the server will fail to start.
+  internal mutating func addServiceProvider(_ provider: CallHandlerProvider) {
+    let name = provider.serviceName
+    let existing = self.serviceProvidersByName.updateValue(provider, forKey: name)
+    if existing != nil, !self.duplicateServiceNames.contains(name) {
+      self.duplicateServiceNames.append(name)
+    }
+  }
+
+  /// An error describing why a server can't be started with this configuration, or `nil` if
+  /// the configuration is valid.
+  internal var validationError: Error? {
+    if let name = self.duplicateServiceNames.first {
+      return GRPCError.InvalidState("Multiple service providers registered for service '\(name)'")
+    }
+
+    let windowSize = self.httpTargetWindowSize
+    guard (1 ... Int(Int32.max)).contains(windowSize) else {
+      return GRPCError.InvalidState(
+        "The HTTP/2 target window size must be in the range 1...\(Int32.max) "
+          + "(but was \(windowSize))"
+      )
+    }
+
+    guard isValidHTTPHeaderTableSize(self.httpHeaderTableSize) else {
+      return GRPCError.InvalidState(
+        "The HTTP/2 header table size must be in the range 0...\(UInt32.max) "
+          + "(but was \(self.httpHeaderTableSize))"
+      )
+    }
+
+    if let limit = self.maximumBufferedRequests, limit <= 0 {
+      return GRPCError.InvalidState(
+        "The maximum number of buffered requests must be greater than zero (but was \(limit))"
+      )
+    }
+
+    if let error = self.pathAliasError {
+      return error
+    }
+
+    return nil
+  }
+}
+
 private extension ServerBootstrapProtocol {
   func bind(to target: BindTarget) -> EventLoopFuture<Channel> {
     switch target.wrapped {
@@ -439,3 +813,9 @@ private extension ServerBootstrapProtocol {
     }
   }
 }
+
+/// Closes a socket whose ownership was transferred to the server but which can't be used. This is
+/// a free function as `close` would otherwise resolve to `Server.close()`.
+private func closeSocket(_ socket: NIOBSDSocket.Handle) {
+  _ = close(socket)
+}
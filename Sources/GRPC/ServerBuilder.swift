@@ -13,6 +13,7 @@
  * See the License for the specific language governing permissions and
  * limitations under the License.
  */
+import Dispatch
 import Logging
 import NIO
 import NIOSSL
@@ -50,11 +51,52 @@ extension Server {
     }
 
     public func bind(host: String, port: Int) -> EventLoopFuture<Server> {
+      self.validate()
+
       // Finish setting up the configuration.
       self.configuration.target = .hostAndPort(host, port)
       self.configuration.tlsConfiguration = self.maybeTLS
       return Server.start(configuration: self.configuration)
     }
+
+    /// Starts a server which accepts connections on an existing bound socket, such as one
+    /// inherited via socket activation. Ownership of the socket is transferred to the server. See
+    /// `Server.start(configuration:boundSocket:)`.
+    public func bind(toSocket socket: NIOBSDSocket.Handle) -> EventLoopFuture<Server> {
+      self.validate()
+      self.configuration.tlsConfiguration = self.maybeTLS
+      return Server.start(configuration: self.configuration, boundSocket: socket)
+    }
+
+    /// Serves RPCs on a single existing connection. The caller retains ownership of the
+    /// `channel`. See `Server.serve(connection:configuration:)`.
+    public func serve(connection channel: Channel) -> EventLoopFuture<Void> {
+      self.validate()
+      self.configuration.tlsConfiguration = self.maybeTLS
+      return Server.serve(connection: channel, configuration: self.configuration)
+    }
+
+    /// Serves RPCs on a single connected socket. Ownership of the socket is transferred to the
+    /// returned `Channel`. See `Server.serve(connectedSocket:configuration:)`.
+    public func serve(connectedSocket socket: NIOBSDSocket.Handle) -> EventLoopFuture<Channel> {
+      self.validate()
+      self.configuration.tlsConfiguration = self.maybeTLS
+      return Server.serve(connectedSocket: socket, configuration: self.configuration)
+    }
+  }
+}
+
+extension Server.Builder {
+  /// Checks the options set on the builder.
+  ///
+  /// Options which are valid but are unlikely to be intended are logged as a warning. Invalid
+  /// options fail the returned future with a `GRPCError.InvalidState` when the server is started.
+  private func validate() {
+    if self.configuration.serviceProvidersByName.isEmpty {
+      self.configuration.logger.warning(
+        "no service providers registered: all RPCs will fail with status code 'unimplemented'"
+      )
+    }
   }
 }
 
@@ -67,14 +109,64 @@ extension Server.Builder {
   }
 }
 
+extension Server.Builder {
+  /// Sets the connection event delegate and the queue on which its methods should be called. If
+  /// no `queue` is provided then gRPC will create a `DispatchQueue` on which to run the delegate.
+  @discardableResult
+  public func withConnectionEventDelegate(
+    _ delegate: ServerConnectionEventDelegate?,
+    executingOn queue: DispatchQueue? = nil
+  ) -> Self {
+    self.configuration.connectionEventDelegate = delegate
+    self.configuration.connectionEventDelegateQueue = queue
+    return self
+  }
+}
+
 extension Server.Builder {
   /// Sets the service providers that this server should offer. Note that calling this multiple
   /// times will override any previously set providers.
+  ///
+  /// Each provider must have a distinct `serviceName`: binding the server fails otherwise.
   @discardableResult
   public func withServiceProviders(_ providers: [CallHandlerProvider]) -> Self {
     self.configuration.serviceProviders = providers
     return self
   }
+
+  /// Sets the service providers that this server should offer. Note that calling this multiple
+  /// times will override any previously set providers.
+  ///
+  /// Generated service providers route every method of their service, so each service need only
+  /// be provided once:
+  ///
+  /// ```
+  /// let server = Server.insecure(group: group)
+  ///   .withServiceProviders(EchoProvider(), GreeterProvider())
+  ///   .bind(host: "localhost", port: 0)
+  /// ```
+  ///
+  /// Each provider must have a distinct `serviceName`: binding the server fails otherwise.
+  @discardableResult
+  public func withServiceProviders(_ providers: CallHandlerProvider...) -> Self {
+    return self.withServiceProviders(providers)
+  }
+
+  /// Adds a service provider to those this server should offer, keeping any previously set
+  /// providers. Generated providers call this from `registerHandlers(on:)`:
+  ///
+  /// ```
+  /// let builder = Server.insecure(group: group)
+  /// EchoProvider().registerHandlers(on: builder)
+  /// GreeterProvider().registerHandlers(on: builder)
+  /// ```
+  ///
+  /// Each provider must have a distinct `serviceName`: binding the server fails otherwise.
+  @discardableResult
+  public func addServiceProvider(_ provider: CallHandlerProvider) -> Self {
+    self.configuration.addServiceProvider(provider)
+    return self
+  }
 }
 
 extension Server.Builder {
@@ -94,6 +186,88 @@ extension Server.Builder {
     self.configuration.connectionIdleTimeout = timeout
     return self
   }
+
+  /// The maximum amount of time a connection may exist before the server gracefully shuts it
+  /// down, and the amount of time to then wait for RPCs in progress to complete before closing
+  /// the connection. Connections may exist indefinitely unless a maximum age is set.
+  @discardableResult
+  public func withMaximumConnectionAge(
+    _ age: TimeAmount,
+    grace: TimeAmount = .nanoseconds(.max)
+  ) -> Self {
+    self.configuration.maximumConnectionAge = age
+    self.configuration.maximumConnectionAgeGrace = grace
+    return self
+  }
+}
+
+extension Server.Builder {
+  /// The maximum amount of time an RPC's stream may go without the server reading or writing a
+  /// frame on it before the RPC is failed and the stream is reset. Inactive streams are never
+  /// reset unless a timeout is set; the default value of this parameter is deliberately generous.
+  @discardableResult
+  public func withStreamInactivityTimeout(_ timeout: TimeAmount = .minutes(5)) -> Self {
+    self.configuration.streamInactivityTimeout = timeout
+    return self
+  }
+}
+
+extension Server.Builder {
+  /// Sets how request metadata containing the same key more than once is presented to RPCs.
+  /// Defaults to `.keepAll`.
+  @discardableResult
+  public func withDuplicateMetadataPolicy(_ policy: DuplicateMetadataPolicy) -> Self {
+    self.configuration.duplicateMetadataPolicy = policy
+    return self
+  }
+}
+
+extension Server.Builder {
+  /// Sets how fields which are unknown to the schema of a Protobuf request message are handled.
+  /// If `service` is provided, the fully qualified name of a service (e.g. "echo.Echo"), the
+  /// handling only applies to that service; otherwise it applies to all services without their
+  /// own handling. Defaults to `.preserve`.
+  @discardableResult
+  public func withUnknownFieldHandling(
+    _ handling: UnknownFieldHandling,
+    forService service: String? = nil
+  ) -> Self {
+    if let service = service {
+      self.configuration.unknownFieldHandlingByService[service] = handling
+    } else {
+      self.configuration.unknownFieldHandling = handling
+    }
+    return self
+  }
+}
+
+extension Server.Builder {
+  /// Sets limits on the total size of the request and response messages of each RPC. If `path`
+  /// is provided (e.g. "/echo.Echo/Update") the limits only apply to that method; otherwise they
+  /// apply to all methods without their own limits. RPCs are not limited by default.
+  @discardableResult
+  public func withTransferLimits(
+    _ limits: MessageTransferLimits,
+    forMethod path: String? = nil
+  ) -> Self {
+    if let path = path {
+      self.configuration.transferLimitsByMethod[path] = limits
+    } else {
+      self.configuration.transferLimits = limits
+    }
+    return self
+  }
+}
+
+extension Server.Builder {
+  /// Sets the registry of interceptors applied to RPCs on every service provider. For each RPC
+  /// the interceptors resolved for its method run ahead of those made by the interceptor factory
+  /// of the service provider. No interceptors are registered by default.
+  @discardableResult
+  public func withInterceptorRegistry(_ registry: ServerInterceptorRegistry) -> Self {
+    self.configuration.interceptorRegistry = registry
+    return self
+  }
 }
 
 extension Server.Builder {
@@ -113,6 +287,17 @@ extension Server.Builder {
     self.configuration.maximumReceiveMessageLength = limit
     return self
   }
+
+  /// Sets the maximum number of request messages a client streaming or bidirectional streaming
+  /// RPC may buffer while its stream observer is being created. RPCs exceeding the limit fail with
+  /// status code `.resourceExhausted`. The number of buffered requests isn't limited by default.
+  ///
+  /// The server fails to start if `limit` isn't greater than zero.
+  @discardableResult
+  public func withMaximumBufferedRequests(_ limit: Int?) -> Self {
+    self.configuration.maximumBufferedRequests = limit
+    return self
+  }
 }
 
 extension Server.Builder.Secure {
@@ -149,6 +334,43 @@ extension Server.Builder.Secure {
     self.tls.requireALPN = requiringALPN
     return self
   }
+
+  /// Sets the certificate chain and private key to present to clients which request the given
+  /// server name via the TLS SNI extension. Clients requesting other server names, or no server
+  /// name, are presented the certificate chain and private key the builder was created with
+  /// unless `withTLS(rejectingUnknownServerNames:)` is set.
+  ///
+  /// Server names are matched case-insensitively. This may be called multiple times to configure
+  /// identities for multiple server names.
+  ///
+  /// - Note: May only be used with the 'NIOSSL' TLS backend.
+  @discardableResult
+  public func withTLS(
+    certificateChain: [NIOSSLCertificate],
+    privateKey: NIOSSLPrivateKey,
+    forServerName serverName: String
+  ) -> Self {
+    let identity = GRPCTLSConfiguration.ServerIdentity(
+      certificateChain: certificateChain.map { .certificate($0) },
+      privateKey: .privateKey(privateKey)
+    )
+    self.tls.updateNIOServerIdentity(identity, forServerName: serverName)
+    return self
+  }
+
+  /// Sets whether connections are rejected if the client requests a server name which was not
+  /// configured with `withTLS(certificateChain:privateKey:forServerName:)`, or doesn't request a
+  /// server name at all. Defaults to `false`: such clients are presented the default certificate
+  /// chain and private key.
+  ///
+  /// This has no effect unless at least one server name has been configured.
+  ///
+  /// - Note: May only be used with the 'NIOSSL' TLS backend.
+  @discardableResult
+  public func withTLS(rejectingUnknownServerNames: Bool) -> Self {
+    self.tls.updateNIORejectUnknownServerNames(to: rejectingUnknownServerNames)
+    return self
+  }
 }
 
 extension Server.Builder {
@@ -160,6 +382,26 @@ extension Server.Builder {
   }
 }
 
+extension Server.Builder {
+  /// Sets the maximum size, in bytes, of the HPACK dynamic table used to decode headers from the
+  /// remote peer. Must be in the range `0 ... 2^32-1`. Defaults to 4096 if not explicitly set.
+  @discardableResult
+  public func withHTTPHeaderTableSize(_ httpHeaderTableSize: Int) -> Self {
+    self.configuration.httpHeaderTableSize = httpHeaderTableSize
+    return self
+  }
+}
+
+extension Server.Builder {
+  /// Sets the options applied to the socket of each accepted connection. Defaults to disabling
+  /// Nagle's algorithm (`TCP_NODELAY`) and enabling `SO_REUSEADDR` if not explicitly set.
+  @discardableResult
+  public func withSocketOptions(_ socketOptions: GRPCSocketOptions) -> Self {
+    self.configuration.socketOptions = socketOptions
+    return self
+  }
+}
+
 extension Server.Builder {
   /// Sets the root server logger. Accepted connections will branch from this logger and RPCs on
   /// each connection will use a logger branched from the connections logger. This logger is made
@@ -185,6 +427,56 @@ extension Server.Builder {
     self.configuration.debugChannelInitializer = debugChannelInitializer
     return self
   }
+
+  /// A logger which logs every HTTP/2 frame sent or received on each accepted connection at the
+  /// 'debug' level. This is intended for debugging and is very verbose.
+  @discardableResult
+  public func withDebugHTTP2FrameLogger(_ logger: Logger) -> Self {
+    self.configuration.debugHTTP2FrameLogger = logger
+    return self
+  }
+
+  /// A closure which is called with every serialized message sent or received by RPCs on the
+  /// server. This is intended for debugging and tooling, such as recording traffic.
+  ///
+  /// The closure is called on the `EventLoop` of the RPC and must not block.
+  @discardableResult
+  public func withDebugMessageObserver(
+    _ observer: @escaping (ObservedMessage) -> Void
+  ) -> Self {
+    self.configuration.debugMessageObserver = observer
+    return self
+  }
+
+  /// A closure which is called with the compression statistics of each RPC on the server once
+  /// the RPC's stream has closed. The closure must not block.
+  @discardableResult
+  public func withCompressionStatisticsObserver(
+    _ observer: @escaping (CompressionStatistics) -> Void
+  ) -> Self {
+    self.configuration.compressionStatisticsObserver = observer
+    return self
+  }
+
+  /// Whether RPCs rejected because their method is not implemented should include the known
+  /// methods closest to the requested one in their status message. Intended for development
+  /// only; defaults to `false`.
+  @discardableResult
+  public func withKnownMethodsInUnimplementedStatus(_ enabled: Bool) -> Self {
+    self.configuration.includeKnownMethodsInUnimplementedStatus = enabled
+    return self
+  }
+}
+
+extension Server.Builder {
+  /// Metadata to add to the `logger` of each RPC. Defaults to `.none`; use
+  /// `ServerRPCLoggerMetadata()` to add the method, peer, 'grpc-timeout' and "x-request-id" header
+  /// of each RPC.
+  @discardableResult
+  public func withRPCLoggerMetadata(_ metadata: ServerRPCLoggerMetadata) -> Self {
+    self.configuration.rpcLoggerMetadata = metadata
+    return self
+  }
 }
 
 extension Server {
@@ -0,0 +1,293 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+
+/// Broadcasts responses from a single source to any number of streaming RPCs.
+///
+/// Each RPC subscribes with its `StreamingResponseCallContext` and receives every response
+/// published after it subscribed, for example:
+///
+/// ```
+/// let broadcaster = try ResponseBroadcaster<Event>(maximumBufferedResponses: 32)
+///
+/// func subscribe(
+///   request: SubscribeRequest,
+///   context: StreamingResponseCallContext<Event>
+/// ) -> EventLoopFuture<GRPCStatus> {
+///   return broadcaster.subscribe(context)
+/// }
+///
+/// // Elsewhere, as events arrive:
+/// broadcaster.publish(event)
+/// ```
+///
+/// Responses are written to each subscriber one at a time: the next response is only written
+/// once the previous write has completed, so subscribers are limited by their own flow control.
+/// Each response is flushed as it is written, even if the subscriber's context doesn't flush
+/// responses automatically.
+/// Responses published while a subscriber is busy are buffered for that subscriber alone; when its
+/// buffer is full the `SlowSubscriberPolicy` decides what happens. A slow subscriber never stalls
+/// the publisher or other subscribers.
+///
+/// All methods may be called from any thread.
+public final class ResponseBroadcaster<Response> {
+  /// What to do with a subscriber whose buffer of unwritten responses is full.
+  public struct SlowSubscriberPolicy: Hashable {
+    internal enum Wrapped: Hashable {
+      case dropOldest
+      case close
+    }
+
+    internal var wrapped: Wrapped
+    private init(_ wrapped: Wrapped) {
+      self.wrapped = wrapped
+    }
+
+    /// Drop the oldest buffered response to make room for the newest one.
+    public static let dropOldest = SlowSubscriberPolicy(.dropOldest)
+
+    /// End the subscriber's RPC with status code `.resourceExhausted`.
+    public static let close = SlowSubscriberPolicy(.close)
+  }
+
+  /// The maximum number of unwritten responses buffered for each subscriber.
+  public let maximumBufferedResponses: Int
+
+  /// What to do with a subscriber whose buffer is full.
+  public let slowSubscriberPolicy: SlowSubscriberPolicy
+
+  private let lock = Lock()
+  private var subscribers: [ObjectIdentifier: Subscriber] = [:]
+  private var finalStatus: GRPCStatus?
+
+  /// Creates a broadcaster.
+  ///
+  /// - Parameters:
+  ///   - maximumBufferedResponses: The maximum number of unwritten responses buffered for each
+  ///       subscriber, must be greater than zero. Defaults to 64.
+  ///   - slowSubscriberPolicy: What to do with a subscriber whose buffer is full. Defaults to
+  ///       `.dropOldest`.
+  /// - Throws: `GRPCError.InvalidState` if `maximumBufferedResponses` isn't greater than zero.
+  public init(
+    maximumBufferedResponses: Int = 64,
+    slowSubscriberPolicy: SlowSubscriberPolicy = .dropOldest
+  ) throws {
+    guard maximumBufferedResponses > 0 else {
+      throw GRPCError.InvalidState(
+        "maximumBufferedResponses must be greater than zero (but was \(maximumBufferedResponses))"
+      )
+    }
+    self.maximumBufferedResponses = maximumBufferedResponses
+    self.slowSubscriberPolicy = slowSubscriberPolicy
+  }
+
+  /// The number of current subscribers.
+  public var subscriberCount: Int {
+    return self.lock.withLock { self.subscribers.count }
+  }
+
+  /// Subscribes an RPC to the responses published by the broadcaster.
+  ///
+  /// - Parameter context: The context of the RPC.
+  /// - Returns: A future status to return from the handler. It is completed once the broadcaster
+  ///     has finished and all buffered responses have been written, or with status code
+  ///     `.resourceExhausted` if the subscriber is closed for being too slow.
+  public func subscribe(
+    _ context: StreamingResponseCallContext<Response>
+  ) -> EventLoopFuture<GRPCStatus> {
+    let subscriber = Subscriber(
+      context: context,
+      maximumBufferedResponses: self.maximumBufferedResponses,
+      policy: self.slowSubscriberPolicy
+    )
+    let id = ObjectIdentifier(subscriber)
+
+    let finalStatus: GRPCStatus? = self.lock.withLock {
+      if self.finalStatus == nil {
+        self.subscribers[id] = subscriber
+      }
+      return self.finalStatus
+    }
+
+    if let status = finalStatus {
+      return context.eventLoop.makeSucceededFuture(status)
+    }
+
+    // Stop tracking the subscriber once it's done, or once its RPC has closed.
+    subscriber.status.whenComplete { _ in
+      self.unsubscribe(id)
+    }
+    context.closeFuture.whenSuccess {
+      subscriber.closed()
+    }
+
+    return subscriber.status
+  }
+
+  /// Publishes a response to all current subscribers.
+  public func publish(_ response: Response) {
+    let subscribers = self.lock.withLock { Array(self.subscribers.values) }
+    for subscriber in subscribers {
+      subscriber.enqueue(response)
+    }
+  }
+
+  /// Finishes the broadcast: each subscriber's RPC ends with `status` once all of its buffered
+  /// responses have been written. RPCs which subscribe later end immediately with `status`.
+  ///
+  /// - Parameter status: The status to end RPCs with. Defaults to `.ok`.
+  public func finish(status: GRPCStatus = .ok) {
+    let subscribers: [Subscriber] = self.lock.withLock {
+      guard self.finalStatus == nil else {
+        return []
+      }
+      self.finalStatus = status
+      defer {
+        self.subscribers.removeAll()
+      }
+      return Array(self.subscribers.values)
+    }
+
+    for subscriber in subscribers {
+      subscriber.finish(status: status)
+    }
+  }
+
+  private func unsubscribe(_ id: ObjectIdentifier) {
+    self.lock.withLockVoid {
+      _ = self.subscribers.removeValue(forKey: id)
+    }
+  }
+}
+
+extension ResponseBroadcaster {
+  /// A single subscribed RPC. All state is only accessed on the RPC's `EventLoop`.
+  private final class Subscriber {
+    private let context: StreamingResponseCallContext<Response>
+    private let maximumBufferedResponses: Int
+    private let policy: SlowSubscriberPolicy
+    private let statusPromise: EventLoopPromise<GRPCStatus>
+
+    private var buffer: CircularBuffer<Response>
+    private var isWriting = false
+    private var pendingStatus: GRPCStatus?
+    private var isFinished = false
+
+    var status: EventLoopFuture<GRPCStatus> {
+      return self.statusPromise.futureResult
+    }
+
+    init(
+      context: StreamingResponseCallContext<Response>,
+      maximumBufferedResponses: Int,
+      policy: SlowSubscriberPolicy
+    ) {
+      self.context = context
+      self.maximumBufferedResponses = maximumBufferedResponses
+      self.policy = policy
+      self.statusPromise = context.eventLoop.makePromise()
+      self.buffer = CircularBuffer(initialCapacity: min(maximumBufferedResponses, 16))
+    }
+
+    func enqueue(_ response: Response) {
+      self.context.eventLoop.execute {
+        self._enqueue(response)
+      }
+    }
+
+    func finish(status: GRPCStatus) {
+      self.context.eventLoop.execute {
+        self._finish(status: status)
+      }
+    }
+
+    func closed() {
+      self.context.eventLoop.execute {
+        guard !self.isFinished else {
+          return
+        }
+        self.buffer.removeAll()
+        self.isFinished = true
+        self.statusPromise.fail(GRPCStatus(code: .unavailable, message: "The RPC was closed"))
+      }
+    }
+
+    private func _enqueue(_ response: Response) {
+      guard !self.isFinished, self.pendingStatus == nil else {
+        return
+      }
+
+      if self.buffer.count >= self.maximumBufferedResponses {
+        switch self.policy.wrapped {
+        case .dropOldest:
+          self.buffer.removeFirst()
+        case .close:
+          self.buffer.removeAll()
+          self.complete(with: GRPCStatus(
+            code: .resourceExhausted,
+            message: "Subscriber fell too far behind the published responses"
+          ))
+          return
+        }
+      }
+
+      self.buffer.append(response)
+      self.writeNextIfPossible()
+    }
+
+    private func _finish(status: GRPCStatus) {
+      guard !self.isFinished, self.pendingStatus == nil else {
+        return
+      }
+
+      self.pendingStatus = status
+      self.writeNextIfPossible()
+    }
+
+    private func writeNextIfPossible() {
+      guard !self.isWriting, !self.isFinished else {
+        return
+      }
+
+      guard let response = self.buffer.popFirst() else {
+        // Nothing left to write: if the broadcast has finished then so has this subscriber.
+        if let status = self.pendingStatus {
+          self.complete(with: status)
+        }
+        return
+      }
+
+      self.isWriting = true
+      self.context.sendResponseAndFlush(response).whenComplete { result in
+        self.isWriting = false
+        switch result {
+        case .success:
+          self.writeNextIfPossible()
+        case let .failure(error):
+          self.buffer.removeAll()
+          self.isFinished = true
+          self.statusPromise.fail(error)
+        }
+      }
+    }
+
+    private func complete(with status: GRPCStatus) {
+      self.isFinished = true
+      self.statusPromise.succeed(status)
+    }
+  }
+}
@@ -0,0 +1,66 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Dispatch
+import NIO
+
+extension ServerCallContext {
+  /// Runs `body` on the given queue and returns a future on this call's `eventLoop` which is
+  /// completed with its result.
+  ///
+  /// Service providers are called on the `EventLoop` of the RPC, which is shared with other RPCs
+  /// and connections. Blocking or CPU intensive work should be offloaded so that it doesn't stall
+  /// them:
+  ///
+  /// ```
+  /// func get(
+  ///   request: Echo_EchoRequest,
+  ///   context: StatusOnlyCallContext
+  /// ) -> EventLoopFuture<Echo_EchoResponse> {
+  ///   return context.offload(to: self.workQueue) {
+  ///     try self.database.lookUp(request.text)
+  ///   }.map { text in
+  ///     Echo_EchoResponse.with { $0.text = text }
+  ///   }
+  /// }
+  /// ```
+  ///
+  /// - Parameters:
+  ///   - queue: The queue to run `body` on.
+  ///   - body: The work to perform.
+  /// - Returns: A future completed on `eventLoop` with the value returned or error thrown by
+  ///   `body`.
+  public func offload<Value>(
+    to queue: DispatchQueue,
+    _ body: @escaping () throws -> Value
+  ) -> EventLoopFuture<Value> {
+    return self.eventLoop.offload(to: queue, body)
+  }
+}
+
+extension EventLoop {
+  /// Runs `body` on `queue` and returns a future on this event loop which is completed with its
+  /// result.
+  internal func offload<Value>(
+    to queue: DispatchQueue,
+    _ body: @escaping () throws -> Value
+  ) -> EventLoopFuture<Value> {
+    let promise = self.makePromise(of: Value.self)
+    queue.async {
+      promise.completeWith(Result(catching: body))
+    }
+    return promise.futureResult
+  }
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOHPACK
+
+extension ServerCallContext {
+  /// Returns call options for an RPC made while handling this call which inherit its deadline
+  /// and selected request metadata, such as trace IDs.
+  ///
+  /// The deadline of the returned options is the earlier of this call's `deadline` and the time
+  /// limit of `options`; it is sent to the next server as a recomputed 'grpc-timeout'. Request
+  /// headers named in `metadataKeys` are copied into `customMetadata` unless `options` already
+  /// has a value for that name. Names are matched case-insensitively.
+  ///
+  /// For example, to make a downstream call from within a handler:
+  ///
+  /// ```
+  /// let options = context.propagatingCallOptions(metadataKeys: ["x-trace-id", "x-tenant"])
+  /// let response = downstream.get(request, callOptions: options).response
+  /// ```
+  ///
+  /// - Parameters:
+  ///   - options: The options to start from. Defaults to `CallOptions()`.
+  ///   - metadataKeys: The names of request headers to propagate.
+  /// - Returns: Call options for a downstream RPC.
+  public func propagatingCallOptions(
+    _ options: CallOptions = CallOptions(),
+    metadataKeys: [String]
+  ) -> CallOptions {
+    var options = options
+
+    let deadline = min(self.deadline, options.timeLimit.makeDeadline())
+    if deadline != .distantFuture {
+      options.timeLimit = .deadline(deadline)
+    }
+
+    for key in metadataKeys where !options.customMetadata.contains(name: key) {
+      for value in self.headers[key] {
+        options.customMetadata.add(name: key.lowercased(), value: value)
+      }
+    }
+
+    return options
+  }
+}
@@ -18,6 +18,7 @@ import Logging
 import NIO
 import NIOHPACK
 import NIOHTTP1
+import NIOHTTP2
 import SwiftProtobuf
 
 /// Protocol declaring a minimum set of properties exposed by *all* types of call contexts.
@@ -42,6 +43,19 @@ public protocol ServerCallContext: AnyObject {
   /// A future which completes when the call closes. This may be used to register callbacks which
   /// free up resources used by the RPC.
   var closeFuture: EventLoopFuture<Void> { get }
+
+  /// The deadline for the call, derived from the 'grpc-timeout' sent by the client, or
+  /// `.distantFuture` if the client didn't send a timeout. The server fails the RPC with status
+  /// code `.deadlineExceeded` if the deadline passes before the RPC completes.
+  var deadline: NIODeadline { get }
+
+  /// The ID of the HTTP/2 stream the call is being served on, if known. The stream ID is stable
+  /// for the lifetime of the call and is also included in the logger's metadata.
+  var streamID: HTTP2StreamID? { get }
+
+  /// State shared by all RPCs on the connection the call is being served on, if known. This may
+  /// be used to store connection-scoped state, such as the result of an authentication handshake.
+  var connection: ConnectionContext? { get }
 }
 
 extension ServerCallContext {
@@ -49,6 +63,21 @@ extension ServerCallContext {
   public var closeFuture: EventLoopFuture<Void> {
     return self.eventLoop.makeFailedFuture(GRPCStatus.closeFutureNotImplemented)
   }
+
+  // Default implementation to avoid breaking API.
+  public var deadline: NIODeadline {
+    return .distantFuture
+  }
+
+  // Default implementation to avoid breaking API.
+  public var streamID: HTTP2StreamID? {
+    return nil
+  }
+
+  // Default implementation to avoid breaking API.
+  public var connection: ConnectionContext? {
+    return nil
+  }
 }
 
 extension GRPCStatus {
@@ -133,6 +162,19 @@ open class ServerCallContextBase: ServerCallContext {
   /// free up resources used by the RPC.
   public let closeFuture: EventLoopFuture<Void>
 
+  /// The deadline for the call, derived from the 'grpc-timeout' sent by the client, or
+  /// `.distantFuture` if the client didn't send a timeout. The server fails the RPC with status
+  /// code `.deadlineExceeded` if the deadline passes before the RPC completes.
+  public let deadline: NIODeadline
+
+  /// The ID of the HTTP/2 stream the call is being served on, if known. The stream ID is stable
+  /// for the lifetime of the call and is also included in the logger's metadata.
+  public let streamID: HTTP2StreamID?
+
+  /// State shared by all RPCs on the connection the call is being served on, if known. This may
+  /// be used to store connection-scoped state, such as the result of an authentication handshake.
+  public let connection: ConnectionContext?
+
   @available(*, deprecated, renamed: "init(eventLoop:headers:logger:userInfo:closeFuture:)")
   public convenience init(
     eventLoop: EventLoop,
@@ -145,7 +187,9 @@ open class ServerCallContextBase: ServerCallContext {
       headers: headers,
       logger: logger,
       userInfoRef: .init(userInfo),
-      closeFuture: eventLoop.makeFailedFuture(GRPCStatus.closeFutureNotImplemented)
+      closeFuture: eventLoop.makeFailedFuture(GRPCStatus.closeFutureNotImplemented),
+      streamID: nil,
+      connection: nil
     )
   }
 
@@ -161,7 +205,9 @@ open class ServerCallContextBase: ServerCallContext {
       headers: headers,
       logger: logger,
       userInfoRef: .init(userInfo),
-      closeFuture: closeFuture
+      closeFuture: closeFuture,
+      streamID: nil,
+      connection: nil
     )
   }
 
@@ -171,12 +217,19 @@ open class ServerCallContextBase: ServerCallContext {
     headers: HPACKHeaders,
     logger: Logger,
     userInfoRef: Ref<UserInfo>,
-    closeFuture: EventLoopFuture<Void>
+    closeFuture: EventLoopFuture<Void>,
+    streamID: HTTP2StreamID?,
+    connection: ConnectionContext?,
+    deadline: NIODeadline? = nil
   ) {
     self.eventLoop = eventLoop
     self.headers = headers
     self.userInfoRef = userInfoRef
     self.logger = logger
     self.closeFuture = closeFuture
+    // The server passes the deadline it enforces; otherwise derive it from the headers.
+    self.deadline = deadline ?? GRPCTimeout.deadline(fromRequestHeaders: headers)
+    self.streamID = streamID
+    self.connection = connection
   }
 }
@@ -0,0 +1,89 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+extension StreamingResponseCallContext {
+  /// Makes a stream event observer for a bidirectional streaming RPC which responds to each
+  /// request with exactly one response, in order, for example:
+  ///
+  /// ```
+  /// func update(
+  ///   context: StreamingResponseCallContext<Echo_EchoResponse>
+  /// ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+  ///   return context.echoStream { request in
+  ///     Echo_EchoResponse.with { $0.text = request.text }
+  ///   }
+  /// }
+  /// ```
+  ///
+  /// Responses are written one at a time: each response is sent once the previous one has been
+  /// written, so responses are sent no faster than the client reads them. Requests received in
+  /// the meantime are transformed and their responses queued. Once the client has finished
+  /// sending requests and the last response has been written the RPC ends with status `.ok`. If
+  /// writing a response fails then no further responses are sent and the RPC ends with that error.
+  ///
+  /// If `transform` throws then the RPC ends with the thrown error, which is converted to a
+  /// status in the same way as errors thrown by other handlers; any further requests are
+  /// ignored.
+  ///
+  /// - Parameter transform: Makes the response for a request. Called on the `eventLoop` as each
+  ///     request is received.
+  /// - Returns: A future stream event observer, to be returned from the handler.
+  public func echoStream<Request>(
+    _ transform: @escaping (Request) throws -> ResponsePayload
+  ) -> EventLoopFuture<(StreamEvent<Request>) -> Void> {
+    // Each response is written after the previous one, so this completes once every response
+    // sent so far has been written or fails with the first write to fail.
+    var lastResponse = self.eventLoop.makeSucceededVoidFuture()
+    var finished = false
+
+    let finish = { (result: Result<GRPCStatus, Error>) in
+      if !finished {
+        finished = true
+        self.statusPromise.completeWith(result)
+      }
+    }
+
+    return self.eventLoop.makeSucceededFuture({ event in
+      guard !finished else {
+        return
+      }
+
+      switch event {
+      case let .message(request):
+        let response: ResponsePayload
+        do {
+          response = try transform(request)
+        } catch {
+          finish(.failure(error))
+          return
+        }
+
+        lastResponse = lastResponse.flatMap {
+          self.sendResponse(response)
+        }
+        lastResponse.whenFailure { error in
+          finish(.failure(error))
+        }
+
+      case .end:
+        lastResponse.whenSuccess {
+          finish(.success(.ok))
+        }
+      }
+    })
+  }
+}
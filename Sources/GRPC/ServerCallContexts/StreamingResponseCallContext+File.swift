@@ -0,0 +1,65 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+extension StreamingResponseCallContext where ResponsePayload == GRPCRawPayload {
+  /// Sends the contents of the file at `path` as a sequence of raw response messages, each holding
+  /// at most `chunkSize` bytes of the file. The file is read with `fileIO` and the next chunk is
+  /// only read once the previous one has been written, so at most one chunk per RPC is held in
+  /// memory regardless of the size of the file.
+  ///
+  /// Chunks are sent as `GRPCRawPayload`s without passing through `Data` or a
+  /// `SwiftProtobuf.Message`.
+  /// A `FileRegion` can't be sent directly: each gRPC message must be length-prefixed and may be
+  /// compressed, and the connection may be encrypted.
+  ///
+  /// Each chunk is flushed as it is sent, even if `flushesResponsesAutomatically` is `false`.
+  ///
+  /// The returned future completes once the last chunk has been written; it fails if the file
+  /// couldn't be read or a chunk couldn't be sent, or with a `GRPCError.InvalidState` if
+  /// `chunkSize` isn't greater than zero. The RPC is not completed: the caller should do so with
+  /// an appropriate status.
+  ///
+  /// - Parameters:
+  ///   - path: The path of the file to send.
+  ///   - chunkSize: The maximum number of bytes of the file to send in each message, defaults to
+  ///     64KiB.
+  ///   - fileIO: Used to read the file without blocking the event loop.
+  public func sendFile(
+    atPath path: String,
+    chunkSize: Int = 64 * 1024,
+    fileIO: NonBlockingFileIO
+  ) -> EventLoopFuture<Void> {
+    guard chunkSize > 0 else {
+      return self.eventLoop.makeFailedFuture(
+        GRPCError.InvalidState("chunkSize must be greater than zero (but was \(chunkSize))")
+      )
+    }
+
+    return fileIO.openFile(path: path, eventLoop: self.eventLoop).flatMap { handle, region in
+      fileIO.readChunked(
+        fileRegion: region,
+        chunkSize: chunkSize,
+        allocator: ByteBufferAllocator(),
+        eventLoop: self.eventLoop
+      ) { chunk in
+        self.sendResponseAndFlush(GRPCRawPayload(chunk))
+      }.always { _ in
+        try? handle.close()
+      }
+    }
+  }
+}
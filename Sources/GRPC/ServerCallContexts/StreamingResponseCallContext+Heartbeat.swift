@@ -0,0 +1,129 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+
+extension StreamingResponseCallContext {
+  /// Sends a heartbeat response to the client whenever no other response has been sent for an
+  /// interval, until the call closes or `stopHeartbeats()` is called.
+  ///
+  /// Proxies and load balancers often close streams on which no data has been sent for some time.
+  /// Long-lived response streams which only send responses occasionally, such as notification
+  /// streams, can use heartbeats to keep the stream open. The heartbeat must be a message the
+  /// client knows to ignore, for example:
+  ///
+  /// ```
+  /// try context.sendHeartbeats(every: .seconds(30)) {
+  ///   Notification.with { $0.isHeartbeat = true }
+  /// }
+  /// ```
+  ///
+  /// A heartbeat is sent once `interval` has elapsed since the last response (or heartbeat) was
+  /// sent. Heartbeats are flushed as they are sent, even if `flushesResponsesAutomatically` is
+  /// `false`. Calling this again replaces the existing heartbeat.
+  ///
+  /// HTTP/2 PINGs can't be sent for a single RPC since they apply to the whole connection. To keep
+  /// connections (rather than streams) open, configure keepalive on the server using
+  /// `ServerConnectionKeepalive`.
+  ///
+  /// This may be called from any thread.
+  ///
+  /// - Parameters:
+  ///   - interval: How long the stream must be idle before a heartbeat is sent.
+  ///   - makeHeartbeat: Makes the heartbeat response to send.
+  /// - Throws: `GRPCError.InvalidState` if `interval` isn't greater than zero.
+  public func sendHeartbeats(
+    every interval: TimeAmount,
+    _ makeHeartbeat: @escaping () -> ResponsePayload
+  ) throws {
+    guard interval.nanoseconds > 0 else {
+      throw GRPCError.InvalidState(
+        "The heartbeat interval must be greater than zero (but was \(interval.nanoseconds)ns)"
+      )
+    }
+
+    if self.eventLoop.inEventLoop {
+      self._sendHeartbeats(every: interval, makeHeartbeat)
+    } else {
+      self.eventLoop.execute {
+        self._sendHeartbeats(every: interval, makeHeartbeat)
+      }
+    }
+  }
+
+  /// Stops sending heartbeats started by `sendHeartbeats(every:_:)`.
+  ///
+  /// This may be called from any thread.
+  public func stopHeartbeats() {
+    if self.eventLoop.inEventLoop {
+      self._stopHeartbeats()
+    } else {
+      self.eventLoop.execute {
+        self._stopHeartbeats()
+      }
+    }
+  }
+
+  /// Called whenever a response is sent: the next heartbeat is due `interval` after it.
+  @usableFromInline
+  internal func responseWasSent() {
+    if self.heartbeat != nil {
+      self.scheduleHeartbeat()
+    }
+  }
+
+  private func _sendHeartbeats(
+    every interval: TimeAmount,
+    _ makeHeartbeat: @escaping () -> ResponsePayload
+  ) {
+    self.eventLoop.assertInEventLoop()
+    self._stopHeartbeats()
+
+    self.heartbeat = (interval, makeHeartbeat)
+    self.scheduleHeartbeat()
+
+    self.closeFuture.whenSuccess {
+      self._stopHeartbeats()
+    }
+  }
+
+  private func scheduleHeartbeat() {
+    self.eventLoop.assertInEventLoop()
+    guard let heartbeat = self.heartbeat else {
+      return
+    }
+
+    self.heartbeatTask?.cancel()
+    self.heartbeatTask = self.eventLoop.scheduleTask(in: heartbeat.interval) {
+      self.heartbeatTask = nil
+      // Sending the heartbeat schedules the next one.
+      self.sendResponseAndFlush(heartbeat.makeHeartbeat()).whenFailure { _ in
+        // The response stream has most likely ended.
+        self._stopHeartbeats()
+      }
+      // Subclasses which don't report sent responses won't have scheduled the next heartbeat.
+      if self.heartbeatTask == nil {
+        self.scheduleHeartbeat()
+      }
+    }
+  }
+
+  private func _stopHeartbeats() {
+    self.eventLoop.assertInEventLoop()
+    self.heartbeat = nil
+    self.heartbeatTask?.cancel()
+    self.heartbeatTask = nil
+  }
+}
@@ -18,6 +18,7 @@ import Logging
 import NIO
 import NIOHPACK
 import NIOHTTP1
+import NIOHTTP2
 import SwiftProtobuf
 
 /// An abstract base class for a context provided to handlers for RPCs which may return multiple
@@ -31,6 +32,36 @@ open class StreamingResponseCallContext<ResponsePayload>: ServerCallContextBase
   /// handler.
   public let statusPromise: EventLoopPromise<GRPCStatus>
 
+  /// How often to send heartbeats and how to make them, if enabled. Only accessed on the
+  /// `eventLoop`.
+  internal var heartbeat: (interval: TimeAmount, makeHeartbeat: () -> ResponsePayload)?
+
+  /// Sends the next heartbeat, if enabled. Only accessed on the `eventLoop`.
+  internal var heartbeatTask: Scheduled<Void>?
+
+  /// Whether each response is flushed to the network as soon as it is sent, defaulting to `true`.
+  ///
+  /// If `false` then responses are buffered until `flush()` is called or the RPC ends, at which
+  /// point any buffered responses are always flushed. This may be used to coalesce bursts of
+  /// responses into fewer writes. Responses passed to `sendResponses(_:compression:promise:)` are
+  /// flushed once, after the last response, if this is `true`.
+  ///
+  /// - Important: This *must* be accessed from the context's `eventLoop` in order to ensure
+  ///   thread-safety.
+  public var flushesResponsesAutomatically: Bool {
+    get {
+      self.eventLoop.assertInEventLoop()
+      return self._flushesResponsesAutomatically
+    }
+    set {
+      self.eventLoop.assertInEventLoop()
+      self._flushesResponsesAutomatically = newValue
+    }
+  }
+
+  @usableFromInline
+  internal var _flushesResponsesAutomatically = true
+
   @available(*, deprecated, renamed: "init(eventLoop:headers:logger:userInfo:closeFuture:)")
   public convenience init(
     eventLoop: EventLoop,
@@ -43,7 +74,9 @@ open class StreamingResponseCallContext<ResponsePayload>: ServerCallContextBase
       headers: headers,
       logger: logger,
       userInfoRef: .init(userInfo),
-      closeFuture: eventLoop.makeFailedFuture(GRPCStatus.closeFutureNotImplemented)
+      closeFuture: eventLoop.makeFailedFuture(GRPCStatus.closeFutureNotImplemented),
+      streamID: nil,
+      connection: nil
     )
   }
 
@@ -59,7 +92,9 @@ open class StreamingResponseCallContext<ResponsePayload>: ServerCallContextBase
       headers: headers,
       logger: logger,
       userInfoRef: .init(userInfo),
-      closeFuture: closeFuture
+      closeFuture: closeFuture,
+      streamID: nil,
+      connection: nil
     )
   }
 
@@ -69,7 +104,10 @@ open class StreamingResponseCallContext<ResponsePayload>: ServerCallContextBase
     headers: HPACKHeaders,
     logger: Logger,
     userInfoRef: Ref<UserInfo>,
-    closeFuture: EventLoopFuture<Void>
+    closeFuture: EventLoopFuture<Void>,
+    streamID: HTTP2StreamID?,
+    connection: ConnectionContext?,
+    deadline: NIODeadline? = nil
   ) {
     self.statusPromise = eventLoop.makePromise()
     super.init(
@@ -77,12 +115,45 @@ open class StreamingResponseCallContext<ResponsePayload>: ServerCallContextBase
       headers: headers,
       logger: logger,
       userInfoRef: userInfoRef,
-      closeFuture: closeFuture
+      closeFuture: closeFuture,
+      streamID: streamID,
+      connection: connection,
+      deadline: deadline
     )
   }
 
+  /// Send response headers to the client.
+  ///
+  /// Response headers may only be sent once and must be sent before any responses. If they are
+  /// not sent by the time the handler (or observer factory) returns then empty headers are sent
+  /// on your behalf. Headers sent after that point, or sent more than once, will fail `promise`.
+  ///
+  /// This should be called on the `eventLoop` before the handler (or observer factory) returns.
+  /// Calls from other threads are executed on the `eventLoop` and so may run after empty headers
+  /// have been sent on your behalf, failing `promise`.
+  ///
+  /// - Parameters:
+  ///   - headers: The response headers to send to the client.
+  ///   - promise: A promise to complete once the headers have been sent.
+  open func sendHeaders(_ headers: HPACKHeaders, promise: EventLoopPromise<Void>?) {
+    fatalError("needs to be overridden")
+  }
+
+  /// Send response headers to the client.
+  ///
+  /// - Parameter headers: The response headers to send to the client.
+  open func sendHeaders(_ headers: HPACKHeaders) -> EventLoopFuture<Void> {
+    let promise = self.eventLoop.makePromise(of: Void.self)
+    self.sendHeaders(headers, promise: promise)
+    return promise.futureResult
+  }
+
   /// Send a response to the client.
   ///
+  /// This may be called from any thread. Each response is written atomically on the `eventLoop`,
+  /// however responses sent concurrently from multiple threads are not guaranteed to be written in
+  /// the order they were sent. Use a `SerialRPCWriter` if ordering is required.
+  ///
   /// - Parameters:
   ///   - message: The message to send to the client.
   ///   - compression: Whether compression should be used for this response. If compression
@@ -142,6 +213,50 @@ open class StreamingResponseCallContext<ResponsePayload>: ServerCallContextBase
     self.sendResponses(messages, compression: compression, promise: promise)
     return promise.futureResult
   }
+
+  /// Flushes any responses which have been sent but not yet flushed to the network. This is only
+  /// necessary if `flushesResponsesAutomatically` is `false`; a response which must be delivered
+  /// promptly may be followed by a call to `flush()`.
+  ///
+  /// Responses are flushed in the order they were sent. Responses which are being held by an
+  /// interceptor (for example, one which sends them asynchronously) when this is called are not
+  /// flushed until the next flush.
+  ///
+  /// This may be called from any thread.
+  ///
+  /// The default implementation does nothing: it is suitable for subclasses which flush every
+  /// response as it is sent.
+  open func flush() {}
+
+  /// Sends a response and flushes it, regardless of `flushesResponsesAutomatically`.
+  ///
+  /// This is used by helpers which wait for each write to complete before sending the next
+  /// response: the promise of a write isn't completed until it has been flushed, so without the
+  /// flush they would wait forever when responses are flushed manually.
+  ///
+  /// - Parameter message: The message to send to the client.
+  /// - Returns: A future which is completed once the message has been sent.
+  internal func sendResponseAndFlush(_ message: ResponsePayload) -> EventLoopFuture<Void> {
+    let promise = self.eventLoop.makePromise(of: Void.self)
+
+    if self.eventLoop.inEventLoop {
+      self._sendResponseAndFlush(message, promise: promise)
+    } else {
+      self.eventLoop.execute {
+        self._sendResponseAndFlush(message, promise: promise)
+      }
+    }
+
+    return promise.futureResult
+  }
+
+  private func _sendResponseAndFlush(_ message: ResponsePayload, promise: EventLoopPromise<Void>) {
+    self.eventLoop.assertInEventLoop()
+    self.sendResponse(message, promise: promise)
+    if !self._flushesResponsesAutomatically {
+      self.flush()
+    }
+  }
 }
 
 /// A concrete implementation of `StreamingResponseCallContext` used internally.
@@ -151,6 +266,12 @@ internal final class _StreamingResponseCallContext<Request, Response>:
   @usableFromInline
   internal let _sendResponse: (Response, MessageMetadata, EventLoopPromise<Void>?) -> Void
 
+  @usableFromInline
+  internal let _sendHeaders: (HPACKHeaders, EventLoopPromise<Void>?) -> Void
+
+  @usableFromInline
+  internal let _flush: () -> Void
+
   @usableFromInline
   internal let _compressionEnabledOnServer: Bool
 
@@ -162,16 +283,26 @@ internal final class _StreamingResponseCallContext<Request, Response>:
     userInfoRef: Ref<UserInfo>,
     compressionIsEnabled: Bool,
     closeFuture: EventLoopFuture<Void>,
-    sendResponse: @escaping (Response, MessageMetadata, EventLoopPromise<Void>?) -> Void
+    streamID: HTTP2StreamID?,
+    connection: ConnectionContext?,
+    deadline: NIODeadline? = nil,
+    sendHeaders: @escaping (HPACKHeaders, EventLoopPromise<Void>?) -> Void,
+    sendResponse: @escaping (Response, MessageMetadata, EventLoopPromise<Void>?) -> Void,
+    flush: @escaping () -> Void = {}
   ) {
+    self._sendHeaders = sendHeaders
     self._sendResponse = sendResponse
+    self._flush = flush
     self._compressionEnabledOnServer = compressionIsEnabled
     super.init(
       eventLoop: eventLoop,
       headers: headers,
       logger: logger,
       userInfoRef: userInfoRef,
-      closeFuture: closeFuture
+      closeFuture: closeFuture,
+      streamID: streamID,
+      connection: connection,
+      deadline: deadline
     )
   }
 
@@ -183,6 +314,17 @@ internal final class _StreamingResponseCallContext<Request, Response>:
     return compression.isEnabled(callDefault: self.compressionEnabled)
   }
 
+  @inlinable
+  override func sendHeaders(_ headers: HPACKHeaders, promise: EventLoopPromise<Void>?) {
+    if self.eventLoop.inEventLoop {
+      self._sendHeaders(headers, promise)
+    } else {
+      self.eventLoop.execute {
+        self._sendHeaders(headers, promise)
+      }
+    }
+  }
+
   @inlinable
   override func sendResponse(
     _ message: Response,
@@ -191,11 +333,15 @@ internal final class _StreamingResponseCallContext<Request, Response>:
   ) {
     if self.eventLoop.inEventLoop {
       let compress = self.shouldCompress(compression)
-      self._sendResponse(message, .init(compress: compress, flush: true), promise)
+      self.responseWasSent()
+      let flush = self._flushesResponsesAutomatically
+      self._sendResponse(message, .init(compress: compress, flush: flush), promise)
     } else {
       self.eventLoop.execute {
         let compress = self.shouldCompress(compression)
-        self._sendResponse(message, .init(compress: compress, flush: true), promise)
+        self.responseWasSent()
+        let flush = self._flushesResponsesAutomatically
+        self._sendResponse(message, .init(compress: compress, flush: flush), promise)
       }
     }
   }
@@ -229,7 +375,21 @@ internal final class _StreamingResponseCallContext<Request, Response>:
       next = iterator.next()
       // Attach the promise, if present, to the last message.
       let isLast = next == nil
-      self._sendResponse(current, .init(compress: compress, flush: isLast), isLast ? promise : nil)
+      let flush = isLast && self._flushesResponsesAutomatically
+      self._sendResponse(current, .init(compress: compress, flush: flush), isLast ? promise : nil)
+    }
+
+    self.responseWasSent()
+  }
+
+  @inlinable
+  override func flush() {
+    if self.eventLoop.inEventLoop {
+      self._flush()
+    } else {
+      self.eventLoop.execute {
+        self._flush()
+      }
     }
   }
 }
@@ -239,6 +399,12 @@ internal final class _StreamingResponseCallContext<Request, Response>:
 /// Simply records all sent messages.
 open class StreamingResponseCallContextTestStub<ResponsePayload>: StreamingResponseCallContext<ResponsePayload> {
   open var recordedResponses: [ResponsePayload] = []
+  open var recordedHeaders: HPACKHeaders?
+
+  override open func sendHeaders(_ headers: HPACKHeaders, promise: EventLoopPromise<Void>?) {
+    self.recordedHeaders = headers
+    promise?.succeed(())
+  }
 
   override open func sendResponse(
     _ message: ResponsePayload,
@@ -246,6 +412,7 @@ open class StreamingResponseCallContextTestStub<ResponsePayload>: StreamingRespo
     promise: EventLoopPromise<Void>?
   ) {
     self.recordedResponses.append(message)
+    self.responseWasSent()
     promise?.succeed(())
   }
 
@@ -255,6 +422,11 @@ open class StreamingResponseCallContextTestStub<ResponsePayload>: StreamingRespo
     promise: EventLoopPromise<Void>?
   ) where ResponsePayload == Messages.Element {
     self.recordedResponses.append(contentsOf: messages)
+    self.responseWasSent()
     promise?.succeed(())
   }
+
+  override open func flush() {
+    // Responses are recorded as they are sent: there's nothing to flush.
+  }
 }
@@ -18,6 +18,7 @@ import Logging
 import NIO
 import NIOHPACK
 import NIOHTTP1
+import NIOHTTP2
 import SwiftProtobuf
 
 /// A context provided to handlers for RPCs which return a single response, i.e. unary and client
@@ -63,7 +64,9 @@ open class UnaryResponseCallContext<Response>: ServerCallContextBase, StatusOnly
       headers: headers,
       logger: logger,
       userInfoRef: .init(userInfo),
-      closeFuture: eventLoop.makeFailedFuture(GRPCStatus.closeFutureNotImplemented)
+      closeFuture: eventLoop.makeFailedFuture(GRPCStatus.closeFutureNotImplemented),
+      streamID: nil,
+      connection: nil
     )
   }
 
@@ -79,7 +82,9 @@ open class UnaryResponseCallContext<Response>: ServerCallContextBase, StatusOnly
       headers: headers,
       logger: logger,
       userInfoRef: .init(userInfo),
-      closeFuture: closeFuture
+      closeFuture: closeFuture,
+      streamID: nil,
+      connection: nil
     )
   }
 
@@ -89,7 +94,10 @@ open class UnaryResponseCallContext<Response>: ServerCallContextBase, StatusOnly
     headers: HPACKHeaders,
     logger: Logger,
     userInfoRef: Ref<UserInfo>,
-    closeFuture: EventLoopFuture<Void>
+    closeFuture: EventLoopFuture<Void>,
+    streamID: HTTP2StreamID?,
+    connection: ConnectionContext?,
+    deadline: NIODeadline? = nil
   ) {
     self.responsePromise = eventLoop.makePromise()
     super.init(
@@ -97,7 +105,10 @@ open class UnaryResponseCallContext<Response>: ServerCallContextBase, StatusOnly
       headers: headers,
       logger: logger,
       userInfoRef: userInfoRef,
-      closeFuture: closeFuture
+      closeFuture: closeFuture,
+      streamID: streamID,
+      connection: connection,
+      deadline: deadline
     )
   }
 }
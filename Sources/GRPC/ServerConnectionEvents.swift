@@ -0,0 +1,136 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Dispatch
+import NIO
+import NIOTLS
+
+/// An event in the lifecycle of a connection accepted by a server.
+public enum ServerConnectionEvent {
+  /// A connection from the peer at the given address was accepted.
+  case connectionAccepted(remoteAddress: SocketAddress?)
+
+  /// The TLS handshake with the peer at the given address failed. The connection is closed
+  /// afterwards.
+  case handshakeFailed(remoteAddress: SocketAddress?, error: Error)
+
+  /// The connection to the peer at the given address closed. The `reason` is the first error
+  /// seen on the connection, or `nil` if it closed without error, for example because it was
+  /// idle or the peer closed it.
+  case connectionClosed(remoteAddress: SocketAddress?, reason: Error?)
+}
+
+/// A delegate which observes the lifecycle of connections accepted by a server. This may be used
+/// to track the number of active connections or TLS handshake failures.
+public protocol ServerConnectionEventDelegate: AnyObject {
+  /// Called when an event occurs on a connection. Events for a connection are delivered in order.
+  ///
+  /// - Parameter event: The event which occurred.
+  func connectionEventDidOccur(_ event: ServerConnectionEvent)
+}
+
+/// Reports the lifecycle of a connection to a `ServerConnectionEventDelegate`. Must be added
+/// to the pipeline after the TLS handler, if there is one.
+internal final class ServerConnectionEventHandler: ChannelInboundHandler {
+  typealias InboundIn = Any
+  typealias InboundOut = Any
+
+  private let delegate: ServerConnectionEventDelegate
+  private let queue: DispatchQueue
+
+  /// Whether a TLS handshake must complete before the connection is ready.
+  private let expectsHandshake: Bool
+
+  private var state: State = .idle
+
+  private enum State {
+    case idle
+    case active(remoteAddress: SocketAddress?, handshakeCompleted: Bool, reason: Error?)
+    case closed
+  }
+
+  internal init(
+    delegate: ServerConnectionEventDelegate,
+    queue: DispatchQueue,
+    expectsHandshake: Bool
+  ) {
+    self.delegate = delegate
+    self.queue = queue
+    self.expectsHandshake = expectsHandshake
+  }
+
+  private func emit(_ event: ServerConnectionEvent) {
+    self.queue.async {
+      self.delegate.connectionEventDidOccur(event)
+    }
+  }
+
+  private func activate(context: ChannelHandlerContext) {
+    guard case .idle = self.state else {
+      return
+    }
+
+    let remoteAddress = context.channel.remoteAddress
+    self.state = .active(
+      remoteAddress: remoteAddress,
+      handshakeCompleted: !self.expectsHandshake,
+      reason: nil
+    )
+    self.emit(.connectionAccepted(remoteAddress: remoteAddress))
+  }
+
+  internal func handlerAdded(context: ChannelHandlerContext) {
+    if context.channel.isActive {
+      self.activate(context: context)
+    }
+  }
+
+  internal func channelActive(context: ChannelHandlerContext) {
+    self.activate(context: context)
+    context.fireChannelActive()
+  }
+
+  internal func userInboundEventTriggered(context: ChannelHandlerContext, event: Any) {
+    if case .handshakeCompleted? = event as? TLSUserEvent,
+      case let .active(remoteAddress, _, reason) = self.state {
+      self.state = .active(remoteAddress: remoteAddress, handshakeCompleted: true, reason: reason)
+    }
+    context.fireUserInboundEventTriggered(event)
+  }
+
+  internal func errorCaught(context: ChannelHandlerContext, error: Error) {
+    // Only the first error is reported.
+    if case let .active(remoteAddress, handshakeCompleted, nil) = self.state {
+      self.state = .active(
+        remoteAddress: remoteAddress,
+        handshakeCompleted: handshakeCompleted,
+        reason: error
+      )
+
+      if !handshakeCompleted {
+        self.emit(.handshakeFailed(remoteAddress: remoteAddress, error: error))
+      }
+    }
+    context.fireErrorCaught(error)
+  }
+
+  internal func channelInactive(context: ChannelHandlerContext) {
+    if case let .active(remoteAddress, _, reason) = self.state {
+      self.state = .closed
+      self.emit(.connectionClosed(remoteAddress: remoteAddress, reason: reason))
+    }
+    context.fireChannelInactive()
+  }
+}
@@ -79,6 +79,17 @@ internal enum ServerErrorProcessor {
       } else {
         mergedTrailers = trailers
       }
+    } else if let details = error as? GRPCStatusDetails {
+      // Send the details in the trailers along with the status.
+      var trailers = trailers
+      do {
+        let value = try details.makeTrailerValue()
+        trailers.replaceOrAdd(name: GRPCStatusDetails.trailerName, value: value)
+        status = details.makeGRPCStatus()
+      } catch {
+        status = GRPCStatus(code: .internalError, message: "Unable to serialize status details")
+      }
+      mergedTrailers = trailers
     } else if let grpcStatusTransformable = error as? GRPCStatusTransformable {
       status = grpcStatusTransformable.makeGRPCStatus()
       mergedTrailers = trailers
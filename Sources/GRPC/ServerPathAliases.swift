@@ -0,0 +1,164 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/// An error thrown when a path alias can't be registered on a server.
+public struct PathAliasError: Error, Hashable, CustomStringConvertible {
+  /// The reason the alias couldn't be registered.
+  public struct Code: Hashable, CustomStringConvertible {
+    private enum Wrapped: Hashable {
+      case invalidPath
+      case aliasAlreadyRegistered
+      case aliasShadowsMethod
+      case targetIsAlias
+      case unknownTarget
+    }
+
+    private var wrapped: Wrapped
+
+    private init(_ wrapped: Wrapped) {
+      self.wrapped = wrapped
+    }
+
+    /// The alias or the path it routes to isn't of the form "/package.Service/Method".
+    public static let invalidPath = Code(.invalidPath)
+
+    /// An alias has already been registered for the path.
+    public static let aliasAlreadyRegistered = Code(.aliasAlreadyRegistered)
+
+    /// The alias is the path of a method provided by one of the server's service providers.
+    public static let aliasShadowsMethod = Code(.aliasShadowsMethod)
+
+    /// The path the alias routes to is itself an alias, or the alias is the target of another
+    /// alias. Aliases may not be chained.
+    public static let targetIsAlias = Code(.targetIsAlias)
+
+    /// The path the alias routes to isn't a method provided by one of the server's service
+    /// providers.
+    public static let unknownTarget = Code(.unknownTarget)
+
+    public var description: String {
+      switch self.wrapped {
+      case .invalidPath:
+        return "invalid path"
+      case .aliasAlreadyRegistered:
+        return "alias already registered"
+      case .aliasShadowsMethod:
+        return "alias shadows a registered method"
+      case .targetIsAlias:
+        return "aliases may not be chained"
+      case .unknownTarget:
+        return "target is not a registered method"
+      }
+    }
+  }
+
+  /// The reason the alias couldn't be registered.
+  public var code: Code
+
+  /// The alias which couldn't be registered.
+  public var alias: String
+
+  /// The path the alias was to route to.
+  public var path: String
+
+  public var description: String {
+    return "Unable to alias '\(self.alias)' to '\(self.path)': \(self.code)"
+  }
+}
+
+extension Server.Configuration {
+  /// Registers `alias` as an alternative path for the method at `path`. RPCs made to `alias` are
+  /// routed to the handler for `path`, for example:
+  ///
+  /// ```
+  /// try configuration.addAlias(from: "/old.Service/Method", to: "/new.Service/Method")
+  /// ```
+  ///
+  /// This allows services to be renamed without breaking clients which still use the old name.
+  /// Handlers and interceptors see the path the RPC was routed to, i.e. `path`.
+  ///
+  /// Aliases are checked against the methods of the `serviceProviders` when the server is
+  /// started, so aliases and service providers may be set in any order. The server fails to start
+  /// with a `PathAliasError` if an alias is the path of a provided method or if the path it routes
+  /// to isn't a provided method.
+  ///
+  /// - Parameters:
+  ///   - alias: The alternative path, e.g. "/old.Service/Method".
+  ///   - path: The path of the method to route RPCs made to `alias` to, e.g. "/new.Service/Method".
+  /// - Throws: `PathAliasError` if either path is invalid, `alias` is already registered or if
+  ///   the alias would form a chain with another alias.
+  public mutating func addAlias(from alias: String, to path: String) throws {
+    func error(_ code: PathAliasError.Code) -> PathAliasError {
+      return PathAliasError(code: code, alias: alias, path: path)
+    }
+
+    guard CallPath(requestURI: alias) != nil, CallPath(requestURI: path) != nil else {
+      throw error(.invalidPath)
+    }
+
+    guard self.pathAliases[alias] == nil else {
+      throw error(.aliasAlreadyRegistered)
+    }
+
+    guard self.pathAliases[path] == nil, !self.pathAliases.values.contains(alias) else {
+      throw error(.targetIsAlias)
+    }
+
+    self.pathAliases[alias] = path
+  }
+
+  /// An error describing why the path aliases can't be used with the `serviceProviders`, or `nil`
+  /// if they can.
+  internal var pathAliasError: PathAliasError? {
+    guard !self.pathAliases.isEmpty else {
+      return nil
+    }
+
+    let services = self.services
+    func isProvided(_ path: String) -> Bool {
+      return services.contains { $0.methods.contains { $0.path == path } }
+    }
+
+    for (alias, path) in self.pathAliases.sorted(by: { $0.key < $1.key }) {
+      if isProvided(alias) {
+        return PathAliasError(code: .aliasShadowsMethod, alias: alias, path: path)
+      }
+
+      // Providers which don't list their method names can't be checked beyond the service name.
+      let target = CallPath(requestURI: path)!
+      let service = services.first { $0.name.utf8.elementsEqual(target.service) }
+      guard let provider = service, provider.methods.isEmpty || isProvided(path) else {
+        return PathAliasError(code: .unknownTarget, alias: alias, path: path)
+      }
+    }
+
+    return nil
+  }
+}
+
+extension Server.Builder {
+  /// Registers `alias` as an alternative path for the method at `path`. RPCs made to `alias` are
+  /// routed to the handler for `path`. Aliases are checked against the service providers when the
+  /// server is started.
+  ///
+  /// - Throws: `PathAliasError` if the alias can't be registered, see
+  ///   `Server.Configuration.addAlias(from:to:)`.
+  @discardableResult
+  public func withPathAlias(from alias: String, to path: String) throws -> Self {
+    try self.configuration.addAlias(from: alias, to: path)
+    return self
+  }
+}
@@ -0,0 +1,162 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Logging
+import NIO
+import NIOHPACK
+
+/// Metadata the server adds to the `logger` of each RPC.
+///
+/// The logger is available to service providers via `logger` on the call context and to
+/// interceptors via `logger` on the interceptor context, so any logs they emit are correlated with
+/// the RPC they relate to.
+public struct ServerRPCLoggerMetadata {
+  /// Whether the path of the RPC (e.g. "/echo.Echo/Get") is added with the key "grpc_method".
+  public var includeMethod: Bool
+
+  /// Whether the address of the remote peer is added with the key "grpc_peer".
+  public var includePeer: Bool
+
+  /// Whether the 'grpc-timeout' sent by the client, if any, is added with the key "grpc_timeout".
+  public var includeTimeout: Bool
+
+  /// Whether the trace context propagated by the client in the W3C 'traceparent' header, if any,
+  /// is added with the keys "trace_id" and "span_id". The span ID is that of the client's span
+  /// which made the RPC. Nothing is added if the header is missing or malformed, for example
+  /// because the client isn't tracing RPCs.
+  ///
+  /// See: https://www.w3.org/TR/trace-context/#traceparent-header
+  public var includeTraceContext: Bool
+
+  /// Request headers whose values are added, if present. Keys are (case insensitive) header
+  /// names, values are the metadata key to use.
+  public var headers: [String: String]
+
+  /// Creates the metadata configuration.
+  ///
+  /// - Parameters:
+  ///   - includeMethod: Whether to add the path of the RPC, defaults to `true`.
+  ///   - includePeer: Whether to add the address of the remote peer, defaults to `true`.
+  ///   - includeTimeout: Whether to add the 'grpc-timeout' sent by the client, defaults to `true`.
+  ///   - includeTraceContext: Whether to add the trace and span IDs from the 'traceparent' header,
+  ///       defaults to `false`.
+  ///   - headers: Request headers to add, keyed by header name. Defaults to adding the value of
+  ///       "x-request-id" with the key "grpc_request_id".
+  public init(
+    includeMethod: Bool = true,
+    includePeer: Bool = true,
+    includeTimeout: Bool = true,
+    includeTraceContext: Bool = false,
+    headers: [String: String] = ["x-request-id": "grpc_request_id"]
+  ) {
+    self.includeMethod = includeMethod
+    self.includePeer = includePeer
+    self.includeTimeout = includeTimeout
+    self.includeTraceContext = includeTraceContext
+    self.headers = headers
+  }
+
+  /// Don't add any metadata.
+  public static let none = ServerRPCLoggerMetadata(
+    includeMethod: false,
+    includePeer: false,
+    includeTimeout: false,
+    includeTraceContext: false,
+    headers: [:]
+  )
+
+  /// Adds the configured metadata for an RPC with the given request headers to the `logger`.
+  internal func apply(
+    to logger: inout Logger,
+    requestHeaders headers: HPACKHeaders,
+    remoteAddress: SocketAddress?
+  ) {
+    if self.includeMethod, let path = headers.first(name: ":path") {
+      logger[metadataKey: MetadataKey.method] = "\(path)"
+    }
+
+    if self.includePeer, let remoteAddress = remoteAddress {
+      logger[metadataKey: MetadataKey.peer] = "\(remoteAddress)"
+    }
+
+    if self.includeTimeout, let timeout = headers.first(name: GRPCHeaderName.timeout) {
+      logger[metadataKey: MetadataKey.timeout] = "\(timeout)"
+    }
+
+    if self.includeTraceContext,
+      let traceParent = headers.first(name: "traceparent").flatMap(TraceParent.init) {
+      logger[metadataKey: MetadataKey.traceID] = "\(traceParent.traceID)"
+      logger[metadataKey: MetadataKey.spanID] = "\(traceParent.parentID)"
+    }
+
+    for (name, key) in self.headers {
+      // 'first(name:)' is case insensitive.
+      if let value = headers.first(name: name) {
+        logger[metadataKey: key] = "\(value)"
+      }
+    }
+  }
+}
+
+/// The IDs carried by a W3C 'traceparent' header, e.g.
+/// "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01".
+///
+/// See: https://www.w3.org/TR/trace-context/#traceparent-header
+internal struct TraceParent: Hashable {
+  /// The ID of the trace, 32 lowercase hex characters.
+  var traceID: Substring
+
+  /// The ID of the span which made the request, 16 lowercase hex characters.
+  var parentID: Substring
+
+  /// Parses the value of a 'traceparent' header, returning `nil` if it is malformed.
+  init?(_ value: String) {
+    let parts = value.split(separator: "-", omittingEmptySubsequences: false)
+    guard parts.count >= 4 else {
+      return nil
+    }
+
+    // Version "ff" is invalid. Later versions may add fields but must start with these four.
+    let version = parts[0]
+    guard TraceParent.isHex(version, count: 2), version != "ff" else {
+      return nil
+    }
+    if version == "00", parts.count != 4 {
+      return nil
+    }
+
+    let traceID = parts[1]
+    let parentID = parts[2]
+    guard TraceParent.isHex(traceID, count: 32), TraceParent.isHex(parentID, count: 16),
+      TraceParent.isHex(parts[3], count: 2) else {
+      return nil
+    }
+
+    // All zero IDs are invalid.
+    guard traceID.contains(where: { $0 != "0" }), parentID.contains(where: { $0 != "0" }) else {
+      return nil
+    }
+
+    self.traceID = traceID
+    self.parentID = parentID
+  }
+
+  private static func isHex(_ value: Substring, count: Int) -> Bool {
+    return value.utf8.count == count && value.utf8.allSatisfy { byte in
+      (UInt8(ascii: "0") ... UInt8(ascii: "9")).contains(byte) ||
+        (UInt8(ascii: "a") ... UInt8(ascii: "f")).contains(byte)
+    }
+  }
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/// Describes a service provided by a server.
+public struct ServerServiceDescriptor: Hashable {
+  /// The name of the service including its package, e.g. "echo.Echo".
+  public var name: String
+
+  /// The methods provided by the service. This is empty if the service's `CallHandlerProvider`
+  /// doesn't list its `methodNames`; providers generated by `protoc-gen-grpc-swift` always do.
+  public var methods: [ServerMethodDescriptor]
+
+  public init(name: String, methods: [ServerMethodDescriptor]) {
+    self.name = name
+    self.methods = methods
+  }
+}
+
+/// Describes a method provided by a server.
+public struct ServerMethodDescriptor: Hashable {
+  /// The name of the service providing the method, e.g. "echo.Echo".
+  public var serviceName: String
+
+  /// The name of the method, e.g. "Get".
+  public var name: String
+
+  /// The path of the method, e.g. "/echo.Echo/Get".
+  public var path: String {
+    return "/\(self.serviceName)/\(self.name)"
+  }
+
+  public init(serviceName: String, name: String) {
+    self.serviceName = serviceName
+    self.name = name
+  }
+}
+
+extension Server.Configuration {
+  /// Descriptions of the services provided by `serviceProviders`, ordered by service name.
+  public var services: [ServerServiceDescriptor] {
+    return self.serviceProvidersByName.map { name, provider in
+      let serviceName = String(name)
+      let methods = provider.methodNames.map {
+        ServerMethodDescriptor(serviceName: serviceName, name: String($0))
+      }
+      return ServerServiceDescriptor(name: serviceName, methods: methods)
+    }.sorted {
+      $0.name < $1.name
+    }
+  }
+}
@@ -0,0 +1,227 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+
+/// A limit on the number of concurrent RPCs a client may have open on a connection.
+///
+/// RPCs started while the limit is reached wait in a queue for an open RPC to finish instead of
+/// opening a stream straight away. This smooths out bursts of RPCs which would otherwise exceed the
+/// maximum number of concurrent streams permitted by the server and fail.
+///
+/// Queued RPCs are started in order of their `CallOptions.priority` and then in the order they
+/// were made. They wait until their deadline; if the queue is full then new RPCs fail immediately
+/// with status code 8 ('resource exhausted').
+public struct ClientConcurrentStreamLimit: Hashable {
+  /// The maximum number of RPCs which may have a stream open at a time. Must be greater than
+  /// zero.
+  public var maximumConcurrentStreams: Int
+
+  /// The maximum number of RPCs which may wait for a stream to become available. Must not be
+  /// negative.
+  public var maximumQueuedStreams: Int
+
+  /// - Parameters:
+  ///   - maximumConcurrentStreams: The maximum number of RPCs which may have a stream open at a
+  ///     time. Must be greater than zero.
+  ///   - maximumQueuedStreams: The maximum number of RPCs which may wait for a stream to become
+  ///     available. Must not be negative, defaults to 100.
+  public init(maximumConcurrentStreams: Int, maximumQueuedStreams: Int = 100) {
+    self.maximumConcurrentStreams = maximumConcurrentStreams
+    self.maximumQueuedStreams = maximumQueuedStreams
+  }
+}
+
+extension ClientConcurrentStreamLimit {
+  /// An error describing why the limit can't be enforced, or `nil` if the limit is valid.
+  internal var validationError: GRPCError.InvalidState? {
+    guard self.maximumConcurrentStreams > 0 else {
+      return GRPCError.InvalidState(
+        "The maximum number of concurrent streams must be greater than zero "
+          + "(but was \(self.maximumConcurrentStreams))"
+      )
+    }
+
+    guard self.maximumQueuedStreams >= 0 else {
+      return GRPCError.InvalidState(
+        "The maximum number of queued streams must not be negative "
+          + "(but was \(self.maximumQueuedStreams))"
+      )
+    }
+
+    return nil
+  }
+}
+
+/// Enforces a `ClientConcurrentStreamLimit`. Each permit acquired must be released once the stream
+/// it was acquired for has closed, or if the stream couldn't be created.
+internal final class StreamGate {
+  private struct Waiter {
+    var id: Int
+    var priority: CallOptions.Priority
+    var promise: EventLoopPromise<Void>
+    var timeout: Scheduled<Void>?
+  }
+
+  private let limit: ClientConcurrentStreamLimit
+
+  /// The number of permits held. Protected by `lock`.
+  private var permitsHeld = 0
+
+  /// RPCs waiting for a permit, in the order they asked for one (regardless of their priority).
+  /// Protected by `lock`.
+  private var waiters = CircularBuffer<Waiter>()
+
+  /// The ID of the next waiter. Protected by `lock`.
+  private var nextWaiterID = 0
+
+  private let lock = Lock()
+
+  internal init(limit: ClientConcurrentStreamLimit) {
+    self.limit = limit
+  }
+
+  /// Acquire a permit to open a stream.
+  ///
+  /// - Parameters:
+  ///   - eventLoop: The `EventLoop` to complete the returned future on.
+  ///   - timeLimit: The time limit of the RPC.
+  ///   - deadline: The deadline of the RPC; the RPC stops waiting for a permit at this point.
+  ///   - clock: The clock used to schedule the deadline.
+  ///   - priority: The priority of the RPC; higher priority RPCs are given permits first.
+  ///   - abandoned: A future which completes if the RPC no longer needs a permit, for example
+  ///     because it was cancelled. The RPC stops waiting for a permit at this point, freeing its
+  ///     place in the queue.
+  /// - Returns: A future which succeeds when a permit has been acquired, or fails if the queue is
+  ///   full, the deadline passes or the RPC is abandoned first.
+  internal func acquire(
+    on eventLoop: EventLoop,
+    timeLimit: TimeLimit,
+    deadline: NIODeadline,
+    clock: GRPCClock = .system,
+    priority: CallOptions.Priority = .normal,
+    abandoned: EventLoopFuture<Void>? = nil
+  ) -> EventLoopFuture<Void> {
+    let outcome: AcquireOutcome = self.lock.withLock {
+      if self.permitsHeld < self.limit.maximumConcurrentStreams {
+        self.permitsHeld += 1
+        return .acquired
+      }
+
+      guard self.waiters.count < self.limit.maximumQueuedStreams else {
+        return .queueFull
+      }
+
+      let id = self.nextWaiterID
+      self.nextWaiterID += 1
+
+      let timeout: Scheduled<Void>?
+      if deadline == .distantFuture {
+        timeout = nil
+      } else {
+        timeout = clock.scheduleTask(deadline: deadline, on: eventLoop) {
+          self.removeWaiter(withID: id, error: GRPCError.RPCTimedOut(timeLimit))
+        }
+      }
+
+      let promise = eventLoop.makePromise(of: Void.self)
+      self.waiters.append(Waiter(id: id, priority: priority, promise: promise, timeout: timeout))
+      return .queued(id: id, future: promise.futureResult)
+    }
+
+    switch outcome {
+    case .acquired:
+      return eventLoop.makeSucceededFuture(())
+
+    case .queueFull:
+      return eventLoop.makeFailedFuture(
+        GRPCStatus(
+          code: .resourceExhausted,
+          message: "Too many RPCs are waiting for a stream on this connection"
+        )
+      )
+
+    case let .queued(id, future):
+      // Registered outside of the lock: the callback runs immediately if the future has completed.
+      abandoned?.whenComplete { result in
+        switch result {
+        case .success:
+          self.removeWaiter(withID: id, error: GRPCError.AlreadyComplete())
+        case let .failure(error):
+          self.removeWaiter(withID: id, error: error)
+        }
+      }
+      return future
+    }
+  }
+
+  private enum AcquireOutcome {
+    case acquired
+    case queueFull
+    case queued(id: Int, future: EventLoopFuture<Void>)
+  }
+
+  /// Release a permit. The permit is handed to the longest waiting RPC of the highest priority, if
+  /// there is one.
+  internal func release() {
+    let next: Waiter? = self.lock.withLock {
+      if let index = self.indexOfNextWaiter() {
+        return self.waiters.remove(at: index)
+      } else {
+        self.permitsHeld -= 1
+        return nil
+      }
+    }
+
+    if let waiter = next {
+      waiter.timeout?.cancel()
+      waiter.promise.succeed(())
+    }
+  }
+
+  /// Returns the index of the longest waiting RPC of the highest priority, or `nil` if there are no
+  /// waiters. Must be called while holding `lock`.
+  private func indexOfNextWaiter() -> CircularBuffer<Waiter>.Index? {
+    guard var next = self.waiters.indices.first else {
+      return nil
+    }
+
+    // Waiters are in the order they were added so only replace the candidate with a waiter of a
+    // strictly higher priority.
+    for index in self.waiters.indices {
+      if self.waiters[index].priority > self.waiters[next].priority {
+        next = index
+      }
+    }
+    return next
+  }
+
+  /// Stops a waiter from waiting for a permit, failing it with the given error. Does nothing if
+  /// the waiter has already been given a permit or removed.
+  private func removeWaiter(withID id: Int, error: Error) {
+    let waiter: Waiter? = self.lock.withLock {
+      guard let index = self.waiters.firstIndex(where: { $0.id == id }) else {
+        return nil
+      }
+      return self.waiters.remove(at: index)
+    }
+
+    if let waiter = waiter {
+      waiter.timeout?.cancel()
+      waiter.promise.fail(error)
+    }
+  }
+}
@@ -0,0 +1,202 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import NIOConcurrencyHelpers
+
+/// Bridges a callback-style producer of messages, such as a delegate, to the response stream of a
+/// server streaming or bidirectional streaming RPC.
+///
+/// Messages are passed to `yield(_:compression:)` and the stream is ended with
+/// `finish(throwing:)`. The service provider should return `status` as the status of the RPC:
+///
+/// ```
+/// func subscribe(
+///   request: SubscribeRequest,
+///   context: StreamingResponseCallContext<Event>
+/// ) -> EventLoopFuture<GRPCStatus> {
+///   let producer = StreamingResponseProducer(context: context)
+///   self.feed.addObserver(
+///     onEvent: { event in producer.yield(event) },
+///     onClose: { error in producer.finish(throwing: error) }
+///   )
+///   return producer.status
+/// }
+/// ```
+///
+/// Backpressure is signalled by the future returned from `yield`: it completes once fewer than
+/// `maximumPendingResponses` responses are waiting to be written. Producers should wait for it to
+/// complete before yielding again; a producer which is not on the `EventLoop` may simply `wait()`
+/// on it. The future fails if the RPC has already finished, for example because the client
+/// cancelled it.
+///
+/// Responses are written via a `SerialRPCWriter` so `yield` and `finish` may be called from any
+/// thread, however they should be called from a single producer so that the order of responses is
+/// well defined.
+public final class StreamingResponseProducer<Response> {
+  /// The `EventLoop` of the RPC.
+  public let eventLoop: EventLoop
+
+  /// The number of responses which may be waiting to be written before the future returned by
+  /// `yield` stops completing immediately.
+  public let maximumPendingResponses: Int
+
+  /// Writes responses on the RPC.
+  private let writer: SerialRPCWriter<Response>
+
+  /// Completed when the producer finishes.
+  private let statusPromise: EventLoopPromise<GRPCStatus>
+
+  /// The number of responses yielded but not yet written. Protected by `lock`.
+  private var pendingResponses = 0
+
+  /// Promises for producers waiting for the number of pending responses to drop below the
+  /// maximum. Protected by `lock`.
+  private var waitingProducers: [EventLoopPromise<Void>] = []
+
+  /// Whether the producer has finished. Protected by `lock`.
+  private var isFinished = false
+
+  private let lock = Lock()
+
+  /// Creates a producer which writes responses to the given context.
+  ///
+  /// - Parameters:
+  ///   - context: The context of a server streaming or bidirectional streaming RPC.
+  ///   - maximumPendingResponses: The number of responses which may be waiting to be written
+  ///     before producers are asked to wait. Defaults to 16.
+  public init(
+    context: StreamingResponseCallContext<Response>,
+    maximumPendingResponses: Int = 16
+  ) {
+    precondition(maximumPendingResponses > 0, "maximumPendingResponses must be greater than zero")
+
+    self.eventLoop = context.eventLoop
+    self.maximumPendingResponses = maximumPendingResponses
+    self.writer = SerialRPCWriter(wrapping: context)
+    self.statusPromise = context.eventLoop.makePromise()
+
+    // If the RPC closes before the producer finishes (i.e. the client cancelled it) then there's
+    // no point producing any more responses.
+    context.closeFuture.whenSuccess {
+      self.finish(throwing: GRPCStatus(code: .cancelled, message: "The RPC was closed"))
+    }
+  }
+
+  /// A future which is completed when the producer finishes. This should be returned by the
+  /// service provider as the status of the RPC.
+  public var status: EventLoopFuture<GRPCStatus> {
+    return self.statusPromise.futureResult
+  }
+
+  /// Yield a response to be written on the RPC.
+  ///
+  /// - Parameters:
+  ///   - response: The response to write.
+  ///   - compression: Whether compression should be used for this response. Defaults to
+  ///     deferring to the value set on the context.
+  /// - Returns: A future which completes when the producer may yield another response, or fails
+  ///   if the producer has already finished.
+  @discardableResult
+  public func yield(
+    _ response: Response,
+    compression: Compression = .deferToCallDefault
+  ) -> EventLoopFuture<Void> {
+    let ready: EventLoopFuture<Void>? = self.lock.withLock {
+      if self.isFinished {
+        return nil
+      }
+
+      self.pendingResponses += 1
+      if self.pendingResponses < self.maximumPendingResponses {
+        return self.eventLoop.makeSucceededFuture(())
+      } else {
+        let promise = self.eventLoop.makePromise(of: Void.self)
+        self.waitingProducers.append(promise)
+        return promise.futureResult
+      }
+    }
+
+    guard let readyFuture = ready else {
+      return self.eventLoop.makeFailedFuture(GRPCError.AlreadyComplete())
+    }
+
+    self.writer.write(response, compression: compression).whenComplete { result in
+      switch result {
+      case .success:
+        self.responseWritten()
+      case let .failure(error):
+        self.responseWritten()
+        self.finish(throwing: error)
+      }
+    }
+
+    return readyFuture
+  }
+
+  /// Finish producing responses. The RPC ends once all previously yielded responses have been
+  /// written. Calling this more than once has no effect.
+  ///
+  /// - Parameter error: An error to fail the RPC with, or `nil` if the RPC should end with an
+  ///   'ok' status.
+  public func finish(throwing error: Error? = nil) {
+    let waiting: [EventLoopPromise<Void>]? = self.lock.withLock {
+      if self.isFinished {
+        return nil
+      }
+
+      self.isFinished = true
+      let waiting = self.waitingProducers
+      self.waitingProducers.removeAll()
+      return waiting
+    }
+
+    guard let waitingProducers = waiting else {
+      return
+    }
+
+    for producer in waitingProducers {
+      producer.fail(GRPCError.AlreadyComplete())
+    }
+
+    // Responses are written via tasks executed on the event loop; completing the status from a
+    // task executed after them ensures it doesn't overtake any responses.
+    self.eventLoop.execute {
+      if let error = error {
+        self.statusPromise.fail(error)
+      } else {
+        self.statusPromise.succeed(.ok)
+      }
+    }
+  }
+
+  /// Called when a yielded response has been written (or failed to be written).
+  private func responseWritten() {
+    let ready: [EventLoopPromise<Void>] = self.lock.withLock {
+      self.pendingResponses -= 1
+      if self.pendingResponses < self.maximumPendingResponses {
+        let ready = self.waitingProducers
+        self.waitingProducers.removeAll()
+        return ready
+      } else {
+        return []
+      }
+    }
+
+    for producer in ready {
+      producer.succeed(())
+    }
+  }
+}
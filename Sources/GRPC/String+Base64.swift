@@ -0,0 +1,28 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+
+extension String {
+  /// Decodes a base64 encoded string, tolerating missing padding as permitted for binary metadata.
+  internal func base64DecodedBytes() -> [UInt8]? {
+    var encoded = self
+    let remainder = encoded.utf8.count % 4
+    if remainder != 0 {
+      encoded.append(String(repeating: "=", count: 4 - remainder))
+    }
+    return Data(base64Encoded: encoded).map { Array($0) }
+  }
+}
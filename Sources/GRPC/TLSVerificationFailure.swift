@@ -0,0 +1,135 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIOSSL
+
+/// An error indicating that a TLS handshake failed because a certificate could not be verified.
+///
+/// RPCs which fail because the connection could not be established due to a verification failure
+/// have a status with code `.unavailable` whose `cause` is a `TLSVerificationFailure`. On the
+/// server, the error of a `ServerConnectionEvent.handshakeFailed` event may be classified with
+/// `init?(_:)`.
+public struct TLSVerificationFailure: Error, CustomStringConvertible {
+  /// Why verification failed.
+  public struct Reason: Hashable, CustomStringConvertible {
+    internal enum Wrapped: Hashable {
+      case hostnameMismatch
+      case certificateExpired
+      case untrustedCertificate
+      case missingClientCertificate
+    }
+
+    internal var wrapped: Wrapped
+    private init(_ wrapped: Wrapped) {
+      self.wrapped = wrapped
+    }
+
+    /// The server's certificate is not valid for the hostname being connected to (or the
+    /// configured hostname override).
+    public static let hostnameMismatch = Reason(.hostnameMismatch)
+
+    /// The peer rejected our certificate because it has expired.
+    ///
+    /// - Note: BoringSSL does not report *why* it failed to verify a certificate presented by the
+    ///   peer, so an expired peer certificate is reported as `untrustedCertificate`.
+    public static let certificateExpired = Reason(.certificateExpired)
+
+    /// A certificate could not be verified, for example because it was not issued by a trusted
+    /// certificate authority, or the peer rejected our certificate.
+    public static let untrustedCertificate = Reason(.untrustedCertificate)
+
+    /// The server requires a client certificate but the client did not provide one.
+    public static let missingClientCertificate = Reason(.missingClientCertificate)
+
+    public var description: String {
+      switch self.wrapped {
+      case .hostnameMismatch:
+        return "hostname mismatch"
+      case .certificateExpired:
+        return "certificate expired"
+      case .untrustedCertificate:
+        return "untrusted certificate"
+      case .missingClientCertificate:
+        return "missing client certificate"
+      }
+    }
+  }
+
+  /// Why verification failed.
+  public var reason: Reason
+
+  /// The error reported by the TLS implementation.
+  public var underlyingError: Error
+
+  public var description: String {
+    return "TLS verification failed (\(self.reason)): \(self.underlyingError)"
+  }
+
+  /// Classifies an error reported by NIOSSL, returning `nil` if the error isn't caused by a
+  /// certificate verification failure.
+  public init?(_ error: Error) {
+    if let failure = error as? TLSVerificationFailure {
+      self = failure
+      return
+    }
+
+    let reason: Reason?
+    if let extraError = error as? NIOSSLExtraError {
+      reason = extraError == .failedToValidateHostname ? .hostnameMismatch : nil
+    } else if let sslError = error as? NIOSSLError {
+      switch sslError {
+      case let .handshakeFailed(boringSSLError):
+        reason = TLSVerificationFailure.classify(String(describing: boringSSLError))
+      case .unableToValidateCertificate:
+        reason = .untrustedCertificate
+      default:
+        reason = nil
+      }
+    } else {
+      reason = nil
+    }
+
+    guard let classified = reason else {
+      return nil
+    }
+
+    self.reason = classified
+    self.underlyingError = error
+  }
+
+  /// Maps the BoringSSL error queue, which includes the names of the errors and of any TLS alerts
+  /// received from the peer, to a reason.
+  private static func classify(_ errors: String) -> Reason? {
+    // Alerts sent by the peer.
+    if errors.contains("ALERT_CERTIFICATE_EXPIRED") {
+      return .certificateExpired
+    } else if errors.contains("ALERT_CERTIFICATE_REQUIRED") {
+      return .missingClientCertificate
+    } else if errors.contains("ALERT_BAD_CERTIFICATE") ||
+      errors.contains("ALERT_UNKNOWN_CA") ||
+      errors.contains("ALERT_CERTIFICATE_UNKNOWN") {
+      return .untrustedCertificate
+    }
+
+    // Errors detected locally.
+    if errors.contains("PEER_DID_NOT_RETURN_A_CERTIFICATE") {
+      return .missingClientCertificate
+    } else if errors.contains("CERTIFICATE_VERIFY_FAILED") {
+      return .untrustedCertificate
+    }
+
+    return nil
+  }
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import NIO
+import SwiftProtobuf
+
+extension TimeAmount {
+  /// Creates a `TimeAmount` from a `google.protobuf.Duration`. Durations are received from
+  /// remote peers and may be larger than a `TimeAmount` can hold: these are clamped rather than
+  /// trapping on overflow.
+  internal init(saturating duration: Google_Protobuf_Duration) {
+    let (seconds, overflow) = duration.seconds.multipliedReportingOverflow(by: 1_000_000_000)
+    if overflow {
+      self = .nanoseconds(duration.seconds < 0 ? .min : .max)
+      return
+    }
+
+    let (nanoseconds, sumOverflow) = seconds.addingReportingOverflow(Int64(duration.nanos))
+    if sumOverflow {
+      self = .nanoseconds(duration.nanos < 0 ? .min : .max)
+    } else {
+      self = .nanoseconds(nanoseconds)
+    }
+  }
+}
+
+extension Google_Protobuf_Duration {
+  /// Creates a `google.protobuf.Duration` from a `TimeAmount`.
+  internal init(_ timeAmount: TimeAmount) {
+    let nanoseconds = timeAmount.nanoseconds
+    self.init(
+      seconds: nanoseconds / 1_000_000_000,
+      nanos: Int32(nanoseconds % 1_000_000_000)
+    )
+  }
+}
@@ -85,6 +85,13 @@ extension TimeLimit {
   /// Make a non-distant-future deadline from the give time limit.
   @usableFromInline
   internal func makeDeadline() -> NIODeadline {
+    return self.makeDeadline(using: .system)
+  }
+
+  /// Make a non-distant-future deadline from the give time limit, resolving timeouts with the
+  /// given clock.
+  @usableFromInline
+  internal func makeDeadline(using clock: GRPCClock) -> NIODeadline {
     switch self.wrapped {
     case .none:
       return .distantFuture
@@ -93,7 +100,7 @@ extension TimeLimit {
       return .distantFuture
 
     case let .timeout(timeout):
-      return .now() + timeout
+      return clock.now() + timeout
 
     case let .deadline(deadline):
       return deadline
@@ -0,0 +1,72 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+/// How fields which are not known to the schema of a Protobuf request message are handled when
+/// the message is deserialized by a server.
+///
+/// Handling is applied to the message and to any messages nested within it. It only applies to
+/// messages deserialized with a `ProtobufDeserializer`; other deserializers ignore it.
+public struct UnknownFieldHandling: Hashable {
+  @usableFromInline
+  internal enum Wrapped: Hashable {
+    case preserve
+    case drop
+    case reject
+  }
+
+  @usableFromInline
+  internal var wrapped: Wrapped
+  private init(_ wrapped: Wrapped) {
+    self.wrapped = wrapped
+  }
+
+  /// Unknown fields are kept in the `unknownFields` of the message and are included if the
+  /// message is serialized again. This is useful for proxies which must forward messages without
+  /// losing fields added in newer versions of the schema.
+  ///
+  /// This is the default.
+  public static let preserve = UnknownFieldHandling(.preserve)
+
+  /// Unknown fields are discarded.
+  public static let drop = UnknownFieldHandling(.drop)
+
+  /// Messages containing unknown fields are rejected: the RPC fails with status code
+  /// `.invalidArgument`.
+  ///
+  /// - Note: Detecting unknown fields requires each message to be decoded twice.
+  public static let reject = UnknownFieldHandling(.reject)
+}
+
+/// The unknown field handling of each service on a server.
+internal struct ServerUnknownFieldHandling {
+  /// Handling used for services which don't have any specific handling.
+  internal var `default`: UnknownFieldHandling
+
+  /// Handling for specific services, keyed by fully qualified service name.
+  internal var byService: [Substring: UnknownFieldHandling]
+
+  internal init(
+    default: UnknownFieldHandling = .preserve,
+    byService: [String: UnknownFieldHandling] = [:]
+  ) {
+    self.default = `default`
+    self.byService = Dictionary(uniqueKeysWithValues: byService.map { (Substring($0), $1) })
+  }
+
+  internal func handling(forService service: Substring) -> UnknownFieldHandling {
+    return self.byService[service] ?? self.default
+  }
+}
@@ -490,6 +490,8 @@ public protocol Grpc_Testing_TestServiceProvider: CallHandlerProvider {
 extension Grpc_Testing_TestServiceProvider {
   public var serviceName: Substring { return "grpc.testing.TestService" }
 
+  public var methodNames: [Substring] { return ["EmptyCall", "UnaryCall", "CacheableUnaryCall", "StreamingOutputCall", "StreamingInputCall", "FullDuplexCall", "HalfDuplexCall"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -564,6 +566,16 @@ extension Grpc_Testing_TestServiceProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'grpc.testing.TestService' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'grpc.testing.TestService' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Grpc_Testing_TestServiceServerInterceptorFactoryProtocol {
@@ -614,6 +626,8 @@ public protocol Grpc_Testing_UnimplementedServiceProvider: CallHandlerProvider {
 extension Grpc_Testing_UnimplementedServiceProvider {
   public var serviceName: Substring { return "grpc.testing.UnimplementedService" }
 
+  public var methodNames: [Substring] { return ["UnimplementedCall"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -634,6 +648,16 @@ extension Grpc_Testing_UnimplementedServiceProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'grpc.testing.UnimplementedService' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'grpc.testing.UnimplementedService' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Grpc_Testing_UnimplementedServiceServerInterceptorFactoryProtocol {
@@ -656,6 +680,8 @@ public protocol Grpc_Testing_ReconnectServiceProvider: CallHandlerProvider {
 extension Grpc_Testing_ReconnectServiceProvider {
   public var serviceName: Substring { return "grpc.testing.ReconnectService" }
 
+  public var methodNames: [Substring] { return ["Start", "Stop"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   public func handle(
@@ -685,6 +711,16 @@ extension Grpc_Testing_ReconnectServiceProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'grpc.testing.ReconnectService' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'grpc.testing.ReconnectService' is registered more
+  /// than once.
+  @discardableResult
+  public func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 public protocol Grpc_Testing_ReconnectServiceServerInterceptorFactoryProtocol {
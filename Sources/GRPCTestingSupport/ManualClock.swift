@@ -0,0 +1,138 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+
+/// A clock whose time only passes when it is advanced.
+///
+/// Time limits of RPCs using the `clock` of a `ManualClock` are scheduled on the manual clock
+/// rather than on the `EventLoop` of the RPC, so they can be tested deterministically regardless of
+/// the `EventLoop` they run on:
+///
+/// ```
+/// let manualClock = ManualClock()
+/// var options = CallOptions(timeLimit: .timeout(.milliseconds(100)))
+/// options.clock = manualClock.clock
+/// let call = client.get(request, callOptions: options)
+///
+/// manualClock.advance(by: .milliseconds(100))
+/// // 'call.status' is now completed with '.deadlineExceeded'.
+/// ```
+public final class ManualClock {
+  private struct Task {
+    var id: Int
+    var deadline: NIODeadline
+    var eventLoop: EventLoop
+    var promise: EventLoopPromise<Void>
+    var body: () -> Void
+  }
+
+  private let lock = Lock()
+
+  /// The current time. Protected by `lock`.
+  private var _now: NIODeadline
+
+  /// Tasks which haven't run or been cancelled yet. Protected by `lock`.
+  private var tasks: [Task] = []
+
+  /// The ID of the next task. Protected by `lock`.
+  private var nextTaskID = 0
+
+  /// Creates a manual clock.
+  ///
+  /// - Parameter now: The time to start the clock at, defaulting to zero.
+  public init(now: NIODeadline = .uptimeNanoseconds(0)) {
+    self._now = now
+  }
+
+  /// The current time.
+  public var now: NIODeadline {
+    return self.lock.withLock {
+      self._now
+    }
+  }
+
+  /// A `GRPCClock` which reads its time from, and schedules deadlines on, this clock.
+  public var clock: GRPCClock {
+    return GRPCClock(
+      now: { self.now },
+      scheduleTask: { deadline, eventLoop, task in
+        self.scheduleTask(deadline: deadline, on: eventLoop, task)
+      }
+    )
+  }
+
+  /// Advances the time of the clock, running any tasks whose deadline has passed on their
+  /// `EventLoop` in the order of their deadlines.
+  ///
+  /// - Parameter amount: The amount of time to advance the clock by.
+  public func advance(by amount: TimeAmount) {
+    let due: [Task] = self.lock.withLock {
+      self._now = self._now + amount
+      let now = self._now
+      let due = self.tasks.filter { $0.deadline <= now }
+      self.tasks.removeAll { $0.deadline <= now }
+      return due.sorted { ($0.deadline, $0.id) < ($1.deadline, $1.id) }
+    }
+
+    for task in due {
+      if task.eventLoop.inEventLoop {
+        task.body()
+        task.promise.succeed(())
+      } else {
+        task.eventLoop.execute {
+          task.body()
+          task.promise.succeed(())
+        }
+      }
+    }
+  }
+
+  private func scheduleTask(
+    deadline: NIODeadline,
+    on eventLoop: EventLoop,
+    _ body: @escaping () -> Void
+  ) -> Scheduled<Void> {
+    let promise = eventLoop.makePromise(of: Void.self)
+
+    let id: Int = self.lock.withLock {
+      let id = self.nextTaskID
+      self.nextTaskID += 1
+      self.tasks.append(
+        Task(id: id, deadline: deadline, eventLoop: eventLoop, promise: promise, body: body)
+      )
+      return id
+    }
+
+    // Tasks which are already due run on their event loop once the caller has the 'Scheduled'.
+    if deadline <= self.now {
+      eventLoop.execute {
+        self.advance(by: .nanoseconds(0))
+      }
+    }
+
+    return Scheduled(promise: promise) {
+      let cancelled: Task? = self.lock.withLock {
+        guard let index = self.tasks.firstIndex(where: { $0.id == id }) else {
+          return nil
+        }
+        return self.tasks.remove(at: index)
+      }
+      cancelled?.promise.fail(EventLoopError.cancelled)
+    }
+  }
+}
@@ -0,0 +1,206 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import GRPC
+import NIO
+import SwiftProtobuf
+
+/// Exercises each kind of RPC on a service against a termination scenario and reports the status
+/// code observed by the client for each, for example:
+///
+/// ```
+/// let outcomes = matrix.run(.deadlineExceeded(.milliseconds(50)))
+/// for outcome in outcomes {
+///   XCTAssert(outcome.isExpected, "\(outcome)")
+/// }
+/// ```
+///
+/// Methods are identified by their path; any kind without a path is skipped. The scenarios rely on
+/// the server behaving appropriately:
+/// - `deadlineExceeded` and `clientCancellation` expect the server to never complete the RPC,
+/// - `serverTermination` expects the server to fail the RPC as soon as it starts.
+public struct RPCTerminationMatrix<
+  Request: SwiftProtobuf.Message,
+  Response: SwiftProtobuf.Message
+> {
+  public enum Scenario {
+    /// The RPC is started with the given (tight) time limit and is expected to fail with
+    /// `.deadlineExceeded`.
+    case deadlineExceeded(TimeAmount)
+    /// The RPC is cancelled by the client after it has started (and, for request streaming RPCs,
+    /// after a message has been sent) and is expected to fail with `.cancelled`.
+    case clientCancellation
+    /// The RPC is terminated early by the server and is expected to fail with the given code.
+    case serverTermination(GRPCStatus.Code)
+  }
+
+  /// The observed result of running a scenario against one kind of RPC.
+  public struct Outcome: CustomStringConvertible {
+    /// The kind of RPC the scenario was run against.
+    public var callType: GRPCCallType
+
+    /// The status code the scenario expects the RPC to end with.
+    public var expectedCode: GRPCStatus.Code
+
+    /// The status the RPC ended with, or the error if no status was received.
+    public var observed: Result<GRPCStatus, Error>
+
+    /// Whether the RPC ended with the expected status code.
+    public var isExpected: Bool {
+      switch self.observed {
+      case let .success(status):
+        return status.code == self.expectedCode
+      case .failure:
+        return false
+      }
+    }
+
+    public var description: String {
+      switch self.observed {
+      case let .success(status):
+        return "\(self.callType) RPC: expected \(self.expectedCode), observed \(status)"
+      case let .failure(error):
+        return "\(self.callType) RPC: expected \(self.expectedCode), failed with \(error)"
+      }
+    }
+  }
+
+  /// The client to make RPCs with.
+  public var client: GRPCClient
+
+  /// The request to send on each RPC.
+  public var request: Request
+
+  /// The paths of the methods to run the scenarios against, by kind of RPC.
+  public var unaryPath: String?
+  public var clientStreamingPath: String?
+  public var serverStreamingPath: String?
+  public var bidirectionalStreamingPath: String?
+
+  /// The time limit for RPCs which are not expected to exceed their deadline.
+  public var timeout: TimeAmount
+
+  public init(
+    client: GRPCClient,
+    request: Request,
+    unaryPath: String? = nil,
+    clientStreamingPath: String? = nil,
+    serverStreamingPath: String? = nil,
+    bidirectionalStreamingPath: String? = nil,
+    timeout: TimeAmount = .seconds(10)
+  ) {
+    self.client = client
+    self.request = request
+    self.unaryPath = unaryPath
+    self.clientStreamingPath = clientStreamingPath
+    self.serverStreamingPath = serverStreamingPath
+    self.bidirectionalStreamingPath = bidirectionalStreamingPath
+    self.timeout = timeout
+  }
+
+  /// Runs the scenario against each kind of RPC with a path, waiting for each RPC to end.
+  ///
+  /// - Parameter scenario: The scenario to run.
+  /// - Returns: The outcome for each kind of RPC which has a path, in the order unary, client
+  ///     streaming, server streaming and bidirectional streaming.
+  public func run(_ scenario: Scenario) -> [Outcome] {
+    var outcomes: [Outcome] = []
+
+    if let path = self.unaryPath {
+      let call: UnaryCall<Request, Response> = self.client.makeUnaryCall(
+        path: path,
+        request: self.request,
+        callOptions: self.callOptions(for: scenario)
+      )
+      outcomes.append(self.wait(for: call, type: .unary, scenario: scenario))
+    }
+
+    if let path = self.clientStreamingPath {
+      let call: ClientStreamingCall<Request, Response> = self.client.makeClientStreamingCall(
+        path: path,
+        callOptions: self.callOptions(for: scenario)
+      )
+      self.send(on: call, scenario: scenario)
+      outcomes.append(self.wait(for: call, type: .clientStreaming, scenario: scenario))
+    }
+
+    if let path = self.serverStreamingPath {
+      let call: ServerStreamingCall<Request, Response> = self.client.makeServerStreamingCall(
+        path: path,
+        request: self.request,
+        callOptions: self.callOptions(for: scenario),
+        handler: { _ in }
+      )
+      outcomes.append(self.wait(for: call, type: .serverStreaming, scenario: scenario))
+    }
+
+    if let path = self.bidirectionalStreamingPath {
+      let call: BidirectionalStreamingCall<Request, Response> =
+        self.client.makeBidirectionalStreamingCall(
+          path: path,
+          callOptions: self.callOptions(for: scenario),
+          handler: { _ in }
+        )
+      self.send(on: call, scenario: scenario)
+      outcomes.append(self.wait(for: call, type: .bidirectionalStreaming, scenario: scenario))
+    }
+
+    return outcomes
+  }
+
+  private func callOptions(for scenario: Scenario) -> CallOptions {
+    var options = self.client.defaultCallOptions
+    switch scenario {
+    case let .deadlineExceeded(timeLimit):
+      options.timeLimit = .timeout(timeLimit)
+    case .clientCancellation, .serverTermination:
+      options.timeLimit = .timeout(self.timeout)
+    }
+    return options
+  }
+
+  private func send<Call: StreamingRequestClientCall>(on call: Call, scenario: Scenario)
+    where Call.RequestPayload == Request {
+    call.sendMessage(self.request, compression: .deferToCallDefault, promise: nil)
+
+    switch scenario {
+    case .serverTermination:
+      call.sendEnd(promise: nil)
+    case .deadlineExceeded, .clientCancellation:
+      // Leave the request stream open so that the RPC can't complete.
+      ()
+    }
+  }
+
+  private func wait<Call: ClientCall>(
+    for call: Call,
+    type: GRPCCallType,
+    scenario: Scenario
+  ) -> Outcome {
+    let expected: GRPCStatus.Code
+    switch scenario {
+    case .deadlineExceeded:
+      expected = .deadlineExceeded
+    case .clientCancellation:
+      expected = .cancelled
+      call.cancel(promise: nil)
+    case let .serverTermination(code):
+      expected = code
+    }
+
+    let observed = Result { try call.status.wait() }
+    return Outcome(callType: type, expectedCode: expected, observed: observed)
+  }
+}
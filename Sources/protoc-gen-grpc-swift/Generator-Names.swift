@@ -82,6 +82,18 @@ extension Generator {
     return nameForPackageService(self.file, self.service) + "TestClient"
   }
 
+  internal var fakeProviderClassName: String {
+    return nameForPackageService(self.file, self.service) + "FakeProvider"
+  }
+
+  internal var fakeMethodHandlerName: String {
+    var name = self.method.name
+    if !self.options.keepMethodCasing {
+      name = name.prefix(1).lowercased() + name.dropFirst()
+    }
+    return name + "Handler"
+  }
+
   internal var clientProtocolName: String {
     return nameForPackageService(file, service) + "ClientProtocol"
   }
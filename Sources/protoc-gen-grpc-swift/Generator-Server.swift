@@ -68,11 +68,90 @@ extension Generator {
     println("}")
   }
 
+  internal func printFakeProvider() {
+    // The fake provider is intended to be subclassed in tests, which are usually in another module.
+    let classAccess = self.options.visibility == .public ? "open" : self.access
+
+    self.println("/// A fake provider for '\(self.servicePath)' for testing clients.")
+    self.println("///")
+    self.println(
+      "/// Each method responds according to the stub of its handler and records the requests it"
+    )
+    self.println("/// receives. Methods may also be overridden to provide custom behaviour.")
+    self.println("\(classAccess) class \(self.fakeProviderClassName): \(self.providerName) {")
+    self.withIndentation {
+      self.println(
+        "\(classAccess) var interceptors: \(self.serverInterceptorProtocolName)? { return nil }"
+      )
+
+      for method in self.service.methods {
+        self.method = method
+        self.println()
+        self.println("/// Stubs and received requests for '\(method.name)'.")
+        self.println(
+          "\(self.access) let \(self.fakeMethodHandlerName) = FakeMethodHandler<\(self.methodInputName), \(self.methodOutputName)>()"
+        )
+      }
+
+      self.println()
+      self.println("\(self.access) init() {}")
+
+      for method in self.service.methods {
+        self.method = method
+        self.println()
+
+        switch streamingType(method) {
+        case .unary:
+          self.println(
+            "\(classAccess) func \(self.methodFunctionName)(request: \(self.methodInputName), context: StatusOnlyCallContext) -> EventLoopFuture<\(self.methodOutputName)> {"
+          )
+          self.withIndentation {
+            self.println(
+              "return self.\(self.fakeMethodHandlerName).handleUnary(request: request, context: context)"
+            )
+          }
+        case .serverStreaming:
+          self.println(
+            "\(classAccess) func \(self.methodFunctionName)(request: \(self.methodInputName), context: StreamingResponseCallContext<\(self.methodOutputName)>) -> EventLoopFuture<GRPCStatus> {"
+          )
+          self.withIndentation {
+            self.println(
+              "return self.\(self.fakeMethodHandlerName).handleServerStreaming(request: request, context: context)"
+            )
+          }
+        case .clientStreaming:
+          self.println(
+            "\(classAccess) func \(self.methodFunctionName)(context: UnaryResponseCallContext<\(self.methodOutputName)>) -> EventLoopFuture<(StreamEvent<\(self.methodInputName)>) -> Void> {"
+          )
+          self.withIndentation {
+            self.println(
+              "return self.\(self.fakeMethodHandlerName).handleClientStreaming(context: context)"
+            )
+          }
+        case .bidirectionalStreaming:
+          self.println(
+            "\(classAccess) func \(self.methodFunctionName)(context: StreamingResponseCallContext<\(self.methodOutputName)>) -> EventLoopFuture<(StreamEvent<\(self.methodInputName)>) -> Void> {"
+          )
+          self.withIndentation {
+            self.println(
+              "return self.\(self.fakeMethodHandlerName).handleBidirectionalStreaming(context: context)"
+            )
+          }
+        }
+        self.println("}")
+      }
+    }
+    self.println("}")
+  }
+
   private func printServerProtocolExtension() {
     self.println("extension \(self.providerName) {")
     self.withIndentation {
       self.println("\(self.access) var serviceName: Substring { return \"\(self.servicePath)\" }")
       self.println()
+      let methodNames = self.service.methods.map { "\"\($0.name)\"" }.joined(separator: ", ")
+      self.println("\(self.access) var methodNames: [Substring] { return [\(methodNames)] }")
+      self.println()
       self.println(
         "/// Determines, calls and returns the appropriate request handler, depending on the request's method."
       )
@@ -131,10 +210,32 @@ extension Generator {
         }
         self.println("}")
       }
+
+      self.println()
+      self.printRegisterHandlers()
     }
     self.println("}")
   }
 
+  private func printRegisterHandlers() {
+    self.println(
+      "/// Registers the handlers for '\(self.servicePath)' on the server builder, in addition to any service"
+    )
+    self.println(
+      "/// providers already registered. The server fails to start if '\(self.servicePath)' is registered more"
+    )
+    self.println("/// than once.")
+    self.println("@discardableResult")
+    self.printFunction(
+      name: "registerHandlers",
+      arguments: ["on builder: Server.Builder"],
+      returnType: "Server.Builder",
+      access: self.access
+    ) {
+      self.println("return builder.addServiceProvider(self)")
+    }
+  }
+
   private func printServerInterceptorFactoryProtocol() {
     self.println("\(self.access) protocol \(self.serverInterceptorProtocolName) {")
     self.withIndentation {
@@ -117,5 +117,14 @@ class Generator {
         printServer()
       }
     }
+
+    // The fake provider conforms to the provider protocol so can only be generated with it.
+    if self.options.generateServer, self.options.generateFakeProvider {
+      for service in self.file.services {
+        self.service = service
+        self.println()
+        self.printFakeProvider()
+      }
+    }
   }
 }
@@ -59,6 +59,38 @@ class AnyServiceClientTests: EchoTestCaseBase {
     XCTAssertEqual(try expand.status.map { $0.code }.wait(), .ok)
   }
 
+  func testServerStreamingWithMappedMessages() throws {
+    var lengths: [Int] = []
+    let expand = self.anyServiceClient.makeServerStreamingCall(
+      path: "/echo.Echo/Expand",
+      request: Echo_EchoRequest.with { $0.text = "a bc def" },
+      responseType: Echo_EchoResponse.self,
+      mapMessages: { $0.text.count },
+      handler: { lengths.append($0) }
+    )
+
+    XCTAssertNoThrow(try expand.completion.wait())
+    XCTAssertEqual(try expand.status.map { $0.code }.wait(), .ok)
+    XCTAssertNoThrow(try expand.trailingMetadata.wait())
+    // Each response is "Swift echo expand (i): <part>".
+    XCTAssertEqual(lengths, [24, 25, 26])
+  }
+
+  func testServerStreamingWithMappedMessagesFailsOnNonOkStatus() throws {
+    let expand = self.anyServiceClient.makeServerStreamingCall(
+      path: "/echo.Echo/NotAMethod",
+      request: Echo_EchoRequest.with { $0.text = "foo" },
+      responseType: Echo_EchoResponse.self,
+      mapMessages: { $0.text },
+      handler: { XCTFail("Unexpected response: '\($0)'") }
+    )
+
+    XCTAssertThrowsError(try expand.completion.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .unimplemented)
+    }
+    XCTAssertEqual(try expand.status.map { $0.code }.wait(), .unimplemented)
+  }
+
   func testBidirectionalStreaming() throws {
     let update = self.anyServiceClient.makeBidirectionalStreamingCall(
       path: "/echo.Echo/Update",
@@ -0,0 +1,110 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import NIOHPACK
+import XCTest
+
+/// Records the "grpc-tags-bin" request metadata seen before the handler and echoes it back in the
+/// trailers.
+private class TagEchoingInterceptor: ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse> {
+  private let lock = Lock()
+  private var _tags: [[UInt8]] = []
+
+  var tags: [[UInt8]] {
+    return self.lock.withLock { self._tags }
+  }
+
+  override func receive(
+    _ part: GRPCServerRequestPart<Echo_EchoRequest>,
+    context: ServerInterceptorContext<Echo_EchoRequest, Echo_EchoResponse>
+  ) {
+    if case let .metadata(headers) = part {
+      let tags = (try? headers.binaryValues(forName: "grpc-tags-bin")) ?? []
+      self.lock.withLockVoid {
+        self._tags = tags
+      }
+    }
+    context.receive(part)
+  }
+
+  override func send(
+    _ part: GRPCServerResponsePart<Echo_EchoResponse>,
+    promise: EventLoopPromise<Void>?,
+    context: ServerInterceptorContext<Echo_EchoRequest, Echo_EchoResponse>
+  ) {
+    if case .end(let status, var trailers) = part {
+      for tag in self.tags {
+        XCTAssertNoThrow(try trailers.addBinary(name: "grpc-tags-bin", value: tag))
+      }
+      context.send(.end(status, trailers), promise: promise)
+    } else {
+      context.send(part, promise: promise)
+    }
+  }
+}
+
+class BinaryMetadataTests: EchoTestCaseBase {
+  private let interceptor = TagEchoingInterceptor()
+
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return EchoProvider(interceptors: EchoInterceptorFactory(interceptor: self.interceptor))
+  }
+
+  func testBinaryMetadataRoundTripsUnmodified() throws {
+    let tags: [[UInt8]] = [Array(0 ... 255), [], [0xFF, 0x00, 0x2C]]
+
+    var headers = HPACKHeaders()
+    for tag in tags {
+      try headers.addBinary(name: "grpc-tags-bin", value: tag)
+    }
+
+    let options = CallOptions(customMetadata: headers)
+    let get = self.client.get(.with { $0.text = "foo" }, callOptions: options)
+    XCTAssertEqual(try get.status.wait().code, .ok)
+
+    // The server saw the exact bytes before the handler was invoked...
+    XCTAssertEqual(self.interceptor.tags, tags)
+    // ...and the client received them back unmodified.
+    let trailers = try get.trailingMetadata.wait()
+    XCTAssertEqual(try trailers.binaryValues(forName: "grpc-tags-bin"), tags)
+  }
+
+  func testDecodingBinaryValues() throws {
+    let headers: HPACKHeaders = [
+      "a-bin": "AQI=",
+      "a-bin": "AQI, AwQ=",
+      "b-bin": "not base64!",
+    ]
+
+    XCTAssertEqual(try headers.binaryValues(forName: "a-bin"), [[1, 2], [1, 2], [3, 4]])
+    XCTAssertEqual(try headers.firstBinaryValue(forName: "a-bin"), [1, 2])
+    XCTAssertNil(try headers.firstBinaryValue(forName: "missing-bin"))
+    XCTAssertThrowsError(try headers.binaryValues(forName: "b-bin"))
+  }
+
+  func testAddingBinaryValueWithoutBinSuffixThrows() {
+    var headers = HPACKHeaders()
+    let value: [UInt8] = [1, 2]
+    XCTAssertThrowsError(try headers.addBinary(name: "grpc-tags", value: value)) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+    XCTAssertTrue(headers.isEmpty)
+  }
+}
@@ -0,0 +1,269 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import GRPC
+import HelloWorldModel
+import Logging
+import NIO
+import XCTest
+
+class BuilderValidationTests: GRPCTestCase {
+  private var group: MultiThreadedEventLoopGroup!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func warnings() -> [String] {
+    return self.logFactory.clearCapturedLogs()
+      .filter { $0.level == .warning }
+      .map { $0.message.description }
+  }
+
+  func testClientWarnsAboutBackoffWithReestablishmentDisabled() throws {
+    let connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .withConnectionBackoff(maximum: .seconds(1))
+      .withConnectionReestablishment(enabled: false)
+      .connect(host: "localhost", port: 0)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    XCTAssertEqual(self.warnings().filter { $0.contains("re-establishment is disabled") }.count, 1)
+  }
+
+  func testClientDoesNotWarnWithDefaultOptions() throws {
+    let connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .withConnectionReestablishment(enabled: false)
+      .connect(host: "localhost", port: 0)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    XCTAssertEqual(self.warnings(), [])
+  }
+
+  private func assertInvalidConnection(
+    _ builder: ClientConnection.Builder,
+    file: StaticString = #file,
+    line: UInt = #line
+  ) {
+    let connection = builder
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: 0)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait(), file: file, line: line)
+    }
+
+    XCTAssertThrowsError(try connection.waitForReady().wait(), file: file, line: line) { error in
+      XCTAssert(error is GRPCError.InvalidState, "\(error)", file: file, line: line)
+    }
+    XCTAssertEqual(connection.connectivity.state, .shutdown, file: file, line: line)
+  }
+
+  func testClientWithInvalidBackoffFailsRPCs() {
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group).withConnectionBackoff(jitter: 1.5)
+    )
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group).withConnectionBackoff(multiplier: 0)
+    )
+  }
+
+  func testClientWithInvalidWindowSizeFailsRPCs() {
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group).withHTTPTargetWindowSize(0)
+    )
+  }
+
+  func testClientWithInvalidHeaderTableSizeFailsRPCs() {
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group).withHTTPHeaderTableSize(-1)
+    )
+  }
+
+  func testClientWithInvalidConnectTimeoutFailsRPCs() {
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group).withConnectTimeout(.nanoseconds(0))
+    )
+  }
+
+  func testClientWithInvalidMaxWaitersFailsRPCs() {
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group).withMaxWaitersForConnection(-1)
+    )
+  }
+
+  func testClientWithInvalidConcurrentStreamLimitFailsRPCs() {
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group)
+        .withConcurrentStreamLimit(.init(maximumConcurrentStreams: 0))
+    )
+    self.assertInvalidConnection(
+      ClientConnection.insecure(group: self.group)
+        .withConcurrentStreamLimit(.init(maximumConcurrentStreams: 1, maximumQueuedStreams: -1))
+    )
+  }
+
+  func testServerWithInvalidWindowSizeFailsToBind() {
+    let bind = Server.insecure(group: self.group)
+      .withServiceProviders([EchoProvider()])
+      .withHTTPTargetWindowSize(Int(Int32.max) + 1)
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+
+    XCTAssertThrowsError(try bind.wait()) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+  }
+
+  func testServerWithInvalidHeaderTableSizeFailsToBind() {
+    let bind = Server.insecure(group: self.group)
+      .withServiceProviders([EchoProvider()])
+      .withHTTPHeaderTableSize(Int(UInt32.max) + 1)
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+
+    XCTAssertThrowsError(try bind.wait()) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+  }
+
+  func testServerWithInvalidMaximumBufferedRequestsFailsToBind() {
+    let bind = Server.insecure(group: self.group)
+      .withServiceProviders([EchoProvider()])
+      .withMaximumBufferedRequests(0)
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+
+    XCTAssertThrowsError(try bind.wait()) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+  }
+
+  func testServerWarnsWithoutServiceProviders() throws {
+    let server = try Server.insecure(group: self.group)
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    XCTAssertEqual(self.warnings().filter { $0.contains("no service providers") }.count, 1)
+  }
+
+  func testServerDoesNotWarnWithServiceProviders() throws {
+    let server = try Server.insecure(group: self.group)
+      .withServiceProviders([EchoProvider()])
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    XCTAssertEqual(self.warnings(), [])
+  }
+
+  func testServerWithVariadicServiceProviders() throws {
+    let server = try Server.insecure(group: self.group)
+      .withServiceProviders(EchoProvider(), GreeterProvider())
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    XCTAssertEqual(server.services.map { $0.name }, ["echo.Echo", "helloworld.Greeter"])
+  }
+
+  func testServerFailsToBindWithDuplicateServiceProviders() throws {
+    let bind = Server.insecure(group: self.group)
+      .withServiceProviders(EchoProvider(), GreeterProvider(), EchoProvider())
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+
+    XCTAssertThrowsError(try bind.wait()) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+      XCTAssert("\(error)".contains("echo.Echo"))
+    }
+  }
+
+  func testServerWithRegisteredHandlers() throws {
+    let builder = Server.insecure(group: self.group).withLogger(self.serverLogger)
+    EchoProvider().registerHandlers(on: builder)
+    GreeterProvider().registerHandlers(on: builder)
+
+    let server = try builder.bind(host: "localhost", port: 0).wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    XCTAssertEqual(server.services.map { $0.name }, ["echo.Echo", "helloworld.Greeter"])
+  }
+
+  func testServerFailsToBindWhenHandlersAreRegisteredTwice() throws {
+    let builder = Server.insecure(group: self.group)
+      .withServiceProviders(EchoProvider())
+      .withLogger(self.serverLogger)
+    GreeterProvider().registerHandlers(on: builder)
+    EchoProvider().registerHandlers(on: builder)
+
+    XCTAssertThrowsError(try builder.bind(host: "localhost", port: 0).wait()) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+      XCTAssert("\(error)".contains("echo.Echo"))
+    }
+  }
+
+  func testServerFailsToStartWithDuplicateServiceProviders() throws {
+    var configuration = Server.Configuration.default(
+      target: .hostAndPort("localhost", 0),
+      eventLoopGroup: self.group,
+      serviceProviders: [GreeterProvider()]
+    )
+    configuration.serviceProviders = [GreeterProvider(), GreeterProvider()]
+
+    XCTAssertThrowsError(try Server.start(configuration: configuration).wait()) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+
+    // Replacing the providers clears the error.
+    configuration.serviceProviders = [GreeterProvider()]
+    let server = try Server.start(configuration: configuration).wait()
+    XCTAssertNoThrow(try server.close().wait())
+  }
+}
+
+private final class GreeterProvider: Helloworld_GreeterProvider {
+  var interceptors: Helloworld_GreeterServerInterceptorFactoryProtocol?
+
+  func sayHello(
+    request: Helloworld_HelloRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Helloworld_HelloReply> {
+    return context.eventLoop.makeSucceededFuture(.init())
+  }
+}
@@ -0,0 +1,152 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+@testable import GRPC
+import NIO
+import XCTest
+
+class CallOptionsAuthorityTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+  private var server: Server!
+  private var connection: ClientConnection!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.connection?.close().wait())
+    XCTAssertNoThrow(try self.server?.close().wait())
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func makeClient(defaultCallOptions: CallOptions = CallOptions()) throws
+    -> Echo_EchoClient {
+    self.server = try Server.insecure(group: self.group)
+      .withServiceProviders([AuthorityEchoProvider()])
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+
+    self.connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: self.server.localAddress!.port!)
+
+    return Echo_EchoClient(channel: self.connection, defaultCallOptions: defaultCallOptions)
+  }
+
+  func testValidAuthorities() {
+    let valid = [
+      "localhost",
+      "localhost:443",
+      "tenant-1.example.com",
+      "tenant-1.example.com:8080",
+      "127.0.0.1",
+      "127.0.0.1:65535",
+      "[::1]",
+      "[2001:db8::1]:443",
+      "xn--bcher-kva.example",
+    ]
+
+    for authority in valid {
+      XCTAssertTrue(CallOptions.isValidAuthority(authority), authority)
+    }
+  }
+
+  func testInvalidAuthorities() {
+    let invalid = [
+      "",
+      ":443",
+      "localhost:",
+      "localhost:65536",
+      "localhost:123456",
+      "localhost:http",
+      "user@localhost",
+      "localhost/path",
+      "local host",
+      "[::1",
+      "[]",
+      "[::1]443",
+      "[::1]:",
+      "[example.com]",
+    ]
+
+    for authority in invalid {
+      XCTAssertFalse(CallOptions.isValidAuthority(authority), authority)
+    }
+  }
+
+  func testDefaultAuthorityIsConnectionTarget() throws {
+    let client = try self.makeClient()
+    let get = client.get(.with { $0.text = "" })
+    XCTAssertEqual(try get.response.wait().text, "localhost")
+  }
+
+  func testAuthorityOverriddenPerCall() throws {
+    let client = try self.makeClient()
+    let options = CallOptions(authority: "tenant.example.com:443")
+    let get = client.get(.with { $0.text = "" }, callOptions: options)
+    XCTAssertEqual(try get.response.wait().text, "tenant.example.com:443")
+  }
+
+  func testAuthorityOverriddenForClient() throws {
+    let client = try self.makeClient(defaultCallOptions: CallOptions(authority: "example.com"))
+    let get = client.get(.with { $0.text = "" })
+    XCTAssertEqual(try get.response.wait().text, "example.com")
+  }
+
+  func testMalformedAuthorityFailsRPC() throws {
+    let client = try self.makeClient()
+    let options = CallOptions(authority: "user@example.com")
+    let get = client.get(.with { $0.text = "" }, callOptions: options)
+    XCTAssertThrowsError(try get.response.wait())
+    XCTAssertEqual(try get.status.map { $0.code }.wait(), .invalidArgument)
+  }
+}
+
+/// Responds to 'Get' with the ":authority" of the request.
+private class AuthorityEchoProvider: Echo_EchoProvider {
+  var interceptors: Echo_EchoServerInterceptorFactoryProtocol?
+
+  func get(
+    request: Echo_EchoRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Echo_EchoResponse> {
+    let authority = context.headers.first(name: ":authority") ?? ""
+    return context.eventLoop.makeSucceededFuture(.with { $0.text = authority })
+  }
+
+  func expand(
+    request: Echo_EchoRequest,
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<GRPCStatus> {
+    return context.eventLoop.makeSucceededFuture(.init(code: .unimplemented, message: nil))
+  }
+
+  func collect(
+    context: UnaryResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+
+  func update(
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+}
@@ -0,0 +1,217 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+class CircuitBreakerClientInterceptorTests: GRPCTestCase {
+  private var eventLoop: EmbeddedEventLoop!
+  private var now = NIODeadline.uptimeNanoseconds(1_000_000_000_000)
+  private var breaker: CircuitBreaker!
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = EmbeddedEventLoop()
+    self.breaker = self.makeBreaker()
+  }
+
+  private func makeBreaker(
+    failureRateThreshold: Double = 0.5,
+    window: TimeAmount = .seconds(10),
+    minimumRequests: Int = 4,
+    openDuration: TimeAmount = .seconds(30)
+  ) -> CircuitBreaker {
+    let configuration = CircuitBreaker.Configuration(
+      failureRateThreshold: failureRateThreshold,
+      window: window,
+      minimumRequests: minimumRequests,
+      openDuration: openDuration
+    )
+    return CircuitBreaker(configuration: configuration, now: { self.now })
+  }
+
+  private final class RecordingRPC {
+    var requestParts: [GRPCClientRequestPart<String>] = []
+    var errors: [Error] = []
+    var pipeline: ClientInterceptorPipeline<String, String>!
+  }
+
+  private func startRPC() -> RecordingRPC {
+    let rpc = RecordingRPC()
+    let details = CallDetails(
+      type: .unary,
+      path: "/foo/bar",
+      authority: "ignored",
+      scheme: "ignored",
+      options: CallOptions(logger: self.clientLogger)
+    )
+
+    rpc.pipeline = ClientInterceptorPipeline(
+      eventLoop: self.eventLoop,
+      details: details,
+      logger: details.options.logger.wrapped,
+      interceptors: [CircuitBreakerClientInterceptor(breaker: self.breaker)],
+      errorDelegate: nil,
+      onError: { rpc.errors.append($0) },
+      onCancel: { _ in },
+      onRequestPart: { part, _ in rpc.requestParts.append(part) },
+      onResponsePart: { _ in }
+    )
+
+    rpc.pipeline.send(.metadata([:]), promise: nil)
+    return rpc
+  }
+
+  private func runRPC(endingWith code: GRPCStatus.Code) {
+    let rpc = self.startRPC()
+    XCTAssertEqual(rpc.requestParts.count, 1, "RPC was unexpectedly rejected")
+    rpc.pipeline.receive(.end(GRPCStatus(code: code, message: nil), [:]))
+  }
+
+  private func assertRejected(_ rpc: RecordingRPC, file: StaticString = #file, line: UInt = #line) {
+    XCTAssertTrue(rpc.requestParts.isEmpty, file: file, line: line)
+    XCTAssertEqual(rpc.errors.count, 1, file: file, line: line)
+    let status = rpc.errors.first as? GRPCStatus
+    XCTAssertEqual(status?.code, .unavailable, file: file, line: line)
+  }
+
+  private func trip() {
+    for _ in 0 ..< 4 {
+      self.runRPC(endingWith: .unavailable)
+    }
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .open)
+  }
+
+  func testInvalidConfigurationIsClamped() {
+    let breaker = self.makeBreaker(
+      failureRateThreshold: 1.5,
+      window: .seconds(-1),
+      minimumRequests: 0,
+      openDuration: .seconds(-1)
+    )
+    XCTAssertEqual(breaker.configuration.failureRateThreshold, 1)
+    XCTAssertEqual(breaker.configuration.window, .nanoseconds(1))
+    XCTAssertEqual(breaker.configuration.minimumRequests, 1)
+    XCTAssertEqual(breaker.configuration.openDuration, .nanoseconds(0))
+  }
+
+  func testBreakerStartsClosed() {
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+    self.runRPC(endingWith: .ok)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+  }
+
+  func testBreakerTripsAtFailureRateThreshold() {
+    self.runRPC(endingWith: .ok)
+    self.runRPC(endingWith: .ok)
+    self.runRPC(endingWith: .unavailable)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+    self.runRPC(endingWith: .deadlineExceeded)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .open)
+
+    self.assertRejected(self.startRPC())
+  }
+
+  func testBreakerDoesNotTripBelowMinimumRequests() {
+    for _ in 0 ..< 3 {
+      self.runRPC(endingWith: .unavailable)
+    }
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+  }
+
+  func testNonFailureCodesAreNotCountedAsFailures() {
+    for _ in 0 ..< 4 {
+      self.runRPC(endingWith: .invalidArgument)
+    }
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+  }
+
+  func testOutcomesOutsideOfWindowAreIgnored() {
+    self.runRPC(endingWith: .unavailable)
+    self.runRPC(endingWith: .unavailable)
+    self.runRPC(endingWith: .unavailable)
+
+    self.now = self.now + .seconds(11)
+    self.runRPC(endingWith: .unavailable)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+  }
+
+  func testBreakersAreTrackedPerPath() {
+    self.trip()
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/baz"), .closed)
+  }
+
+  func testErrorsAreCountedAsFailures() {
+    for _ in 0 ..< 4 {
+      let rpc = self.startRPC()
+      rpc.pipeline.errorCaught(GRPCStatus(code: .unavailable, message: "connection refused"))
+    }
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .open)
+  }
+
+  func testCancelledRPCsAreNotRecorded() {
+    for _ in 0 ..< 4 {
+      let rpc = self.startRPC()
+      rpc.pipeline.cancel(promise: nil)
+    }
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+  }
+
+  func testSuccessfulProbeClosesBreaker() {
+    self.trip()
+
+    self.now = self.now + .seconds(30)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .halfOpen)
+
+    // Only one probe is allowed at a time.
+    let probe = self.startRPC()
+    XCTAssertEqual(probe.requestParts.count, 1)
+    self.assertRejected(self.startRPC())
+
+    probe.pipeline.receive(.end(.ok, [:]))
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+    self.runRPC(endingWith: .ok)
+  }
+
+  func testFailedProbeReopensBreaker() {
+    self.trip()
+
+    self.now = self.now + .seconds(30)
+    self.runRPC(endingWith: .unavailable)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .open)
+    self.assertRejected(self.startRPC())
+
+    // The open duration starts again from the failed probe.
+    self.now = self.now + .seconds(29)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .open)
+    self.now = self.now + .seconds(1)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .halfOpen)
+  }
+
+  func testCancelledProbeAllowsAnotherProbe() {
+    self.trip()
+
+    self.now = self.now + .seconds(30)
+    let probe = self.startRPC()
+    XCTAssertEqual(probe.requestParts.count, 1)
+    probe.pipeline.cancel(promise: nil)
+
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .halfOpen)
+    self.runRPC(endingWith: .ok)
+    XCTAssertEqual(self.breaker.state(forPath: "/foo/bar"), .closed)
+  }
+}
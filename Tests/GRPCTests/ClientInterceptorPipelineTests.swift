@@ -52,13 +52,25 @@ class ClientInterceptorPipelineTests: GRPCTestCase {
     )
   }
 
-  private func makeCallDetails(timeLimit: TimeLimit = .none) -> CallDetails {
+  private func makeCallDetails(
+    type: GRPCCallType = .unary,
+    timeLimit: TimeLimit = .none,
+    responseIdleTimeout: TimeAmount? = nil,
+    responseHeadersTimeout: TimeAmount? = nil,
+    writeTimeout: TimeAmount? = nil
+  ) -> CallDetails {
     return CallDetails(
-      type: .unary,
+      type: type,
       path: "ignored",
       authority: "ignored",
       scheme: "ignored",
-      options: CallOptions(timeLimit: timeLimit, logger: self.clientLogger)
+      options: CallOptions(
+        timeLimit: timeLimit,
+        responseIdleTimeout: responseIdleTimeout,
+        responseHeadersTimeout: responseHeadersTimeout,
+        writeTimeout: writeTimeout,
+        logger: self.clientLogger
+      )
     )
   }
 
@@ -189,6 +201,235 @@ class ClientInterceptorPipelineTests: GRPCTestCase {
     pipeline.receive(.metadata([:]))
   }
 
+  func testTimeoutIsHonouredWhenInterceptorDropsError() throws {
+    var cancelled = false
+    var timedOut = false
+
+    class DropErrors<Request, Response>: ClientInterceptor<Request, Response> {
+      override func errorCaught(
+        _ error: Error,
+        context: ClientInterceptorContext<Request, Response>
+      ) {
+        // Not forwarded.
+      }
+    }
+
+    let deadline = NIODeadline.uptimeNanoseconds(100)
+    let pipeline = self.makePipeline(
+      requests: String.self,
+      responses: String.self,
+      details: self.makeCallDetails(timeLimit: .deadline(deadline)),
+      interceptors: [DropErrors()],
+      onError: { error in
+        assertThat(error, .is(.instanceOf(GRPCError.RPCTimedOut.self)))
+        timedOut = true
+      },
+      onCancel: { _ in
+        cancelled = true
+      },
+      onRequestPart: { _, _ in },
+      onResponsePart: { _ in
+        XCTFail("Unexpected response part")
+      }
+    )
+
+    self.embeddedEventLoop.advanceTime(to: deadline)
+    assertThat(timedOut, .is(true))
+    assertThat(cancelled, .is(true))
+
+    // The pipeline is closed.
+    let promise = pipeline.eventLoop.makePromise(of: Void.self)
+    pipeline.send(.end, promise: promise)
+    assertThat(
+      try promise.futureResult.wait(),
+      .throws(.instanceOf(GRPCError.AlreadyComplete.self))
+    )
+  }
+
+  func testResponseIdleTimeout() throws {
+    var timedOut = false
+    var responseParts: [GRPCClientResponsePart<String>] = []
+
+    let pipeline = self.makePipeline(
+      requests: String.self,
+      responses: String.self,
+      details: self.makeCallDetails(type: .serverStreaming, responseIdleTimeout: .nanoseconds(100)),
+      onError: { error in
+        assertThat(error, .is(.instanceOf(GRPCError.RPCIdleTimedOut.self)))
+        timedOut = true
+      },
+      onRequestPart: { _, _ in },
+      onResponsePart: { part in
+        responseParts.append(part)
+      }
+    )
+
+    // Each response part resets the timeout.
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(90))
+    pipeline.receive(.metadata([:]))
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(90))
+    pipeline.receive(.message("foo"))
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(90))
+    assertThat(timedOut, .is(false))
+    assertThat(responseParts, .hasCount(2))
+
+    // Nothing was received for the duration of the timeout.
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(10))
+    assertThat(timedOut, .is(true))
+
+    // The pipeline is closed: further parts are ignored.
+    pipeline.receive(.message("bar"))
+    assertThat(responseParts, .hasCount(2))
+  }
+
+  func testResponseIdleTimeoutIsIgnoredForSingleResponseRPCs() throws {
+    let pipeline = self.makePipeline(
+      requests: String.self,
+      responses: String.self,
+      details: self.makeCallDetails(type: .clientStreaming, responseIdleTimeout: .nanoseconds(100)),
+      onError: { error in
+        XCTFail("Unexpected error: \(error)")
+      },
+      onRequestPart: { _, _ in },
+      onResponsePart: { _ in }
+    )
+
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(200))
+    pipeline.receive(.metadata([:]))
+    pipeline.receive(.end(.ok, [:]))
+  }
+
+  func testResponseHeadersTimeout() throws {
+    var timedOut = false
+    var headersWritten: EventLoopPromise<Void>?
+
+    let pipeline = self.makePipeline(
+      requests: String.self,
+      responses: String.self,
+      details: self.makeCallDetails(responseHeadersTimeout: .nanoseconds(100)),
+      onError: { error in
+        assertThat(error, .is(.instanceOf(GRPCError.RPCResponseHeadersTimedOut.self)))
+        timedOut = true
+      },
+      onRequestPart: { part, promise in
+        if case .metadata = part {
+          headersWritten = promise
+        }
+      },
+      onResponsePart: { _ in }
+    )
+
+    // The timeout doesn't start until the request headers have been written.
+    pipeline.send(.metadata([:]), promise: nil)
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(200))
+    assertThat(timedOut, .is(false))
+
+    let written = try assertNotNil(headersWritten)
+    written.succeed(())
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(99))
+    assertThat(timedOut, .is(false))
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(1))
+    assertThat(timedOut, .is(true))
+  }
+
+  func testResponseHeadersTimeoutIsCancelledByResponse() throws {
+    let pipeline = self.makePipeline(
+      requests: String.self,
+      responses: String.self,
+      details: self.makeCallDetails(
+        type: .serverStreaming,
+        timeLimit: .timeout(.seconds(10)),
+        responseHeadersTimeout: .nanoseconds(100)
+      ),
+      onError: { error in
+        XCTFail("Unexpected error: \(error)")
+      },
+      onRequestPart: { _, promise in
+        promise?.succeed(())
+      },
+      onResponsePart: { _ in }
+    )
+
+    let written = self.embeddedEventLoop.makePromise(of: Void.self)
+    pipeline.send(.metadata([:]), promise: written)
+    assertThat(try written.futureResult.wait(), .doesNotThrow())
+
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(90))
+    pipeline.receive(.metadata([:]))
+
+    // Only the response headers are limited, the rest of the response may take longer.
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(200))
+    pipeline.receive(.message("foo"))
+    pipeline.receive(.end(.ok, [:]))
+  }
+
+  func testWriteTimeout() throws {
+    var timedOut = false
+    var writes: [EventLoopPromise<Void>] = []
+
+    let pipeline = self.makePipeline(
+      requests: String.self,
+      responses: String.self,
+      details: self.makeCallDetails(type: .bidirectionalStreaming, writeTimeout: .nanoseconds(100)),
+      onError: { error in
+        assertThat(error, .is(.instanceOf(GRPCError.RPCWriteTimedOut.self)))
+        timedOut = true
+      },
+      onRequestPart: { part, promise in
+        if case .message = part, let promise = promise {
+          writes.append(promise)
+        }
+      },
+      onResponsePart: { _ in }
+    )
+
+    // Nothing is pending, so the timeout doesn't apply.
+    pipeline.send(.metadata([:]), promise: nil)
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(200))
+    assertThat(timedOut, .is(false))
+
+    // Each completed write resets the timeout while writes are pending.
+    let first = self.embeddedEventLoop.makePromise(of: Void.self)
+    pipeline.send(.message("foo", .init(compress: false, flush: false)), promise: first)
+    pipeline.send(.message("bar", .init(compress: false, flush: false)), promise: nil)
+    assertThat(writes, .hasCount(2))
+
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(90))
+    writes[0].succeed(())
+    assertThat(try first.futureResult.wait(), .doesNotThrow())
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(90))
+    writes[1].succeed(())
+    assertThat(timedOut, .is(false))
+
+    // No writes are pending.
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(200))
+    assertThat(timedOut, .is(false))
+
+    // This write never completes.
+    pipeline.send(.message("baz", .init(compress: false, flush: false)), promise: nil)
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(100))
+    assertThat(timedOut, .is(true))
+  }
+
+  func testWriteTimeoutIsIgnoredForSingleRequestRPCs() throws {
+    let pipeline = self.makePipeline(
+      requests: String.self,
+      responses: String.self,
+      details: self.makeCallDetails(type: .serverStreaming, writeTimeout: .nanoseconds(100)),
+      onError: { error in
+        XCTFail("Unexpected error: \(error)")
+      },
+      onRequestPart: { _, promise in
+        XCTAssertNil(promise)
+      },
+      onResponsePart: { _ in }
+    )
+
+    pipeline.send(.message("foo", .init(compress: false, flush: false)), promise: nil)
+    self.embeddedEventLoop.advanceTime(by: .nanoseconds(200))
+    pipeline.receive(.end(.ok, [:]))
+  }
+
   func testTimeoutIsCancelledOnCompletion() throws {
     let deadline = NIODeadline.uptimeNanoseconds(100)
     var cancellations = 0
@@ -239,6 +480,56 @@ class ClientInterceptorPipelineTests: GRPCTestCase {
     XCTAssertEqual(message, "oof")
   }
 
+  func testInterceptorsSeeResolvedDeadline() {
+    let recorder = DeadlineRecorder<String, String>()
+    let deadline = NIODeadline.uptimeNanoseconds(1_000_000)
+    let pipeline = self.makePipeline(
+      details: self.makeCallDetails(timeLimit: .deadline(deadline)),
+      interceptors: [recorder],
+      onRequestPart: { _, _ in },
+      onResponsePart: { _ in }
+    )
+
+    pipeline.send(.metadata([:]), promise: nil)
+    XCTAssertEqual(recorder.deadline, deadline)
+    XCTAssertEqual(recorder.hasDeadline, true)
+  }
+
+  func testInterceptorsSeeDeadlineResolvedFromTimeout() throws {
+    let recorder = DeadlineRecorder<String, String>()
+    let before = NIODeadline.now()
+    let pipeline = self.makePipeline(
+      details: self.makeCallDetails(timeLimit: .timeout(.seconds(10))),
+      interceptors: [recorder],
+      onRequestPart: { _, _ in },
+      onResponsePart: { _ in }
+    )
+    let after = NIODeadline.now()
+
+    // The deadline is resolved once, so it mustn't change between request parts.
+    pipeline.send(.metadata([:]), promise: nil)
+    let first = recorder.deadline
+    pipeline.send(.end, promise: nil)
+    XCTAssertEqual(recorder.deadline, first)
+
+    let resolved = try XCTUnwrap(first)
+    XCTAssertGreaterThanOrEqual(resolved, before + .seconds(10))
+    XCTAssertLessThanOrEqual(resolved, after + .seconds(10))
+  }
+
+  func testInterceptorsSeeNoDeadline() {
+    let recorder = DeadlineRecorder<String, String>()
+    let pipeline = self.makePipeline(
+      interceptors: [recorder],
+      onRequestPart: { _, _ in },
+      onResponsePart: { _ in }
+    )
+
+    pipeline.send(.metadata([:]), promise: nil)
+    XCTAssertEqual(recorder.deadline, .distantFuture)
+    XCTAssertEqual(recorder.hasDeadline, false)
+  }
+
   func testErrorDelegateIsCalled() throws {
     class Delegate: ClientErrorDelegate {
       let expectedError: GRPCError.InvalidState
@@ -318,6 +609,22 @@ class RecordingInterceptor<Request, Response>: ClientInterceptor<Request, Respon
   }
 }
 
+/// An interceptor which records the deadline from its context when sending request parts.
+class DeadlineRecorder<Request, Response>: ClientInterceptor<Request, Response> {
+  var deadline: NIODeadline?
+  var hasDeadline: Bool?
+
+  override func send(
+    _ part: GRPCClientRequestPart<Request>,
+    promise: EventLoopPromise<Void>?,
+    context: ClientInterceptorContext<Request, Response>
+  ) {
+    self.deadline = context.deadline
+    self.hasDeadline = context.hasDeadline
+    context.send(part, promise: promise)
+  }
+}
+
 /// An interceptor which reverses string request messages.
 class StringRequestReverser: ClientInterceptor<String, String> {
   override func send(
@@ -240,4 +240,49 @@ class ClientTLSFailureTests: GRPCTestCase {
       XCTFail("Expected NIOSSLError.handshakeFailed(BoringSSL.sslError)")
     }
   }
+
+  private func getStatus(tls: GRPCTLSConfiguration) throws -> GRPCStatus {
+    let connection = ClientConnection(configuration: self.makeClientConfiguration(tls: tls))
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let echo = Echo_EchoClient(channel: connection)
+    return try echo.get(.with { $0.text = "foo" }).status.wait()
+  }
+
+  func testStatusCauseWhenServerIsUnknown() throws {
+    var tls = self.defaultClientTLSConfiguration
+    tls.updateNIOTrustRoots(to: .certificates([]))
+
+    let status = try self.getStatus(tls: tls)
+    XCTAssertEqual(status.code, .unavailable)
+
+    let failure = try XCTUnwrap(status.cause as? TLSVerificationFailure)
+    XCTAssertEqual(failure.reason, .untrustedCertificate)
+  }
+
+  func testStatusCauseWhenHostnameIsNotValid() throws {
+    var tls = self.defaultClientTLSConfiguration
+    tls.hostnameOverride = "not-the-server-hostname"
+
+    let status = try self.getStatus(tls: tls)
+    XCTAssertEqual(status.code, .unavailable)
+
+    let failure = try XCTUnwrap(status.cause as? TLSVerificationFailure)
+    XCTAssertEqual(failure.reason, .hostnameMismatch)
+    XCTAssertEqual(failure.underlyingError as? NIOSSLExtraError, .failedToValidateHostname)
+  }
+
+  func testClassifyingErrors() {
+    let hostname = TLSVerificationFailure(NIOSSLExtraError.failedToValidateHostname)
+    XCTAssertEqual(hostname?.reason, .hostnameMismatch)
+    XCTAssertEqual(
+      TLSVerificationFailure(NIOSSLError.unableToValidateCertificate)?.reason,
+      .untrustedCertificate
+    )
+
+    XCTAssertNil(TLSVerificationFailure(NIOSSLError.uncleanShutdown))
+    XCTAssertNil(TLSVerificationFailure(GRPCStatus(code: .unavailable, message: nil)))
+  }
 }
@@ -16,6 +16,7 @@
 import EchoModel
 import Foundation
 @testable import GRPC
+import GRPCTestingSupport
 import NIO
 import XCTest
 
@@ -158,4 +159,82 @@ class ClientTimeoutTests: GRPCTestCase {
 
     self.wait(for: [statusExpectation], timeout: self.testTimeout)
   }
+
+  func testTimeoutIsResolvedWithCallOptionsClock() throws {
+    // The deadline is scheduled relative to the clock so its time needn't agree with the loop.
+    var options = self.callOptionsWithLogger
+    options.timeLimit = .timeout(self.timeout)
+    options.clock = GRPCClock { .uptimeNanoseconds(0) }
+
+    let call = self.client.get(Echo_EchoRequest(text: "foo"), callOptions: options)
+
+    var status: GRPCStatus?
+    call.status.whenSuccess {
+      status = $0
+    }
+
+    // Just before the deadline.
+    self.channel.embeddedEventLoop.advanceTime(by: self.timeout - .nanoseconds(1))
+    XCTAssertNil(status)
+
+    // At the deadline.
+    self.channel.embeddedEventLoop.advanceTime(by: .nanoseconds(1))
+    XCTAssertEqual(status?.code, .deadlineExceeded)
+  }
+
+  func testTimeoutIsScheduledOnManualClock() throws {
+    let manualClock = ManualClock()
+    var options = self.callOptionsWithLogger
+    options.timeLimit = .timeout(self.timeout)
+    options.clock = manualClock.clock
+
+    let call = self.client.get(Echo_EchoRequest(text: "foo"), callOptions: options)
+
+    var status: GRPCStatus?
+    call.status.whenSuccess {
+      status = $0
+    }
+
+    // Advancing the event loop doesn't affect the deadline.
+    self.channel.embeddedEventLoop.advanceTime(by: self.timeout)
+    XCTAssertNil(status)
+
+    // Just before the deadline.
+    manualClock.advance(by: self.timeout - .nanoseconds(1))
+    XCTAssertNil(status)
+
+    // At the deadline.
+    manualClock.advance(by: .nanoseconds(1))
+    XCTAssertEqual(status?.code, .deadlineExceeded)
+  }
+
+  func testMakeDeadlineUsingClock() {
+    let clock = GRPCClock { .uptimeNanoseconds(1000) }
+    XCTAssertEqual(
+      TimeLimit.timeout(.nanoseconds(10)).makeDeadline(using: clock),
+      .uptimeNanoseconds(1010)
+    )
+    XCTAssertEqual(
+      TimeLimit.deadline(.uptimeNanoseconds(42)).makeDeadline(using: clock),
+      .uptimeNanoseconds(42)
+    )
+    XCTAssertEqual(TimeLimit.none.makeDeadline(using: clock), .distantFuture)
+  }
+
+  func testLimitingDeadline() {
+    var options = CallOptions(timeLimit: .timeout(.nanoseconds(10)))
+    options.clock = GRPCClock { .uptimeNanoseconds(1000) }
+
+    // The scope's deadline is earlier than the call's timeout.
+    let earlier = options.limitingDeadline(to: .uptimeNanoseconds(1005))
+    XCTAssertEqual(earlier.timeLimit, .deadline(.uptimeNanoseconds(1005)))
+
+    // The call's timeout is earlier than the scope's deadline.
+    let later = options.limitingDeadline(to: .uptimeNanoseconds(2000))
+    XCTAssertEqual(later.timeLimit, .timeout(.nanoseconds(10)))
+
+    options.timeLimit = .none
+    let unlimited = options.limitingDeadline(to: .uptimeNanoseconds(2000))
+    XCTAssertEqual(unlimited.timeLimit, .deadline(.uptimeNanoseconds(2000)))
+  }
 }
@@ -0,0 +1,229 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+@testable import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+class CoalescingServerInterceptorTests: GRPCTestCase {
+  private var eventLoop: EmbeddedEventLoop!
+  private var coalescer: UnaryRequestCoalescer!
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = EmbeddedEventLoop()
+    self.coalescer = UnaryRequestCoalescer()
+  }
+
+  private final class RecordingRPC {
+    var requestParts: [GRPCServerRequestPart<String>] = []
+    var responseParts: [GRPCServerResponsePart<String>] = []
+    var pipeline: ServerInterceptorPipeline<String, String>!
+  }
+
+  private func makeRPC(callType: GRPCCallType = .unary) -> RecordingRPC {
+    let rpc = RecordingRPC()
+    let interceptor = CoalescingServerInterceptor<String, String>(coalescer: self.coalescer) {
+      Data($0.utf8)
+    }
+
+    rpc.pipeline = ServerInterceptorPipeline(
+      logger: self.serverLogger,
+      eventLoop: self.eventLoop,
+      path: "/foo/bar",
+      callType: callType,
+      remoteAddress: nil,
+      userInfoRef: Ref(UserInfo()),
+      interceptors: [interceptor],
+      onRequestPart: { rpc.requestParts.append($0) },
+      onResponsePart: { part, _ in rpc.responseParts.append(part) }
+    )
+
+    return rpc
+  }
+
+  private func start(_ rpc: RecordingRPC, request: String) {
+    rpc.pipeline.receive(.metadata([:]))
+    rpc.pipeline.receive(.message(request))
+    rpc.pipeline.receive(.end)
+  }
+
+  func testIdenticalRequestsShareResponse() {
+    let leader = self.makeRPC()
+    let follower = self.makeRPC()
+
+    self.start(leader, request: "foo")
+    self.start(follower, request: "foo")
+
+    // Only the leader reaches the service provider.
+    assertThat(leader.requestParts, .hasCount(3))
+    assertThat(follower.requestParts, .isEmpty())
+    assertThat(self.coalescer.inFlightCount, .is(1))
+
+    let metadata = MessageMetadata(compress: false, flush: true)
+    leader.pipeline.send(.metadata(["key": "value"]), promise: nil)
+    leader.pipeline.send(.message("bar", metadata), promise: nil)
+    leader.pipeline.send(.end(.ok, [:]), promise: nil)
+    self.eventLoop.run()
+
+    assertThat(self.coalescer.inFlightCount, .is(0))
+    for rpc in [leader, follower] {
+      assertThat(rpc.responseParts, .hasCount(3))
+      assertThat(rpc.responseParts[0].metadata?.first(name: "key"), .is("value"))
+      assertThat(rpc.responseParts[1].message, .is("bar"))
+      assertThat(rpc.responseParts[2].end?.0.code, .is(.ok))
+    }
+  }
+
+  func testDifferentRequestsAreNotCoalesced() {
+    let first = self.makeRPC()
+    let second = self.makeRPC()
+
+    self.start(first, request: "foo")
+    self.start(second, request: "bar")
+
+    assertThat(first.requestParts, .hasCount(3))
+    assertThat(second.requestParts, .hasCount(3))
+    assertThat(self.coalescer.inFlightCount, .is(2))
+  }
+
+  func testFailureIsSharedWithFollowers() {
+    let leader = self.makeRPC()
+    let follower = self.makeRPC()
+
+    self.start(leader, request: "foo")
+    self.start(follower, request: "foo")
+
+    leader.pipeline.send(.end(GRPCStatus(code: .notFound, message: nil), [:]), promise: nil)
+    self.eventLoop.run()
+
+    // No metadata was sent by the leader so none should be replayed.
+    assertThat(follower.responseParts, .hasCount(1))
+    assertThat(follower.responseParts[0].end?.0.code, .is(.notFound))
+  }
+
+  func testFollowerMayFinishIndependently() {
+    let leader = self.makeRPC()
+    let follower = self.makeRPC()
+
+    self.start(leader, request: "foo")
+    self.start(follower, request: "foo")
+
+    // e.g. the follower's deadline expired.
+    let deadlineExceeded = GRPCStatus(code: .deadlineExceeded, message: nil)
+    follower.pipeline.send(.end(deadlineExceeded, [:]), promise: nil)
+    assertThat(follower.responseParts, .hasCount(1))
+
+    leader.pipeline.send(.end(.ok, [:]), promise: nil)
+    self.eventLoop.run()
+
+    // The leader's response isn't replayed on the follower.
+    assertThat(follower.responseParts, .hasCount(1))
+    assertThat(follower.responseParts[0].end?.0.code, .is(.deadlineExceeded))
+    assertThat(leader.responseParts, .hasCount(1))
+  }
+
+  func testFollowerIsPromotedWhenLeaderIsCancelled() {
+    let leader = self.makeRPC()
+    let first = self.makeRPC()
+    let second = self.makeRPC()
+
+    self.start(leader, request: "foo")
+    self.start(first, request: "foo")
+    self.start(second, request: "foo")
+
+    // The client cancels the leader: the server fails it, but the status isn't shared.
+    leader.pipeline._isTerminatedByServer = true
+    leader.pipeline.send(.end(GRPCStatus(code: .unavailable, message: nil), [:]), promise: nil)
+    self.eventLoop.run()
+
+    assertThat(leader.responseParts, .hasCount(1))
+    assertThat(first.responseParts, .isEmpty())
+    assertThat(second.responseParts, .isEmpty())
+
+    // The longest waiting follower is now the leader and its request reaches the service provider.
+    assertThat(first.requestParts, .hasCount(3))
+    assertThat(second.requestParts, .isEmpty())
+    assertThat(self.coalescer.inFlightCount, .is(1))
+
+    let metadata = MessageMetadata(compress: false, flush: true)
+    first.pipeline.send(.message("bar", metadata), promise: nil)
+    first.pipeline.send(.end(.ok, [:]), promise: nil)
+    self.eventLoop.run()
+
+    assertThat(self.coalescer.inFlightCount, .is(0))
+    for rpc in [first, second] {
+      assertThat(rpc.responseParts, .hasCount(2))
+      assertThat(rpc.responseParts[0].message, .is("bar"))
+      assertThat(rpc.responseParts[1].end?.0.code, .is(.ok))
+    }
+  }
+
+  func testFinishedFollowerIsNotPromoted() {
+    let leader = self.makeRPC()
+    let first = self.makeRPC()
+    let second = self.makeRPC()
+
+    self.start(leader, request: "foo")
+    self.start(first, request: "foo")
+    self.start(second, request: "foo")
+
+    // The first follower's deadline expires, then the leader's does.
+    let deadlineExceeded = GRPCStatus(code: .deadlineExceeded, message: nil)
+    first.pipeline.send(.end(deadlineExceeded, [:]), promise: nil)
+    leader.pipeline._isTerminatedByServer = true
+    leader.pipeline.send(.end(deadlineExceeded, [:]), promise: nil)
+    self.eventLoop.run()
+
+    // Leadership passes over the finished follower to the next one.
+    assertThat(first.requestParts, .isEmpty())
+    assertThat(first.responseParts, .hasCount(1))
+    assertThat(second.requestParts, .hasCount(3))
+    assertThat(second.responseParts, .isEmpty())
+  }
+
+  func testLeaderTerminatedWithoutFollowers() {
+    let leader = self.makeRPC()
+    self.start(leader, request: "foo")
+
+    leader.pipeline._isTerminatedByServer = true
+    leader.pipeline.send(.end(GRPCStatus(code: .unavailable, message: nil), [:]), promise: nil)
+    assertThat(self.coalescer.inFlightCount, .is(0))
+  }
+
+  func testRequestsAfterCompletionAreNotCoalesced() {
+    let first = self.makeRPC()
+    self.start(first, request: "foo")
+    first.pipeline.send(.end(.ok, [:]), promise: nil)
+
+    let second = self.makeRPC()
+    self.start(second, request: "foo")
+    assertThat(second.requestParts, .hasCount(3))
+  }
+
+  func testStreamingRPCsAreNotCoalesced() {
+    let first = self.makeRPC(callType: .clientStreaming)
+    let second = self.makeRPC(callType: .clientStreaming)
+
+    self.start(first, request: "foo")
+    self.start(second, request: "foo")
+
+    assertThat(first.requestParts, .hasCount(3))
+    assertThat(second.requestParts, .hasCount(3))
+    assertThat(self.coalescer.inFlightCount, .is(0))
+  }
+}
@@ -306,6 +306,8 @@ internal protocol Normalization_NormalizationProvider: CallHandlerProvider {
 extension Normalization_NormalizationProvider {
   internal var serviceName: Substring { return "normalization.Normalization" }
 
+  internal var methodNames: [Substring] { return ["Unary", "unary", "ServerStreaming", "serverStreaming", "ClientStreaming", "clientStreaming", "BidirectionalStreaming", "bidirectionalStreaming"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   internal func handle(
@@ -389,6 +391,16 @@ extension Normalization_NormalizationProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'normalization.Normalization' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'normalization.Normalization' is registered more
+  /// than once.
+  @discardableResult
+  internal func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 internal protocol Normalization_NormalizationServerInterceptorFactoryProtocol {
@@ -0,0 +1,131 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class CompressionStatisticsTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  func testStatisticsAreReportedForCompressedCall() throws {
+    let serverStatistics = self.group.next().makePromise(of: CompressionStatistics.self)
+    let server = try Server.insecure(group: self.group)
+      .withLogger(self.serverLogger)
+      .withServiceProviders([EchoProvider()])
+      .withMessageCompression(.enabled(.init(decompressionLimit: .absolute(1 << 20))))
+      .withCompressionStatisticsObserver { serverStatistics.succeed($0) }
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    let clientStatistics = self.group.next().makePromise(of: CompressionStatistics.self)
+    let connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .withCompressionStatisticsObserver { clientStatistics.succeed($0) }
+      .connect(host: "localhost", port: server.channel.localAddress!.port!)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let echo = Echo_EchoClient(
+      channel: connection,
+      defaultCallOptions: CallOptions(
+        messageEncoding: .enabled(.init(
+          forRequests: .gzip,
+          acceptableForResponses: [.deflate, .gzip],
+          decompressionLimit: .absolute(1 << 20)
+        )),
+        logger: self.clientLogger
+      )
+    )
+
+    let request = Echo_EchoRequest.with { $0.text = String(repeating: "a", count: 1024) }
+    let get = echo.get(request)
+    let response = try get.response.wait()
+    XCTAssertEqual(try get.status.wait().code, .ok)
+
+    let requestSize = try request.serializedData().count
+    let responseSize = try response.serializedData().count
+
+    let client = try clientStatistics.futureResult.wait()
+    XCTAssertEqual(client.path, "/echo.Echo/Get")
+    XCTAssertEqual(client.sent.messages, 1)
+    XCTAssertEqual(client.sent.uncompressedBytes, requestSize)
+    XCTAssertLessThan(client.sent.wireBytes, requestSize)
+    XCTAssertEqual(client.received.messages, 1)
+    XCTAssertEqual(client.received.uncompressedBytes, responseSize)
+    XCTAssertLessThan(client.received.wireBytes, responseSize)
+    XCTAssertGreaterThan(try XCTUnwrap(client.sent.compressionRatio), 1)
+
+    let server = try serverStatistics.futureResult.wait()
+    XCTAssertEqual(server.path, "/echo.Echo/Get")
+    XCTAssertEqual(server.received, client.sent.withDuration(server.received.duration))
+    XCTAssertEqual(server.sent, client.received.withDuration(server.sent.duration))
+  }
+
+  func testStatisticsForUncompressedCall() throws {
+    let serverStatistics = self.group.next().makePromise(of: CompressionStatistics.self)
+    let server = try Server.insecure(group: self.group)
+      .withLogger(self.serverLogger)
+      .withServiceProviders([EchoProvider()])
+      .withCompressionStatisticsObserver { serverStatistics.succeed($0) }
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    let connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: server.channel.localAddress!.port!)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let echo = Echo_EchoClient(channel: connection)
+    let request = Echo_EchoRequest.with { $0.text = "hello" }
+    XCTAssertEqual(try echo.get(request).status.wait().code, .ok)
+
+    let statistics = try serverStatistics.futureResult.wait()
+    let requestSize = try request.serializedData().count
+    XCTAssertEqual(statistics.received.messages, 1)
+    XCTAssertEqual(statistics.received.uncompressedBytes, requestSize)
+    XCTAssertEqual(statistics.received.wireBytes, requestSize)
+    XCTAssertEqual(statistics.received.compressionRatio, 1)
+  }
+}
+
+extension CompressionStatistics.MessageTotals {
+  fileprivate func withDuration(_ duration: TimeAmount) -> Self {
+    var copy = self
+    copy.duration = duration
+    return copy
+  }
+}
@@ -0,0 +1,239 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import NIO
+import NIOHPACK
+import NIOHTTP2
+import XCTest
+
+class ConnectUnaryClientChannelHandlerTests: GRPCTestCase {
+  private func makeChannel(
+    callType: GRPCCallType = .unary,
+    timeLimit: TimeLimit = .none,
+    clock: GRPCClock = .system
+  ) -> EmbeddedChannel {
+    let handler = ConnectUnaryClientChannelHandler(
+      callType: callType,
+      timeLimit: timeLimit,
+      clock: clock,
+      maximumReceiveMessageLength: .max,
+      logger: GRPCLogger(wrapping: self.clientLogger)
+    )
+    return EmbeddedChannel(handler: handler)
+  }
+
+  private func makeRequestHead(
+    deadline: NIODeadline = .distantFuture,
+    customMetadata: HPACKHeaders = [:]
+  ) -> _GRPCRequestHead {
+    return _GRPCRequestHead(
+      method: "POST",
+      scheme: "https",
+      path: "/echo.Echo/Get",
+      host: "localhost",
+      deadline: deadline,
+      customMetadata: customMetadata,
+      encoding: .disabled
+    )
+  }
+
+  private func sendRequest(on channel: EmbeddedChannel) throws {
+    let message = _MessageContext(ByteBuffer(string: "request"), compressed: false)
+    try channel.writeOutbound(_RawGRPCClientRequestPart.head(self.makeRequestHead()))
+    try channel.writeOutbound(_RawGRPCClientRequestPart.message(message))
+    try channel.writeOutbound(_RawGRPCClientRequestPart.end)
+  }
+
+  private func respond(
+    on channel: EmbeddedChannel,
+    headers: HPACKHeaders,
+    body: String
+  ) throws {
+    let headersPayload = HTTP2Frame.FramePayload.headers(.init(headers: headers))
+    try channel.writeInbound(headersPayload)
+    let data = HTTP2Frame.FramePayload.Data(data: .byteBuffer(.init(string: body)), endStream: true)
+    try channel.writeInbound(HTTP2Frame.FramePayload.data(data))
+  }
+
+  private func readStatus(from channel: EmbeddedChannel) throws -> GRPCStatus? {
+    while let part = try channel.readInbound(as: _RawGRPCClientResponsePart.self) {
+      if case let .status(status) = part {
+        return status
+      }
+    }
+    return nil
+  }
+
+  func testRequestFrames() throws {
+    let channel = self.makeChannel(timeLimit: .timeout(.milliseconds(1500)))
+    let metadata: HPACKHeaders = ["Foo": "bar"]
+    let head = self.makeRequestHead(customMetadata: metadata)
+    XCTAssertNoThrow(try channel.writeOutbound(_RawGRPCClientRequestPart.head(head)))
+
+    let headersFrame = try channel.readOutbound(as: HTTP2Frame.FramePayload.self)
+    guard case let .some(.headers(headers)) = headersFrame else {
+      return XCTFail("Expected HEADERS but got \(String(describing: headersFrame))")
+    }
+
+    XCTAssertFalse(headers.endStream)
+    XCTAssertEqual(headers.headers.first(name: ":path"), "/echo.Echo/Get")
+    XCTAssertEqual(headers.headers.first(name: "content-type"), "application/proto")
+    XCTAssertEqual(headers.headers.first(name: "connect-protocol-version"), "1")
+    XCTAssertEqual(headers.headers.first(name: "connect-timeout-ms"), "1500")
+    XCTAssertEqual(headers.headers.first(name: "foo"), "bar")
+    XCTAssertNil(headers.headers.first(name: "te"))
+    XCTAssertNil(headers.headers.first(name: "grpc-timeout"))
+
+    let message = _MessageContext(ByteBuffer(string: "request"), compressed: false)
+    XCTAssertNoThrow(try channel.writeOutbound(_RawGRPCClientRequestPart.message(message)))
+    let dataFrame = try channel.readOutbound(as: HTTP2Frame.FramePayload.self)
+    guard case let .some(.data(data)) = dataFrame, case let .byteBuffer(body) = data.data else {
+      return XCTFail("Expected DATA but got \(String(describing: dataFrame))")
+    }
+    // The body is the serialized message without a length-prefix.
+    XCTAssertEqual(body, ByteBuffer(string: "request"))
+    XCTAssertFalse(data.endStream)
+
+    XCTAssertNoThrow(try channel.writeOutbound(_RawGRPCClientRequestPart.end))
+    let endFrame = try channel.readOutbound(as: HTTP2Frame.FramePayload.self)
+    guard case let .some(.data(end)) = endFrame else {
+      return XCTFail("Expected DATA but got \(String(describing: endFrame))")
+    }
+    XCTAssertTrue(end.endStream)
+  }
+
+  func testConnectTimeoutIsMeasuredByClock() throws {
+    let clock = GRPCClock { .uptimeNanoseconds(1_000_000_000) }
+    let deadline = NIODeadline.uptimeNanoseconds(1_000_000_000) + .milliseconds(250)
+    let channel = self.makeChannel(timeLimit: .deadline(deadline), clock: clock)
+    // The deadline of the head is measured by the system clock, it's ignored.
+    let head = self.makeRequestHead(deadline: .now() + .seconds(10))
+    XCTAssertNoThrow(try channel.writeOutbound(_RawGRPCClientRequestPart.head(head)))
+
+    let headersFrame = try channel.readOutbound(as: HTTP2Frame.FramePayload.self)
+    guard case let .some(.headers(headers)) = headersFrame else {
+      return XCTFail("Expected HEADERS but got \(String(describing: headersFrame))")
+    }
+    XCTAssertEqual(headers.headers.first(name: "connect-timeout-ms"), "250")
+  }
+
+  func testSecondRequestMessageFails() throws {
+    let channel = self.makeChannel()
+    let head = self.makeRequestHead()
+    let message = _MessageContext(ByteBuffer(string: "request"), compressed: false)
+    XCTAssertNoThrow(try channel.writeOutbound(_RawGRPCClientRequestPart.head(head)))
+    XCTAssertNoThrow(try channel.writeOutbound(_RawGRPCClientRequestPart.message(message)))
+    XCTAssertThrowsError(try channel.writeOutbound(_RawGRPCClientRequestPart.message(message))) {
+      XCTAssert($0 is GRPCError.StreamCardinalityViolation)
+    }
+  }
+
+  func testSuccessfulResponse() throws {
+    let channel = self.makeChannel()
+    XCTAssertNoThrow(try self.sendRequest(on: channel))
+
+    let headers: HPACKHeaders = [
+      ":status": "200",
+      "content-type": "application/proto",
+      "foo": "bar",
+      "trailer-baz": "qux",
+    ]
+    XCTAssertNoThrow(try self.respond(on: channel, headers: headers, body: "response"))
+
+    let initialMetadata = try channel.readInbound(as: _RawGRPCClientResponsePart.self)
+    guard case let .some(.initialMetadata(initial)) = initialMetadata else {
+      return XCTFail("Expected initial metadata but got \(String(describing: initialMetadata))")
+    }
+    XCTAssertEqual(initial.first(name: "foo"), "bar")
+    XCTAssertNil(initial.first(name: "trailer-baz"))
+
+    let message = try channel.readInbound(as: _RawGRPCClientResponsePart.self)
+    guard case let .some(.message(context)) = message else {
+      return XCTFail("Expected message but got \(String(describing: message))")
+    }
+    XCTAssertEqual(context.message, ByteBuffer(string: "response"))
+
+    let trailingMetadata = try channel.readInbound(as: _RawGRPCClientResponsePart.self)
+    guard case let .some(.trailingMetadata(trailing)) = trailingMetadata else {
+      return XCTFail("Expected trailing metadata but got \(String(describing: trailingMetadata))")
+    }
+    XCTAssertEqual(trailing.first(name: "baz"), "qux")
+
+    XCTAssertEqual(try self.readStatus(from: channel)?.code, .ok)
+  }
+
+  func testResponseWithUnexpectedContentType() throws {
+    let channel = self.makeChannel()
+    XCTAssertNoThrow(try self.sendRequest(on: channel))
+
+    let headers: HPACKHeaders = [":status": "200", "content-type": "text/html"]
+    XCTAssertThrowsError(try self.respond(on: channel, headers: headers, body: "<html>")) {
+      let error = ($0 as? GRPCError.WithContext)?.error as? GRPCError.InvalidContentType
+      XCTAssertNotNil(error)
+    }
+  }
+
+  func testErrorResponse() throws {
+    let channel = self.makeChannel()
+    XCTAssertNoThrow(try self.sendRequest(on: channel))
+
+    let headers: HPACKHeaders = [
+      ":status": "404",
+      "content-type": "application/json",
+      "foo": "bar",
+      "trailer-baz": "qux",
+    ]
+    let body = #"{"code": "not_found", "message": "no such echo"}"#
+    XCTAssertNoThrow(try self.respond(on: channel, headers: headers, body: body))
+
+    // The response headers are the initial metadata and are read before the status.
+    let initialMetadata = try channel.readInbound(as: _RawGRPCClientResponsePart.self)
+    guard case let .some(.initialMetadata(initial)) = initialMetadata else {
+      return XCTFail("Expected initial metadata but got \(String(describing: initialMetadata))")
+    }
+    XCTAssertEqual(initial.first(name: "foo"), "bar")
+    XCTAssertNil(initial.first(name: "trailer-baz"))
+
+    let trailingMetadata = try channel.readInbound(as: _RawGRPCClientResponsePart.self)
+    guard case let .some(.trailingMetadata(trailing)) = trailingMetadata else {
+      return XCTFail("Expected trailing metadata but got \(String(describing: trailingMetadata))")
+    }
+    XCTAssertEqual(trailing.first(name: "foo"), "bar")
+    XCTAssertEqual(trailing.first(name: "baz"), "qux")
+
+    let status = try self.readStatus(from: channel)
+    XCTAssertEqual(status?.code, .notFound)
+    XCTAssertEqual(status?.message, "no such echo")
+  }
+
+  func testErrorResponseWithoutJSONBody() throws {
+    let channel = self.makeChannel()
+    XCTAssertNoThrow(try self.sendRequest(on: channel))
+
+    let headers: HPACKHeaders = [":status": "503", "content-type": "text/plain"]
+    XCTAssertNoThrow(try self.respond(on: channel, headers: headers, body: "overloaded"))
+
+    XCTAssertEqual(try self.readStatus(from: channel)?.code, .unavailable)
+  }
+
+  func testStreamingRPCsAreUnimplemented() throws {
+    let channel = self.makeChannel(callType: .serverStreaming)
+    let head = self.makeRequestHead()
+    XCTAssertThrowsError(try channel.writeOutbound(_RawGRPCClientRequestPart.head(head))) {
+      XCTAssertEqual(($0 as? GRPCStatus)?.code, .unimplemented)
+    }
+  }
+}
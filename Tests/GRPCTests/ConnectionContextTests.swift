@@ -0,0 +1,82 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import XCTest
+
+private enum RPCCountKey: UserInfo.Key {
+  typealias Value = Int
+}
+
+/// Counts the RPCs on each connection using the connection's `UserInfo`.
+private class RPCCountingInterceptor: ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse> {
+  private let lock = Lock()
+  private var _counts: [Int] = []
+
+  var counts: [Int] {
+    return self.lock.withLock { self._counts }
+  }
+
+  override func receive(
+    _ part: GRPCServerRequestPart<Echo_EchoRequest>,
+    context: ServerInterceptorContext<Echo_EchoRequest, Echo_EchoResponse>
+  ) {
+    if case .metadata = part, let connection = context.connection {
+      let count = (connection.userInfo[RPCCountKey.self] ?? 0) + 1
+      connection.userInfo[RPCCountKey.self] = count
+      self.lock.withLockVoid {
+        self._counts.append(count)
+      }
+    }
+    context.receive(part)
+  }
+}
+
+class ConnectionContextTests: EchoTestCaseBase {
+  private let counter = RPCCountingInterceptor()
+
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return EchoProvider(interceptors: EchoInterceptorFactory(interceptor: self.counter))
+  }
+
+  func testUserInfoIsSharedByRPCsOnTheSameConnection() throws {
+    for _ in 0 ..< 3 {
+      let get = self.client.get(.with { $0.text = "foo" })
+      XCTAssertEqual(try get.status.wait().code, .ok)
+    }
+
+    XCTAssertEqual(self.counter.counts, [1, 2, 3])
+  }
+
+  func testUserInfoIsNotSharedAcrossConnections() throws {
+    let get = self.client.get(.with { $0.text = "foo" })
+    XCTAssertEqual(try get.status.wait().code, .ok)
+
+    let connection = ClientConnection.insecure(group: self.clientEventLoopGroup)
+      .connect(host: "localhost", port: self.port)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let otherGet = Echo_EchoClient(channel: connection).get(.with { $0.text = "foo" })
+    XCTAssertEqual(try otherGet.status.wait().code, .ok)
+
+    XCTAssertEqual(self.counter.counts, [1, 1])
+  }
+}
@@ -15,6 +15,7 @@
  */
 import EchoModel
 @testable import GRPC
+import GRPCTestingSupport
 import Logging
 import NIO
 import NIOHTTP2
@@ -311,6 +312,163 @@ extension ConnectionManagerTests {
     }
   }
 
+  func testIdleTimeoutIsScheduledOnConnectionClock() throws {
+    let manualClock = ManualClock()
+    let channelPromise = self.loop.makePromise(of: Channel.self)
+    let manager = self.makeConnectionManager { _, _ in
+      return channelPromise.futureResult
+    }
+
+    // Start the connection.
+    let readyChannelMux: EventLoopFuture<HTTP2StreamMultiplexer> = self
+      .waitForStateChange(from: .idle, to: .connecting) {
+        let readyChannelMux = manager.getHTTP2Multiplexer()
+        self.loop.run()
+        return readyChannelMux
+      }
+
+    // Setup the channel.
+    let channel = EmbeddedChannel(loop: self.loop)
+    let h2mux = HTTP2StreamMultiplexer(
+      mode: .client,
+      channel: channel,
+      inboundStreamInitializer: nil
+    )
+    try channel.pipeline.addHandler(
+      GRPCIdleHandler(
+        connectionManager: manager,
+        multiplexer: h2mux,
+        idleTimeout: .minutes(5),
+        keepalive: .init(),
+        clock: manualClock.clock,
+        logger: self.logger
+      )
+    ).wait()
+    channelPromise.succeed(channel)
+    XCTAssertNoThrow(
+      try channel.connect(to: SocketAddress(unixDomainSocketPath: "/ignored"))
+        .wait()
+    )
+
+    // Write a settings frame on the root stream; this'll make the channel 'ready'.
+    try self.waitForStateChange(from: .connecting, to: .ready) {
+      let frame = HTTP2Frame(streamID: .rootStream, payload: .settings(.settings([])))
+      XCTAssertNoThrow(try channel.writeInbound(frame))
+      // Wait for the multiplexer, it _must_ be ready now.
+      XCTAssertNoThrow(try readyChannelMux.wait())
+    }
+
+    // Advancing the event loop doesn't affect the idle timeout.
+    self.loop.advanceTime(by: .minutes(5))
+    XCTAssertTrue(channel.isActive)
+
+    // Go idle. This will shutdown the channel.
+    try self.waitForStateChange(from: .ready, to: .idle) {
+      manualClock.advance(by: .minutes(5))
+      XCTAssertNoThrow(try channel.closeFuture.wait())
+    }
+
+    // Now shutdown.
+    try self.waitForStateChange(from: .idle, to: .shutdown) {
+      let shutdown = manager.shutdown()
+      self.loop.run()
+      XCTAssertNoThrow(try shutdown.wait())
+    }
+  }
+
+  func testKeepaliveIsScheduledOnConnectionClock() throws {
+    let manualClock = ManualClock()
+    var roundTripTimes: [TimeAmount] = []
+    var configuration = self.defaultConfiguration
+    configuration.keepaliveRoundTripTimeObserver = { roundTripTimes.append($0) }
+
+    let channelPromise = self.loop.makePromise(of: Channel.self)
+    let manager = self.makeConnectionManager(configuration: configuration) { _, _ in
+      return channelPromise.futureResult
+    }
+
+    // Start the connection.
+    let readyChannelMux: EventLoopFuture<HTTP2StreamMultiplexer> = self
+      .waitForStateChange(from: .idle, to: .connecting) {
+        let readyChannelMux = manager.getHTTP2Multiplexer()
+        self.loop.run()
+        return readyChannelMux
+      }
+
+    // Setup the channel.
+    let channel = EmbeddedChannel(loop: self.loop)
+    let h2mux = HTTP2StreamMultiplexer(
+      mode: .client,
+      channel: channel,
+      inboundStreamInitializer: nil
+    )
+    try channel.pipeline.addHandler(
+      GRPCIdleHandler(
+        connectionManager: manager,
+        multiplexer: h2mux,
+        idleTimeout: .minutes(5),
+        keepalive: .init(interval: .seconds(10), timeout: .seconds(5)),
+        clock: manualClock.clock,
+        logger: self.logger
+      )
+    ).wait()
+    channelPromise.succeed(channel)
+    XCTAssertNoThrow(
+      try channel.connect(to: SocketAddress(unixDomainSocketPath: "/ignored"))
+        .wait()
+    )
+
+    // Write a settings frame on the root stream; this'll make the channel 'ready'.
+    try self.waitForStateChange(from: .connecting, to: .ready) {
+      let frame = HTTP2Frame(streamID: .rootStream, payload: .settings(.settings([])))
+      XCTAssertNoThrow(try channel.writeInbound(frame))
+      // Wait for the multiplexer, it _must_ be ready now.
+      XCTAssertNoThrow(try readyChannelMux.wait())
+    }
+
+    // "create" a stream; pings are only sent when there are active streams.
+    let streamCreated = NIOHTTP2StreamCreatedEvent(
+      streamID: 1,
+      localInitialWindowSize: nil,
+      remoteInitialWindowSize: nil
+    )
+    channel.pipeline.fireUserInboundEventTriggered(streamCreated)
+
+    // Advancing the event loop doesn't send a ping.
+    self.loop.advanceTime(by: .seconds(10))
+    XCTAssertNil(try channel.readOutbound(as: HTTP2Frame.self))
+
+    // Each interval on the clock sends a ping, acknowledge them.
+    for _ in 0 ..< 2 {
+      manualClock.advance(by: .seconds(10))
+      let ping = try channel.readOutbound(as: HTTP2Frame.self)
+      guard case let .some(.ping(data, ack: false)) = ping?.payload else {
+        return XCTFail("Expected a ping but got \(String(describing: ping))")
+      }
+
+      manualClock.advance(by: .milliseconds(25))
+      let pong = HTTP2Frame(streamID: .rootStream, payload: .ping(data, ack: true))
+      XCTAssertNoThrow(try channel.writeInbound(pong))
+    }
+
+    XCTAssertEqual(roundTripTimes, [.milliseconds(25), .milliseconds(25)])
+
+    // The timeout was cancelled by the acknowledgement.
+    manualClock.advance(by: .seconds(5))
+    XCTAssertTrue(channel.isActive)
+
+    // Don't acknowledge the next ping: the connection is closed once the timeout has passed.
+    manualClock.advance(by: .seconds(5))
+    XCTAssertNotNil(try channel.readOutbound(as: HTTP2Frame.self))
+    manualClock.advance(by: .seconds(5) - .nanoseconds(1))
+    XCTAssertTrue(channel.isActive)
+
+    try self.waitForStateChange(from: .ready, to: .shutdown) {
+      manualClock.advance(by: .nanoseconds(1))
+      XCTAssertNoThrow(try channel.closeFuture.wait())
+    }
+  }
+
   func testConnectAndThenBecomeInactive() throws {
     let channelPromise = self.loop.makePromise(of: Channel.self)
     let manager = self.makeConnectionManager { _, _ in
@@ -466,6 +624,95 @@ extension ConnectionManagerTests {
     XCTAssertNoThrow(try channel.closeFuture.wait())
   }
 
+  func testMaxWaitersForConnection() throws {
+    var waiterCounts: [Int] = []
+    var configuration = self.defaultConfiguration
+    configuration.maxWaitersForConnection = 2
+    configuration.waitersForConnectionObserver = { waiterCounts.append($0) }
+
+    let channelPromise = self.loop.makePromise(of: Channel.self)
+    let manager = self.makeConnectionManager(configuration: configuration) { _, _ in
+      return channelPromise.futureResult
+    }
+
+    let first: EventLoopFuture<HTTP2StreamMultiplexer> = self
+      .waitForStateChange(from: .idle, to: .connecting) {
+        let first = manager.getHTTP2Multiplexer()
+        self.loop.run()
+        return first
+      }
+    let second = manager.getHTTP2Multiplexer()
+
+    // The limit has been reached: the third waiter should fail immediately.
+    let third = manager.getHTTP2Multiplexer()
+    XCTAssertThrowsError(try third.wait()) { error in
+      let status = (error as? GRPCStatusTransformable)?.makeGRPCStatus()
+      XCTAssertEqual(status?.code, .unavailable)
+      XCTAssertEqual(status?.message, "too many queued requests")
+    }
+    XCTAssertEqual(waiterCounts, [1, 2])
+
+    // Shutting down fails the waiters and releases their slots.
+    try self.waitForStateChange(from: .connecting, to: .shutdown) {
+      let shutdown = manager.shutdown()
+      self.loop.run()
+      XCTAssertNoThrow(try shutdown.wait())
+    }
+
+    XCTAssertThrowsError(try first.wait())
+    XCTAssertThrowsError(try second.wait())
+    XCTAssertEqual(waiterCounts, [1, 2, 1, 0])
+
+    channelPromise.succeed(EmbeddedChannel(loop: self.loop))
+    self.loop.run()
+  }
+
+  func testAbandonedWaitersForConnectionAreNotCounted() throws {
+    var waiterCounts: [Int] = []
+    var configuration = self.defaultConfiguration
+    configuration.connectionBackoff = .oneSecondFixed
+    configuration.maxWaitersForConnection = 1
+    configuration.waitersForConnectionObserver = { waiterCounts.append($0) }
+
+    let manager = self.makeConnectionManager(configuration: configuration) { _, _ in
+      self.loop.makeFailedFuture(DoomedChannelError())
+    }
+
+    let first: EventLoopFuture<HTTP2StreamMultiplexer> = self.waitForStateChanges([
+      Change(from: .idle, to: .connecting),
+      Change(from: .connecting, to: .transientFailure),
+    ]) {
+      let first = manager.getHTTP2Multiplexer()
+      self.loop.run()
+      return first
+    }
+
+    // The RPC waiting for the connection is cancelled (or times out) while backing off.
+    manager.stopWaitingForConnection(first, error: GRPCError.RPCCancelledByClient())
+    XCTAssertThrowsError(try first.wait()) { error in
+      XCTAssert(error is GRPCError.RPCCancelledByClient)
+    }
+    XCTAssertEqual(waiterCounts, [1, 0])
+
+    // Its slot is free for the next RPC.
+    let second = manager.getHTTP2Multiplexer()
+    XCTAssertEqual(waiterCounts, [1, 0, 1])
+
+    // Stopping again has no effect.
+    manager.stopWaitingForConnection(first, error: GRPCError.RPCCancelledByClient())
+    XCTAssertEqual(waiterCounts, [1, 0, 1])
+
+    try self.waitForStateChange(from: .transientFailure, to: .shutdown) {
+      let shutdown = manager.shutdown()
+      self.loop.run()
+      XCTAssertNoThrow(try shutdown.wait())
+    }
+    XCTAssertThrowsError(try second.wait()) { error in
+      XCTAssertFalse(error is GRPCError.RPCCancelledByClient)
+    }
+    XCTAssertEqual(waiterCounts, [1, 0, 1, 0])
+  }
+
   func testShutdownWhileTransientFailure() throws {
     var configuration = self.defaultConfiguration
     configuration.connectionBackoff = .oneSecondFixed
@@ -632,6 +879,116 @@ extension ConnectionManagerTests {
     XCTAssertThrowsError(try readyChannelMux.wait())
   }
 
+  func testConnectTimeoutWhileActive() throws {
+    var configuration = self.defaultConfiguration
+    configuration.connectTimeout = .seconds(5)
+
+    let channelPromise: EventLoopPromise<Channel> = self.loop.makePromise()
+    let manager = self.makeConnectionManager(configuration: configuration) { _, _ in
+      return channelPromise.futureResult
+    }
+
+    let readyChannelMux: EventLoopFuture<HTTP2StreamMultiplexer> = self
+      .waitForStateChange(from: .idle, to: .connecting) {
+        let readyChannelMux = manager.getHTTP2Multiplexer()
+        self.loop.run()
+        return readyChannelMux
+      }
+
+    // Prepare the channel
+    let channel = EmbeddedChannel(loop: self.loop)
+    let h2mux = HTTP2StreamMultiplexer(
+      mode: .client,
+      channel: channel,
+      inboundStreamInitializer: nil
+    )
+    try channel.pipeline.addHandler(
+      GRPCIdleHandler(
+        connectionManager: manager,
+        multiplexer: h2mux,
+        idleTimeout: .minutes(5),
+        keepalive: .init(),
+        logger: self.logger
+      )
+    ).wait()
+
+    channelPromise.succeed(channel)
+    XCTAssertNoThrow(
+      try channel.connect(to: SocketAddress(unixDomainSocketPath: "/ignored")).wait()
+    )
+
+    // The server never sends its SETTINGS frame; nothing should happen before the timeout.
+    self.loop.advanceTime(by: .seconds(4))
+    XCTAssertTrue(channel.isActive)
+
+    // Reconnect is disabled so we should shutdown.
+    try self.waitForStateChange(from: .connecting, to: .shutdown) {
+      self.loop.advanceTime(by: .seconds(1))
+    }
+
+    XCTAssertFalse(channel.isActive)
+    XCTAssertThrowsError(try readyChannelMux.wait()) { error in
+      let status = error as? GRPCStatus
+      XCTAssertEqual(status?.code, .unavailable)
+      XCTAssertTrue(status?.message?.contains("TLS handshake") ?? false)
+    }
+  }
+
+  func testConnectTimeoutIsCancelledWhenReady() throws {
+    var configuration = self.defaultConfiguration
+    configuration.connectTimeout = .seconds(5)
+
+    let channelPromise: EventLoopPromise<Channel> = self.loop.makePromise()
+    let manager = self.makeConnectionManager(configuration: configuration) { _, _ in
+      return channelPromise.futureResult
+    }
+
+    let readyChannelMux: EventLoopFuture<HTTP2StreamMultiplexer> = self
+      .waitForStateChange(from: .idle, to: .connecting) {
+        let readyChannelMux = manager.getHTTP2Multiplexer()
+        self.loop.run()
+        return readyChannelMux
+      }
+
+    let channel = EmbeddedChannel(loop: self.loop)
+    let h2mux = HTTP2StreamMultiplexer(
+      mode: .client,
+      channel: channel,
+      inboundStreamInitializer: nil
+    )
+    try channel.pipeline.addHandler(
+      GRPCIdleHandler(
+        connectionManager: manager,
+        multiplexer: h2mux,
+        idleTimeout: .minutes(5),
+        keepalive: .init(),
+        logger: self.logger
+      )
+    ).wait()
+
+    channelPromise.succeed(channel)
+    XCTAssertNoThrow(
+      try channel.connect(to: SocketAddress(unixDomainSocketPath: "/ignored")).wait()
+    )
+
+    // Write a settings frame on the root stream; this'll make the channel 'ready'.
+    try self.waitForStateChange(from: .connecting, to: .ready) {
+      let frame = HTTP2Frame(streamID: .rootStream, payload: .settings(.settings([])))
+      XCTAssertNoThrow(try channel.writeInbound(frame))
+    }
+    XCTAssertNoThrow(try readyChannelMux.wait())
+
+    // The connect timeout must not close a ready connection.
+    self.loop.advanceTime(by: .seconds(5))
+    XCTAssertTrue(channel.isActive)
+
+    try self.waitForStateChange(from: .ready, to: .shutdown) {
+      let shutdown = manager.shutdown()
+      self.loop.run()
+      XCTAssertNoThrow(try shutdown.wait())
+    }
+  }
+
   func testTransientFailureWhileReady() throws {
     var configuration = self.defaultConfiguration
     configuration.connectionBackoff = .oneSecondFixed
@@ -1001,6 +1358,152 @@ extension ConnectionManagerTests {
     }
   }
 
+  /// Makes a channel managed by `manager` and readies it by writing a SETTINGS frame.
+  private func makeReadyChannel(
+    managedBy manager: ConnectionManager,
+    channelPromise: EventLoopPromise<Channel>
+  ) throws -> EmbeddedChannel {
+    let channel = EmbeddedChannel(loop: self.loop)
+    let h2mux = HTTP2StreamMultiplexer(
+      mode: .client,
+      channel: channel,
+      inboundStreamInitializer: nil
+    )
+    try channel.pipeline.addHandler(
+      GRPCIdleHandler(
+        connectionManager: manager,
+        multiplexer: h2mux,
+        idleTimeout: .minutes(5),
+        keepalive: .init(),
+        logger: self.logger
+      )
+    ).wait()
+    channelPromise.succeed(channel)
+    self.loop.run()
+
+    try channel.connect(to: SocketAddress(unixDomainSocketPath: "/ignored")).wait()
+
+    try self.waitForStateChange(from: .connecting, to: .ready) {
+      let frame = HTTP2Frame(streamID: .rootStream, payload: .settings(.settings([])))
+      XCTAssertNoThrow(try channel.writeInbound(frame))
+    }
+
+    return channel
+  }
+
+  func testDrainReadyConnection() throws {
+    let channelPromises = [self.loop.makePromise(of: Channel.self), self.loop.makePromise()]
+    var connectionAttempts = 0
+    let manager = self.makeConnectionManager { _, _ in
+      defer {
+        connectionAttempts += 1
+      }
+      return channelPromises[connectionAttempts].futureResult
+    }
+
+    let readyChannelMux = self.waitForStateChange(from: .idle, to: .connecting) {
+      () -> EventLoopFuture<HTTP2StreamMultiplexer> in
+      let readyChannelMux = manager.getHTTP2Multiplexer()
+      self.loop.run()
+      return readyChannelMux
+    }
+    let drained = try self.makeReadyChannel(managedBy: manager, channelPromise: channelPromises[0])
+    XCTAssertNoThrow(try readyChannelMux.wait())
+
+    // Open a stream on the connection.
+    let streamCreated = NIOHTTP2StreamCreatedEvent(
+      streamID: 1,
+      localInitialWindowSize: nil,
+      remoteInitialWindowSize: nil
+    )
+    drained.pipeline.fireUserInboundEventTriggered(streamCreated)
+
+    // Draining goes idle straight away, sends a GOAWAY and leaves the stream open.
+    let drain = self.waitForStateChange(from: .ready, to: .idle) { () -> EventLoopFuture<Void> in
+      let drain = manager.drain(grace: nil)
+      self.loop.run()
+      return drain
+    }
+
+    let goAway = try drained.readOutbound(as: HTTP2Frame.self)
+    switch goAway?.payload {
+    case .goAway:
+      ()
+    default:
+      XCTFail("Expected GOAWAY frame but got \(String(describing: goAway))")
+    }
+    XCTAssertTrue(drained.isActive)
+
+    // The next RPC gets a new connection.
+    let nextChannelMux = self.waitForStateChange(from: .idle, to: .connecting) {
+      () -> EventLoopFuture<HTTP2StreamMultiplexer> in
+      let nextChannelMux = manager.getHTTP2Multiplexer()
+      self.loop.run()
+      return nextChannelMux
+    }
+    let next = try self.makeReadyChannel(managedBy: manager, channelPromise: channelPromises[1])
+    XCTAssertNoThrow(try nextChannelMux.wait())
+    XCTAssertEqual(connectionAttempts, 2)
+
+    // Closing the stream closes the drained connection without affecting the new one.
+    drained.pipeline.fireUserInboundEventTriggered(StreamClosedEvent(streamID: 1, reason: nil))
+    self.loop.run()
+    XCTAssertNoThrow(try drain.wait())
+    XCTAssertFalse(drained.isActive)
+    XCTAssertTrue(next.isActive)
+    XCTAssertNotNil(manager.sync.multiplexer)
+
+    try self.waitForStateChange(from: .ready, to: .shutdown) {
+      let shutdown = manager.shutdown()
+      self.loop.run()
+      XCTAssertNoThrow(try shutdown.wait())
+    }
+  }
+
+  func testDrainClosesConnectionAfterGrace() throws {
+    let channelPromise = self.loop.makePromise(of: Channel.self)
+    let manager = self.makeConnectionManager { _, _ in
+      return channelPromise.futureResult
+    }
+
+    let readyChannelMux = self.waitForStateChange(from: .idle, to: .connecting) {
+      () -> EventLoopFuture<HTTP2StreamMultiplexer> in
+      let readyChannelMux = manager.getHTTP2Multiplexer()
+      self.loop.run()
+      return readyChannelMux
+    }
+    let channel = try self.makeReadyChannel(managedBy: manager, channelPromise: channelPromise)
+    XCTAssertNoThrow(try readyChannelMux.wait())
+
+    let streamCreated = NIOHTTP2StreamCreatedEvent(
+      streamID: 1,
+      localInitialWindowSize: nil,
+      remoteInitialWindowSize: nil
+    )
+    channel.pipeline.fireUserInboundEventTriggered(streamCreated)
+
+    let drain = self.waitForStateChange(from: .ready, to: .idle) { () -> EventLoopFuture<Void> in
+      let drain = manager.drain(grace: .seconds(10))
+      self.loop.run()
+      return drain
+    }
+
+    // The stream is still open, the connection is closed once the grace period has elapsed.
+    self.loop.advanceTime(by: .seconds(9))
+    XCTAssertTrue(channel.isActive)
+    self.loop.advanceTime(by: .seconds(1))
+    XCTAssertFalse(channel.isActive)
+    XCTAssertNoThrow(try drain.wait())
+  }
+
+  func testDrainWhenIdleDoesNothing() throws {
+    let manager = self.makeConnectionManager()
+    let drain = manager.drain(grace: nil)
+    self.loop.run()
+    XCTAssertNoThrow(try drain.wait())
+    XCTAssertTrue(manager.sync.isIdle)
+  }
+
   func testIdleErrorDoesNothing() throws {
     let manager = self.makeConnectionManager()
 
@@ -0,0 +1,213 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class ConnectionStartBehaviorTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+  private var server: Server!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+    self.server = try! Server.insecure(group: self.group)
+      .withServiceProviders([EchoProvider()])
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.server.close().wait())
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func makeConnection(
+    port: Int,
+    startBehavior: ConnectionStartBehavior,
+    delegate: ConnectivityStateDelegate? = nil
+  ) -> ClientConnection {
+    return ClientConnection.insecure(group: self.group)
+      .withConnectionStartBehavior(startBehavior)
+      .withCallStartBehavior(.fastFailure)
+      .withConnectivityStateDelegate(delegate)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: port)
+  }
+
+  func testLazyConnectionDoesNotConnectOnCreation() throws {
+    let connection = self.makeConnection(
+      port: self.server.channel.localAddress!.port!,
+      startBehavior: .lazy
+    )
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    XCTAssertEqual(connection.connectivity.state, .idle)
+    XCTAssertNoThrow(try connection.waitForReady().wait())
+    XCTAssertEqual(connection.connectivity.state, .ready)
+  }
+
+  func testEagerConnectionConnectsOnCreation() throws {
+    let delegate = RecordingConnectivityDelegate()
+    delegate.expectChanges(2) { changes in
+      XCTAssertEqual(changes, [
+        Change(from: .idle, to: .connecting),
+        Change(from: .connecting, to: .ready),
+      ])
+    }
+
+    let connection = self.makeConnection(
+      port: self.server.channel.localAddress!.port!,
+      startBehavior: .eager,
+      delegate: delegate
+    )
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    // No RPC or explicit request to connect is made.
+    delegate.waitForExpectedChanges(timeout: .seconds(5))
+    XCTAssertNoThrow(try connection.waitForReady().wait())
+  }
+
+  func testEagerConnectionToUnreachableTargetFails() throws {
+    // Nothing is listening on the port once this server has closed.
+    let closedServer = try Server.insecure(group: self.group)
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+    let port = closedServer.channel.localAddress!.port!
+    XCTAssertNoThrow(try closedServer.close().wait())
+
+    let connection = self.makeConnection(port: port, startBehavior: .eager)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    XCTAssertThrowsError(try connection.waitForReady().wait())
+  }
+}
+
+extension ConnectionStartBehaviorTests {
+  func testWarmUpWithPrimingRPC() throws {
+    let connection = self.makeConnection(
+      port: self.server.channel.localAddress!.port!,
+      startBehavior: .lazy
+    )
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let warm = connection.warmUp(primingPath: "/echo.Echo/Get")
+    XCTAssertTrue(try warm.wait())
+    XCTAssertEqual(connection.connectivity.state, .ready)
+  }
+
+  func testWarmUpWithUnimplementedPrimingRPC() throws {
+    let connection = self.makeConnection(
+      port: self.server.channel.localAddress!.port!,
+      startBehavior: .lazy
+    )
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    // The server handled the RPC, so the connection is primed.
+    let warm = connection.warmUp(primingPath: "/echo.Echo/Unknown")
+    XCTAssertTrue(try warm.wait())
+  }
+
+  func testWarmUpWithFailingPrimingRPC() throws {
+    let failingServer = try Server.insecure(group: self.group)
+      .withServiceProviders([FailingEchoProvider()])
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try failingServer.close().wait())
+    }
+
+    let connection = self.makeConnection(
+      port: failingServer.channel.localAddress!.port!,
+      startBehavior: .lazy
+    )
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let warm = connection.warmUp(primingPath: "/echo.Echo/Get")
+    XCTAssertFalse(try warm.wait())
+  }
+
+  func testWarmUpClient() throws {
+    let connection = self.makeConnection(
+      port: self.server.channel.localAddress!.port!,
+      startBehavior: .lazy
+    )
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let client = Echo_EchoClient(channel: connection)
+    let warm = try XCTUnwrap(client.warmUp(primingPath: "/echo.Echo/Get"))
+    XCTAssertTrue(try warm.wait())
+    XCTAssertEqual(connection.connectivity.state, .ready)
+  }
+
+  func testWarmUpClientWithRoutingChannel() throws {
+    let port = self.server.channel.localAddress!.port!
+    let first = self.makeConnection(port: port, startBehavior: .lazy)
+    let second = self.makeConnection(port: port, startBehavior: .lazy)
+    let channel = try RoutingGRPCChannel(
+      channels: ["first": first, "second": second],
+      defaultChannel: "first"
+    ) { _, _ in nil }
+    defer {
+      XCTAssertNoThrow(try channel.close().wait())
+    }
+
+    let client = Echo_EchoClient(channel: channel)
+    let warm = try XCTUnwrap(client.warmUp())
+    XCTAssertTrue(try warm.wait())
+    XCTAssertEqual(first.connectivity.state, .ready)
+    XCTAssertEqual(second.connectivity.state, .ready)
+  }
+
+  func testWarmUpDoesNotFailWhenTargetIsUnreachable() throws {
+    let closedServer = try Server.insecure(group: self.group)
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+    let port = closedServer.channel.localAddress!.port!
+    XCTAssertNoThrow(try closedServer.close().wait())
+
+    let connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: port)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let warm = connection.warmUp(timeLimit: .timeout(.milliseconds(100)))
+    XCTAssertFalse(try warm.wait())
+  }
+}
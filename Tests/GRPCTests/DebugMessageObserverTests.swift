@@ -0,0 +1,103 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import Foundation
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import SwiftProtobuf
+import XCTest
+
+final class DebugMessageObserverTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private final class Recorder {
+    private let lock = Lock()
+    private var _messages: [ObservedMessage] = []
+
+    var messages: [ObservedMessage] {
+      return self.lock.withLock { self._messages }
+    }
+
+    func record(_ message: ObservedMessage) {
+      self.lock.withLockVoid { self._messages.append(message) }
+    }
+  }
+
+  private func decode<Message: SwiftProtobuf.Message>(
+    _ observed: ObservedMessage,
+    as type: Message.Type = Message.self
+  ) throws -> Message {
+    return try Message(serializedData: Data(observed.bytes.readableBytesView))
+  }
+
+  func testMessagesAreObservedOnClientAndServer() throws {
+    let serverRecorder = Recorder()
+    let server = try Server.insecure(group: self.group)
+      .withLogger(self.serverLogger)
+      .withServiceProviders([EchoProvider()])
+      .withDebugMessageObserver(serverRecorder.record(_:))
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    let clientRecorder = Recorder()
+    let connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .withDebugMessageObserver(clientRecorder.record(_:))
+      .connect(host: "localhost", port: server.channel.localAddress!.port!)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let echo = Echo_EchoClient(channel: connection)
+    let get = echo.get(.with { $0.text = "hello" })
+    XCTAssertEqual(try get.status.wait().code, .ok)
+
+    let client = clientRecorder.messages
+    XCTAssertEqual(client.count, 2)
+    XCTAssertEqual(client.map { $0.path }, ["/echo.Echo/Get", "/echo.Echo/Get"])
+    XCTAssertEqual(client.map { $0.direction }, [.outbound, .inbound])
+    XCTAssertEqual(try self.decode(client[0], as: Echo_EchoRequest.self).text, "hello")
+    XCTAssertEqual(
+      try self.decode(client[1], as: Echo_EchoResponse.self).text,
+      "Swift echo get: hello"
+    )
+
+    let serverMessages = serverRecorder.messages
+    XCTAssertEqual(serverMessages.count, 2)
+    XCTAssertEqual(serverMessages.map { $0.path }, ["/echo.Echo/Get", "/echo.Echo/Get"])
+    XCTAssertEqual(serverMessages.map { $0.direction }, [.inbound, .outbound])
+    XCTAssertEqual(try self.decode(serverMessages[0], as: Echo_EchoRequest.self).text, "hello")
+    XCTAssertEqual(
+      try self.decode(serverMessages[1], as: Echo_EchoResponse.self).text,
+      "Swift echo get: hello"
+    )
+  }
+}
@@ -0,0 +1,64 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import NIOHPACK
+import XCTest
+
+class DuplicateMetadataPolicyTests: GRPCTestCase {
+  private let headers: HPACKHeaders = [
+    ":path": "/echo.Echo/Get",
+    "x-trace": "a",
+    "content-type": "application/grpc",
+    "X-Trace": "b",
+    "x-other": "c",
+    "x-trace": "d",
+  ]
+
+  private func apply(_ policy: DuplicateMetadataPolicy) -> HPACKHeaders {
+    var headers = self.headers
+    policy.apply(to: &headers)
+    return headers
+  }
+
+  func testKeepAll() {
+    let headers = self.apply(.keepAll)
+    XCTAssertEqual(headers, self.headers)
+    XCTAssertEqual(headers.first(name: "x-trace"), "a")
+    XCTAssertEqual(headers["x-trace"], ["a", "b", "d"])
+  }
+
+  func testJoinWithCommas() {
+    let headers = self.apply(.joinWithCommas)
+    XCTAssertEqual(headers.map { $0.name }, [":path", "x-trace", "content-type", "x-other"])
+    XCTAssertEqual(headers["x-trace"], ["a, b, d"])
+    XCTAssertEqual(headers[canonicalForm: "x-trace"], ["a", "b", "d"])
+    XCTAssertEqual(headers.first(name: "x-other"), "c")
+  }
+
+  func testKeepFirst() {
+    let headers = self.apply(.keepFirst)
+    XCTAssertEqual(headers.map { $0.name }, [":path", "x-trace", "content-type", "x-other"])
+    XCTAssertEqual(headers["x-trace"], ["a"])
+  }
+
+  func testHeadersWithoutDuplicatesAreUnchanged() {
+    var headers: HPACKHeaders = ["x-a": "1, 2", "x-b": "3"]
+    DuplicateMetadataPolicy.keepFirst.apply(to: &headers)
+    XCTAssertEqual(headers, ["x-a": "1, 2", "x-b": "3"])
+    DuplicateMetadataPolicy.joinWithCommas.apply(to: &headers)
+    XCTAssertEqual(headers, ["x-a": "1, 2", "x-b": "3"])
+  }
+}
@@ -0,0 +1,109 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+/// A fake provider which overrides 'get' to respond with the request text reversed.
+private class ReversingFakeProvider: Echo_EchoFakeProvider {
+  override func get(
+    request: Echo_EchoRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Echo_EchoResponse> {
+    return context.eventLoop.makeSucceededFuture(.with {
+      $0.text = String(request.text.reversed())
+    })
+  }
+}
+
+class EchoFakeProviderTests: EchoTestCaseBase {
+  private let provider = ReversingFakeProvider()
+
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return self.provider
+  }
+
+  func testOverriddenMethod() throws {
+    let get = self.client.get(.with { $0.text = "foo" })
+    XCTAssertEqual(try get.response.wait().text, "oof")
+  }
+
+  func testUnstubbedMethodIsUnimplemented() throws {
+    let expand = self.client.expand(.with { $0.text = "foo" }) { _ in
+      XCTFail("Unexpected response")
+    }
+    XCTAssertEqual(try expand.status.wait().code, .unimplemented)
+    XCTAssertEqual(self.provider.expandHandler.callCount, 1)
+  }
+
+  func testServerStreamingStub() throws {
+    self.provider.expandHandler.stub = .respond(
+      with: [.with { $0.text = "a" }, .with { $0.text = "b" }],
+      status: GRPCStatus(code: .dataLoss, message: "oops")
+    )
+
+    var responses: [String] = []
+    let expand = self.client.expand(.with { $0.text = "foo" }) { response in
+      responses.append(response.text)
+    }
+
+    let status = try expand.status.wait()
+    XCTAssertEqual(status.code, .dataLoss)
+    XCTAssertEqual(status.message, "oops")
+    XCTAssertEqual(responses, ["a", "b"])
+    XCTAssertEqual(self.provider.expandHandler.receivedRequests.map { $0.text }, ["foo"])
+  }
+
+  func testClientStreamingStubRecordsRequests() throws {
+    self.provider.collectHandler.stub = .respond(with: .with { $0.text = "done" })
+
+    let collect = self.client.collect()
+    collect.sendMessages(["a", "b", "c"].map { text in .with { $0.text = text } }, promise: nil)
+    collect.sendEnd(promise: nil)
+
+    XCTAssertEqual(try collect.response.wait().text, "done")
+    XCTAssertEqual(self.provider.collectHandler.receivedRequests.map { $0.text }, ["a", "b", "c"])
+  }
+
+  func testBidirectionalStreamingStubFails() throws {
+    self.provider.updateHandler.stub = .fail(with: GRPCStatus(code: .unavailable, message: nil))
+
+    let update = self.client.update { _ in
+      XCTFail("Unexpected response")
+    }
+    update.sendMessage(.with { $0.text = "a" }, promise: nil)
+    update.sendEnd(promise: nil)
+
+    XCTAssertEqual(try update.status.wait().code, .unavailable)
+    XCTAssertEqual(self.provider.updateHandler.receivedRequests.map { $0.text }, ["a"])
+  }
+
+  func testStubDelay() throws {
+    self.provider.collectHandler.stub = .respond(
+      with: .with { $0.text = "late" },
+      delay: .milliseconds(50)
+    )
+
+    let options = CallOptions(timeLimit: .timeout(.milliseconds(10)))
+    let collect = self.client.collect(callOptions: options)
+    collect.sendEnd(promise: nil)
+    XCTAssertEqual(try collect.status.wait().code, .deadlineExceeded)
+
+    self.provider.collectHandler.reset()
+    XCTAssertEqual(self.provider.collectHandler.callCount, 0)
+  }
+}
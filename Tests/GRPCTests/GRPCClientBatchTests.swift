@@ -0,0 +1,174 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class GRPCClientBatchTests: EchoTestCaseBase {
+  private var eventLoop: EventLoop!
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = self.clientEventLoopGroup.next()
+  }
+
+  private func makeRequests(_ count: Int) -> [Echo_EchoRequest] {
+    return (0 ..< count).map { Echo_EchoRequest(text: "\($0)") }
+  }
+
+  /// Makes a 'Get' call, the call fails if the request text is "fail".
+  private func get(
+    _ request: Echo_EchoRequest,
+    options: CallOptions
+  ) -> UnaryCall<Echo_EchoRequest, Echo_EchoResponse> {
+    var options = options
+    if request.text == "fail" {
+      // A deadline in the past: the call fails immediately.
+      options.timeLimit = .deadline(.uptimeNanoseconds(0))
+    }
+    return self.client.get(request, callOptions: options)
+  }
+
+  func testResultsAreInRequestOrder() throws {
+    let requests = self.makeRequests(10)
+    let results = try self.client.batch(requests, maxConcurrent: 3, on: self.eventLoop) {
+      self.get($0, options: $1)
+    }.wait()
+
+    let texts = try results.map { try $0.get().text }
+    XCTAssertEqual(texts, requests.map { "Swift echo get: \($0.text)" })
+  }
+
+  func testConcurrencyIsBounded() throws {
+    var inFlight = 0
+    var maxInFlight = 0
+
+    let results = try self.client.batch(
+      self.makeRequests(20),
+      maxConcurrent: 4,
+      on: self.eventLoop
+    ) { request, options in
+      // All calls are made and completed on the batch's event loop.
+      inFlight += 1
+      maxInFlight = max(maxInFlight, inFlight)
+      let call = self.get(request, options: options)
+      call.response.whenComplete { _ in
+        inFlight -= 1
+      }
+      return call
+    }.wait()
+
+    XCTAssertEqual(results.count, 20)
+    XCTAssertEqual(maxInFlight, 4)
+  }
+
+  func testEmptyBatch() throws {
+    let results = try self.client.batch([], maxConcurrent: 1, on: self.eventLoop) {
+      self.get($0, options: $1)
+    }.wait()
+    XCTAssertTrue(results.isEmpty)
+  }
+
+  func testFailFast() throws {
+    var callsMade = 0
+    var requests = self.makeRequests(10)
+    requests[0].text = "fail"
+
+    let batch = self.client.batch(requests, maxConcurrent: 1, on: self.eventLoop) {
+      callsMade += 1
+      return self.get($0, options: $1)
+    }
+
+    XCTAssertThrowsError(try batch.wait()) { error in
+      XCTAssert(error is GRPCError.RPCTimedOut)
+    }
+    // The failed call was the only one in progress, no more calls were made.
+    XCTAssertEqual(try self.eventLoop.submit { callsMade }.wait(), 1)
+  }
+
+  func testFailFastWhenCallFailsSynchronously() throws {
+    // Make a call which has already failed by the time the batch makes it: its completion
+    // callback runs as soon as it's registered.
+    var options = self.client.defaultCallOptions
+    options.eventLoopPreference = .exact(self.eventLoop)
+    let failedCall = self.get(Echo_EchoRequest(text: "fail"), options: options)
+    XCTAssertThrowsError(try failedCall.response.wait())
+
+    var callsMade = 0
+    var requests = self.makeRequests(10)
+    requests[0].text = "fail"
+
+    let batch = self.client.batch(requests, maxConcurrent: 3, on: self.eventLoop) {
+      callsMade += 1
+      return $0.text == "fail" ? failedCall : self.get($0, options: $1)
+    }
+
+    XCTAssertThrowsError(try batch.wait()) { error in
+      XCTAssert(error is GRPCError.RPCTimedOut)
+    }
+    // The batch finished when the first call failed, no more calls were started.
+    XCTAssertEqual(try self.eventLoop.submit { callsMade }.wait(), 1)
+  }
+
+  func testManyCallsCompletingSynchronously() throws {
+    // Every call has already failed by the time the batch makes it: the batch mustn't recurse
+    // to make the next call when one completes.
+    var options = self.client.defaultCallOptions
+    options.eventLoopPreference = .exact(self.eventLoop)
+    let failedCall = self.get(Echo_EchoRequest(text: "fail"), options: options)
+    XCTAssertThrowsError(try failedCall.response.wait())
+
+    let results = try self.client.batch(
+      self.makeRequests(50000),
+      maxConcurrent: 1,
+      failureMode: .collectAll,
+      on: self.eventLoop
+    ) { _, _ in
+      failedCall
+    }.wait()
+
+    XCTAssertEqual(results.count, 50000)
+    XCTAssertTrue(results.allSatisfy { (try? $0.get()) == nil })
+  }
+
+  func testCollectAll() throws {
+    var requests = self.makeRequests(5)
+    requests[1].text = "fail"
+    requests[3].text = "fail"
+
+    let results = try self.client.batch(
+      requests,
+      maxConcurrent: 2,
+      failureMode: .collectAll,
+      on: self.eventLoop
+    ) {
+      self.get($0, options: $1)
+    }.wait()
+
+    XCTAssertEqual(results.count, 5)
+    for (index, result) in results.enumerated() {
+      switch (index, result) {
+      case (1, .failure), (3, .failure):
+        ()
+      case let (_, .success(response)):
+        XCTAssertEqual(response.text, "Swift echo get: \(index)")
+      default:
+        XCTFail("Unexpected result \(result) for request \(index)")
+      }
+    }
+  }
+}
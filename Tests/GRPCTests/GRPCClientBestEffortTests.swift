@@ -0,0 +1,50 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class GRPCClientBestEffortTests: EchoTestCaseBase {
+  func testSuccessfulCallDoesNotReportFailure() throws {
+    let failed = self.expectation(description: "call failed")
+    failed.isInverted = true
+
+    self.client.sendBestEffort(
+      path: "/echo.Echo/Get",
+      request: Echo_EchoRequest(text: "ping"),
+      responseType: Echo_EchoResponse.self
+    ) { _ in
+      failed.fulfill()
+    }
+
+    self.wait(for: [failed], timeout: 1.0)
+  }
+
+  func testFailedCallIsReported() throws {
+    let failed = self.expectation(description: "call failed")
+    self.client.sendBestEffort(
+      path: "/echo.Echo/NotAMethod",
+      request: Echo_EchoRequest(text: "ping"),
+      responseType: Echo_EchoResponse.self
+    ) { status in
+      XCTAssertEqual(status.code, .unimplemented)
+      failed.fulfill()
+    }
+
+    self.wait(for: [failed], timeout: 5.0)
+  }
+}
@@ -32,6 +32,49 @@ class GRPCClientChannelHandlerTests: GRPCTestCase {
     )
   }
 
+  func testRequestHeadersExceedingPeerLimitAreNotSent() throws {
+    let handler = GRPCClientChannelHandler(
+      callType: .unary,
+      maximumReceiveMessageLength: .max,
+      maximumHeaderListSize: 1024,
+      logger: GRPCLogger(wrapping: self.clientLogger)
+    )
+    let channel = EmbeddedChannel(handler: handler)
+
+    var head = self.makeRequestHead()
+    head.customMetadata.add(name: "authorization", value: String(repeating: "a", count: 1024))
+    head.customMetadata.add(name: "x-small", value: "b")
+
+    XCTAssertThrowsError(try channel.writeOutbound(_RawGRPCClientRequestPart.head(head))) {
+      let status = $0 as? GRPCStatus
+      XCTAssertEqual(status?.code, .invalidArgument)
+      XCTAssertEqual(status?.message?.hasPrefix("metadata too large"), true)
+      XCTAssertEqual(status?.message?.contains("authorization"), true)
+      XCTAssertEqual(status?.message?.contains("x-small"), false)
+    }
+    XCTAssertNil(try channel.readOutbound(as: HTTP2Frame.FramePayload.self))
+  }
+
+  func testRequestHeadersWithinPeerLimitAreSent() throws {
+    let handler = GRPCClientChannelHandler(
+      callType: .unary,
+      maximumReceiveMessageLength: .max,
+      maximumHeaderListSize: 1024,
+      logger: GRPCLogger(wrapping: self.clientLogger)
+    )
+    let channel = EmbeddedChannel(handler: handler)
+
+    var head = self.makeRequestHead()
+    head.customMetadata.add(name: "authorization", value: "a")
+    XCTAssertNoThrow(try channel.writeOutbound(_RawGRPCClientRequestPart.head(head)))
+    XCTAssertNotNil(try channel.readOutbound(as: HTTP2Frame.FramePayload.self))
+  }
+
+  func testHeaderListSize() {
+    let headers: HPACKHeaders = ["foo": "bar", "baz": ""]
+    XCTAssertEqual(headers.headerListSize, (3 + 3 + 32) + (3 + 0 + 32))
+  }
+
   func doTestDataFrameWithEndStream(dataContainsMessage: Bool) throws {
     let handler = GRPCClientChannelHandler(
       callType: .unary,
@@ -92,4 +135,87 @@ class GRPCClientChannelHandlerTests: GRPCTestCase {
   func testEmptyDataFrameWithEndStream() throws {
     try self.doTestDataFrameWithEndStream(dataContainsMessage: false)
   }
+
+  private func makeChannelAwaitingResponse() throws -> EmbeddedChannel {
+    let handler = GRPCClientChannelHandler(
+      callType: .unary,
+      maximumReceiveMessageLength: .max,
+      logger: GRPCLogger(wrapping: self.clientLogger)
+    )
+
+    let channel = EmbeddedChannel(handler: handler)
+    try channel.writeOutbound(_RawGRPCClientRequestPart.head(self.makeRequestHead()))
+    XCTAssertNotNil(try channel.readOutbound(as: HTTP2Frame.FramePayload.self))
+    return channel
+  }
+
+  private func assertInvalidContentType(
+    _ channel: EmbeddedChannel,
+    contentType: String?,
+    bodySnippet: String?,
+    file: StaticString = #file,
+    line: UInt = #line
+  ) {
+    XCTAssertThrowsError(try channel.throwIfErrorCaught(), file: file, line: line) { error in
+      let withContext = error as? GRPCError.WithContext
+      let invalidContentType = withContext?.error as? GRPCError.InvalidContentType
+      XCTAssertEqual(invalidContentType?.contentType, contentType, file: file, line: line)
+      XCTAssertEqual(invalidContentType?.bodySnippet, bodySnippet, file: file, line: line)
+      let status = invalidContentType?.makeGRPCStatus()
+      XCTAssertEqual(status?.code, .internalError, file: file, line: line)
+    }
+  }
+
+  func testNonGRPCResponseIncludesBodySnippet() throws {
+    let channel = try self.makeChannelAwaitingResponse()
+
+    // Deliver the headers and body in the same read.
+    let headers: HPACKHeaders = [":status": "200", "content-type": "text/html"]
+    let headersPayload = HTTP2Frame.FramePayload.headers(.init(headers: headers))
+    channel.pipeline.fireChannelRead(NIOAny(headersPayload))
+    // No error until we've had the chance to read the body.
+    XCTAssertNoThrow(try channel.throwIfErrorCaught())
+
+    let body = ByteBuffer(string: "<html>Please log in</html>")
+    let data = HTTP2Frame.FramePayload.Data(data: .byteBuffer(body), endStream: false)
+    channel.pipeline.fireChannelRead(NIOAny(HTTP2Frame.FramePayload.data(data)))
+    channel.pipeline.fireChannelReadComplete()
+
+    self.assertInvalidContentType(
+      channel,
+      contentType: "text/html",
+      bodySnippet: "<html>Please log in</html>"
+    )
+  }
+
+  func testNonGRPCResponseBodySnippetIsTruncated() throws {
+    let channel = try self.makeChannelAwaitingResponse()
+
+    let headers: HPACKHeaders = [":status": "200", "content-type": "text/plain"]
+    let headersPayload = HTTP2Frame.FramePayload.headers(.init(headers: headers))
+    channel.pipeline.fireChannelRead(NIOAny(headersPayload))
+
+    let body = ByteBuffer(string: String(repeating: "a", count: 1024))
+    let data = HTTP2Frame.FramePayload.Data(data: .byteBuffer(body), endStream: false)
+    channel.pipeline.fireChannelRead(NIOAny(HTTP2Frame.FramePayload.data(data)))
+
+    // The error is fired as soon as we have enough of the body.
+    self.assertInvalidContentType(
+      channel,
+      contentType: "text/plain",
+      bodySnippet: String(repeating: "a", count: 256)
+    )
+  }
+
+  func testNonGRPCResponseWithoutBody() throws {
+    let channel = try self.makeChannelAwaitingResponse()
+
+    let headers: HPACKHeaders = [":status": "200"]
+    let headersPayload = HTTP2Frame.FramePayload.headers(.init(headers: headers))
+    channel.pipeline.fireChannelRead(NIOAny(headersPayload))
+    channel.pipeline.fireChannelReadComplete()
+
+    // The read completed without any body; the error should have no snippet.
+    self.assertInvalidContentType(channel, contentType: nil, bodySnippet: nil)
+  }
 }
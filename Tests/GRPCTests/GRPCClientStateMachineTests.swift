@@ -476,6 +476,7 @@ extension GRPCClientStateMachineTests {
     var stateMachine = self.makeStateMachine(state)
     let status = try assertNotNil(stateMachine.receiveEndOfResponseStream())
     XCTAssertEqual(status.code, .internalError)
+    XCTAssertTrue(status.message?.contains("without trailers") ?? false)
   }
 
   func testReceiveEndStreamOnDataClientActiveServerIdle() throws {
@@ -836,6 +837,76 @@ extension GRPCClientStateMachineTests {
     }
   }
 
+  func testSendRequestHeadersPassesUnsafeRawHeadersThrough() throws {
+    var requestHead = _GRPCRequestHead(
+      method: "POST",
+      scheme: "http",
+      path: "/echo/Get",
+      host: "localhost",
+      deadline: .distantFuture,
+      customMetadata: ["X-Custom": "custom"],
+      encoding: .disabled
+    )
+    requestHead.unsafeRawHeaders = ["X-Legacy": "caf\u{E9}\tvalue"]
+
+    var stateMachine = self
+      .makeStateMachine(.clientIdleServerIdle(pendingWriteState: .one(), readArity: .one))
+    stateMachine.sendRequestHeaders(requestHead: requestHead).assertSuccess { headers in
+      // Raw headers come last and are not normalized.
+      let last = headers.map { ($0.name, $0.value) }.last
+      XCTAssertEqual(last?.0, "X-Legacy")
+      XCTAssertEqual(last?.1, "caf\u{E9}\tvalue")
+
+      let custom = headers.filter { _, value, _ in value == "custom" }.map { name, _, _ in name }
+      XCTAssertEqual(custom, ["x-custom"])
+    }
+  }
+
+  func testSendRequestHeadersMergesMixedCaseCustomMetadataInOrder() throws {
+    var customMetadata: HPACKHeaders = ["Authorization": "first"]
+    customMetadata.add(name: "x-other", value: "other")
+    customMetadata.add(name: "authorization", value: "second")
+    customMetadata.add(name: "AUTHORIZATION", value: "third")
+
+    // Lookups are case-insensitive before normalization.
+    XCTAssertEqual(customMetadata["authorization"], ["first", "second", "third"])
+    XCTAssertEqual(customMetadata["Authorization"], customMetadata["authorization"])
+
+    var stateMachine = self
+      .makeStateMachine(.clientIdleServerIdle(pendingWriteState: .one(), readArity: .one))
+    stateMachine.sendRequestHeaders(requestHead: .init(
+      method: "POST",
+      scheme: "http",
+      path: "/echo/Get",
+      host: "localhost",
+      deadline: .distantFuture,
+      customMetadata: customMetadata,
+      encoding: .disabled
+    )).assertSuccess { headers in
+      // All values end up under the same lowercased name, in the order they were added.
+      let authorization = headers.filter { name, _, _ in
+        name.lowercased() == "authorization"
+      }.map { name, value, _ in
+        (name, value)
+      }
+      let expected = HPACKHeaders([
+        ("authorization", "first"),
+        ("authorization", "second"),
+        ("authorization", "third"),
+      ])
+      XCTAssertEqual(HPACKHeaders(authorization), expected)
+      XCTAssertEqual(headers["AUTHORIZATION"], ["first", "second", "third"])
+
+      // The relative order of custom metadata is preserved.
+      let names = headers.map { $0.name }
+      let firstIndex = names.firstIndex(of: "authorization")
+      let otherIndex = names.firstIndex(of: "x-other")
+      XCTAssertNotNil(firstIndex)
+      XCTAssertNotNil(otherIndex)
+      XCTAssertLessThan(firstIndex ?? 0, otherIndex ?? 0)
+    }
+  }
+
   func testSendRequestHeadersWithCustomUserAgent() throws {
     let customMetadata: HPACKHeaders = [
       "user-agent": "test-user-agent",
@@ -1034,6 +1105,16 @@ extension GRPCClientStateMachineTests {
     }
   }
 
+  func testReceiveEndOfResponseStreamWithoutStatus() throws {
+    var stateMachine = self.makeStateMachine(.clientClosedServerActive(readState: .one()))
+
+    let trailers: HPACKHeaders = ["foo": "bar"]
+    stateMachine.receiveEndOfResponseStream(trailers).assertSuccess { status in
+      XCTAssertEqual(status.code, .unknown)
+      XCTAssertTrue(status.message?.contains("did not include a 'grpc-status'") ?? false)
+    }
+  }
+
   func testReceiveEndOfResponseStreamWithUnknownStatus() throws {
     var stateMachine = self.makeStateMachine(.clientClosedServerActive(readState: .one()))
 
@@ -1048,7 +1129,31 @@ extension GRPCClientStateMachineTests {
 
     let trailers: HPACKHeaders = ["grpc-status": "not-a-real-status-code"]
     stateMachine.receiveEndOfResponseStream(trailers).assertSuccess { status in
-      XCTAssertEqual(status.code, .unknown)
+      XCTAssertEqual(status.code, .internalError)
+      XCTAssertEqual(status.message, "Invalid 'grpc-status' header value 'not-a-real-status-code'")
+    }
+  }
+
+  func testReceiveEndOfResponseStreamWithPaddedStatus() throws {
+    for value in ["0 ", " 0", "\t0\t", "00", " 0005 "] {
+      var stateMachine = self.makeStateMachine(.clientClosedServerActive(readState: .one()))
+
+      let trailers: HPACKHeaders = ["grpc-status": value]
+      stateMachine.receiveEndOfResponseStream(trailers).assertSuccess { status in
+        XCTAssertEqual(status.code, value.contains("5") ? .notFound : .ok, "'\(value)'")
+      }
+    }
+  }
+
+  func testReceiveEndOfResponseStreamWithMalformedStatus() throws {
+    for value in ["", " ", "-1", "+0", "0x0", "1 2", "99999999999999999999999"] {
+      var stateMachine = self.makeStateMachine(.clientClosedServerActive(readState: .one()))
+
+      let trailers: HPACKHeaders = ["grpc-status": value]
+      stateMachine.receiveEndOfResponseStream(trailers).assertSuccess { status in
+        XCTAssertEqual(status.code, .internalError, "'\(value)'")
+        XCTAssertTrue(status.message?.contains("'\(value)'") ?? false)
+      }
     }
   }
 
@@ -1120,6 +1225,58 @@ extension GRPCClientStateMachineTests {
     }
   }
 
+  func testReceiveTrailersOnlyEndOfResponseStreamWithMalformedStatus() throws {
+    var stateMachine = self.makeStateMachine(.clientActiveServerIdle(
+      writeState: .one(),
+      pendingReadState: .init(arity: .one, messageEncoding: .disabled)
+    ))
+
+    let trailers: HPACKHeaders = [
+      ":status": "200",
+      "grpc-status": "0x0",
+    ]
+    stateMachine.receiveEndOfResponseStream(trailers).assertSuccess { status in
+      XCTAssertEqual(status.code, .internalError)
+      XCTAssertEqual(status.message, "Invalid 'grpc-status' header value '0x0'")
+    }
+  }
+
+  func testReceiveTrailersOnlyEndOfResponseStreamWithPaddedStatus() throws {
+    var stateMachine = self.makeStateMachine(.clientActiveServerIdle(
+      writeState: .one(),
+      pendingReadState: .init(arity: .one, messageEncoding: .disabled)
+    ))
+
+    let trailers: HPACKHeaders = [
+      ":status": "200",
+      "grpc-status": " 05 ",
+    ]
+    stateMachine.receiveEndOfResponseStream(trailers).assertSuccess { status in
+      XCTAssertEqual(status.code, .notFound)
+    }
+  }
+
+  func testReceiveTrailersOnlyEndOfResponseStreamWithInvalidHTTPStatusAndMalformedGRPCStatus() throws {
+    var stateMachine = self.makeStateMachine(.clientActiveServerIdle(
+      writeState: .one(),
+      pendingReadState: .init(arity: .one, messageEncoding: .disabled)
+    ))
+
+    let trailers: HPACKHeaders = [
+      ":status": "418",
+      "grpc-status": "five",
+    ]
+    stateMachine.receiveEndOfResponseStream(trailers).assertFailure { error in
+      XCTAssertEqual(
+        error,
+        .invalidHTTPStatusWithGRPCStatus(GRPCStatus(
+          code: .internalError,
+          message: "Invalid 'grpc-status' header value 'five'"
+        ))
+      )
+    }
+  }
+
   func testReceiveTrailersOnlyEndOfResponseStreamWithInvalidHTTPStatusAndNoGRPCStatus() throws {
     var stateMachine = self.makeStateMachine(.clientActiveServerIdle(
       writeState: .one(),
@@ -155,6 +155,38 @@ class GRPCCustomPayloadTests: GRPCTestCase {
 
     XCTAssertEqual(try rpc.status.map { $0.code }.wait(), .ok)
   }
+
+  func testRawByteBufferPayloadUnary() throws {
+    // The bytes of a 'GRPCRawPayload' are sent and received as-is.
+    let request = GRPCRawPayload(ByteBuffer(string: "foobarbaz"))
+    let rpc: UnaryCall<GRPCRawPayload, GRPCRawPayload> = self.client.makeUnaryCall(
+      path: "/CustomPayload/Reverse",
+      request: request
+    )
+
+    XCTAssertEqual(try rpc.response.map { $0.buffer }.wait(), ByteBuffer(string: "zabraboof"))
+    XCTAssertEqual(try rpc.status.map { $0.code }.wait(), .ok)
+  }
+
+  func testRawBytesPayloadUnary() throws {
+    let rpc: UnaryCall<GRPCRawPayload, GRPCRawPayload> = self.client.makeUnaryCall(
+      path: "/CustomPayload/Reverse",
+      request: GRPCRawPayload(bytes: "foobarbaz".utf8)
+    )
+
+    XCTAssertEqual(try rpc.response.map { $0.bytes }.wait(), Array("zabraboof".utf8))
+    XCTAssertEqual(try rpc.status.map { $0.code }.wait(), .ok)
+  }
+
+  func testRawPayloadOnTheServer() throws {
+    let rpc: UnaryCall<StringPayload, StringPayload> = self.client.makeUnaryCall(
+      path: "/CustomPayload/EchoBytes",
+      request: StringPayload(message: "foobarbaz")
+    )
+
+    XCTAssertEqual(try rpc.response.map { $0.message }.wait(), "foobarbaz")
+    XCTAssertEqual(try rpc.status.map { $0.code }.wait(), .ok)
+  }
 }
 
 // MARK: Custom Payload Service
@@ -170,6 +202,14 @@ private class CustomPayloadProvider: CallHandlerProvider {
     return context.eventLoop.makeSucceededFuture(reversed)
   }
 
+  // Unary RPC which returns the bytes it was sent without ever deserializing them.
+  fileprivate func echoBytes(
+    request: GRPCRawPayload,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<GRPCRawPayload> {
+    return context.eventLoop.makeSucceededFuture(request)
+  }
+
   fileprivate func reverseThenJoin(
     context: UnaryResponseCallContext<StringPayload>
   ) -> EventLoopFuture<(StreamEvent<StringPayload>) -> Void> {
@@ -229,6 +269,15 @@ private class CustomPayloadProvider: CallHandlerProvider {
         userFunction: self.reverseString(request:context:)
       )
 
+    case "EchoBytes":
+      return UnaryServerHandler(
+        context: context,
+        requestDeserializer: GRPCPayloadDeserializer<GRPCRawPayload>(),
+        responseSerializer: GRPCPayloadSerializer<GRPCRawPayload>(),
+        interceptors: [],
+        userFunction: self.echoBytes(request:context:)
+      )
+
     case "ReverseThenJoin":
       return ClientStreamingServerHandler(
         context: context,
@@ -440,6 +440,19 @@ class GRPCIdleHandlerStateMachineTests: GRPCTestCase {
     op4.assertShouldClose()
   }
 
+  func testMaxHeaderListSizeChanges() {
+    var stateMachine = self.makeClientStateMachine()
+
+    // There's no limit by default so nothing is emitted if it isn't set.
+    let op1 = stateMachine.receiveSettings([])
+    XCTAssertNil(op1.maxHeaderListSizeChange)
+
+    let op2 = stateMachine.receiveSettings([
+      HTTP2Setting(parameter: .maxHeaderListSize, value: 8192),
+    ])
+    XCTAssertEqual(op2.maxHeaderListSizeChange, 8192)
+  }
+
   func testNormalFlow() {
     var stateMachine = self.makeClientStateMachine()
 
@@ -69,7 +69,7 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
 
     let get = self.echo.get(self.makeRequest(minimumLength: 1024))
     XCTAssertThrowsError(try get.response.wait())
-    XCTAssertEqual(try get.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try get.status.map { $0.code }.wait(), .resourceExhausted)
   }
 
   func testServerRejectsLongClientStreamingRequest() throws {
@@ -82,7 +82,7 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
     // (No need to send end, the server is going to close the RPC because the message was too long.)
 
     XCTAssertThrowsError(try collect.response.wait())
-    XCTAssertEqual(try collect.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try collect.status.map { $0.code }.wait(), .resourceExhausted)
   }
 
   func testServerRejectsLongServerStreamingRequest() throws {
@@ -93,7 +93,7 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
       XCTFail("Unexpected response")
     }
 
-    XCTAssertEqual(try expand.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try expand.status.map { $0.code }.wait(), .resourceExhausted)
   }
 
   func testServerRejectsLongBidirectionalStreamingRequest() throws {
@@ -106,7 +106,7 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
     XCTAssertNoThrow(try update.sendMessage(self.makeRequest(minimumLength: 1024)).wait())
     // (No need to send end, the server is going to close the RPC because the message was too long.)
 
-    XCTAssertEqual(try update.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try update.status.map { $0.code }.wait(), .resourceExhausted)
   }
 
   func testClientRejectsLongUnaryResponse() throws {
@@ -116,7 +116,7 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
 
     let get = self.echo.get(.with { $0.text = String(repeating: "x", count: 1024) })
     XCTAssertThrowsError(try get.response.wait())
-    XCTAssertEqual(try get.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try get.status.map { $0.code }.wait(), .resourceExhausted)
   }
 
   func testClientRejectsLongClientStreamingResponse() throws {
@@ -129,7 +129,7 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
     XCTAssertNoThrow(try collect.sendEnd().wait())
 
     XCTAssertThrowsError(try collect.response.wait())
-    XCTAssertEqual(try collect.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try collect.status.map { $0.code }.wait(), .resourceExhausted)
   }
 
   func testClientRejectsLongServerStreamingRequest() throws {
@@ -142,7 +142,7 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
       XCTFail("Unexpected response")
     }
 
-    XCTAssertEqual(try expand.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try expand.status.map { $0.code }.wait(), .resourceExhausted)
   }
 
   func testClientRejectsLongServerBidirectionalStreamingResponse() throws {
@@ -156,6 +156,6 @@ final class GRPCMessageLengthLimitTests: GRPCTestCase {
     // (No need to send end, the client will close the RPC when it receives a response which is too
     // long.
 
-    XCTAssertEqual(try update.status.map { $0.code }.wait(), .internalError)
+    XCTAssertEqual(try update.status.map { $0.code }.wait(), .resourceExhausted)
   }
 }
@@ -34,6 +34,76 @@ class GRPCPingHandlerTests: GRPCTestCase {
     XCTAssertEqual(response, .none)
   }
 
+  func testRoundTripTimeIsRecordedForKeepalivePong() {
+    self.setupPingHandler(interval: .seconds(1), timeout: .seconds(1))
+    XCTAssertNil(self.pingHandler.lastRoundTripTime)
+
+    _ = self.pingHandler.streamCreated()
+
+    let sentAt = NIODeadline.now() + .seconds(1)
+    self.pingHandler._testingOnlyNow = sentAt
+    _ = self.pingHandler.pingFired()
+
+    self.pingHandler._testingOnlyNow = sentAt + .milliseconds(25)
+    let response = self.pingHandler.read(pingData: HTTP2PingData(withInteger: 1), ack: true)
+    XCTAssertEqual(response, .cancelScheduledTimeout)
+    XCTAssertEqual(self.pingHandler.lastRoundTripTime, .milliseconds(25))
+
+    // Acks for pings we didn't send shouldn't affect the round-trip time.
+    _ = self.pingHandler.read(pingData: HTTP2PingData(withInteger: 2), ack: true)
+    XCTAssertEqual(self.pingHandler.lastRoundTripTime, .milliseconds(25))
+  }
+
+  func testRoundTripTimeProbeWithCallInFlight() {
+    self.setupPingHandler(interval: .seconds(1), timeout: .seconds(1))
+    _ = self.pingHandler.streamCreated()
+
+    // Probes aren't limited while there are active streams.
+    for _ in 0 ..< 5 {
+      let probe = self.pingHandler.roundTripTimeProbe(code: 42).map(PingHandler.Action.reply)
+      XCTAssertEqual(probe, .reply(.ping(HTTP2PingData(withInteger: 42), ack: false)))
+    }
+  }
+
+  func testRoundTripTimeProbesWithoutCallsAreLimited() {
+    self.setupPingHandler(
+      interval: .seconds(1),
+      timeout: .seconds(1),
+      maximumPingsWithoutData: 1,
+      minimumSentPingIntervalWithoutData: .seconds(5)
+    )
+
+    let now = NIODeadline.now()
+    self.pingHandler._testingOnlyNow = now
+    XCTAssertNotNil(self.pingHandler.roundTripTimeProbe(code: 42))
+
+    // Too soon after the last ping.
+    self.pingHandler._testingOnlyNow = now + .seconds(1)
+    XCTAssertNil(self.pingHandler.roundTripTimeProbe(code: 42))
+
+    self.pingHandler._testingOnlyNow = now + .seconds(5)
+    XCTAssertNotNil(self.pingHandler.roundTripTimeProbe(code: 42))
+
+    // Too many pings without data.
+    self.pingHandler._testingOnlyNow = now + .seconds(10)
+    XCTAssertNil(self.pingHandler.roundTripTimeProbe(code: 42))
+
+    // Starting a stream resets the count.
+    _ = self.pingHandler.streamCreated()
+    _ = self.pingHandler.streamClosed()
+    XCTAssertNotNil(self.pingHandler.roundTripTimeProbe(code: 42))
+  }
+
+  func testRoundTripTimeProbeAckDoesNotAffectKeepalive() {
+    self.setupPingHandler(interval: .seconds(1), timeout: .seconds(1))
+    _ = self.pingHandler.streamCreated()
+    _ = self.pingHandler.roundTripTimeProbe(code: 42)
+
+    let response = self.pingHandler.read(pingData: HTTP2PingData(withInteger: 42), ack: true)
+    XCTAssertEqual(response, .none)
+    XCTAssertNil(self.pingHandler.lastRoundTripTime)
+  }
+
   func testClosingStreamWithPermitCalls() {
     // Allow pings without calls (since `minimumReceivedPingIntervalWithoutData` and `maximumPingStrikes` are not set, ping strikes should not have any effect)
     self.setupPingHandler(interval: .seconds(1), timeout: .seconds(1), permitWithoutCalls: true)
@@ -0,0 +1,92 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class GRPCSocketOptionsTests: GRPCTestCase {
+  private typealias SocketOptions = (
+    noDelay: SocketOptionValue,
+    receiveBufferSize: SocketOptionValue
+  )
+
+  private static func getSocketOptions(
+    from channel: Channel
+  ) -> EventLoopFuture<SocketOptions> {
+    let noDelay = channel.getOption(ChannelOptions.socket(IPPROTO_TCP, TCP_NODELAY))
+    let receiveBufferSize = channel.getOption(
+      ChannelOptions.socket(SocketOptionLevel(SOL_SOCKET), SO_RCVBUF)
+    )
+    return noDelay.and(receiveBufferSize).map { (noDelay: $0, receiveBufferSize: $1) }
+  }
+
+  func testDefaultsEnableNoDelay() {
+    let options = GRPCSocketOptions()
+    XCTAssertTrue(options.noDelay)
+    XCTAssertTrue(options.reuseAddress)
+    XCTAssertNil(options.sendBufferSize)
+    XCTAssertNil(options.receiveBufferSize)
+  }
+
+  func testSocketOptionsAreApplied() throws {
+    let group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+    defer {
+      XCTAssertNoThrow(try group.syncShutdownGracefully())
+    }
+
+    let serverOptions = group.next().makePromise(of: SocketOptions.self)
+    let server = try Server.insecure(group: group)
+      .withServiceProviders([EchoProvider()])
+      .withSocketOptions(GRPCSocketOptions(noDelay: false, receiveBufferSize: 64 * 1024))
+      .withDebugChannelInitializer { channel in
+        serverOptions.completeWith(GRPCSocketOptionsTests.getSocketOptions(from: channel))
+        return channel.eventLoop.makeSucceededFuture(())
+      }
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+    defer {
+      XCTAssertNoThrow(try server.close().wait())
+    }
+
+    let clientOptions = group.next().makePromise(of: SocketOptions.self)
+    let connection = ClientConnection.insecure(group: group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .withDebugChannelInitializer { channel in
+        clientOptions.completeWith(GRPCSocketOptionsTests.getSocketOptions(from: channel))
+        return channel.eventLoop.makeSucceededFuture(())
+      }
+      .connect(host: "localhost", port: server.channel.localAddress!.port!)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let echo = Echo_EchoClient(channel: connection)
+    // Make an RPC to trigger channel creation.
+    let get = echo.get(.with { $0.text = "Hello!" })
+    XCTAssertTrue(try get.status.map { $0.isOk }.wait())
+
+    let client = try clientOptions.futureResult.wait()
+    XCTAssertNotEqual(client.noDelay, 0)
+
+    let accepted = try serverOptions.futureResult.wait()
+    XCTAssertEqual(accepted.noDelay, 0)
+    // The kernel may round up (or double) the requested size.
+    XCTAssertGreaterThanOrEqual(accepted.receiveBufferSize, 64 * 1024)
+  }
+}
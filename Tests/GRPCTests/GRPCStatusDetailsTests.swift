@@ -0,0 +1,166 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import Foundation
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import NIOHPACK
+import SwiftProtobuf
+import XCTest
+
+/// Sends a response for each word in the request and then fails with status details.
+class PartialFailureEchoProvider: Echo_EchoProvider {
+  let interceptors: Echo_EchoServerInterceptorFactoryProtocol? = nil
+
+  static func makeDetails() throws -> GRPCStatusDetails {
+    return GRPCStatusDetails(
+      code: .unavailable,
+      message: "Results are incomplete",
+      details: [try Google_Protobuf_Any(message: Echo_EchoResponse(text: "backend failed"))]
+    )
+  }
+
+  func get(
+    request: Echo_EchoRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Echo_EchoResponse> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+
+  func expand(
+    request: Echo_EchoRequest,
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<GRPCStatus> {
+    let responses = request.text.split(separator: " ").map {
+      context.sendResponse(Echo_EchoResponse(text: String($0)))
+    }
+
+    return EventLoopFuture.andAllSucceed(responses, on: context.eventLoop).flatMapThrowing {
+      throw try PartialFailureEchoProvider.makeDetails()
+    }
+  }
+
+  func collect(
+    context: UnaryResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+
+  func update(
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+}
+
+class GRPCStatusDetailsTests: EchoTestCaseBase {
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return PartialFailureEchoProvider()
+  }
+
+  func testRoundTripThroughTrailerValue() throws {
+    let details = try PartialFailureEchoProvider.makeDetails()
+    let decoded = try GRPCStatusDetails(trailerValue: try details.makeTrailerValue())
+    XCTAssertEqual(decoded, details)
+    XCTAssertEqual(try Echo_EchoResponse(unpackingAny: decoded.details[0]).text, "backend failed")
+  }
+
+  func testTrailerValueWithoutPadding() throws {
+    let details = GRPCStatusDetails(code: .notFound, message: "a")
+    var value = try details.makeTrailerValue()
+    XCTAssert(value.hasSuffix("="))
+    value.removeAll(where: { $0 == "=" })
+
+    XCTAssertEqual(try GRPCStatusDetails(trailerValue: value), details)
+  }
+
+  func testInvalidTrailerValue() throws {
+    XCTAssertThrowsError(try GRPCStatusDetails(trailerValue: "not base64!"))
+  }
+
+  func testInitFromTrailers() throws {
+    XCTAssertNil(try GRPCStatusDetails(trailers: [:]))
+
+    let details = GRPCStatusDetails(code: .aborted, message: "aborted")
+    let trailers: HPACKHeaders = [GRPCStatusDetails.trailerName: try details.makeTrailerValue()]
+    XCTAssertEqual(try GRPCStatusDetails(trailers: trailers), details)
+  }
+
+  func testMakeGRPCStatus() throws {
+    let status = try PartialFailureEchoProvider.makeDetails().makeGRPCStatus()
+    XCTAssertEqual(status.code, .unavailable)
+    XCTAssertEqual(status.message, "Results are incomplete")
+
+    XCTAssertNil(GRPCStatusDetails(code: .unknown).makeGRPCStatus().message)
+  }
+
+  func testResponsesAreDeliveredBeforeStatusWithDetails() throws {
+    let received = NIOAtomic<Int>.makeAtomic(value: 0)
+    let call = self.client.expand(Echo_EchoRequest(text: "a b c")) { _ in
+      received.add(1)
+    }
+
+    let status = try call.status.wait()
+    XCTAssertEqual(received.load(), 3)
+    XCTAssertEqual(status.code, .unavailable)
+    XCTAssertEqual(status.message, "Results are incomplete")
+
+    let details = try GRPCStatusDetails(trailers: try call.trailingMetadata.wait())
+    XCTAssertEqual(details, try PartialFailureEchoProvider.makeDetails())
+  }
+
+  func testRetryDelayRoundTrip() throws {
+    var details = GRPCStatusDetails(
+      code: .unavailable,
+      retryDelay: .milliseconds(1500),
+      details: [try Google_Protobuf_Any(message: Echo_EchoResponse(text: "other"))]
+    )
+    XCTAssertEqual(details.retryDelay, .milliseconds(1500))
+    XCTAssertEqual(details.details.count, 2)
+
+    let trailers: HPACKHeaders = [GRPCStatusDetails.trailerName: try details.makeTrailerValue()]
+    XCTAssertEqual(GRPCStatusDetails.retryDelay(in: trailers), .milliseconds(1500))
+
+    details.retryDelay = .seconds(3)
+    XCTAssertEqual(details.retryDelay, .seconds(3))
+    XCTAssertEqual(details.details.count, 2)
+
+    details.retryDelay = nil
+    XCTAssertNil(details.retryDelay)
+    XCTAssertEqual(details.details.count, 1)
+  }
+
+  func testRetryDelayIsNilWithoutRetryInfo() throws {
+    XCTAssertNil(try PartialFailureEchoProvider.makeDetails().retryDelay)
+    XCTAssertNil(GRPCStatusDetails.retryDelay(in: [:]))
+    XCTAssertNil(GRPCStatusDetails.retryDelay(in: [GRPCStatusDetails.trailerName: "!"]))
+  }
+
+  func testOversizedRetryDelayIsClamped() throws {
+    // A 'google.rpc.RetryInfo' whose delay doesn't fit in a 'TimeAmount'.
+    let duration = try Google_Protobuf_Duration(seconds: .max).serializedData()
+    var retryInfo = Google_Protobuf_Any()
+    retryInfo.typeURL = GRPCStatusDetails.retryInfoTypeURL
+    retryInfo.value = Data([0x0A, UInt8(duration.count)]) + duration
+
+    let details = GRPCStatusDetails(code: .unavailable, details: [retryInfo])
+    XCTAssertEqual(details.retryDelay, .nanoseconds(.max))
+
+    let trailers: HPACKHeaders = [GRPCStatusDetails.trailerName: try details.makeTrailerValue()]
+    XCTAssertEqual(GRPCStatusDetails.retryDelay(in: trailers), .nanoseconds(.max))
+  }
+}
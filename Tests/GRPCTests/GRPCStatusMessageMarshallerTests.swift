@@ -13,8 +13,10 @@
  * See the License for the specific language governing permissions and
  * limitations under the License.
  */
+import EchoModel
 import Foundation
 import GRPC
+import NIO
 import XCTest
 
 class GRPCStatusMessageMarshallerTests: GRPCTestCase {
@@ -41,4 +43,68 @@ class GRPCStatusMessageMarshallerTests: GRPCTestCase {
     XCTAssertEqual(GRPCStatusMessageMarshaller.marshall(message), marshalled)
     XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall(marshalled), message)
   }
+
+  func testMarshallingEmojiAndPercentSigns() {
+    let message = "100% 🎉 done — 50%25 off"
+    let marshalled = "100%25 %F0%9F%8E%89 done %E2%80%94 50%2525 off"
+    XCTAssertEqual(GRPCStatusMessageMarshaller.marshall(message), marshalled)
+    XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall(marshalled), message)
+  }
+
+  func testUnmarshallingLowercaseHex() {
+    XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall("%f0%9f%9a%80"), "🚀")
+  }
+
+  func testUnmarshallingInvalidEncodingReturnsMessage() {
+    // Not hex.
+    XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall("%GG"), "%GG")
+    XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall("50% off"), "50% off")
+    // Truncated.
+    XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall("ab%"), "ab%")
+    XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall("abc%4"), "abc%4")
+    // Not UTF-8.
+    XCTAssertEqual(GRPCStatusMessageMarshaller.unmarshall("%F0%9F"), "%F0%9F")
+  }
+}
+
+/// Fails each unary RPC with the text of its request as the status message, and each server
+/// streaming RPC with the same status after sending a response.
+private class MessageFailingEchoProvider: FailingEchoProvider {
+  override func get(
+    request: Echo_EchoRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Echo_EchoResponse> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .aborted, message: request.text))
+  }
+
+  override func expand(
+    request: Echo_EchoRequest,
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<GRPCStatus> {
+    return context.sendResponse(.with { $0.text = request.text }).map {
+      GRPCStatus(code: .aborted, message: request.text)
+    }
+  }
+}
+
+class GRPCStatusMessageEndToEndTests: EchoTestCaseBase {
+  private let message = "Échec : 100% 🚨 — %41 is not 'A'"
+
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return MessageFailingEchoProvider()
+  }
+
+  func testStatusMessageInTrailersOnlyResponse() throws {
+    let get = self.client.get(.with { $0.text = self.message })
+    let status = try get.status.wait()
+    XCTAssertEqual(status.code, .aborted)
+    XCTAssertEqual(status.message, self.message)
+  }
+
+  func testStatusMessageInTrailers() throws {
+    let expand = self.client.expand(.with { $0.text = self.message }) { _ in }
+    let status = try expand.status.wait()
+    XCTAssertEqual(status.code, .aborted)
+    XCTAssertEqual(status.message, self.message)
+  }
 }
@@ -50,4 +50,48 @@ class GRPCStatusTests: GRPCTestCase {
       String(describing: GRPCStatus(code: .failedPrecondition, message: "invalid state"))
     )
   }
+
+  func testStatusDescriptionWithCause() {
+    struct TransportError: Error, CustomStringConvertible {
+      var description: String { return "handshake failed" }
+    }
+
+    XCTAssertEqual(
+      "unavailable (14): connection failed, cause: handshake failed",
+      String(describing: GRPCStatus(
+        code: .unavailable,
+        message: "connection failed",
+        cause: TransportError()
+      ))
+    )
+
+    XCTAssertEqual(
+      "unavailable (14), cause: handshake failed",
+      String(describing: GRPCStatus(code: .unavailable, message: nil, cause: TransportError()))
+    )
+  }
+
+  func testCauseDoesNotAffectEquality() {
+    struct TransportError: Error {}
+    let withCause = GRPCStatus(code: .unavailable, message: "foo", cause: TransportError())
+    let withoutCause = GRPCStatus(code: .unavailable, message: "foo")
+    XCTAssertEqual(withCause, withoutCause)
+  }
+
+  func testMutatingCopyDoesNotAffectOriginal() {
+    struct TransportError: Error {}
+    let original = GRPCStatus(code: .unavailable, message: "foo", cause: TransportError())
+    var copy = original
+    copy.message = "bar"
+    copy.cause = nil
+
+    XCTAssertEqual(original.message, "foo")
+    XCTAssertNotNil(original.cause)
+    XCTAssertEqual(copy.message, "bar")
+    XCTAssertNil(copy.cause)
+
+    copy.message = nil
+    XCTAssertNil(copy.message)
+    XCTAssertEqual(original.message, "foo")
+  }
 }
@@ -17,6 +17,7 @@ import Dispatch
 import Foundation
 @testable import GRPC
 import NIO
+import NIOHPACK
 import XCTest
 
 class GRPCTimeoutTests: GRPCTestCase {
@@ -128,4 +129,36 @@ class GRPCTimeoutTests: GRPCTestCase {
   func testTimeoutFromDistantFuture() throws {
     XCTAssertEqual(GRPCTimeout(deadline: .distantFuture), .infinite)
   }
+
+  func testTimeoutFromWireEncoding() throws {
+    XCTAssertEqual(GRPCTimeout(decoding: "100m"), GRPCTimeout(amount: 100, unit: .milliseconds))
+    XCTAssertEqual(GRPCTimeout(decoding: "1H"), GRPCTimeout(amount: 1, unit: .hours))
+    XCTAssertEqual(
+      GRPCTimeout(decoding: "99999999n"),
+      GRPCTimeout(amount: 99_999_999, unit: .nanoseconds)
+    )
+  }
+
+  func testTimeoutFromInvalidWireEncoding() throws {
+    XCTAssertNil(GRPCTimeout(decoding: ""))
+    XCTAssertNil(GRPCTimeout(decoding: "m"))
+    XCTAssertNil(GRPCTimeout(decoding: "100"))
+    XCTAssertNil(GRPCTimeout(decoding: "100x"))
+    XCTAssertNil(GRPCTimeout(decoding: "-100m"))
+    XCTAssertNil(GRPCTimeout(decoding: "+100m"))
+    XCTAssertNil(GRPCTimeout(decoding: "123456789S"))
+  }
+
+  func testDeadlineFromRequestHeaders() throws {
+    let now = NIODeadline.uptimeNanoseconds(1000)
+
+    let deadline = GRPCTimeout.deadline(fromRequestHeaders: ["grpc-timeout": "5S"], now: now)
+    XCTAssertEqual(deadline, now + .seconds(5))
+
+    XCTAssertEqual(GRPCTimeout.deadline(fromRequestHeaders: [:], now: now), .distantFuture)
+    XCTAssertEqual(
+      GRPCTimeout.deadline(fromRequestHeaders: ["grpc-timeout": "bogus"], now: now),
+      .distantFuture
+    )
+  }
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import GRPC
+import NIOHPACK
+import XCTest
+
+class HPACKHeadersHTTP1Tests: GRPCTestCase {
+  func testNamesAreLowercased() throws {
+    let headers = try HPACKHeaders(httpHeaders: [("X-Trace-ID", "abc")])
+    XCTAssertEqual(headers["x-trace-id"], ["abc"])
+  }
+
+  func testRepeatedNamesAreKept() throws {
+    let headers = try HPACKHeaders(httpHeaders: [
+      ("x-tenant", "a"),
+      ("Accept", "application/grpc"),
+      ("X-Tenant", "b, c"),
+    ])
+    XCTAssertEqual(headers["x-tenant"], ["a", "b, c"])
+    XCTAssertEqual(headers.count, 3)
+  }
+
+  func testBinaryValuesArePadded() throws {
+    // "foo" encodes to "Zm9v", "fo" to "Zm8=".
+    let headers = try HPACKHeaders(httpHeaders: [("x-data-bin", "Zm9v"), ("x-data-bin", "Zm8")])
+    XCTAssertEqual(headers["x-data-bin"], ["Zm9v", "Zm8="])
+  }
+
+  func testInvalidBinaryValueThrows() {
+    XCTAssertThrowsError(try HPACKHeaders(httpHeaders: [("x-data-bin", "not base64!")])) {
+      XCTAssert($0 is GRPCError.Base64DecodeError)
+    }
+  }
+
+  func testPseudoHeadersAreDropped() throws {
+    let headers = try HPACKHeaders(httpHeaders: [(":path", "/foo"), ("x-foo", "bar")])
+    XCTAssertEqual(headers.count, 1)
+
+    let exported = HPACKHeaders([(":status", "200"), ("x-foo", "bar")]).httpHeaders
+    XCTAssertEqual(exported.map { $0.0 }, ["x-foo"])
+  }
+
+  func testRoundTrip() throws {
+    let pairs = [("x-tenant", "a"), ("x-data-bin", "Zm8="), ("x-tenant", "b")]
+    let exported = try HPACKHeaders(httpHeaders: pairs).httpHeaders
+    XCTAssertEqual(exported.map { $0.0 }, pairs.map { $0.0 })
+    XCTAssertEqual(exported.map { $0.1 }, pairs.map { $0.1 })
+  }
+}
@@ -0,0 +1,55 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import GRPC
+import NIOHPACK
+import XCTest
+
+class HPACKHeadersRedactionTests: GRPCTestCase {
+  private let headers: HPACKHeaders = [
+    "authorization": "Bearer secret",
+    "Cookie": "session=secret",
+    "x-custom-secret": "hunter2",
+    "content-type": "application/grpc",
+  ]
+
+  func testRedactingDefaultSensitiveNames() {
+    let redacted = self.headers.redacting()
+    XCTAssertEqual(redacted[canonicalForm: "authorization"], ["<redacted>"])
+    XCTAssertEqual(redacted[canonicalForm: "cookie"], ["<redacted>"])
+    XCTAssertEqual(redacted[canonicalForm: "x-custom-secret"], ["hunter2"])
+    XCTAssertEqual(redacted[canonicalForm: "content-type"], ["application/grpc"])
+    XCTAssertEqual(redacted.count, self.headers.count)
+  }
+
+  func testRedactingCustomNames() {
+    let redacted = self.headers.redacting(names: ["X-Custom-Secret"], replacement: "***")
+    XCTAssertEqual(redacted[canonicalForm: "authorization"], ["Bearer secret"])
+    XCTAssertEqual(redacted[canonicalForm: "x-custom-secret"], ["***"])
+  }
+
+  func testAllowingOnly() {
+    let redacted = self.headers.allowingOnly(names: ["content-type"])
+    XCTAssertEqual(redacted[canonicalForm: "authorization"], ["<redacted>"])
+    XCTAssertEqual(redacted[canonicalForm: "cookie"], ["<redacted>"])
+    XCTAssertEqual(redacted[canonicalForm: "x-custom-secret"], ["<redacted>"])
+    XCTAssertEqual(redacted[canonicalForm: "content-type"], ["application/grpc"])
+  }
+
+  func testOriginalHeadersAreUnchanged() {
+    _ = self.headers.redacting()
+    XCTAssertEqual(self.headers[canonicalForm: "authorization"], ["Bearer secret"])
+  }
+}
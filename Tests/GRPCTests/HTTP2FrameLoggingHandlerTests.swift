@@ -0,0 +1,77 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import Logging
+import NIO
+import NIOHPACK
+import NIOHTTP2
+import XCTest
+
+class HTTP2FrameLoggingHandlerTests: GRPCTestCase {
+  private var recorder: CapturingLogHandlerFactory!
+  private var channel: EmbeddedChannel!
+
+  override func setUp() {
+    super.setUp()
+    self.recorder = CapturingLogHandlerFactory(printWhenCaptured: false)
+    let logger = Logger(label: "io.grpc.testing", factory: self.recorder.make(_:))
+    self.channel = EmbeddedChannel(handler: HTTP2FrameLoggingHandler(logger: logger))
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.channel.finish())
+    super.tearDown()
+  }
+
+  func testFramesArePassedThroughAndLogged() throws {
+    let headers: HPACKHeaders = [":path": "/echo.Echo/Get", "authorization": "secret"]
+    let inbound = HTTP2Frame(streamID: 1, payload: .headers(.init(headers: headers)))
+    XCTAssertNoThrow(try self.channel.writeInbound(inbound))
+    XCTAssertNotNil(try self.channel.readInbound(as: HTTP2Frame.self))
+
+    let data = HTTP2Frame(
+      streamID: 1,
+      payload: .data(.init(data: .byteBuffer(ByteBuffer(string: "foo")), endStream: true))
+    )
+    XCTAssertNoThrow(try self.channel.writeOutbound(data))
+    XCTAssertNotNil(try self.channel.readOutbound(as: HTTP2Frame.self))
+
+    let goAway = HTTP2Frame(
+      streamID: .rootStream,
+      payload: .goAway(lastStreamID: 1, errorCode: .noError, opaqueData: nil)
+    )
+    XCTAssertNoThrow(try self.channel.writeOutbound(goAway))
+    XCTAssertNotNil(try self.channel.readOutbound(as: HTTP2Frame.self))
+
+    let logs = self.recorder.clearCapturedLogs()
+    XCTAssertEqual(logs.count, 3)
+    XCTAssert(logs.allSatisfy { $0.level == .debug })
+
+    let types = logs.map { $0.metadata[MetadataKey.h2Payload] }
+    XCTAssertEqual(types, ["HEADERS", "DATA", "GOAWAY"])
+
+    let directions = logs.map { $0.metadata[MetadataKey.h2Direction] }
+    XCTAssertEqual(directions, ["inbound", "outbound", "outbound"])
+
+    XCTAssertEqual(logs[0].metadata[MetadataKey.h2StreamID], "1")
+    let loggedHeaders = logs[0].metadata[MetadataKey.h2Headers].map { "\($0)" } ?? ""
+    XCTAssertFalse(loggedHeaders.contains("secret"))
+
+    XCTAssertEqual(logs[1].metadata[MetadataKey.h2DataBytes], "3")
+    XCTAssertEqual(logs[1].metadata[MetadataKey.h2EndStream], "true")
+    XCTAssertEqual(logs[2].metadata[MetadataKey.h2ErrorCode], "\(HTTP2ErrorCode.noError)")
+  }
+}
@@ -241,6 +241,56 @@ class HTTP2ToRawGRPCStateMachineTests: GRPCTestCase {
     assertThat(action, .is(.rejectRPC(.trailersOnly(code: .unimplemented))))
   }
 
+  func testUnknownMethodStatusDoesNotIncludeKnownMethodsByDefault() {
+    var machine = StateMachine()
+    let action = machine.receive(
+      headers: self.makeHeaders(path: "/echo.Echo/Gte"),
+      eventLoop: self.eventLoop,
+      errorDelegate: nil,
+      remoteAddress: nil,
+      logger: self.logger,
+      allocator: ByteBufferAllocator(),
+      responseWriter: NoOpResponseWriter(),
+      closeFuture: self.eventLoop.makeSucceededVoidFuture(),
+      services: self.services,
+      encoding: .disabled,
+      normalizeHeaders: false
+    )
+    assertThat(action, .is(.rejectRPC(.trailersOnly(code: .unimplemented))))
+    assertThat(
+      action,
+      .is(.rejectRPC(.contains("grpc-message", ["'/echo.Echo/Gte' is not implemented"])))
+    )
+  }
+
+  func testUnknownMethodStatusIncludesClosestKnownMethods() {
+    var machine = StateMachine()
+    let action = machine.receive(
+      headers: self.makeHeaders(path: "/echo.Echo/Gte"),
+      eventLoop: self.eventLoop,
+      errorDelegate: nil,
+      remoteAddress: nil,
+      logger: self.logger,
+      allocator: ByteBufferAllocator(),
+      responseWriter: NoOpResponseWriter(),
+      closeFuture: self.eventLoop.makeSucceededVoidFuture(),
+      services: self.services,
+      encoding: .disabled,
+      normalizeHeaders: false,
+      includeKnownMethodsInUnimplementedStatus: true
+    )
+
+    let message = "'/echo.Echo/Gte' is not implemented; closest known methods: " +
+      "/echo.Echo/Get, /echo.Echo/Update, /echo.Echo/Collect, /echo.Echo/Expand"
+    assertThat(action, .is(.rejectRPC(.trailersOnly(code: .unimplemented))))
+    assertThat(action, .is(.rejectRPC(.contains("grpc-message", [message]))))
+  }
+
+  func testClosestKnownMethodsForUnknownService() {
+    let closest = StateMachine.closestKnownMethods(to: "/foo.Foo/Get", in: self.services, limit: 2)
+    XCTAssertEqual(closest, ["/echo.Echo/Get", "/echo.Echo/Collect"])
+  }
+
   func testReceiveValidHeadersForInvalidPath() {
     var machine = StateMachine()
     let action = machine.receive(
@@ -392,6 +442,56 @@ class HTTP2ToRawGRPCStateMachineTests: GRPCTestCase {
     assertThat(sendAction, .success(.contains("grpc-encoding", ["deflate"])))
   }
 
+  func testReceiveHeadersNegotiatesClientsPreferredResponseEncoding() {
+    var machine = StateMachine()
+
+    let action = machine.receive(
+      headers: self.makeHeaders(contentType: "application/grpc", acceptEncoding: [
+        "identity;q=0.1", "gzip;q=0.5", "deflate",
+      ]),
+      eventLoop: self.eventLoop,
+      errorDelegate: nil,
+      remoteAddress: nil,
+      logger: self.logger,
+      allocator: ByteBufferAllocator(),
+      responseWriter: NoOpResponseWriter(),
+      closeFuture: self.eventLoop.makeSucceededVoidFuture(),
+      services: self.services,
+      encoding: .enabled(.gzip, .deflate),
+      normalizeHeaders: false
+    )
+
+    assertThat(action, .is(.configure()))
+    let sendAction = machine.send(headers: [:])
+    assertThat(sendAction, .success(.contains("grpc-encoding", ["deflate"])))
+  }
+
+  func testReceiveHeadersDoesNotNegotiateResponseEncodingWhenResponseCompressionIsDisabled() {
+    var machine = StateMachine()
+
+    let action = machine.receive(
+      headers: self.makeHeaders(acceptEncoding: [.deflate]),
+      eventLoop: self.eventLoop,
+      errorDelegate: nil,
+      remoteAddress: nil,
+      logger: self.logger,
+      allocator: ByteBufferAllocator(),
+      responseWriter: NoOpResponseWriter(),
+      closeFuture: self.eventLoop.makeSucceededVoidFuture(),
+      services: self.services,
+      encoding: .enabled(.init(
+        enabledAlgorithms: [.gzip, .deflate],
+        decompressionLimit: .absolute(.max),
+        compressResponses: false
+      )),
+      normalizeHeaders: false
+    )
+
+    assertThat(action, .is(.configure()))
+    let sendAction = machine.send(headers: [:])
+    XCTAssertNil(try sendAction.get().first(name: "grpc-encoding"))
+  }
+
   // MARK: Receive Data Tests
 
   func testReceiveDataBeforePipelineIsConfigured() {
@@ -610,6 +710,20 @@ class HTTP2ToRawGRPCStateMachineTests: GRPCTestCase {
     assertThat(action, .success(.contains(caseSensitive: "foo")))
   }
 
+  func testSendMetadataNormalizesMixedCaseDuplicates() {
+    var machine = self.makeStateMachine(state: .requestOpenResponseIdle(pipelineConfigured: true))
+    var headers: HPACKHeaders = ["X-Foo": "1"]
+    headers.add(name: "x-foo", value: "2")
+    let action = machine.send(headers: headers)
+    assertThat(action, .success(.contains(caseSensitive: "x-foo")))
+
+    guard case let .success(sent) = action else {
+      return XCTFail("Expected headers to be sent")
+    }
+    let values = sent.filter { name, _, _ in name == "x-foo" }.map { $0.value }
+    XCTAssertEqual(values, ["1", "2"])
+  }
+
   // MARK: Send Data Tests
 
   func testSendData() {
@@ -733,6 +847,8 @@ class NoOpResponseWriter: GRPCServerResponseWriter {
   func sendEnd(status: GRPCStatus, trailers: HPACKHeaders, promise: EventLoopPromise<Void>?) {
     promise?.succeed(())
   }
+
+  func flush() {}
 }
 
 extension HTTP2ToRawGRPCStateMachine {
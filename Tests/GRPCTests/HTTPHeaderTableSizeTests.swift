@@ -0,0 +1,47 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+@testable import GRPC
+import NIO
+import XCTest
+
+class HTTPHeaderTableSizeTests: EchoTestCaseBase {
+  override func connectionBuilder() -> ClientConnection.Builder {
+    return super.connectionBuilder().withHTTPHeaderTableSize(64 * 1024)
+  }
+
+  override func serverBuilder() -> Server.Builder {
+    return super.serverBuilder().withHTTPHeaderTableSize(0)
+  }
+
+  func testRPCsWithRepeatedHeadersSucceed() throws {
+    let token = String(repeating: "t", count: 2048)
+    let options = CallOptions(customMetadata: ["authorization": "Bearer \(token)"])
+
+    for _ in 0 ..< 3 {
+      let get = self.client.get(.with { $0.text = "foo" }, callOptions: options)
+      XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+    }
+  }
+
+  func testHeaderTableSizeValidation() {
+    XCTAssertTrue(isValidHTTPHeaderTableSize(0))
+    XCTAssertTrue(isValidHTTPHeaderTableSize(4096))
+    XCTAssertTrue(isValidHTTPHeaderTableSize(Int(UInt32.max)))
+    XCTAssertFalse(isValidHTTPHeaderTableSize(-1))
+    XCTAssertFalse(isValidHTTPHeaderTableSize(Int(UInt32.max) + 1))
+  }
+}
@@ -0,0 +1,58 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Dispatch
+import EchoModel
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import XCTest
+
+class InterceptorContextOffloadTests: EchoTestCaseBase {
+  func testClientInterceptorCanOffloadEachPart() throws {
+    let queue = DispatchQueue(label: "io.grpc.testing.offload")
+    let offloadedOnEventLoop = NIOAtomic<Int>.makeAtomic(value: 0)
+
+    let factory = DelegatingEchoClientInterceptorFactory { part, promise, context in
+      context.offload(to: queue) {
+        if context.eventLoop.inEventLoop {
+          offloadedOnEventLoop.add(1)
+        }
+      }.whenSuccess {
+        context.send(part, promise: promise)
+      }
+    }
+
+    let client = Echo_EchoClient(
+      channel: self.client.channel,
+      defaultCallOptions: self.callOptionsWithLogger,
+      interceptors: factory
+    )
+
+    var responses: [String] = []
+    let update = client.update { response in
+      responses.append(response.text)
+    }
+
+    let messages: [Echo_EchoRequest] = [.with { $0.text = "a" }, .with { $0.text = "b" }]
+    XCTAssertNoThrow(try update.sendMessages(messages).wait())
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertEqual(try update.status.map { $0.code }.wait(), .ok)
+
+    // The parts were sent in order and none of the work ran on the event loop.
+    XCTAssertEqual(responses, ["Swift echo update (0): a", "Swift echo update (1): b"])
+    XCTAssertEqual(offloadedOnEventLoop.load(), 0)
+  }
+}
@@ -226,7 +226,10 @@ class LengthPrefixedMessageReaderTests: GRPCTestCase {
 
     XCTAssertThrowsError(try self.reader.nextMessage()) { error in
       let errorWithContext = error as? GRPCError.WithContext
-      XCTAssertTrue(errorWithContext?.error is GRPCError.CompressionUnsupported)
+      let mismatch = errorWithContext?.error as? GRPCError.CompressedFlagMismatch
+      XCTAssertNotNil(mismatch)
+      XCTAssertNil(mismatch?.encoding)
+      XCTAssertEqual(mismatch?.makeGRPCStatus().code, .internalError)
     }
   }
 
@@ -240,6 +243,43 @@ class LengthPrefixedMessageReaderTests: GRPCTestCase {
     self.assertMessagesEqual(expected: self.twoByteMessage, actual: try self.reader.nextMessage())
   }
 
+  func testNextMessageThrowsWhenCompressionFlagIsSetForIdentityEncoding() throws {
+    self.reader = LengthPrefixedMessageReader(compression: .identity, decompressionLimit: .ratio(1))
+
+    var buffer = self
+      .byteBuffer(withBytes: self.lengthPrefixedTwoByteMessage(withCompression: true))
+    self.reader.append(buffer: &buffer)
+
+    XCTAssertThrowsError(try self.reader.nextMessage()) { error in
+      let errorWithContext = error as? GRPCError.WithContext
+      let mismatch = errorWithContext?.error as? GRPCError.CompressedFlagMismatch
+      XCTAssertEqual(mismatch?.encoding, "identity")
+      XCTAssertEqual(
+        mismatch?.makeGRPCStatus().message,
+        "Message has the compressed flag set but its declared encoding is 'identity'"
+      )
+    }
+  }
+
+  func testNextMessageThrowsWhenMessageLengthExceedsLimit() throws {
+    // Only the length-prefix is available: we should fail before any message bytes arrive.
+    let bytes: [UInt8] = [
+      0x00, // 1-byte compression flag
+      0x7F, 0xFF, 0xFF, 0xFF, // 4-byte message length
+    ]
+
+    var buffer = self.byteBuffer(withBytes: bytes)
+    self.reader.append(buffer: &buffer)
+
+    XCTAssertThrowsError(try self.reader.nextMessage(maxLength: 1024)) { error in
+      let errorWithContext = error as? GRPCError.WithContext
+      let limitExceeded = errorWithContext?.error as? GRPCError.PayloadLengthLimitExceeded
+      XCTAssertEqual(limitExceeded?.actualLength, 0x7FFF_FFFF)
+      XCTAssertEqual(limitExceeded?.limit, 1024)
+      XCTAssertEqual(limitExceeded?.makeGRPCStatus().code, .resourceExhausted)
+    }
+  }
+
   func testAppendReadsAllBytes() throws {
     var buffer = self.byteBuffer(withBytes: self.lengthPrefixedTwoByteMessage())
     self.reader.append(buffer: &buffer)
@@ -270,6 +310,104 @@ class LengthPrefixedMessageReaderTests: GRPCTestCase {
     XCTAssertEqual(self.reader.unprocessedBytes, 1024 + 5)
     XCTAssertEqual(self.reader._consumedNonDiscardedBytes, 0)
   }
+
+  // MARK: - Segmentation
+
+  /// Messages of various sizes, including empty messages, back-to-back.
+  private let segmentationMessages: [[UInt8]] = [
+    [],
+    [0x01],
+    [0x01, 0x02, 0x03, 0x04],
+    [],
+    Array(0 ..< 200),
+    [],
+  ]
+
+  private var segmentationBytes: [UInt8] {
+    return self.segmentationMessages.flatMap { message -> [UInt8] in
+      let length = UInt32(message.count)
+      return [
+        0x00,
+        UInt8(truncatingIfNeeded: length >> 24),
+        UInt8(truncatingIfNeeded: length >> 16),
+        UInt8(truncatingIfNeeded: length >> 8),
+        UInt8(truncatingIfNeeded: length),
+      ] + message
+    }
+  }
+
+  /// Appends each chunk to the reader in turn, reading as many messages as possible after each.
+  private func readMessages(appending chunks: [ArraySlice<UInt8>]) throws -> [[UInt8]] {
+    var messages: [[UInt8]] = []
+
+    for chunk in chunks {
+      var buffer = self.byteBuffer(withBytes: Array(chunk))
+      self.reader.append(buffer: &buffer)
+
+      while let message = try self.reader.nextMessage() {
+        messages.append(message.getBytes(at: message.readerIndex, length: message.readableBytes)!)
+      }
+    }
+
+    return messages
+  }
+
+  func testMessagesDeliveredOneByteAtATime() throws {
+    let bytes = self.segmentationBytes
+    let chunks = bytes.indices.map { bytes[$0 ..< $0 + 1] }
+
+    XCTAssertEqual(try self.readMessages(appending: chunks), self.segmentationMessages)
+    XCTAssertFalse(self.reader.isReading)
+    XCTAssertEqual(self.reader.unprocessedBytes, 0)
+  }
+
+  func testMessagesDeliveredWithEveryPartialLengthPrefix() throws {
+    let bytes = self.lengthPrefixedTwoByteMessage()
+
+    // Split after the flag and after each byte of the length prefix.
+    for split in 1 ... 5 {
+      self.reader = LengthPrefixedMessageReader()
+      let chunks = [bytes[..<split], bytes[split...]]
+      let messages = try self.readMessages(appending: chunks)
+      XCTAssertEqual(messages, [self.twoByteMessage], "split=\(split)")
+    }
+  }
+
+  func testMessagesDeliveredAtRandomSplitPoints() throws {
+    let bytes = self.segmentationBytes
+
+    for _ in 0 ..< 100 {
+      self.reader = LengthPrefixedMessageReader()
+
+      var chunks: [ArraySlice<UInt8>] = []
+      var start = bytes.startIndex
+      while start < bytes.endIndex {
+        let end = min(start + Int.random(in: 1 ... 16), bytes.endIndex)
+        chunks.append(bytes[start ..< end])
+        start = end
+      }
+
+      let splits = chunks.map { $0.count }
+      XCTAssertEqual(
+        try self.readMessages(appending: chunks),
+        self.segmentationMessages,
+        "chunk sizes: \(splits)"
+      )
+    }
+  }
+
+  func testCompressedZeroLengthMessage() throws {
+    self.reader = LengthPrefixedMessageReader(compression: .gzip, decompressionLimit: .ratio(1))
+    let bytes: [UInt8] = [
+      0x01, // 1-byte compression flag
+      0x00, 0x00, 0x00, 0x00, // 4-byte message length (0)
+    ]
+    var buffer = self.byteBuffer(withBytes: bytes)
+    self.reader.append(buffer: &buffer)
+
+    self.assertMessagesEqual(expected: [], actual: try self.reader.nextMessage())
+    XCTAssertNil(try self.reader.nextMessage())
+  }
 }
 
 extension LengthPrefixedMessageReader {
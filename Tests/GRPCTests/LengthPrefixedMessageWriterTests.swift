@@ -72,4 +72,45 @@ class LengthPrefixedMessageWriterTests: GRPCTestCase {
     XCTAssertNotNil(prefixed.readBytes(length: Int(size)))
     XCTAssertEqual(prefixed.readableBytes, 0)
   }
+
+  func testMessageBelowCompressionThresholdIsNotCompressed() throws {
+    let writer = LengthPrefixedMessageWriter(compression: .gzip, compressionThreshold: 100)
+    let allocator = ByteBufferAllocator()
+
+    let buffer = allocator.buffer(bytes: Array(repeating: 42, count: 99))
+    var prefixed = try writer.write(buffer: buffer, allocator: allocator)
+
+    XCTAssertEqual(prefixed.readInteger(as: UInt8.self), 0)
+    XCTAssertEqual(prefixed.readInteger(as: UInt32.self), 99)
+    XCTAssertEqual(prefixed.readBytes(length: 99), Array(repeating: 42, count: 99))
+    XCTAssertEqual(prefixed.readableBytes, 0)
+  }
+
+  func testMessageMeetingCompressionThresholdIsCompressed() throws {
+    let writer = LengthPrefixedMessageWriter(compression: .gzip, compressionThreshold: 100)
+    let allocator = ByteBufferAllocator()
+
+    let buffer = allocator.buffer(bytes: Array(repeating: 42, count: 100))
+    var prefixed = try writer.write(buffer: buffer, allocator: allocator)
+
+    XCTAssertEqual(prefixed.readInteger(as: UInt8.self), 1)
+    let size = prefixed.readInteger(as: UInt32.self)!
+    XCTAssertLessThan(size, 100)
+    XCTAssertNotNil(prefixed.readBytes(length: Int(size)))
+    XCTAssertEqual(prefixed.readableBytes, 0)
+  }
+
+  func testMessageWhichDoesNotShrinkIsNotCompressed() throws {
+    let writer = LengthPrefixedMessageWriter(compression: .gzip, compressionThreshold: 0)
+    let allocator = ByteBufferAllocator()
+
+    // Too short to benefit from compression: the gzip header alone is longer.
+    let buffer = allocator.buffer(bytes: [1, 2, 3])
+    var prefixed = try writer.write(buffer: buffer, allocator: allocator)
+
+    XCTAssertEqual(prefixed.readInteger(as: UInt8.self), 0)
+    XCTAssertEqual(prefixed.readInteger(as: UInt32.self), 3)
+    XCTAssertEqual(prefixed.readBytes(length: 3), [1, 2, 3])
+    XCTAssertEqual(prefixed.readableBytes, 0)
+  }
 }
@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class MessageTransferLimitsTests: EchoTestCaseBase {
+  override func serverBuilder() -> Server.Builder {
+    return super.serverBuilder()
+      // Each serialized request with 5 characters of text is 7 bytes.
+      .withTransferLimits(.init(maximumTotalRequestBytes: 10), forMethod: "/echo.Echo/Update")
+      // Each 'expand' response is 26 bytes.
+      .withTransferLimits(.init(maximumTotalResponseBytes: 60), forMethod: "/echo.Echo/Expand")
+  }
+
+  func testRequestsWithinLimitSucceed() throws {
+    let update = self.client.update { _ in }
+    XCTAssertNoThrow(try update.sendMessage(.with { $0.text = "12345" }).wait())
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertEqual(try update.status.wait().code, .ok)
+  }
+
+  func testRequestsExceedingLimitFail() throws {
+    let update = self.client.update { _ in }
+    update.sendMessage(.with { $0.text = "12345" }, promise: nil)
+    update.sendMessage(.with { $0.text = "67890" }, promise: nil)
+    update.sendEnd(promise: nil)
+    XCTAssertEqual(try update.status.wait().code, .resourceExhausted)
+  }
+
+  func testResponsesExceedingLimitFail() throws {
+    var responses: [Echo_EchoResponse] = []
+    let expand = self.client.expand(.with { $0.text = "a b c" }) {
+      responses.append($0)
+    }
+
+    XCTAssertEqual(try expand.status.wait().code, .resourceExhausted)
+    XCTAssertEqual(
+      responses.map { $0.text },
+      ["Swift echo expand (0): a", "Swift echo expand (1): b"]
+    )
+  }
+
+  func testMethodsWithoutLimitsAreUnaffected() throws {
+    let get = self.client.get(.with { $0.text = String(repeating: "a", count: 1024) })
+    XCTAssertEqual(try get.status.wait().code, .ok)
+  }
+}
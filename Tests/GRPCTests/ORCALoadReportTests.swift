@@ -0,0 +1,100 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import GRPC
+import NIO
+import SwiftProtobuf
+import XCTest
+
+class ORCALoadReportTests: GRPCTestCase {
+  func testDecodeFromTrailer() throws {
+    // cpu_utilization: 0.5, mem_utilization: 0.25, utilization: {"foo": 2.0}, rps: 10 and an
+    // unknown varint field (100).
+    let value = "CQAAAAAAAOA/EQAAAAAAANA/Kg4KA2ZvbxEAAAAAAAAAQBgKoAYB"
+    let report = try ORCALoadReport(trailerValue: value)
+
+    XCTAssertEqual(report.cpuUtilization, 0.5)
+    XCTAssertEqual(report.memoryUtilization, 0.25)
+    XCTAssertEqual(report.utilization, ["foo": 2.0])
+    XCTAssertEqual(report.requestsPerSecond, 10)
+    XCTAssertEqual(report.requestCost, [:])
+    XCTAssertEqual(report.namedMetrics, [:])
+  }
+
+  func testDecodeFromUnpaddedTrailer() throws {
+    // cpu_utilization: 0.5, rps: 10 ("CQAAAAAAAOA/GAo=" with padding).
+    let report = try ORCALoadReport(trailerValue: "CQAAAAAAAOA/GAo")
+    XCTAssertEqual(report.cpuUtilization, 0.5)
+    XCTAssertEqual(report.requestsPerSecond, 10)
+  }
+
+  func testDecodeInvalidTrailer() {
+    XCTAssertThrowsError(try ORCALoadReport(trailerValue: "not base64!"))
+    // A truncated double.
+    XCTAssertThrowsError(try ORCALoadReport(trailerValue: "CQAAAA"))
+  }
+
+  func testReportRoundTrip() throws {
+    let report = ORCALoadReport(
+      cpuUtilization: 0.1,
+      memoryUtilization: 0.2,
+      applicationUtilization: 0.3,
+      requestsPerSecond: 40.5,
+      errorsPerSecond: 1.5,
+      requestCost: ["a": 1],
+      utilization: ["b": 2, "c": 3],
+      namedMetrics: ["d": 4]
+    )
+
+    var buffer = ByteBufferAllocator().buffer(capacity: 0)
+    try report.serialize(into: &buffer)
+    XCTAssertEqual(try ORCALoadReport(serializedByteBuffer: &buffer), report)
+  }
+
+  func testRequestRoundTrip() throws {
+    let request = ORCALoadReportRequest(
+      reportInterval: .milliseconds(1500),
+      requestCostNames: ["foo", "bar"]
+    )
+
+    var buffer = ByteBufferAllocator().buffer(capacity: 0)
+    try request.serialize(into: &buffer)
+    XCTAssertEqual(try ORCALoadReportRequest(serializedByteBuffer: &buffer), request)
+  }
+
+  private func decodeRequest(reportInterval: Google_Protobuf_Duration) throws
+    -> ORCALoadReportRequest {
+    let duration = try reportInterval.serializedData()
+    var buffer = ByteBufferAllocator().buffer(capacity: duration.count + 2)
+    // 'report_interval' is field 1, a length-delimited message.
+    buffer.writeInteger(UInt8(0x0A))
+    buffer.writeInteger(UInt8(duration.count))
+    buffer.writeBytes(duration)
+    return try ORCALoadReportRequest(serializedByteBuffer: &buffer)
+  }
+
+  func testRequestWithOversizedReportIntervalIsClamped() throws {
+    let tooLong = try self.decodeRequest(reportInterval: .init(seconds: .max, nanos: 999_999_999))
+    XCTAssertEqual(tooLong.reportInterval, .nanoseconds(.max))
+
+    let tooShort = try self.decodeRequest(reportInterval: .init(seconds: .min, nanos: -999_999_999))
+    XCTAssertEqual(tooShort.reportInterval, .nanoseconds(.min))
+
+    // The seconds fit but adding the nanoseconds overflows.
+    let seconds = Int64.max / 1_000_000_000
+    let almost = try self.decodeRequest(reportInterval: .init(seconds: seconds, nanos: 999_999_999))
+    XCTAssertEqual(almost.reportInterval, .nanoseconds(.max))
+  }
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+import GRPC
+import NIO
+import SwiftProtobuf
+import XCTest
+
+class ProtobufSerializationTests: GRPCTestCase {
+  private func roundTrip<Message: SwiftProtobuf.Message>(_ message: Message) throws -> Message {
+    let serializer = ProtobufSerializer<Message>()
+    let deserializer = ProtobufDeserializer<Message>()
+    let buffer = try serializer.serialize(message, allocator: ByteBufferAllocator())
+    return try deserializer.deserialize(byteBuffer: buffer)
+  }
+
+  func testOneofSetToDefaultValueIsPreserved() throws {
+    let value = Google_Protobuf_Value.with { $0.stringValue = "" }
+    let decoded = try self.roundTrip(value)
+    XCTAssertEqual(decoded.kind, .stringValue(""))
+    XCTAssertEqual(decoded, value)
+  }
+
+  func testUnsetOneofIsPreserved() throws {
+    let decoded = try self.roundTrip(Google_Protobuf_Value())
+    XCTAssertNil(decoded.kind)
+  }
+
+  func testEmptyMessageFieldPresenceIsPreserved() throws {
+    let option = Google_Protobuf_Option.with {
+      $0.name = "foo"
+      $0.value = Google_Protobuf_Any()
+    }
+    let decoded = try self.roundTrip(option)
+    XCTAssertTrue(decoded.hasValue)
+
+    var unset = option
+    unset.clearValue()
+    XCTAssertFalse(try self.roundTrip(unset).hasValue)
+  }
+
+  func testWrapperWithDefaultValueIsPreserved() throws {
+    // A wrapper holding its default value is encoded as an empty message; presence is carried by
+    // the field holding the wrapper.
+    let wrapped = try Google_Protobuf_Any(message: Google_Protobuf_StringValue(""))
+    let option = Google_Protobuf_Option.with { $0.value = wrapped }
+
+    let decoded = try self.roundTrip(option)
+    XCTAssertTrue(decoded.hasValue)
+    XCTAssertEqual(try Google_Protobuf_StringValue(unpackingAny: decoded.value).value, "")
+  }
+
+  // A 'StringValue' of "foo" followed by an unknown varint field numbered 2 with value 1.
+  private let stringValueWithUnknownField: [UInt8] = [0x0A, 0x03, 0x66, 0x6F, 0x6F, 0x10, 0x01]
+
+  // An 'Option' named "foo" whose 'Any' value has an unknown varint field numbered 3.
+  private let optionWithNestedUnknownField: [UInt8] = [
+    0x0A, 0x03, 0x66, 0x6F, 0x6F, 0x12, 0x02, 0x18, 0x01,
+  ]
+
+  private func deserialize<Message: SwiftProtobuf.Message>(
+    _ bytes: [UInt8],
+    as: Message.Type = Message.self,
+    unknownFields: UnknownFieldHandling
+  ) throws -> Message {
+    let buffer = ByteBufferAllocator().buffer(bytes: bytes)
+    let deserializer = ProtobufDeserializer<Message>()
+    return try deserializer.deserialize(byteBuffer: buffer, unknownFields: unknownFields)
+  }
+
+  func testUnknownFieldsArePreserved() throws {
+    let value = try self.deserialize(
+      self.stringValueWithUnknownField,
+      as: Google_Protobuf_StringValue.self,
+      unknownFields: .preserve
+    )
+    XCTAssertEqual(value.value, "foo")
+    XCTAssertEqual(value.unknownFields.data, Data([0x10, 0x01]))
+  }
+
+  func testUnknownFieldsAreDropped() throws {
+    let value = try self.deserialize(
+      self.stringValueWithUnknownField,
+      as: Google_Protobuf_StringValue.self,
+      unknownFields: .drop
+    )
+    XCTAssertEqual(value.value, "foo")
+    XCTAssertTrue(value.unknownFields.data.isEmpty)
+
+    let option = try self.deserialize(
+      self.optionWithNestedUnknownField,
+      as: Google_Protobuf_Option.self,
+      unknownFields: .drop
+    )
+    XCTAssertEqual(option.name, "foo")
+    XCTAssertTrue(option.value.unknownFields.data.isEmpty)
+  }
+
+  func testUnknownFieldsAreRejected() throws {
+    XCTAssertThrowsError(
+      try self.deserialize(
+        self.stringValueWithUnknownField,
+        as: Google_Protobuf_StringValue.self,
+        unknownFields: .reject
+      )
+    ) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .invalidArgument)
+    }
+
+    XCTAssertThrowsError(
+      try self.deserialize(
+        self.optionWithNestedUnknownField,
+        as: Google_Protobuf_Option.self,
+        unknownFields: .reject
+      )
+    ) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .invalidArgument)
+    }
+  }
+
+  func testMessageWithoutUnknownFieldsIsNotRejected() throws {
+    let value = Google_Protobuf_StringValue("foo")
+    let bytes = try value.serializedData()
+    let decoded = try self.deserialize(
+      Array(bytes),
+      as: Google_Protobuf_StringValue.self,
+      unknownFields: .reject
+    )
+    XCTAssertEqual(decoded, value)
+  }
+}
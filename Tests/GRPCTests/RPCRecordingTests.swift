@@ -0,0 +1,198 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import Foundation
+import GRPC
+import NIOHPACK
+import XCTest
+
+class RPCRecordingTests: GRPCTestCase {
+  typealias Request = Echo_EchoRequest
+  typealias Response = Echo_EchoResponse
+
+  private var fakeChannel: FakeChannel!
+  private var recorder: RPCRecorder!
+
+  override func setUp() {
+    super.setUp()
+    self.fakeChannel = FakeChannel(logger: self.clientLogger)
+    self.recorder = RPCRecorder()
+  }
+
+  private func recordUnary(text: String, responseText: String) throws {
+    let response: FakeUnaryResponse<Request, Response> = self.fakeChannel.makeFakeUnaryResponse(
+      path: "/echo.Echo/Get",
+      requestHandler: { _ in }
+    )
+    try response.sendMessage(
+      .with { $0.text = responseText },
+      initialMetadata: ["initial": "foo"],
+      trailingMetadata: ["trailing": "bar"]
+    )
+
+    let call = self.fakeChannel.makeUnaryCall(
+      path: "/echo.Echo/Get",
+      request: Request.with { $0.text = text },
+      callOptions: self.callOptionsWithLogger,
+      interceptors: [self.recorder.makeInterceptor()]
+    )
+    XCTAssertEqual(try call.status.map { $0.code }.wait(), .ok)
+  }
+
+  private func recordCollect(texts: [String], responseText: String) throws {
+    let response: FakeUnaryResponse<Request, Response> = self.fakeChannel.makeFakeUnaryResponse(
+      path: "/echo.Echo/Collect",
+      requestHandler: { _ in }
+    )
+    try response.sendMessage(.with { $0.text = responseText })
+
+    let call: ClientStreamingCall<Request, Response> = self.fakeChannel.makeClientStreamingCall(
+      path: "/echo.Echo/Collect",
+      callOptions: self.callOptionsWithLogger,
+      interceptors: [self.recorder.makeInterceptor()]
+    )
+    for text in texts {
+      call.sendMessage(.with { $0.text = text }, promise: nil)
+    }
+    call.sendEnd(promise: nil)
+    XCTAssertEqual(try call.status.map { $0.code }.wait(), .ok)
+  }
+
+  func testRecordUnary() throws {
+    try self.recordUnary(text: "foo", responseText: "bar")
+
+    let recordings = self.recorder.recordings
+    XCTAssertEqual(recordings.count, 1)
+
+    let recording = try XCTUnwrap(recordings.first)
+    XCTAssertEqual(recording.path, "/echo.Echo/Get")
+    XCTAssertEqual(recording.requests, [try Request.with { $0.text = "foo" }.serializedData()])
+    XCTAssertEqual(recording.responses, [try Response.with { $0.text = "bar" }.serializedData()])
+    XCTAssertEqual(recording.initialMetadata, [.init(name: "initial", value: "foo")])
+    XCTAssertEqual(recording.trailingMetadata, [.init(name: "trailing", value: "bar")])
+    XCTAssertEqual(recording.status, RPCRecording.Status(.ok))
+  }
+
+  func testReplayUnary() throws {
+    try self.recordUnary(text: "foo", responseText: "bar")
+    try self.recordUnary(text: "baz", responseText: "qux")
+
+    let replay = RPCReplayChannel(recordings: self.recorder.recordings)
+    let call: UnaryCall<Request, Response> = replay.makeUnaryCall(
+      path: "/echo.Echo/Get",
+      request: .with { $0.text = "baz" },
+      callOptions: self.callOptionsWithLogger
+    )
+
+    XCTAssertEqual(try call.response.wait(), .with { $0.text = "qux" })
+    XCTAssertEqual(try call.initialMetadata.wait(), ["initial": "foo"])
+    XCTAssertEqual(try call.trailingMetadata.wait(), ["trailing": "bar"])
+    XCTAssertEqual(try call.status.map { $0.code }.wait(), .ok)
+  }
+
+  func testReplayClientStreaming() throws {
+    try self.recordCollect(texts: ["a", "b", "c"], responseText: "a b c")
+
+    let replay = RPCReplayChannel(recordings: self.recorder.recordings)
+    let call: ClientStreamingCall<Request, Response> = replay.makeClientStreamingCall(
+      path: "/echo.Echo/Collect",
+      callOptions: self.callOptionsWithLogger
+    )
+    for text in ["a", "b", "c"] {
+      call.sendMessage(.with { $0.text = text }, promise: nil)
+    }
+    call.sendEnd(promise: nil)
+
+    XCTAssertEqual(try call.response.wait(), .with { $0.text = "a b c" })
+    XCTAssertEqual(try call.status.map { $0.code }.wait(), .ok)
+  }
+
+  func testReplayServerStreamingWithStatus() throws {
+    let response: FakeStreamingResponse<Request, Response> = self.fakeChannel
+      .makeFakeStreamingResponse(path: "/echo.Echo/Expand", requestHandler: { _ in })
+    try response.sendMessage(.with { $0.text = "foo" })
+    try response.sendMessage(.with { $0.text = "bar" })
+    try response.sendEnd(
+      status: GRPCStatus(code: .notFound, message: "not found"),
+      trailingMetadata: ["trailing": "baz"]
+    )
+
+    let call: ServerStreamingCall<Request, Response> = self.fakeChannel.makeServerStreamingCall(
+      path: "/echo.Echo/Expand",
+      request: .with { $0.text = "foo bar" },
+      callOptions: self.callOptionsWithLogger,
+      interceptors: [self.recorder.makeInterceptor()],
+      handler: { _ in }
+    )
+    XCTAssertEqual(try call.status.map { $0.code }.wait(), .notFound)
+
+    var responses: [Response] = []
+    let replay = RPCReplayChannel(recordings: self.recorder.recordings)
+    let replayed: ServerStreamingCall<Request, Response> = replay.makeServerStreamingCall(
+      path: "/echo.Echo/Expand",
+      request: .with { $0.text = "foo bar" },
+      callOptions: self.callOptionsWithLogger,
+      handler: { responses.append($0) }
+    )
+
+    let status = try replayed.status.wait()
+    XCTAssertEqual(status.code, .notFound)
+    XCTAssertEqual(status.message, "not found")
+    XCTAssertEqual(try replayed.trailingMetadata.wait(), ["trailing": "baz"])
+    XCTAssertEqual(responses, [.with { $0.text = "foo" }, .with { $0.text = "bar" }])
+  }
+
+  func testReplayWithoutMatchingRecordingFails() throws {
+    try self.recordUnary(text: "foo", responseText: "bar")
+
+    let replay = RPCReplayChannel(recordings: self.recorder.recordings)
+    let call: UnaryCall<Request, Response> = replay.makeUnaryCall(
+      path: "/echo.Echo/Get",
+      request: .with { $0.text = "not recorded" },
+      callOptions: self.callOptionsWithLogger
+    )
+
+    XCTAssertThrowsError(try call.response.wait())
+    XCTAssertEqual(try call.status.map { $0.code }.wait(), .unavailable)
+  }
+
+  func testRecordingsAreSavedToFile() throws {
+    let path = NSTemporaryDirectory() + "grpc-recording-\(UUID().uuidString).json"
+    defer {
+      try? FileManager.default.removeItem(atPath: path)
+    }
+
+    try self.recordUnary(text: "foo", responseText: "bar")
+    try self.recordCollect(texts: ["a", "b"], responseText: "a b")
+    try self.recorder.save(to: path)
+
+    let data = try Data(contentsOf: URL(fileURLWithPath: path))
+    XCTAssertEqual(try RPCRecording.decode(data), self.recorder.recordings)
+
+    // Encoding is deterministic.
+    XCTAssertEqual(try RPCRecording.encode(self.recorder.recordings), data)
+
+    let replay = try RPCReplayChannel(contentsOfFile: path)
+    XCTAssertEqual(replay.recordings.count, 2)
+
+    let call: UnaryCall<Request, Response> = replay.makeUnaryCall(
+      path: "/echo.Echo/Get",
+      request: .with { $0.text = "foo" },
+      callOptions: self.callOptionsWithLogger
+    )
+    XCTAssertEqual(try call.response.wait(), .with { $0.text = "bar" })
+  }
+}
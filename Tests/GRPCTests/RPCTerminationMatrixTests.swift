@@ -0,0 +1,68 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import GRPCTestingSupport
+import XCTest
+
+extension RPCTerminationMatrix where Request == Echo_EchoRequest, Response == Echo_EchoResponse {
+  /// A matrix covering every method on the Echo service.
+  static func echo(client: Echo_EchoClient) -> RPCTerminationMatrix {
+    return RPCTerminationMatrix(
+      client: client,
+      request: .with { $0.text = "foo" },
+      unaryPath: "/echo.Echo/Get",
+      clientStreamingPath: "/echo.Echo/Collect",
+      serverStreamingPath: "/echo.Echo/Expand",
+      bidirectionalStreamingPath: "/echo.Echo/Update"
+    )
+  }
+}
+
+class RPCTerminationMatrixUnresponsiveServerTests: EchoTestCaseBase {
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return NeverResolvingEchoProvider()
+  }
+
+  func testDeadlineExceeded() {
+    let matrix = RPCTerminationMatrix.echo(client: self.client)
+    for outcome in matrix.run(.deadlineExceeded(.milliseconds(50))) {
+      XCTAssert(outcome.isExpected, "\(outcome)")
+    }
+  }
+
+  func testClientCancellation() {
+    let matrix = RPCTerminationMatrix.echo(client: self.client)
+    for outcome in matrix.run(.clientCancellation) {
+      XCTAssert(outcome.isExpected, "\(outcome)")
+    }
+  }
+}
+
+class RPCTerminationMatrixFailingServerTests: EchoTestCaseBase {
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return FailingEchoProvider()
+  }
+
+  func testServerTermination() {
+    let matrix = RPCTerminationMatrix.echo(client: self.client)
+    let outcomes = matrix.run(.serverTermination(.internalError))
+    XCTAssertEqual(outcomes.count, 4)
+    for outcome in outcomes {
+      XCTAssert(outcome.isExpected, "\(outcome)")
+    }
+  }
+}
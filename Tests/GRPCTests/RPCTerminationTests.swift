@@ -0,0 +1,118 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+@testable import GRPC
+import NIO
+import NIOHTTP2
+import XCTest
+
+class RPCTerminationTests: EchoTestCaseBase {
+  private func assertCancelled(
+    _ termination: RPCTermination,
+    reason expected: RPCTermination.CancellationReason,
+    file: StaticString = #file,
+    line: UInt = #line
+  ) {
+    guard case let .cancelled(reason) = termination else {
+      return XCTFail("Expected cancellation but got \(termination)", file: file, line: line)
+    }
+    XCTAssertEqual(reason, expected, file: file, line: line)
+  }
+
+  private func assertTransportFailure(
+    _ termination: RPCTermination,
+    file: StaticString = #file,
+    line: UInt = #line
+  ) {
+    guard case .transportFailure = termination else {
+      return XCTFail("Expected transport failure but got \(termination)", file: file, line: line)
+    }
+  }
+
+  func testClassifyingErrors() {
+    let streamID = HTTP2StreamID(1)
+    self.assertCancelled(
+      RPCTermination(error: GRPCError.RPCCancelledByClient()),
+      reason: .cancelledByClient
+    )
+    self.assertCancelled(
+      RPCTermination(error: GRPCError.RPCIdleTimedOut(.seconds(1))),
+      reason: .responseIdleTimeout
+    )
+    self.assertCancelled(
+      RPCTermination(error: GRPCError.RPCResponseHeadersTimedOut(.seconds(1))),
+      reason: .responseHeadersTimeout
+    )
+    self.assertCancelled(
+      RPCTermination(error: GRPCError.RPCWriteTimedOut(.seconds(1))),
+      reason: .writeTimeout
+    )
+    self.assertCancelled(
+      RPCTermination(error: NIOHTTP2Errors.StreamClosed(streamID: streamID, errorCode: .cancel)),
+      reason: .streamReset
+    )
+
+    guard case .deadlineExceeded = RPCTermination(error: GRPCError.RPCTimedOut(.none)) else {
+      return XCTFail("Expected deadline exceeded")
+    }
+
+    let reset = NIOHTTP2Errors.StreamClosed(streamID: streamID, errorCode: .protocolError)
+    self.assertTransportFailure(RPCTermination(error: reset))
+    self.assertTransportFailure(RPCTermination(error: ChannelError.ioOnClosedChannel))
+    self.assertTransportFailure(
+      RPCTermination(error: ConnectionFailure(reason: ChannelError.connectTimeout(.seconds(1))))
+    )
+    self.assertTransportFailure(
+      RPCTermination(error: GRPCStatus(code: .unavailable, message: "Transport became inactive"))
+    )
+
+    guard case .failed = RPCTermination(error: GRPCError.DeserializationFailure()) else {
+      return XCTFail("Expected failure")
+    }
+  }
+
+  func testErrorContextIsRemoved() {
+    let error = GRPCError.RPCTimedOut(.none).captureContext()
+    guard case .deadlineExceeded = RPCTermination(error: error) else {
+      return XCTFail("Expected deadline exceeded")
+    }
+  }
+
+  func testCompletedWithStatusFromServer() throws {
+    let call = self.client.get(.with { $0.text = "hello" })
+    guard case let .completed(status) = try call.termination.wait() else {
+      return XCTFail("Expected the RPC to complete")
+    }
+    XCTAssertEqual(status.code, .ok)
+  }
+
+  func testCancelledByClient() throws {
+    let call = self.client.update { _ in }
+    call.cancel(promise: nil)
+
+    self.assertCancelled(try call.termination.wait(), reason: .cancelledByClient)
+    XCTAssertEqual(try call.status.wait().code, .cancelled)
+  }
+
+  func testDeadlineExceeded() throws {
+    let options = CallOptions(timeLimit: .timeout(.milliseconds(1)))
+    let call = self.client.update(callOptions: options) { _ in }
+
+    guard case .deadlineExceeded = try call.termination.wait() else {
+      return XCTFail("Expected deadline exceeded")
+    }
+  }
+}
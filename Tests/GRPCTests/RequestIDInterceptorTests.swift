@@ -0,0 +1,143 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+class RequestIDInterceptorTests: GRPCTestCase {
+  private var eventLoop: EmbeddedEventLoop!
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = EmbeddedEventLoop()
+  }
+
+  private func makeClientPipeline(
+    interceptor: RequestIDClientInterceptor<String, String>,
+    onRequestPart: @escaping (GRPCClientRequestPart<String>) -> Void
+  ) -> ClientInterceptorPipeline<String, String> {
+    let details = CallDetails(
+      type: .unary,
+      path: "/foo/bar",
+      authority: "ignored",
+      scheme: "ignored",
+      options: CallOptions(logger: self.clientLogger)
+    )
+
+    return ClientInterceptorPipeline(
+      eventLoop: self.eventLoop,
+      details: details,
+      logger: details.options.logger.wrapped,
+      interceptors: [interceptor],
+      errorDelegate: nil,
+      onError: { _ in },
+      onCancel: { _ in },
+      onRequestPart: { part, _ in onRequestPart(part) },
+      onResponsePart: { _ in }
+    )
+  }
+
+  private func makeServerPipeline(
+    interceptor: RequestIDServerInterceptor<String, String>,
+    userInfoRef: Ref<UserInfo>,
+    onRequestPart: @escaping (GRPCServerRequestPart<String>) -> Void,
+    onResponsePart: @escaping (GRPCServerResponsePart<String>) -> Void
+  ) -> ServerInterceptorPipeline<String, String> {
+    return ServerInterceptorPipeline(
+      logger: self.serverLogger,
+      eventLoop: self.eventLoop,
+      path: "/foo/bar",
+      callType: .unary,
+      remoteAddress: nil,
+      userInfoRef: userInfoRef,
+      interceptors: [interceptor],
+      onRequestPart: onRequestPart,
+      onResponsePart: { part, _ in onResponsePart(part) }
+    )
+  }
+
+  func testClientAddsRequestIDIfAbsent() {
+    var requestParts: [GRPCClientRequestPart<String>] = []
+    let interceptor = RequestIDClientInterceptor<String, String>(generateID: { "generated" })
+    let pipeline = self.makeClientPipeline(interceptor: interceptor) {
+      requestParts.append($0)
+    }
+
+    pipeline.send(.metadata([:]), promise: nil)
+    assertThat(requestParts, .hasCount(1))
+    assertThat(requestParts[0].metadata?.first(name: "x-request-id"), .is("generated"))
+  }
+
+  func testClientKeepsExistingRequestID() {
+    var requestParts: [GRPCClientRequestPart<String>] = []
+    let interceptor = RequestIDClientInterceptor<String, String>(
+      headerName: "X-Correlation-ID",
+      generateID: { "generated" }
+    )
+    let pipeline = self.makeClientPipeline(interceptor: interceptor) {
+      requestParts.append($0)
+    }
+
+    pipeline.send(.metadata(["x-correlation-id": "existing"]), promise: nil)
+    assertThat(requestParts, .hasCount(1))
+    assertThat(requestParts[0].metadata?.first(name: "x-correlation-id"), .is("existing"))
+  }
+
+  func testServerReadsAndEchoesRequestID() {
+    var requestParts: [GRPCServerRequestPart<String>] = []
+    var responseParts: [GRPCServerResponsePart<String>] = []
+    let userInfoRef = Ref(UserInfo())
+
+    let pipeline = self.makeServerPipeline(
+      interceptor: RequestIDServerInterceptor(generateID: { "generated" }),
+      userInfoRef: userInfoRef,
+      onRequestPart: { requestParts.append($0) },
+      onResponsePart: { responseParts.append($0) }
+    )
+
+    pipeline.receive(.metadata(["x-request-id": "from-client"]))
+    assertThat(userInfoRef.value.requestID, .is("from-client"))
+
+    pipeline.send(.end(.ok, [:]), promise: nil)
+    assertThat(responseParts, .hasCount(1))
+    assertThat(responseParts[0].end?.1.first(name: "x-request-id"), .is("from-client"))
+  }
+
+  func testServerGeneratesRequestIDIfAbsent() {
+    var requestParts: [GRPCServerRequestPart<String>] = []
+    var responseParts: [GRPCServerResponsePart<String>] = []
+    let userInfoRef = Ref(UserInfo())
+
+    let pipeline = self.makeServerPipeline(
+      interceptor: RequestIDServerInterceptor(generateID: { "generated" }),
+      userInfoRef: userInfoRef,
+      onRequestPart: { requestParts.append($0) },
+      onResponsePart: { responseParts.append($0) }
+    )
+
+    pipeline.receive(.metadata([:]))
+    assertThat(userInfoRef.value.requestID, .is("generated"))
+    // The handler should see the same ID.
+    assertThat(requestParts, .hasCount(1))
+    assertThat(requestParts[0].metadata?.first(name: "x-request-id"), .is("generated"))
+
+    pipeline.send(.metadata([:]), promise: nil)
+    pipeline.send(.end(.ok, [:]), promise: nil)
+    assertThat(responseParts, .hasCount(2))
+    assertThat(responseParts[1].end?.1.first(name: "x-request-id"), .is("generated"))
+  }
+}
@@ -0,0 +1,141 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+class RequiredMetadataServerInterceptorTests: GRPCTestCase {
+  private var eventLoop: EmbeddedEventLoop!
+  private var requestParts: [GRPCServerRequestPart<String>] = []
+  private var responseParts: [GRPCServerResponsePart<String>] = []
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = EmbeddedEventLoop()
+    self.requestParts = []
+    self.responseParts = []
+  }
+
+  private func makePipeline(
+    path: String = "/foo/bar",
+    requirements: [ServerMetadataRequirement]
+  ) -> ServerInterceptorPipeline<String, String> {
+    return ServerInterceptorPipeline(
+      logger: self.logger,
+      eventLoop: self.eventLoop,
+      path: path,
+      callType: .unary,
+      remoteAddress: nil,
+      userInfoRef: Ref(UserInfo()),
+      interceptors: [RequiredMetadataServerInterceptor(requirements: requirements)],
+      onRequestPart: { self.requestParts.append($0) },
+      onResponsePart: { part, _ in self.responseParts.append(part) }
+    )
+  }
+
+  func testAllRequirementsMet() {
+    let pipeline = self.makePipeline(requirements: [
+      .authorization(),
+      ServerMetadataRequirement(name: "x-tenant-id"),
+    ])
+
+    pipeline.receive(.metadata(["authorization": "Bearer abc", "X-Tenant-ID": "tenant"]))
+    pipeline.receive(.message("foo"))
+    pipeline.receive(.end)
+
+    assertThat(self.requestParts, .hasCount(3))
+    assertThat(self.responseParts, .isEmpty())
+  }
+
+  func testMissingRequiredMetadata() {
+    let pipeline = self.makePipeline(requirements: [ServerMetadataRequirement(name: "x-tenant-id")])
+
+    pipeline.receive(.metadata([:]))
+    pipeline.receive(.message("foo"))
+    pipeline.receive(.end)
+
+    // Nothing should reach the handler.
+    assertThat(self.requestParts, .isEmpty())
+    assertThat(self.responseParts, .hasCount(1))
+    assertThat(self.responseParts[0], .is(.end(status: .hasCode(.invalidArgument))))
+  }
+
+  func testInvalidAuthorization() {
+    let pipeline = self.makePipeline(requirements: [.authorization()])
+
+    pipeline.receive(.metadata(["authorization": "Basic abc"]))
+
+    assertThat(self.requestParts, .isEmpty())
+    assertThat(self.responseParts, .hasCount(1))
+    assertThat(self.responseParts[0], .is(.end(status: .hasCode(.unauthenticated))))
+  }
+
+  func testEmptyBearerToken() {
+    let pipeline = self.makePipeline(requirements: [.authorization()])
+
+    pipeline.receive(.metadata(["authorization": "Bearer "]))
+
+    assertThat(self.requestParts, .isEmpty())
+    assertThat(self.responseParts[0], .is(.end(status: .hasCode(.unauthenticated))))
+  }
+
+  func testWhitespaceBearerToken() {
+    let pipeline = self.makePipeline(requirements: [.authorization()])
+
+    pipeline.receive(.metadata(["authorization": "Bearer  \t "]))
+
+    assertThat(self.requestParts, .isEmpty())
+    assertThat(self.responseParts[0], .is(.end(status: .hasCode(.unauthenticated))))
+  }
+
+  func testBearerTokenSurroundedByWhitespace() {
+    let pipeline = self.makePipeline(requirements: [.authorization()])
+
+    pipeline.receive(.metadata(["authorization": "Bearer  abc "]))
+
+    assertThat(self.requestParts, .hasCount(1))
+    assertThat(self.responseParts, .isEmpty())
+  }
+
+  func testRequirementDoesNotApplyToOtherPaths() {
+    let pipeline = self.makePipeline(
+      path: "/foo/baz",
+      requirements: [ServerMetadataRequirement(name: "x-tenant-id", paths: ["/foo/bar"])]
+    )
+
+    pipeline.receive(.metadata([:]))
+    pipeline.receive(.message("foo"))
+    pipeline.receive(.end)
+
+    assertThat(self.requestParts, .hasCount(3))
+    assertThat(self.responseParts, .isEmpty())
+  }
+
+  func testCustomValidation() {
+    let requirement = ServerMetadataRequirement(
+      name: "x-tenant-id",
+      statusCode: .permissionDenied,
+      validate: { $0.hasPrefix("tenant-") }
+    )
+    let pipeline = self.makePipeline(requirements: [requirement])
+
+    pipeline.receive(.metadata(["x-tenant-id": "someone-else"]))
+
+    assertThat(self.requestParts, .isEmpty())
+    assertThat(self.responseParts[0], .is(.end(status: .hasCode(.permissionDenied))))
+  }
+}
@@ -0,0 +1,189 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import Logging
+import NIO
+import XCTest
+
+/// A call context which records responses and completes their writes either immediately or when
+/// asked to.
+private final class RecordingCallContext: StreamingResponseCallContext<Int> {
+  private let completesWritesImmediately: Bool
+  private var pendingWrites: CircularBuffer<EventLoopPromise<Void>?> = []
+  private(set) var responses: [Int] = []
+  private(set) var flushes = 0
+
+  init(eventLoop: EventLoop, logger: Logger, completesWritesImmediately: Bool) {
+    self.completesWritesImmediately = completesWritesImmediately
+    super.init(
+      eventLoop: eventLoop,
+      headers: [:],
+      logger: logger,
+      userInfoRef: Ref(UserInfo()),
+      closeFuture: eventLoop.makePromise(of: Void.self).futureResult,
+      streamID: nil,
+      connection: nil
+    )
+  }
+
+  override func sendResponse(
+    _ message: Int,
+    compression: Compression = .deferToCallDefault,
+    promise: EventLoopPromise<Void>?
+  ) {
+    self.responses.append(message)
+    if self.completesWritesImmediately {
+      promise?.succeed(())
+    } else {
+      self.pendingWrites.append(promise)
+    }
+  }
+
+  override func flush() {
+    self.flushes += 1
+  }
+
+  func completePendingWrites() {
+    // Completing a write may trigger another, keep going until there are none left.
+    while let promise = self.pendingWrites.popFirst() {
+      promise?.succeed(())
+    }
+  }
+}
+
+class ResponseBroadcasterTests: GRPCTestCase {
+  private let eventLoop = EmbeddedEventLoop()
+
+  private func makeContext(completesWritesImmediately: Bool = true) -> RecordingCallContext {
+    return RecordingCallContext(
+      eventLoop: self.eventLoop,
+      logger: self.logger,
+      completesWritesImmediately: completesWritesImmediately
+    )
+  }
+
+  func testSubscribersReceivePublishedResponses() throws {
+    let broadcaster = try ResponseBroadcaster<Int>()
+    let fast = self.makeContext()
+    let manual = self.makeContext(completesWritesImmediately: false)
+
+    let fastStatus = broadcaster.subscribe(fast)
+    let manualStatus = broadcaster.subscribe(manual)
+    XCTAssertEqual(broadcaster.subscriberCount, 2)
+
+    broadcaster.publish(1)
+    broadcaster.publish(2)
+    broadcaster.finish()
+    self.eventLoop.run()
+
+    XCTAssertEqual(fast.responses, [1, 2])
+    XCTAssertEqual(try fastStatus.wait().code, .ok)
+
+    // Only one write is in flight at a time and the status waits for the buffer to drain.
+    XCTAssertEqual(manual.responses, [1])
+    manual.completePendingWrites()
+    XCTAssertEqual(manual.responses, [1, 2])
+    manual.completePendingWrites()
+    XCTAssertEqual(try manualStatus.wait().code, .ok)
+    XCTAssertEqual(broadcaster.subscriberCount, 0)
+  }
+
+  func testSlowSubscriberDropsOldestResponses() throws {
+    let broadcaster = try ResponseBroadcaster<Int>(maximumBufferedResponses: 2)
+    let fast = self.makeContext()
+    let slow = self.makeContext(completesWritesImmediately: false)
+    _ = broadcaster.subscribe(fast)
+    _ = broadcaster.subscribe(slow)
+
+    for value in 1 ... 5 {
+      broadcaster.publish(value)
+    }
+    self.eventLoop.run()
+
+    // The slow subscriber doesn't hold up the fast one.
+    XCTAssertEqual(fast.responses, [1, 2, 3, 4, 5])
+
+    // '1' was written immediately, '2' and '3' were dropped from the full buffer.
+    slow.completePendingWrites()
+    XCTAssertEqual(slow.responses, [1, 4, 5])
+
+    broadcaster.finish()
+    self.eventLoop.run()
+  }
+
+  func testSlowSubscriberIsClosed() throws {
+    let broadcaster = try ResponseBroadcaster<Int>(
+      maximumBufferedResponses: 1,
+      slowSubscriberPolicy: .close
+    )
+    let fast = self.makeContext()
+    let slow = self.makeContext(completesWritesImmediately: false)
+    let fastStatus = broadcaster.subscribe(fast)
+    let slowStatus = broadcaster.subscribe(slow)
+
+    for value in 1 ... 3 {
+      broadcaster.publish(value)
+    }
+    self.eventLoop.run()
+
+    XCTAssertEqual(try slowStatus.wait().code, .resourceExhausted)
+    XCTAssertEqual(broadcaster.subscriberCount, 1)
+    slow.completePendingWrites()
+    XCTAssertEqual(slow.responses, [1])
+
+    broadcaster.publish(4)
+    broadcaster.finish()
+    self.eventLoop.run()
+    XCTAssertEqual(fast.responses, [1, 2, 3, 4])
+    XCTAssertEqual(try fastStatus.wait().code, .ok)
+  }
+
+  func testSubscribingAfterFinishing() throws {
+    let broadcaster = try ResponseBroadcaster<Int>()
+    broadcaster.finish(status: GRPCStatus(code: .unavailable, message: nil))
+
+    let context = self.makeContext()
+    let status = broadcaster.subscribe(context)
+    XCTAssertEqual(try status.wait().code, .unavailable)
+    XCTAssertEqual(broadcaster.subscriberCount, 0)
+  }
+
+  func testInvalidBufferSizeThrows() {
+    XCTAssertThrowsError(try ResponseBroadcaster<Int>(maximumBufferedResponses: 0)) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+  }
+
+  func testResponsesAreFlushedWhenFlushingManually() throws {
+    let broadcaster = try ResponseBroadcaster<Int>()
+    let automatic = self.makeContext()
+    let manual = self.makeContext()
+    manual.flushesResponsesAutomatically = false
+
+    _ = broadcaster.subscribe(automatic)
+    _ = broadcaster.subscribe(manual)
+    broadcaster.publish(1)
+    broadcaster.publish(2)
+    self.eventLoop.run()
+
+    XCTAssertEqual(automatic.flushes, 0)
+    XCTAssertEqual(manual.flushes, 2)
+    XCTAssertEqual(manual.responses, [1, 2])
+
+    broadcaster.finish()
+    self.eventLoop.run()
+  }
+}
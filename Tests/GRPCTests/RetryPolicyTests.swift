@@ -0,0 +1,157 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+@testable import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+class RetryPolicyTests: GRPCTestCase {
+  private func delay(
+    _ policy: RetryPolicy,
+    afterAttempt attempt: Int = 1,
+    code: GRPCStatus.Code = .unavailable,
+    trailers: HPACKHeaders = [:]
+  ) -> TimeAmount? {
+    return policy.delayBeforeRetrying(
+      afterAttempt: attempt,
+      status: GRPCStatus(code: code, message: nil),
+      trailers: trailers
+    )
+  }
+
+  func testRetryableStatusCodes() {
+    let policy = RetryPolicy(retryableStatusCodes: [.unavailable])
+    XCTAssertNotNil(self.delay(policy, code: .unavailable))
+    XCTAssertNil(self.delay(policy, code: .unknown))
+  }
+
+  func testMaximumAttempts() {
+    let policy = RetryPolicy(maximumAttempts: 3)
+    XCTAssertNotNil(self.delay(policy, afterAttempt: 1))
+    XCTAssertNotNil(self.delay(policy, afterAttempt: 2))
+    XCTAssertNil(self.delay(policy, afterAttempt: 3))
+
+    // Nothing is retried if only a single attempt is allowed.
+    XCTAssertNil(self.delay(RetryPolicy(maximumAttempts: 0)))
+  }
+
+  func testBackoffIsBounded() {
+    let policy = RetryPolicy(
+      initialBackoff: .milliseconds(100),
+      maximumBackoff: .milliseconds(300),
+      backoffMultiplier: 2
+    )
+
+    for _ in 0 ..< 100 {
+      XCTAssertLessThanOrEqual(policy.backoff(afterAttempt: 1), .milliseconds(100))
+      XCTAssertLessThanOrEqual(policy.backoff(afterAttempt: 2), .milliseconds(200))
+      XCTAssertLessThanOrEqual(policy.backoff(afterAttempt: 10), .milliseconds(300))
+    }
+  }
+
+  func testServerRetryDelayReplacesBackoff() throws {
+    let details = GRPCStatusDetails(code: .unavailable, retryDelay: .seconds(42))
+    let trailers: HPACKHeaders = [GRPCStatusDetails.trailerName: try details.makeTrailerValue()]
+
+    let policy = RetryPolicy(maximumBackoff: .seconds(1))
+    XCTAssertEqual(self.delay(policy, trailers: trailers), .seconds(42))
+
+    // An explicit delay from the decider takes precedence.
+    let decided = RetryPolicy { _, _, _ in .retry(after: .seconds(2)) }
+    XCTAssertEqual(self.delay(decided, trailers: trailers), .seconds(2))
+
+    // The server can't make the policy retry a status it wouldn't otherwise.
+    XCTAssertNil(self.delay(policy, code: .invalidArgument, trailers: trailers))
+  }
+
+  func testDeciderOverridesRetryableStatusCodes() {
+    var decisions: [(GRPCStatus.Code, String?, Int)] = []
+    let policy = RetryPolicy(retryableStatusCodes: []) { status, trailers, attempt in
+      let isTransient = trailers.first(name: "x-transient")
+      decisions.append((status.code, isTransient, attempt))
+      return isTransient == "true" ? .retry(after: .seconds(1)) : .doNotRetry
+    }
+
+    let transient: HPACKHeaders = ["x-transient": "true"]
+    XCTAssertEqual(self.delay(policy, code: .unknown, trailers: transient), .seconds(1))
+    XCTAssertNil(self.delay(policy, afterAttempt: 2, code: .unknown))
+
+    XCTAssertEqual(decisions.map { $0.0 }, [.unknown, .unknown])
+    XCTAssertEqual(decisions.map { $0.1 }, ["true", nil])
+    XCTAssertEqual(decisions.map { $0.2 }, [1, 2])
+  }
+
+  func testDeciderCantExceedMaximumAttempts() {
+    let policy = RetryPolicy(maximumAttempts: 2) { _, _, _ in .retry }
+    XCTAssertNotNil(self.delay(policy, afterAttempt: 1))
+    XCTAssertNil(self.delay(policy, afterAttempt: 2))
+  }
+
+  func testThrottle() {
+    let throttle = RetryThrottle(maximumTokens: 4, tokenRatio: 1)
+    let policy = RetryPolicy(maximumAttempts: 10, throttle: throttle)
+
+    // 4 -> 3 tokens: still more than half.
+    XCTAssertNotNil(self.delay(policy))
+    // 3 -> 2 tokens: no longer more than half.
+    XCTAssertNil(self.delay(policy))
+    XCTAssertFalse(throttle.isRetryPermitted)
+
+    // Non-retryable failures don't count against the throttle.
+    throttle.recordSuccess()
+    XCTAssertNil(self.delay(policy, code: .invalidArgument))
+    XCTAssertTrue(throttle.isRetryPermitted)
+  }
+}
+
+class RetryPolicyEchoTests: EchoTestCaseBase {
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return FailingEchoProvider()
+  }
+
+  func testFailedRPCIsRetriedUpToMaximumAttempts() {
+    let policy = RetryPolicy(
+      maximumAttempts: 3,
+      initialBackoff: .milliseconds(1),
+      retryableStatusCodes: [.internalError]
+    )
+
+    var attempts = 0
+    let response = policy.retrying { () -> UnaryCall<Echo_EchoRequest, Echo_EchoResponse> in
+      attempts += 1
+      return self.client.get(.with { $0.text = "foo" })
+    }
+
+    XCTAssertThrowsError(try response.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatusTransformable)?.makeGRPCStatus().code, .internalError)
+    }
+    XCTAssertEqual(attempts, 3)
+  }
+
+  func testNonRetryableFailureIsNotRetried() {
+    let policy = RetryPolicy(maximumAttempts: 3, retryableStatusCodes: [.unavailable])
+
+    var attempts = 0
+    let response = policy.retrying { () -> UnaryCall<Echo_EchoRequest, Echo_EchoResponse> in
+      attempts += 1
+      return self.client.get(.with { $0.text = "foo" })
+    }
+
+    XCTAssertThrowsError(try response.wait())
+    XCTAssertEqual(attempts, 1)
+  }
+}
@@ -0,0 +1,42 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class RoundTripTimeTests: EchoTestCaseBase {
+  private var connection: ClientConnection {
+    return self.client.channel as! ClientConnection
+  }
+
+  func testMeasureRoundTripTimeOnReadyConnection() throws {
+    // Make an RPC so that the connection is ready.
+    let get = self.client.get(.with { $0.text = "foo" })
+    XCTAssertNoThrow(try get.response.wait())
+
+    let roundTripTime = try self.connection.measureRoundTripTime().wait()
+    XCTAssertGreaterThanOrEqual(roundTripTime, .nanoseconds(0))
+  }
+
+  func testMeasureRoundTripTimeFailsWithoutConnection() throws {
+    XCTAssertNoThrow(try self.connection.close().wait())
+
+    XCTAssertThrowsError(try self.connection.measureRoundTripTime().wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .unavailable)
+    }
+  }
+}
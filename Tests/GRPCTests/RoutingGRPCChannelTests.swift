@@ -0,0 +1,294 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+class RoutingGRPCChannelTests: EchoTestCaseBase {
+  private var primary: ClientConnection!
+  private var secondary: ClientConnection!
+
+  override func setUp() {
+    super.setUp()
+    self.primary = try! self.makeClientConnection(port: self.port)
+    self.secondary = try! self.makeClientConnection(port: self.port)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.primary.close().wait())
+    XCTAssertNoThrow(try self.secondary.close().wait())
+    super.tearDown()
+  }
+
+  private func makeRoutingChannel(clock: GRPCClock = .system) -> RoutingGRPCChannel {
+    return try! RoutingGRPCChannel(
+      channels: ["primary": self.primary, "secondary": self.secondary],
+      defaultChannel: "primary",
+      clock: clock
+    ) { _, callOptions in
+      callOptions.customMetadata.first(name: "x-route")
+    }
+  }
+
+  private func makeRoutingClient() -> Echo_EchoClient {
+    return Echo_EchoClient(channel: self.makeRoutingChannel())
+  }
+
+  func testRoutesUsingMetadata() throws {
+    let client = self.makeRoutingClient()
+    let options = CallOptions(customMetadata: ["x-route": "secondary"])
+
+    let get = client.get(.with { $0.text = "foo" }, callOptions: options)
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+
+    XCTAssertEqual(self.primary.connectivity.state, .idle)
+    XCTAssertEqual(self.secondary.connectivity.state, .ready)
+  }
+
+  func testRoutesToDefaultChannel() throws {
+    let client = self.makeRoutingClient()
+    let options = CallOptions(customMetadata: ["x-route": "does-not-exist"])
+
+    let get = client.get(.with { $0.text = "foo" }, callOptions: options)
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+
+    XCTAssertEqual(self.primary.connectivity.state, .ready)
+    XCTAssertEqual(self.secondary.connectivity.state, .idle)
+  }
+
+  func testCloseClosesAllChannels() throws {
+    let client = self.makeRoutingClient()
+    XCTAssertNoThrow(try client.channel.close().wait())
+
+    XCTAssertEqual(self.primary.connectivity.state, .shutdown)
+    XCTAssertEqual(self.secondary.connectivity.state, .shutdown)
+  }
+
+  func testUnhealthyChannelIsNotUsedForNewRPCs() throws {
+    let channel = self.makeRoutingChannel()
+    let client = Echo_EchoClient(channel: channel)
+    let options = CallOptions(customMetadata: ["x-route": "secondary"])
+
+    channel.markUnhealthy("secondary", for: .hours(1))
+    XCTAssertFalse(channel.isHealthy("secondary"))
+
+    let get = client.get(.with { $0.text = "foo" }, callOptions: options)
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+
+    XCTAssertEqual(self.primary.connectivity.state, .ready)
+    XCTAssertEqual(self.secondary.connectivity.state, .idle)
+  }
+
+  func testUnhealthyDefaultChannelFallsBackToHealthyChannel() throws {
+    let channel = self.makeRoutingChannel()
+    let client = Echo_EchoClient(channel: channel)
+
+    channel.markUnhealthy("primary", for: .hours(1))
+
+    let get = client.get(.with { $0.text = "foo" })
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+
+    XCTAssertEqual(self.primary.connectivity.state, .idle)
+    XCTAssertEqual(self.secondary.connectivity.state, .ready)
+  }
+
+  func testAllChannelsUnhealthyRoutesAsNormal() throws {
+    let channel = self.makeRoutingChannel()
+    let client = Echo_EchoClient(channel: channel)
+    let options = CallOptions(customMetadata: ["x-route": "secondary"])
+
+    channel.markUnhealthy("primary", for: .hours(1))
+    channel.markUnhealthy("secondary", for: .hours(1))
+
+    let get = client.get(.with { $0.text = "foo" }, callOptions: options)
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+
+    XCTAssertEqual(self.primary.connectivity.state, .idle)
+    XCTAssertEqual(self.secondary.connectivity.state, .ready)
+  }
+
+  func testMarkHealthy() throws {
+    let channel = self.makeRoutingChannel()
+    channel.markUnhealthy("secondary", for: .hours(1))
+    XCTAssertFalse(channel.isHealthy("secondary"))
+    channel.markHealthy("secondary")
+    XCTAssertTrue(channel.isHealthy("secondary"))
+  }
+
+  func testUnknownChannelNamesAreIgnored() throws {
+    let channel = self.makeRoutingChannel()
+    channel.markUnhealthy("does-not-exist", for: .hours(1))
+    XCTAssertTrue(channel.isHealthy("does-not-exist"))
+    channel.markHealthy("does-not-exist")
+    XCTAssertTrue(channel.isHealthy("does-not-exist"))
+  }
+
+  func testMissingDefaultChannelIsRejected() throws {
+    XCTAssertThrowsError(
+      try RoutingGRPCChannel(channels: ["primary": self.primary], defaultChannel: "default") {
+        _, _ in nil
+      }
+    ) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+  }
+
+  func testChannelIsHealthyAfterCooldown() throws {
+    var now = NIODeadline.uptimeNanoseconds(0)
+    let channel = self.makeRoutingChannel(clock: GRPCClock { now })
+
+    channel.markUnhealthy("secondary", for: .milliseconds(10))
+    now = now + .milliseconds(9)
+    XCTAssertFalse(channel.isHealthy("secondary"))
+    now = now + .milliseconds(1)
+    XCTAssertTrue(channel.isHealthy("secondary"))
+  }
+
+  func testUnhealthyConnectionIsDrainedAndReestablishedAfterCooldown() throws {
+    var now = NIODeadline.uptimeNanoseconds(0)
+    let channel = self.makeRoutingChannel(clock: GRPCClock { now })
+    let client = Echo_EchoClient(channel: channel)
+    let options = CallOptions(customMetadata: ["x-route": "secondary"])
+
+    let recorder = RecordingConnectivityDelegate()
+    self.secondary.connectivity.delegate = recorder
+
+    // Start an RPC on the secondary and wait for it to be running.
+    let responseReceived = self.expectation(description: "response received")
+    let update = client.update(callOptions: options) { _ in
+      responseReceived.fulfill()
+    }
+    XCTAssertNoThrow(try update.sendMessage(.with { $0.text = "foo" }).wait())
+    self.wait(for: [responseReceived], timeout: 1.0)
+
+    // The connection is drained: it goes idle straight away but the RPC in progress isn't affected.
+    recorder.expectChange {
+      XCTAssertEqual($0, Change(from: .ready, to: .idle))
+    }
+    channel.markUnhealthy("secondary", for: .seconds(1))
+
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertEqual(try update.status.map { $0.code }.wait(), .ok)
+    recorder.waitForExpectedChanges(timeout: .seconds(5))
+
+    // RPCs go to the primary during the cooldown.
+    let get = client.get(.with { $0.text = "foo" }, callOptions: options)
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+    XCTAssertEqual(self.primary.connectivity.state, .ready)
+    XCTAssertEqual(self.secondary.connectivity.state, .idle)
+
+    // Once the cooldown has passed a new connection is established to the secondary.
+    now = now + .seconds(1)
+    let reprobe = client.get(.with { $0.text = "bar" }, callOptions: options)
+    XCTAssertEqual(try reprobe.response.wait().text, "Swift echo get: bar")
+    XCTAssertEqual(self.secondary.connectivity.state, .ready)
+  }
+
+  func testPinnedChannelKeepsUsingFirstSelectedChannel() throws {
+    let channel = self.makeRoutingChannel()
+    let toSecondary = CallOptions(customMetadata: ["x-route": "secondary"])
+
+    try channel.withPinnedChannel { pinned in
+      let client = Echo_EchoClient(channel: pinned)
+
+      // The first RPC picks the channel, later RPCs ignore the selector.
+      let first = client.get(.with { $0.text = "foo" })
+      XCTAssertEqual(try first.response.wait().text, "Swift echo get: foo")
+      let second = client.get(.with { $0.text = "bar" }, callOptions: toSecondary)
+      XCTAssertEqual(try second.response.wait().text, "Swift echo get: bar")
+    }
+
+    XCTAssertEqual(self.primary.connectivity.state, .ready)
+    XCTAssertEqual(self.secondary.connectivity.state, .idle)
+  }
+
+  func testPinnedChannelFailsOverWhenUnhealthy() throws {
+    let channel = self.makeRoutingChannel()
+    let toSecondary = CallOptions(customMetadata: ["x-route": "secondary"])
+
+    try channel.withPinnedChannel { pinned in
+      let client = Echo_EchoClient(channel: pinned)
+
+      let first = client.get(.with { $0.text = "foo" }, callOptions: toSecondary)
+      XCTAssertEqual(try first.response.wait().text, "Swift echo get: foo")
+      XCTAssertEqual(self.primary.connectivity.state, .idle)
+
+      channel.markUnhealthy("secondary", for: .hours(1))
+
+      // The pinned channel is unhealthy so the RPC is re-pinned to the primary, and stays there
+      // once the secondary is healthy again.
+      let second = client.get(.with { $0.text = "bar" }, callOptions: toSecondary)
+      XCTAssertEqual(try second.response.wait().text, "Swift echo get: bar")
+      XCTAssertEqual(self.primary.connectivity.state, .ready)
+
+      channel.markHealthy("secondary")
+      let third = client.get(.with { $0.text = "baz" }, callOptions: toSecondary)
+      XCTAssertEqual(try third.response.wait().text, "Swift echo get: baz")
+    }
+  }
+
+  func testPinnedChannelFailsOverWhenConnectionIsShutDown() throws {
+    let channel = self.makeRoutingChannel()
+    let toSecondary = CallOptions(customMetadata: ["x-route": "secondary"])
+
+    try channel.withPinnedChannel { pinned in
+      let client = Echo_EchoClient(channel: pinned)
+
+      let first = client.get(.with { $0.text = "foo" }, callOptions: toSecondary)
+      XCTAssertEqual(try first.response.wait().text, "Swift echo get: foo")
+      XCTAssertEqual(self.primary.connectivity.state, .idle)
+
+      // The secondary is still healthy but its connection is gone: the RPC must be re-pinned to
+      // the primary even though the selector picks the secondary.
+      XCTAssertNoThrow(try self.secondary.close().wait())
+      XCTAssertTrue(channel.isHealthy("secondary"))
+
+      let second = client.get(.with { $0.text = "bar" }, callOptions: toSecondary)
+      XCTAssertEqual(try second.response.wait().text, "Swift echo get: bar")
+      XCTAssertEqual(self.primary.connectivity.state, .ready)
+    }
+  }
+
+  func testClosingPinnedChannelDoesNotCloseChannels() throws {
+    let channel = self.makeRoutingChannel()
+    try channel.withPinnedChannel { pinned in
+      XCTAssertNoThrow(try pinned.close().wait())
+    }
+
+    let get = self.makeRoutingClient().get(.with { $0.text = "foo" })
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+  }
+
+  func testPinnedChannelNeedsAnEventLoopIfNoneIsKnown() throws {
+    let fake = FakeChannel()
+    let channel = try RoutingGRPCChannel(channels: ["fake": fake], defaultChannel: "fake") {
+      _, _ in nil
+    }
+
+    XCTAssertThrowsError(try channel.withPinnedChannel { _ in }) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+
+    let eventLoop = EmbeddedEventLoop()
+    try channel.withPinnedChannel(eventLoop: eventLoop) { pinned in
+      let closed = pinned.close()
+      XCTAssert(closed.eventLoop === eventLoop)
+      XCTAssertNoThrow(try closed.wait())
+    }
+  }
+}
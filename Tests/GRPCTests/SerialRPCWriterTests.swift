@@ -0,0 +1,88 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Dispatch
+import GRPC
+import NIO
+import XCTest
+
+class SerialRPCWriterTests: GRPCTestCase {
+  private func makeContext(on eventLoop: EventLoop) -> StreamingResponseCallContextTestStub<Int> {
+    return StreamingResponseCallContextTestStub(
+      eventLoop: eventLoop,
+      headers: [:],
+      logger: self.logger,
+      closeFuture: eventLoop.makeSucceededVoidFuture()
+    )
+  }
+
+  func testWritesArePerformedOnTheEventLoop() throws {
+    let eventLoop = EmbeddedEventLoop()
+    let context = self.makeContext(on: eventLoop)
+    let writer = SerialRPCWriter(wrapping: context)
+
+    let writes = (0 ..< 5).map { writer.write($0) }
+
+    // Nothing is written until the event loop runs.
+    XCTAssertEqual(context.recordedResponses, [])
+    eventLoop.run()
+    XCTAssertEqual(context.recordedResponses, [0, 1, 2, 3, 4])
+
+    for write in writes {
+      XCTAssertNoThrow(try write.wait())
+    }
+  }
+
+  func testWritesFromConcurrentProducersAreOrderedPerProducer() throws {
+    let group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+    defer {
+      XCTAssertNoThrow(try group.syncShutdownGracefully())
+    }
+
+    let eventLoop = group.next()
+    let context = self.makeContext(on: eventLoop)
+    let writer = SerialRPCWriter(wrapping: context)
+
+    let producers = 4
+    let messagesPerProducer = 100
+    let promises = (0 ..< producers).map { _ in
+      eventLoop.makePromise(of: Void.self)
+    }
+
+    DispatchQueue.concurrentPerform(iterations: producers) { producer in
+      for index in 0 ..< messagesPerProducer {
+        let isLast = index == messagesPerProducer - 1
+        writer.write(
+          producer * messagesPerProducer + index,
+          promise: isLast ? promises[producer] : nil
+        )
+      }
+    }
+
+    XCTAssertNoThrow(try EventLoopFuture.andAllSucceed(
+      promises.map { $0.futureResult },
+      on: eventLoop
+    ).wait())
+
+    let responses = try eventLoop.submit { context.recordedResponses }.wait()
+    XCTAssertEqual(responses.count, producers * messagesPerProducer)
+
+    for producer in 0 ..< producers {
+      let range = producer * messagesPerProducer ..< (producer + 1) * messagesPerProducer
+      let fromProducer = responses.filter { range.contains($0) }
+      XCTAssertEqual(fromProducer, Array(range))
+    }
+  }
+}
@@ -0,0 +1,87 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Dispatch
+import GRPC
+import NIO
+import XCTest
+
+class ServerCallContextOffloadTests: GRPCTestCase {
+  private var group: MultiThreadedEventLoopGroup!
+  private let queue = DispatchQueue(label: "io.grpc.testing.offload")
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func makeContext() -> StreamingResponseCallContextTestStub<Int> {
+    let eventLoop = self.group.next()
+    return StreamingResponseCallContextTestStub(
+      eventLoop: eventLoop,
+      headers: [:],
+      logger: self.logger,
+      closeFuture: eventLoop.makeSucceededVoidFuture()
+    )
+  }
+
+  func testWorkIsRunOffTheEventLoop() throws {
+    let context = self.makeContext()
+    let future = context.offload(to: self.queue) {
+      context.eventLoop.inEventLoop
+    }
+    XCTAssertFalse(try future.wait())
+  }
+
+  func testResultIsDeliveredOnTheEventLoop() throws {
+    let context = self.makeContext()
+    let onEventLoop = context.offload(to: self.queue) {
+      42
+    }.map { value -> Bool in
+      XCTAssertEqual(value, 42)
+      return context.eventLoop.inEventLoop
+    }
+    XCTAssertTrue(try onEventLoop.wait())
+  }
+
+  func testErrorsAreDelivered() throws {
+    let context = self.makeContext()
+    let future: EventLoopFuture<Int> = context.offload(to: self.queue) {
+      throw GRPCStatus(code: .aborted, message: nil)
+    }
+    XCTAssertThrowsError(try future.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .aborted)
+    }
+  }
+
+  func testBlockingWorkDoesNotStallTheEventLoop() throws {
+    let context = self.makeContext()
+    let semaphore = DispatchSemaphore(value: 0)
+    let blocked = context.offload(to: self.queue) {
+      semaphore.wait()
+    }
+
+    // The event loop is still free to run other work while the offloaded work is blocked.
+    XCTAssertNoThrow(try context.eventLoop.submit { () }.wait())
+
+    semaphore.signal()
+    XCTAssertNoThrow(try blocked.wait())
+  }
+}
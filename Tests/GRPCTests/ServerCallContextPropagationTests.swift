@@ -0,0 +1,74 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+class ServerCallContextPropagationTests: GRPCTestCase {
+  private func makeContext(headers: HPACKHeaders) -> StreamingResponseCallContextTestStub<Int> {
+    let eventLoop = EmbeddedEventLoop()
+    return StreamingResponseCallContextTestStub(
+      eventLoop: eventLoop,
+      headers: headers,
+      logger: self.logger,
+      closeFuture: eventLoop.makeSucceededVoidFuture()
+    )
+  }
+
+  func testDeadlineIsPropagated() throws {
+    let context = self.makeContext(headers: ["grpc-timeout": "10S"])
+    let options = context.propagatingCallOptions(metadataKeys: [])
+    XCTAssertEqual(options.timeLimit.deadline, context.deadline)
+  }
+
+  func testEarlierTimeLimitIsKept() throws {
+    let context = self.makeContext(headers: ["grpc-timeout": "10S"])
+    let earlier = NIODeadline.now() + .seconds(1)
+    let options = context.propagatingCallOptions(
+      CallOptions(timeLimit: .deadline(earlier)),
+      metadataKeys: []
+    )
+    XCTAssertEqual(options.timeLimit.deadline, earlier)
+  }
+
+  func testNoDeadlineLeavesTimeLimitUnchanged() throws {
+    let context = self.makeContext(headers: [:])
+    let options = context.propagatingCallOptions(
+      CallOptions(timeLimit: .timeout(.seconds(5))),
+      metadataKeys: []
+    )
+    XCTAssertEqual(options.timeLimit, .timeout(.seconds(5)))
+  }
+
+  func testSelectedMetadataIsPropagated() throws {
+    let context = self.makeContext(headers: [
+      "x-trace-id": "abc",
+      "x-tenant": "inbound",
+      "authorization": "secret",
+    ])
+
+    let options = context.propagatingCallOptions(
+      CallOptions(customMetadata: ["x-tenant": "explicit"]),
+      metadataKeys: ["X-Trace-ID", "x-tenant", "x-missing"]
+    )
+
+    XCTAssertEqual(options.customMetadata[canonicalForm: "x-trace-id"], ["abc"])
+    XCTAssertEqual(options.customMetadata[canonicalForm: "x-tenant"], ["explicit"])
+    XCTAssertFalse(options.customMetadata.contains(name: "authorization"))
+    XCTAssertFalse(options.customMetadata.contains(name: "x-missing"))
+  }
+}
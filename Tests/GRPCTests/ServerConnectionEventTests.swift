@@ -0,0 +1,116 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import XCTest
+
+private final class RecordingConnectionEventDelegate: ServerConnectionEventDelegate {
+  private let lock = Lock()
+  private var _events: [ServerConnectionEvent] = []
+  private let closed: XCTestExpectation
+
+  var events: [ServerConnectionEvent] {
+    return self.lock.withLock { self._events }
+  }
+
+  init(closed: XCTestExpectation) {
+    self.closed = closed
+  }
+
+  func connectionEventDidOccur(_ event: ServerConnectionEvent) {
+    let isFirstClose: Bool = self.lock.withLock {
+      self._events.append(event)
+      guard case .connectionClosed = event else {
+        return false
+      }
+      // Clients may reconnect, only the first connection is waited for.
+      return self._events.filter {
+        if case .connectionClosed = $0 { return true } else { return false }
+      }.count == 1
+    }
+
+    if isFirstClose {
+      self.closed.fulfill()
+    }
+  }
+}
+
+class ServerConnectionEventTests: EchoTestCaseBase {
+  private lazy var closed = self.expectation(description: "connection closed")
+  private lazy var delegate = RecordingConnectionEventDelegate(closed: self.closed)
+
+  override func serverBuilder() -> Server.Builder {
+    return super.serverBuilder().withConnectionEventDelegate(self.delegate)
+  }
+
+  func testConnectionAcceptedAndClosed() throws {
+    let get = self.client.get(Echo_EchoRequest(text: "foo"))
+    XCTAssertEqual(try get.status.wait().code, .ok)
+
+    XCTAssertNoThrow(try self.client.channel.close().wait())
+    self.wait(for: [self.closed], timeout: 5.0)
+
+    let events = self.delegate.events
+    XCTAssertEqual(events.count, 2)
+
+    guard case let .some(.connectionAccepted(remoteAddress)) = events.first else {
+      return XCTFail("Expected connection to be accepted first, events were \(events)")
+    }
+    XCTAssertNotNil(remoteAddress)
+
+    guard case let .some(.connectionClosed(closedAddress, _)) = events.last else {
+      return XCTFail("Expected connection to be closed last, events were \(events)")
+    }
+    XCTAssertEqual(closedAddress, remoteAddress)
+  }
+}
+
+class ServerConnectionEventTLSTests: EchoTestCaseBase {
+  private lazy var closed = self.expectation(description: "connection closed")
+  private lazy var delegate = RecordingConnectionEventDelegate(closed: self.closed)
+
+  override var transportSecurity: TransportSecurity {
+    return .anonymousClient
+  }
+
+  override func serverBuilder() -> Server.Builder {
+    return super.serverBuilder().withConnectionEventDelegate(self.delegate)
+  }
+
+  func testHandshakeFailed() throws {
+    // A plaintext client can't complete the TLS handshake.
+    let connection = ClientConnection.insecure(group: self.clientEventLoopGroup)
+      .connect(host: "localhost", port: self.port)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let client = Echo_EchoClient(channel: connection)
+    let get = client.get(
+      Echo_EchoRequest(text: "foo"),
+      callOptions: CallOptions(timeLimit: .timeout(.seconds(5)))
+    )
+    XCTAssertNotEqual(try get.status.wait().code, .ok)
+    self.wait(for: [self.closed], timeout: 5.0)
+
+    let events = self.delegate.events
+    XCTAssert(events.contains {
+      if case .handshakeFailed = $0 { return true } else { return false }
+    })
+  }
+}
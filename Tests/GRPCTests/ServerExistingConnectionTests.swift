@@ -0,0 +1,86 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import Foundation
+import GRPC
+import NIO
+import XCTest
+
+class ServerExistingConnectionTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+  private var listener: Channel!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+
+    let configuration = Server.Configuration.default(
+      target: .hostAndPort("ignored", 0),
+      eventLoopGroup: self.group,
+      serviceProviders: [EchoProvider()]
+    )
+
+    // Accept connections with a plain NIO bootstrap and hand each one to gRPC.
+    self.listener = try! ServerBootstrap(group: self.group)
+      .childChannelInitializer { channel in
+        Server.serve(connection: channel, configuration: configuration)
+      }
+      .bind(host: "localhost", port: 0)
+      .wait()
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.listener.close().wait())
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  func testRPCsAreServedOnExistingConnection() throws {
+    let connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: self.listener.localAddress!.port!)
+    defer {
+      XCTAssertNoThrow(try connection.close().wait())
+    }
+
+    let echo = Echo_EchoClient(channel: connection)
+    let get = echo.get(.with { $0.text = "foo" })
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+    XCTAssertEqual(try get.status.wait().code, .ok)
+  }
+
+  func testConnectedSocketRequiresMultiThreadedEventLoopGroup() throws {
+    let group = EmbeddedEventLoop()
+    let configuration = Server.Configuration.default(
+      target: .hostAndPort("ignored", 0),
+      eventLoopGroup: group,
+      serviceProviders: [EchoProvider()]
+    )
+
+    // Any descriptor will do: it must be closed without being used.
+    let socket = open("/dev/null", O_RDONLY)
+    XCTAssertGreaterThanOrEqual(socket, 0)
+
+    let channel = Server.serve(connectedSocket: socket, configuration: configuration)
+    XCTAssertThrowsError(try channel.wait()) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+
+    // Ownership was transferred, so the descriptor must have been closed.
+    XCTAssertEqual(fcntl(socket, F_GETFD), -1)
+  }
+}
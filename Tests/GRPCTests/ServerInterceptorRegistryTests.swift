@@ -0,0 +1,251 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+/// An interceptor which passes everything through, tagged with the name it was registered with.
+private final class NamedInterceptor<Request, Response>: ServerInterceptor<Request, Response> {
+  let name: String
+  let method: ServerMethodDescriptor
+
+  init(name: String, method: ServerMethodDescriptor) {
+    self.name = name
+    self.method = method
+  }
+}
+
+private struct NamedInterceptorMaker: ServerInterceptorMaker {
+  var name: String
+
+  func makeInterceptor<Request, Response>(
+    for method: ServerMethodDescriptor
+  ) -> ServerInterceptor<Request, Response> {
+    return NamedInterceptor(name: self.name, method: method)
+  }
+}
+
+class ServerInterceptorRegistryTests: GRPCTestCase {
+  private let get = ServerMethodDescriptor(serviceName: "echo.Echo", name: "Get")
+  private let update = ServerMethodDescriptor(serviceName: "echo.Echo", name: "Update")
+  private let check = ServerMethodDescriptor(serviceName: "grpc.health.v1.Health", name: "Check")
+
+  private func register(
+    _ names: [(String, Int, ServerInterceptorScope)],
+    in registry: inout ServerInterceptorRegistry
+  ) throws {
+    for (name, priority, scope) in names {
+      let maker = NamedInterceptorMaker(name: name)
+      try registry.register(name, priority: priority, scope: scope, maker: maker)
+    }
+  }
+
+  func testChainIsOrderedByPriority() throws {
+    var registry = ServerInterceptorRegistry()
+    try self.register([
+      ("logging", 100, .allMethods),
+      ("auth", 300, .allMethods),
+      ("rate-limit", 200, .allMethods),
+    ], in: &registry)
+
+    XCTAssertEqual(registry.resolvedChain(for: self.get), ["auth", "rate-limit", "logging"])
+  }
+
+  func testEqualPrioritiesAreOrderedByRegistration() throws {
+    var registry = ServerInterceptorRegistry()
+    try self.register([
+      ("b", 0, .allMethods),
+      ("a", 0, .allMethods),
+      ("first", 1, .allMethods),
+      ("c", 0, .allMethods),
+    ], in: &registry)
+
+    XCTAssertEqual(registry.resolvedChain(for: self.get), ["first", "b", "a", "c"])
+  }
+
+  func testChainIsScoped() throws {
+    var registry = ServerInterceptorRegistry()
+    try self.register([
+      ("logging", 100, .allMethods),
+      ("auth", 300, .methods([self.get])),
+      ("rate-limit", 200, .services(["echo.Echo"])),
+    ], in: &registry)
+
+    XCTAssertEqual(registry.resolvedChain(for: self.get), ["auth", "rate-limit", "logging"])
+    XCTAssertEqual(registry.resolvedChain(for: self.update), ["rate-limit", "logging"])
+    XCTAssertEqual(registry.resolvedChain(for: self.check), ["logging"])
+  }
+
+  func testMakeInterceptors() throws {
+    var registry = ServerInterceptorRegistry()
+    try self.register([
+      ("logging", 100, .allMethods),
+      ("auth", 300, .methods([self.get])),
+    ], in: &registry)
+
+    let interceptors: [ServerInterceptor<String, Int>] = registry.makeInterceptors(for: self.get)
+    let named = interceptors.compactMap { $0 as? NamedInterceptor<String, Int> }
+    XCTAssertEqual(named.map { $0.name }, ["auth", "logging"])
+    XCTAssertEqual(named.map { $0.method }, [self.get, self.get])
+
+    // A new instance is made each time.
+    let again: [ServerInterceptor<String, Int>] = registry.makeInterceptors(for: self.get)
+    XCTAssertFalse(interceptors[0] === again[0])
+  }
+
+  func testDuplicateNameIsRejected() throws {
+    var registry = ServerInterceptorRegistry()
+    try self.register([("logging", 100, .allMethods)], in: &registry)
+
+    XCTAssertThrowsError(
+      try registry.register("logging", priority: 200, maker: NamedInterceptorMaker(name: "logging"))
+    ) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+    XCTAssertEqual(registry.resolvedChain(for: self.get), ["logging"])
+  }
+
+  func testEmptyRegistry() {
+    let registry = ServerInterceptorRegistry()
+    XCTAssertEqual(registry.resolvedChain(for: self.get), [])
+    let interceptors: [ServerInterceptor<String, Int>] = registry.makeInterceptors(for: self.get)
+    XCTAssertTrue(interceptors.isEmpty)
+  }
+}
+
+/// An interceptor which adds its name to the trailers of each RPC it intercepts.
+private final class TrailerNamingInterceptor<Request, Response>:
+  ServerInterceptor<Request, Response> {
+  let name: String
+
+  init(name: String) {
+    self.name = name
+  }
+
+  override func send(
+    _ part: GRPCServerResponsePart<Response>,
+    promise: EventLoopPromise<Void>?,
+    context: ServerInterceptorContext<Request, Response>
+  ) {
+    switch part {
+    case var .end(status, trailers):
+      trailers.add(name: "x-interceptor", value: self.name)
+      context.send(.end(status, trailers), promise: promise)
+    case .metadata, .message:
+      context.send(part, promise: promise)
+    }
+  }
+}
+
+private struct TrailerNamingInterceptorMaker: ServerInterceptorMaker {
+  var name: String
+
+  func makeInterceptor<Request, Response>(
+    for method: ServerMethodDescriptor
+  ) -> ServerInterceptor<Request, Response> {
+    return TrailerNamingInterceptor(name: self.name)
+  }
+}
+
+private final class TrailerNamingEchoInterceptors: Echo_EchoServerInterceptorFactoryProtocol {
+  func makeGetInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return [TrailerNamingInterceptor(name: "provider")]
+  }
+
+  func makeExpandInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return [TrailerNamingInterceptor(name: "provider")]
+  }
+
+  func makeCollectInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return [TrailerNamingInterceptor(name: "provider")]
+  }
+
+  func makeUpdateInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return [TrailerNamingInterceptor(name: "provider")]
+  }
+}
+
+class ServerInterceptorRegistryServerTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+  private var server: Server!
+  private var connection: ClientConnection!
+  private var echo: Echo_EchoClient!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+
+    var registry = ServerInterceptorRegistry()
+    let get = ServerMethodDescriptor(serviceName: "echo.Echo", name: "Get")
+    XCTAssertNoThrow(
+      try registry.register(
+        "logging",
+        priority: 100,
+        maker: TrailerNamingInterceptorMaker(name: "logging")
+      )
+    )
+    XCTAssertNoThrow(
+      try registry.register(
+        "auth",
+        priority: 200,
+        scope: .methods([get]),
+        maker: TrailerNamingInterceptorMaker(name: "auth")
+      )
+    )
+
+    self.server = try! Server.insecure(group: self.group)
+      .withServiceProviders([EchoProvider(interceptors: TrailerNamingEchoInterceptors())])
+      .withInterceptorRegistry(registry)
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+
+    self.connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: self.server.channel.localAddress!.port!)
+
+    self.echo = Echo_EchoClient(
+      channel: self.connection,
+      defaultCallOptions: CallOptions(logger: self.clientLogger)
+    )
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.connection.close().wait())
+    XCTAssertNoThrow(try self.server.close().wait())
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  func testRegistryInterceptorsRunAheadOfProviderInterceptors() throws {
+    let get = self.echo.get(.with { $0.text = "foo" })
+    XCTAssertEqual(try get.status.map { $0.code }.wait(), .ok)
+    // Trailers are added as the end of the RPC is sent, i.e. in reverse order of the chain.
+    let trailers = try get.trailingMetadata.wait()
+    XCTAssertEqual(trailers[canonicalForm: "x-interceptor"], ["provider", "logging", "auth"])
+  }
+
+  func testRegistryInterceptorsAreScoped() throws {
+    let update = self.echo.update { _ in }
+    update.sendEnd(promise: nil)
+    XCTAssertEqual(try update.status.map { $0.code }.wait(), .ok)
+    let trailers = try update.trailingMetadata.wait()
+    XCTAssertEqual(trailers[canonicalForm: "x-interceptor"], ["provider", "logging"])
+  }
+}
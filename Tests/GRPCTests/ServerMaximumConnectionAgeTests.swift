@@ -0,0 +1,114 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import NIO
+import NIOHTTP2
+import XCTest
+
+class ServerMaximumConnectionAgeTests: GRPCTestCase {
+  private var loop: EmbeddedEventLoop!
+  private var channel: EmbeddedChannel!
+
+  override func setUp() {
+    super.setUp()
+    self.loop = EmbeddedEventLoop()
+    self.channel = EmbeddedChannel(loop: self.loop)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.channel.finish(acceptAlreadyClosed: true))
+    super.tearDown()
+  }
+
+  private func addIdleHandler(age: TimeAmount, grace: TimeAmount) throws {
+    let handler = GRPCIdleHandler(
+      idleTimeout: .nanoseconds(.max),
+      keepalive: ServerConnectionKeepalive(),
+      maximumConnectionAge: age,
+      maximumConnectionAgeGrace: grace,
+      logger: self.serverLogger
+    )
+    try self.channel.pipeline.addHandler(handler).wait()
+    try self.channel.connect(to: SocketAddress(unixDomainSocketPath: "/ignored")).wait()
+  }
+
+  private func openStream(_ streamID: HTTP2StreamID) {
+    let event = NIOHTTP2StreamCreatedEvent(
+      streamID: streamID,
+      localInitialWindowSize: nil,
+      remoteInitialWindowSize: nil
+    )
+    self.channel.pipeline.fireUserInboundEventTriggered(event)
+  }
+
+  private func assertGoAwaySent(
+    lastStreamID: HTTP2StreamID,
+    file: StaticString = #file,
+    line: UInt = #line
+  ) throws {
+    let frame = try XCTUnwrap(try self.channel.readOutbound(as: HTTP2Frame.self))
+    guard case let .goAway(streamID, errorCode, _) = frame.payload else {
+      XCTFail("Expected GOAWAY but got \(frame.payload)", file: file, line: line)
+      return
+    }
+    XCTAssertEqual(streamID, lastStreamID, file: file, line: line)
+    XCTAssertEqual(errorCode, .noError, file: file, line: line)
+  }
+
+  func testConnectionWithoutStreamsIsClosedAtMaximumAge() throws {
+    try self.addIdleHandler(age: .seconds(10), grace: .seconds(5))
+
+    // Jitter is at most 10%.
+    self.loop.advanceTime(by: .seconds(8))
+    XCTAssertNil(try self.channel.readOutbound(as: HTTP2Frame.self))
+    XCTAssertTrue(self.channel.isActive)
+
+    self.loop.advanceTime(by: .seconds(4))
+    try self.assertGoAwaySent(lastStreamID: .rootStream)
+    XCTAssertFalse(self.channel.isActive)
+  }
+
+  func testOpenStreamsAreGivenGracePeriod() throws {
+    try self.addIdleHandler(age: .seconds(10), grace: .seconds(5))
+    self.openStream(1)
+
+    self.loop.advanceTime(by: .seconds(11))
+    try self.assertGoAwaySent(lastStreamID: 1)
+    XCTAssertTrue(self.channel.isActive)
+
+    self.loop.advanceTime(by: .seconds(5))
+    XCTAssertFalse(self.channel.isActive)
+  }
+
+  func testConnectionClosesWhenStreamsCompleteWithinGracePeriod() throws {
+    try self.addIdleHandler(age: .seconds(10), grace: .seconds(5))
+    self.openStream(1)
+
+    self.loop.advanceTime(by: .seconds(11))
+    try self.assertGoAwaySent(lastStreamID: 1)
+    XCTAssertTrue(self.channel.isActive)
+
+    self.channel.pipeline.fireUserInboundEventTriggered(StreamClosedEvent(streamID: 1, reason: nil))
+    XCTAssertFalse(self.channel.isActive)
+  }
+
+  func testNoMaximumAgeByDefault() throws {
+    try self.addIdleHandler(age: .nanoseconds(.max), grace: .nanoseconds(.max))
+    self.loop.advanceTime(by: .hours(24 * 365))
+    XCTAssertNil(try self.channel.readOutbound(as: HTTP2Frame.self))
+    XCTAssertTrue(self.channel.isActive)
+  }
+}
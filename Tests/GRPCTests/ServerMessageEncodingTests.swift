@@ -0,0 +1,50 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import XCTest
+
+class ServerMessageEncodingTests: GRPCTestCase {
+  private let configuration = ServerMessageEncoding.Configuration(
+    enabledAlgorithms: [.gzip, .deflate],
+    decompressionLimit: .absolute(1024)
+  )
+
+  private func select(_ acceptEncoding: [String]) -> CompressionAlgorithm? {
+    return self.configuration.responseAlgorithm(acceptEncoding: acceptEncoding)
+  }
+
+  func testClientOrderIsPreferred() {
+    XCTAssertEqual(self.select(["deflate", "gzip"]), .deflate)
+    XCTAssertEqual(self.select(["gzip", "deflate"]), .gzip)
+  }
+
+  func testUnsupportedAndDisabledAlgorithmsAreSkipped() {
+    XCTAssertEqual(self.select(["zstd", "identity", "deflate"]), .deflate)
+    XCTAssertNil(self.select(["zstd", "identity"]))
+    XCTAssertNil(self.select([]))
+  }
+
+  func testWeightsTakePrecedenceOverOrder() {
+    XCTAssertEqual(self.select(["gzip;q=0.5", "deflate"]), .deflate)
+    XCTAssertEqual(self.select(["gzip; q=0.8", "deflate;q=0.8"]), .gzip)
+    XCTAssertEqual(self.select([" GZIP ;q=1.0", "deflate;q=0.9"]), .gzip)
+  }
+
+  func testZeroWeightIsNeverSelected() {
+    XCTAssertEqual(self.select(["gzip;q=0", "deflate;q=0.1"]), .deflate)
+    XCTAssertNil(self.select(["gzip;q=0"]))
+  }
+}
@@ -0,0 +1,119 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class ServerPathAliasTests: EchoTestCaseBase {
+  override func serverBuilder() -> Server.Builder {
+    return try! super.serverBuilder().withPathAlias(from: "/old.Echo/Get", to: "/echo.Echo/Get")
+  }
+
+  func testRPCOnAliasIsRoutedToMethod() throws {
+    let get: UnaryCall<Echo_EchoRequest, Echo_EchoResponse> = self.client.channel.makeUnaryCall(
+      path: "/old.Echo/Get",
+      request: .with { $0.text = "foo" },
+      callOptions: self.callOptionsWithLogger
+    )
+
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+    XCTAssertEqual(try get.status.wait().code, .ok)
+  }
+
+  func testOriginalPathStillWorks() throws {
+    let get = self.client.get(.with { $0.text = "foo" })
+    XCTAssertEqual(try get.status.wait().code, .ok)
+  }
+
+  private func assertAliasError(
+    _ code: PathAliasError.Code,
+    file: StaticString = #file,
+    line: UInt = #line,
+    _ body: () throws -> Void
+  ) {
+    XCTAssertThrowsError(try body(), file: file, line: line) { error in
+      XCTAssertEqual((error as? PathAliasError)?.code, code, file: file, line: line)
+    }
+  }
+
+  func testCollisionsAreRejected() throws {
+    var configuration = Server.Configuration.default(
+      target: .hostAndPort("localhost", 0),
+      eventLoopGroup: self.serverEventLoopGroup,
+      serviceProviders: [EchoProvider()]
+    )
+
+    XCTAssertNoThrow(try configuration.addAlias(from: "/old.Echo/Get", to: "/echo.Echo/Get"))
+
+    self.assertAliasError(.aliasAlreadyRegistered) {
+      try configuration.addAlias(from: "/old.Echo/Get", to: "/echo.Echo/Update")
+    }
+    self.assertAliasError(.targetIsAlias) {
+      try configuration.addAlias(from: "/older.Echo/Get", to: "/old.Echo/Get")
+    }
+    self.assertAliasError(.invalidPath) {
+      try configuration.addAlias(from: "old.Echo.Get", to: "/echo.Echo/Get")
+    }
+  }
+
+  private func assertServerFailsToStart(
+    _ code: PathAliasError.Code,
+    serviceProviders: [CallHandlerProvider] = [EchoProvider()],
+    from alias: String,
+    to path: String,
+    file: StaticString = #file,
+    line: UInt = #line
+  ) throws {
+    var configuration = Server.Configuration.default(
+      target: .hostAndPort("localhost", 0),
+      eventLoopGroup: self.serverEventLoopGroup,
+      serviceProviders: []
+    )
+
+    // Aliases are checked when the server starts so may be added before the providers.
+    try configuration.addAlias(from: alias, to: path)
+    configuration.serviceProviders = serviceProviders
+
+    let server = Server.start(configuration: configuration)
+    self.assertAliasError(code, file: file, line: line) {
+      let server = try server.wait()
+      try server.close().wait()
+    }
+  }
+
+  func testAliasShadowingMethodFailsServerStart() throws {
+    try self.assertServerFailsToStart(
+      .aliasShadowsMethod,
+      from: "/echo.Echo/Expand",
+      to: "/echo.Echo/Get"
+    )
+  }
+
+  func testAliasToUnknownMethodFailsServerStart() throws {
+    try self.assertServerFailsToStart(
+      .unknownTarget,
+      from: "/old.Echo/Get",
+      to: "/echo.Echo/Nope"
+    )
+    try self.assertServerFailsToStart(
+      .unknownTarget,
+      from: "/old.Echo/Get",
+      to: "/unknown.Echo/Get"
+    )
+  }
+}
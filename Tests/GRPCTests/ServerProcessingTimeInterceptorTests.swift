@@ -0,0 +1,114 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+private final class ProcessingTimeInterceptorFactory: Echo_EchoServerInterceptorFactoryProtocol {
+  private func make() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return [ServerProcessingTimeInterceptor()]
+  }
+
+  func makeGetInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return self.make()
+  }
+
+  func makeExpandInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return self.make()
+  }
+
+  func makeCollectInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return self.make()
+  }
+
+  func makeUpdateInterceptors() -> [ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return self.make()
+  }
+}
+
+/// Sets trailers on every response and fails 'get' if the request text is "fail".
+private final class TrailerSettingEchoProvider: Echo_EchoProvider {
+  let interceptors: Echo_EchoServerInterceptorFactoryProtocol? =
+    ProcessingTimeInterceptorFactory()
+
+  func get(
+    request: Echo_EchoRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Echo_EchoResponse> {
+    context.trailers.add(name: "x-handler", value: "set")
+    // Overwritten by the interceptor.
+    context.trailers.add(name: "x-server-processing-ms", value: "spoofed")
+
+    if request.text == "fail" {
+      return context.eventLoop.makeFailedFuture(GRPCStatus(code: .internalError, message: nil))
+    }
+
+    let promise = context.eventLoop.makePromise(of: Echo_EchoResponse.self)
+    context.eventLoop.scheduleTask(in: .milliseconds(50)) {
+      promise.succeed(Echo_EchoResponse(text: request.text))
+    }
+    return promise.futureResult
+  }
+
+  func expand(
+    request: Echo_EchoRequest,
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<GRPCStatus> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+
+  func collect(
+    context: UnaryResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+
+  func update(
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+}
+
+class ServerProcessingTimeInterceptorTests: EchoTestCaseBase {
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return TrailerSettingEchoProvider()
+  }
+
+  func testProcessingTimeIsAddedToHandlerTrailers() throws {
+    let get = self.client.get(Echo_EchoRequest(text: "foo"))
+    XCTAssertEqual(try get.status.wait().code, .ok)
+
+    let trailers = try get.trailingMetadata.wait()
+    XCTAssertEqual(trailers.first(name: "x-handler"), "set")
+
+    let values = trailers[canonicalForm: "x-server-processing-ms"]
+    XCTAssertEqual(values.count, 1)
+    let millis = try XCTUnwrap(values.first.flatMap { Int($0) })
+    XCTAssertGreaterThanOrEqual(millis, 50)
+  }
+
+  func testProcessingTimeIsAddedWhenHandlerFails() throws {
+    let get = self.client.get(Echo_EchoRequest(text: "fail"))
+    XCTAssertEqual(try get.status.wait().code, .internalError)
+
+    let trailers = try get.trailingMetadata.wait()
+    XCTAssertEqual(trailers.first(name: "x-handler"), "set")
+    let value = try XCTUnwrap(trailers.first(name: "x-server-processing-ms"))
+    XCTAssertNotNil(Int(value))
+  }
+}
@@ -0,0 +1,123 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import Logging
+import NIO
+import NIOHPACK
+import XCTest
+
+class ServerRPCLoggerMetadataTests: GRPCTestCase {
+  private let requestHeaders: HPACKHeaders = [
+    ":path": "/echo.Echo/Get",
+    "grpc-timeout": "100m",
+    "X-Request-ID": "abc",
+    "tenant": "foo",
+  ]
+
+  func testDefaultMetadata() throws {
+    var logger = self.logger
+    let peer = try SocketAddress(ipAddress: "127.0.0.1", port: 1234)
+    ServerRPCLoggerMetadata().apply(
+      to: &logger,
+      requestHeaders: self.requestHeaders,
+      remoteAddress: peer
+    )
+
+    XCTAssertEqual(logger[metadataKey: "grpc_method"], "/echo.Echo/Get")
+    XCTAssertEqual(logger[metadataKey: "grpc_peer"], "\(peer)")
+    XCTAssertEqual(logger[metadataKey: "grpc_timeout"], "100m")
+    XCTAssertEqual(logger[metadataKey: "grpc_request_id"], "abc")
+    XCTAssertNil(logger[metadataKey: "tenant"])
+  }
+
+  func testNoMetadata() throws {
+    var logger = self.logger
+    ServerRPCLoggerMetadata.none.apply(
+      to: &logger,
+      requestHeaders: self.requestHeaders,
+      remoteAddress: try SocketAddress(ipAddress: "127.0.0.1", port: 1234)
+    )
+
+    XCTAssertNil(logger[metadataKey: "grpc_method"])
+    XCTAssertNil(logger[metadataKey: "grpc_peer"])
+    XCTAssertNil(logger[metadataKey: "grpc_timeout"])
+    XCTAssertNil(logger[metadataKey: "grpc_request_id"])
+  }
+
+  func testCustomHeaders() {
+    var logger = self.logger
+    let metadata = ServerRPCLoggerMetadata(headers: ["tenant": "tenant_id", "missing": "missing"])
+    metadata.apply(to: &logger, requestHeaders: self.requestHeaders, remoteAddress: nil)
+
+    XCTAssertEqual(logger[metadataKey: "tenant_id"], "foo")
+    XCTAssertNil(logger[metadataKey: "missing"])
+    XCTAssertNil(logger[metadataKey: "grpc_request_id"])
+    XCTAssertNil(logger[metadataKey: "grpc_peer"])
+  }
+
+  func testTraceContext() {
+    var headers = self.requestHeaders
+    headers.add(
+      name: "traceparent",
+      value: "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01"
+    )
+
+    var logger = self.logger
+    ServerRPCLoggerMetadata(includeTraceContext: true)
+      .apply(to: &logger, requestHeaders: headers, remoteAddress: nil)
+    XCTAssertEqual(logger[metadataKey: "trace_id"], "4bf92f3577b34da6a3ce929d0e0e4736")
+    XCTAssertEqual(logger[metadataKey: "span_id"], "00f067aa0ba902b7")
+
+    // Not included by default.
+    var disabled = self.logger
+    ServerRPCLoggerMetadata().apply(to: &disabled, requestHeaders: headers, remoteAddress: nil)
+    XCTAssertNil(disabled[metadataKey: "trace_id"])
+    XCTAssertNil(disabled[metadataKey: "span_id"])
+  }
+
+  func testMissingTraceContext() {
+    var logger = self.logger
+    ServerRPCLoggerMetadata(includeTraceContext: true).apply(
+      to: &logger,
+      requestHeaders: self.requestHeaders,
+      remoteAddress: nil
+    )
+    XCTAssertNil(logger[metadataKey: "trace_id"])
+    XCTAssertNil(logger[metadataKey: "span_id"])
+  }
+
+  func testParseTraceParent() {
+    let traceID = "4bf92f3577b34da6a3ce929d0e0e4736"
+    let spanID = "00f067aa0ba902b7"
+
+    let valid = TraceParent("00-\(traceID)-\(spanID)-01")
+    XCTAssertEqual(valid?.traceID, Substring(traceID))
+    XCTAssertEqual(valid?.parentID, Substring(spanID))
+
+    // Later versions may append fields.
+    XCTAssertNotNil(TraceParent("01-\(traceID)-\(spanID)-01-extra"))
+
+    XCTAssertNil(TraceParent(""))
+    XCTAssertNil(TraceParent("00-\(traceID)-\(spanID)"))
+    XCTAssertNil(TraceParent("00-\(traceID)-\(spanID)-01-extra"))
+    XCTAssertNil(TraceParent("ff-\(traceID)-\(spanID)-01"))
+    XCTAssertNil(TraceParent("00-\(traceID.uppercased())-\(spanID)-01"))
+    XCTAssertNil(TraceParent("00-\(traceID.dropLast())-\(spanID)-01"))
+    XCTAssertNil(TraceParent("00-\(String(repeating: "0", count: 32))-\(spanID)-01"))
+    XCTAssertNil(TraceParent("00-\(traceID)-\(String(repeating: "0", count: 16))-01"))
+    XCTAssertNil(TraceParent("00-\(traceID)-\(spanID)-0z"))
+  }
+}
@@ -0,0 +1,59 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import GRPC
+import HelloWorldModel
+import NIO
+import XCTest
+
+class ServerServiceDescriptorTests: EchoTestCaseBase {
+  func testServicesOfRunningServer() {
+    let echo = ServerServiceDescriptor(
+      name: "echo.Echo",
+      methods: ["Get", "Expand", "Collect", "Update"].map {
+        ServerMethodDescriptor(serviceName: "echo.Echo", name: $0)
+      }
+    )
+    XCTAssertEqual(self.server.services, [echo])
+    XCTAssertEqual(
+      self.server.registeredMethods.map { $0.path },
+      ["/echo.Echo/Get", "/echo.Echo/Expand", "/echo.Echo/Collect", "/echo.Echo/Update"]
+    )
+  }
+
+  func testServicesAreOrderedByName() {
+    final class Greeter: Helloworld_GreeterProvider {
+      var interceptors: Helloworld_GreeterServerInterceptorFactoryProtocol?
+
+      func sayHello(
+        request: Helloworld_HelloRequest,
+        context: StatusOnlyCallContext
+      ) -> EventLoopFuture<Helloworld_HelloReply> {
+        return context.eventLoop.makeSucceededFuture(.init())
+      }
+    }
+
+    let configuration = Server.Configuration.default(
+      target: .hostAndPort("localhost", 0),
+      eventLoopGroup: self.serverEventLoopGroup,
+      serviceProviders: [Greeter(), EchoProvider()]
+    )
+
+    let services = configuration.services
+    XCTAssertEqual(services.map { $0.name }, ["echo.Echo", "helloworld.Greeter"])
+    XCTAssertEqual(services.last?.methods.map { $0.path }, ["/helloworld.Greeter/SayHello"])
+  }
+}
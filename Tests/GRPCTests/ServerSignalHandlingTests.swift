@@ -0,0 +1,94 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import Foundation
+import GRPC
+import NIO
+import XCTest
+
+class ServerSignalHandlingTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func startServer() throws -> Server {
+    return try Server.insecure(group: self.group)
+      .withServiceProviders([EchoProvider()])
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+  }
+
+  func testSignalInitiatesGracefulShutdown() throws {
+    let server = try self.startServer()
+    server.initiateGracefulShutdownOnSignals([SIGUSR1], gracePeriod: .seconds(5))
+
+    let closed = self.expectation(description: "server closed")
+    server.onClose.whenComplete { _ in
+      closed.fulfill()
+    }
+
+    // The handlers are registered once 'initiateGracefulShutdownOnSignals' returns, so a single
+    // signal is enough.
+    kill(getpid(), SIGUSR1)
+    self.wait(for: [closed], timeout: 5.0)
+  }
+
+  func testInstallingHandlersTwiceIsHarmless() throws {
+    let server = try self.startServer()
+    server.initiateGracefulShutdownOnSignals([SIGUSR2], gracePeriod: .seconds(5))
+    server.initiateGracefulShutdownOnSignals([SIGUSR2], gracePeriod: .seconds(5))
+
+    let closed = self.expectation(description: "server closed")
+    server.onClose.whenComplete { _ in
+      closed.fulfill()
+    }
+
+    kill(getpid(), SIGUSR2)
+    self.wait(for: [closed], timeout: 5.0)
+  }
+
+  func testServerClosedWithoutSignal() throws {
+    let server = try self.startServer()
+    server.initiateGracefulShutdownOnSignals([SIGUSR1], gracePeriod: .seconds(5))
+    XCTAssertNoThrow(try server.close().wait())
+  }
+
+  func testPreviousSignalDispositionIsRestoredOnClose() throws {
+    // Ignore the signal before installing the handlers, the default action would terminate
+    // the process.
+    let original = signal(SIGUSR2, SIG_IGN)
+    defer {
+      signal(SIGUSR2, original)
+    }
+
+    let server = try self.startServer()
+    server.initiateGracefulShutdownOnSignals([SIGUSR2], gracePeriod: .seconds(5))
+    XCTAssertNoThrow(try server.close().wait())
+
+    // The signal must still be ignored: if the default action were restored instead then this
+    // would terminate the process.
+    kill(getpid(), SIGUSR2)
+  }
+}
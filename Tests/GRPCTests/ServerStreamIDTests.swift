@@ -0,0 +1,62 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import NIOHTTP2
+import XCTest
+
+private class StreamIDRecordingInterceptor: ServerInterceptor<Echo_EchoRequest, Echo_EchoResponse> {
+  private let lock = Lock()
+  private var _streamIDs: [HTTP2StreamID?] = []
+
+  var streamIDs: [HTTP2StreamID?] {
+    return self.lock.withLock { self._streamIDs }
+  }
+
+  override func receive(
+    _ part: GRPCServerRequestPart<Echo_EchoRequest>,
+    context: ServerInterceptorContext<Echo_EchoRequest, Echo_EchoResponse>
+  ) {
+    if case .metadata = part {
+      self.lock.withLockVoid {
+        self._streamIDs.append(context.streamID)
+      }
+    }
+    context.receive(part)
+  }
+}
+
+class ServerStreamIDTests: EchoTestCaseBase {
+  private let recorder = StreamIDRecordingInterceptor()
+
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return EchoProvider(interceptors: EchoInterceptorFactory(interceptor: self.recorder))
+  }
+
+  func testStreamIDIsAvailableToInterceptors() throws {
+    for _ in 0 ..< 3 {
+      let get = self.client.get(.with { $0.text = "foo" })
+      XCTAssertNoThrow(try get.status.wait())
+    }
+
+    // Client initiated streams have odd stream IDs and are used in increasing order.
+    let expected: [HTTP2StreamID?] = [1, 3, 5].map { HTTP2StreamID($0) }
+    XCTAssertEqual(self.recorder.streamIDs, expected)
+  }
+}
@@ -0,0 +1,121 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+@testable import GRPC
+import NIO
+import NIOHPACK
+import NIOHTTP2
+import XCTest
+
+class ServerStreamInactivityTimeoutTests: GRPCTestCase {
+  private var loop: EmbeddedEventLoop!
+  private var channel: EmbeddedChannel!
+
+  override func setUp() {
+    super.setUp()
+    self.loop = EmbeddedEventLoop()
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.channel.finish(acceptAlreadyClosed: true))
+    super.tearDown()
+  }
+
+  private func setUpChannel(streamInactivityTimeout: TimeAmount) throws {
+    let provider = EchoProvider()
+    let handler = HTTP2ToRawGRPCServerCodec(
+      servicesByName: [provider.serviceName: provider],
+      encoding: .disabled,
+      errorDelegate: nil,
+      normalizeHeaders: true,
+      maximumReceiveMessageLength: .max,
+      streamInactivityTimeout: streamInactivityTimeout,
+      logger: self.serverLogger
+    )
+    self.channel = EmbeddedChannel(handler: handler, loop: self.loop)
+    try self.channel.connect(to: SocketAddress(unixDomainSocketPath: "/ignored")).wait()
+  }
+
+  private func openStream() throws {
+    let headers: HPACKHeaders = [
+      ":method": "POST",
+      ":path": "/echo.Echo/Update",
+      "content-type": "application/grpc",
+    ]
+    try self.channel.writeInbound(HTTP2Frame.FramePayload.headers(.init(headers: headers)))
+  }
+
+  private func sendEmptyMessage() throws {
+    // A compression flag and a zero length: this is an empty request message.
+    var buffer = ByteBuffer()
+    buffer.writeInteger(UInt8(0))
+    buffer.writeInteger(UInt32(0))
+    try self.channel.writeInbound(HTTP2Frame.FramePayload.data(.init(data: .byteBuffer(buffer))))
+  }
+
+  /// Reads all outbound frames, returning the 'grpc-status' of the last one to have a status.
+  private func readStatusCode() throws -> String? {
+    var code: String?
+    while let payload = try self.channel.readOutbound(as: HTTP2Frame.FramePayload.self) {
+      if case let .headers(headers) = payload,
+        let status = headers.headers.first(name: "grpc-status") {
+        code = status
+      }
+    }
+    return code
+  }
+
+  func testInactiveStreamIsReset() throws {
+    try self.setUpChannel(streamInactivityTimeout: .milliseconds(250))
+
+    // The client opens the stream but never sends a message or closes it.
+    try self.openStream()
+
+    self.loop.advanceTime(by: .milliseconds(249))
+    XCTAssertTrue(self.channel.isActive)
+
+    self.loop.advanceTime(by: .milliseconds(1))
+    XCTAssertFalse(self.channel.isActive)
+    XCTAssertEqual(try self.readStatusCode(), "\(GRPCStatus.Code.deadlineExceeded.rawValue)")
+  }
+
+  func testActiveStreamIsNotReset() throws {
+    try self.setUpChannel(streamInactivityTimeout: .milliseconds(250))
+    try self.openStream()
+
+    // The stream stays open for longer than the timeout in total but is never inactive for that
+    // long.
+    for _ in 0 ..< 5 {
+      self.loop.advanceTime(by: .milliseconds(200))
+      XCTAssertTrue(self.channel.isActive)
+      try self.sendEmptyMessage()
+    }
+
+    // Once the client stops sending the stream is reset.
+    self.loop.advanceTime(by: .milliseconds(249))
+    XCTAssertTrue(self.channel.isActive)
+    self.loop.advanceTime(by: .milliseconds(1))
+    XCTAssertFalse(self.channel.isActive)
+  }
+
+  func testNoTimeoutByDefault() throws {
+    try self.setUpChannel(streamInactivityTimeout: .nanoseconds(.max))
+    try self.openStream()
+
+    self.loop.advanceTime(by: .hours(24))
+    XCTAssertTrue(self.channel.isActive)
+  }
+}
@@ -0,0 +1,102 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import GRPCSampleData
+import NIO
+import NIOSSL
+import XCTest
+
+class ServerTLSServerNameTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+  private var server: Server!
+  private var connection: ClientConnection!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.connection?.close().wait())
+    XCTAssertNoThrow(try self.server?.close().wait())
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func startServer(rejectingUnknownServerNames: Bool = false) throws {
+    // The default identity is valid for 'localhost'; 'example.com' has its own identity.
+    self.server = try Server.usingTLSBackedByNIOSSL(
+      on: self.group,
+      certificateChain: [SampleCertificate.server.certificate],
+      privateKey: SamplePrivateKey.server
+    )
+    .withTLS(
+      certificateChain: [SampleCertificate.exampleServer.certificate],
+      privateKey: SamplePrivateKey.exampleServer,
+      forServerName: "Example.com"
+    )
+    .withTLS(rejectingUnknownServerNames: rejectingUnknownServerNames)
+    .withServiceProviders([EchoProvider()])
+    .withLogger(self.serverLogger)
+    .bind(host: "localhost", port: 0)
+    .wait()
+  }
+
+  private func connect(serverHostnameOverride: String?) {
+    self.connection = ClientConnection.usingTLSBackedByNIOSSL(on: self.group)
+      .withTLS(trustRoots: .certificates([SampleCertificate.ca.certificate]))
+      .withTLS(serverHostnameOverride: serverHostnameOverride)
+      .withConnectionReestablishment(enabled: false)
+      .withCallStartBehavior(.fastFailure)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: self.server.channel.localAddress!.port!)
+  }
+
+  private func get() throws -> GRPCStatus {
+    let echo = Echo_EchoClient(
+      channel: self.connection,
+      defaultCallOptions: self.callOptionsWithLogger
+    )
+    return try echo.get(.with { $0.text = "foo" }).status.wait()
+  }
+
+  func testIdentityIsSelectedByServerName() throws {
+    try self.startServer()
+    // The client verifies the certificate against 'example.com': the default identity would fail.
+    self.connect(serverHostnameOverride: "example.com")
+    XCTAssertEqual(try self.get().code, .ok)
+  }
+
+  func testUnknownServerNameUsesDefaultIdentity() throws {
+    try self.startServer()
+    self.connect(serverHostnameOverride: nil)
+    XCTAssertEqual(try self.get().code, .ok)
+  }
+
+  func testUnknownServerNameIsRejected() throws {
+    try self.startServer(rejectingUnknownServerNames: true)
+    self.connect(serverHostnameOverride: nil)
+    XCTAssertEqual(try self.get().code, .unavailable)
+  }
+
+  func testKnownServerNameIsAcceptedWhenRejectingUnknownServerNames() throws {
+    try self.startServer(rejectingUnknownServerNames: true)
+    self.connect(serverHostnameOverride: "example.com")
+    XCTAssertEqual(try self.get().code, .ok)
+  }
+}
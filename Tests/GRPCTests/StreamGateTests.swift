@@ -0,0 +1,209 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+@testable import GRPC
+import NIO
+import XCTest
+
+class StreamGateTests: GRPCTestCase {
+  private var loop: EmbeddedEventLoop!
+
+  override func setUp() {
+    super.setUp()
+    self.loop = EmbeddedEventLoop()
+  }
+
+  private func acquire(
+    _ gate: StreamGate,
+    timeLimit: TimeLimit = .none,
+    priority: CallOptions.Priority = .normal
+  ) -> EventLoopFuture<Void> {
+    // Deadlines are scheduled relative to the clock, so its time needn't advance with the loop.
+    let clock = GRPCClock { .uptimeNanoseconds(0) }
+    return gate.acquire(
+      on: self.loop,
+      timeLimit: timeLimit,
+      deadline: timeLimit.makeDeadline(using: clock),
+      clock: clock,
+      priority: priority
+    )
+  }
+
+  func testPermitsAreGrantedUpToTheLimit() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 2))
+    let first = self.acquire(gate)
+    let second = self.acquire(gate)
+    let third = self.acquire(gate)
+
+    XCTAssertNoThrow(try first.wait())
+    XCTAssertNoThrow(try second.wait())
+
+    var thirdAcquired = false
+    third.whenSuccess { thirdAcquired = true }
+    XCTAssertFalse(thirdAcquired)
+
+    gate.release()
+    XCTAssertTrue(thirdAcquired)
+  }
+
+  func testWaitersAreServedInOrder() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 1))
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+
+    var acquired: [Int] = []
+    for index in 0 ..< 3 {
+      self.acquire(gate).whenSuccess { acquired.append(index) }
+    }
+
+    for _ in 0 ..< 3 {
+      gate.release()
+    }
+    XCTAssertEqual(acquired, [0, 1, 2])
+  }
+
+  func testWaitersAreServedInPriorityOrder() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 1))
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+
+    var acquired: [String] = []
+    let waiters: [(String, CallOptions.Priority)] = [
+      ("low-1", .low), ("normal-1", .normal), ("high-1", .high),
+      ("low-2", .low), ("high-2", .high), ("normal-2", .normal),
+    ]
+    for (name, priority) in waiters {
+      self.acquire(gate, priority: priority).whenSuccess { acquired.append(name) }
+    }
+
+    for _ in waiters {
+      gate.release()
+    }
+    XCTAssertEqual(acquired, ["high-1", "high-2", "normal-1", "normal-2", "low-1", "low-2"])
+  }
+
+  func testTimedOutWaiterIsSkipped() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 1))
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+
+    let high = self.acquire(gate, timeLimit: .timeout(.seconds(1)), priority: .high)
+    var lowAcquired = false
+    self.acquire(gate, priority: .low).whenSuccess { lowAcquired = true }
+
+    self.loop.advanceTime(by: .seconds(1))
+    XCTAssertThrowsError(try high.wait())
+
+    gate.release()
+    XCTAssertTrue(lowAcquired)
+  }
+
+  func testFullQueueFailsImmediately() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 1, maximumQueuedStreams: 1))
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+    _ = self.acquire(gate)
+
+    XCTAssertThrowsError(try self.acquire(gate).wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .resourceExhausted)
+    }
+  }
+
+  func testWaiterTimesOut() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 1))
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+
+    let timeLimit = TimeLimit.timeout(.seconds(1))
+    let waiter = self.acquire(gate, timeLimit: timeLimit)
+    self.loop.advanceTime(by: .seconds(1))
+
+    XCTAssertThrowsError(try waiter.wait()) { error in
+      XCTAssert(error is GRPCError.RPCTimedOut)
+    }
+
+    // The timed out waiter no longer holds a place in the queue, the next waiter gets the permit.
+    let next = self.acquire(gate)
+    gate.release()
+    XCTAssertNoThrow(try next.wait())
+  }
+
+  func testAbandonedWaiterLeavesTheQueue() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 1, maximumQueuedStreams: 1))
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+
+    let abandoned = self.loop.makePromise(of: Void.self)
+    let waiter = gate.acquire(
+      on: self.loop,
+      timeLimit: .none,
+      deadline: .distantFuture,
+      abandoned: abandoned.futureResult
+    )
+
+    abandoned.fail(GRPCError.RPCCancelledByClient())
+    XCTAssertThrowsError(try waiter.wait()) { error in
+      XCTAssert(error is GRPCError.RPCCancelledByClient)
+    }
+
+    // The abandoned waiter no longer holds a place in the queue.
+    let next = self.acquire(gate)
+    gate.release()
+    XCTAssertNoThrow(try next.wait())
+  }
+
+  func testReleasingWithNoWaitersFreesThePermit() {
+    let gate = StreamGate(limit: .init(maximumConcurrentStreams: 1, maximumQueuedStreams: 0))
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+    gate.release()
+    XCTAssertNoThrow(try self.acquire(gate).wait())
+  }
+}
+
+class ClientConcurrentStreamLimitTests: EchoTestCaseBase {
+  override func connectionBuilder() -> ClientConnection.Builder {
+    return super.connectionBuilder()
+      .withConcurrentStreamLimit(.init(maximumConcurrentStreams: 1, maximumQueuedStreams: 1))
+  }
+
+  func testQueuedRPCRunsWhenStreamCloses() throws {
+    let update = self.client.update { _ in }
+    // Only one stream may be open, 'get' waits for 'update' to finish.
+    let get = self.client.get(Echo_EchoRequest(text: "foo"))
+
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertEqual(try update.status.wait().code, .ok)
+    XCTAssertEqual(try get.response.wait().text, "Swift echo get: foo")
+  }
+
+  func testRPCFailsWhenQueueIsFull() throws {
+    let update = self.client.update { _ in }
+    let queued = self.client.get(Echo_EchoRequest(text: "foo"))
+    let rejected = self.client.get(Echo_EchoRequest(text: "bar"))
+
+    XCTAssertEqual(try rejected.status.wait().code, .resourceExhausted)
+
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertEqual(try queued.status.wait().code, .ok)
+  }
+
+  func testCancelledQueuedRPCLeavesQueue() throws {
+    let update = self.client.update { _ in }
+    let cancelled = self.client.get(Echo_EchoRequest(text: "foo"))
+    XCTAssertNoThrow(try cancelled.cancel().wait())
+    XCTAssertEqual(try cancelled.status.wait().code, .cancelled)
+
+    // The cancelled RPC no longer holds the only place in the queue.
+    let queued = self.client.get(Echo_EchoRequest(text: "bar"))
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertEqual(try update.status.wait().code, .ok)
+    XCTAssertEqual(try queued.response.wait().text, "Swift echo get: bar")
+  }
+}
@@ -0,0 +1,80 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoImplementation
+import EchoModel
+import GRPC
+import NIO
+import NIOHPACK
+import XCTest
+
+/// An `Echo_EchoProvider` whose 'Update' RPC acknowledges the "x-session" request header in its
+/// response headers before any messages are sent.
+private final class HandshakeEchoProvider: Echo_EchoProvider {
+  let interceptors: Echo_EchoServerInterceptorFactoryProtocol? = nil
+  private let echo = EchoProvider()
+
+  func get(
+    request: Echo_EchoRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Echo_EchoResponse> {
+    return self.echo.get(request: request, context: context)
+  }
+
+  func expand(
+    request: Echo_EchoRequest,
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<GRPCStatus> {
+    return self.echo.expand(request: request, context: context)
+  }
+
+  func collect(
+    context: UnaryResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return self.echo.collect(context: context)
+  }
+
+  func update(
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    let session = context.headers.first(name: "x-session") ?? ""
+    context.sendHeaders(["x-session-ack": session], promise: nil)
+    return self.echo.update(context: context)
+  }
+}
+
+class StreamingHeadersTests: EchoTestCaseBase {
+  override func makeEchoProvider() -> Echo_EchoProvider {
+    return HandshakeEchoProvider()
+  }
+
+  func testHeadersAreExchangedBeforeAnyMessages() throws {
+    let options = CallOptions(customMetadata: ["x-session": "abc"])
+    var responses: [String] = []
+    let update = self.client.update(callOptions: options) { response in
+      responses.append(response.text)
+    }
+
+    // No messages have been sent: the server can only respond if the request headers were
+    // flushed when the call was made.
+    let headers = try update.initialMetadata.wait()
+    XCTAssertEqual(headers.first(name: "x-session-ack"), "abc")
+
+    XCTAssertNoThrow(try update.sendMessage(.with { $0.text = "foo" }).wait())
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertEqual(try update.status.map { $0.code }.wait(), .ok)
+    XCTAssertEqual(responses, ["Swift echo update (0): foo"])
+  }
+}
@@ -0,0 +1,47 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+class StreamingResponseCompletionTests: EchoTestCaseBase {
+  func testServerStreamingCompletionSucceedsWithOkStatus() throws {
+    var responses: [String] = []
+    let expand = self.client.expand(.with { $0.text = "a b c" }) { response in
+      responses.append(response.text)
+    }
+
+    XCTAssertNoThrow(try expand.completion.wait())
+    XCTAssertEqual(responses.count, 3)
+  }
+
+  func testBidirectionalStreamingCompletionSucceedsWithOkStatus() throws {
+    let update = self.client.update { _ in }
+    XCTAssertNoThrow(try update.sendMessage(.with { $0.text = "foo" }).wait())
+    XCTAssertNoThrow(try update.sendEnd().wait())
+    XCTAssertNoThrow(try update.completion.wait())
+  }
+
+  func testCompletionFailsWithNonOkStatus() throws {
+    let update = self.client.update { _ in }
+    update.cancel(promise: nil)
+
+    XCTAssertThrowsError(try update.completion.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .cancelled)
+    }
+  }
+}
@@ -0,0 +1,146 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import Foundation
+@testable import GRPC
+import NIO
+import XCTest
+
+private final class FileProvider: CallHandlerProvider {
+  let serviceName: Substring = "File"
+  let fileIO: NonBlockingFileIO
+
+  init(fileIO: NonBlockingFileIO) {
+    self.fileIO = fileIO
+  }
+
+  private func download(
+    request: GRPCRawPayload,
+    context: StreamingResponseCallContext<GRPCRawPayload>
+  ) -> EventLoopFuture<GRPCStatus> {
+    let path = String(buffer: request.buffer)
+    return context.sendFile(atPath: path, chunkSize: 4, fileIO: self.fileIO).map {
+      .ok
+    }
+  }
+
+  private func downloadFlushingManually(
+    request: GRPCRawPayload,
+    context: StreamingResponseCallContext<GRPCRawPayload>
+  ) -> EventLoopFuture<GRPCStatus> {
+    context.flushesResponsesAutomatically = false
+    return self.download(request: request, context: context)
+  }
+
+  func handle(method name: Substring, context: CallHandlerContext) -> GRPCServerHandlerProtocol? {
+    switch name {
+    case "Download":
+      return ServerStreamingServerHandler(
+        context: context,
+        requestDeserializer: GRPCPayloadDeserializer<GRPCRawPayload>(),
+        responseSerializer: GRPCPayloadSerializer<GRPCRawPayload>(),
+        interceptors: [],
+        userFunction: self.download(request:context:)
+      )
+
+    case "DownloadFlushingManually":
+      return ServerStreamingServerHandler(
+        context: context,
+        requestDeserializer: GRPCPayloadDeserializer<GRPCRawPayload>(),
+        responseSerializer: GRPCPayloadSerializer<GRPCRawPayload>(),
+        interceptors: [],
+        userFunction: self.downloadFlushingManually(request:context:)
+      )
+
+    default:
+      return nil
+    }
+  }
+}
+
+class StreamingResponseFileTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+  private var threadPool: NIOThreadPool!
+  private var server: Server!
+  private var client: AnyServiceClient!
+  private var path: String!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+    self.threadPool = NIOThreadPool(numberOfThreads: 1)
+    self.threadPool.start()
+
+    self.server = try! Server.insecure(group: self.group)
+      .withServiceProviders([FileProvider(fileIO: NonBlockingFileIO(threadPool: self.threadPool))])
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+
+    let channel = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: self.server.channel.localAddress!.port!)
+    self.client = AnyServiceClient(channel: channel, defaultCallOptions: self.callOptionsWithLogger)
+
+    self.path = NSTemporaryDirectory() + "grpc-swift-\(UUID().uuidString)"
+  }
+
+  override func tearDown() {
+    try? FileManager.default.removeItem(atPath: self.path)
+    XCTAssertNoThrow(try self.client.channel.close().wait())
+    XCTAssertNoThrow(try self.server.close().wait())
+    XCTAssertNoThrow(try self.threadPool.syncShutdownGracefully())
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func download(
+    method: String = "Download"
+  ) -> (responses: [String], status: GRPCStatus?) {
+    var responses: [String] = []
+    let rpc = self.client.makeServerStreamingCall(
+      path: "/File/\(method)",
+      request: GRPCRawPayload(ByteBuffer(string: self.path)),
+      responseType: GRPCRawPayload.self
+    ) { response in
+      responses.append(String(buffer: response.buffer))
+    }
+
+    let status = try? rpc.status.wait()
+    return (responses, status)
+  }
+
+  func testFileIsSentInChunks() throws {
+    try "0123456789".write(toFile: self.path, atomically: true, encoding: .utf8)
+
+    let (responses, status) = self.download()
+    XCTAssertEqual(status?.code, .ok)
+    XCTAssertEqual(responses, ["0123", "4567", "89"])
+  }
+
+  func testFileIsSentWhenFlushingManually() throws {
+    try "0123456789".write(toFile: self.path, atomically: true, encoding: .utf8)
+
+    let (responses, status) = self.download(method: "DownloadFlushingManually")
+    XCTAssertEqual(status?.code, .ok)
+    XCTAssertEqual(responses, ["0123", "4567", "89"])
+  }
+
+  func testMissingFileFailsRPC() throws {
+    let (responses, status) = self.download()
+    XCTAssertNotEqual(status?.code, .ok)
+    XCTAssertEqual(responses, [])
+  }
+}
@@ -0,0 +1,115 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import GRPC
+import NIO
+import XCTest
+
+class StreamingResponseHeartbeatTests: GRPCTestCase {
+  private var eventLoop: EmbeddedEventLoop!
+  private var closePromise: EventLoopPromise<Void>!
+  private var context: StreamingResponseCallContextTestStub<String>!
+  private var isClosed = false
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = EmbeddedEventLoop()
+    self.isClosed = false
+    self.closePromise = self.eventLoop.makePromise()
+    self.context = StreamingResponseCallContextTestStub(
+      eventLoop: self.eventLoop,
+      headers: [:],
+      logger: self.logger,
+      closeFuture: self.closePromise.futureResult
+    )
+  }
+
+  private func close() {
+    self.isClosed = true
+    self.closePromise.succeed(())
+  }
+
+  override func tearDown() {
+    if !self.isClosed {
+      self.close()
+    }
+    XCTAssertNoThrow(try self.eventLoop.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  func testHeartbeatsAreSentWhileIdle() {
+    XCTAssertNoThrow(try self.context.sendHeartbeats(every: .seconds(10)) { "heartbeat" })
+
+    self.eventLoop.advanceTime(by: .seconds(9))
+    XCTAssertEqual(self.context.recordedResponses, [])
+
+    self.eventLoop.advanceTime(by: .seconds(1))
+    XCTAssertEqual(self.context.recordedResponses, ["heartbeat"])
+
+    self.eventLoop.advanceTime(by: .seconds(10))
+    XCTAssertEqual(self.context.recordedResponses, ["heartbeat", "heartbeat"])
+  }
+
+  func testHeartbeatIsSkippedAfterResponse() {
+    XCTAssertNoThrow(try self.context.sendHeartbeats(every: .seconds(10)) { "heartbeat" })
+
+    self.eventLoop.advanceTime(by: .seconds(5))
+    self.context.sendResponse("response", promise: nil)
+
+    // A response was sent during this interval.
+    self.eventLoop.advanceTime(by: .seconds(5))
+    XCTAssertEqual(self.context.recordedResponses, ["response"])
+
+    self.eventLoop.advanceTime(by: .seconds(10))
+    XCTAssertEqual(self.context.recordedResponses, ["response", "heartbeat"])
+  }
+
+  func testHeartbeatIsSentAnIntervalAfterTheLastResponse() {
+    XCTAssertNoThrow(try self.context.sendHeartbeats(every: .seconds(10)) { "heartbeat" })
+
+    self.eventLoop.advanceTime(by: .seconds(9))
+    self.context.sendResponse("response", promise: nil)
+
+    // The stream has only been idle for 9 seconds.
+    self.eventLoop.advanceTime(by: .seconds(9))
+    XCTAssertEqual(self.context.recordedResponses, ["response"])
+
+    self.eventLoop.advanceTime(by: .seconds(1))
+    XCTAssertEqual(self.context.recordedResponses, ["response", "heartbeat"])
+  }
+
+  func testInvalidIntervalThrows() {
+    XCTAssertThrowsError(try self.context.sendHeartbeats(every: .seconds(0)) { "heartbeat" }) { error in
+      XCTAssert(error is GRPCError.InvalidState)
+    }
+  }
+
+  func testHeartbeatsStopWhenCallCloses() {
+    XCTAssertNoThrow(try self.context.sendHeartbeats(every: .seconds(10)) { "heartbeat" })
+    self.close()
+
+    self.eventLoop.advanceTime(by: .seconds(30))
+    XCTAssertEqual(self.context.recordedResponses, [])
+  }
+
+  func testStopHeartbeats() {
+    XCTAssertNoThrow(try self.context.sendHeartbeats(every: .seconds(10)) { "heartbeat" })
+    self.eventLoop.advanceTime(by: .seconds(10))
+    self.context.stopHeartbeats()
+
+    self.eventLoop.advanceTime(by: .seconds(30))
+    XCTAssertEqual(self.context.recordedResponses, ["heartbeat"])
+  }
+}
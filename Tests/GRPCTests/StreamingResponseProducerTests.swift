@@ -0,0 +1,175 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+@testable import GRPC
+import NIO
+import XCTest
+
+class StreamingResponseProducerTests: GRPCTestCase {
+  private var eventLoop: EmbeddedEventLoop!
+  private var closePromise: EventLoopPromise<Void>!
+
+  /// Responses written to the context, in order.
+  private var written: [Int] = []
+  /// Promises for responses written to the context, completed by the test.
+  private var writePromises: [EventLoopPromise<Void>?] = []
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = EmbeddedEventLoop()
+    self.closePromise = self.eventLoop.makePromise()
+    self.written = []
+    self.writePromises = []
+  }
+
+  override func tearDown() {
+    // Complete anything left outstanding so that no promises are leaked.
+    self.completeWrites()
+    self.closePromise.succeed(())
+    self.eventLoop.run()
+    XCTAssertNoThrow(try self.eventLoop.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func makeContext() -> StreamingResponseCallContext<Int> {
+    return _StreamingResponseCallContext(
+      eventLoop: self.eventLoop,
+      headers: [:],
+      logger: self.logger,
+      userInfoRef: Ref(UserInfo()),
+      compressionIsEnabled: false,
+      closeFuture: self.closePromise.futureResult,
+      streamID: nil,
+      connection: nil,
+      sendHeaders: { _, promise in
+        promise?.succeed(())
+      },
+      sendResponse: { response, _, promise in
+        self.written.append(response)
+        self.writePromises.append(promise)
+      }
+    )
+  }
+
+  private func completeWrites() {
+    let promises = self.writePromises
+    self.writePromises.removeAll()
+    for promise in promises {
+      promise?.succeed(())
+    }
+  }
+
+  func testResponsesAreWrittenInOrder() throws {
+    let producer = StreamingResponseProducer(context: self.makeContext())
+
+    for response in 0 ..< 5 {
+      producer.yield(response)
+    }
+
+    self.eventLoop.run()
+    XCTAssertEqual(self.written, [0, 1, 2, 3, 4])
+  }
+
+  func testYieldAppliesBackpressure() throws {
+    let producer = StreamingResponseProducer(
+      context: self.makeContext(),
+      maximumPendingResponses: 2
+    )
+
+    let first = producer.yield(0)
+    let second = producer.yield(1)
+    self.eventLoop.run()
+
+    // One response may be pending without waiting.
+    XCTAssertNoThrow(try first.wait())
+
+    var secondReady = false
+    second.whenSuccess {
+      secondReady = true
+    }
+    XCTAssertFalse(secondReady)
+
+    // Once a write completes there's room for another response.
+    self.writePromises.removeFirst()?.succeed(())
+    XCTAssertTrue(secondReady)
+
+    self.completeWrites()
+    XCTAssertEqual(self.written, [0, 1])
+  }
+
+  func testStatusIsCompletedAfterResponsesAreWritten() throws {
+    let producer = StreamingResponseProducer(context: self.makeContext())
+
+    var writtenWhenStatusCompleted: [Int]?
+    producer.status.whenSuccess { _ in
+      writtenWhenStatusCompleted = self.written
+    }
+
+    producer.yield(0)
+    producer.yield(1)
+    producer.finish()
+    self.eventLoop.run()
+
+    XCTAssertEqual(writtenWhenStatusCompleted, [0, 1])
+    XCTAssertEqual(try producer.status.wait(), .ok)
+  }
+
+  func testFinishWithError() throws {
+    let producer = StreamingResponseProducer(context: self.makeContext())
+    producer.finish(throwing: GRPCStatus(code: .dataLoss, message: nil))
+    self.eventLoop.run()
+
+    XCTAssertThrowsError(try producer.status.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .dataLoss)
+    }
+  }
+
+  func testYieldAfterFinishFails() throws {
+    let producer = StreamingResponseProducer(context: self.makeContext())
+    producer.finish()
+    self.eventLoop.run()
+
+    XCTAssertThrowsError(try producer.yield(0).wait()) { error in
+      XCTAssert(error is GRPCError.AlreadyComplete)
+    }
+    XCTAssertEqual(self.written, [])
+  }
+
+  func testFinishFailsWaitingProducers() throws {
+    let producer = StreamingResponseProducer(
+      context: self.makeContext(),
+      maximumPendingResponses: 1
+    )
+
+    let ready = producer.yield(0)
+    producer.finish()
+    self.eventLoop.run()
+
+    XCTAssertThrowsError(try ready.wait()) { error in
+      XCTAssert(error is GRPCError.AlreadyComplete)
+    }
+  }
+
+  func testClosingTheRPCFinishesTheProducer() throws {
+    let producer = StreamingResponseProducer(context: self.makeContext())
+    self.closePromise.succeed(())
+    self.eventLoop.run()
+
+    XCTAssertThrowsError(try producer.status.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .cancelled)
+    }
+    XCTAssertThrowsError(try producer.yield(0).wait())
+  }
+}
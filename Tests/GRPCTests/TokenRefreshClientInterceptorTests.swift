@@ -0,0 +1,321 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+@testable import GRPC
+import NIO
+import NIOConcurrencyHelpers
+import NIOHPACK
+import XCTest
+
+class TokenRefreshClientInterceptorTests: GRPCTestCase {
+  private var eventLoop: EmbeddedEventLoop!
+
+  /// Promises for each token fetch, in the order they were made.
+  private var fetches: [EventLoopPromise<String>] = []
+
+  override func setUp() {
+    super.setUp()
+    self.eventLoop = EmbeddedEventLoop()
+    self.fetches = []
+  }
+
+  private func makeProvider(initialToken: String? = nil) -> AccessTokenProvider {
+    return AccessTokenProvider(initialToken: initialToken) { eventLoop in
+      let promise = eventLoop.makePromise(of: String.self)
+      self.fetches.append(promise)
+      return promise.futureResult
+    }
+  }
+
+  private final class RecordingRPC {
+    var requestParts: [GRPCClientRequestPart<String>] = []
+    var responseParts: [GRPCClientResponsePart<String>] = []
+    var errors: [Error] = []
+    var pipeline: ClientInterceptorPipeline<String, String>!
+
+    var authorization: String? {
+      guard case let .some(.metadata(headers)) = self.requestParts.first else {
+        return nil
+      }
+      return headers.first(name: "authorization")
+    }
+  }
+
+  private func startRPC(provider: AccessTokenProvider) -> RecordingRPC {
+    let rpc = RecordingRPC()
+    let details = CallDetails(
+      type: .unary,
+      path: "/foo/bar",
+      authority: "ignored",
+      scheme: "ignored",
+      options: CallOptions(logger: self.clientLogger)
+    )
+
+    rpc.pipeline = ClientInterceptorPipeline(
+      eventLoop: self.eventLoop,
+      details: details,
+      logger: details.options.logger.wrapped,
+      interceptors: [TokenRefreshClientInterceptor(tokenProvider: provider)],
+      errorDelegate: nil,
+      onError: { rpc.errors.append($0) },
+      onCancel: { _ in },
+      onRequestPart: { part, _ in rpc.requestParts.append(part) },
+      onResponsePart: { rpc.responseParts.append($0) }
+    )
+
+    rpc.pipeline.send(.metadata([:]), promise: nil)
+    rpc.pipeline.send(.message("foo", .init(compress: false, flush: false)), promise: nil)
+    rpc.pipeline.send(.end, promise: nil)
+    return rpc
+  }
+
+  func testTokenIsAddedToMetadata() {
+    let rpc = self.startRPC(provider: self.makeProvider(initialToken: "abc"))
+    XCTAssertEqual(rpc.requestParts.count, 3)
+    XCTAssertEqual(rpc.authorization, "Bearer abc")
+    XCTAssertTrue(self.fetches.isEmpty)
+  }
+
+  func testRequestPartsWaitForFirstToken() {
+    let provider = self.makeProvider()
+    let first = self.startRPC(provider: provider)
+    let second = self.startRPC(provider: provider)
+
+    // Both RPCs wait for the same fetch.
+    XCTAssertEqual(self.fetches.count, 1)
+    XCTAssertTrue(first.requestParts.isEmpty)
+    XCTAssertTrue(second.requestParts.isEmpty)
+
+    self.fetches[0].succeed("abc")
+    XCTAssertEqual(provider.token, "abc")
+    for rpc in [first, second] {
+      XCTAssertEqual(rpc.requestParts.count, 3)
+      XCTAssertEqual(rpc.authorization, "Bearer abc")
+    }
+  }
+
+  func testUnauthenticatedRefreshesTokenOnce() {
+    let provider = self.makeProvider(initialToken: "stale")
+    let rpcs = (0 ..< 3).map { _ in self.startRPC(provider: provider) }
+
+    for rpc in rpcs {
+      rpc.pipeline.receive(.end(GRPCStatus(code: .unauthenticated, message: nil), [:]))
+    }
+
+    // The failure is passed through and the refreshes are coalesced.
+    XCTAssertEqual(self.fetches.count, 1)
+    for rpc in rpcs {
+      XCTAssertEqual(rpc.responseParts.count, 1)
+    }
+
+    self.fetches[0].succeed("fresh")
+    XCTAssertEqual(provider.token, "fresh")
+
+    // The stale token was already replaced: this doesn't cause another refresh.
+    rpcs[0].pipeline.receive(.end(GRPCStatus(code: .unauthenticated, message: nil), [:]))
+    XCTAssertEqual(self.fetches.count, 1)
+
+    let retry = self.startRPC(provider: provider)
+    XCTAssertEqual(retry.authorization, "Bearer fresh")
+  }
+
+  func testOtherFailuresDoNotRefreshToken() {
+    let provider = self.makeProvider(initialToken: "abc")
+    let rpc = self.startRPC(provider: provider)
+    rpc.pipeline.receive(.end(GRPCStatus(code: .permissionDenied, message: nil), [:]))
+
+    XCTAssertTrue(self.fetches.isEmpty)
+    XCTAssertEqual(rpc.responseParts.count, 1)
+    XCTAssertEqual(provider.token, "abc")
+  }
+
+  func testFailedFetchFailsRPC() {
+    let rpc = self.startRPC(provider: self.makeProvider())
+
+    struct FetchFailed: Error {}
+    self.fetches[0].fail(FetchFailed())
+
+    XCTAssertTrue(rpc.requestParts.isEmpty)
+    XCTAssertEqual(rpc.errors.count, 1)
+    XCTAssertEqual((rpc.errors.first as? GRPCStatus)?.code, .unauthenticated)
+  }
+}
+
+class TokenRefreshRetryTests: GRPCTestCase {
+  private var group: EventLoopGroup!
+  private var server: Server!
+  private var connection: ClientConnection!
+  private var fetches: NIOAtomic<Int>!
+
+  override func setUp() {
+    super.setUp()
+    self.group = MultiThreadedEventLoopGroup(numberOfThreads: 1)
+    self.fetches = .makeAtomic(value: 0)
+
+    self.server = try! Server.insecure(group: self.group)
+      .withServiceProviders([AuthenticatingEchoProvider(validToken: "fresh")])
+      .withLogger(self.serverLogger)
+      .bind(host: "localhost", port: 0)
+      .wait()
+
+    self.connection = ClientConnection.insecure(group: self.group)
+      .withBackgroundActivityLogger(self.clientLogger)
+      .connect(host: "localhost", port: self.server.localAddress!.port!)
+  }
+
+  override func tearDown() {
+    XCTAssertNoThrow(try self.connection.close().wait())
+    XCTAssertNoThrow(try self.server.close().wait())
+    XCTAssertNoThrow(try self.group.syncShutdownGracefully())
+    super.tearDown()
+  }
+
+  private func makeClient(tokenProvider: AccessTokenProvider) -> Echo_EchoClient {
+    return Echo_EchoClient(
+      channel: self.connection,
+      defaultCallOptions: CallOptions(logger: self.clientLogger),
+      interceptors: TokenRefreshInterceptorFactory(tokenProvider: tokenProvider)
+    )
+  }
+
+  private func makeProvider(initialToken: String?, fetchedToken: String) -> AccessTokenProvider {
+    return AccessTokenProvider(initialToken: initialToken) { eventLoop in
+      self.fetches.add(1)
+      return eventLoop.makeSucceededFuture(fetchedToken)
+    }
+  }
+
+  func testUnauthenticatedRPCIsRetriedWithRefreshedToken() throws {
+    let provider = self.makeProvider(initialToken: "stale", fetchedToken: "fresh")
+    let client = self.makeClient(tokenProvider: provider)
+
+    let attempts = NIOAtomic<Int>.makeAtomic(value: 0)
+    let response = provider.retryingOnceIfUnauthenticated { () -> UnaryCall<
+      Echo_EchoRequest,
+      Echo_EchoResponse
+    > in
+      attempts.add(1)
+      return client.get(.with { $0.text = "foo" })
+    }
+
+    XCTAssertEqual(try response.wait().text, "foo")
+    XCTAssertEqual(attempts.load(), 2)
+    XCTAssertEqual(self.fetches.load(), 1)
+    XCTAssertEqual(provider.token, "fresh")
+  }
+
+  func testRPCIsRetriedAtMostOnce() throws {
+    // The refreshed token is rejected too.
+    let provider = self.makeProvider(initialToken: "stale", fetchedToken: "also-stale")
+    let client = self.makeClient(tokenProvider: provider)
+
+    let attempts = NIOAtomic<Int>.makeAtomic(value: 0)
+    let response = provider.retryingOnceIfUnauthenticated { () -> UnaryCall<
+      Echo_EchoRequest,
+      Echo_EchoResponse
+    > in
+      attempts.add(1)
+      return client.get(.with { $0.text = "foo" })
+    }
+
+    XCTAssertThrowsError(try response.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .unauthenticated)
+    }
+    XCTAssertEqual(attempts.load(), 2)
+  }
+
+  func testRPCWithValidTokenIsNotRetried() throws {
+    let provider = self.makeProvider(initialToken: "fresh", fetchedToken: "fresh")
+    let client = self.makeClient(tokenProvider: provider)
+
+    let attempts = NIOAtomic<Int>.makeAtomic(value: 0)
+    let response = provider.retryingOnceIfUnauthenticated { () -> UnaryCall<
+      Echo_EchoRequest,
+      Echo_EchoResponse
+    > in
+      attempts.add(1)
+      return client.get(.with { $0.text = "foo" })
+    }
+
+    XCTAssertEqual(try response.wait().text, "foo")
+    XCTAssertEqual(attempts.load(), 1)
+    XCTAssertEqual(self.fetches.load(), 0)
+  }
+}
+
+private final class TokenRefreshInterceptorFactory: Echo_EchoClientInterceptorFactoryProtocol {
+  private let tokenProvider: AccessTokenProvider
+
+  init(tokenProvider: AccessTokenProvider) {
+    self.tokenProvider = tokenProvider
+  }
+
+  func makeGetInterceptors() -> [ClientInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return [TokenRefreshClientInterceptor(tokenProvider: self.tokenProvider)]
+  }
+
+  func makeExpandInterceptors() -> [ClientInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return []
+  }
+
+  func makeCollectInterceptors() -> [ClientInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return []
+  }
+
+  func makeUpdateInterceptors() -> [ClientInterceptor<Echo_EchoRequest, Echo_EchoResponse>] {
+    return []
+  }
+}
+
+/// Responds to 'Get' with the text of the request if the request has the expected token,
+/// otherwise fails with status code 'unauthenticated'.
+private final class AuthenticatingEchoProvider: Echo_EchoProvider {
+  let interceptors: Echo_EchoServerInterceptorFactoryProtocol? = nil
+  private let validToken: String
+
+  init(validToken: String) {
+    self.validToken = validToken
+  }
+
+  func get(
+    request: Echo_EchoRequest,
+    context: StatusOnlyCallContext
+  ) -> EventLoopFuture<Echo_EchoResponse> {
+    guard context.headers.first(name: "authorization") == "Bearer \(self.validToken)" else {
+      return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unauthenticated, message: nil))
+    }
+    return context.eventLoop.makeSucceededFuture(.with { $0.text = request.text })
+  }
+
+  func expand(
+    request: Echo_EchoRequest,
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<GRPCStatus> {
+    return context.eventLoop.makeSucceededFuture(.init(code: .unimplemented, message: nil))
+  }
+
+  func collect(
+    context: UnaryResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+
+  func update(
+    context: StreamingResponseCallContext<Echo_EchoResponse>
+  ) -> EventLoopFuture<(StreamEvent<Echo_EchoRequest>) -> Void> {
+    return context.eventLoop.makeFailedFuture(GRPCStatus(code: .unimplemented, message: nil))
+  }
+}
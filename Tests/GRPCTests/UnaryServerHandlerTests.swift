@@ -14,6 +14,7 @@
  * limitations under the License.
  */
 @testable import GRPC
+import Logging
 import NIO
 import NIOHPACK
 import XCTest
@@ -26,6 +27,7 @@ final class ResponseRecorder: GRPCServerResponseWriter {
   var messageMetadata: [MessageMetadata] = []
   var status: GRPCStatus?
   var trailers: HPACKHeaders?
+  var flushes = 0
 
   func sendMetadata(_ metadata: HPACKHeaders, flush: Bool, promise: EventLoopPromise<Void>?) {
     XCTAssertNil(self.metadata)
@@ -50,6 +52,10 @@ final class ResponseRecorder: GRPCServerResponseWriter {
     self.trailers = trailers
     promise?.succeed(())
   }
+
+  func flush() {
+    self.flushes += 1
+  }
 }
 
 protocol ServerHandlerTestCase: GRPCTestCase {
@@ -83,13 +89,14 @@ class UnaryServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
 
   private func makeHandler(
     encoding: ServerMessageEncoding = .disabled,
+    interceptors: [ServerInterceptor<String, String>] = [],
     function: @escaping (String, StatusOnlyCallContext) -> EventLoopFuture<String>
   ) -> UnaryServerHandler<StringSerializer, StringDeserializer> {
     return UnaryServerHandler(
       context: self.makeCallHandlerContext(encoding: encoding),
       requestDeserializer: StringDeserializer(),
       responseSerializer: StringSerializer(),
-      interceptors: [],
+      interceptors: interceptors,
       userFunction: function
     )
   }
@@ -287,6 +294,83 @@ class UnaryServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     assertThat(self.recorder.status, .notNil(.hasCode(.unavailable)))
     assertThat(self.recorder.trailers, .is([:]))
   }
+
+  func testMultipleResponsesFailWithInternalError() {
+    let handler = self.makeHandler(
+      interceptors: [ResponseRepeatingInterceptor(count: 2)],
+      function: self.echo(_:context:)
+    )
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "hello"))
+    handler.receiveEnd()
+
+    assertThat(self.recorder.messages.count, .is(1))
+    assertThat(self.recorder.status, .notNil(.hasCode(.internalError)))
+  }
+
+  func testNoResponseFailsWithInternalError() {
+    let handler = self.makeHandler(
+      interceptors: [ResponseRepeatingInterceptor(count: 0)],
+      function: self.echo(_:context:)
+    )
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "hello"))
+    handler.receiveEnd()
+
+    assertThat(self.recorder.messages, .isEmpty())
+    assertThat(self.recorder.status, .notNil(.hasCode(.internalError)))
+  }
+
+  func testContextUsesDeadlineFromCallHandlerContext() {
+    // The deadline is computed once by the server, the context mustn't parse the timeout again.
+    var callHandlerContext = self.makeCallHandlerContext()
+    callHandlerContext.deadline = .uptimeNanoseconds(42)
+
+    var deadline: NIODeadline?
+    let handler = UnaryServerHandler(
+      context: callHandlerContext,
+      requestDeserializer: StringDeserializer(),
+      responseSerializer: StringSerializer(),
+      interceptors: []
+    ) { (request: String, context: StatusOnlyCallContext) -> EventLoopFuture<String> in
+      deadline = context.deadline
+      return context.eventLoop.makeSucceededFuture(request)
+    }
+
+    handler.receiveMetadata(["grpc-timeout": "1S"])
+    handler.receiveMessage(ByteBuffer(string: "hello"))
+    handler.receiveEnd()
+    handler.finish()
+
+    XCTAssertEqual(deadline, .uptimeNanoseconds(42))
+  }
+}
+
+/// Sends each response message `count` times.
+private final class ResponseRepeatingInterceptor: ServerInterceptor<String, String> {
+  private let count: Int
+
+  init(count: Int) {
+    self.count = count
+  }
+
+  override func send(
+    _ part: GRPCServerResponsePart<String>,
+    promise: EventLoopPromise<Void>?,
+    context: ServerInterceptorContext<String, String>
+  ) {
+    switch part {
+    case .message:
+      for _ in 0 ..< self.count {
+        context.send(part, promise: nil)
+      }
+      promise?.succeed(())
+    case .metadata, .end:
+      context.send(part, promise: promise)
+    }
+  }
 }
 
 // MARK: - Client Streaming
@@ -298,11 +382,14 @@ class ClientStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
 
   private func makeHandler(
     encoding: ServerMessageEncoding = .disabled,
+    maximumBufferedRequests: Int? = nil,
     observerFactory: @escaping (UnaryResponseCallContext<String>)
       -> EventLoopFuture<(StreamEvent<String>) -> Void>
   ) -> ClientStreamingServerHandler<StringSerializer, StringDeserializer> {
+    var context = self.makeCallHandlerContext(encoding: encoding)
+    context.maximumBufferedRequests = maximumBufferedRequests
     return ClientStreamingServerHandler(
-      context: self.makeCallHandlerContext(encoding: encoding),
+      context: context,
       requestDeserializer: StringDeserializer(),
       responseSerializer: StringSerializer(),
       interceptors: [],
@@ -493,6 +580,47 @@ class ClientStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
   }
 
+  func testDelayedObserverFactoryWithinBufferedRequestLimit() {
+    let promise = self.eventLoop.makePromise(of: Void.self)
+    let handler = self.makeHandler(maximumBufferedRequests: 2) { context in
+      return promise.futureResult.flatMap {
+        self.joinWithSpaces(context: context)
+      }
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "1"))
+    handler.receiveMessage(ByteBuffer(string: "2"))
+    promise.succeed(())
+    // The buffer has been emptied so more messages are fine.
+    handler.receiveMessage(ByteBuffer(string: "3"))
+    handler.receiveMessage(ByteBuffer(string: "4"))
+    handler.receiveEnd()
+
+    assertThat(self.recorder.messages.first, .is(ByteBuffer(string: "1 2 3 4")))
+    assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
+  }
+
+  func testDelayedObserverFactoryExceedingBufferedRequestLimit() {
+    let promise = self.eventLoop.makePromise(of: Void.self)
+    let handler = self.makeHandler(maximumBufferedRequests: 2) { context in
+      return promise.futureResult.flatMap {
+        self.neverReceivesMessage(context: context)
+      }
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "1"))
+    handler.receiveMessage(ByteBuffer(string: "2"))
+    handler.receiveMessage(ByteBuffer(string: "3"))
+    assertThat(self.recorder.status, .notNil(.hasCode(.resourceExhausted)))
+
+    // The observer must not see the buffered messages.
+    promise.succeed(())
+    handler.receiveEnd()
+    assertThat(self.recorder.messages, .isEmpty())
+  }
+
   func testReceiveMessageBeforeHeaders() {
     let handler = self.makeHandler(observerFactory: self.neverCalled(context:))
 
@@ -597,9 +725,11 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     let handler = self.makeHandler(userFunction: self.breakOnSpaces(_:context:))
 
     handler.receiveMetadata([:])
-    assertThat(self.recorder.metadata, .is([:]))
+    // Headers aren't sent until the user function has been invoked.
+    assertThat(self.recorder.metadata, .is(.nil()))
 
     handler.receiveMessage(ByteBuffer(string: "a b"))
+    assertThat(self.recorder.metadata, .is([:]))
     handler.receiveEnd()
     handler.finish()
 
@@ -626,6 +756,49 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     assertThat(self.recorder.messageMetadata.first?.compress, .is(true))
   }
 
+  func testResponsesAreFlushedAutomaticallyByDefault() {
+    let handler = self.makeHandler { request, context in
+      context.sendResponse(request, promise: nil)
+      context.sendResponses(["b", "c"], promise: nil)
+      return context.eventLoop.makeSucceededFuture(.ok)
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "a"))
+    assertThat(self.recorder.messageMetadata.map { $0.flush }, .is([true, false, true]))
+  }
+
+  func testFlushingResponsesManually() {
+    let handler = self.makeHandler { request, context in
+      context.flushesResponsesAutomatically = false
+      context.sendResponse(request, promise: nil)
+      context.sendResponses(["b", "c"], promise: nil)
+      context.flush()
+      return context.eventLoop.makeSucceededFuture(.ok)
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "a"))
+    assertThat(self.recorder.messageMetadata.map { $0.flush }, .is([false, false, false]))
+    assertThat(self.recorder.flushes, .is(1))
+    // The end of the response stream is always flushed by the writer.
+    assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
+  }
+
+  func testHelperResponsesAreFlushedWhenFlushingManually() {
+    let handler = self.makeHandler { request, context in
+      context.flushesResponsesAutomatically = false
+      context.sendResponse(request, promise: nil)
+      return context.sendResponseAndFlush("b").map { .ok }
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "a"))
+    assertThat(self.recorder.messageMetadata.map { $0.flush }, .is([false, false]))
+    assertThat(self.recorder.flushes, .is(1))
+    assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
+  }
+
   func testHappyPathWithCompressionEnabledButDisabledByCaller() {
     let handler = self.makeHandler(
       encoding: .enabled(.init(decompressionLimit: .absolute(.max)))
@@ -652,11 +825,13 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     )
 
     handler.receiveMetadata([:])
-    assertThat(self.recorder.metadata, .is([:]))
+    assertThat(self.recorder.metadata, .is(.nil()))
 
     let buffer = ByteBuffer(string: "hello")
     handler.receiveMessage(buffer)
 
+    // The function was never invoked so no headers were sent.
+    assertThat(self.recorder.metadata, .is(.nil()))
     assertThat(self.recorder.messages, .isEmpty())
     assertThat(self.recorder.status, .notNil(.hasCode(.internalError)))
   }
@@ -671,10 +846,11 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     )
 
     handler.receiveMetadata([:])
-    assertThat(self.recorder.metadata, .is([:]))
+    assertThat(self.recorder.metadata, .is(.nil()))
 
     let buffer = ByteBuffer(string: "1 2 3")
     handler.receiveMessage(buffer)
+    assertThat(self.recorder.metadata, .is([:]))
     handler.receiveEnd()
 
     assertThat(self.recorder.messages, .isEmpty())
@@ -687,11 +863,12 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     }
 
     handler.receiveMetadata([:])
-    assertThat(self.recorder.metadata, .is([:]))
+    assertThat(self.recorder.metadata, .is(.nil()))
 
     let buffer = ByteBuffer(string: "hello")
     handler.receiveMessage(buffer)
 
+    assertThat(self.recorder.metadata, .is([:]))
     assertThat(self.recorder.messages, .isEmpty())
     assertThat(self.recorder.status, .notNil(.hasCode(.unavailable)))
     assertThat(self.recorder.status?.message, .is(":("))
@@ -710,7 +887,7 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     let handler = self.makeHandler(userFunction: self.neverCalled(_:context:))
 
     handler.receiveMetadata([:])
-    assertThat(self.recorder.metadata, .is([:]))
+    assertThat(self.recorder.metadata, .is(.nil()))
 
     handler.receiveMetadata([:])
     assertThat(self.recorder.messages, .isEmpty())
@@ -721,10 +898,11 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     let handler = self.makeHandler(userFunction: self.neverComplete(_:context:))
 
     handler.receiveMetadata([:])
-    assertThat(self.recorder.metadata, .is([:]))
+    assertThat(self.recorder.metadata, .is(.nil()))
 
     let buffer = ByteBuffer(string: "hello")
     handler.receiveMessage(buffer)
+    assertThat(self.recorder.metadata, .is([:]))
     handler.receiveEnd()
     // Send another message before the function completes.
     handler.receiveMessage(buffer)
@@ -746,7 +924,7 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
   func testFinishAfterHeaders() {
     let handler = self.makeHandler(userFunction: self.neverCalled(_:context:))
     handler.receiveMetadata([:])
-    assertThat(self.recorder.metadata, .is([:]))
+    assertThat(self.recorder.metadata, .is(.nil()))
 
     handler.finish()
 
@@ -766,6 +944,26 @@ class ServerStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestCase {
     assertThat(self.recorder.status, .notNil(.hasCode(.unavailable)))
     assertThat(self.recorder.trailers, .is([:]))
   }
+
+  func testUserFunctionSendsHeaders() {
+    var secondHeaders: EventLoopFuture<Void>?
+    let handler = self.makeHandler { request, context in
+      context.sendHeaders(["foo": "bar"], promise: nil)
+      secondHeaders = context.sendHeaders(["foo": "baz"])
+      return self.breakOnSpaces(request, context: context)
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "a b"))
+    handler.receiveEnd()
+
+    assertThat(self.recorder.metadata, .is(["foo": "bar"]))
+    assertThat(self.recorder.messages, .hasCount(2))
+    assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
+    XCTAssertThrowsError(try secondHeaders?.wait()) { error in
+      assertThat(error, .is(.instanceOf(GRPCError.InvalidState.self)))
+    }
+  }
 }
 
 // MARK: - Bidirectional Streaming
@@ -777,11 +975,14 @@ class BidirectionalStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestC
 
   private func makeHandler(
     encoding: ServerMessageEncoding = .disabled,
+    maximumBufferedRequests: Int? = nil,
     observerFactory: @escaping (StreamingResponseCallContext<String>)
       -> EventLoopFuture<(StreamEvent<String>) -> Void>
   ) -> BidirectionalStreamingServerHandler<StringSerializer, StringDeserializer> {
+    var context = self.makeCallHandlerContext(encoding: encoding)
+    context.maximumBufferedRequests = maximumBufferedRequests
     return BidirectionalStreamingServerHandler(
-      context: self.makeCallHandlerContext(encoding: encoding),
+      context: context,
       requestDeserializer: StringDeserializer(),
       responseSerializer: StringSerializer(),
       interceptors: [],
@@ -924,6 +1125,86 @@ class BidirectionalStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestC
     assertThat(self.recorder.status, .notNil(.hasCode(.internalError)))
   }
 
+  func testEchoStream() {
+    let handler = self.makeHandler { context in
+      context.echoStream { request in
+        request.uppercased()
+      }
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "a"))
+    handler.receiveMessage(ByteBuffer(string: "b"))
+    handler.receiveEnd()
+
+    assertThat(self.recorder.messages, .is([ByteBuffer(string: "A"), ByteBuffer(string: "B")]))
+    assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
+  }
+
+  func testEchoStreamWithThrowingTransform() {
+    let handler = self.makeHandler { context in
+      context.echoStream { (request: String) -> String in
+        guard request != "b" else {
+          throw GRPCStatus(code: .invalidArgument, message: "b is not allowed")
+        }
+        return request
+      }
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "a"))
+    handler.receiveMessage(ByteBuffer(string: "b"))
+    handler.receiveMessage(ByteBuffer(string: "c"))
+
+    assertThat(self.recorder.messages, .is([ByteBuffer(string: "a")]))
+    assertThat(self.recorder.status, .notNil(.hasCode(.invalidArgument)))
+    assertThat(self.recorder.status?.message, .is("b is not allowed"))
+  }
+
+  private func makeEchoStream(
+    on context: PendingWritesCallContext
+  ) throws -> (StreamEvent<String>) -> Void {
+    return try context.echoStream { (request: String) -> String in
+      request.uppercased()
+    }.wait()
+  }
+
+  func testEchoStreamSendsEachResponseOnceThePreviousIsWritten() throws {
+    let context = PendingWritesCallContext(eventLoop: self.eventLoop, logger: self.logger)
+    let observer = try self.makeEchoStream(on: context)
+
+    observer(.message("a"))
+    observer(.message("b"))
+    observer(.end)
+    assertThat(context.recordedResponses, .is(["A"]))
+
+    context.pendingWrites[0].succeed(())
+    assertThat(context.recordedResponses, .is(["A", "B"]))
+    XCTAssertNil(context.status)
+
+    context.pendingWrites[1].succeed(())
+    XCTAssertEqual(try context.statusPromise.futureResult.wait().code, .ok)
+  }
+
+  func testEchoStreamEndsWithTheFirstWriteFailure() throws {
+    let context = PendingWritesCallContext(eventLoop: self.eventLoop, logger: self.logger)
+    let observer = try self.makeEchoStream(on: context)
+
+    observer(.message("a"))
+    observer(.message("b"))
+    context.pendingWrites[0].fail(GRPCStatus(code: .unavailable, message: "first"))
+
+    // The queued response is never sent.
+    assertThat(context.recordedResponses, .is(["A"]))
+    XCTAssertThrowsError(try context.statusPromise.futureResult.wait()) { error in
+      assertThat((error as? GRPCStatus)?.message, .is("first"))
+    }
+
+    // Later requests are ignored.
+    observer(.message("c"))
+    assertThat(context.recordedResponses, .is(["A"]))
+  }
+
   func testObserverFactoryReturnsFailedFuture() {
     let handler = self.makeHandler { context in
       context.eventLoop.makeFailedFuture(GRPCStatus(code: .unavailable, message: ":("))
@@ -982,6 +1263,47 @@ class BidirectionalStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestC
     assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
   }
 
+  func testDelayedObserverFactoryWithinBufferedRequestLimit() {
+    let promise = self.eventLoop.makePromise(of: Void.self)
+    let handler = self.makeHandler(maximumBufferedRequests: 1) { context in
+      return promise.futureResult.flatMap {
+        self.echo(context: context)
+      }
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "1"))
+    promise.succeed(())
+    handler.receiveMessage(ByteBuffer(string: "2"))
+    handler.receiveMessage(ByteBuffer(string: "3"))
+    handler.receiveEnd()
+
+    assertThat(
+      self.recorder.messages,
+      .is([ByteBuffer(string: "1"), ByteBuffer(string: "2"), ByteBuffer(string: "3")])
+    )
+    assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
+  }
+
+  func testDelayedObserverFactoryExceedingBufferedRequestLimit() {
+    let promise = self.eventLoop.makePromise(of: Void.self)
+    let handler = self.makeHandler(maximumBufferedRequests: 1) { context in
+      return promise.futureResult.flatMap {
+        self.neverReceivesMessage(context: context)
+      }
+    }
+
+    handler.receiveMetadata([:])
+    handler.receiveMessage(ByteBuffer(string: "1"))
+    handler.receiveMessage(ByteBuffer(string: "2"))
+    assertThat(self.recorder.status, .notNil(.hasCode(.resourceExhausted)))
+
+    // The observer must not see the buffered messages.
+    promise.succeed(())
+    handler.receiveEnd()
+    assertThat(self.recorder.messages, .isEmpty())
+  }
+
   func testReceiveMessageBeforeHeaders() {
     let handler = self.makeHandler(observerFactory: self.neverCalled(context:))
 
@@ -1035,4 +1357,69 @@ class BidirectionalStreamingServerHandlerTests: GRPCTestCase, ServerHandlerTestC
     assertThat(self.recorder.status, .notNil(.hasCode(.unavailable)))
     assertThat(self.recorder.trailers, .is([:]))
   }
+
+  func testObserverFactorySendsHeaders() {
+    let handler = self.makeHandler { context in
+      context.sendHeaders(["foo": "bar"], promise: nil)
+      return self.echo(context: context)
+    }
+
+    handler.receiveMetadata([:])
+    assertThat(self.recorder.metadata, .is(["foo": "bar"]))
+
+    handler.receiveMessage(ByteBuffer(string: "a"))
+    handler.receiveEnd()
+
+    assertThat(self.recorder.messages.first, .is(ByteBuffer(string: "a")))
+    assertThat(self.recorder.status, .notNil(.hasCode(.ok)))
+  }
+
+  func testSendHeadersAfterObserverFactoryReturns() {
+    var context: StreamingResponseCallContext<String>?
+    let handler = self.makeHandler { ctx in
+      context = ctx
+      return self.echo(context: ctx)
+    }
+
+    handler.receiveMetadata([:])
+    assertThat(self.recorder.metadata, .is([:]))
+
+    // Empty headers were sent on the user's behalf; sending more must fail.
+    let headers = context?.sendHeaders(["foo": "bar"])
+    XCTAssertThrowsError(try headers?.wait()) { error in
+      assertThat(error, .is(.instanceOf(GRPCError.InvalidState.self)))
+    }
+  }
+}
+
+/// A call context which records responses and holds the promises of their writes so that tests
+/// can complete them.
+private final class PendingWritesCallContext: StreamingResponseCallContextTestStub<String> {
+  var pendingWrites: [EventLoopPromise<Void>] = []
+
+  /// The status the RPC ended with, if it has ended successfully.
+  var status: GRPCStatus?
+
+  convenience init(eventLoop: EventLoop, logger: Logger) {
+    self.init(
+      eventLoop: eventLoop,
+      headers: [:],
+      logger: logger,
+      closeFuture: eventLoop.makeSucceededVoidFuture()
+    )
+    self.statusPromise.futureResult.whenSuccess { status in
+      self.status = status
+    }
+  }
+
+  override func sendResponse(
+    _ message: String,
+    compression: Compression = .deferToCallDefault,
+    promise: EventLoopPromise<Void>?
+  ) {
+    self.recordedResponses.append(message)
+    if let promise = promise {
+      self.pendingWrites.append(promise)
+    }
+  }
 }
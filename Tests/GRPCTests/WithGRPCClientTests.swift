@@ -0,0 +1,95 @@
+/*
+ * Copyright 2021, gRPC Authors All rights reserved.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+import EchoModel
+import GRPC
+import NIO
+import XCTest
+
+extension Echo_EchoClient: InterceptableGRPCClient {}
+
+class WithGRPCClientTests: EchoTestCaseBase {
+  private var transport: ClientConnection.Configuration {
+    var configuration = ClientConnection.Configuration.default(
+      target: .hostAndPort("localhost", self.port),
+      eventLoopGroup: self.clientEventLoopGroup
+    )
+    configuration.backgroundActivityLogger = self.clientLogger
+    return configuration
+  }
+
+  func testConnectionIsShutDownAfterSuccess() throws {
+    var connection: ClientConnection?
+    let response = withGRPCClient(transport: self.transport, interceptors: nil) {
+      (client: Echo_EchoClient) in
+      connection = client.channel as? ClientConnection
+      return client.get(.with { $0.text = "foo" }).response
+    }
+
+    XCTAssertEqual(try response.wait().text, "Swift echo get: foo")
+    XCTAssertEqual(connection?.connectivity.state, .shutdown)
+  }
+
+  func testConnectionIsShutDownAfterFailure() throws {
+    var connection: ClientConnection?
+    let response = withGRPCClient(transport: self.transport, interceptors: nil) {
+      (client: Echo_EchoClient) -> EventLoopFuture<Echo_EchoResponse> in
+      connection = client.channel as? ClientConnection
+      let promise = self.clientEventLoopGroup.next().makePromise(of: Echo_EchoResponse.self)
+      promise.fail(GRPCStatus(code: .aborted, message: nil))
+      return promise.futureResult
+    }
+
+    XCTAssertThrowsError(try response.wait()) { error in
+      XCTAssertEqual((error as? GRPCStatus)?.code, .aborted)
+    }
+    XCTAssertEqual(connection?.connectivity.state, .shutdown)
+  }
+
+  func testRPCsInProgressCompleteBeforeShutdown() throws {
+    var update: BidirectionalStreamingCall<Echo_EchoRequest, Echo_EchoResponse>?
+    let response = withGRPCClient(transport: self.transport, interceptors: nil) {
+      (client: Echo_EchoClient) -> EventLoopFuture<Void> in
+      let firstResponse = self.clientEventLoopGroup.next().makePromise(of: Void.self)
+      let call = client.update { _ in
+        firstResponse.succeed(())
+      }
+      update = call
+      call.sendMessage(.with { $0.text = "foo" }, promise: nil)
+
+      // The body completes while the RPC is still in progress; end it a little later.
+      return firstResponse.futureResult.map {
+        _ = call.eventLoop.scheduleTask(in: .milliseconds(100)) {
+          call.sendEnd(promise: nil)
+        }
+      }
+    }
+
+    XCTAssertNoThrow(try response.wait())
+    XCTAssertEqual(try update?.status.map { $0.code }.wait(), .ok)
+  }
+
+  func testClosingWithinBodyIsAllowed() throws {
+    var connection: ClientConnection?
+    let closed = withGRPCClient(transport: self.transport, interceptors: nil) {
+      (client: Echo_EchoClient) -> EventLoopFuture<Void> in
+      connection = client.channel as? ClientConnection
+      return client.channel.close()
+    }
+
+    XCTAssertNoThrow(try closed.wait())
+    XCTAssertEqual(connection?.connectivity.state, .shutdown)
+  }
+}
@@ -192,6 +192,8 @@ internal protocol Echo_EchoProvider: CallHandlerProvider {
 extension Echo_EchoProvider {
   internal var serviceName: Substring { return "echo.Echo" }
 
+  internal var methodNames: [Substring] { return ["Get", "Expand", "Collect", "Update"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   internal func handle(
@@ -239,6 +241,16 @@ extension Echo_EchoProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'echo.Echo' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'echo.Echo' is registered more
+  /// than once.
+  @discardableResult
+  internal func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 internal protocol Echo_EchoServerInterceptorFactoryProtocol {
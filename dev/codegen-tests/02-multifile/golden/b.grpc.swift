@@ -98,6 +98,8 @@ internal protocol B_ServiceBProvider: CallHandlerProvider {
 extension B_ServiceBProvider {
   internal var serviceName: Substring { return "b.ServiceB" }
 
+  internal var methodNames: [Substring] { return ["CallServiceB"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   internal func handle(
@@ -118,6 +120,16 @@ extension B_ServiceBProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'b.ServiceB' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'b.ServiceB' is registered more
+  /// than once.
+  @discardableResult
+  internal func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 internal protocol B_ServiceBServerInterceptorFactoryProtocol {
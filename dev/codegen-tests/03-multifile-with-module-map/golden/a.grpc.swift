@@ -99,6 +99,8 @@ internal protocol A_ServiceAProvider: CallHandlerProvider {
 extension A_ServiceAProvider {
   internal var serviceName: Substring { return "a.ServiceA" }
 
+  internal var methodNames: [Substring] { return ["CallServiceA"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   internal func handle(
@@ -119,6 +121,16 @@ extension A_ServiceAProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'a.ServiceA' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'a.ServiceA' is registered more
+  /// than once.
+  @discardableResult
+  internal func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 internal protocol A_ServiceAServerInterceptorFactoryProtocol {
@@ -98,6 +98,8 @@ internal protocol Codegentest_FooProvider: CallHandlerProvider {
 extension Codegentest_FooProvider {
   internal var serviceName: Substring { return "codegentest.Foo" }
 
+  internal var methodNames: [Substring] { return ["Bar"] }
+
   /// Determines, calls and returns the appropriate request handler, depending on the request's method.
   /// Returns nil for methods not handled by this service.
   internal func handle(
@@ -118,6 +120,16 @@ extension Codegentest_FooProvider {
       return nil
     }
   }
+
+  /// Registers the handlers for 'codegentest.Foo' on the server builder, in addition to any service
+  /// providers already registered. The server fails to start if 'codegentest.Foo' is registered more
+  /// than once.
+  @discardableResult
+  internal func registerHandlers(
+    on builder: Server.Builder
+  ) -> Server.Builder {
+    return builder.addServiceProvider(self)
+  }
 }
 
 internal protocol Codegentest_FooServerInterceptorFactoryProtocol {
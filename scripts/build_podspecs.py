@@ -137,6 +137,14 @@ class PodManager:
             dependencies=self.build_dependency_list('GRPC')
         )
 
+        grpc_testing_support_pod = Pod(
+            self.pod_name_for_grpc_target('GRPCTestingSupport'),
+            'GRPCTestingSupport',
+            self.version,
+            'Utilities for testing gRPC Swift services',
+            dependencies=self.build_dependency_list('GRPCTestingSupport')
+        )
+
         grpc_plugins_pod = Pod(
             'gRPC-Swift-Plugins',
             '',
@@ -146,7 +154,7 @@ class PodManager:
             is_plugins_pod=True
         )
 
-        return [cgrpczlib_pod, grpc_pod, grpc_plugins_pod]
+        return [cgrpczlib_pod, grpc_pod, grpc_testing_support_pod, grpc_plugins_pod]
 
     def go(self, start_from):
         pods = self.build_pods()
@@ -181,6 +189,7 @@ class PodManager:
         """Return the CocoaPod name for a given gRPC Swift target."""
         return {
           'GRPC': 'gRPC-Swift',
+          'GRPCTestingSupport': 'gRPC-Swift-TestingSupport',
           'CGRPCZlib': 'CGRPCZlib'
         }[name]
 